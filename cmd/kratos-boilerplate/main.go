@@ -1,17 +1,22 @@
 package main
 
 import (
+	"context"
 	"flag"
 	"os"
 
+	"kratos-boilerplate/internal/biz"
 	"kratos-boilerplate/internal/conf"
+	"kratos-boilerplate/internal/data"
 	configValidator "kratos-boilerplate/internal/pkg/config"
+	pkglog "kratos-boilerplate/internal/pkg/log"
+	"kratos-boilerplate/internal/pkg/plugin"
+	"kratos-boilerplate/internal/pkg/sensitive"
 
 	"github.com/go-kratos/kratos/v2"
 	"github.com/go-kratos/kratos/v2/config"
 	"github.com/go-kratos/kratos/v2/config/file"
 	"github.com/go-kratos/kratos/v2/log"
-	"github.com/go-kratos/kratos/v2/middleware/tracing"
 	"github.com/go-kratos/kratos/v2/transport/grpc"
 	"github.com/go-kratos/kratos/v2/transport/http"
 	"github.com/go-kratos/swagger-api/openapiv2"
@@ -35,7 +40,7 @@ func init() {
 	flag.StringVar(&flagconf, "conf", "../../configs", "config path, eg: -conf config.yaml")
 }
 
-func newApp(logger log.Logger, gs *grpc.Server, hs *http.Server) *kratos.App {
+func newApp(logger log.Logger, gs *grpc.Server, hs *http.Server, pm plugin.PluginManager, events plugin.EventBus, outbox *data.OutboxRelay, checkpointer *biz.AuditCheckpointer, archiver *biz.OperationLogArchiver) *kratos.App {
 	openAPIHandler := openapiv2.NewHandler()
 	hs.HandlePrefix("/q/", openAPIHandler)
 
@@ -48,21 +53,38 @@ func newApp(logger log.Logger, gs *grpc.Server, hs *http.Server) *kratos.App {
 		kratos.Server(
 			gs,
 			hs,
+			outbox,
+			checkpointer,
+			archiver,
 		),
+		// 插件事件处理失败不影响服务启停
+		kratos.AfterStart(func(ctx context.Context) error {
+			_ = events.PublishAsync(ctx, plugin.NewEvent(plugin.EventSystemStartup, Name, map[string]interface{}{
+				"version": Version,
+				"plugins": len(pm.ListPlugins()),
+			}))
+			return nil
+		}),
+		kratos.BeforeStop(func(ctx context.Context) error {
+			_ = events.Publish(ctx, plugin.NewEvent(plugin.EventSystemShutdown, Name, map[string]interface{}{
+				"version": Version,
+			}))
+			return nil
+		}),
 	)
 }
 
 func main() {
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "verify-audit":
+			os.Exit(verifyAudit(os.Args[2:]))
+		case "restore-audit":
+			os.Exit(restoreAudit(os.Args[2:]))
+		}
+	}
+
 	flag.Parse()
-	logger := log.With(log.NewStdLogger(os.Stdout),
-		"ts", log.DefaultTimestamp,
-		"caller", log.DefaultCaller,
-		"service.id", id,
-		"service.name", Name,
-		"service.version", Version,
-		"trace.id", tracing.TraceID(),
-		"span.id", tracing.SpanID(),
-	)
 	c := config.New(
 		config.WithSource(
 			file.NewSource(flagconf),
@@ -79,13 +101,39 @@ func main() {
 		panic(err)
 	}
 
+	// 日志由 conf.Log 驱动，zap 在编码前脱敏，所有经由 log.Helper 与全局 log 写入的日志都先脱敏
+	sinks, closeLogs, err := pkglog.NewSinks(bc.GetLog())
+	if err != nil {
+		panic(err)
+	}
+	defer closeLogs()
+	serviceFields := []interface{}{
+		"service.id", id,
+		"service.name", Name,
+		"service.version", Version,
+	}
+	logger := pkglog.WithContextFields(log.With(sinks.App, append([]interface{}{"caller", log.DefaultCaller}, serviceFields...)...))
+	log.SetLogger(logger)
+	accessLogger := pkglog.WithContextFields(log.With(sinks.Access, serviceFields...))
+	auditLogger := log.With(sinks.Audit, serviceFields...)
+	if err := sinks.Levels.WatchConfig(c); err != nil {
+		log.Warnf("log section is not watched, module log levels can only be changed through the admin API: %v", err)
+	}
+
 	// Validate configuration
 	validator := configValidator.NewConfigValidator(&bc)
 	if err := validator.Validate(); err != nil {
 		log.Fatalf("Configuration validation failed: %v", err)
 	}
 
-	app, cleanup, err := wireApp(bc.Server, bc.Data, bc.Auth, &bc, logger)
+	// 加载自定义敏感信息检测器，之后的日志脱敏即使用
+	if path := bc.GetLog().GetSensitiveDetectors(); path != "" {
+		if err := sensitive.DefaultDetectorRegistry().LoadFile(path); err != nil {
+			log.Fatalf("Failed to load sensitive detectors: %v", err)
+		}
+	}
+
+	app, cleanup, err := wireApp(bc.Server, bc.Data, bc.Auth, &bc, logger, accessLogger, auditLogger, sinks.Levels)
 	if err != nil {
 		panic(err)
 	}
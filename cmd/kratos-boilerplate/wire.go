@@ -9,6 +9,7 @@ import (
 	"kratos-boilerplate/internal/biz"
 	"kratos-boilerplate/internal/conf"
 	"kratos-boilerplate/internal/data"
+	pkglog "kratos-boilerplate/internal/pkg/log"
 	"kratos-boilerplate/internal/server"
 	"kratos-boilerplate/internal/service"
 
@@ -18,7 +19,7 @@ import (
 )
 
 // wireApp init kratos application.
-func wireApp(*conf.Server, *conf.Data, *conf.Auth, *conf.Bootstrap, log.Logger) (*kratos.App, func(), error) {
+func wireApp(*conf.Server, *conf.Data, *conf.Auth, *conf.Bootstrap, log.Logger, pkglog.AccessLogger, pkglog.AuditLogger, *pkglog.LevelRegistry) (*kratos.App, func(), error) {
 	wire.Build(server.ProviderSet, data.ProviderSet, biz.ProviderSet, service.ProviderSet, newApp)
 	return nil, nil, nil
 }
@@ -0,0 +1,164 @@
+// plugin-sign 生成插件发布者密钥并为插件制品签名
+//
+//	plugin-sign keygen -publisher acme -out ./keys
+//	plugin-sign sign -key ./keys/acme.key -publisher acme -name audit_logger -version 1.0.0 -permissions hooks,events [-schema settings.json] ./bin/plugins/audit_logger
+//	plugin-sign verify -trust-store ./configs/plugin-trust.yaml ./bin/plugins/audit_logger
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"kratos-boilerplate/internal/pkg/crypto"
+	"kratos-boilerplate/internal/pkg/plugin"
+)
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+		os.Exit(2)
+	}
+
+	var err error
+	switch os.Args[1] {
+	case "keygen":
+		err = keygen(os.Args[2:])
+	case "sign":
+		err = sign(os.Args[2:])
+	case "verify":
+		err = verify(os.Args[2:])
+	default:
+		usage()
+		os.Exit(2)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "Usage: plugin-sign <keygen|sign|verify> [flags]")
+}
+
+// keygen 生成发布者密钥对，私钥写入文件，打印信任库条目
+func keygen(args []string) error {
+	fs := flag.NewFlagSet("keygen", flag.ExitOnError)
+	publisher := fs.String("publisher", "", "Publisher name")
+	out := fs.String("out", ".", "Directory to write the private key to")
+	fs.Parse(args)
+
+	if *publisher == "" {
+		return fmt.Errorf("-publisher is required")
+	}
+
+	pub, priv, err := crypto.GenerateSigningKey()
+	if err != nil {
+		return err
+	}
+	keyPath := filepath.Join(*out, *publisher+".key")
+	if err := os.WriteFile(keyPath, []byte(priv+"\n"), 0600); err != nil {
+		return err
+	}
+
+	fmt.Printf("Private key written to %s, keep it secret.\n", keyPath)
+	fmt.Println("Add the publisher to the trust store:")
+	fmt.Printf("  - name: %s\n    public_key: %q\n", *publisher, pub)
+	return nil
+}
+
+// sign 为插件制品生成清单与分离签名
+func sign(args []string) error {
+	fs := flag.NewFlagSet("sign", flag.ExitOnError)
+	keyPath := fs.String("key", "", "Path to the publisher private key")
+	publisher := fs.String("publisher", "", "Publisher name in the trust store")
+	name := fs.String("name", "", "Plugin name reported by the plugin")
+	version := fs.String("version", "", "Plugin version reported by the plugin")
+	permissions := fs.String("permissions", "", "Comma separated permissions required by the plugin")
+	schemaPath := fs.String("schema", "", "Optional JSON Schema file for the plugin settings")
+	fs.Parse(args)
+
+	if fs.NArg() != 1 {
+		return fmt.Errorf("exactly one plugin artifact is required")
+	}
+	if *keyPath == "" {
+		return fmt.Errorf("-key is required")
+	}
+
+	raw, err := os.ReadFile(*keyPath)
+	if err != nil {
+		return err
+	}
+	key, err := crypto.DecodePrivateKey(string(raw))
+	if err != nil {
+		return err
+	}
+
+	artifact := fs.Arg(0)
+	manifest := plugin.PluginManifest{
+		Name:        *name,
+		Version:     *version,
+		Permissions: splitList(*permissions),
+		Publisher:   *publisher,
+	}
+	if *schemaPath != "" {
+		schema, err := os.ReadFile(*schemaPath)
+		if err != nil {
+			return err
+		}
+		if _, err := plugin.CompileSettingsSchema(schema); err != nil {
+			return fmt.Errorf("invalid settings schema: %w", err)
+		}
+		manifest.SettingsSchema = schema
+	}
+	if err := plugin.SignArtifact(artifact, manifest, key); err != nil {
+		return err
+	}
+
+	fmt.Printf("Signed %s: %s, %s\n", artifact, plugin.ManifestPath(artifact), plugin.SignaturePath(artifact))
+	return nil
+}
+
+// verify 使用信任库验证插件制品
+func verify(args []string) error {
+	fs := flag.NewFlagSet("verify", flag.ExitOnError)
+	trustStore := fs.String("trust-store", "./configs/plugin-trust.yaml", "Path to the trust store")
+	fs.Parse(args)
+
+	if fs.NArg() == 0 {
+		return fmt.Errorf("at least one plugin artifact is required")
+	}
+
+	ts, err := plugin.LoadTrustStore(*trustStore)
+	if err != nil {
+		return err
+	}
+
+	failed := false
+	for _, artifact := range fs.Args() {
+		manifest, err := ts.Verify(artifact)
+		if err != nil {
+			fmt.Printf("FAIL %s: %v\n", artifact, err)
+			failed = true
+			continue
+		}
+		fmt.Printf("OK   %s: %s %s by %s, permissions %v\n", artifact, manifest.Name, manifest.Version, manifest.Publisher, manifest.Permissions)
+	}
+	if failed {
+		return fmt.Errorf("verification failed")
+	}
+	return nil
+}
+
+func splitList(s string) []string {
+	var items []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
@@ -0,0 +1,402 @@
+package biz
+
+import (
+	"context"
+	"crypto/ed25519"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"kratos-boilerplate/internal/conf"
+	"kratos-boilerplate/internal/pkg/audit"
+	"kratos-boilerplate/internal/pkg/crypto"
+
+	"github.com/go-kratos/kratos/v2/log"
+)
+
+const (
+	// defaultAuditCheckpointInterval 默认检查点间隔
+	defaultAuditCheckpointInterval = time.Hour
+	// auditCheckpointTimeout 生成一个检查点的超时时间
+	auditCheckpointTimeout = 30 * time.Second
+	// auditVerifyBatch 校验时每次读取的记录数
+	auditVerifyBatch = 1000
+	// defaultAuditArchiveInterval 默认检查过期分区的间隔
+	defaultAuditArchiveInterval = 24 * time.Hour
+)
+
+var ErrAuditKeyMissing = errors.New("audit checkpoint public key is not configured")
+
+// AuditConfig 操作日志防篡改配置
+type AuditConfig struct {
+	HashAlgorithm      string
+	CheckpointInterval time.Duration
+	// CheckpointKey 检查点签名私钥，为空时不生成检查点
+	CheckpointKey ed25519.PrivateKey
+	// VerifyKey 校验检查点签名的公钥
+	VerifyKey ed25519.PublicKey
+	// RetentionMonths 操作日志在线保留的月数（含当月），为 0 时不归档
+	RetentionMonths int
+	// ArchiveDirectory 过期分区的归档目录
+	ArchiveDirectory string
+	// ArchiveInterval 检查过期分区与预建分区的间隔
+	ArchiveInterval time.Duration
+}
+
+// NewAuditConfig 解析操作日志防篡改配置
+func NewAuditConfig(c *conf.Bootstrap) (*AuditConfig, error) {
+	ac := c.GetAudit()
+	config := &AuditConfig{
+		HashAlgorithm:      ac.GetHashAlgorithm(),
+		CheckpointInterval: ac.GetCheckpointInterval().AsDuration(),
+	}
+	if config.HashAlgorithm == "" {
+		config.HashAlgorithm = audit.AlgorithmSHA256
+	}
+	if config.CheckpointInterval <= 0 {
+		config.CheckpointInterval = defaultAuditCheckpointInterval
+	}
+	if rc := ac.GetRetention(); rc != nil {
+		config.RetentionMonths = int(rc.GetMonths())
+		config.ArchiveDirectory = rc.GetArchiveDirectory()
+		config.ArchiveInterval = rc.GetCheckInterval().AsDuration()
+	}
+	if config.RetentionMonths < 0 {
+		return nil, fmt.Errorf("audit retention months must not be negative: %d", config.RetentionMonths)
+	}
+	if config.RetentionMonths > 0 && config.ArchiveDirectory == "" {
+		return nil, errors.New("audit retention requires an archive directory")
+	}
+	if config.ArchiveInterval <= 0 {
+		config.ArchiveInterval = defaultAuditArchiveInterval
+	}
+	if key := ac.GetCheckpointKey(); key != "" {
+		priv, err := crypto.DecodePrivateKey(key)
+		if err != nil {
+			return nil, fmt.Errorf("audit checkpoint key: %w", err)
+		}
+		config.CheckpointKey = priv
+		config.VerifyKey = priv.Public().(ed25519.PublicKey)
+	}
+	if key := ac.GetCheckpointPublicKey(); key != "" {
+		pub, err := crypto.DecodePublicKey(key)
+		if err != nil {
+			return nil, fmt.Errorf("audit checkpoint public key: %w", err)
+		}
+		config.VerifyKey = pub
+	}
+	return config, nil
+}
+
+// NewAuditChain 按配置的算法创建哈希链
+func NewAuditChain(config *AuditConfig) (*audit.Chain, error) {
+	return audit.NewChain(config.HashAlgorithm)
+}
+
+// AuditChainRepo 操作日志哈希链仓储接口，只读取已入链的记录
+type AuditChainRepo interface {
+	// ChainHead 返回链上最后一条记录，链为空时返回 nil
+	ChainHead(ctx context.Context) (*OperationLog, error)
+	// ChainRange 返回操作时间在 [start, end) 内的记录的最小与最大序号，没有记录时均为 0
+	ChainRange(ctx context.Context, start, end time.Time) (minSeq, maxSeq int64, err error)
+	// ListChain 按序号升序返回序号在 [fromSeq, toSeq] 内的记录，最多 limit 条
+	ListChain(ctx context.Context, fromSeq, toSeq int64, limit int) ([]*OperationLog, error)
+	// SaveCheckpoint 保存检查点
+	SaveCheckpoint(ctx context.Context, cp *audit.Checkpoint) error
+	// LatestCheckpoint 返回最新的检查点，没有时返回 nil
+	LatestCheckpoint(ctx context.Context) (*audit.Checkpoint, error)
+	// ListCheckpoints 按序号升序返回序号在 [fromSeq, toSeq] 内的检查点
+	ListCheckpoints(ctx context.Context, fromSeq, toSeq int64) ([]*audit.Checkpoint, error)
+	// NextCheckpoint 返回序号不小于 seq 的第一个检查点，没有时返回 nil
+	NextCheckpoint(ctx context.Context, seq int64) (*audit.Checkpoint, error)
+	// ArchivedRanges 按最小序号升序返回与 [fromSeq, toSeq] 相交的已归档分区的序号范围
+	ArchivedRanges(ctx context.Context, fromSeq, toSeq int64) ([]AuditSeqRange, error)
+}
+
+// AuditSeqRange 一个已归档分区登记的哈希链序号范围
+type AuditSeqRange struct {
+	MinSeq int64
+	MaxSeq int64
+}
+
+// 校验发现的问题类型
+const (
+	AuditProblemGap                 = "gap"                  // 序号不连续，记录被删除
+	AuditProblemDuplicate           = "duplicate"            // 序号重复，记录被插入或重放
+	AuditProblemBrokenLink          = "broken_link"          // 前驱哈希与前一条记录不符
+	AuditProblemHashMismatch        = "hash_mismatch"        // 记录内容与哈希不符，记录被修改
+	AuditProblemCheckpointSignature = "checkpoint_signature" // 检查点签名无效
+	AuditProblemCheckpointMismatch  = "checkpoint_mismatch"  // 检查点与链上记录不符
+)
+
+// AuditProblem 校验发现的第一个问题
+type AuditProblem struct {
+	Kind   string
+	Seq    int64
+	Detail string
+}
+
+// AuditReport 哈希链校验结果
+type AuditReport struct {
+	FromSeq     int64
+	ToSeq       int64
+	Records     int
+	Checkpoints int
+	// Archived 因所在分区已归档而跳过的记录数
+	Archived int
+	// Problem 第一个问题，为空时链完整
+	Problem *AuditProblem
+}
+
+// AuditUsecase 操作日志哈希链的检查点与校验
+type AuditUsecase struct {
+	repo   AuditChainRepo
+	config *AuditConfig
+	log    *log.Helper
+	now    func() time.Time
+}
+
+// NewAuditUsecase 创建操作日志审计用例
+func NewAuditUsecase(repo AuditChainRepo, config *AuditConfig, logger log.Logger) *AuditUsecase {
+	return &AuditUsecase{
+		repo:   repo,
+		config: config,
+		log:    log.NewHelper(log.With(logger, "module", "biz.audit")),
+		now:    time.Now,
+	}
+}
+
+// Checkpoint 签名当前链头并保存，链为空或链头已有检查点时返回 nil
+func (uc *AuditUsecase) Checkpoint(ctx context.Context) (*audit.Checkpoint, error) {
+	if uc.config.CheckpointKey == nil {
+		return nil, nil
+	}
+	head, err := uc.repo.ChainHead(ctx)
+	if err != nil || head == nil {
+		return nil, err
+	}
+	latest, err := uc.repo.LatestCheckpoint(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if latest != nil && latest.Seq >= head.Seq {
+		return nil, nil
+	}
+
+	cp := &audit.Checkpoint{Seq: head.Seq, Hash: head.Hash, CreatedAt: uc.now().UTC().Truncate(time.Microsecond)}
+	cp.Sign(uc.config.CheckpointKey)
+	if err := uc.repo.SaveCheckpoint(ctx, cp); err != nil {
+		return nil, err
+	}
+	return cp, nil
+}
+
+// Verify 校验操作时间在 [start, end) 内的记录所在的链段，遇到第一个问题即停止。
+// 链段向前包含前一条记录以校验首条链接，向后延伸到下一个检查点，以发现链尾被截断。
+// 序号落在某个已归档分区登记的序号范围内的缺失记录视为已归档，缺口后的首条记录不校验与前驱的链接。
+// 分区按操作时间划分，而序号在写入时分配，未归档分区中的记录序号落在归档范围之外时，缺失仍视为删除。
+// 分区表无法对 seq 建全局唯一索引，同一序号出现多次时报告 duplicate。
+func (uc *AuditUsecase) Verify(ctx context.Context, start, end time.Time) (*AuditReport, error) {
+	if uc.config.VerifyKey == nil {
+		return nil, ErrAuditKeyMissing
+	}
+	minSeq, maxSeq, err := uc.repo.ChainRange(ctx, start, end)
+	if err != nil {
+		return nil, err
+	}
+	report := &AuditReport{FromSeq: minSeq, ToSeq: maxSeq}
+	if maxSeq == 0 {
+		return report, nil
+	}
+	if minSeq > 1 {
+		report.FromSeq = minSeq - 1
+	}
+	next, err := uc.repo.NextCheckpoint(ctx, maxSeq)
+	if err != nil {
+		return nil, err
+	}
+	if next != nil {
+		report.ToSeq = next.Seq
+	}
+
+	cps, err := uc.repo.ListCheckpoints(ctx, report.FromSeq, report.ToSeq)
+	if err != nil {
+		return nil, err
+	}
+	checkpoints := make(map[int64][]*audit.Checkpoint, len(cps))
+	for _, cp := range cps {
+		checkpoints[cp.Seq] = append(checkpoints[cp.Seq], cp)
+	}
+	archived, err := uc.repo.ArchivedRanges(ctx, report.FromSeq, report.ToSeq)
+	if err != nil {
+		return nil, err
+	}
+	// skipArchived 从 expected 起跳过 [expected, before) 中落在归档范围内的连续序号
+	skipArchived := func(expected, before int64) int64 {
+		for _, r := range archived {
+			if expected >= before {
+				break
+			}
+			if expected < r.MinSeq || expected > r.MaxSeq {
+				continue
+			}
+			last := min(before-1, r.MaxSeq)
+			report.Archived += int(last - expected + 1)
+			expected = last + 1
+		}
+		return expected
+	}
+
+	expected := report.FromSeq
+	var prev *OperationLog
+	for expected <= report.ToSeq {
+		// 从上一条记录的序号重新读取，分批边界上的重复序号同样能发现
+		from := expected
+		if prev != nil {
+			from = prev.Seq
+		}
+		logs, err := uc.repo.ListChain(ctx, from, report.ToSeq, auditVerifyBatch)
+		if err != nil {
+			return nil, err
+		}
+		if prev != nil && len(logs) > 0 && sameRecord(logs[0], prev) {
+			logs = logs[1:]
+		}
+		if len(logs) == 0 {
+			break
+		}
+		for _, l := range logs {
+			if skipped := skipArchived(expected, l.Seq); skipped != expected {
+				expected = skipped
+				prev = nil
+			}
+			if report.Problem = uc.verifyRecord(l, prev, expected, checkpoints[l.Seq]); report.Problem != nil {
+				return report, nil
+			}
+			report.Records++
+			report.Checkpoints += len(checkpoints[l.Seq])
+			prev = l
+			expected = l.Seq + 1
+		}
+	}
+	expected = skipArchived(expected, report.ToSeq+1)
+	if expected <= report.ToSeq {
+		report.Problem = &AuditProblem{
+			Kind:   AuditProblemGap,
+			Seq:    expected,
+			Detail: fmt.Sprintf("records %d to %d are missing", expected, report.ToSeq),
+		}
+	}
+	return report, nil
+}
+
+// verifyRecord 校验单条记录的序号、链接、哈希与该序号上的检查点
+func (uc *AuditUsecase) verifyRecord(l, prev *OperationLog, expected int64, cps []*audit.Checkpoint) *AuditProblem {
+	if prev != nil && l.Seq == prev.Seq {
+		return &AuditProblem{Kind: AuditProblemDuplicate, Seq: l.Seq, Detail: fmt.Sprintf("record %d appears more than once", l.Seq)}
+	}
+	if l.Seq != expected {
+		return &AuditProblem{Kind: AuditProblemGap, Seq: expected, Detail: fmt.Sprintf("expected record %d, found %d", expected, l.Seq)}
+	}
+	// 链段的首条记录没有可比较的前驱，仅在链首时要求前驱哈希为空
+	if prev != nil && l.PrevHash != prev.Hash {
+		return &AuditProblem{Kind: AuditProblemBrokenLink, Seq: l.Seq, Detail: fmt.Sprintf("previous hash does not match record %d", prev.Seq)}
+	}
+	if prev == nil && l.Seq == 1 && l.PrevHash != "" {
+		return &AuditProblem{Kind: AuditProblemBrokenLink, Seq: l.Seq, Detail: "first record has a previous hash"}
+	}
+	ok, err := audit.VerifyLink(l.PrevHash, l.AuditPayload(), l.Hash)
+	if err != nil {
+		return &AuditProblem{Kind: AuditProblemHashMismatch, Seq: l.Seq, Detail: err.Error()}
+	}
+	if !ok {
+		return &AuditProblem{Kind: AuditProblemHashMismatch, Seq: l.Seq, Detail: "record content does not match its hash"}
+	}
+	for _, cp := range cps {
+		if !cp.Verify(uc.config.VerifyKey) {
+			return &AuditProblem{Kind: AuditProblemCheckpointSignature, Seq: cp.Seq, Detail: fmt.Sprintf("checkpoint %d has an invalid signature", cp.ID)}
+		}
+		if cp.Hash != l.Hash {
+			return &AuditProblem{Kind: AuditProblemCheckpointMismatch, Seq: cp.Seq, Detail: fmt.Sprintf("checkpoint %d does not match the record hash", cp.ID)}
+		}
+	}
+	return nil
+}
+
+// sameRecord 判断两次读取到的是否为同一条记录
+func sameRecord(a, b *OperationLog) bool {
+	return a.Seq == b.Seq && a.ID == b.ID && a.Hash == b.Hash
+}
+
+// AuditCheckpointer 定期生成哈希链检查点，作为 kratos 服务随应用启停
+type AuditCheckpointer struct {
+	uc       *AuditUsecase
+	interval time.Duration
+	log      *log.Helper
+
+	stopOnce sync.Once
+	done     chan struct{}
+	stopped  chan struct{}
+}
+
+// NewAuditCheckpointer 创建检查点生成器
+func NewAuditCheckpointer(uc *AuditUsecase, config *AuditConfig, logger log.Logger) *AuditCheckpointer {
+	return &AuditCheckpointer{
+		uc:       uc,
+		interval: config.CheckpointInterval,
+		log:      log.NewHelper(log.With(logger, "module", "biz.audit")),
+		done:     make(chan struct{}),
+		stopped:  make(chan struct{}),
+	}
+}
+
+// Start 定期生成检查点，直到 Stop；未配置签名私钥时不生成
+func (c *AuditCheckpointer) Start(context.Context) error {
+	defer close(c.stopped)
+
+	if c.uc.config.CheckpointKey == nil {
+		c.log.Warn("audit checkpoint key is not configured, checkpoints are disabled")
+		<-c.done
+		return nil
+	}
+
+	ticker := time.NewTicker(c.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-c.done:
+			// 停止前为最后写入的记录生成检查点
+			c.checkpoint()
+			return nil
+		case <-ticker.C:
+			c.checkpoint()
+		}
+	}
+}
+
+// Stop 停止生成检查点
+func (c *AuditCheckpointer) Stop(ctx context.Context) error {
+	c.stopOnce.Do(func() {
+		close(c.done)
+	})
+	select {
+	case <-c.stopped:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (c *AuditCheckpointer) checkpoint() {
+	ctx, cancel := context.WithTimeout(context.Background(), auditCheckpointTimeout)
+	defer cancel()
+	cp, err := c.uc.Checkpoint(ctx)
+	if err != nil {
+		c.log.Errorf("failed to create audit checkpoint: %v", err)
+		return
+	}
+	if cp != nil {
+		c.log.Infof("audit checkpoint created at record %d", cp.Seq)
+	}
+}
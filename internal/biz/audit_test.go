@@ -0,0 +1,265 @@
+package biz
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"os"
+	"sort"
+	"testing"
+	"time"
+
+	"kratos-boilerplate/internal/pkg/audit"
+
+	"github.com/go-kratos/kratos/v2/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// memoryAuditChainRepo 内存实现的AuditChainRepo
+type memoryAuditChainRepo struct {
+	logs        map[int64]*OperationLog
+	duplicates  []*OperationLog
+	checkpoints []*audit.Checkpoint
+	archived    []AuditSeqRange
+}
+
+func (r *memoryAuditChainRepo) sorted() []*OperationLog {
+	var logs []*OperationLog
+	for _, l := range r.logs {
+		logs = append(logs, l)
+	}
+	logs = append(logs, r.duplicates...)
+	sort.SliceStable(logs, func(i, j int) bool { return logs[i].Seq < logs[j].Seq })
+	return logs
+}
+
+func (r *memoryAuditChainRepo) ChainHead(ctx context.Context) (*OperationLog, error) {
+	logs := r.sorted()
+	if len(logs) == 0 {
+		return nil, nil
+	}
+	return logs[len(logs)-1], nil
+}
+
+func (r *memoryAuditChainRepo) ChainRange(ctx context.Context, start, end time.Time) (int64, int64, error) {
+	var minSeq, maxSeq int64
+	for _, l := range r.sorted() {
+		if l.CreatedAt.Before(start) || !l.CreatedAt.Before(end) {
+			continue
+		}
+		if minSeq == 0 {
+			minSeq = l.Seq
+		}
+		maxSeq = l.Seq
+	}
+	return minSeq, maxSeq, nil
+}
+
+func (r *memoryAuditChainRepo) ListChain(ctx context.Context, fromSeq, toSeq int64, limit int) ([]*OperationLog, error) {
+	var logs []*OperationLog
+	for _, l := range r.sorted() {
+		if l.Seq >= fromSeq && l.Seq <= toSeq && len(logs) < limit {
+			logs = append(logs, l)
+		}
+	}
+	return logs, nil
+}
+
+func (r *memoryAuditChainRepo) SaveCheckpoint(ctx context.Context, cp *audit.Checkpoint) error {
+	cp.ID = int64(len(r.checkpoints) + 1)
+	r.checkpoints = append(r.checkpoints, cp)
+	return nil
+}
+
+func (r *memoryAuditChainRepo) LatestCheckpoint(ctx context.Context) (*audit.Checkpoint, error) {
+	if len(r.checkpoints) == 0 {
+		return nil, nil
+	}
+	return r.checkpoints[len(r.checkpoints)-1], nil
+}
+
+func (r *memoryAuditChainRepo) ListCheckpoints(ctx context.Context, fromSeq, toSeq int64) ([]*audit.Checkpoint, error) {
+	var cps []*audit.Checkpoint
+	for _, cp := range r.checkpoints {
+		if cp.Seq >= fromSeq && cp.Seq <= toSeq {
+			cps = append(cps, cp)
+		}
+	}
+	return cps, nil
+}
+
+func (r *memoryAuditChainRepo) NextCheckpoint(ctx context.Context, seq int64) (*audit.Checkpoint, error) {
+	for _, cp := range r.checkpoints {
+		if cp.Seq >= seq {
+			return cp, nil
+		}
+	}
+	return nil, nil
+}
+
+func (r *memoryAuditChainRepo) ArchivedRanges(ctx context.Context, fromSeq, toSeq int64) ([]AuditSeqRange, error) {
+	var ranges []AuditSeqRange
+	for _, sr := range r.archived {
+		if sr.MinSeq <= toSeq && sr.MaxSeq >= fromSeq {
+			ranges = append(ranges, sr)
+		}
+	}
+	return ranges, nil
+}
+
+// newAuditFixture 创建 n 条链上记录，第 i 条记录的操作时间为 base + i 分钟，在第 checkpointAt 条后生成检查点
+func newAuditFixture(t *testing.T, n int, checkpointAt ...int) (*AuditUsecase, *memoryAuditChainRepo, time.Time) {
+	_, priv, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	chain, err := audit.NewChain(audit.AlgorithmSM3)
+	require.NoError(t, err)
+
+	repo := &memoryAuditChainRepo{logs: make(map[int64]*OperationLog)}
+	uc := NewAuditUsecase(repo, &AuditConfig{CheckpointKey: priv, VerifyKey: priv.Public().(ed25519.PublicKey)}, log.NewStdLogger(os.Stdout))
+	base := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+
+	prevHash := ""
+	for i := 1; i <= n; i++ {
+		l := &OperationLog{Seq: int64(i), UserID: int64(i), Operation: "GET /api/v1/x", Result: "success", CreatedAt: base.Add(time.Duration(i) * time.Minute), PrevHash: prevHash}
+		l.Hash = chain.Link(prevHash, l.AuditPayload())
+		prevHash = l.Hash
+		repo.logs[l.Seq] = l
+		for _, at := range checkpointAt {
+			if at == i {
+				_, err := uc.Checkpoint(context.Background())
+				require.NoError(t, err)
+			}
+		}
+	}
+	return uc, repo, base
+}
+
+// TestAuditUsecase_Checkpoint 测试检查点只在链头变化时生成
+// duplicateLog 复制一条记录并改写内容，模拟以相同序号插入的记录
+func duplicateLog(l *OperationLog) *OperationLog {
+	dup := *l
+	dup.ID = l.ID + 1000000
+	dup.Result = "replayed"
+	chain, _ := audit.NewChain(audit.AlgorithmSM3)
+	dup.Hash = chain.Link(dup.PrevHash, dup.AuditPayload())
+	return &dup
+}
+
+func TestAuditUsecase_Checkpoint(t *testing.T) {
+	uc, repo, _ := newAuditFixture(t, 3, 3)
+	require.Len(t, repo.checkpoints, 1)
+	assert.Equal(t, int64(3), repo.checkpoints[0].Seq)
+	assert.Equal(t, repo.logs[3].Hash, repo.checkpoints[0].Hash)
+	assert.True(t, repo.checkpoints[0].Verify(uc.config.VerifyKey))
+
+	cp, err := uc.Checkpoint(context.Background())
+	require.NoError(t, err)
+	assert.Nil(t, cp)
+
+	// 未配置私钥时不生成
+	cp, err = NewAuditUsecase(repo, &AuditConfig{}, log.NewStdLogger(os.Stdout)).Checkpoint(context.Background())
+	require.NoError(t, err)
+	assert.Nil(t, cp)
+}
+
+// TestAuditUsecase_Verify 测试完整链段与各类篡改
+func TestAuditUsecase_Verify(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name   string
+		tamper func(repo *memoryAuditChainRepo)
+		kind   string
+		seq    int64
+	}{
+		{"intact", func(*memoryAuditChainRepo) {}, "", 0},
+		{"modified", func(repo *memoryAuditChainRepo) { repo.logs[5].Result = "error" }, AuditProblemHashMismatch, 5},
+		{"deleted", func(repo *memoryAuditChainRepo) { delete(repo.logs, 5) }, AuditProblemGap, 5},
+		{"deleted_before_range", func(repo *memoryAuditChainRepo) { delete(repo.logs, 2) }, AuditProblemGap, 2},
+		{"truncated", func(repo *memoryAuditChainRepo) { delete(repo.logs, 8); delete(repo.logs, 7) }, AuditProblemGap, 7},
+		{"relinked", func(repo *memoryAuditChainRepo) {
+			// 删除记录后重算后续哈希，链接完整但与检查点不符
+			delete(repo.logs, 5)
+			chain, _ := audit.NewChain(audit.AlgorithmSM3)
+			prev := repo.logs[4].Hash
+			for seq := int64(6); seq <= 8; seq++ {
+				l := repo.logs[seq]
+				l.Seq--
+				l.PrevHash = prev
+				l.Hash = chain.Link(prev, l.AuditPayload())
+				prev = l.Hash
+				delete(repo.logs, seq)
+				repo.logs[l.Seq] = l
+			}
+		}, AuditProblemGap, 8},
+		{"duplicated", func(repo *memoryAuditChainRepo) {
+			repo.duplicates = append(repo.duplicates, duplicateLog(repo.logs[5]))
+		}, AuditProblemDuplicate, 5},
+		{"forged_checkpoint", func(repo *memoryAuditChainRepo) { repo.checkpoints[0].Hash = repo.logs[4].Hash }, AuditProblemCheckpointSignature, 8},
+		{"deleted_after_archive", func(repo *memoryAuditChainRepo) {
+			// 第 4 条及之前的记录已归档，此后的缺失仍是删除
+			repo.archived = []AuditSeqRange{{MinSeq: 1, MaxSeq: 4}}
+			delete(repo.logs, 2)
+			delete(repo.logs, 4)
+			delete(repo.logs, 6)
+		}, AuditProblemGap, 6},
+		{"deleted_between_archives", func(repo *memoryAuditChainRepo) {
+			// 缺失的记录序号小于后一个归档的最大序号，但不在任何归档范围内
+			repo.archived = []AuditSeqRange{{MinSeq: 1, MaxSeq: 2}, {MinSeq: 7, MaxSeq: 7}}
+			delete(repo.logs, 2)
+			delete(repo.logs, 4)
+		}, AuditProblemGap, 4},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			uc, repo, base := newAuditFixture(t, 8, 8)
+			tt.tamper(repo)
+
+			// 校验第 3 到第 6 条记录，链段为 2 到检查点所在的 8
+			report, err := uc.Verify(ctx, base.Add(3*time.Minute), base.Add(7*time.Minute))
+			require.NoError(t, err)
+			assert.Equal(t, int64(2), report.FromSeq)
+			assert.Equal(t, int64(8), report.ToSeq)
+			if tt.kind == "" {
+				assert.Nil(t, report.Problem)
+				assert.Equal(t, 7, report.Records)
+				assert.Equal(t, 1, report.Checkpoints)
+				return
+			}
+			require.NotNil(t, report.Problem)
+			assert.Equal(t, tt.kind, report.Problem.Kind)
+			assert.Equal(t, tt.seq, report.Problem.Seq)
+		})
+	}
+
+	// 已归档分区中的记录，包括跨月交错的记录，视为已归档
+	uc, repo, base := newAuditFixture(t, 8, 8)
+	repo.archived = []AuditSeqRange{{MinSeq: 1, MaxSeq: 2}, {MinSeq: 3, MaxSeq: 4}}
+	delete(repo.logs, 2)
+	delete(repo.logs, 4)
+	report, err := uc.Verify(ctx, base.Add(3*time.Minute), base.Add(7*time.Minute))
+	require.NoError(t, err)
+	assert.Nil(t, report.Problem)
+	assert.Equal(t, 2, report.Archived)
+	assert.Equal(t, 5, report.Records)
+
+	// 分批读取的边界上出现的重复序号
+	uc, repo, base = newAuditFixture(t, auditVerifyBatch+1)
+	repo.duplicates = append(repo.duplicates, duplicateLog(repo.logs[auditVerifyBatch]))
+	report, err = uc.Verify(ctx, base, base.Add(time.Duration(auditVerifyBatch+2)*time.Minute))
+	require.NoError(t, err)
+	require.NotNil(t, report.Problem)
+	assert.Equal(t, AuditProblemDuplicate, report.Problem.Kind)
+	assert.Equal(t, int64(auditVerifyBatch), report.Problem.Seq)
+
+	// 没有记录的时间范围
+	uc, _, base = newAuditFixture(t, 2)
+	report, err = uc.Verify(ctx, base.Add(time.Hour), base.Add(2*time.Hour))
+	require.NoError(t, err)
+	assert.Nil(t, report.Problem)
+	assert.Zero(t, report.Records)
+
+	_, err = NewAuditUsecase(&memoryAuditChainRepo{}, &AuditConfig{}, log.NewStdLogger(os.Stdout)).Verify(ctx, base, base)
+	assert.ErrorIs(t, err, ErrAuditKeyMissing)
+}
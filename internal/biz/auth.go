@@ -5,13 +5,17 @@ import (
 	"errors"
 	"fmt"
 	"math/rand"
+	"strconv"
 	"sync"
 	"time"
 
 	"github.com/go-kratos/kratos/v2/log"
 	"github.com/golang-jwt/jwt/v4"
 	"golang.org/x/crypto/bcrypt"
+	"google.golang.org/protobuf/proto"
 
+	pluginv1 "kratos-boilerplate/api/plugin/v1"
+	"kratos-boilerplate/internal/pkg/plugin"
 	"kratos-boilerplate/internal/pkg/sensitive"
 )
 
@@ -231,23 +235,54 @@ type authUsecase struct {
 	log            *log.Helper
 	// 内存缓存黑名单的访问令牌
 	tokenBlacklist sync.Map
+	// 插件钩子与事件总线，为 nil 时不触发
+	hooks  plugin.HookManager
+	events plugin.EventBus
 }
 
 // NewAuthUsecase creates a new authUsecase instance.
 func NewAuthUsecase(repo UserRepo, captchaService CaptchaService, config AuthConfig, logger log.Logger) AuthUsecase {
+	return NewAuthUsecaseWithPlugins(repo, captchaService, config, nil, nil, logger)
+}
+
+// NewAuthUsecaseWithPlugins creates an authUsecase that fires plugin auth and biz hooks and user events.
+func NewAuthUsecaseWithPlugins(repo UserRepo, captchaService CaptchaService, config AuthConfig, hooks plugin.HookManager, events plugin.EventBus, logger log.Logger) AuthUsecase {
 	if config.JWTSecretKey == "" {
 		config = DefaultAuthConfig
 	}
-	return &authUsecase{
+	uc := &authUsecase{
 		repo:           repo,
 		captchaService: captchaService,
 		config:         config,
-		log:            log.NewHelper(logger),
+		log:            log.NewHelper(log.With(logger, "module", "biz.auth")),
+		hooks:          hooks,
+		events:         events,
 	}
+	if hooks == nil {
+		return uc
+	}
+	return &hookedAuthUsecase{AuthUsecase: uc, hooks: hooks, log: uc.log}
 }
 
 // Register 用户注册
 func (uc *authUsecase) Register(ctx context.Context, username, password, email, phone string, captchaID, captchaCode string) error {
+	if err := uc.beforeAuth(ctx, "register", username); err != nil {
+		return err
+	}
+
+	if err := uc.register(ctx, username, password, email, phone, captchaID, captchaCode); err != nil {
+		uc.authFailed(ctx, "register", username, err)
+		return err
+	}
+
+	// user.register 事件由仓储与用户在同一事务中写入发件箱，提交后发布
+	uc.afterAuth(ctx, "register", &User{Username: username})
+
+	return nil
+}
+
+// register 校验验证码与注册信息并创建用户
+func (uc *authUsecase) register(ctx context.Context, username, password, email, phone string, captchaID, captchaCode string) error {
 	// 验证验证码
 	if uc.config.CaptchaEnabled {
 		if captchaID == "" || captchaCode == "" {
@@ -319,27 +354,45 @@ func (uc *authUsecase) Register(ctx context.Context, username, password, email,
 
 // Login 用户登录
 func (uc *authUsecase) Login(ctx context.Context, username, password, captchaID, captchaCode, totpCode string) (*TokenPair, error) {
+	if err := uc.beforeAuth(ctx, "login", username); err != nil {
+		return nil, err
+	}
+
+	tokenPair, user, err := uc.login(ctx, username, password, captchaID, captchaCode, totpCode)
+	if err != nil {
+		uc.authFailed(ctx, "login", username, err)
+		return nil, err
+	}
+
+	uc.afterAuth(ctx, "login", user)
+	publishEvent(ctx, uc, plugin.EventUserLogin, &pluginv1.UserLoginEvent{UserId: user.ID, Username: user.Username})
+
+	return tokenPair, nil
+}
+
+// login 校验登录凭证并签发令牌
+func (uc *authUsecase) login(ctx context.Context, username, password, captchaID, captchaCode, totpCode string) (*TokenPair, *User, error) {
 	// 检查账户是否被锁定
 	lock, err := uc.repo.GetLock(ctx, username)
 	if err != nil && err != ErrUserNotFound {
-		return nil, fmt.Errorf("查询账户锁定状态失败: %v", err)
+		return nil, nil, fmt.Errorf("查询账户锁定状态失败: %v", err)
 	}
 
 	if lock != nil && lock.LockUntil.After(time.Now()) {
-		return nil, ErrAccountLocked
+		return nil, nil, ErrAccountLocked
 	}
 
 	// 验证验证码
 	if uc.config.CaptchaEnabled {
 		if captchaID == "" || captchaCode == "" {
-			return nil, ErrCaptchaRequired
+			return nil, nil, ErrCaptchaRequired
 		}
 		valid, err := uc.captchaService.Verify(ctx, captchaID, captchaCode)
 		if err != nil {
-			return nil, err
+			return nil, nil, err
 		}
 		if !valid {
-			return nil, ErrCaptchaInvalid
+			return nil, nil, ErrCaptchaInvalid
 		}
 	}
 
@@ -348,25 +401,25 @@ func (uc *authUsecase) Login(ctx context.Context, username, password, captchaID,
 	if err != nil {
 		// 记录失败尝试
 		uc.recordFailedAttempt(ctx, username)
-		return nil, ErrUserNotFound
+		return nil, nil, ErrUserNotFound
 	}
 
 	// 验证密码
 	if err := bcryptCompareHashAndPassword([]byte(user.Password), []byte(password)); err != nil {
 		// 记录失败尝试
 		uc.recordFailedAttempt(ctx, username)
-		return nil, ErrPasswordIncorrect
+		return nil, nil, ErrPasswordIncorrect
 	}
 
 	// 如果启用了TOTP，验证TOTP码
 	if uc.config.TOTPEnabled && user.TotpSecret != "" {
 		if totpCode == "" {
-			return nil, ErrTotpRequired
+			return nil, nil, ErrTotpRequired
 		}
 		if !uc.verifyTOTP(user.TotpSecret, totpCode) {
 			// 记录失败尝试
 			uc.recordFailedAttempt(ctx, username)
-			return nil, ErrTotpCodeInvalid
+			return nil, nil, ErrTotpCodeInvalid
 		}
 	}
 
@@ -380,10 +433,10 @@ func (uc *authUsecase) Login(ctx context.Context, username, password, captchaID,
 	// 生成JWT令牌对
 	tokenPair, err := uc.generateTokens(ctx, user)
 	if err != nil {
-		return nil, fmt.Errorf("生成令牌失败: %v", err)
+		return nil, nil, fmt.Errorf("生成令牌失败: %v", err)
 	}
 
-	return tokenPair, nil
+	return tokenPair, user, nil
 }
 
 // Logout 退出登录
@@ -391,13 +444,22 @@ func (uc *authUsecase) Logout(ctx context.Context, accessToken string) error {
 	// 将访问令牌加入黑名单
 	claims, err := uc.parseAccessToken(accessToken)
 	if err != nil {
+		// 令牌无效时无法确定用户
+		uc.authFailed(ctx, "logout", "", err)
+		return err
+	}
+
+	username, _ := claims["username"].(string)
+	if err := uc.beforeAuth(ctx, "logout", username); err != nil {
 		return err
 	}
 
 	// 获取过期时间，将令牌加入黑名单直到过期
 	expFloat, ok := claims["exp"].(float64)
 	if !ok {
-		return fmt.Errorf("无效的令牌过期时间")
+		err := fmt.Errorf("无效的令牌过期时间")
+		uc.authFailed(ctx, "logout", username, err)
+		return err
 	}
 	exp := time.Unix(int64(expFloat), 0)
 	uc.tokenBlacklist.Store(accessToken, exp)
@@ -405,12 +467,15 @@ func (uc *authUsecase) Logout(ctx context.Context, accessToken string) error {
 	// 清理令牌黑名单中已过期的条目
 	uc.cleanupTokenBlacklist()
 
-	username := claims["username"].(string)
 	// 可选：使所有刷新令牌无效
 	if err := uc.repo.InvalidateAllRefreshTokens(ctx, username); err != nil {
 		uc.log.Warnf("使所有刷新令牌无效失败: %v", err)
 	}
 
+	userID, _ := claims["user_id"].(float64)
+	uc.afterAuth(ctx, "logout", &User{ID: int64(userID), Username: username})
+	publishEvent(ctx, uc, plugin.EventUserLogout, &pluginv1.UserLogoutEvent{UserId: int64(userID), Username: username})
+
 	return nil
 }
 
@@ -503,17 +568,22 @@ func (uc *authUsecase) GetLockStatus(ctx context.Context, username string) (*Acc
 }
 
 // 生成令牌对
+// 令牌同时带有 sub 与 attributes.username，操作日志等按 auth.Subject 解析令牌的组件可以识别用户
 func (uc *authUsecase) generateTokens(ctx context.Context, user *User) (*TokenPair, error) {
 	now := time.Now()
+	subject := strconv.FormatInt(user.ID, 10)
+	attributes := map[string]string{"username": user.Username}
 
 	// 生成access token
 	accessExp := now.Add(uc.config.AccessTokenExpiration)
 	accessClaims := jwt.MapClaims{
-		"user_id":  user.ID,
-		"username": user.Username,
-		"exp":      accessExp.Unix(),
-		"iat":      now.Unix(),
-		"type":     "access",
+		"sub":        subject,
+		"attributes": attributes,
+		"user_id":    user.ID,
+		"username":   user.Username,
+		"exp":        accessExp.Unix(),
+		"iat":        now.Unix(),
+		"type":       "access",
 	}
 	accessToken := jwt.NewWithClaims(jwt.SigningMethodHS256, accessClaims)
 	signedAccessToken, err := accessToken.SignedString([]byte(uc.config.JWTSecretKey))
@@ -525,12 +595,14 @@ func (uc *authUsecase) generateTokens(ctx context.Context, user *User) (*TokenPa
 	refreshExp := now.Add(uc.config.RefreshTokenExpiration)
 	tokenID := generateRandomString(32)
 	refreshClaims := jwt.MapClaims{
-		"user_id":  user.ID,
-		"username": user.Username,
-		"exp":      refreshExp.Unix(),
-		"iat":      now.Unix(),
-		"type":     "refresh",
-		"jti":      tokenID, // 令牌ID，用于标识刷新令牌
+		"sub":        subject,
+		"attributes": attributes,
+		"user_id":    user.ID,
+		"username":   user.Username,
+		"exp":        refreshExp.Unix(),
+		"iat":        now.Unix(),
+		"type":       "refresh",
+		"jti":        tokenID, // 令牌ID，用于标识刷新令牌
 	}
 	refreshToken := jwt.NewWithClaims(jwt.SigningMethodHS256, refreshClaims)
 	signedRefreshToken, err := refreshToken.SignedString([]byte(uc.config.JWTSecretKey))
@@ -651,6 +723,57 @@ func (uc *authUsecase) cleanupTokenBlacklist() {
 	})
 }
 
+// beforeAuth 执行 before_auth 钩子，钩子中止时返回 HookAbortError，其他错误只记录日志
+func (uc *authUsecase) beforeAuth(ctx context.Context, action, username string) error {
+	if uc.hooks == nil {
+		return nil
+	}
+
+	payload := &pluginv1.BeforeAuthPayload{Action: action, Username: username}
+	if err := plugin.ExecuteHooks(ctx, uc.hooks, plugin.HookPointBeforeAuth, payload); err != nil {
+		if abort, ok := plugin.AsHookAbort(err); ok {
+			return abort
+		}
+		uc.log.Warnf("执行 before_auth 钩子失败: %v", err)
+	}
+	return nil
+}
+
+// afterAuth 执行 after_auth 钩子
+func (uc *authUsecase) afterAuth(ctx context.Context, action string, user *User) {
+	if uc.hooks == nil {
+		return
+	}
+
+	payload := &pluginv1.AfterAuthPayload{Action: action, UserId: user.ID, Username: user.Username}
+	if err := plugin.ExecuteHooks(ctx, uc.hooks, plugin.HookPointAfterAuth, payload); err != nil {
+		uc.log.Warnf("执行 after_auth 钩子失败: %v", err)
+	}
+}
+
+// authFailed 执行 auth_failed 钩子
+func (uc *authUsecase) authFailed(ctx context.Context, action, username string, cause error) {
+	if uc.hooks == nil {
+		return
+	}
+
+	payload := &pluginv1.AuthFailedPayload{Action: action, Username: username, Reason: cause.Error()}
+	if err := plugin.ExecuteHooks(ctx, uc.hooks, plugin.HookPointAuthFailed, payload); err != nil {
+		uc.log.Warnf("执行 auth_failed 钩子失败: %v", err)
+	}
+}
+
+// publishEvent 异步发布用户事件，载荷类型须为事件类型注册的类型
+func publishEvent[T proto.Message](ctx context.Context, uc *authUsecase, eventType plugin.EventType, payload T) {
+	if uc.events == nil {
+		return
+	}
+
+	if err := plugin.PublishAsync(ctx, uc.events, eventType, "auth", payload); err != nil {
+		uc.log.Warnf("发布事件 %s 失败: %v", eventType, err)
+	}
+}
+
 // 验证TOTP码
 func (uc *authUsecase) verifyTOTP(secret, code string) bool {
 	// 实际项目中应该使用TOTP库实现验证
@@ -0,0 +1,93 @@
+package biz
+
+import (
+	"context"
+
+	"kratos-boilerplate/internal/pkg/plugin"
+
+	"github.com/go-kratos/kratos/v2/log"
+)
+
+// hookedAuthUsecase 在认证用例的每次调用前后执行业务逻辑钩子点 before_biz、after_biz 与 biz_error
+type hookedAuthUsecase struct {
+	AuthUsecase
+
+	hooks plugin.HookManager
+	log   *log.Helper
+}
+
+// run 执行业务逻辑钩子，钩子失败只记录日志，before_biz 中止时返回 HookAbortError
+func (uc *hookedAuthUsecase) run(ctx context.Context, operation string, fn func(ctx context.Context) error) error {
+	return plugin.RunLayerHooks(ctx, uc.hooks, plugin.BizHookPoints, operation, func(point plugin.HookPoint, err error) {
+		uc.log.Warnf("执行 %s 钩子失败: %v", point, err)
+	}, fn)
+}
+
+func (uc *hookedAuthUsecase) Register(ctx context.Context, username, password, email, phone, captchaID, captchaCode string) error {
+	return uc.run(ctx, "auth.Register", func(ctx context.Context) error {
+		return uc.AuthUsecase.Register(ctx, username, password, email, phone, captchaID, captchaCode)
+	})
+}
+
+func (uc *hookedAuthUsecase) Login(ctx context.Context, username, password, captchaID, captchaCode, totpCode string) (*TokenPair, error) {
+	var tokenPair *TokenPair
+	err := uc.run(ctx, "auth.Login", func(ctx context.Context) (err error) {
+		tokenPair, err = uc.AuthUsecase.Login(ctx, username, password, captchaID, captchaCode, totpCode)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return tokenPair, nil
+}
+
+func (uc *hookedAuthUsecase) Logout(ctx context.Context, accessToken string) error {
+	return uc.run(ctx, "auth.Logout", func(ctx context.Context) error {
+		return uc.AuthUsecase.Logout(ctx, accessToken)
+	})
+}
+
+func (uc *hookedAuthUsecase) RefreshToken(ctx context.Context, refreshToken string) (*TokenPair, error) {
+	var tokenPair *TokenPair
+	err := uc.run(ctx, "auth.RefreshToken", func(ctx context.Context) (err error) {
+		tokenPair, err = uc.AuthUsecase.RefreshToken(ctx, refreshToken)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return tokenPair, nil
+}
+
+func (uc *hookedAuthUsecase) GetCaptcha(ctx context.Context, captchaType, target string) (*Captcha, error) {
+	var captcha *Captcha
+	err := uc.run(ctx, "auth.GetCaptcha", func(ctx context.Context) (err error) {
+		captcha, err = uc.AuthUsecase.GetCaptcha(ctx, captchaType, target)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return captcha, nil
+}
+
+func (uc *hookedAuthUsecase) VerifyCaptcha(ctx context.Context, captchaID, captchaCode string) (bool, error) {
+	var valid bool
+	err := uc.run(ctx, "auth.VerifyCaptcha", func(ctx context.Context) (err error) {
+		valid, err = uc.AuthUsecase.VerifyCaptcha(ctx, captchaID, captchaCode)
+		return err
+	})
+	return valid, err
+}
+
+func (uc *hookedAuthUsecase) GetLockStatus(ctx context.Context, username string) (*AccountLock, error) {
+	var lock *AccountLock
+	err := uc.run(ctx, "auth.GetLockStatus", func(ctx context.Context) (err error) {
+		lock, err = uc.AuthUsecase.GetLockStatus(ctx, username)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return lock, nil
+}
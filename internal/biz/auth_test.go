@@ -10,6 +10,10 @@ import (
 	"github.com/golang-jwt/jwt/v4"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	pluginv1 "kratos-boilerplate/api/plugin/v1"
+	"kratos-boilerplate/internal/pkg/plugin"
 )
 
 // 模拟UserRepo
@@ -509,6 +513,167 @@ func generateTestRefreshToken(username string, userID int64, secretKey string) (
 	return token.SignedString([]byte(secretKey))
 }
 
+func TestLogin_PluginHookAbort(t *testing.T) {
+	repo := new(mockUserRepo)
+	captchaService := new(mockCaptchaService)
+	logger := log.NewStdLogger(os.Stdout)
+
+	hooks := plugin.NewHookManager()
+	require.NoError(t, hooks.RegisterHook(plugin.HookPointBeforeAuth, plugin.NewBaseHook("deny", 10, time.Second,
+		func(ctx context.Context, data plugin.HookData) error {
+			if data.GetData()["username"] == "testuser" && data.GetData()["action"] == "login" {
+				return plugin.NewHookAbortError(429, "LOGIN_THROTTLED", "too many attempts")
+			}
+			return nil
+		})))
+
+	uc := NewAuthUsecaseWithPlugins(repo, captchaService, DefaultAuthConfig, hooks, nil, logger)
+
+	tokenPair, err := uc.Login(context.Background(), "testuser", "Password123", "captcha123", "123456", "")
+
+	// 钩子中止时不访问仓储
+	assert.Nil(t, tokenPair)
+	abort, ok := plugin.AsHookAbort(err)
+	require.True(t, ok)
+	assert.Equal(t, 429, abort.Status)
+	repo.AssertNotCalled(t, "GetLock", mock.Anything, mock.Anything)
+}
+
+func TestLogin_PluginHooksAndEvents(t *testing.T) {
+	repo := new(mockUserRepo)
+	captchaService := new(mockCaptchaService)
+	logger := log.NewStdLogger(os.Stdout)
+
+	user := &User{ID: 7, Username: "testuser", Password: "hashed"}
+	repo.On("GetLock", mock.Anything, "testuser").Return(nil, ErrUserNotFound)
+	repo.On("GetUser", mock.Anything, "testuser").Return(user, nil)
+	repo.On("SaveRefreshToken", mock.Anything, "testuser", mock.AnythingOfType("string"), mock.AnythingOfType("time.Time")).Return(nil)
+	repo.On("SaveLock", mock.Anything, mock.AnythingOfType("*biz.AccountLock")).Return(nil)
+
+	hooks := plugin.NewHookManager()
+	var fired []plugin.HookPoint
+	for _, point := range []plugin.HookPoint{plugin.HookPointBeforeAuth, plugin.HookPointAfterAuth, plugin.HookPointAuthFailed} {
+		point := point
+		require.NoError(t, hooks.RegisterHook(point, plugin.NewBaseHook("record_"+string(point), 10, time.Second,
+			func(ctx context.Context, data plugin.HookData) error {
+				fired = append(fired, point)
+				return nil
+			})))
+	}
+
+	events := plugin.NewEventBus(1)
+	logins := make(chan plugin.Event, 1)
+	require.NoError(t, events.Subscribe(plugin.EventUserLogin, plugin.NewBaseEventHandler("record_login",
+		[]plugin.EventType{plugin.EventUserLogin}, time.Second,
+		func(ctx context.Context, event plugin.Event) error {
+			logins <- event
+			return nil
+		})))
+
+	config := DefaultAuthConfig
+	config.CaptchaEnabled = false
+	uc := NewAuthUsecaseWithPlugins(repo, captchaService, config, hooks, events, logger)
+
+	originalVerifyPassword := bcryptCompareHashAndPassword
+	defer func() { bcryptCompareHashAndPassword = originalVerifyPassword }()
+
+	// 密码错误触发 auth_failed
+	bcryptCompareHashAndPassword = func(hashedPassword, password []byte) error {
+		return ErrPasswordIncorrect
+	}
+	_, err := uc.Login(context.Background(), "testuser", "wrong", "", "", "")
+	assert.Equal(t, ErrPasswordIncorrect, err)
+	assert.Equal(t, []plugin.HookPoint{plugin.HookPointBeforeAuth, plugin.HookPointAuthFailed}, fired)
+
+	// 登录成功触发 after_auth 并发布 user.login 事件
+	fired = nil
+	bcryptCompareHashAndPassword = func(hashedPassword, password []byte) error {
+		return nil
+	}
+	_, err = uc.Login(context.Background(), "testuser", "Password123", "", "", "")
+	require.NoError(t, err)
+	assert.Equal(t, []plugin.HookPoint{plugin.HookPointBeforeAuth, plugin.HookPointAfterAuth}, fired)
+
+	select {
+	case event := <-logins:
+		typed, err := plugin.DecodeEvent[*pluginv1.UserLoginEvent](event)
+		require.NoError(t, err)
+		assert.Equal(t, "testuser", typed.Payload.GetUsername())
+		assert.Equal(t, int64(7), typed.Payload.GetUserId())
+		assert.Equal(t, "plugin.v1.UserLoginEvent", event.GetMetadata()[plugin.EventMetadataPayloadType])
+	case <-time.After(time.Second):
+		t.Fatal("user.login event not published")
+	}
+}
+
+func TestRegisterAndLogout_AuthFailedHook(t *testing.T) {
+	repo := new(mockUserRepo)
+	captchaService := new(mockCaptchaService)
+	repo.On("GetUser", mock.Anything, "existing").Return(&User{ID: 1, Username: "existing"}, nil)
+	captchaService.On("Verify", mock.Anything, "captcha-id", "wrong").Return(false, nil)
+	captchaService.On("Verify", mock.Anything, "captcha-id", "right").Return(true, nil)
+
+	hooks := plugin.NewHookManager()
+	var failures []map[string]interface{}
+	require.NoError(t, hooks.RegisterHook(plugin.HookPointAuthFailed, plugin.NewBaseHook("record_auth_failed", 10, time.Second,
+		func(ctx context.Context, data plugin.HookData) error {
+			failures = append(failures, data.GetData())
+			return nil
+		})))
+
+	uc := NewAuthUsecaseWithPlugins(repo, captchaService, DefaultAuthConfig, hooks, nil, log.NewStdLogger(os.Stdout))
+
+	// 验证码错误
+	err := uc.Register(context.Background(), "newuser", "Password123", "", "", "captcha-id", "wrong")
+	assert.Equal(t, ErrCaptchaInvalid, err)
+	require.Len(t, failures, 1)
+	assert.Equal(t, "register", failures[0]["action"])
+	assert.Equal(t, "newuser", failures[0]["username"])
+	assert.Equal(t, ErrCaptchaInvalid.Error(), failures[0]["reason"])
+
+	// 用户已存在
+	err = uc.Register(context.Background(), "existing", "Password123", "", "", "captcha-id", "right")
+	assert.Equal(t, ErrUserExists, err)
+	require.Len(t, failures, 2)
+	assert.Equal(t, "existing", failures[1]["username"])
+	assert.Equal(t, ErrUserExists.Error(), failures[1]["reason"])
+
+	// 无效的访问令牌
+	err = uc.Logout(context.Background(), "invalid-token")
+	require.Error(t, err)
+	require.Len(t, failures, 3)
+	assert.Equal(t, "logout", failures[2]["action"])
+	assert.Equal(t, "", failures[2]["username"])
+}
+
+func TestAuthUsecase_BizHooks(t *testing.T) {
+	repo := new(mockUserRepo)
+	captchaService := new(mockCaptchaService)
+	repo.On("GetLock", mock.Anything, "testuser").Return(nil, ErrUserNotFound)
+	repo.On("GetUser", mock.Anything, "testuser").Return(nil, ErrUserNotFound)
+	repo.On("SaveLock", mock.Anything, mock.AnythingOfType("*biz.AccountLock")).Return(nil)
+
+	hooks := plugin.NewHookManager()
+	var fired []string
+	for _, point := range []plugin.HookPoint{plugin.HookPointBeforeBiz, plugin.HookPointAfterBiz, plugin.HookPointBizError} {
+		point := point
+		require.NoError(t, hooks.RegisterHook(point, plugin.NewBaseHook("record_"+string(point), 10, time.Second,
+			func(ctx context.Context, data plugin.HookData) error {
+				fired = append(fired, string(point)+" "+data.GetData()["operation"].(string))
+				return nil
+			})))
+	}
+
+	config := DefaultAuthConfig
+	config.CaptchaEnabled = false
+	uc := NewAuthUsecaseWithPlugins(repo, captchaService, config, hooks, nil, log.NewStdLogger(os.Stdout))
+
+	_, err := uc.Login(context.Background(), "testuser", "Password123", "", "", "")
+	assert.Equal(t, ErrUserNotFound, err)
+	assert.Equal(t, []string{"before_biz auth.Login", "biz_error auth.Login"}, fired)
+	assert.Equal(t, int32(DefaultAuthConfig.MaxLoginAttempts), uc.GetMaxLoginAttempts())
+}
+
 // 表驱动TDD测试示例
 func TestAdd_TableDriven(t *testing.T) {
 	tests := []struct {
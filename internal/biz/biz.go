@@ -8,7 +8,7 @@ import (
 )
 
 // ProviderSet is biz providers.
-var ProviderSet = wire.NewSet(NewGreeterUsecase, NewAuthUsecase, NewAuthConfig)
+var ProviderSet = wire.NewSet(NewGreeterUsecase, NewAuthUsecaseWithPlugins, NewAuthConfig, NewWebhookUsecase, NewPIIUnmaskAuditor, NewOperationLogUsecase, NewOperationLogWriter, NewAuditConfig, NewAuditChain, NewAuditUsecase, NewAuditCheckpointer, NewOperationLogArchiveUsecase, NewOperationLogArchiver)
 
 // NewAuthConfig creates a new AuthConfig from conf.Auth
 func NewAuthConfig(auth *conf.Auth) AuthConfig {
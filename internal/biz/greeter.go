@@ -28,7 +28,7 @@ type GreeterUsecase struct {
 
 // NewGreeterUsecase new a Greeter usecase.
 func NewGreeterUsecase(repo GreeterRepo, logger log.Logger) *GreeterUsecase {
-	return &GreeterUsecase{repo: repo, log: log.NewHelper(logger)}
+	return &GreeterUsecase{repo: repo, log: log.NewHelper(log.With(logger, "module", "biz.greeter"))}
 }
 
 // CreateGreeter creates a Greeter, and returns the new Greeter.
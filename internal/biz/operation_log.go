@@ -2,9 +2,19 @@ package biz
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
 	"time"
+
+	"kratos-boilerplate/internal/pkg/audit"
+	"kratos-boilerplate/internal/pkg/sensitive"
 )
 
+// ErrDatabaseNotConfigured 未配置数据库，依赖数据库的后台任务遇到时停止
+var ErrDatabaseNotConfigured = errors.New("database is not configured")
+
 // OperationLog 操作日志六要素
 type OperationLog struct {
 	ID        int64     `json:"id"`
@@ -15,10 +25,148 @@ type OperationLog struct {
 	Content   string    `json:"content"`   // 操作内容
 	Result    string    `json:"result"`    // 操作结果
 	CreatedAt time.Time `json:"created_at"` // 操作时间
+	Seq       int64     `json:"seq"`        // 哈希链序号
+	PrevHash  string    `json:"prev_hash"`  // 链上前一条记录的哈希
+	Hash      string    `json:"hash"`       // 本条记录的链上哈希
+}
+
+// 操作日志字段的长度上限（字符数），与 operation_logs 表的列定义一致
+const (
+	OperationLogMaxUsername  = 255
+	OperationLogMaxOperation = 255
+	OperationLogMaxTarget    = 2048
+)
+
+// TruncateColumns 将用户名、操作类型与操作对象截断到列长度上限，避免超长的一条记录导致整批写入失败
+func (l *OperationLog) TruncateColumns() {
+	l.Username = truncateRunes(l.Username, OperationLogMaxUsername)
+	l.Operation = truncateRunes(l.Operation, OperationLogMaxOperation)
+	l.Target = truncateRunes(l.Target, OperationLogMaxTarget)
+}
+
+// truncateRunes 保留 s 的前 n 个字符
+func truncateRunes(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	count := 0
+	for i := range s {
+		if count == n {
+			return s[:i]
+		}
+		count++
+	}
+	return s
+}
+
+// AuditPayload 参与哈希链计算的规范编码，包含序号与六要素
+func (l *OperationLog) AuditPayload() []byte {
+	return audit.Encode(
+		strconv.FormatInt(l.Seq, 10),
+		strconv.FormatInt(l.UserID, 10),
+		l.Username,
+		l.Operation,
+		l.Target,
+		l.Content,
+		l.Result,
+		audit.EncodeTime(l.CreatedAt),
+	)
+}
+
+// OperationLogQuery 操作日志查询条件，零值条件不参与过滤
+type OperationLogQuery struct {
+	UserID    int64
+	Username  string
+	Operation string // 按前缀匹配，如 "POST /api/v1/admin"
+	Target    string // 按前缀匹配
+	StartTime time.Time
+	EndTime   time.Time
+	Page      int // 从 1 开始
+	PageSize  int
 }
 
 // OperationLogRepo 操作日志仓储接口
 type OperationLogRepo interface {
 	CreateLog(ctx context.Context, log *OperationLog) error
+	// CreateLogs 批量写入操作日志
+	CreateLogs(ctx context.Context, logs []*OperationLog) error
 	ListLogs(ctx context.Context, userID int64, startTime, endTime time.Time) ([]*OperationLog, error)
-}
\ No newline at end of file
+	// QueryLogs 分页查询操作日志，按时间倒序，同时返回符合条件的总数
+	QueryLogs(ctx context.Context, query *OperationLogQuery) ([]*OperationLog, int64, error)
+}
+
+var ErrOperationLogQueryInvalid = errors.New("operation log query invalid")
+
+const (
+	// defaultOperationLogPageSize 默认每页条数
+	defaultOperationLogPageSize = 20
+	// maxOperationLogPageSize 每页最大条数
+	maxOperationLogPageSize = 100
+)
+
+// OperationLogUsecase 操作日志查询
+type OperationLogUsecase struct {
+	repo OperationLogRepo
+}
+
+// NewOperationLogUsecase 创建操作日志用例
+func NewOperationLogUsecase(repo OperationLogRepo) *OperationLogUsecase {
+	return &OperationLogUsecase{repo: repo}
+}
+
+// ListLogs 分页查询操作日志，页码与每页条数缺省时使用默认值并回写到 query
+func (uc *OperationLogUsecase) ListLogs(ctx context.Context, query *OperationLogQuery) ([]*OperationLog, int64, error) {
+	q := *query
+	if q.Page < 0 || q.PageSize < 0 {
+		return nil, 0, fmt.Errorf("%w: 页码与每页条数不能为负数", ErrOperationLogQueryInvalid)
+	}
+	if q.PageSize > maxOperationLogPageSize {
+		return nil, 0, fmt.Errorf("%w: 每页最多 %d 条", ErrOperationLogQueryInvalid, maxOperationLogPageSize)
+	}
+	if !q.StartTime.IsZero() && !q.EndTime.IsZero() && q.EndTime.Before(q.StartTime) {
+		return nil, 0, fmt.Errorf("%w: 结束时间早于开始时间", ErrOperationLogQueryInvalid)
+	}
+	if q.Page == 0 {
+		q.Page = 1
+	}
+	if q.PageSize == 0 {
+		q.PageSize = defaultOperationLogPageSize
+	}
+	*query = q
+	return uc.repo.QueryLogs(ctx, query)
+}
+
+// OperationPIIUnmask 查看个人信息明文的操作类型
+const OperationPIIUnmask = "pii.unmask"
+
+// piiUnmaskAuditor 将个人信息明文查看记录写入操作日志
+type piiUnmaskAuditor struct {
+	repo OperationLogRepo
+}
+
+// NewPIIUnmaskAuditor 创建个人信息明文查看审计
+func NewPIIUnmaskAuditor(repo OperationLogRepo) sensitive.UnmaskAuditor {
+	return &piiUnmaskAuditor{repo: repo}
+}
+
+// RecordUnmask 写入审计记录，操作对象为接口，内容为字段与原因
+func (a *piiUnmaskAuditor) RecordUnmask(ctx context.Context, record *sensitive.UnmaskRecord) error {
+	content, err := json.Marshal(map[string]interface{}{
+		"fields": record.Fields,
+		"reason": record.Reason,
+	})
+	if err != nil {
+		return err
+	}
+	// 认证主体 ID 为用户 ID 时写入 user_id，其他主体（如 API 客户端）只记录在 username
+	userID, _ := strconv.ParseInt(record.SubjectID, 10, 64)
+	return a.repo.CreateLog(ctx, &OperationLog{
+		UserID:    userID,
+		Username:  record.SubjectID,
+		Operation: OperationPIIUnmask,
+		Target:    record.Operation,
+		Content:   string(content),
+		Result:    "success",
+		CreatedAt: record.Time,
+	})
+}
@@ -0,0 +1,348 @@
+package biz
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"sync"
+	"time"
+
+	"kratos-boilerplate/internal/pkg/audit"
+
+	"github.com/go-kratos/kratos/v2/log"
+)
+
+const (
+	// operationLogPartitionsAhead 预建当月之后的分区数
+	operationLogPartitionsAhead = 2
+	// operationLogArchiveBatch 归档与恢复时每批处理的记录数
+	operationLogArchiveBatch = 1000
+	// operationLogArchiveTimeout 一次归档检查的超时时间
+	operationLogArchiveTimeout = time.Hour
+)
+
+var ErrInvalidRestoreTable = errors.New("invalid restore table name")
+
+// restoreTablePattern 恢复表名只允许小写标识符，避免拼接 SQL 时注入
+var restoreTablePattern = regexp.MustCompile(`^[a-z_][a-z0-9_]{0,62}$`)
+
+// OperationLogPartition 操作日志的月分区，范围为 [Start, End)
+type OperationLogPartition struct {
+	Name  string
+	Start time.Time
+	End   time.Time
+}
+
+// OperationLogArchive 已导出的分区
+type OperationLogArchive struct {
+	Partition string
+	Start     time.Time
+	End       time.Time
+	Records   int64
+	MinSeq    int64 // 分区内的哈希链序号范围，没有入链记录时为 0
+	MaxSeq    int64
+	LastHash  string // 序号为 MaxSeq 的记录的哈希
+	File      string // 归档清单路径
+	SHA256    string
+}
+
+// OperationLogArchiveRepo 操作日志分区与归档仓储接口
+type OperationLogArchiveRepo interface {
+	// EnsurePartition 创建 month 所在月份的分区并返回，已存在时返回 false。默认分区中属于该月的记录一并移入新分区
+	EnsurePartition(ctx context.Context, month time.Time) (*OperationLogPartition, bool, error)
+	// ListPartitions 按时间升序返回月分区，不含默认分区
+	ListPartitions(ctx context.Context) ([]*OperationLogPartition, error)
+	// ArchivePartition 在咨询锁内按 ID 升序分批读取分区记录交给 write，全部写完后调用 seal 完成归档文件，
+	// 再登记归档并删除分区。其他实例正在归档时返回 false
+	ArchivePartition(ctx context.Context, p *OperationLogPartition, write func([]*OperationLog) error, seal func() (*OperationLogArchive, error)) (bool, error)
+	// CreateRestoreTable 创建与操作日志表结构相同的恢复表，表已存在时返回错误
+	CreateRestoreTable(ctx context.Context, table string) error
+	// RestoreLogs 将记录写入恢复表
+	RestoreLogs(ctx context.Context, table string, logs []*OperationLog) error
+}
+
+// OperationLogRestoreReport 归档恢复结果
+type OperationLogRestoreReport struct {
+	Table     string
+	Partition string
+	Records   int
+	// Invalid 内容与哈希不符或与前一条记录链接不上的记录数
+	Invalid         int
+	FirstInvalidSeq int64
+}
+
+// OperationLogArchiveUsecase 操作日志分区维护、过期分区归档与恢复
+type OperationLogArchiveUsecase struct {
+	repo   OperationLogArchiveRepo
+	config *AuditConfig
+	log    *log.Helper
+	now    func() time.Time
+}
+
+// NewOperationLogArchiveUsecase 创建操作日志归档用例
+func NewOperationLogArchiveUsecase(repo OperationLogArchiveRepo, config *AuditConfig, logger log.Logger) *OperationLogArchiveUsecase {
+	return &OperationLogArchiveUsecase{
+		repo:   repo,
+		config: config,
+		log:    log.NewHelper(log.With(logger, "module", "biz.audit")),
+		now:    time.Now,
+	}
+}
+
+// Maintain 预建当月与之后的分区，并归档超出保留期的分区，返回本次归档的分区
+func (uc *OperationLogArchiveUsecase) Maintain(ctx context.Context) ([]*OperationLogArchive, error) {
+	month := monthStart(uc.now())
+	for i := 0; i <= operationLogPartitionsAhead; i++ {
+		p, created, err := uc.repo.EnsurePartition(ctx, month.AddDate(0, i, 0))
+		if err != nil {
+			return nil, err
+		}
+		if created {
+			uc.log.Infof("operation log partition %s created", p.Name)
+		}
+	}
+	if uc.config.RetentionMonths <= 0 {
+		return nil, nil
+	}
+
+	// 保留含当月在内的 RetentionMonths 个月，更早结束的分区均已过期
+	cutoff := month.AddDate(0, 1-uc.config.RetentionMonths, 0)
+	partitions, err := uc.repo.ListPartitions(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var archives []*OperationLogArchive
+	for _, p := range partitions {
+		if p.End.After(cutoff) {
+			continue
+		}
+		archive, err := uc.archive(ctx, p)
+		if err != nil {
+			return archives, fmt.Errorf("archive partition %s: %w", p.Name, err)
+		}
+		if archive == nil {
+			// 其他实例正在归档
+			return archives, nil
+		}
+		uc.log.Infof("operation log partition %s archived to %s: %d records", p.Name, archive.File, archive.Records)
+		archives = append(archives, archive)
+	}
+	return archives, nil
+}
+
+// archive 将分区导出为归档文件后删除分区
+func (uc *OperationLogArchiveUsecase) archive(ctx context.Context, p *OperationLogPartition) (*OperationLogArchive, error) {
+	w, err := audit.CreateArchive(uc.config.ArchiveDirectory, p.Name)
+	if err != nil {
+		return nil, err
+	}
+	archive := &OperationLogArchive{Partition: p.Name, Start: p.Start, End: p.End}
+	sealed := false
+	defer func() {
+		if !sealed {
+			w.Abort()
+		}
+	}()
+
+	write := func(logs []*OperationLog) error {
+		for _, l := range logs {
+			if err := w.Write(l); err != nil {
+				return err
+			}
+			if l.Seq == 0 {
+				continue
+			}
+			if archive.MinSeq == 0 || l.Seq < archive.MinSeq {
+				archive.MinSeq = l.Seq
+			}
+			if l.Seq > archive.MaxSeq {
+				archive.MaxSeq = l.Seq
+				archive.LastHash = l.Hash
+			}
+		}
+		return nil
+	}
+	seal := func() (*OperationLogArchive, error) {
+		manifest := &audit.ArchiveManifest{
+			Table:     "operation_logs",
+			Partition: p.Name,
+			From:      p.Start,
+			To:        p.End,
+			MinSeq:    archive.MinSeq,
+			MaxSeq:    archive.MaxSeq,
+			CreatedAt: uc.now().UTC(),
+		}
+		sealed = true
+		if err := w.Close(manifest); err != nil {
+			return nil, err
+		}
+		archive.Records = manifest.Records
+		archive.File = audit.ManifestPath(uc.config.ArchiveDirectory, p.Name)
+		archive.SHA256 = manifest.SHA256
+		return archive, nil
+	}
+
+	ok, err := uc.repo.ArchivePartition(ctx, p, write, seal)
+	if err != nil || !ok {
+		return nil, err
+	}
+	return archive, nil
+}
+
+// Restore 校验归档文件后将其中的记录导入恢复表，table 为空时使用 operation_logs_restore_<年月>。
+// 恢复表不受分区维护与归档影响，调查结束后需手动删除。
+func (uc *OperationLogArchiveUsecase) Restore(ctx context.Context, manifestPath, table string) (*OperationLogRestoreReport, error) {
+	r, manifest, err := audit.OpenArchive(manifestPath)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	if table == "" {
+		table = "operation_logs_restore_" + manifest.From.UTC().Format("200601")
+	}
+	if !restoreTablePattern.MatchString(table) || table == "operation_logs" {
+		return nil, fmt.Errorf("%w: %q", ErrInvalidRestoreTable, table)
+	}
+	if err := uc.repo.CreateRestoreTable(ctx, table); err != nil {
+		return nil, err
+	}
+
+	report := &OperationLogRestoreReport{Table: table, Partition: manifest.Partition}
+	var prev *OperationLog
+	batch := make([]*OperationLog, 0, operationLogArchiveBatch)
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		if err := uc.repo.RestoreLogs(ctx, table, batch); err != nil {
+			return err
+		}
+		batch = batch[:0]
+		return nil
+	}
+	for {
+		l := &OperationLog{}
+		if err := r.Next(l); err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			return nil, fmt.Errorf("read archive: %w", err)
+		}
+		report.Records++
+		if !restoredLogValid(l, prev) {
+			report.Invalid++
+			if report.FirstInvalidSeq == 0 {
+				report.FirstInvalidSeq = l.Seq
+			}
+		}
+		if l.Seq != 0 {
+			prev = l
+		}
+		if batch = append(batch, l); len(batch) == operationLogArchiveBatch {
+			if err := flush(); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if err := flush(); err != nil {
+		return nil, err
+	}
+	return report, nil
+}
+
+// restoredLogValid 校验归档记录的哈希，序号与前一条记录相连时同时校验链接
+func restoredLogValid(l, prev *OperationLog) bool {
+	if l.Seq == 0 {
+		// 哈希链启用前写入的记录
+		return true
+	}
+	if prev != nil && prev.Seq == l.Seq-1 && l.PrevHash != prev.Hash {
+		return false
+	}
+	ok, err := audit.VerifyLink(l.PrevHash, l.AuditPayload(), l.Hash)
+	return err == nil && ok
+}
+
+// monthStart 返回 t 所在 UTC 月份的月初
+func monthStart(t time.Time) time.Time {
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+}
+
+// OperationLogArchiver 定期维护操作日志分区并归档过期分区，作为 kratos 服务随应用启停
+type OperationLogArchiver struct {
+	uc       *OperationLogArchiveUsecase
+	interval time.Duration
+	log      *log.Helper
+
+	stopOnce sync.Once
+	done     chan struct{}
+	stopped  chan struct{}
+}
+
+// NewOperationLogArchiver 创建操作日志归档任务
+func NewOperationLogArchiver(uc *OperationLogArchiveUsecase, config *AuditConfig, logger log.Logger) *OperationLogArchiver {
+	return &OperationLogArchiver{
+		uc:       uc,
+		interval: config.ArchiveInterval,
+		log:      log.NewHelper(log.With(logger, "module", "biz.audit")),
+		done:     make(chan struct{}),
+		stopped:  make(chan struct{}),
+	}
+}
+
+// Start 启动后立即检查一次，之后定期检查，直到 Stop；未配置数据库时不再检查
+func (a *OperationLogArchiver) Start(context.Context) error {
+	defer close(a.stopped)
+
+	ticker := time.NewTicker(a.interval)
+	defer ticker.Stop()
+	for {
+		if !a.maintain() {
+			a.log.Warn("database is not configured, operation log partition maintenance is disabled")
+			<-a.done
+			return nil
+		}
+		select {
+		case <-a.done:
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// Stop 停止归档任务，进行中的归档会被取消并在下次检查时重做
+func (a *OperationLogArchiver) Stop(ctx context.Context) error {
+	a.stopOnce.Do(func() {
+		close(a.done)
+	})
+	select {
+	case <-a.stopped:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// maintain 执行一次分区维护，未配置数据库时返回 false
+func (a *OperationLogArchiver) maintain() bool {
+	ctx, cancel := context.WithTimeout(context.Background(), operationLogArchiveTimeout)
+	defer cancel()
+	go func() {
+		select {
+		case <-a.done:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+	if _, err := a.uc.Maintain(ctx); err != nil {
+		if errors.Is(err, ErrDatabaseNotConfigured) {
+			return false
+		}
+		a.log.Errorf("failed to maintain operation log partitions: %v", err)
+	}
+	return true
+}
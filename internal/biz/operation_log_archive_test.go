@@ -0,0 +1,204 @@
+package biz
+
+import (
+	"context"
+	"os"
+	"sort"
+	"testing"
+	"time"
+
+	"kratos-boilerplate/internal/pkg/audit"
+
+	"github.com/go-kratos/kratos/v2/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// memoryOperationLogArchiveRepo 内存实现的OperationLogArchiveRepo，按月份名保存分区记录
+type memoryOperationLogArchiveRepo struct {
+	partitions map[string][]*OperationLog
+	archives   []*OperationLogArchive
+	restored   map[string][]*OperationLog
+	locked     bool
+	ensured    int
+	err        error
+}
+
+func newMemoryOperationLogArchiveRepo() *memoryOperationLogArchiveRepo {
+	return &memoryOperationLogArchiveRepo{partitions: make(map[string][]*OperationLog), restored: make(map[string][]*OperationLog)}
+}
+
+func memoryPartition(month time.Time) *OperationLogPartition {
+	start := monthStart(month)
+	return &OperationLogPartition{Name: "operation_logs_" + start.Format("200601"), Start: start, End: start.AddDate(0, 1, 0)}
+}
+
+func (r *memoryOperationLogArchiveRepo) EnsurePartition(ctx context.Context, month time.Time) (*OperationLogPartition, bool, error) {
+	r.ensured++
+	if r.err != nil {
+		return nil, false, r.err
+	}
+	p := memoryPartition(month)
+	if _, ok := r.partitions[p.Name]; ok {
+		return p, false, nil
+	}
+	r.partitions[p.Name] = []*OperationLog{}
+	return p, true, nil
+}
+
+func (r *memoryOperationLogArchiveRepo) ListPartitions(ctx context.Context) ([]*OperationLogPartition, error) {
+	var partitions []*OperationLogPartition
+	for name := range r.partitions {
+		month, _ := time.Parse("200601", name[len("operation_logs_"):])
+		partitions = append(partitions, memoryPartition(month))
+	}
+	sort.Slice(partitions, func(i, j int) bool { return partitions[i].Start.Before(partitions[j].Start) })
+	return partitions, nil
+}
+
+func (r *memoryOperationLogArchiveRepo) ArchivePartition(ctx context.Context, p *OperationLogPartition, write func([]*OperationLog) error, seal func() (*OperationLogArchive, error)) (bool, error) {
+	if r.locked {
+		return false, nil
+	}
+	if err := write(r.partitions[p.Name]); err != nil {
+		return false, err
+	}
+	archive, err := seal()
+	if err != nil {
+		return false, err
+	}
+	r.archives = append(r.archives, archive)
+	delete(r.partitions, p.Name)
+	return true, nil
+}
+
+func (r *memoryOperationLogArchiveRepo) CreateRestoreTable(ctx context.Context, table string) error {
+	r.restored[table] = []*OperationLog{}
+	return nil
+}
+
+func (r *memoryOperationLogArchiveRepo) RestoreLogs(ctx context.Context, table string, logs []*OperationLog) error {
+	r.restored[table] = append(r.restored[table], logs...)
+	return nil
+}
+
+// newArchiveFixture 在 2026 年 1 月到 5 月每月写入 3 条链上记录，当前时间为 5 月中
+func newArchiveFixture(t *testing.T, months int) (*OperationLogArchiveUsecase, *memoryOperationLogArchiveRepo) {
+	chain, err := audit.NewChain(audit.AlgorithmSHA256)
+	require.NoError(t, err)
+	repo := newMemoryOperationLogArchiveRepo()
+	uc := NewOperationLogArchiveUsecase(repo, &AuditConfig{RetentionMonths: months, ArchiveDirectory: t.TempDir()}, log.NewStdLogger(os.Stdout))
+	uc.now = func() time.Time { return time.Date(2026, 5, 15, 0, 0, 0, 0, time.UTC) }
+
+	var seq int64
+	prevHash := ""
+	for month := 1; month <= 5; month++ {
+		p := memoryPartition(time.Date(2026, time.Month(month), 1, 0, 0, 0, 0, time.UTC))
+		for i := 0; i < 3; i++ {
+			seq++
+			l := &OperationLog{ID: seq, Seq: seq, UserID: 1, Operation: "GET /api/v1/x", Result: "success", CreatedAt: p.Start.Add(time.Duration(i) * time.Hour), PrevHash: prevHash}
+			l.Hash = chain.Link(prevHash, l.AuditPayload())
+			prevHash = l.Hash
+			repo.partitions[p.Name] = append(repo.partitions[p.Name], l)
+		}
+	}
+	return uc, repo
+}
+
+// TestOperationLogArchiveUsecase_Maintain 测试预建分区与按保留期归档
+func TestOperationLogArchiveUsecase_Maintain(t *testing.T) {
+	ctx := context.Background()
+
+	uc, repo := newArchiveFixture(t, 2)
+	archives, err := uc.Maintain(ctx)
+	require.NoError(t, err)
+
+	// 保留 4 月与 5 月，预建 6 月与 7 月
+	require.Len(t, archives, 3)
+	assert.Equal(t, "operation_logs_202601", archives[0].Partition)
+	assert.Equal(t, "operation_logs_202603", archives[2].Partition)
+	assert.Equal(t, int64(7), archives[2].MinSeq)
+	assert.Equal(t, int64(9), archives[2].MaxSeq)
+	assert.Equal(t, int64(3), archives[2].Records)
+	var names []string
+	for name := range repo.partitions {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	assert.Equal(t, []string{"operation_logs_202604", "operation_logs_202605", "operation_logs_202606", "operation_logs_202607"}, names)
+
+	// 归档文件完整，清单记录分区范围
+	r, manifest, err := audit.OpenArchive(archives[0].File)
+	require.NoError(t, err)
+	require.NoError(t, r.Close())
+	assert.Equal(t, "operation_logs_202601", manifest.Partition)
+	assert.Equal(t, archives[0].SHA256, manifest.SHA256)
+	assert.True(t, time.Date(2026, 2, 1, 0, 0, 0, 0, time.UTC).Equal(manifest.To))
+
+	// 再次检查没有需要归档的分区
+	archives, err = uc.Maintain(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, archives)
+
+	// 未配置保留期时只预建分区
+	uc, repo = newArchiveFixture(t, 0)
+	archives, err = uc.Maintain(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, archives)
+	assert.Len(t, repo.partitions, 7)
+
+	// 其他实例正在归档时不留下归档文件
+	uc, repo = newArchiveFixture(t, 2)
+	repo.locked = true
+	archives, err = uc.Maintain(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, archives)
+	entries, err := os.ReadDir(uc.config.ArchiveDirectory)
+	require.NoError(t, err)
+	assert.Empty(t, entries)
+}
+
+// TestOperationLogArchiver_DatabaseNotConfigured 测试未配置数据库时停止分区维护
+func TestOperationLogArchiver_DatabaseNotConfigured(t *testing.T) {
+	repo := newMemoryOperationLogArchiveRepo()
+	repo.err = ErrDatabaseNotConfigured
+	config := &AuditConfig{ArchiveInterval: time.Millisecond}
+	archiver := NewOperationLogArchiver(NewOperationLogArchiveUsecase(repo, config, log.NewStdLogger(os.Stdout)), config, log.NewStdLogger(os.Stdout))
+
+	go func() { _ = archiver.Start(context.Background()) }()
+	time.Sleep(20 * time.Millisecond)
+	require.NoError(t, archiver.Stop(context.Background()))
+	assert.Equal(t, 1, repo.ensured)
+}
+
+// TestOperationLogArchiveUsecase_Restore 测试归档恢复与记录校验
+func TestOperationLogArchiveUsecase_Restore(t *testing.T) {
+	ctx := context.Background()
+	uc, repo := newArchiveFixture(t, 2)
+	archives, err := uc.Maintain(ctx)
+	require.NoError(t, err)
+	require.Len(t, archives, 3)
+
+	report, err := uc.Restore(ctx, archives[1].File, "")
+	require.NoError(t, err)
+	assert.Equal(t, "operation_logs_restore_202602", report.Table)
+	assert.Equal(t, 3, report.Records)
+	assert.Zero(t, report.Invalid)
+	require.Len(t, repo.restored["operation_logs_restore_202602"], 3)
+	assert.Equal(t, int64(4), repo.restored["operation_logs_restore_202602"][0].Seq)
+
+	_, err = uc.Restore(ctx, archives[1].File, "operation_logs; DROP TABLE users")
+	assert.ErrorIs(t, err, ErrInvalidRestoreTable)
+	_, err = uc.Restore(ctx, archives[1].File, "operation_logs")
+	assert.ErrorIs(t, err, ErrInvalidRestoreTable)
+
+	// 归档前被篡改的记录在恢复时报告
+	uc, repo = newArchiveFixture(t, 2)
+	repo.partitions["operation_logs_202601"][1].Result = "error"
+	archives, err = uc.Maintain(ctx)
+	require.NoError(t, err)
+	report, err = uc.Restore(ctx, archives[0].File, "jan")
+	require.NoError(t, err)
+	assert.Equal(t, 1, report.Invalid)
+	assert.Equal(t, int64(2), report.FirstInvalidSeq)
+}
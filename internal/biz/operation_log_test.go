@@ -0,0 +1,217 @@
+package biz
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"github.com/go-kratos/kratos/v2/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// memoryOperationLogRepo 内存实现的OperationLogRepo
+type memoryOperationLogRepo struct {
+	OperationLogRepo
+
+	mu      sync.Mutex
+	batches [][]*OperationLog
+	block   chan struct{}
+	err     error
+	query   *OperationLogQuery
+	// reject 返回 true 的日志使所在的整批写入失败
+	reject func(*OperationLog) bool
+}
+
+func (r *memoryOperationLogRepo) CreateLogs(ctx context.Context, logs []*OperationLog) error {
+	if r.block != nil {
+		<-r.block
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.err != nil {
+		return r.err
+	}
+	for i, l := range logs {
+		l.Seq = int64(i + 1)
+		if r.reject != nil && r.reject(l) {
+			return errors.New("value too long for type character varying(255)")
+		}
+	}
+	r.batches = append(r.batches, logs)
+	return nil
+}
+
+func (r *memoryOperationLogRepo) QueryLogs(ctx context.Context, query *OperationLogQuery) ([]*OperationLog, int64, error) {
+	r.query = query
+	return []*OperationLog{{ID: 1}}, 1, nil
+}
+
+func (r *memoryOperationLogRepo) written() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	n := 0
+	for _, batch := range r.batches {
+		n += len(batch)
+	}
+	return n
+}
+
+// TestOperationLogWriter_Batch 测试攒批写入与关闭时写完缓冲
+func TestOperationLogWriter_Batch(t *testing.T) {
+	repo := &memoryOperationLogRepo{}
+	w := NewOperationLogWriterWithConfig(repo, OperationLogWriterConfig{
+		BatchSize:     3,
+		FlushInterval: time.Hour,
+	}, log.NewStdLogger(os.Stdout))
+
+	for i := 0; i < 7; i++ {
+		assert.True(t, w.Write(&OperationLog{Operation: "op"}))
+	}
+	w.Close()
+
+	require.Len(t, repo.batches, 3)
+	assert.Len(t, repo.batches[0], 3)
+	assert.Len(t, repo.batches[1], 3)
+	assert.Len(t, repo.batches[2], 1)
+
+	// 关闭后不再接收
+	assert.False(t, w.Write(&OperationLog{}))
+	w.Close()
+}
+
+// TestOperationLogWriter_FlushInterval 测试未攒满一批时按间隔写入
+func TestOperationLogWriter_FlushInterval(t *testing.T) {
+	repo := &memoryOperationLogRepo{}
+	w := NewOperationLogWriterWithConfig(repo, OperationLogWriterConfig{
+		BatchSize:     100,
+		FlushInterval: 10 * time.Millisecond,
+	}, log.NewStdLogger(os.Stdout))
+	defer w.Close()
+
+	w.Write(&OperationLog{Operation: "op"})
+	assert.Eventually(t, func() bool { return repo.written() == 1 }, time.Second, 5*time.Millisecond)
+}
+
+// TestOperationLogWriter_Bounded 测试缓冲满时丢弃而不阻塞
+func TestOperationLogWriter_Bounded(t *testing.T) {
+	repo := &memoryOperationLogRepo{block: make(chan struct{})}
+	w := NewOperationLogWriterWithConfig(repo, OperationLogWriterConfig{
+		BufferSize:    2,
+		BatchSize:     1,
+		FlushInterval: time.Hour,
+	}, log.NewStdLogger(os.Stdout))
+
+	// 第一条被后台协程取出后阻塞在写入，之后缓冲最多容纳两条
+	require.True(t, w.Write(&OperationLog{}))
+	require.Eventually(t, func() bool { return len(w.logs) == 0 }, time.Second, time.Millisecond)
+	assert.True(t, w.Write(&OperationLog{}))
+	assert.True(t, w.Write(&OperationLog{}))
+	assert.False(t, w.Write(&OperationLog{}))
+
+	close(repo.block)
+	w.Close()
+	assert.Equal(t, 3, repo.written())
+}
+
+// TestOperationLogWriter_WriteFailed 测试写入失败时丢弃该批并继续
+func TestOperationLogWriter_WriteFailed(t *testing.T) {
+	repo := &memoryOperationLogRepo{err: errors.New("db down")}
+	w := NewOperationLogWriterWithConfig(repo, OperationLogWriterConfig{BatchSize: 1}, log.NewStdLogger(os.Stdout))
+	assert.True(t, w.Write(&OperationLog{}))
+	w.Close()
+	assert.Zero(t, repo.written())
+}
+
+// TestOperationLogWriter_RetryOneByOne 测试整批写入失败时逐条重试，只丢弃写入失败的日志
+func TestOperationLogWriter_RetryOneByOne(t *testing.T) {
+	var buf bytes.Buffer
+	repo := &memoryOperationLogRepo{reject: func(l *OperationLog) bool { return l.Operation == "bad" }}
+	w := NewOperationLogWriterWithConfig(repo, OperationLogWriterConfig{
+		BatchSize:     3,
+		FlushInterval: time.Hour,
+		AuditLogger:   log.NewStdLogger(&buf),
+	}, log.NewStdLogger(os.Stdout))
+
+	bad := &OperationLog{Operation: "bad"}
+	for _, entry := range []*OperationLog{{Operation: "a"}, bad, {Operation: "b"}} {
+		require.True(t, w.Write(entry))
+	}
+	w.Close()
+
+	assert.Equal(t, 2, repo.written())
+	require.Len(t, repo.batches, 2)
+	assert.Equal(t, "a", repo.batches[0][0].Operation)
+	assert.Equal(t, "b", repo.batches[1][0].Operation)
+	assert.Zero(t, bad.Seq)
+	assert.Contains(t, buf.String(), "operation=bad")
+	assert.Contains(t, buf.String(), "persisted=false")
+}
+
+// TestOperationLog_TruncateColumns 测试按字符截断超出列长度的字段
+func TestOperationLog_TruncateColumns(t *testing.T) {
+	l := &OperationLog{
+		Username:  "alice",
+		Operation: "GET /" + strings.Repeat("路", 300),
+		Target:    "/" + strings.Repeat("x", 3000),
+	}
+	l.TruncateColumns()
+	assert.Equal(t, "alice", l.Username)
+	assert.Equal(t, OperationLogMaxOperation, utf8.RuneCountInString(l.Operation))
+	assert.True(t, utf8.ValidString(l.Operation))
+	assert.Len(t, l.Target, OperationLogMaxTarget)
+}
+
+// TestOperationLogWriter_AuditLogger 测试日志同时写入审计日志输出，写入仓储失败的日志也写入
+func TestOperationLogWriter_AuditLogger(t *testing.T) {
+	var buf bytes.Buffer
+	repo := &memoryOperationLogRepo{}
+	w := NewOperationLogWriterWithConfig(repo, OperationLogWriterConfig{BatchSize: 1, AuditLogger: log.NewStdLogger(&buf)}, log.NewStdLogger(os.Stdout))
+	require.True(t, w.Write(&OperationLog{Operation: "POST /api/v1/auth/login", Username: "alice"}))
+	w.Close()
+	assert.Contains(t, buf.String(), "operation=POST /api/v1/auth/login")
+	assert.Contains(t, buf.String(), "username=alice")
+	assert.Contains(t, buf.String(), "persisted=true")
+
+	buf.Reset()
+	repo = &memoryOperationLogRepo{err: errors.New("db down")}
+	w = NewOperationLogWriterWithConfig(repo, OperationLogWriterConfig{BatchSize: 1, AuditLogger: log.NewStdLogger(&buf)}, log.NewStdLogger(os.Stdout))
+	require.True(t, w.Write(&OperationLog{Operation: "DELETE /api/v1/admin/webhooks/1"}))
+	w.Close()
+	assert.Contains(t, buf.String(), "operation=DELETE /api/v1/admin/webhooks/1")
+	assert.Contains(t, buf.String(), "persisted=false")
+}
+
+// TestOperationLogUsecase_ListLogs 测试分页参数默认值与校验
+func TestOperationLogUsecase_ListLogs(t *testing.T) {
+	repo := &memoryOperationLogRepo{}
+	uc := NewOperationLogUsecase(repo)
+	ctx := context.Background()
+
+	query := &OperationLogQuery{Username: "alice"}
+	logs, total, err := uc.ListLogs(ctx, query)
+	require.NoError(t, err)
+	assert.Len(t, logs, 1)
+	assert.Equal(t, int64(1), total)
+	assert.Equal(t, 1, repo.query.Page)
+	assert.Equal(t, defaultOperationLogPageSize, repo.query.PageSize)
+	assert.Equal(t, defaultOperationLogPageSize, query.PageSize)
+
+	now := time.Now()
+	for name, q := range map[string]*OperationLogQuery{
+		"negative_page":  {Page: -1},
+		"page_too_large": {PageSize: maxOperationLogPageSize + 1},
+		"reversed_range": {StartTime: now, EndTime: now.Add(-time.Hour)},
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, _, err := uc.ListLogs(ctx, q)
+			assert.ErrorIs(t, err, ErrOperationLogQueryInvalid)
+		})
+	}
+}
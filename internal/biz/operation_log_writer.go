@@ -0,0 +1,244 @@
+package biz
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	pkglog "kratos-boilerplate/internal/pkg/log"
+
+	"github.com/go-kratos/kratos/v2/log"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+const operationLogInstrumentationName = "kratos-boilerplate/internal/biz"
+
+// 操作日志被丢弃的原因
+const (
+	operationLogDropBufferFull  = "buffer_full"
+	operationLogDropClosed      = "closed"
+	operationLogDropWriteFailed = "write_failed"
+)
+
+// OperationLogWriterConfig 操作日志异步写入配置
+type OperationLogWriterConfig struct {
+	// BufferSize 待写入日志的缓冲容量，缓冲已满时丢弃新日志
+	BufferSize int
+	// BatchSize 单次批量写入的最大条数
+	BatchSize int
+	// FlushInterval 未攒满一批时的最长等待时间
+	FlushInterval time.Duration
+	// WriteTimeout 单次批量写入的超时时间
+	WriteTimeout time.Duration
+	// MeterProvider 写入与丢弃指标，为空时使用全局 MeterProvider
+	MeterProvider metric.MeterProvider
+	// AuditLogger 每批写入后将日志逐条写入审计日志输出，写入仓储失败的日志同样写入，为空时不写
+	AuditLogger log.Logger
+}
+
+// DefaultOperationLogWriterConfig 默认操作日志异步写入配置
+var DefaultOperationLogWriterConfig = OperationLogWriterConfig{
+	BufferSize:    4096,
+	BatchSize:     100,
+	FlushInterval: time.Second,
+	WriteTimeout:  5 * time.Second,
+}
+
+// OperationLogWriter 操作日志异步批量写入器
+// 请求路径上只把日志放入有界缓冲，由单个后台协程攒批写入；缓冲满时丢弃并计数，不阻塞请求。
+// Close 停止接收新日志，并在返回前写完缓冲中的日志。
+type OperationLogWriter struct {
+	repo   OperationLogRepo
+	config OperationLogWriterConfig
+	log    *log.Helper
+
+	mu     sync.RWMutex
+	closed bool
+	logs   chan *OperationLog
+	done   chan struct{}
+
+	written metric.Int64Counter
+	dropped metric.Int64Counter
+}
+
+// NewOperationLogWriter 创建操作日志写入器，日志同时写入审计日志输出，返回的清理函数写完缓冲中的日志
+func NewOperationLogWriter(repo OperationLogRepo, audit pkglog.AuditLogger, logger log.Logger) (*OperationLogWriter, func()) {
+	config := DefaultOperationLogWriterConfig
+	config.AuditLogger = audit
+	w := NewOperationLogWriterWithConfig(repo, config, logger)
+	return w, w.Close
+}
+
+// NewOperationLogWriterWithConfig 根据配置创建操作日志写入器并启动后台写入
+func NewOperationLogWriterWithConfig(repo OperationLogRepo, config OperationLogWriterConfig, logger log.Logger) *OperationLogWriter {
+	if config.BufferSize <= 0 {
+		config.BufferSize = DefaultOperationLogWriterConfig.BufferSize
+	}
+	if config.BatchSize <= 0 {
+		config.BatchSize = DefaultOperationLogWriterConfig.BatchSize
+	}
+	if config.FlushInterval <= 0 {
+		config.FlushInterval = DefaultOperationLogWriterConfig.FlushInterval
+	}
+	if config.WriteTimeout <= 0 {
+		config.WriteTimeout = DefaultOperationLogWriterConfig.WriteTimeout
+	}
+	mp := config.MeterProvider
+	if mp == nil {
+		mp = otel.GetMeterProvider()
+	}
+
+	w := &OperationLogWriter{
+		repo:   repo,
+		config: config,
+		log:    log.NewHelper(log.With(logger, "module", "biz.operation_log")),
+		logs:   make(chan *OperationLog, config.BufferSize),
+		done:   make(chan struct{}),
+	}
+
+	meter := mp.Meter(operationLogInstrumentationName)
+	// 指标创建失败时返回的仍是可用的空实现
+	w.written, _ = meter.Int64Counter("operation_log.written",
+		metric.WithDescription("Number of operation logs written to the repository"))
+	w.dropped, _ = meter.Int64Counter("operation_log.dropped",
+		metric.WithDescription("Number of operation logs dropped by reason"))
+
+	go w.run()
+	return w
+}
+
+// Write 将日志放入缓冲，缓冲已满或写入器已关闭时丢弃并返回 false
+func (w *OperationLogWriter) Write(entry *OperationLog) bool {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+
+	if w.closed {
+		w.drop(operationLogDropClosed, 1)
+		return false
+	}
+	select {
+	case w.logs <- entry:
+		return true
+	default:
+		w.drop(operationLogDropBufferFull, 1)
+		return false
+	}
+}
+
+// Close 停止接收日志，等待缓冲中的日志写完
+func (w *OperationLogWriter) Close() {
+	w.mu.Lock()
+	if !w.closed {
+		w.closed = true
+		close(w.logs)
+	}
+	w.mu.Unlock()
+	<-w.done
+}
+
+// run 攒批写入，攒满一批或到达刷新间隔时写入
+func (w *OperationLogWriter) run() {
+	defer close(w.done)
+
+	ticker := time.NewTicker(w.config.FlushInterval)
+	defer ticker.Stop()
+
+	batch := make([]*OperationLog, 0, w.config.BatchSize)
+	for {
+		select {
+		case entry, ok := <-w.logs:
+			if !ok {
+				w.flush(batch)
+				return
+			}
+			batch = append(batch, entry)
+			if len(batch) >= w.config.BatchSize {
+				w.flush(batch)
+				batch = make([]*OperationLog, 0, w.config.BatchSize)
+			}
+		case <-ticker.C:
+			if len(batch) > 0 {
+				w.flush(batch)
+				batch = make([]*OperationLog, 0, w.config.BatchSize)
+			}
+		}
+	}
+}
+
+// flush 写入一批日志。整批写入失败时逐条重试，只丢弃仍然写入失败的日志，避免一条异常记录拖累整批
+func (w *OperationLogWriter) flush(batch []*OperationLog) {
+	if len(batch) == 0 {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), w.config.WriteTimeout)
+	defer cancel()
+
+	err := w.repo.CreateLogs(ctx, batch)
+	if err == nil {
+		w.written.Add(ctx, int64(len(batch)))
+		w.audit(batch, true)
+		return
+	}
+	if len(batch) == 1 || errors.Is(err, ErrDatabaseNotConfigured) {
+		w.failed(batch, err)
+		return
+	}
+
+	w.log.Warnf("failed to write %d operation logs, retrying one by one: %v", len(batch), err)
+	retryCtx, retryCancel := context.WithTimeout(context.Background(), w.config.WriteTimeout)
+	defer retryCancel()
+	var written, failed []*OperationLog
+	for _, entry := range batch {
+		if err = w.repo.CreateLogs(retryCtx, []*OperationLog{entry}); err != nil {
+			failed = append(failed, entry)
+			continue
+		}
+		written = append(written, entry)
+	}
+	if len(written) > 0 {
+		w.written.Add(retryCtx, int64(len(written)))
+		w.audit(written, true)
+	}
+	if len(failed) > 0 {
+		w.failed(failed, err)
+	}
+}
+
+// failed 丢弃写入失败的日志并写入审计日志输出，清除写入时分配但已回滚的哈希链字段
+func (w *OperationLogWriter) failed(batch []*OperationLog, err error) {
+	w.log.Errorf("failed to write %d operation logs: %v", len(batch), err)
+	for _, entry := range batch {
+		entry.Seq, entry.PrevHash, entry.Hash = 0, "", ""
+	}
+	w.drop(operationLogDropWriteFailed, len(batch))
+	w.audit(batch, false)
+}
+
+// audit 将日志写入审计日志输出，persisted 表示是否已写入仓储，已写入的日志带有哈希链序号与哈希
+func (w *OperationLogWriter) audit(batch []*OperationLog, persisted bool) {
+	if w.config.AuditLogger == nil {
+		return
+	}
+	for _, l := range batch {
+		_ = w.config.AuditLogger.Log(log.LevelInfo,
+			log.DefaultMessageKey, "operation log",
+			"user_id", l.UserID,
+			"username", l.Username,
+			"operation", l.Operation,
+			"target", l.Target,
+			"content", l.Content,
+			"result", l.Result,
+			"created_at", l.CreatedAt.Format(time.RFC3339Nano),
+			"seq", l.Seq,
+			"hash", l.Hash,
+			"persisted", persisted,
+		)
+	}
+}
+
+func (w *OperationLogWriter) drop(reason string, n int) {
+	w.dropped.Add(context.Background(), int64(n), metric.WithAttributes(attribute.String("reason", reason)))
+}
@@ -0,0 +1,484 @@
+package biz
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"sync"
+	"time"
+
+	"kratos-boilerplate/internal/pkg/plugin"
+	"kratos-boilerplate/internal/pkg/webhook"
+
+	"github.com/go-kratos/kratos/v2/log"
+)
+
+var (
+	ErrWebhookNotFound = errors.New("webhook not found")
+	ErrWebhookInvalid  = errors.New("webhook invalid")
+)
+
+const (
+	// webhookHandlerPrefix Webhook 端点在事件总线上的处理器名称前缀
+	webhookHandlerPrefix = "webhook:"
+	// webhookResponseLimit 投递日志中保留的响应体长度
+	webhookResponseLimit = 1024
+)
+
+// WebhookEndpoint Webhook 端点
+type WebhookEndpoint struct {
+	ID          int64
+	URL         string
+	Secret      string
+	EventTypes  []string
+	Description string
+	Enabled     bool
+	// MaxRetries 首次投递失败后的最大重试次数
+	MaxRetries int
+	// FailureCount 连续投递失败次数，成功后清零
+	FailureCount int
+	// DisabledReason 端点被自动停用的原因
+	DisabledReason string
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+}
+
+// WebhookDelivery 一次 Webhook 投递记录
+type WebhookDelivery struct {
+	ID         int64
+	EndpointID int64
+	EventID    string
+	EventType  string
+	// IdempotencyKey 同一事件对同一端点的重试保持不变，即 X-Webhook-Id
+	IdempotencyKey string
+	Attempt        int
+	StatusCode     int
+	Success        bool
+	Error          string
+	Response       string
+	Duration       time.Duration
+	CreatedAt      time.Time
+}
+
+// WebhookRepo Webhook 仓储接口
+type WebhookRepo interface {
+	CreateEndpoint(ctx context.Context, endpoint *WebhookEndpoint) error
+	UpdateEndpoint(ctx context.Context, endpoint *WebhookEndpoint) error
+	GetEndpoint(ctx context.Context, id int64) (*WebhookEndpoint, error)
+	ListEndpoints(ctx context.Context) ([]*WebhookEndpoint, error)
+	DeleteEndpoint(ctx context.Context, id int64) error
+	// RecordFailure 连续失败次数加一，返回更新后的次数
+	RecordFailure(ctx context.Context, id int64) (int, error)
+	// ResetFailures 连续失败次数清零
+	ResetFailures(ctx context.Context, id int64) error
+	// DisableEndpoint 停用端点并记录原因
+	DisableEndpoint(ctx context.Context, id int64, reason string) error
+
+	SaveDelivery(ctx context.Context, delivery *WebhookDelivery) error
+	ListDeliveries(ctx context.Context, endpointID int64, limit int) ([]*WebhookDelivery, error)
+}
+
+// WebhookConfig Webhook 投递配置
+type WebhookConfig struct {
+	// Timeout 单次投递的超时时间
+	Timeout time.Duration
+	// MaxRetries 端点未指定时的默认重试次数
+	MaxRetries int
+	// Backoff 首次重试的等待时间，之后每次翻倍
+	Backoff time.Duration
+	// DisableThreshold 连续失败多少次后自动停用端点
+	DisableThreshold int
+	// AllowPrivateNetworks 允许回调地址指向回环、私有、链路本地等内部网络地址，仅用于本地开发与测试
+	AllowPrivateNetworks bool
+}
+
+// DefaultWebhookConfig 默认 Webhook 投递配置
+var DefaultWebhookConfig = WebhookConfig{
+	Timeout:          10 * time.Second,
+	MaxRetries:       5,
+	Backoff:          time.Second,
+	DisableThreshold: 20,
+}
+
+// webhookPayload 投递给接收方的请求体
+type webhookPayload struct {
+	ID        string                 `json:"id"`
+	Type      string                 `json:"type"`
+	Source    string                 `json:"source"`
+	Timestamp time.Time              `json:"timestamp"`
+	Data      map[string]interface{} `json:"data"`
+}
+
+// webhookTarget 已订阅的端点及其事件过滤器
+type webhookTarget struct {
+	endpoint *WebhookEndpoint
+	filter   plugin.EventFilter
+}
+
+// WebhookUsecase 将事件总线上的事件以签名的 HTTP 回调投递给外部端点
+// 每个启用的端点以至少一次投递方式订阅其事件类型，失败按指数退避重试，
+// 重试次数用尽的投递进入事件总线死信；连续失败达到阈值的端点被自动停用
+type WebhookUsecase struct {
+	repo   WebhookRepo
+	events plugin.EventBus
+	client *http.Client
+	config WebhookConfig
+	log    *log.Helper
+
+	mu      sync.RWMutex
+	targets map[int64]*webhookTarget
+}
+
+// NewWebhookUsecase 创建 Webhook 用例，并订阅已启用端点的事件
+func NewWebhookUsecase(repo WebhookRepo, events plugin.EventBus, logger log.Logger) *WebhookUsecase {
+	return NewWebhookUsecaseWithConfig(repo, events, DefaultWebhookConfig, logger)
+}
+
+// NewWebhookUsecaseWithConfig 根据配置创建 Webhook 用例
+func NewWebhookUsecaseWithConfig(repo WebhookRepo, events plugin.EventBus, config WebhookConfig, logger log.Logger) *WebhookUsecase {
+	if config.Timeout <= 0 {
+		config.Timeout = DefaultWebhookConfig.Timeout
+	}
+	if config.DisableThreshold <= 0 {
+		config.DisableThreshold = DefaultWebhookConfig.DisableThreshold
+	}
+	uc := &WebhookUsecase{
+		repo:    repo,
+		events:  events,
+		client:  webhook.NewClient(webhook.ClientConfig{Timeout: config.Timeout, AllowPrivateNetworks: config.AllowPrivateNetworks}),
+		config:  config,
+		log:     log.NewHelper(log.With(logger, "module", "biz.webhook")),
+		targets: make(map[int64]*webhookTarget),
+	}
+
+	endpoints, err := repo.ListEndpoints(context.Background())
+	if err != nil {
+		uc.log.Warnf("failed to load webhook endpoints: %v", err)
+		return uc
+	}
+	for _, ep := range endpoints {
+		if ep.Enabled {
+			if err := uc.subscribe(ep); err != nil {
+				uc.log.Warnf("failed to subscribe webhook %d: %v", ep.ID, err)
+			}
+		}
+	}
+	return uc
+}
+
+// CreateEndpoint 注册端点，未指定密钥时自动生成
+func (uc *WebhookUsecase) CreateEndpoint(ctx context.Context, ep *WebhookEndpoint) (*WebhookEndpoint, error) {
+	if err := uc.validate(ep); err != nil {
+		return nil, err
+	}
+	if ep.Secret == "" {
+		secret, err := webhook.GenerateSecret()
+		if err != nil {
+			return nil, fmt.Errorf("生成签名密钥失败: %v", err)
+		}
+		ep.Secret = secret
+	}
+	if ep.MaxRetries <= 0 {
+		ep.MaxRetries = uc.config.MaxRetries
+	}
+	now := time.Now()
+	ep.CreatedAt = now
+	ep.UpdatedAt = now
+
+	if err := uc.repo.CreateEndpoint(ctx, ep); err != nil {
+		return nil, err
+	}
+	if ep.Enabled {
+		if err := uc.subscribe(ep); err != nil {
+			return nil, err
+		}
+	}
+	uc.log.WithContext(ctx).Infof("webhook %d registered for %v", ep.ID, ep.EventTypes)
+	return ep, nil
+}
+
+// UpdateEndpoint 更新端点，Secret 为空时保留原密钥
+func (uc *WebhookUsecase) UpdateEndpoint(ctx context.Context, ep *WebhookEndpoint) (*WebhookEndpoint, error) {
+	current, err := uc.repo.GetEndpoint(ctx, ep.ID)
+	if err != nil {
+		return nil, err
+	}
+	if err := uc.validate(ep); err != nil {
+		return nil, err
+	}
+
+	current.URL = ep.URL
+	current.EventTypes = ep.EventTypes
+	current.Description = ep.Description
+	current.Enabled = ep.Enabled
+	if ep.Secret != "" {
+		current.Secret = ep.Secret
+	}
+	if ep.MaxRetries > 0 {
+		current.MaxRetries = ep.MaxRetries
+	}
+	if ep.Enabled {
+		current.DisabledReason = ""
+	}
+	current.UpdatedAt = time.Now()
+
+	if err := uc.repo.UpdateEndpoint(ctx, current); err != nil {
+		return nil, err
+	}
+	uc.unsubscribe(current.ID)
+	if current.Enabled {
+		if err := uc.subscribe(current); err != nil {
+			return nil, err
+		}
+	}
+	return current, nil
+}
+
+// EnableEndpoint 重新启用端点并清零连续失败次数
+func (uc *WebhookUsecase) EnableEndpoint(ctx context.Context, id int64) (*WebhookEndpoint, error) {
+	ep, err := uc.repo.GetEndpoint(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := uc.repo.ResetFailures(ctx, id); err != nil {
+		return nil, err
+	}
+	return uc.UpdateEndpoint(ctx, &WebhookEndpoint{
+		ID:          ep.ID,
+		URL:         ep.URL,
+		EventTypes:  ep.EventTypes,
+		Description: ep.Description,
+		Enabled:     true,
+		MaxRetries:  ep.MaxRetries,
+	})
+}
+
+// GetEndpoint 获取端点
+func (uc *WebhookUsecase) GetEndpoint(ctx context.Context, id int64) (*WebhookEndpoint, error) {
+	return uc.repo.GetEndpoint(ctx, id)
+}
+
+// ListEndpoints 列出端点
+func (uc *WebhookUsecase) ListEndpoints(ctx context.Context) ([]*WebhookEndpoint, error) {
+	return uc.repo.ListEndpoints(ctx)
+}
+
+// DeleteEndpoint 删除端点，未完成的投递被丢弃
+func (uc *WebhookUsecase) DeleteEndpoint(ctx context.Context, id int64) error {
+	if err := uc.repo.DeleteEndpoint(ctx, id); err != nil {
+		return err
+	}
+	uc.unsubscribe(id)
+	return nil
+}
+
+// ListDeliveries 查询端点的投递记录，按时间倒序
+func (uc *WebhookUsecase) ListDeliveries(ctx context.Context, id int64, limit int) ([]*WebhookDelivery, error) {
+	if _, err := uc.repo.GetEndpoint(ctx, id); err != nil {
+		return nil, err
+	}
+	return uc.repo.ListDeliveries(ctx, id, limit)
+}
+
+// validate 校验端点地址与事件类型，域名解析到的地址在投递建立连接时再次检查
+func (uc *WebhookUsecase) validate(ep *WebhookEndpoint) error {
+	u, err := url.Parse(ep.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Hostname() == "" {
+		return fmt.Errorf("%w: 回调地址须为 http 或 https URL", ErrWebhookInvalid)
+	}
+	if !uc.config.AllowPrivateNetworks {
+		if err := webhook.CheckHost(u.Hostname()); err != nil {
+			return fmt.Errorf("%w: 回调地址不能指向内部网络: %v", ErrWebhookInvalid, err)
+		}
+	}
+	if len(ep.EventTypes) == 0 {
+		return fmt.Errorf("%w: 至少订阅一种事件类型", ErrWebhookInvalid)
+	}
+	for _, t := range ep.EventTypes {
+		if t == "" {
+			return fmt.Errorf("%w: 事件类型不能为空", ErrWebhookInvalid)
+		}
+	}
+	return nil
+}
+
+// subscribe 在事件总线上订阅端点的事件类型
+func (uc *WebhookUsecase) subscribe(ep *WebhookEndpoint) error {
+	types := make([]plugin.EventType, 0, len(ep.EventTypes))
+	for _, t := range ep.EventTypes {
+		types = append(types, plugin.EventType(t))
+	}
+
+	// 保存副本，调用方持有的端点不受投递状态变化影响
+	endpoint := *ep
+	uc.mu.Lock()
+	uc.targets[ep.ID] = &webhookTarget{endpoint: &endpoint, filter: plugin.NewTypeFilter(types)}
+	uc.mu.Unlock()
+
+	handler := &webhookHandler{uc: uc, id: ep.ID, eventTypes: types}
+	options := plugin.DeliveryOptions{
+		Mode:       plugin.DeliveryAtLeastOnce,
+		MaxRetries: ep.MaxRetries,
+		Backoff:    uc.config.Backoff,
+	}
+	for _, t := range types {
+		if err := uc.events.SubscribeWithOptions(t, handler, options); err != nil {
+			uc.unsubscribe(ep.ID)
+			return err
+		}
+	}
+	return nil
+}
+
+// unsubscribe 取消端点的所有订阅
+func (uc *WebhookUsecase) unsubscribe(id int64) {
+	uc.mu.Lock()
+	target, ok := uc.targets[id]
+	delete(uc.targets, id)
+	uc.mu.Unlock()
+	if !ok {
+		return
+	}
+
+	for _, t := range target.endpoint.EventTypes {
+		_ = uc.events.Unsubscribe(plugin.EventType(t), webhookHandlerName(id))
+	}
+}
+
+// deliver 将事件投递给端点并记录结果，失败时返回错误由事件总线重试
+func (uc *WebhookUsecase) deliver(ctx context.Context, id int64, event plugin.Event) error {
+	uc.mu.RLock()
+	target, ok := uc.targets[id]
+	uc.mu.RUnlock()
+	// 端点已删除或停用，或已不再订阅该事件类型
+	if !ok || !target.filter.Match(event) {
+		return nil
+	}
+	ep := target.endpoint
+
+	body, err := json.Marshal(webhookPayload{
+		ID:        event.GetID(),
+		Type:      string(event.GetType()),
+		Source:    event.GetSource(),
+		Timestamp: event.GetTimestamp(),
+		Data:      event.GetData(),
+	})
+	if err != nil {
+		return fmt.Errorf("encode webhook payload: %w", err)
+	}
+
+	record := &WebhookDelivery{
+		EndpointID:     id,
+		EventID:        event.GetID(),
+		EventType:      string(event.GetType()),
+		IdempotencyKey: event.GetID() + ":" + webhookHandlerName(id),
+		Attempt:        1,
+		CreatedAt:      time.Now(),
+	}
+	if d, ok := plugin.DeliveryFromContext(ctx); ok {
+		record.IdempotencyKey = d.IdempotencyKey
+		record.Attempt = d.Attempt
+	}
+
+	deliveryErr := uc.post(ctx, ep, record, body)
+	record.Duration = time.Since(record.CreatedAt)
+	record.Success = deliveryErr == nil
+	if deliveryErr != nil {
+		record.Error = deliveryErr.Error()
+	}
+	if err := uc.repo.SaveDelivery(ctx, record); err != nil {
+		uc.log.Warnf("failed to save webhook delivery log of %d: %v", id, err)
+	}
+
+	if deliveryErr == nil {
+		uc.mu.RLock()
+		failing := ep.FailureCount > 0
+		uc.mu.RUnlock()
+		if failing {
+			if err := uc.repo.ResetFailures(ctx, id); err == nil {
+				uc.mu.Lock()
+				ep.FailureCount = 0
+				uc.mu.Unlock()
+			}
+		}
+		return nil
+	}
+
+	failures, err := uc.repo.RecordFailure(ctx, id)
+	if err != nil {
+		uc.log.Warnf("failed to record webhook failure of %d: %v", id, err)
+		return deliveryErr
+	}
+	uc.mu.Lock()
+	ep.FailureCount = failures
+	uc.mu.Unlock()
+	if failures >= uc.config.DisableThreshold {
+		reason := fmt.Sprintf("连续 %d 次投递失败，最后错误: %s", failures, deliveryErr.Error())
+		if err := uc.repo.DisableEndpoint(ctx, id, reason); err != nil {
+			uc.log.Errorf("failed to disable webhook %d: %v", id, err)
+			return deliveryErr
+		}
+		uc.unsubscribe(id)
+		uc.log.Warnf("webhook %d disabled after %d consecutive failures", id, failures)
+	}
+	return deliveryErr
+}
+
+// post 发送签名的回调请求，2xx 视为成功
+func (uc *WebhookUsecase) post(ctx context.Context, ep *WebhookEndpoint, record *WebhookDelivery, body []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, ep.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(webhook.HeaderID, record.IdempotencyKey)
+	req.Header.Set(webhook.HeaderEvent, record.EventType)
+	webhook.SignRequest(req, ep.Secret, time.Now(), body)
+
+	resp, err := uc.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	record.StatusCode = resp.StatusCode
+	respBody, _ := io.ReadAll(io.LimitReader(resp.Body, webhookResponseLimit))
+	record.Response = string(respBody)
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+func webhookHandlerName(id int64) string {
+	return webhookHandlerPrefix + strconv.FormatInt(id, 10)
+}
+
+// webhookHandler 端点在事件总线上的事件处理器
+type webhookHandler struct {
+	uc         *WebhookUsecase
+	id         int64
+	eventTypes []plugin.EventType
+}
+
+func (h *webhookHandler) GetName() string {
+	return webhookHandlerName(h.id)
+}
+
+func (h *webhookHandler) GetEventTypes() []plugin.EventType {
+	return h.eventTypes
+}
+
+func (h *webhookHandler) GetTimeout() time.Duration {
+	return h.uc.config.Timeout
+}
+
+func (h *webhookHandler) Handle(ctx context.Context, event plugin.Event) error {
+	return h.uc.deliver(ctx, h.id, event)
+}
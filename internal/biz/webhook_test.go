@@ -0,0 +1,348 @@
+package biz
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-kratos/kratos/v2/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"kratos-boilerplate/internal/pkg/plugin"
+	"kratos-boilerplate/internal/pkg/webhook"
+)
+
+// memoryWebhookRepo 内存实现的WebhookRepo
+type memoryWebhookRepo struct {
+	mu         sync.Mutex
+	nextID     int64
+	endpoints  map[int64]*WebhookEndpoint
+	deliveries []*WebhookDelivery
+}
+
+func newMemoryWebhookRepo() *memoryWebhookRepo {
+	return &memoryWebhookRepo{endpoints: make(map[int64]*WebhookEndpoint)}
+}
+
+func (r *memoryWebhookRepo) CreateEndpoint(ctx context.Context, ep *WebhookEndpoint) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.nextID++
+	ep.ID = r.nextID
+	stored := *ep
+	r.endpoints[ep.ID] = &stored
+	return nil
+}
+
+func (r *memoryWebhookRepo) UpdateEndpoint(ctx context.Context, ep *WebhookEndpoint) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	current, ok := r.endpoints[ep.ID]
+	if !ok {
+		return ErrWebhookNotFound
+	}
+	stored := *ep
+	stored.FailureCount = current.FailureCount
+	r.endpoints[ep.ID] = &stored
+	return nil
+}
+
+func (r *memoryWebhookRepo) GetEndpoint(ctx context.Context, id int64) (*WebhookEndpoint, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	ep, ok := r.endpoints[id]
+	if !ok {
+		return nil, ErrWebhookNotFound
+	}
+	result := *ep
+	return &result, nil
+}
+
+func (r *memoryWebhookRepo) ListEndpoints(ctx context.Context) ([]*WebhookEndpoint, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var result []*WebhookEndpoint
+	for _, ep := range r.endpoints {
+		copied := *ep
+		result = append(result, &copied)
+	}
+	return result, nil
+}
+
+func (r *memoryWebhookRepo) DeleteEndpoint(ctx context.Context, id int64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.endpoints[id]; !ok {
+		return ErrWebhookNotFound
+	}
+	delete(r.endpoints, id)
+	return nil
+}
+
+func (r *memoryWebhookRepo) RecordFailure(ctx context.Context, id int64) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	ep, ok := r.endpoints[id]
+	if !ok {
+		return 0, ErrWebhookNotFound
+	}
+	ep.FailureCount++
+	return ep.FailureCount, nil
+}
+
+func (r *memoryWebhookRepo) ResetFailures(ctx context.Context, id int64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	ep, ok := r.endpoints[id]
+	if !ok {
+		return ErrWebhookNotFound
+	}
+	ep.FailureCount = 0
+	return nil
+}
+
+func (r *memoryWebhookRepo) DisableEndpoint(ctx context.Context, id int64, reason string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	ep, ok := r.endpoints[id]
+	if !ok {
+		return ErrWebhookNotFound
+	}
+	ep.Enabled = false
+	ep.DisabledReason = reason
+	return nil
+}
+
+func (r *memoryWebhookRepo) SaveDelivery(ctx context.Context, d *WebhookDelivery) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	d.ID = int64(len(r.deliveries) + 1)
+	r.deliveries = append(r.deliveries, d)
+	return nil
+}
+
+func (r *memoryWebhookRepo) ListDeliveries(ctx context.Context, endpointID int64, limit int) ([]*WebhookDelivery, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var result []*WebhookDelivery
+	for i := len(r.deliveries) - 1; i >= 0; i-- {
+		if r.deliveries[i].EndpointID == endpointID {
+			result = append(result, r.deliveries[i])
+		}
+	}
+	if limit > 0 && len(result) > limit {
+		result = result[:limit]
+	}
+	return result, nil
+}
+
+func newTestWebhookUsecase(t *testing.T, repo WebhookRepo) *WebhookUsecase {
+	bus := plugin.NewEventBusWithConfig(plugin.EventBusConfig{PollInterval: 10 * time.Millisecond})
+	t.Cleanup(func() { bus.Close() })
+	return NewWebhookUsecaseWithConfig(repo, bus, WebhookConfig{
+		Timeout:              time.Second,
+		MaxRetries:           2,
+		Backoff:              10 * time.Millisecond,
+		DisableThreshold:     3,
+		AllowPrivateNetworks: true,
+	}, log.NewStdLogger(os.Stdout))
+}
+
+// 测试投递 - 签名可验证，仅投递订阅的事件类型
+func TestWebhookUsecase_Deliver(t *testing.T) {
+	received := make(chan map[string]interface{}, 10)
+	var secret string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if err := webhook.Verify(r.Header, secret, body, webhook.DefaultTolerance); err != nil {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		assert.Equal(t, "user.login", r.Header.Get(webhook.HeaderEvent))
+		assert.NotEmpty(t, r.Header.Get(webhook.HeaderID))
+
+		var payload map[string]interface{}
+		require.NoError(t, json.Unmarshal(body, &payload))
+		received <- payload
+	}))
+	defer server.Close()
+
+	repo := newMemoryWebhookRepo()
+	uc := newTestWebhookUsecase(t, repo)
+
+	ep, err := uc.CreateEndpoint(context.Background(), &WebhookEndpoint{
+		URL:        server.URL,
+		EventTypes: []string{string(plugin.EventUserLogin)},
+		Enabled:    true,
+	})
+	require.NoError(t, err)
+	secret = ep.Secret
+	assert.NotEmpty(t, secret)
+
+	require.NoError(t, uc.events.Publish(context.Background(), plugin.NewEvent(plugin.EventUserLogout, "auth", nil)))
+	login := plugin.NewEvent(plugin.EventUserLogin, "auth", map[string]interface{}{"username": "alice"})
+	require.NoError(t, uc.events.Publish(context.Background(), login))
+
+	select {
+	case payload := <-received:
+		assert.Equal(t, login.GetID(), payload["id"])
+		assert.Equal(t, "user.login", payload["type"])
+		assert.Equal(t, "alice", payload["data"].(map[string]interface{})["username"])
+	case <-time.After(2 * time.Second):
+		t.Fatal("webhook was not delivered")
+	}
+
+	require.Eventually(t, func() bool {
+		deliveries, _ := uc.ListDeliveries(context.Background(), ep.ID, 0)
+		return len(deliveries) == 1 && deliveries[0].Success && deliveries[0].StatusCode == http.StatusOK
+	}, time.Second, 10*time.Millisecond)
+}
+
+// 测试投递失败 - 重试后成功，幂等键不变
+func TestWebhookUsecase_Retry(t *testing.T) {
+	var mu sync.Mutex
+	var keys []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		keys = append(keys, r.Header.Get(webhook.HeaderID))
+		if len(keys) < 2 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer server.Close()
+
+	repo := newMemoryWebhookRepo()
+	uc := newTestWebhookUsecase(t, repo)
+
+	ep, err := uc.CreateEndpoint(context.Background(), &WebhookEndpoint{
+		URL:        server.URL,
+		EventTypes: []string{string(plugin.EventDataCreated)},
+		Enabled:    true,
+	})
+	require.NoError(t, err)
+
+	require.NoError(t, uc.events.Publish(context.Background(), plugin.NewEvent(plugin.EventDataCreated, "data", nil)))
+
+	require.Eventually(t, func() bool {
+		deliveries, _ := uc.ListDeliveries(context.Background(), ep.ID, 0)
+		return len(deliveries) == 2 && deliveries[0].Success
+	}, 2*time.Second, 10*time.Millisecond)
+
+	deliveries, _ := uc.ListDeliveries(context.Background(), ep.ID, 0)
+	assert.Equal(t, 2, deliveries[0].Attempt)
+	assert.Equal(t, http.StatusServiceUnavailable, deliveries[1].StatusCode)
+
+	mu.Lock()
+	assert.Equal(t, keys[0], keys[1])
+	mu.Unlock()
+
+	stored, err := uc.GetEndpoint(context.Background(), ep.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 0, stored.FailureCount)
+}
+
+// 测试连续失败 - 达到阈值后自动停用，重新启用后恢复投递
+func TestWebhookUsecase_AutoDisable(t *testing.T) {
+	var mu sync.Mutex
+	healthy := false
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		if !healthy {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+	defer server.Close()
+
+	repo := newMemoryWebhookRepo()
+	uc := newTestWebhookUsecase(t, repo)
+
+	ep, err := uc.CreateEndpoint(context.Background(), &WebhookEndpoint{
+		URL:        server.URL,
+		EventTypes: []string{string(plugin.EventUserLogin)},
+		Enabled:    true,
+	})
+	require.NoError(t, err)
+
+	require.NoError(t, uc.events.Publish(context.Background(), plugin.NewEvent(plugin.EventUserLogin, "auth", nil)))
+
+	require.Eventually(t, func() bool {
+		stored, _ := uc.GetEndpoint(context.Background(), ep.ID)
+		return !stored.Enabled
+	}, 2*time.Second, 10*time.Millisecond)
+
+	stored, _ := uc.GetEndpoint(context.Background(), ep.ID)
+	assert.Equal(t, 3, stored.FailureCount)
+	assert.Contains(t, stored.DisabledReason, "unexpected status 500")
+
+	// 停用后不再投递
+	require.NoError(t, uc.events.Publish(context.Background(), plugin.NewEvent(plugin.EventUserLogin, "auth", nil)))
+	time.Sleep(50 * time.Millisecond)
+	deliveries, _ := uc.ListDeliveries(context.Background(), ep.ID, 0)
+	assert.Len(t, deliveries, 3)
+
+	mu.Lock()
+	healthy = true
+	mu.Unlock()
+
+	enabled, err := uc.EnableEndpoint(context.Background(), ep.ID)
+	require.NoError(t, err)
+	assert.True(t, enabled.Enabled)
+	assert.Empty(t, enabled.DisabledReason)
+
+	require.NoError(t, uc.events.Publish(context.Background(), plugin.NewEvent(plugin.EventUserLogin, "auth", nil)))
+	require.Eventually(t, func() bool {
+		deliveries, _ := uc.ListDeliveries(context.Background(), ep.ID, 0)
+		return len(deliveries) == 4 && deliveries[0].Success
+	}, 2*time.Second, 10*time.Millisecond)
+}
+
+// 测试端点校验
+func TestWebhookUsecase_Validate(t *testing.T) {
+	uc := newTestWebhookUsecase(t, newMemoryWebhookRepo())
+
+	_, err := uc.CreateEndpoint(context.Background(), &WebhookEndpoint{URL: "ftp://example.com", EventTypes: []string{"user.login"}})
+	assert.ErrorIs(t, err, ErrWebhookInvalid)
+
+	_, err = uc.CreateEndpoint(context.Background(), &WebhookEndpoint{URL: "https://example.com"})
+	assert.ErrorIs(t, err, ErrWebhookInvalid)
+
+	_, err = uc.UpdateEndpoint(context.Background(), &WebhookEndpoint{ID: 42, URL: "https://example.com", EventTypes: []string{"user.login"}})
+	assert.ErrorIs(t, err, ErrWebhookNotFound)
+}
+
+// 测试默认配置拒绝指向内部网络的回调地址，域名解析到内部地址时投递失败且不跟随重定向
+func TestWebhookUsecase_RejectsPrivateDestinations(t *testing.T) {
+	hit := make(chan struct{}, 10)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hit <- struct{}{}
+	}))
+	defer server.Close()
+
+	repo := newMemoryWebhookRepo()
+	bus := plugin.NewEventBusWithConfig(plugin.EventBusConfig{PollInterval: 10 * time.Millisecond})
+	defer bus.Close()
+	uc := NewWebhookUsecaseWithConfig(repo, bus, WebhookConfig{Timeout: time.Second, Backoff: 10 * time.Millisecond}, log.NewStdLogger(os.Stdout))
+
+	for _, u := range []string{server.URL, "http://localhost/hooks", "http://169.254.169.254/latest/meta-data", "http://[::1]:8080", "http://10.0.0.1/hooks"} {
+		_, err := uc.CreateEndpoint(context.Background(), &WebhookEndpoint{URL: u, EventTypes: []string{"user.login"}})
+		assert.ErrorIs(t, err, ErrWebhookInvalid, u)
+	}
+
+	// 已保存的端点域名在投递时解析到回环地址
+	ep := &WebhookEndpoint{URL: strings.Replace(server.URL, "127.0.0.1", "localhost", 1), Secret: "s", EventTypes: []string{"user.login"}, Enabled: true}
+	require.NoError(t, repo.CreateEndpoint(context.Background(), ep))
+	require.NoError(t, uc.subscribe(ep))
+	err := uc.deliver(context.Background(), ep.ID, plugin.NewEvent(plugin.EventUserLogin, "auth", nil))
+	assert.ErrorIs(t, err, webhook.ErrDestinationNotAllowed)
+	assert.Empty(t, hit)
+}
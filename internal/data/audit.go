@@ -0,0 +1,172 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"kratos-boilerplate/internal/biz"
+	"kratos-boilerplate/internal/pkg/audit"
+
+	"github.com/go-kratos/kratos/v2/log"
+)
+
+// chainColumns 哈希链校验读取的操作日志列
+const chainColumns = `id, user_id, username, operation, target, content, result, created_at, seq, prev_hash, hash`
+
+type auditChainRepo struct {
+	data *Data
+	log  *log.Helper
+}
+
+// NewAuditChainRepo 创建操作日志哈希链仓储
+func NewAuditChainRepo(data *Data, logger log.Logger) biz.AuditChainRepo {
+	return &auditChainRepo{
+		data: data,
+		log:  log.NewHelper(log.With(logger, "module", "data.audit")),
+	}
+}
+
+func (r *auditChainRepo) db() (*sql.DB, error) {
+	if r.data == nil || r.data.db == nil {
+		return nil, errDatabaseNotConfigured
+	}
+	return r.data.db, nil
+}
+
+// ChainHead 返回链上序号最大的记录
+func (r *auditChainRepo) ChainHead(ctx context.Context) (*biz.OperationLog, error) {
+	logs, err := r.queryChain(ctx, `SELECT `+chainColumns+` FROM operation_logs WHERE seq IS NOT NULL ORDER BY seq DESC LIMIT 1`)
+	if err != nil || len(logs) == 0 {
+		return nil, err
+	}
+	return logs[0], nil
+}
+
+// ChainRange 返回时间范围内已入链记录的序号范围
+func (r *auditChainRepo) ChainRange(ctx context.Context, start, end time.Time) (int64, int64, error) {
+	db, err := r.db()
+	if err != nil {
+		return 0, 0, err
+	}
+	var minSeq, maxSeq sql.NullInt64
+	query := `SELECT MIN(seq), MAX(seq) FROM operation_logs WHERE seq IS NOT NULL AND created_at >= $1 AND created_at < $2`
+	if err := db.QueryRowContext(ctx, query, start, end).Scan(&minSeq, &maxSeq); err != nil {
+		return 0, 0, fmt.Errorf("failed to query operation log chain range: %w", err)
+	}
+	return minSeq.Int64, maxSeq.Int64, nil
+}
+
+// ListChain 按序号升序读取链上记录
+func (r *auditChainRepo) ListChain(ctx context.Context, fromSeq, toSeq int64, limit int) ([]*biz.OperationLog, error) {
+	query := `SELECT ` + chainColumns + ` FROM operation_logs WHERE seq >= $1 AND seq <= $2 ORDER BY seq, id LIMIT $3`
+	return r.queryChain(ctx, query, fromSeq, toSeq, limit)
+}
+
+func (r *auditChainRepo) queryChain(ctx context.Context, query string, args ...interface{}) ([]*biz.OperationLog, error) {
+	db, err := r.db()
+	if err != nil {
+		return nil, err
+	}
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query operation log chain: %w", err)
+	}
+	defer rows.Close()
+
+	var logs []*biz.OperationLog
+	for rows.Next() {
+		l := &biz.OperationLog{}
+		if err := rows.Scan(&l.ID, &l.UserID, &l.Username, &l.Operation, &l.Target, &l.Content, &l.Result, &l.CreatedAt,
+			&l.Seq, &l.PrevHash, &l.Hash); err != nil {
+			return nil, err
+		}
+		logs = append(logs, l)
+	}
+	return logs, rows.Err()
+}
+
+// SaveCheckpoint 保存检查点并回填 ID
+func (r *auditChainRepo) SaveCheckpoint(ctx context.Context, cp *audit.Checkpoint) error {
+	db, err := r.db()
+	if err != nil {
+		return err
+	}
+	query := `INSERT INTO audit_checkpoints (seq, hash, signature, created_at) VALUES ($1, $2, $3, $4) RETURNING id`
+	if err := db.QueryRowContext(ctx, query, cp.Seq, cp.Hash, cp.Signature, cp.CreatedAt).Scan(&cp.ID); err != nil {
+		return fmt.Errorf("failed to save audit checkpoint: %w", err)
+	}
+	return nil
+}
+
+// LatestCheckpoint 返回序号最大的检查点
+func (r *auditChainRepo) LatestCheckpoint(ctx context.Context) (*audit.Checkpoint, error) {
+	cps, err := r.queryCheckpoints(ctx, `SELECT id, seq, hash, signature, created_at FROM audit_checkpoints ORDER BY seq DESC, id DESC LIMIT 1`)
+	if err != nil || len(cps) == 0 {
+		return nil, err
+	}
+	return cps[0], nil
+}
+
+// ListCheckpoints 按序号升序返回范围内的检查点
+func (r *auditChainRepo) ListCheckpoints(ctx context.Context, fromSeq, toSeq int64) ([]*audit.Checkpoint, error) {
+	query := `SELECT id, seq, hash, signature, created_at FROM audit_checkpoints WHERE seq >= $1 AND seq <= $2 ORDER BY seq, id`
+	return r.queryCheckpoints(ctx, query, fromSeq, toSeq)
+}
+
+// NextCheckpoint 返回序号不小于 seq 的第一个检查点
+func (r *auditChainRepo) NextCheckpoint(ctx context.Context, seq int64) (*audit.Checkpoint, error) {
+	query := `SELECT id, seq, hash, signature, created_at FROM audit_checkpoints WHERE seq >= $1 ORDER BY seq, id LIMIT 1`
+	cps, err := r.queryCheckpoints(ctx, query, seq)
+	if err != nil || len(cps) == 0 {
+		return nil, err
+	}
+	return cps[0], nil
+}
+
+// ArchivedRanges 返回与 [fromSeq, toSeq] 相交的已归档分区的序号范围
+func (r *auditChainRepo) ArchivedRanges(ctx context.Context, fromSeq, toSeq int64) ([]biz.AuditSeqRange, error) {
+	db, err := r.db()
+	if err != nil {
+		return nil, err
+	}
+	query := `SELECT min_seq, max_seq FROM operation_log_archives WHERE min_seq IS NOT NULL AND min_seq <= $2 AND max_seq >= $1 ORDER BY min_seq`
+	rows, err := db.QueryContext(ctx, query, fromSeq, toSeq)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query archived operation log seq ranges: %w", err)
+	}
+	defer rows.Close()
+
+	var ranges []biz.AuditSeqRange
+	for rows.Next() {
+		var sr biz.AuditSeqRange
+		if err := rows.Scan(&sr.MinSeq, &sr.MaxSeq); err != nil {
+			return nil, err
+		}
+		ranges = append(ranges, sr)
+	}
+	return ranges, rows.Err()
+}
+
+func (r *auditChainRepo) queryCheckpoints(ctx context.Context, query string, args ...interface{}) ([]*audit.Checkpoint, error) {
+	db, err := r.db()
+	if err != nil {
+		return nil, err
+	}
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query audit checkpoints: %w", err)
+	}
+	defer rows.Close()
+
+	var cps []*audit.Checkpoint
+	for rows.Next() {
+		cp := &audit.Checkpoint{}
+		if err := rows.Scan(&cp.ID, &cp.Seq, &cp.Hash, &cp.Signature, &cp.CreatedAt); err != nil {
+			return nil, err
+		}
+		cps = append(cps, cp)
+	}
+	return cps, rows.Err()
+}
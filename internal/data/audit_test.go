@@ -0,0 +1,97 @@
+package data
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"kratos-boilerplate/internal/biz"
+	"kratos-boilerplate/internal/pkg/audit"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/go-kratos/kratos/v2/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// 测试ChainRange - 范围内没有记录时返回 0
+func TestAuditChainRepo_ChainRange(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := NewAuditChainRepo(&Data{db: db}, log.NewStdLogger(os.Stdout))
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	end := start.AddDate(0, 1, 0)
+
+	mock.ExpectQuery(`SELECT MIN\(seq\), MAX\(seq\) FROM operation_logs`).WithArgs(start, end).
+		WillReturnRows(sqlmock.NewRows([]string{"min", "max"}).AddRow(3, 42))
+	mock.ExpectQuery(`SELECT MIN\(seq\), MAX\(seq\) FROM operation_logs`).WithArgs(start, end).
+		WillReturnRows(sqlmock.NewRows([]string{"min", "max"}).AddRow(nil, nil))
+
+	minSeq, maxSeq, err := repo.ChainRange(context.Background(), start, end)
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), minSeq)
+	assert.Equal(t, int64(42), maxSeq)
+
+	minSeq, maxSeq, err = repo.ChainRange(context.Background(), start, end)
+	require.NoError(t, err)
+	assert.Zero(t, minSeq)
+	assert.Zero(t, maxSeq)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+// 测试ListChain与检查点读写
+func TestAuditChainRepo_ChainAndCheckpoints(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := NewAuditChainRepo(&Data{db: db}, log.NewStdLogger(os.Stdout))
+	ctx := context.Background()
+	at := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	mock.ExpectQuery(`SELECT .+ FROM operation_logs WHERE seq >= \$1 AND seq <= \$2 ORDER BY seq, id LIMIT \$3`).
+		WithArgs(int64(1), int64(10), 1000).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "username", "operation", "target", "content", "result", "created_at", "seq", "prev_hash", "hash"}).
+			AddRow(5, 7, "alice", "GET /x", "/x", "", "success", at, 1, "", "sha256:aa").
+			AddRow(6, 7, "alice", "GET /y", "/y", "", "success", at, 2, "sha256:aa", "sha256:bb"))
+	logs, err := repo.ListChain(ctx, 1, 10, 1000)
+	require.NoError(t, err)
+	require.Len(t, logs, 2)
+	assert.Equal(t, int64(2), logs[1].Seq)
+	assert.Equal(t, "sha256:aa", logs[1].PrevHash)
+
+	cp := &audit.Checkpoint{Seq: 2, Hash: "sha256:bb", Signature: "c2ln", CreatedAt: at}
+	mock.ExpectQuery(`INSERT INTO audit_checkpoints \(seq, hash, signature, created_at\)`).
+		WithArgs(int64(2), "sha256:bb", "c2ln", at).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(9))
+	require.NoError(t, repo.SaveCheckpoint(ctx, cp))
+	assert.Equal(t, int64(9), cp.ID)
+
+	mock.ExpectQuery(`SELECT id, seq, hash, signature, created_at FROM audit_checkpoints WHERE seq >= \$1 ORDER BY seq, id LIMIT 1`).
+		WithArgs(int64(3)).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "seq", "hash", "signature", "created_at"}))
+	next, err := repo.NextCheckpoint(ctx, 3)
+	require.NoError(t, err)
+	assert.Nil(t, next)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+// 测试ArchivedRanges - 返回与校验范围相交的归档序号范围
+func TestAuditChainRepo_ArchivedRanges(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := NewAuditChainRepo(&Data{db: db}, log.NewStdLogger(os.Stdout))
+	mock.ExpectQuery(`SELECT min_seq, max_seq FROM operation_log_archives WHERE min_seq IS NOT NULL AND min_seq <= \$2 AND max_seq >= \$1 ORDER BY min_seq`).
+		WithArgs(int64(50), int64(300)).
+		WillReturnRows(sqlmock.NewRows([]string{"min_seq", "max_seq"}).AddRow(1, 120).AddRow(121, 240))
+
+	ranges, err := repo.ArchivedRanges(context.Background(), 50, 300)
+	require.NoError(t, err)
+	assert.Equal(t, []biz.AuditSeqRange{{MinSeq: 1, MaxSeq: 120}, {MinSeq: 121, MaxSeq: 240}}, ranges)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
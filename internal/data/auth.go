@@ -5,17 +5,23 @@ import (
 	"database/sql"
 	"encoding/hex"
 	"fmt"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
 
+	pluginv1 "kratos-boilerplate/api/plugin/v1"
 	"kratos-boilerplate/internal/biz"
 	"kratos-boilerplate/internal/pkg/crypto"
 	"kratos-boilerplate/internal/pkg/kms"
+	"kratos-boilerplate/internal/pkg/plugin"
 
 	"github.com/go-kratos/kratos/v2/log"
 )
 
+// userAggregate 用户事件在发件箱中的业务对象类型
+const userAggregate = "user"
+
 type userRepo struct {
 	data *Data
 	log  *log.Helper
@@ -47,7 +53,7 @@ func NewUserRepo(data *Data, logger log.Logger, kmsManager kms.KMSManager) (biz.
 
 	return &userRepo{
 		data: data,
-		log:  log.NewHelper(logger),
+		log:  log.NewHelper(log.With(logger, "module", "data.auth")),
 		enc:  enc,
 		kms:  kmsManager,
 	}, nil
@@ -134,14 +140,24 @@ func (r *userRepo) CreateUser(ctx context.Context, u *biz.User) error {
 		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
 		RETURNING id
 	`
-	err = r.data.db.QueryRowContext(ctx, query,
-		u.Username, u.Password,
-		emailEnc, emailHash,
-		phoneEnc, phoneHash,
-		nameEnc, nameHash,
-		time.Now(), time.Now(),
-	).Scan(&u.ID)
-	return err
+	// 用户与注册事件在同一事务中写入
+	return r.data.Transaction(ctx, func(ctx context.Context) error {
+		err := r.data.conn(ctx).QueryRowContext(ctx, query,
+			u.Username, u.Password,
+			emailEnc, emailHash,
+			phoneEnc, phoneHash,
+			nameEnc, nameHash,
+			time.Now(), time.Now(),
+		).Scan(&u.ID)
+		if err != nil {
+			return err
+		}
+		event, err := plugin.NewTypedEvent(plugin.EventUserRegister, "auth", &pluginv1.UserRegisterEvent{UserId: u.ID, Username: u.Username})
+		if err != nil {
+			return err
+		}
+		return r.data.SaveOutboxEvent(ctx, userAggregate, strconv.FormatInt(u.ID, 10), event.Event)
+	})
 }
 
 func (r *userRepo) GetUser(ctx context.Context, username string) (*biz.User, error) {
@@ -301,14 +317,23 @@ func (r *userRepo) UpdateUser(ctx context.Context, u *biz.User) error {
 			updated_at = $7
 		WHERE id = $8
 	`
-	_, err = r.data.db.ExecContext(ctx, query,
-		emailEnc, emailHash,
-		phoneEnc, phoneHash,
-		nameEnc, nameHash,
-		time.Now(),
-		u.ID,
-	)
-	return err
+	return r.data.Transaction(ctx, func(ctx context.Context) error {
+		_, err := r.data.conn(ctx).ExecContext(ctx, query,
+			emailEnc, emailHash,
+			phoneEnc, phoneHash,
+			nameEnc, nameHash,
+			time.Now(),
+			u.ID,
+		)
+		if err != nil {
+			return err
+		}
+		return r.data.SaveOutboxEvent(ctx, userAggregate, strconv.FormatInt(u.ID, 10), plugin.NewEvent(
+			plugin.EventUserUpdated, "auth", map[string]interface{}{
+				"user_id":  u.ID,
+				"username": u.Username,
+			}))
+	})
 }
 
 // 验证码相关方法
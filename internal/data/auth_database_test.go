@@ -64,7 +64,8 @@ func TestUserDatabaseOperations(t *testing.T) {
 			Name:     "测试用户",
 		}
 
-		// 设置mock期望 - 使用ExpectQuery因为CreateUser使用QueryRowContext
+		// 设置mock期望 - 用户与注册事件在同一事务中写入
+		mock.ExpectBegin()
 		mock.ExpectQuery("INSERT INTO users").
 			WithArgs(
 				sqlmock.AnyArg(), sqlmock.AnyArg(),
@@ -74,6 +75,11 @@ func TestUserDatabaseOperations(t *testing.T) {
 				sqlmock.AnyArg(), sqlmock.AnyArg(),
 			).
 			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+		mock.ExpectExec("INSERT INTO event_outbox").
+			WithArgs(sqlmock.AnyArg(), "user", "1", "user.register", "auth",
+				sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg()).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectCommit()
 
 		// 执行测试
 		err := userRepo.CreateUser(ctx, user)
@@ -208,6 +214,7 @@ func TestUserDatabaseOperations(t *testing.T) {
 		}
 
 		// 设置mock期望 - 8个参数：emailEnc, emailHash, phoneEnc, phoneHash, nameEnc, nameHash, time.Now(), u.ID
+		mock.ExpectBegin()
 		mock.ExpectExec("UPDATE users").
 			WithArgs(
 				sqlmock.AnyArg(), sqlmock.AnyArg(), // emailEnc, emailHash
@@ -216,6 +223,11 @@ func TestUserDatabaseOperations(t *testing.T) {
 				sqlmock.AnyArg(), user.ID, // time.Now(), u.ID
 			).
 			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec("INSERT INTO event_outbox").
+			WithArgs(sqlmock.AnyArg(), "user", "1", "user.updated", "auth",
+				sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg()).
+			WillReturnResult(sqlmock.NewResult(2, 1))
+		mock.ExpectCommit()
 
 		// 执行测试
 		err := userRepo.UpdateUser(ctx, user)
@@ -253,7 +265,8 @@ func TestUserDatabaseOperations(t *testing.T) {
 			Name:     "重复用户",
 		}
 		expectedErr := fmt.Errorf("duplicate key value violates unique constraint")
-		// 设置mock期望 - 重复用户名错误，使用ExpectQuery因为CreateUser使用QueryRowContext
+		// 设置mock期望 - 重复用户名错误，事务回滚且不写入注册事件
+		mock.ExpectBegin()
 		mock.ExpectQuery("INSERT INTO users").
 			WithArgs(
 				sqlmock.AnyArg(), sqlmock.AnyArg(),
@@ -263,6 +276,7 @@ func TestUserDatabaseOperations(t *testing.T) {
 				sqlmock.AnyArg(), sqlmock.AnyArg(),
 			).
 			WillReturnError(expectedErr)
+		mock.ExpectRollback()
 		// 执行测试
 		err := userRepo.CreateUser(ctx, user)
 		assert.Error(t, err)
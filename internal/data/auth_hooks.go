@@ -0,0 +1,155 @@
+package data
+
+import (
+	"context"
+	"time"
+
+	"kratos-boilerplate/internal/biz"
+	"kratos-boilerplate/internal/pkg/kms"
+	"kratos-boilerplate/internal/pkg/plugin"
+
+	"github.com/go-kratos/kratos/v2/log"
+)
+
+// NewUserRepoWithHooks 创建在每次数据访问前后执行 before_data、after_data 与 data_error 钩子的用户仓储
+func NewUserRepoWithHooks(data *Data, logger log.Logger, kmsManager kms.KMSManager, hooks plugin.HookManager) (biz.UserRepo, error) {
+	repo, err := NewUserRepo(data, logger, kmsManager)
+	if err != nil || hooks == nil {
+		return repo, err
+	}
+	return &hookedUserRepo{next: repo, hooks: hooks, log: log.NewHelper(log.With(logger, "module", "data.auth"))}, nil
+}
+
+// hookedUserRepo 在用户仓储的每次调用前后执行数据访问钩子点，钩子数据不包含参数与返回值
+type hookedUserRepo struct {
+	next  biz.UserRepo
+	hooks plugin.HookManager
+	log   *log.Helper
+}
+
+// run 执行数据访问钩子，钩子失败只记录日志，before_data 中止时返回 HookAbortError
+func (r *hookedUserRepo) run(ctx context.Context, operation string, fn func(ctx context.Context) error) error {
+	return plugin.RunLayerHooks(ctx, r.hooks, plugin.DataHookPoints, "user."+operation, func(point plugin.HookPoint, err error) {
+		r.log.Warnf("执行 %s 钩子失败: %v", point, err)
+	}, fn)
+}
+
+func (r *hookedUserRepo) CreateUser(ctx context.Context, user *biz.User) error {
+	return r.run(ctx, "CreateUser", func(ctx context.Context) error {
+		return r.next.CreateUser(ctx, user)
+	})
+}
+
+func (r *hookedUserRepo) GetUser(ctx context.Context, username string) (*biz.User, error) {
+	var result *biz.User
+	err := r.run(ctx, "GetUser", func(ctx context.Context) (err error) {
+		result, err = r.next.GetUser(ctx, username)
+		return err
+	})
+	return result, err
+}
+
+func (r *hookedUserRepo) GetUserByEmail(ctx context.Context, email string) (*biz.User, error) {
+	var result *biz.User
+	err := r.run(ctx, "GetUserByEmail", func(ctx context.Context) (err error) {
+		result, err = r.next.GetUserByEmail(ctx, email)
+		return err
+	})
+	return result, err
+}
+
+func (r *hookedUserRepo) GetUserByPhone(ctx context.Context, phone string) (*biz.User, error) {
+	var result *biz.User
+	err := r.run(ctx, "GetUserByPhone", func(ctx context.Context) (err error) {
+		result, err = r.next.GetUserByPhone(ctx, phone)
+		return err
+	})
+	return result, err
+}
+
+func (r *hookedUserRepo) GetUserByName(ctx context.Context, name string) (*biz.User, error) {
+	var result *biz.User
+	err := r.run(ctx, "GetUserByName", func(ctx context.Context) (err error) {
+		result, err = r.next.GetUserByName(ctx, name)
+		return err
+	})
+	return result, err
+}
+
+func (r *hookedUserRepo) UpdateUser(ctx context.Context, user *biz.User) error {
+	return r.run(ctx, "UpdateUser", func(ctx context.Context) error {
+		return r.next.UpdateUser(ctx, user)
+	})
+}
+
+func (r *hookedUserRepo) GetLock(ctx context.Context, username string) (*biz.AccountLock, error) {
+	var result *biz.AccountLock
+	err := r.run(ctx, "GetLock", func(ctx context.Context) (err error) {
+		result, err = r.next.GetLock(ctx, username)
+		return err
+	})
+	return result, err
+}
+
+func (r *hookedUserRepo) SaveLock(ctx context.Context, lock *biz.AccountLock) error {
+	return r.run(ctx, "SaveLock", func(ctx context.Context) error {
+		return r.next.SaveLock(ctx, lock)
+	})
+}
+
+func (r *hookedUserRepo) RemoveLock(ctx context.Context, username string) error {
+	return r.run(ctx, "RemoveLock", func(ctx context.Context) error {
+		return r.next.RemoveLock(ctx, username)
+	})
+}
+
+func (r *hookedUserRepo) SaveRefreshToken(ctx context.Context, username, tokenID string, expiresAt time.Time) error {
+	return r.run(ctx, "SaveRefreshToken", func(ctx context.Context) error {
+		return r.next.SaveRefreshToken(ctx, username, tokenID, expiresAt)
+	})
+}
+
+func (r *hookedUserRepo) GetRefreshToken(ctx context.Context, tokenID string) (string, bool, error) {
+	var (
+		username string
+		used     bool
+	)
+	err := r.run(ctx, "GetRefreshToken", func(ctx context.Context) (err error) {
+		username, used, err = r.next.GetRefreshToken(ctx, tokenID)
+		return err
+	})
+	return username, used, err
+}
+
+func (r *hookedUserRepo) InvalidateRefreshToken(ctx context.Context, tokenID string) error {
+	return r.run(ctx, "InvalidateRefreshToken", func(ctx context.Context) error {
+		return r.next.InvalidateRefreshToken(ctx, tokenID)
+	})
+}
+
+func (r *hookedUserRepo) InvalidateAllRefreshTokens(ctx context.Context, username string) error {
+	return r.run(ctx, "InvalidateAllRefreshTokens", func(ctx context.Context) error {
+		return r.next.InvalidateAllRefreshTokens(ctx, username)
+	})
+}
+
+func (r *hookedUserRepo) SaveCaptcha(ctx context.Context, captcha *biz.Captcha) error {
+	return r.run(ctx, "SaveCaptcha", func(ctx context.Context) error {
+		return r.next.SaveCaptcha(ctx, captcha)
+	})
+}
+
+func (r *hookedUserRepo) GetCaptcha(ctx context.Context, captchaID string) (*biz.Captcha, error) {
+	var result *biz.Captcha
+	err := r.run(ctx, "GetCaptcha", func(ctx context.Context) (err error) {
+		result, err = r.next.GetCaptcha(ctx, captchaID)
+		return err
+	})
+	return result, err
+}
+
+func (r *hookedUserRepo) MarkCaptchaUsed(ctx context.Context, captchaID string) error {
+	return r.run(ctx, "MarkCaptchaUsed", func(ctx context.Context) error {
+		return r.next.MarkCaptchaUsed(ctx, captchaID)
+	})
+}
@@ -0,0 +1,66 @@
+package data
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"kratos-boilerplate/internal/biz"
+	"kratos-boilerplate/internal/pkg/plugin"
+
+	"github.com/go-kratos/kratos/v2/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// stubUserRepo 只实现 GetUser 的用户仓储
+type stubUserRepo struct {
+	biz.UserRepo
+	calls int
+}
+
+func (r *stubUserRepo) GetUser(ctx context.Context, username string) (*biz.User, error) {
+	r.calls++
+	if username == "missing" {
+		return nil, biz.ErrUserNotFound
+	}
+	return &biz.User{ID: 1, Username: username}, nil
+}
+
+// TestHookedUserRepo 测试数据访问钩子点的执行顺序与 before_data 中止
+func TestHookedUserRepo(t *testing.T) {
+	hooks := plugin.NewHookManager()
+	var fired []string
+	abort := false
+	for _, point := range []plugin.HookPoint{plugin.HookPointBeforeData, plugin.HookPointAfterData, plugin.HookPointDataError} {
+		point := point
+		require.NoError(t, hooks.RegisterHook(point, plugin.NewBaseHook("record_"+string(point), 10, time.Second,
+			func(ctx context.Context, data plugin.HookData) error {
+				fired = append(fired, string(point)+" "+data.GetData()["operation"].(string))
+				if abort && point == plugin.HookPointBeforeData {
+					return plugin.NewHookAbortError(0, "MAINTENANCE", "maintenance")
+				}
+				return nil
+			})))
+	}
+
+	next := &stubUserRepo{}
+	repo := &hookedUserRepo{next: next, hooks: hooks, log: log.NewHelper(log.NewStdLogger(os.Stdout))}
+
+	user, err := repo.GetUser(context.Background(), "alice")
+	require.NoError(t, err)
+	assert.Equal(t, "alice", user.Username)
+	_, err = repo.GetUser(context.Background(), "missing")
+	assert.ErrorIs(t, err, biz.ErrUserNotFound)
+	assert.Equal(t, []string{
+		"before_data user.GetUser", "after_data user.GetUser",
+		"before_data user.GetUser", "data_error user.GetUser",
+	}, fired)
+
+	abort = true
+	_, err = repo.GetUser(context.Background(), "alice")
+	_, ok := plugin.AsHookAbort(err)
+	assert.True(t, ok)
+	assert.Equal(t, 2, next.calls)
+}
@@ -17,7 +17,7 @@ import (
 )
 
 // ProviderSet is data providers.
-var ProviderSet = wire.NewSet(NewData, NewGreeterRepo, NewUserRepo, NewOperationLogRepo, NewCaptchaRepo, captcha.NewCaptchaService, NewCaptchaConfig, NewKMSRepo, NewKMSManager)
+var ProviderSet = wire.NewSet(NewData, NewGreeterRepo, NewUserRepoWithHooks, NewOperationLogRepoWithChain, NewAuditChainRepo, NewOperationLogArchiveRepo, NewCaptchaRepo, captcha.NewCaptchaService, NewCaptchaConfig, NewKMSRepo, NewKMSManager, NewEventStore, NewOutboxRelay, NewWebhookRepo, NewPseudonymVault)
 
 // Data .
 type Data struct {
@@ -75,6 +75,47 @@ func NewCaptchaConfig(auth *conf.Auth) *captcha.Config {
 	}
 }
 
+// txKey 上下文中数据库事务的键
+type txKey struct{}
+
+// dbConn 数据库连接与事务共有的操作
+type dbConn interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+// Transaction 在一个数据库事务中执行 fn，fn 返回错误时回滚
+// fn 内的仓储方法通过 conn(ctx) 使用同一事务；嵌套调用时加入外层事务
+func (d *Data) Transaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	if _, ok := ctx.Value(txKey{}).(*sql.Tx); ok {
+		return fn(ctx)
+	}
+
+	tx, err := d.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	if err := fn(context.WithValue(ctx, txKey{}, tx)); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+// conn 返回上下文中的事务，不在事务中时返回数据库连接
+func (d *Data) conn(ctx context.Context) dbConn {
+	if tx, ok := ctx.Value(txKey{}).(*sql.Tx); ok {
+		return tx
+	}
+	return d.db
+}
+
+// configured 是否配置了数据库，未配置时 NewData 返回的 Data 为 nil
+func (d *Data) configured() bool {
+	return d != nil && d.db != nil
+}
+
 // GetDB 获取数据库连接
 func (d *Data) GetDB() *sql.DB {
 	return d.db
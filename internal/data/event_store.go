@@ -0,0 +1,199 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"kratos-boilerplate/internal/pkg/plugin"
+
+	"github.com/go-kratos/kratos/v2/log"
+)
+
+// eventDeliveryColumns event_deliveries 表的查询列，与 scanDeliveryRecords 的顺序一致
+const eventDeliveryColumns = `id, event_id, event_type, source, event_time, data, metadata, subscription,
+	status, attempts, last_error, next_attempt_at, created_at, updated_at`
+
+// eventStore 基于 Postgres 的事件投递队列
+type eventStore struct {
+	data *Data
+	log  *log.Helper
+}
+
+// NewEventStore 创建事件投递队列，未配置数据库时使用内存队列
+func NewEventStore(data *Data, logger log.Logger) plugin.EventStore {
+	if data == nil || data.db == nil {
+		log.NewHelper(logger).Warn("database is not configured, event deliveries are kept in memory")
+		return plugin.NewMemoryEventStore()
+	}
+	return &eventStore{
+		data: data,
+		log:  log.NewHelper(log.With(logger, "module", "data.event_store")),
+	}
+}
+
+// Enqueue 写入投递记录，ID 已存在的记录被忽略
+func (s *eventStore) Enqueue(ctx context.Context, records []*plugin.DeliveryRecord) error {
+	tx, err := s.data.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	query := `
+		INSERT INTO event_deliveries (id, event_id, event_type, source, event_time, data, metadata, subscription,
+			status, attempts, last_error, next_attempt_at, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14)
+		ON CONFLICT (id) DO NOTHING
+	`
+	for _, r := range records {
+		data, err := json.Marshal(r.Data)
+		if err != nil {
+			return fmt.Errorf("failed to encode event data: %w", err)
+		}
+		metadata, err := json.Marshal(r.Metadata)
+		if err != nil {
+			return fmt.Errorf("failed to encode event metadata: %w", err)
+		}
+		if _, err := tx.ExecContext(ctx, query,
+			r.ID, r.EventID, string(r.EventType), r.Source, r.Timestamp, data, metadata, r.Subscription,
+			string(r.Status), r.Attempts, r.LastError, r.NextAttemptAt, r.CreatedAt, r.UpdatedAt,
+		); err != nil {
+			return fmt.Errorf("failed to enqueue delivery %s: %w", r.ID, err)
+		}
+	}
+
+	return tx.Commit()
+}
+
+// Claim 领取到期的待投递记录，SKIP LOCKED 保证多个实例不会领取同一条记录
+func (s *eventStore) Claim(ctx context.Context, consumer string, limit int, lease time.Duration) ([]*plugin.DeliveryRecord, error) {
+	now := time.Now()
+	query := `
+		UPDATE event_deliveries SET locked_by = $1, locked_until = $2
+		WHERE id IN (
+			SELECT id FROM event_deliveries
+			WHERE status = 'pending' AND next_attempt_at <= $3 AND (locked_until IS NULL OR locked_until <= $3)
+			ORDER BY next_attempt_at
+			LIMIT $4
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING ` + eventDeliveryColumns
+
+	rows, err := s.data.db.QueryContext(ctx, query, consumer, now.Add(lease), now, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim deliveries: %w", err)
+	}
+	defer rows.Close()
+
+	return scanDeliveryRecords(rows)
+}
+
+// Ack 投递成功，删除记录
+func (s *eventStore) Ack(ctx context.Context, id string) error {
+	_, err := s.data.db.ExecContext(ctx, `DELETE FROM event_deliveries WHERE id = $1`, id)
+	return err
+}
+
+// Retry 记录尝试次数并安排下一次投递
+func (s *eventStore) Retry(ctx context.Context, id string, attempts int, next time.Time, lastErr string) error {
+	query := `
+		UPDATE event_deliveries
+		SET attempts = $2, next_attempt_at = $3, last_error = $4, locked_by = NULL, locked_until = NULL, updated_at = $5
+		WHERE id = $1
+	`
+	return s.update(ctx, query, id, attempts, next, lastErr, time.Now())
+}
+
+// DeadLetter 移入死信
+func (s *eventStore) DeadLetter(ctx context.Context, id string, attempts int, lastErr string) error {
+	query := `
+		UPDATE event_deliveries
+		SET status = 'dead', attempts = $2, last_error = $3, locked_by = NULL, locked_until = NULL, updated_at = $4
+		WHERE id = $1
+	`
+	return s.update(ctx, query, id, attempts, lastErr, time.Now())
+}
+
+// ListDeadLetters 查询死信，按移入时间倒序
+func (s *eventStore) ListDeadLetters(ctx context.Context, query plugin.DeadLetterQuery) ([]*plugin.DeliveryRecord, error) {
+	sqlQuery := `
+		SELECT ` + eventDeliveryColumns + `
+		FROM event_deliveries
+		WHERE status = 'dead' AND ($1 = '' OR event_type = $1) AND ($2 = '' OR subscription = $2)
+		ORDER BY updated_at DESC
+	`
+	args := []interface{}{string(query.EventType), query.Subscription}
+	if query.Limit > 0 {
+		sqlQuery += ` LIMIT $3`
+		args = append(args, query.Limit)
+	}
+
+	rows, err := s.data.db.QueryContext(ctx, sqlQuery, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list dead letters: %w", err)
+	}
+	defer rows.Close()
+
+	return scanDeliveryRecords(rows)
+}
+
+// Replay 将死信放回队列，尝试次数清零
+func (s *eventStore) Replay(ctx context.Context, id string) error {
+	now := time.Now()
+	query := `
+		UPDATE event_deliveries
+		SET status = 'pending', attempts = 0, next_attempt_at = $2, updated_at = $2
+		WHERE id = $1 AND status = 'dead'
+	`
+	return s.update(ctx, query, id, now)
+}
+
+// update 执行单条记录更新，记录不存在时返回 plugin.ErrDeliveryNotFound
+func (s *eventStore) update(ctx context.Context, query string, id string, args ...interface{}) error {
+	result, err := s.data.db.ExecContext(ctx, query, append([]interface{}{id}, args...)...)
+	if err != nil {
+		s.log.Errorf("failed to update delivery %s: %v", id, err)
+		return fmt.Errorf("failed to update delivery: %w", err)
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return plugin.ErrDeliveryNotFound
+	}
+	return nil
+}
+
+// scanDeliveryRecords 读取 eventDeliveryColumns 查询结果
+func scanDeliveryRecords(rows *sql.Rows) ([]*plugin.DeliveryRecord, error) {
+	var records []*plugin.DeliveryRecord
+	for rows.Next() {
+		r := &plugin.DeliveryRecord{}
+		var eventType, status string
+		var data, metadata []byte
+		err := rows.Scan(&r.ID, &r.EventID, &eventType, &r.Source, &r.Timestamp, &data, &metadata, &r.Subscription,
+			&status, &r.Attempts, &r.LastError, &r.NextAttemptAt, &r.CreatedAt, &r.UpdatedAt)
+		if err != nil {
+			return nil, err
+		}
+		r.EventType = plugin.EventType(eventType)
+		r.Status = plugin.DeliveryStatus(status)
+		// 事件数据以 JSON 保存，数值还原为 float64
+		if len(data) > 0 {
+			if err := json.Unmarshal(data, &r.Data); err != nil {
+				return nil, fmt.Errorf("failed to decode event data of %s: %w", r.ID, err)
+			}
+		}
+		if len(metadata) > 0 {
+			if err := json.Unmarshal(metadata, &r.Metadata); err != nil {
+				return nil, fmt.Errorf("failed to decode event metadata of %s: %w", r.ID, err)
+			}
+		}
+		records = append(records, r)
+	}
+	return records, rows.Err()
+}
@@ -0,0 +1,104 @@
+package data
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"kratos-boilerplate/internal/pkg/plugin"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/go-kratos/kratos/v2/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// 测试未配置数据库时使用内存队列
+func TestNewEventStore_Memory(t *testing.T) {
+	store := NewEventStore(nil, log.NewStdLogger(os.Stdout))
+	_, ok := store.(*eventStore)
+	assert.False(t, ok)
+}
+
+// 测试Enqueue
+func TestEventStoreEnqueue(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	store := NewEventStore(&Data{db: db}, log.NewStdLogger(os.Stdout))
+
+	now := time.Now()
+	record := &plugin.DeliveryRecord{
+		ID:            "event-1:audit",
+		EventID:       "event-1",
+		EventType:     plugin.EventUserLogin,
+		Source:        "auth",
+		Timestamp:     now,
+		Data:          map[string]interface{}{"user": "alice"},
+		Metadata:      map[string]string{},
+		Subscription:  "audit",
+		Status:        plugin.DeliveryPending,
+		NextAttemptAt: now,
+		CreatedAt:     now,
+		UpdatedAt:     now,
+	}
+
+	mock.ExpectBegin()
+	mock.ExpectExec("INSERT INTO event_deliveries").
+		WithArgs(record.ID, record.EventID, "user.login", "auth", now, []byte(`{"user":"alice"}`), []byte(`{}`), "audit",
+			"pending", 0, "", now, now, now).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	err = store.Enqueue(context.Background(), []*plugin.DeliveryRecord{record})
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+// 测试Claim
+func TestEventStoreClaim(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	store := NewEventStore(&Data{db: db}, log.NewStdLogger(os.Stdout))
+
+	now := time.Now()
+	rows := sqlmock.NewRows([]string{"id", "event_id", "event_type", "source", "event_time", "data", "metadata", "subscription",
+		"status", "attempts", "last_error", "next_attempt_at", "created_at", "updated_at"}).
+		AddRow("event-1:audit", "event-1", "user.login", "auth", now, []byte(`{"user":"alice"}`), []byte(`{"ip":"127.0.0.1"}`), "audit",
+			"pending", 1, "timeout", now, now, now)
+
+	mock.ExpectQuery("UPDATE event_deliveries SET locked_by").
+		WithArgs("consumer-1", sqlmock.AnyArg(), sqlmock.AnyArg(), 10).
+		WillReturnRows(rows)
+
+	records, err := store.Claim(context.Background(), "consumer-1", 10, time.Minute)
+	require.NoError(t, err)
+	require.Len(t, records, 1)
+	assert.Equal(t, plugin.EventUserLogin, records[0].EventType)
+	assert.Equal(t, plugin.DeliveryPending, records[0].Status)
+	assert.Equal(t, 1, records[0].Attempts)
+	assert.Equal(t, "alice", records[0].Data["user"])
+	assert.Equal(t, "127.0.0.1", records[0].Metadata["ip"])
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+// 测试Replay - 死信不存在
+func TestEventStoreReplay_NotFound(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	store := NewEventStore(&Data{db: db}, log.NewStdLogger(os.Stdout))
+
+	mock.ExpectExec("UPDATE event_deliveries").
+		WithArgs("missing", sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	err = store.Replay(context.Background(), "missing")
+	assert.ErrorIs(t, err, plugin.ErrDeliveryNotFound)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -20,7 +20,7 @@ type kmsRepo struct {
 func NewKMSRepo(data *Data, logger log.Logger) biz.KMSRepo {
 	return &kmsRepo{
 		data: data,
-		log:  log.NewHelper(logger),
+		log:  log.NewHelper(log.With(logger, "module", "data.kms")),
 	}
 }
 
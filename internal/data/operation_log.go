@@ -2,33 +2,112 @@ package data
 
 import (
 	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
 	"time"
 
 	"kratos-boilerplate/internal/biz"
+	"kratos-boilerplate/internal/pkg/audit"
 
 	"github.com/go-kratos/kratos/v2/log"
 )
 
+// operationLogChainLockKey 操作日志哈希链的 Postgres 咨询锁，保证多实例写入时链上序号连续
+const operationLogChainLockKey = 0x6f706c6f67
+
+// chainHeadQuery 读取哈希链头。链上记录所在的分区可能已全部归档，此时以归档登记的最后一条记录为链头
+const chainHeadQuery = `
+	SELECT seq, hash FROM (
+		(SELECT seq, hash FROM operation_logs WHERE seq IS NOT NULL ORDER BY seq DESC LIMIT 1)
+		UNION ALL
+		(SELECT max_seq, last_hash FROM operation_log_archives WHERE max_seq IS NOT NULL ORDER BY max_seq DESC LIMIT 1)
+	) head
+	ORDER BY seq DESC LIMIT 1
+`
+
 type operationLogRepo struct {
-	data *Data
-	log  *log.Helper
+	data  *Data
+	chain *audit.Chain
+	log   *log.Helper
 }
 
 // NewOperationLogRepo .
 func NewOperationLogRepo(data *Data, logger log.Logger) biz.OperationLogRepo {
+	chain, _ := audit.NewChain(audit.AlgorithmSHA256)
+	return NewOperationLogRepoWithChain(data, chain, logger)
+}
+
+// NewOperationLogRepoWithChain 创建按指定哈希链写入的操作日志仓储
+func NewOperationLogRepoWithChain(data *Data, chain *audit.Chain, logger log.Logger) biz.OperationLogRepo {
 	return &operationLogRepo{
-		data: data,
-		log:  log.NewHelper(logger),
+		data:  data,
+		chain: chain,
+		log:   log.NewHelper(log.With(logger, "module", "data.operation_log")),
 	}
 }
 
 func (r *operationLogRepo) CreateLog(ctx context.Context, log *biz.OperationLog) error {
-	query := `INSERT INTO operation_logs (user_id, username, operation, target, content, result, created_at) VALUES ($1, $2, $3, $4, $5, $6, $7)`
-	_, err := r.data.db.ExecContext(ctx, query, log.UserID, log.Username, log.Operation, log.Target, log.Content, log.Result, time.Now())
-	return err
+	return r.CreateLogs(ctx, []*biz.OperationLog{log})
+}
+
+// CreateLogs 在事务中将日志依次接到哈希链尾部，以一条多行 INSERT 写入
+func (r *operationLogRepo) CreateLogs(ctx context.Context, logs []*biz.OperationLog) error {
+	if len(logs) == 0 {
+		return nil
+	}
+	if !r.data.configured() {
+		return errDatabaseNotConfigured
+	}
+	return r.data.Transaction(ctx, func(ctx context.Context) error {
+		conn := r.data.conn(ctx)
+		if _, err := conn.ExecContext(ctx, `SELECT pg_advisory_xact_lock($1)`, operationLogChainLockKey); err != nil {
+			return fmt.Errorf("failed to lock operation log chain: %w", err)
+		}
+		var seq int64
+		var prevHash string
+		err := conn.QueryRowContext(ctx, chainHeadQuery).Scan(&seq, &prevHash)
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("failed to read operation log chain head: %w", err)
+		}
+
+		// 分区表上 seq 没有全局唯一索引，先在登记表中占用序号，重复时整个事务失败
+		if _, err := conn.ExecContext(ctx, `INSERT INTO operation_log_seqs (seq) SELECT generate_series($1::BIGINT, $2::BIGINT)`,
+			seq+1, seq+int64(len(logs))); err != nil {
+			return fmt.Errorf("failed to register operation log sequence: %w", err)
+		}
+
+		var sb strings.Builder
+		sb.WriteString(`INSERT INTO operation_logs (user_id, username, operation, target, content, result, created_at, seq, prev_hash, hash) VALUES `)
+		args := make([]interface{}, 0, len(logs)*10)
+		for i, log := range logs {
+			seq++
+			log.Seq = seq
+			log.CreatedAt = logTime(log)
+			log.PrevHash = prevHash
+			log.Hash = r.chain.Link(prevHash, log.AuditPayload())
+			prevHash = log.Hash
+
+			if i > 0 {
+				sb.WriteString(", ")
+			}
+			n := len(args)
+			fmt.Fprintf(&sb, "($%d, $%d, $%d, $%d, $%d, $%d, $%d, $%d, $%d, $%d)", n+1, n+2, n+3, n+4, n+5, n+6, n+7, n+8, n+9, n+10)
+			args = append(args, log.UserID, log.Username, log.Operation, log.Target, log.Content, log.Result, log.CreatedAt,
+				log.Seq, log.PrevHash, log.Hash)
+		}
+		if _, err := conn.ExecContext(ctx, sb.String(), args...); err != nil {
+			return fmt.Errorf("failed to create operation logs: %w", err)
+		}
+		return nil
+	})
 }
 
 func (r *operationLogRepo) ListLogs(ctx context.Context, userID int64, startTime, endTime time.Time) ([]*biz.OperationLog, error) {
+	if !r.data.configured() {
+		return nil, errDatabaseNotConfigured
+	}
 	query := `SELECT id, user_id, username, operation, target, content, result, created_at FROM operation_logs WHERE user_id = $1 AND created_at BETWEEN $2 AND $3 ORDER BY created_at DESC`
 	rows, err := r.data.db.QueryContext(ctx, query, userID, startTime, endTime)
 	if err != nil {
@@ -47,3 +126,83 @@ func (r *operationLogRepo) ListLogs(ctx context.Context, userID int64, startTime
 	}
 	return logs, nil
 }
+
+// QueryLogs 按条件分页查询操作日志
+func (r *operationLogRepo) QueryLogs(ctx context.Context, q *biz.OperationLogQuery) ([]*biz.OperationLog, int64, error) {
+	if !r.data.configured() {
+		return nil, 0, errDatabaseNotConfigured
+	}
+	var conds []string
+	var args []interface{}
+	where := func(cond string, arg interface{}) {
+		args = append(args, arg)
+		conds = append(conds, fmt.Sprintf(cond, len(args)))
+	}
+	if q.UserID != 0 {
+		where("user_id = $%d", q.UserID)
+	}
+	if q.Username != "" {
+		where("username = $%d", q.Username)
+	}
+	if q.Operation != "" {
+		where(`operation LIKE $%d ESCAPE '\'`, likePrefix(q.Operation))
+	}
+	if q.Target != "" {
+		where(`target LIKE $%d ESCAPE '\'`, likePrefix(q.Target))
+	}
+	if !q.StartTime.IsZero() {
+		where("created_at >= $%d", q.StartTime)
+	}
+	if !q.EndTime.IsZero() {
+		where("created_at < $%d", q.EndTime)
+	}
+	filter := ""
+	if len(conds) > 0 {
+		filter = " WHERE " + strings.Join(conds, " AND ")
+	}
+
+	var total int64
+	if err := r.data.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM operation_logs`+filter, args...).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("failed to count operation logs: %w", err)
+	}
+	if total == 0 {
+		return nil, 0, nil
+	}
+
+	query := `SELECT id, user_id, username, operation, target, content, result, created_at FROM operation_logs` + filter +
+		fmt.Sprintf(` ORDER BY created_at DESC, id DESC LIMIT $%d OFFSET $%d`, len(args)+1, len(args)+2)
+	args = append(args, q.PageSize, (q.Page-1)*q.PageSize)
+	rows, err := r.data.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to query operation logs: %w", err)
+	}
+	defer rows.Close()
+
+	var logs []*biz.OperationLog
+	for rows.Next() {
+		log := &biz.OperationLog{}
+		if err := rows.Scan(&log.ID, &log.UserID, &log.Username, &log.Operation, &log.Target, &log.Content, &log.Result, &log.CreatedAt); err != nil {
+			return nil, 0, err
+		}
+		logs = append(logs, log)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, err
+	}
+	return logs, total, nil
+}
+
+// logTime 日志异步写入时以操作发生时间为准，未设置时使用当前时间。
+// 截断到微秒与 Postgres 的时间精度一致，读回的记录才能通过哈希校验。
+func logTime(log *biz.OperationLog) time.Time {
+	t := log.CreatedAt
+	if t.IsZero() {
+		t = time.Now()
+	}
+	return t.UTC().Truncate(time.Microsecond)
+}
+
+// likePrefix 构造前缀匹配的 LIKE 模式，转义通配符
+func likePrefix(prefix string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(prefix) + "%"
+}
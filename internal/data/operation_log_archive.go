@@ -0,0 +1,257 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"kratos-boilerplate/internal/biz"
+
+	"github.com/go-kratos/kratos/v2/log"
+	"github.com/lib/pq"
+)
+
+const (
+	// operationLogArchiveLockKey 操作日志分区维护的 Postgres 咨询锁，多实例部署时同一时间只有一个实例建分区或归档
+	operationLogArchiveLockKey = 0x6f706172636876
+	// operationLogPartitionPrefix 月分区名前缀，后接 UTC 年月
+	operationLogPartitionPrefix = "operation_logs_"
+	// operationLogArchivePage 归档时每次读取的记录数
+	operationLogArchivePage = 1000
+)
+
+// archiveColumns 归档与恢复的操作日志列
+const archiveColumns = `id, user_id, username, operation, target, content, result, created_at, seq, prev_hash, hash`
+
+type operationLogArchiveRepo struct {
+	data *Data
+	log  *log.Helper
+}
+
+// NewOperationLogArchiveRepo 创建操作日志分区与归档仓储
+func NewOperationLogArchiveRepo(data *Data, logger log.Logger) biz.OperationLogArchiveRepo {
+	return &operationLogArchiveRepo{
+		data: data,
+		log:  log.NewHelper(log.With(logger, "module", "data.operation_log")),
+	}
+}
+
+// operationLogPartition 返回 month 所在 UTC 月份的分区
+func operationLogPartition(month time.Time) *biz.OperationLogPartition {
+	month = month.UTC()
+	start := time.Date(month.Year(), month.Month(), 1, 0, 0, 0, 0, time.UTC)
+	return &biz.OperationLogPartition{
+		Name:  operationLogPartitionPrefix + start.Format("200601"),
+		Start: start,
+		End:   start.AddDate(0, 1, 0),
+	}
+}
+
+// EnsurePartition 创建月分区。默认分区中可能已有该月的记录，先建普通表并移入这些记录，再挂载为分区
+func (r *operationLogArchiveRepo) EnsurePartition(ctx context.Context, month time.Time) (*biz.OperationLogPartition, bool, error) {
+	if !r.data.configured() {
+		return nil, false, errDatabaseNotConfigured
+	}
+	p := operationLogPartition(month)
+	created := false
+	err := r.data.Transaction(ctx, func(ctx context.Context) error {
+		conn := r.data.conn(ctx)
+		if _, err := conn.ExecContext(ctx, `SELECT pg_advisory_xact_lock($1)`, operationLogArchiveLockKey); err != nil {
+			return fmt.Errorf("failed to lock operation log partitions: %w", err)
+		}
+		var exists bool
+		if err := conn.QueryRowContext(ctx, `SELECT to_regclass($1) IS NOT NULL`, p.Name).Scan(&exists); err != nil {
+			return fmt.Errorf("failed to check operation log partition: %w", err)
+		}
+		if exists {
+			return nil
+		}
+
+		table := pq.QuoteIdentifier(p.Name)
+		stmts := []struct {
+			query string
+			args  []interface{}
+		}{
+			{`CREATE TABLE ` + table + ` (LIKE operation_logs INCLUDING DEFAULTS INCLUDING CONSTRAINTS)`, nil},
+			{`INSERT INTO ` + table + ` SELECT * FROM operation_logs_default WHERE created_at >= $1 AND created_at < $2`, []interface{}{p.Start, p.End}},
+			{`DELETE FROM operation_logs_default WHERE created_at >= $1 AND created_at < $2`, []interface{}{p.Start, p.End}},
+			// 分区边界不支持参数
+			{fmt.Sprintf(`ALTER TABLE operation_logs ATTACH PARTITION %s FOR VALUES FROM (%s) TO (%s)`, table,
+				pq.QuoteLiteral(p.Start.Format(time.RFC3339)), pq.QuoteLiteral(p.End.Format(time.RFC3339))), nil},
+		}
+		for _, stmt := range stmts {
+			if _, err := conn.ExecContext(ctx, stmt.query, stmt.args...); err != nil {
+				return fmt.Errorf("failed to create operation log partition %s: %w", p.Name, err)
+			}
+		}
+		created = true
+		return nil
+	})
+	if err != nil {
+		return nil, false, err
+	}
+	return p, created, nil
+}
+
+// ListPartitions 按名称即时间升序返回月分区
+func (r *operationLogArchiveRepo) ListPartitions(ctx context.Context) ([]*biz.OperationLogPartition, error) {
+	if !r.data.configured() {
+		return nil, errDatabaseNotConfigured
+	}
+	query := `
+		SELECT c.relname
+		FROM pg_inherits i
+		JOIN pg_class c ON c.oid = i.inhrelid
+		WHERE i.inhparent = 'operation_logs'::regclass
+		ORDER BY c.relname
+	`
+	rows, err := r.data.conn(ctx).QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list operation log partitions: %w", err)
+	}
+	defer rows.Close()
+
+	var partitions []*biz.OperationLogPartition
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, err
+		}
+		// 跳过默认分区与不按月命名的分区
+		month, err := time.Parse("200601", strings.TrimPrefix(name, operationLogPartitionPrefix))
+		if err != nil || !strings.HasPrefix(name, operationLogPartitionPrefix) {
+			continue
+		}
+		partitions = append(partitions, operationLogPartition(month))
+	}
+	return partitions, rows.Err()
+}
+
+// ArchivePartition 在一个事务中导出、登记并删除分区。分区在导出期间以 SHARE 模式锁定，
+// 导出的记录与删除的记录一致；事务失败时分区保留，下次检查重新导出并覆盖归档文件
+func (r *operationLogArchiveRepo) ArchivePartition(ctx context.Context, p *biz.OperationLogPartition, write func([]*biz.OperationLog) error, seal func() (*biz.OperationLogArchive, error)) (bool, error) {
+	if !r.data.configured() {
+		return false, errDatabaseNotConfigured
+	}
+	locked := false
+	err := r.data.Transaction(ctx, func(ctx context.Context) error {
+		conn := r.data.conn(ctx)
+		if err := conn.QueryRowContext(ctx, `SELECT pg_try_advisory_xact_lock($1)`, operationLogArchiveLockKey).Scan(&locked); err != nil {
+			return fmt.Errorf("failed to lock operation log partitions: %w", err)
+		}
+		if !locked {
+			return nil
+		}
+
+		table := pq.QuoteIdentifier(p.Name)
+		if _, err := conn.ExecContext(ctx, `LOCK TABLE `+table+` IN SHARE MODE`); err != nil {
+			return fmt.Errorf("failed to lock operation log partition %s: %w", p.Name, err)
+		}
+		var lastID int64
+		for {
+			logs, err := r.page(ctx, `SELECT `+archiveColumns+` FROM `+table+` WHERE id > $1 ORDER BY id LIMIT $2`, lastID, operationLogArchivePage)
+			if err != nil {
+				return err
+			}
+			if len(logs) == 0 {
+				break
+			}
+			if err := write(logs); err != nil {
+				return err
+			}
+			lastID = logs[len(logs)-1].ID
+		}
+		archive, err := seal()
+		if err != nil {
+			return err
+		}
+
+		query := `
+			INSERT INTO operation_log_archives (partition_name, range_start, range_end, records, min_seq, max_seq, last_hash, file, sha256)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		`
+		if _, err := conn.ExecContext(ctx, query, archive.Partition, archive.Start, archive.End, archive.Records,
+			nullSeq(archive.MinSeq), nullSeq(archive.MaxSeq), archive.LastHash, archive.File, archive.SHA256); err != nil {
+			return fmt.Errorf("failed to register operation log archive: %w", err)
+		}
+		if _, err := conn.ExecContext(ctx, `ALTER TABLE operation_logs DETACH PARTITION `+table); err != nil {
+			return fmt.Errorf("failed to detach operation log partition %s: %w", p.Name, err)
+		}
+		if _, err := conn.ExecContext(ctx, `DROP TABLE `+table); err != nil {
+			return fmt.Errorf("failed to drop operation log partition %s: %w", p.Name, err)
+		}
+		return nil
+	})
+	return locked && err == nil, err
+}
+
+// CreateRestoreTable 创建不写 WAL 的恢复表，只复制列定义
+func (r *operationLogArchiveRepo) CreateRestoreTable(ctx context.Context, table string) error {
+	if !r.data.configured() {
+		return errDatabaseNotConfigured
+	}
+	if _, err := r.data.conn(ctx).ExecContext(ctx, `CREATE UNLOGGED TABLE `+pq.QuoteIdentifier(table)+` (LIKE operation_logs)`); err != nil {
+		return fmt.Errorf("failed to create restore table %s: %w", table, err)
+	}
+	return nil
+}
+
+// RestoreLogs 以一条多行 INSERT 写入恢复表，保留原记录的 ID 与哈希链字段
+func (r *operationLogArchiveRepo) RestoreLogs(ctx context.Context, table string, logs []*biz.OperationLog) error {
+	if len(logs) == 0 {
+		return nil
+	}
+	if !r.data.configured() {
+		return errDatabaseNotConfigured
+	}
+	var sb strings.Builder
+	sb.WriteString(`INSERT INTO ` + pq.QuoteIdentifier(table) + ` (` + archiveColumns + `) VALUES `)
+	args := make([]interface{}, 0, len(logs)*11)
+	for i, l := range logs {
+		if i > 0 {
+			sb.WriteString(", ")
+		}
+		sb.WriteString("(")
+		for j := 1; j <= 11; j++ {
+			if j > 1 {
+				sb.WriteString(", ")
+			}
+			fmt.Fprintf(&sb, "$%d", len(args)+j)
+		}
+		sb.WriteString(")")
+		args = append(args, l.ID, l.UserID, l.Username, l.Operation, l.Target, l.Content, l.Result, l.CreatedAt,
+			nullSeq(l.Seq), l.PrevHash, l.Hash)
+	}
+	if _, err := r.data.conn(ctx).ExecContext(ctx, sb.String(), args...); err != nil {
+		return fmt.Errorf("failed to restore operation logs into %s: %w", table, err)
+	}
+	return nil
+}
+
+func (r *operationLogArchiveRepo) page(ctx context.Context, query string, args ...interface{}) ([]*biz.OperationLog, error) {
+	rows, err := r.data.conn(ctx).QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read operation log partition: %w", err)
+	}
+	defer rows.Close()
+
+	var logs []*biz.OperationLog
+	for rows.Next() {
+		l := &biz.OperationLog{}
+		var seq sql.NullInt64
+		if err := rows.Scan(&l.ID, &l.UserID, &l.Username, &l.Operation, &l.Target, &l.Content, &l.Result, &l.CreatedAt,
+			&seq, &l.PrevHash, &l.Hash); err != nil {
+			return nil, err
+		}
+		l.Seq = seq.Int64
+		logs = append(logs, l)
+	}
+	return logs, rows.Err()
+}
+
+// nullSeq 哈希链启用前写入的记录没有序号
+func nullSeq(seq int64) sql.NullInt64 {
+	return sql.NullInt64{Int64: seq, Valid: seq != 0}
+}
@@ -0,0 +1,176 @@
+package data
+
+import (
+	"context"
+	"errors"
+	"os"
+	"testing"
+	"time"
+
+	"kratos-boilerplate/internal/biz"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/go-kratos/kratos/v2/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// 测试EnsurePartition - 新建分区时移入默认分区中的记录，已存在时跳过
+func TestOperationLogArchiveRepo_EnsurePartition(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := NewOperationLogArchiveRepo(&Data{db: db}, log.NewStdLogger(os.Stdout))
+	ctx := context.Background()
+	start := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	end := start.AddDate(0, 1, 0)
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`SELECT pg_advisory_xact_lock`).WithArgs(operationLogArchiveLockKey).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery(`SELECT to_regclass`).WithArgs("operation_logs_202603").WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+	mock.ExpectExec(`CREATE TABLE "operation_logs_202603" \(LIKE operation_logs`).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(`INSERT INTO "operation_logs_202603" SELECT \* FROM operation_logs_default`).WithArgs(start, end).WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectExec(`DELETE FROM operation_logs_default`).WithArgs(start, end).WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectExec(`ALTER TABLE operation_logs ATTACH PARTITION "operation_logs_202603" FOR VALUES FROM \('2026-03-01T00:00:00Z'\) TO \('2026-04-01T00:00:00Z'\)`).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectCommit()
+
+	p, created, err := repo.EnsurePartition(ctx, start.Add(10*24*time.Hour))
+	require.NoError(t, err)
+	assert.True(t, created)
+	assert.Equal(t, &biz.OperationLogPartition{Name: "operation_logs_202603", Start: start, End: end}, p)
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`SELECT pg_advisory_xact_lock`).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery(`SELECT to_regclass`).WithArgs("operation_logs_202603").WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+	mock.ExpectCommit()
+
+	_, created, err = repo.EnsurePartition(ctx, start)
+	require.NoError(t, err)
+	assert.False(t, created)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+// 测试未配置数据库时返回错误而不是崩溃
+func TestOperationLogRepos_DatabaseNotConfigured(t *testing.T) {
+	ctx := context.Background()
+	archiveRepo := NewOperationLogArchiveRepo(nil, log.NewStdLogger(os.Stdout))
+	_, _, err := archiveRepo.EnsurePartition(ctx, time.Now())
+	assert.ErrorIs(t, err, biz.ErrDatabaseNotConfigured)
+	_, err = archiveRepo.ListPartitions(ctx)
+	assert.ErrorIs(t, err, errDatabaseNotConfigured)
+
+	logRepo := NewOperationLogRepo(nil, log.NewStdLogger(os.Stdout))
+	assert.ErrorIs(t, logRepo.CreateLogs(ctx, []*biz.OperationLog{{Operation: "GET /"}}), errDatabaseNotConfigured)
+	_, _, err = logRepo.QueryLogs(ctx, &biz.OperationLogQuery{Page: 1, PageSize: 10})
+	assert.ErrorIs(t, err, errDatabaseNotConfigured)
+}
+
+// 测试ListPartitions - 跳过默认分区
+func TestOperationLogArchiveRepo_ListPartitions(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := NewOperationLogArchiveRepo(&Data{db: db}, log.NewStdLogger(os.Stdout))
+	mock.ExpectQuery(`SELECT c.relname FROM pg_inherits`).
+		WillReturnRows(sqlmock.NewRows([]string{"relname"}).AddRow("operation_logs_202512").AddRow("operation_logs_202601").AddRow("operation_logs_default"))
+
+	partitions, err := repo.ListPartitions(context.Background())
+	require.NoError(t, err)
+	require.Len(t, partitions, 2)
+	assert.Equal(t, "operation_logs_202512", partitions[0].Name)
+	assert.Equal(t, time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC), partitions[0].End)
+	assert.Equal(t, "operation_logs_202601", partitions[1].Name)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+// 测试ArchivePartition - 导出、登记并删除分区
+func TestOperationLogArchiveRepo_ArchivePartition(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := NewOperationLogArchiveRepo(&Data{db: db}, log.NewStdLogger(os.Stdout))
+	ctx := context.Background()
+	p := operationLogPartition(time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC))
+	at := p.Start.Add(time.Hour)
+	columns := []string{"id", "user_id", "username", "operation", "target", "content", "result", "created_at", "seq", "prev_hash", "hash"}
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT pg_try_advisory_xact_lock`).WithArgs(operationLogArchiveLockKey).WillReturnRows(sqlmock.NewRows([]string{"locked"}).AddRow(true))
+	mock.ExpectExec(`LOCK TABLE "operation_logs_202601" IN SHARE MODE`).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery(`SELECT .+ FROM "operation_logs_202601" WHERE id > \$1 ORDER BY id LIMIT \$2`).WithArgs(int64(0), operationLogArchivePage).
+		WillReturnRows(sqlmock.NewRows(columns).
+			AddRow(1, 7, "alice", "GET /x", "/x", "", "success", at, nil, "", "").
+			AddRow(2, 7, "alice", "GET /y", "/y", "", "success", at, 1, "", "sha256:aa"))
+	mock.ExpectQuery(`SELECT .+ FROM "operation_logs_202601" WHERE id > \$1`).WithArgs(int64(2), operationLogArchivePage).
+		WillReturnRows(sqlmock.NewRows(columns))
+	mock.ExpectExec(`INSERT INTO operation_log_archives`).
+		WithArgs("operation_logs_202601", p.Start, p.End, int64(2), sqlmock.AnyArg(), sqlmock.AnyArg(), "sha256:aa", "/archive/operation_logs_202601.manifest.json", "abc").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec(`ALTER TABLE operation_logs DETACH PARTITION "operation_logs_202601"`).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(`DROP TABLE "operation_logs_202601"`).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectCommit()
+
+	var written []*biz.OperationLog
+	write := func(logs []*biz.OperationLog) error {
+		written = append(written, logs...)
+		return nil
+	}
+	seal := func() (*biz.OperationLogArchive, error) {
+		return &biz.OperationLogArchive{Partition: p.Name, Start: p.Start, End: p.End, Records: int64(len(written)), MinSeq: 1, MaxSeq: 1,
+			LastHash: "sha256:aa", File: "/archive/operation_logs_202601.manifest.json", SHA256: "abc"}, nil
+	}
+	ok, err := repo.ArchivePartition(ctx, p, write, seal)
+	require.NoError(t, err)
+	assert.True(t, ok)
+	require.Len(t, written, 2)
+	assert.Zero(t, written[0].Seq)
+	assert.Equal(t, int64(1), written[1].Seq)
+
+	// 其他实例持有锁
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT pg_try_advisory_xact_lock`).WillReturnRows(sqlmock.NewRows([]string{"locked"}).AddRow(false))
+	mock.ExpectCommit()
+	ok, err = repo.ArchivePartition(ctx, p, write, seal)
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	// 归档文件写入失败时保留分区
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT pg_try_advisory_xact_lock`).WillReturnRows(sqlmock.NewRows([]string{"locked"}).AddRow(true))
+	mock.ExpectExec(`LOCK TABLE`).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery(`SELECT .+ FROM "operation_logs_202601"`).WillReturnRows(sqlmock.NewRows(columns))
+	mock.ExpectRollback()
+	ok, err = repo.ArchivePartition(ctx, p, write, func() (*biz.OperationLogArchive, error) { return nil, errors.New("disk full") })
+	assert.Error(t, err)
+	assert.False(t, ok)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+// 测试恢复表的创建与写入
+func TestOperationLogArchiveRepo_Restore(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := NewOperationLogArchiveRepo(&Data{db: db}, log.NewStdLogger(os.Stdout))
+	ctx := context.Background()
+	at := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	mock.ExpectExec(`CREATE UNLOGGED TABLE "operation_logs_restore_202601" \(LIKE operation_logs\)`).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(`INSERT INTO "operation_logs_restore_202601" \(id, .+\) VALUES \(\$1, .+, \$11\), \(\$12, .+, \$22\)`).
+		WithArgs(int64(1), int64(7), "alice", "GET /x", "/x", "", "success", at, sqlmock.AnyArg(), "", "",
+			int64(2), int64(7), "alice", "GET /y", "/y", "", "success", at, sqlmock.AnyArg(), "", "sha256:aa").
+		WillReturnResult(sqlmock.NewResult(0, 2))
+
+	require.NoError(t, repo.CreateRestoreTable(ctx, "operation_logs_restore_202601"))
+	err = repo.RestoreLogs(ctx, "operation_logs_restore_202601", []*biz.OperationLog{
+		{ID: 1, UserID: 7, Username: "alice", Operation: "GET /x", Target: "/x", Result: "success", CreatedAt: at},
+		{ID: 2, UserID: 7, Username: "alice", Operation: "GET /y", Target: "/y", Result: "success", CreatedAt: at, Seq: 1, Hash: "sha256:aa"},
+	})
+	require.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -8,6 +8,7 @@ import (
 	"time"
 
 	"kratos-boilerplate/internal/biz"
+	"kratos-boilerplate/internal/pkg/audit"
 
 	"github.com/DATA-DOG/go-sqlmock"
 	"github.com/go-kratos/kratos/v2/log"
@@ -51,10 +52,17 @@ func TestCreateLog(t *testing.T) {
 		CreatedAt: time.Now(),
 	}
 
-	// 设置mock期望
+	// 设置mock期望：加锁读取链头后接在链尾
+	mock.ExpectBegin()
+	mock.ExpectExec("SELECT pg_advisory_xact_lock").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery("SELECT seq, hash FROM operation_logs").
+		WillReturnRows(sqlmock.NewRows([]string{"seq", "hash"}).AddRow(9, "sha256:prev"))
+	mock.ExpectExec("INSERT INTO operation_log_seqs").WithArgs(int64(10), int64(10)).WillReturnResult(sqlmock.NewResult(0, 1))
 	mock.ExpectExec("INSERT INTO operation_logs").
-		WithArgs(logEntry.UserID, logEntry.Username, logEntry.Operation, logEntry.Target, logEntry.Content, logEntry.Result, sqlmock.AnyArg()).
+		WithArgs(logEntry.UserID, logEntry.Username, logEntry.Operation, logEntry.Target, logEntry.Content, logEntry.Result, sqlmock.AnyArg(),
+			int64(10), "sha256:prev", sqlmock.AnyArg()).
 		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
 
 	// 执行测试
 	ctx := context.Background()
@@ -92,10 +100,16 @@ func TestCreateLog_DatabaseError(t *testing.T) {
 		CreatedAt: time.Now(),
 	}
 
-	// 设置mock期望 - 返回错误
+	// 设置mock期望 - 返回错误，事务回滚
+	mock.ExpectBegin()
+	mock.ExpectExec("SELECT pg_advisory_xact_lock").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery("SELECT seq, hash FROM operation_logs").WillReturnError(sql.ErrNoRows)
+	mock.ExpectExec("INSERT INTO operation_log_seqs").WithArgs(int64(1), int64(1)).WillReturnResult(sqlmock.NewResult(0, 1))
 	mock.ExpectExec("INSERT INTO operation_logs").
-		WithArgs(logEntry.UserID, logEntry.Username, logEntry.Operation, logEntry.Target, logEntry.Content, logEntry.Result, sqlmock.AnyArg()).
+		WithArgs(logEntry.UserID, logEntry.Username, logEntry.Operation, logEntry.Target, logEntry.Content, logEntry.Result, sqlmock.AnyArg(),
+			int64(1), "", sqlmock.AnyArg()).
 		WillReturnError(sql.ErrConnDone)
+	mock.ExpectRollback()
 
 	// 执行测试
 	ctx := context.Background()
@@ -257,3 +271,93 @@ func TestOperationLogRepoInterface(t *testing.T) {
 	var _ biz.OperationLogRepo = repo
 	assert.NotNil(t, repo)
 }
+
+// 测试CreateLogs - 多行批量写入，依次接到哈希链尾部并保留操作时间
+func TestCreateLogs(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := NewOperationLogRepo(&Data{db: db}, log.NewStdLogger(os.Stdout))
+	at := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	logs := []*biz.OperationLog{
+		{UserID: 1, Username: "alice", Operation: "GET /api/v1/a", Target: "/api/v1/a", Result: "success", CreatedAt: at},
+		{UserID: 2, Username: "bob", Operation: "POST /api/v1/b", Target: "/api/v1/b", Result: "error: boom", CreatedAt: at},
+	}
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`SELECT pg_advisory_xact_lock\(\$1\)`).WithArgs(operationLogChainLockKey).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery("SELECT seq, hash FROM operation_logs").WillReturnRows(sqlmock.NewRows([]string{"seq", "hash"}))
+	mock.ExpectExec(`INSERT INTO operation_log_seqs \(seq\) SELECT generate_series`).WithArgs(int64(1), int64(2)).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectExec(`INSERT INTO operation_logs \(.+\) VALUES \(\$1, .+, \$10\), \(\$11, .+, \$20\)$`).
+		WithArgs(int64(1), "alice", "GET /api/v1/a", "/api/v1/a", "", "success", at, int64(1), "", sqlmock.AnyArg(),
+			int64(2), "bob", "POST /api/v1/b", "/api/v1/b", "", "error: boom", at, int64(2), sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectCommit()
+
+	require.NoError(t, repo.CreateLogs(context.Background(), logs))
+	require.NoError(t, repo.CreateLogs(context.Background(), nil))
+	assert.NoError(t, mock.ExpectationsWereMet())
+
+	// 链上哈希可按记录内容重新计算
+	assert.Equal(t, "", logs[0].PrevHash)
+	assert.Equal(t, logs[0].Hash, logs[1].PrevHash)
+	for _, l := range logs {
+		ok, err := audit.VerifyLink(l.PrevHash, l.AuditPayload(), l.Hash)
+		require.NoError(t, err)
+		assert.True(t, ok)
+	}
+}
+
+// 测试QueryLogs - 组合条件、前缀匹配与分页
+func TestQueryLogs(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := NewOperationLogRepo(&Data{db: db}, log.NewStdLogger(os.Stdout))
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	end := start.Add(24 * time.Hour)
+	query := &biz.OperationLogQuery{
+		UserID:    7,
+		Operation: "POST /api/v1/admin_",
+		StartTime: start,
+		EndTime:   end,
+		Page:      3,
+		PageSize:  10,
+	}
+	filter := `WHERE user_id = \$1 AND operation LIKE \$2 ESCAPE '\\' AND created_at >= \$3 AND created_at < \$4`
+
+	mock.ExpectQuery(`SELECT COUNT\(\*\) FROM operation_logs `+filter+`$`).
+		WithArgs(int64(7), `POST /api/v1/admin\_%`, start, end).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(21))
+	mock.ExpectQuery(`SELECT id, .+ FROM operation_logs `+filter+` ORDER BY created_at DESC, id DESC LIMIT \$5 OFFSET \$6$`).
+		WithArgs(int64(7), `POST /api/v1/admin\_%`, start, end, 10, 20).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "username", "operation", "target", "content", "result", "created_at"}).
+			AddRow(21, 7, "alice", "POST /api/v1/admin_x", "/api/v1/admin_x", "", "success", start))
+
+	logs, total, err := repo.QueryLogs(context.Background(), query)
+	require.NoError(t, err)
+	assert.Equal(t, int64(21), total)
+	require.Len(t, logs, 1)
+	assert.Equal(t, int64(21), logs[0].ID)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+// 测试QueryLogs - 无条件且无结果时不查询明细
+func TestQueryLogs_Empty(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := NewOperationLogRepo(&Data{db: db}, log.NewStdLogger(os.Stdout))
+	mock.ExpectQuery(`SELECT COUNT\(\*\) FROM operation_logs$`).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+
+	logs, total, err := repo.QueryLogs(context.Background(), &biz.OperationLogQuery{Page: 1, PageSize: 20})
+	require.NoError(t, err)
+	assert.Zero(t, total)
+	assert.Empty(t, logs)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
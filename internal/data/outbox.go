@@ -0,0 +1,227 @@
+package data
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"kratos-boilerplate/internal/pkg/plugin"
+
+	"github.com/go-kratos/kratos/v2/log"
+)
+
+const (
+	// outboxRelayInterval 转发发件箱事件的轮询间隔
+	outboxRelayInterval = time.Second
+	// outboxRelayBatch 每轮转发的最大事件数
+	outboxRelayBatch = 100
+	// outboxRelayLockKey 转发器的 Postgres 咨询锁，保证同一时间只有一个实例按顺序转发
+	outboxRelayLockKey = 0x6f7574626f78
+)
+
+// SaveOutboxEvent 将事件写入发件箱，与业务数据在同一事务中提交后由 OutboxRelay 发布
+// aggregateType 与 aggregateID 标识事件所属的业务对象，同一对象的事件按写入顺序发布
+func (d *Data) SaveOutboxEvent(ctx context.Context, aggregateType, aggregateID string, event plugin.Event) error {
+	data, err := json.Marshal(event.GetData())
+	if err != nil {
+		return fmt.Errorf("failed to encode event data: %w", err)
+	}
+	metadata, err := json.Marshal(event.GetMetadata())
+	if err != nil {
+		return fmt.Errorf("failed to encode event metadata: %w", err)
+	}
+
+	query := `
+		INSERT INTO event_outbox (event_id, aggregate_type, aggregate_id, event_type, source, event_time, data, metadata, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+	`
+	_, err = d.conn(ctx).ExecContext(ctx, query,
+		event.GetID(), aggregateType, aggregateID, string(event.GetType()), event.GetSource(), event.GetTimestamp(),
+		data, metadata, time.Now(),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to save outbox event: %w", err)
+	}
+	return nil
+}
+
+// OutboxRelay 将已提交的发件箱事件发布到事件总线，作为 kratos 服务随应用启停
+type OutboxRelay struct {
+	data     *Data
+	events   plugin.EventBus
+	interval time.Duration
+	batch    int
+	log      *log.Helper
+
+	stopOnce sync.Once
+	done     chan struct{}
+	stopped  chan struct{}
+}
+
+// NewOutboxRelay 创建发件箱转发器
+func NewOutboxRelay(data *Data, events plugin.EventBus, logger log.Logger) *OutboxRelay {
+	return &OutboxRelay{
+		data:     data,
+		events:   events,
+		interval: outboxRelayInterval,
+		batch:    outboxRelayBatch,
+		log:      log.NewHelper(log.With(logger, "module", "data.outbox")),
+		done:     make(chan struct{}),
+		stopped:  make(chan struct{}),
+	}
+}
+
+// Start 定期转发发件箱事件，直到 Stop
+func (r *OutboxRelay) Start(context.Context) error {
+	defer close(r.stopped)
+
+	// 未配置数据库时没有发件箱
+	if r.data == nil || r.data.db == nil {
+		<-r.done
+		return nil
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		<-r.done
+		cancel()
+	}()
+
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+
+	for {
+		for {
+			n, err := r.relay(ctx)
+			if err != nil && ctx.Err() == nil {
+				r.log.Errorf("failed to relay outbox events: %v", err)
+			}
+			if err != nil || n < r.batch {
+				break
+			}
+		}
+
+		select {
+		case <-r.done:
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// Stop 停止转发，等待进行中的一轮结束
+func (r *OutboxRelay) Stop(ctx context.Context) error {
+	r.stopOnce.Do(func() {
+		close(r.done)
+	})
+	select {
+	case <-r.stopped:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// outboxRow 发件箱中的一条事件
+type outboxRow struct {
+	id            int64
+	aggregateType string
+	aggregateID   string
+	event         plugin.Event
+}
+
+// relay 转发一批事件，返回读取的事件数
+// 事件在事务内按写入顺序发布，发布成功后删除；某个业务对象的事件发布失败时，
+// 该对象的后续事件留待下一轮，保证同一对象的事件不乱序
+func (r *OutboxRelay) relay(ctx context.Context) (int, error) {
+	var n int
+	err := r.data.Transaction(ctx, func(txCtx context.Context) error {
+		var locked bool
+		if err := r.data.conn(txCtx).QueryRowContext(txCtx, `SELECT pg_try_advisory_xact_lock($1)`, outboxRelayLockKey).Scan(&locked); err != nil {
+			return err
+		}
+		if !locked {
+			// 其他实例正在转发
+			return nil
+		}
+
+		rows, err := r.pending(txCtx)
+		if err != nil {
+			return err
+		}
+		n = len(rows)
+
+		blocked := make(map[string]bool)
+		for _, row := range rows {
+			aggregate := row.aggregateType + "/" + row.aggregateID
+			if blocked[aggregate] {
+				continue
+			}
+
+			// 发布时不携带事务，订阅者的数据库操作不加入转发事务
+			if err := r.events.Publish(ctx, row.event); err != nil {
+				if errors.Is(err, plugin.ErrEnqueueFailed) {
+					r.log.Errorf("failed to publish outbox event %s of %s: %v", row.event.GetID(), aggregate, err)
+					blocked[aggregate] = true
+					continue
+				}
+				// 事件已交给事件总线，订阅者的失败由其投递选项处理
+				r.log.Warnf("outbox event %s of %s handled with error: %v", row.event.GetID(), aggregate, err)
+			}
+
+			if _, err := r.data.conn(txCtx).ExecContext(txCtx, `DELETE FROM event_outbox WHERE id = $1`, row.id); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	return n, err
+}
+
+// pending 按写入顺序读取待发布的事件
+func (r *OutboxRelay) pending(ctx context.Context) ([]*outboxRow, error) {
+	query := `
+		SELECT id, event_id, aggregate_type, aggregate_id, event_type, source, event_time, data, metadata
+		FROM event_outbox
+		ORDER BY id
+		LIMIT $1
+	`
+	rows, err := r.data.conn(ctx).QueryContext(ctx, query, r.batch)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var result []*outboxRow
+	for rows.Next() {
+		var (
+			row                        outboxRow
+			eventID, eventType, source string
+			eventTime                  time.Time
+			data, metadata             []byte
+		)
+		if err := rows.Scan(&row.id, &eventID, &row.aggregateType, &row.aggregateID, &eventType, &source, &eventTime, &data, &metadata); err != nil {
+			return nil, err
+		}
+
+		var payload map[string]interface{}
+		if len(data) > 0 {
+			if err := json.Unmarshal(data, &payload); err != nil {
+				return nil, fmt.Errorf("failed to decode outbox event %s: %w", eventID, err)
+			}
+		}
+		var meta map[string]string
+		if len(metadata) > 0 {
+			if err := json.Unmarshal(metadata, &meta); err != nil {
+				return nil, fmt.Errorf("failed to decode outbox event %s: %w", eventID, err)
+			}
+		}
+		row.event = plugin.RestoreEvent(eventID, plugin.EventType(eventType), source, eventTime, payload, meta)
+		result = append(result, &row)
+	}
+	return result, rows.Err()
+}
@@ -0,0 +1,134 @@
+package data
+
+import (
+	"context"
+	"errors"
+	"os"
+	"testing"
+	"time"
+
+	"kratos-boilerplate/internal/pkg/plugin"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/go-kratos/kratos/v2/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// 测试Transaction - 出错回滚，嵌套调用加入外层事务
+func TestTransaction(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	data := &Data{db: db}
+	ctx := context.Background()
+
+	mock.ExpectBegin()
+	mock.ExpectExec("INSERT INTO users").WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec("INSERT INTO event_outbox").WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+
+	err = data.Transaction(ctx, func(ctx context.Context) error {
+		if _, err := data.conn(ctx).ExecContext(ctx, "INSERT INTO users (username) VALUES ($1)", "alice"); err != nil {
+			return err
+		}
+		return data.Transaction(ctx, func(ctx context.Context) error {
+			return data.SaveOutboxEvent(ctx, "user", "1", plugin.NewEvent(plugin.EventUserRegister, "auth", nil))
+		})
+	})
+	assert.NoError(t, err)
+
+	expectedErr := errors.New("outbox unavailable")
+	mock.ExpectBegin()
+	mock.ExpectExec("INSERT INTO users").WillReturnResult(sqlmock.NewResult(2, 1))
+	mock.ExpectExec("INSERT INTO event_outbox").WillReturnError(expectedErr)
+	mock.ExpectRollback()
+
+	err = data.Transaction(ctx, func(ctx context.Context) error {
+		if _, err := data.conn(ctx).ExecContext(ctx, "INSERT INTO users (username) VALUES ($1)", "bob"); err != nil {
+			return err
+		}
+		return data.SaveOutboxEvent(ctx, "user", "2", plugin.NewEvent(plugin.EventUserRegister, "auth", nil))
+	})
+	assert.ErrorIs(t, err, expectedErr)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+// failingStore 写入队列失败的事件投递队列
+type failingStore struct {
+	plugin.EventStore
+	failEvent string
+}
+
+func (s *failingStore) Enqueue(ctx context.Context, records []*plugin.DeliveryRecord) error {
+	for _, r := range records {
+		if r.EventID == s.failEvent {
+			return errors.New("queue unavailable")
+		}
+	}
+	return s.EventStore.Enqueue(ctx, records)
+}
+
+// 测试OutboxRelay - 按写入顺序发布，发布失败的业务对象后续事件留待下一轮
+func TestOutboxRelay(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	bus := plugin.NewEventBusWithConfig(plugin.EventBusConfig{
+		Store: &failingStore{EventStore: plugin.NewMemoryEventStore(), failEvent: "event-2"},
+	})
+	defer bus.Close()
+
+	var published []string
+	require.NoError(t, bus.SubscribeWithOptions(plugin.EventUserRegister, plugin.NewBaseEventHandler("audit",
+		[]plugin.EventType{plugin.EventUserRegister}, time.Second, func(ctx context.Context, event plugin.Event) error {
+			return nil
+		}), plugin.DeliveryOptions{Mode: plugin.DeliveryAtLeastOnce}))
+	require.NoError(t, bus.Subscribe(plugin.EventUserRegister, plugin.NewBaseEventHandler("recorder",
+		[]plugin.EventType{plugin.EventUserRegister}, time.Second, func(ctx context.Context, event plugin.Event) error {
+			published = append(published, event.GetID())
+			return nil
+		})))
+
+	relay := NewOutboxRelay(&Data{db: db}, bus, log.NewStdLogger(os.Stdout))
+
+	now := time.Now()
+	rows := sqlmock.NewRows([]string{"id", "event_id", "aggregate_type", "aggregate_id", "event_type", "source", "event_time", "data", "metadata"}).
+		AddRow(1, "event-1", "user", "1", "user.register", "auth", now, []byte(`{"username":"alice"}`), []byte(`{}`)).
+		AddRow(2, "event-2", "user", "2", "user.register", "auth", now, []byte(`{"username":"bob"}`), []byte(`{}`)).
+		AddRow(3, "event-3", "user", "2", "user.register", "auth", now, []byte(`{"username":"bob"}`), []byte(`{}`)).
+		AddRow(4, "event-4", "user", "3", "user.register", "auth", now, []byte(`{"username":"carol"}`), []byte(`{}`))
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT pg_try_advisory_xact_lock").WillReturnRows(sqlmock.NewRows([]string{"locked"}).AddRow(true))
+	mock.ExpectQuery("SELECT (.+) FROM event_outbox").WithArgs(outboxRelayBatch).WillReturnRows(rows)
+	mock.ExpectExec("DELETE FROM event_outbox").WithArgs(int64(1)).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("DELETE FROM event_outbox").WithArgs(int64(4)).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	n, err := relay.relay(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 4, n)
+	assert.Equal(t, []string{"event-1", "event-4"}, published)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+// 测试OutboxRelay - 其他实例持有锁时跳过本轮
+func TestOutboxRelay_Locked(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	relay := NewOutboxRelay(&Data{db: db}, plugin.NewEventBus(1), log.NewStdLogger(os.Stdout))
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT pg_try_advisory_xact_lock").WillReturnRows(sqlmock.NewRows([]string{"locked"}).AddRow(false))
+	mock.ExpectCommit()
+
+	n, err := relay.relay(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 0, n)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
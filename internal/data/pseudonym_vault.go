@@ -0,0 +1,68 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"kratos-boilerplate/internal/pkg/sensitive"
+
+	"github.com/go-kratos/kratos/v2/log"
+)
+
+// pseudonymVault 可逆假名化令牌库，密文保存在 pseudonym_tokens 表
+type pseudonymVault struct {
+	data *Data
+	log  *log.Helper
+}
+
+// NewPseudonymVault 创建持久化的假名化令牌库
+func NewPseudonymVault(data *Data, logger log.Logger) sensitive.TokenVault {
+	return &pseudonymVault{
+		data: data,
+		log:  log.NewHelper(log.With(logger, "module", "data.pseudonym_vault")),
+	}
+}
+
+// Store 保存令牌密文，令牌已存在时不覆盖并返回 sensitive.ErrTokenExists
+func (v *pseudonymVault) Store(ctx context.Context, dataset, token string, sealed []byte) error {
+	if !v.data.configured() {
+		return errDatabaseNotConfigured
+	}
+	result, err := v.data.db.ExecContext(ctx, `
+		INSERT INTO pseudonym_tokens (dataset, token, sealed)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (dataset, token) DO NOTHING
+	`, dataset, token, sealed)
+	if err != nil {
+		v.log.Errorf("Failed to store pseudonym token: %v", err)
+		return fmt.Errorf("failed to store pseudonym token: %w", err)
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to store pseudonym token: %w", err)
+	}
+	if affected == 0 {
+		return sensitive.ErrTokenExists
+	}
+	return nil
+}
+
+// Load 读取令牌密文，不存在时返回 sensitive.ErrTokenNotFound
+func (v *pseudonymVault) Load(ctx context.Context, dataset, token string) ([]byte, error) {
+	if !v.data.configured() {
+		return nil, errDatabaseNotConfigured
+	}
+	var sealed []byte
+	err := v.data.db.QueryRowContext(ctx,
+		`SELECT sealed FROM pseudonym_tokens WHERE dataset = $1 AND token = $2`, dataset, token,
+	).Scan(&sealed)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, sensitive.ErrTokenNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load pseudonym token: %w", err)
+	}
+	return sealed, nil
+}
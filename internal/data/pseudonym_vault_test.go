@@ -0,0 +1,47 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	"os"
+	"testing"
+
+	"kratos-boilerplate/internal/pkg/sensitive"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/go-kratos/kratos/v2/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// 测试假名化令牌库 - 保存不覆盖已有令牌，读取不存在的令牌
+func TestPseudonymVault(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	vault := NewPseudonymVault(&Data{db: db}, log.NewStdLogger(os.Stdout))
+	ctx := context.Background()
+
+	mock.ExpectExec("INSERT INTO pseudonym_tokens").WithArgs("orders", "tok_a", []byte("sealed")).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("INSERT INTO pseudonym_tokens").WithArgs("orders", "tok_a", []byte("other")).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery("SELECT sealed FROM pseudonym_tokens").WithArgs("orders", "tok_a").
+		WillReturnRows(sqlmock.NewRows([]string{"sealed"}).AddRow([]byte("sealed")))
+	mock.ExpectQuery("SELECT sealed FROM pseudonym_tokens").WithArgs("orders", "tok_b").
+		WillReturnError(sql.ErrNoRows)
+
+	require.NoError(t, vault.Store(ctx, "orders", "tok_a", []byte("sealed")))
+	assert.ErrorIs(t, vault.Store(ctx, "orders", "tok_a", []byte("other")), sensitive.ErrTokenExists)
+	sealed, err := vault.Load(ctx, "orders", "tok_a")
+	require.NoError(t, err)
+	assert.Equal(t, []byte("sealed"), sealed)
+	_, err = vault.Load(ctx, "orders", "tok_b")
+	assert.ErrorIs(t, err, sensitive.ErrTokenNotFound)
+	assert.NoError(t, mock.ExpectationsWereMet())
+
+	// 未配置数据库
+	_, err = NewPseudonymVault(nil, log.NewStdLogger(os.Stdout)).Load(ctx, "orders", "tok_a")
+	assert.ErrorIs(t, err, errDatabaseNotConfigured)
+}
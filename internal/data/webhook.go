@@ -0,0 +1,254 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"kratos-boilerplate/internal/biz"
+
+	"github.com/go-kratos/kratos/v2/log"
+	"github.com/lib/pq"
+)
+
+// errDatabaseNotConfigured 未配置数据库，biz 层据此识别
+var errDatabaseNotConfigured = biz.ErrDatabaseNotConfigured
+
+// webhookEndpointColumns webhook_endpoints 表的查询列，与 scanWebhookEndpoint 的顺序一致
+const webhookEndpointColumns = `id, url, secret, event_types, description, enabled, max_retries, failure_count,
+	disabled_reason, created_at, updated_at`
+
+// webhookRepo Webhook 数据仓库实现
+type webhookRepo struct {
+	data *Data
+	log  *log.Helper
+}
+
+// NewWebhookRepo 创建 Webhook 数据仓库
+func NewWebhookRepo(data *Data, logger log.Logger) biz.WebhookRepo {
+	return &webhookRepo{
+		data: data,
+		log:  log.NewHelper(log.With(logger, "module", "data.webhook")),
+	}
+}
+
+func (r *webhookRepo) db() (*sql.DB, error) {
+	if r.data == nil || r.data.db == nil {
+		return nil, errDatabaseNotConfigured
+	}
+	return r.data.db, nil
+}
+
+// CreateEndpoint 保存端点并回填 ID
+func (r *webhookRepo) CreateEndpoint(ctx context.Context, ep *biz.WebhookEndpoint) error {
+	db, err := r.db()
+	if err != nil {
+		return err
+	}
+	query := `
+		INSERT INTO webhook_endpoints (url, secret, event_types, description, enabled, max_retries, failure_count,
+			disabled_reason, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+		RETURNING id
+	`
+	err = db.QueryRowContext(ctx, query,
+		ep.URL, ep.Secret, pq.Array(ep.EventTypes), ep.Description, ep.Enabled, ep.MaxRetries, ep.FailureCount,
+		ep.DisabledReason, ep.CreatedAt, ep.UpdatedAt,
+	).Scan(&ep.ID)
+	if err != nil {
+		r.log.Errorf("Failed to create webhook endpoint: %v", err)
+		return fmt.Errorf("failed to create webhook endpoint: %w", err)
+	}
+	return nil
+}
+
+// UpdateEndpoint 更新端点
+func (r *webhookRepo) UpdateEndpoint(ctx context.Context, ep *biz.WebhookEndpoint) error {
+	query := `
+		UPDATE webhook_endpoints
+		SET url = $2, secret = $3, event_types = $4, description = $5, enabled = $6, max_retries = $7,
+			disabled_reason = $8, updated_at = $9
+		WHERE id = $1
+	`
+	return r.update(ctx, query, ep.ID,
+		ep.URL, ep.Secret, pq.Array(ep.EventTypes), ep.Description, ep.Enabled, ep.MaxRetries,
+		ep.DisabledReason, ep.UpdatedAt,
+	)
+}
+
+// GetEndpoint 根据 ID 获取端点
+func (r *webhookRepo) GetEndpoint(ctx context.Context, id int64) (*biz.WebhookEndpoint, error) {
+	db, err := r.db()
+	if err != nil {
+		return nil, err
+	}
+	row := db.QueryRowContext(ctx, `SELECT `+webhookEndpointColumns+` FROM webhook_endpoints WHERE id = $1`, id)
+	ep, err := scanWebhookEndpoint(row)
+	if err == sql.ErrNoRows {
+		return nil, biz.ErrWebhookNotFound
+	}
+	if err != nil {
+		r.log.Errorf("Failed to get webhook endpoint %d: %v", id, err)
+		return nil, fmt.Errorf("failed to get webhook endpoint: %w", err)
+	}
+	return ep, nil
+}
+
+// ListEndpoints 列出所有端点
+func (r *webhookRepo) ListEndpoints(ctx context.Context) ([]*biz.WebhookEndpoint, error) {
+	db, err := r.db()
+	if err != nil {
+		return nil, err
+	}
+	rows, err := db.QueryContext(ctx, `SELECT `+webhookEndpointColumns+` FROM webhook_endpoints ORDER BY id`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list webhook endpoints: %w", err)
+	}
+	defer rows.Close()
+
+	var endpoints []*biz.WebhookEndpoint
+	for rows.Next() {
+		ep, err := scanWebhookEndpoint(rows)
+		if err != nil {
+			return nil, err
+		}
+		endpoints = append(endpoints, ep)
+	}
+	return endpoints, rows.Err()
+}
+
+// DeleteEndpoint 删除端点，投递日志级联删除
+func (r *webhookRepo) DeleteEndpoint(ctx context.Context, id int64) error {
+	return r.update(ctx, `DELETE FROM webhook_endpoints WHERE id = $1`, id)
+}
+
+// RecordFailure 连续失败次数加一
+func (r *webhookRepo) RecordFailure(ctx context.Context, id int64) (int, error) {
+	db, err := r.db()
+	if err != nil {
+		return 0, err
+	}
+	var count int
+	err = db.QueryRowContext(ctx,
+		`UPDATE webhook_endpoints SET failure_count = failure_count + 1 WHERE id = $1 RETURNING failure_count`, id,
+	).Scan(&count)
+	if err == sql.ErrNoRows {
+		return 0, biz.ErrWebhookNotFound
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to record webhook failure: %w", err)
+	}
+	return count, nil
+}
+
+// ResetFailures 连续失败次数清零
+func (r *webhookRepo) ResetFailures(ctx context.Context, id int64) error {
+	return r.update(ctx, `UPDATE webhook_endpoints SET failure_count = 0 WHERE id = $1`, id)
+}
+
+// DisableEndpoint 停用端点并记录原因
+func (r *webhookRepo) DisableEndpoint(ctx context.Context, id int64, reason string) error {
+	query := `UPDATE webhook_endpoints SET enabled = FALSE, disabled_reason = $2, updated_at = $3 WHERE id = $1`
+	return r.update(ctx, query, id, reason, time.Now())
+}
+
+// SaveDelivery 保存投递日志
+func (r *webhookRepo) SaveDelivery(ctx context.Context, d *biz.WebhookDelivery) error {
+	db, err := r.db()
+	if err != nil {
+		return err
+	}
+	query := `
+		INSERT INTO webhook_deliveries (endpoint_id, event_id, event_type, idempotency_key, attempt, status_code,
+			success, error, response, duration_ms, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+		RETURNING id
+	`
+	err = db.QueryRowContext(ctx, query,
+		d.EndpointID, d.EventID, d.EventType, d.IdempotencyKey, d.Attempt, d.StatusCode,
+		d.Success, d.Error, d.Response, d.Duration.Milliseconds(), d.CreatedAt,
+	).Scan(&d.ID)
+	if err != nil {
+		return fmt.Errorf("failed to save webhook delivery: %w", err)
+	}
+	return nil
+}
+
+// ListDeliveries 查询端点的投递日志，按时间倒序
+func (r *webhookRepo) ListDeliveries(ctx context.Context, endpointID int64, limit int) ([]*biz.WebhookDelivery, error) {
+	db, err := r.db()
+	if err != nil {
+		return nil, err
+	}
+	query := `
+		SELECT id, endpoint_id, event_id, event_type, idempotency_key, attempt, status_code, success, error, response,
+			duration_ms, created_at
+		FROM webhook_deliveries
+		WHERE endpoint_id = $1
+		ORDER BY created_at DESC, id DESC
+	`
+	args := []interface{}{endpointID}
+	if limit > 0 {
+		query += ` LIMIT $2`
+		args = append(args, limit)
+	}
+
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list webhook deliveries: %w", err)
+	}
+	defer rows.Close()
+
+	var deliveries []*biz.WebhookDelivery
+	for rows.Next() {
+		d := &biz.WebhookDelivery{}
+		var durationMs int64
+		if err := rows.Scan(&d.ID, &d.EndpointID, &d.EventID, &d.EventType, &d.IdempotencyKey, &d.Attempt, &d.StatusCode,
+			&d.Success, &d.Error, &d.Response, &durationMs, &d.CreatedAt); err != nil {
+			return nil, err
+		}
+		d.Duration = time.Duration(durationMs) * time.Millisecond
+		deliveries = append(deliveries, d)
+	}
+	return deliveries, rows.Err()
+}
+
+// update 执行单个端点的更新，端点不存在时返回 biz.ErrWebhookNotFound
+func (r *webhookRepo) update(ctx context.Context, query string, id int64, args ...interface{}) error {
+	db, err := r.db()
+	if err != nil {
+		return err
+	}
+	result, err := db.ExecContext(ctx, query, append([]interface{}{id}, args...)...)
+	if err != nil {
+		r.log.Errorf("Failed to update webhook endpoint %d: %v", id, err)
+		return fmt.Errorf("failed to update webhook endpoint: %w", err)
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return biz.ErrWebhookNotFound
+	}
+	return nil
+}
+
+// rowScanner sql.Row 与 sql.Rows 的公共读取接口
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// scanWebhookEndpoint 读取 webhookEndpointColumns 查询结果
+func scanWebhookEndpoint(row rowScanner) (*biz.WebhookEndpoint, error) {
+	ep := &biz.WebhookEndpoint{}
+	var eventTypes pq.StringArray
+	err := row.Scan(&ep.ID, &ep.URL, &ep.Secret, &eventTypes, &ep.Description, &ep.Enabled, &ep.MaxRetries,
+		&ep.FailureCount, &ep.DisabledReason, &ep.CreatedAt, &ep.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	ep.EventTypes = eventTypes
+	return ep, nil
+}
@@ -0,0 +1,146 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	"os"
+	"testing"
+	"time"
+
+	"kratos-boilerplate/internal/biz"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/go-kratos/kratos/v2/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// 测试CreateEndpoint - 回填ID
+func TestWebhookRepoCreateEndpoint(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := NewWebhookRepo(&Data{db: db}, log.NewStdLogger(os.Stdout))
+
+	now := time.Now()
+	ep := &biz.WebhookEndpoint{
+		URL:        "https://partner.example.com/hooks",
+		Secret:     "whsec_test",
+		EventTypes: []string{"user.login", "user.logout"},
+		Enabled:    true,
+		MaxRetries: 5,
+		CreatedAt:  now,
+		UpdatedAt:  now,
+	}
+
+	mock.ExpectQuery("INSERT INTO webhook_endpoints").
+		WithArgs(ep.URL, ep.Secret, "{\"user.login\",\"user.logout\"}", "", true, 5, 0, "", now, now).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(7))
+
+	require.NoError(t, repo.CreateEndpoint(context.Background(), ep))
+	assert.Equal(t, int64(7), ep.ID)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+// 测试GetEndpoint - 读取事件类型数组，端点不存在
+func TestWebhookRepoGetEndpoint(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := NewWebhookRepo(&Data{db: db}, log.NewStdLogger(os.Stdout))
+
+	now := time.Now()
+	rows := sqlmock.NewRows([]string{"id", "url", "secret", "event_types", "description", "enabled", "max_retries",
+		"failure_count", "disabled_reason", "created_at", "updated_at"}).
+		AddRow(7, "https://partner.example.com/hooks", "whsec_test", []byte(`{user.login,data.created}`), "partner", false, 5,
+			20, "too many failures", now, now)
+	mock.ExpectQuery("SELECT (.+) FROM webhook_endpoints WHERE id").WithArgs(int64(7)).WillReturnRows(rows)
+	mock.ExpectQuery("SELECT (.+) FROM webhook_endpoints WHERE id").WithArgs(int64(8)).WillReturnError(sql.ErrNoRows)
+
+	ep, err := repo.GetEndpoint(context.Background(), 7)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"user.login", "data.created"}, ep.EventTypes)
+	assert.False(t, ep.Enabled)
+	assert.Equal(t, 20, ep.FailureCount)
+	assert.Equal(t, "too many failures", ep.DisabledReason)
+
+	_, err = repo.GetEndpoint(context.Background(), 8)
+	assert.ErrorIs(t, err, biz.ErrWebhookNotFound)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+// 测试RecordFailure与DisableEndpoint
+func TestWebhookRepoFailures(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := NewWebhookRepo(&Data{db: db}, log.NewStdLogger(os.Stdout))
+
+	mock.ExpectQuery("UPDATE webhook_endpoints SET failure_count = failure_count \\+ 1").
+		WithArgs(int64(7)).
+		WillReturnRows(sqlmock.NewRows([]string{"failure_count"}).AddRow(3))
+	mock.ExpectExec("UPDATE webhook_endpoints SET enabled = FALSE").
+		WithArgs(int64(7), "failing", sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("UPDATE webhook_endpoints SET failure_count = 0").
+		WithArgs(int64(9)).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	count, err := repo.RecordFailure(context.Background(), 7)
+	require.NoError(t, err)
+	assert.Equal(t, 3, count)
+	assert.NoError(t, repo.DisableEndpoint(context.Background(), 7, "failing"))
+	assert.ErrorIs(t, repo.ResetFailures(context.Background(), 9), biz.ErrWebhookNotFound)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+// 测试SaveDelivery与ListDeliveries
+func TestWebhookRepoDeliveries(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := NewWebhookRepo(&Data{db: db}, log.NewStdLogger(os.Stdout))
+
+	now := time.Now()
+	delivery := &biz.WebhookDelivery{
+		EndpointID:     7,
+		EventID:        "event-1",
+		EventType:      "user.login",
+		IdempotencyKey: "event-1:webhook:7",
+		Attempt:        2,
+		StatusCode:     500,
+		Error:          "unexpected status 500",
+		Duration:       1500 * time.Millisecond,
+		CreatedAt:      now,
+	}
+	mock.ExpectQuery("INSERT INTO webhook_deliveries").
+		WithArgs(int64(7), "event-1", "user.login", "event-1:webhook:7", 2, 500, false, "unexpected status 500", "",
+			int64(1500), now).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(11))
+
+	rows := sqlmock.NewRows([]string{"id", "endpoint_id", "event_id", "event_type", "idempotency_key", "attempt", "status_code",
+		"success", "error", "response", "duration_ms", "created_at"}).
+		AddRow(11, 7, "event-1", "user.login", "event-1:webhook:7", 2, 500, false, "unexpected status 500", "", 1500, now)
+	mock.ExpectQuery("SELECT (.+) FROM webhook_deliveries").WithArgs(int64(7), 10).WillReturnRows(rows)
+
+	require.NoError(t, repo.SaveDelivery(context.Background(), delivery))
+	assert.Equal(t, int64(11), delivery.ID)
+
+	deliveries, err := repo.ListDeliveries(context.Background(), 7, 10)
+	require.NoError(t, err)
+	require.Len(t, deliveries, 1)
+	assert.Equal(t, 1500*time.Millisecond, deliveries[0].Duration)
+	assert.Equal(t, 2, deliveries[0].Attempt)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+// 测试未配置数据库
+func TestWebhookRepo_NoDatabase(t *testing.T) {
+	repo := NewWebhookRepo(nil, log.NewStdLogger(os.Stdout))
+	_, err := repo.ListEndpoints(context.Background())
+	assert.ErrorIs(t, err, errDatabaseNotConfigured)
+}
@@ -0,0 +1,200 @@
+package audit
+
+import (
+	"bufio"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// 归档文件
+//
+//	<name>.jsonl.gz       每行一条 JSON 记录，gzip 压缩
+//	<name>.manifest.json  归档清单，记录数据文件的 SHA-256 校验和与记录范围
+//
+// 数据文件先写入临时文件并同步到磁盘后再改名，清单最后写入；只有清单存在且校验和一致的归档才是完整的。
+
+const (
+	archiveDataSuffix     = ".jsonl.gz"
+	archiveManifestSuffix = ".manifest.json"
+)
+
+var ErrArchiveChecksum = errors.New("archive checksum mismatch")
+
+// ArchiveManifest 归档清单
+type ArchiveManifest struct {
+	Table     string    `json:"table"`
+	Partition string    `json:"partition"`
+	From      time.Time `json:"from"` // 分区范围，左闭右开
+	To        time.Time `json:"to"`
+	Records   int64     `json:"records"`
+	MinSeq    int64     `json:"min_seq"` // 哈希链序号范围
+	MaxSeq    int64     `json:"max_seq"`
+	File      string    `json:"file"` // 数据文件名，与清单位于同一目录
+	SHA256    string    `json:"sha256"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// ArchiveWriter 写入 gzip JSONL 归档
+type ArchiveWriter struct {
+	dir  string
+	name string
+	tmp  *os.File
+	hash hash.Hash
+	gz   *gzip.Writer
+	enc  *json.Encoder
+
+	records int64
+}
+
+// CreateArchive 在目录中创建名为 name 的归档
+func CreateArchive(dir, name string) (*ArchiveWriter, error) {
+	if err := os.MkdirAll(dir, 0750); err != nil {
+		return nil, fmt.Errorf("create archive directory: %w", err)
+	}
+	tmp, err := os.CreateTemp(dir, "."+name+"-*.tmp")
+	if err != nil {
+		return nil, fmt.Errorf("create archive file: %w", err)
+	}
+	w := &ArchiveWriter{dir: dir, name: name, tmp: tmp, hash: sha256.New()}
+	w.gz = gzip.NewWriter(io.MultiWriter(tmp, w.hash))
+	w.enc = json.NewEncoder(w.gz)
+	return w, nil
+}
+
+// Write 写入一条记录
+func (w *ArchiveWriter) Write(record interface{}) error {
+	if err := w.enc.Encode(record); err != nil {
+		return fmt.Errorf("write archive record: %w", err)
+	}
+	w.records++
+	return nil
+}
+
+// Close 完成数据文件并写入清单，清单中的文件名、记录数与校验和由写入器填写
+func (w *ArchiveWriter) Close(manifest *ArchiveManifest) (err error) {
+	defer func() {
+		if err != nil {
+			w.Abort()
+		}
+	}()
+	if err := w.gz.Close(); err != nil {
+		return fmt.Errorf("finish archive: %w", err)
+	}
+	if err := w.tmp.Sync(); err != nil {
+		return fmt.Errorf("sync archive: %w", err)
+	}
+	if err := w.tmp.Close(); err != nil {
+		return fmt.Errorf("close archive: %w", err)
+	}
+	dataFile := w.name + archiveDataSuffix
+	if err := os.Rename(w.tmp.Name(), filepath.Join(w.dir, dataFile)); err != nil {
+		return fmt.Errorf("rename archive: %w", err)
+	}
+
+	manifest.File = dataFile
+	manifest.Records = w.records
+	manifest.SHA256 = hex.EncodeToString(w.hash.Sum(nil))
+	raw, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(filepath.Join(w.dir, w.name+archiveManifestSuffix), append(raw, '\n'))
+}
+
+// Abort 放弃未完成的归档
+func (w *ArchiveWriter) Abort() {
+	_ = w.tmp.Close()
+	_ = os.Remove(w.tmp.Name())
+}
+
+// ManifestPath 返回归档清单路径
+func ManifestPath(dir, name string) string {
+	return filepath.Join(dir, name+archiveManifestSuffix)
+}
+
+// ArchiveReader 读取归档记录
+type ArchiveReader struct {
+	file *os.File
+	gz   *gzip.Reader
+	dec  *json.Decoder
+}
+
+// OpenArchive 读取清单并校验数据文件的校验和，校验通过后返回记录读取器
+func OpenArchive(manifestPath string) (*ArchiveReader, *ArchiveManifest, error) {
+	raw, err := os.ReadFile(manifestPath)
+	if err != nil {
+		return nil, nil, fmt.Errorf("read archive manifest: %w", err)
+	}
+	manifest := &ArchiveManifest{}
+	if err := json.Unmarshal(raw, manifest); err != nil {
+		return nil, nil, fmt.Errorf("parse archive manifest: %w", err)
+	}
+	if manifest.File == "" || filepath.Base(manifest.File) != manifest.File {
+		return nil, nil, fmt.Errorf("invalid archive file name %q", manifest.File)
+	}
+
+	file, err := os.Open(filepath.Join(filepath.Dir(manifestPath), manifest.File))
+	if err != nil {
+		return nil, nil, fmt.Errorf("open archive: %w", err)
+	}
+	h := sha256.New()
+	if _, err := io.Copy(h, file); err != nil {
+		file.Close()
+		return nil, nil, fmt.Errorf("read archive: %w", err)
+	}
+	if sum := hex.EncodeToString(h.Sum(nil)); sum != manifest.SHA256 {
+		file.Close()
+		return nil, nil, fmt.Errorf("%w: expected %s, got %s", ErrArchiveChecksum, manifest.SHA256, sum)
+	}
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		file.Close()
+		return nil, nil, err
+	}
+	gz, err := gzip.NewReader(bufio.NewReader(file))
+	if err != nil {
+		file.Close()
+		return nil, nil, fmt.Errorf("open archive: %w", err)
+	}
+	return &ArchiveReader{file: file, gz: gz, dec: json.NewDecoder(gz)}, manifest, nil
+}
+
+// Next 读取下一条记录，读完时返回 io.EOF
+func (r *ArchiveReader) Next(record interface{}) error {
+	return r.dec.Decode(record)
+}
+
+// Close 关闭读取器
+func (r *ArchiveReader) Close() error {
+	r.gz.Close()
+	return r.file.Close()
+}
+
+// writeFileAtomic 先写临时文件再改名，避免留下不完整的文件
+func writeFileAtomic(path string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+"-*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
@@ -0,0 +1,76 @@
+package audit
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type archiveRecord struct {
+	Seq  int64  `json:"seq"`
+	Name string `json:"name"`
+}
+
+func TestArchive(t *testing.T) {
+	dir := t.TempDir()
+	from := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	w, err := CreateArchive(dir, "operation_logs_202601")
+	require.NoError(t, err)
+	for i := int64(1); i <= 3; i++ {
+		require.NoError(t, w.Write(&archiveRecord{Seq: i, Name: "login"}))
+	}
+	require.NoError(t, w.Close(&ArchiveManifest{Table: "operation_logs", Partition: "operation_logs_202601", From: from, To: from.AddDate(0, 1, 0), MinSeq: 1, MaxSeq: 3}))
+
+	// 只留下数据文件与清单
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+
+	manifestPath := ManifestPath(dir, "operation_logs_202601")
+	r, manifest, err := OpenArchive(manifestPath)
+	require.NoError(t, err)
+	assert.Equal(t, "operation_logs_202601.jsonl.gz", manifest.File)
+	assert.Equal(t, int64(3), manifest.Records)
+	assert.Len(t, manifest.SHA256, 64)
+	assert.True(t, from.Equal(manifest.From))
+
+	var records []archiveRecord
+	for {
+		var rec archiveRecord
+		err := r.Next(&rec)
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+		records = append(records, rec)
+	}
+	require.NoError(t, r.Close())
+	assert.Equal(t, []archiveRecord{{1, "login"}, {2, "login"}, {3, "login"}}, records)
+
+	// 数据文件被修改后拒绝读取
+	dataPath := filepath.Join(dir, manifest.File)
+	raw, err := os.ReadFile(dataPath)
+	require.NoError(t, err)
+	raw[len(raw)-1] ^= 0xff
+	require.NoError(t, os.WriteFile(dataPath, raw, 0640))
+	_, _, err = OpenArchive(manifestPath)
+	assert.ErrorIs(t, err, ErrArchiveChecksum)
+}
+
+func TestArchiveWriter_Abort(t *testing.T) {
+	dir := t.TempDir()
+	w, err := CreateArchive(dir, "operation_logs_202601")
+	require.NoError(t, err)
+	require.NoError(t, w.Write(&archiveRecord{Seq: 1}))
+	w.Abort()
+
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	assert.Empty(t, entries)
+}
@@ -0,0 +1,133 @@
+// Package audit 提供审计记录的哈希链与签名检查点。
+//
+// 每条记录的哈希为 H(前一条记录的哈希 || 记录的规范编码)，以 "算法:十六进制" 形式保存，
+// 修改或删除任一记录都会使其后的链接校验失败。检查点定期以 ed25519 私钥签名链头的序号与哈希，
+// 持有数据库写权限但没有签名私钥的人无法在截断或重写链后伪造检查点。
+package audit
+
+import (
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/tjfoc/gmsm/sm3"
+)
+
+const (
+	// AlgorithmSHA256 SHA-256 哈希链
+	AlgorithmSHA256 = "sha256"
+	// AlgorithmSM3 国密 SM3 哈希链
+	AlgorithmSM3 = "sm3"
+)
+
+var ErrUnsupportedAlgorithm = errors.New("unsupported audit hash algorithm")
+
+// Chain 按指定算法计算哈希链
+type Chain struct {
+	algorithm string
+	newHash   func() hash.Hash
+}
+
+// NewChain 创建哈希链，算法为空时使用 SHA-256
+func NewChain(algorithm string) (*Chain, error) {
+	if algorithm == "" {
+		algorithm = AlgorithmSHA256
+	}
+	newHash, err := hashFunc(algorithm)
+	if err != nil {
+		return nil, err
+	}
+	return &Chain{algorithm: algorithm, newHash: newHash}, nil
+}
+
+// Algorithm 返回哈希算法
+func (c *Chain) Algorithm() string {
+	return c.algorithm
+}
+
+// Link 计算记录的链上哈希，prevHash 为链上前一条记录的哈希，首条记录为空
+func (c *Chain) Link(prevHash string, payload []byte) string {
+	return c.algorithm + ":" + digest(c.newHash, prevHash, payload)
+}
+
+// VerifyLink 按哈希中记录的算法校验记录的链上哈希
+func VerifyLink(prevHash string, payload []byte, linkHash string) (bool, error) {
+	algorithm, _, ok := strings.Cut(linkHash, ":")
+	if !ok {
+		return false, fmt.Errorf("%w: malformed hash %q", ErrUnsupportedAlgorithm, linkHash)
+	}
+	newHash, err := hashFunc(algorithm)
+	if err != nil {
+		return false, err
+	}
+	return linkHash == algorithm+":"+digest(newHash, prevHash, payload), nil
+}
+
+func hashFunc(algorithm string) (func() hash.Hash, error) {
+	switch algorithm {
+	case AlgorithmSHA256:
+		return sha256.New, nil
+	case AlgorithmSM3:
+		return sm3.New, nil
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedAlgorithm, algorithm)
+	}
+}
+
+func digest(newHash func() hash.Hash, prevHash string, payload []byte) string {
+	h := newHash()
+	h.Write(Encode(prevHash))
+	h.Write(payload)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// Encode 规范编码记录字段：每个字段以无符号变长整数长度为前缀，字段内容不会相互混淆
+func Encode(fields ...string) []byte {
+	var buf []byte
+	for _, field := range fields {
+		buf = binary.AppendUvarint(buf, uint64(len(field)))
+		buf = append(buf, field...)
+	}
+	return buf
+}
+
+// EncodeTime 规范编码时间，精确到微秒（与 Postgres 时间精度一致）
+func EncodeTime(t time.Time) string {
+	return t.UTC().Truncate(time.Microsecond).Format(time.RFC3339Nano)
+}
+
+// Checkpoint 链头的签名检查点
+type Checkpoint struct {
+	ID        int64
+	Seq       int64  // 检查点时链头记录的序号
+	Hash      string // 链头记录的哈希
+	Signature string // base64 编码的 ed25519 签名
+	CreatedAt time.Time
+}
+
+// signedBytes 检查点被签名的内容
+func (cp *Checkpoint) signedBytes() []byte {
+	return Encode("audit-checkpoint", strconv.FormatInt(cp.Seq, 10), cp.Hash, EncodeTime(cp.CreatedAt))
+}
+
+// Sign 以私钥签名检查点
+func (cp *Checkpoint) Sign(key ed25519.PrivateKey) {
+	cp.Signature = base64.StdEncoding.EncodeToString(ed25519.Sign(key, cp.signedBytes()))
+}
+
+// Verify 以公钥校验检查点签名
+func (cp *Checkpoint) Verify(key ed25519.PublicKey) bool {
+	signature, err := base64.StdEncoding.DecodeString(cp.Signature)
+	if err != nil {
+		return false
+	}
+	return ed25519.Verify(key, cp.signedBytes(), signature)
+}
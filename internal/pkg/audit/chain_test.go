@@ -0,0 +1,73 @@
+package audit
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestChain_Link(t *testing.T) {
+	for _, algorithm := range []string{AlgorithmSHA256, AlgorithmSM3} {
+		t.Run(algorithm, func(t *testing.T) {
+			chain, err := NewChain(algorithm)
+			require.NoError(t, err)
+
+			first := chain.Link("", Encode("1", "login"))
+			second := chain.Link(first, Encode("2", "logout"))
+			assert.True(t, strings.HasPrefix(first, algorithm+":"))
+			assert.Len(t, first, len(algorithm)+1+64)
+			assert.Equal(t, first, chain.Link("", Encode("1", "login")))
+
+			ok, err := VerifyLink(first, Encode("2", "logout"), second)
+			require.NoError(t, err)
+			assert.True(t, ok)
+
+			// 修改内容或前驱哈希都无法通过校验
+			ok, _ = VerifyLink(first, Encode("2", "logoff"), second)
+			assert.False(t, ok)
+			ok, _ = VerifyLink("", Encode("2", "logout"), second)
+			assert.False(t, ok)
+		})
+	}
+
+	chain, err := NewChain("")
+	require.NoError(t, err)
+	assert.Equal(t, AlgorithmSHA256, chain.Algorithm())
+
+	_, err = NewChain("md5")
+	assert.ErrorIs(t, err, ErrUnsupportedAlgorithm)
+	_, err = VerifyLink("", nil, "deadbeef")
+	assert.ErrorIs(t, err, ErrUnsupportedAlgorithm)
+}
+
+func TestEncode(t *testing.T) {
+	// 字段边界不同的编码结果不同
+	assert.NotEqual(t, Encode("ab", "c"), Encode("a", "bc"))
+	assert.Equal(t, "2026-01-02T03:04:05.123456Z",
+		EncodeTime(time.Date(2026, 1, 2, 11, 4, 5, 123456789, time.FixedZone("CST", 8*3600))))
+}
+
+func TestCheckpoint_Sign(t *testing.T) {
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	other, _, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	cp := &Checkpoint{Seq: 42, Hash: "sha256:abc", CreatedAt: time.Now()}
+	cp.Sign(priv)
+	assert.True(t, cp.Verify(pub))
+	assert.False(t, cp.Verify(other))
+
+	tampered := *cp
+	tampered.Seq = 41
+	assert.False(t, tampered.Verify(pub))
+
+	tampered = *cp
+	tampered.Signature = "not base64"
+	assert.False(t, tampered.Verify(pub))
+}
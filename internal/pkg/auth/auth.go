@@ -144,14 +144,8 @@ func (m *DefaultAuthManager) GetTokenManager() TokenManager {
 // CheckPermission 检查权限
 func (m *DefaultAuthManager) CheckPermission(ctx context.Context, subject *Subject, resource string, action string) error {
 	permission := fmt.Sprintf("%s:%s", resource, action)
-	
-	for _, perm := range subject.Permissions {
-		if perm == "*" || perm == permission {
-			return nil
-		}
-		if matchWildcard(perm, permission) {
-			return nil
-		}
+	if HasPermission(subject, permission) {
+		return nil
 	}
 	
 	return fmt.Errorf("permission denied: %s", permission)
@@ -2,12 +2,16 @@ package auth
 
 import (
 	"context"
+	"net/http"
 	"testing"
 	"time"
 
+	"github.com/go-kratos/kratos/v2/errors"
 	"github.com/go-kratos/kratos/v2/log"
+	"github.com/go-kratos/kratos/v2/transport"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 	"golang.org/x/crypto/bcrypt"
 )
 
@@ -171,6 +175,25 @@ func TestDefaultAuthManager(t *testing.T) {
 	})
 }
 
+// TestRoleMiddleware 角色中间件测试
+func TestRoleMiddleware(t *testing.T) {
+	handler := RoleMiddleware("admin")(func(ctx context.Context, req interface{}) (interface{}, error) {
+		return "ok", nil
+	})
+
+	_, err := handler(context.Background(), nil)
+	assert.Equal(t, int32(401), errors.FromError(err).Code)
+
+	ctx := context.WithValue(context.Background(), SubjectKey, &Subject{ID: "user123", Roles: []string{"user"}})
+	_, err = handler(ctx, nil)
+	assert.Equal(t, int32(403), errors.FromError(err).Code)
+
+	ctx = context.WithValue(context.Background(), SubjectKey, &Subject{ID: "admin1", Roles: []string{"user", "admin"}})
+	reply, err := handler(ctx, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "ok", reply)
+}
+
 // BenchmarkJWTTokenGeneration JWT令牌生成性能测试
 func BenchmarkJWTTokenGeneration(b *testing.B) {
 	config := &JWTConfig{
@@ -199,4 +222,62 @@ func BenchmarkJWTTokenGeneration(b *testing.B) {
 			b.Fatal(err)
 		}
 	}
-}
\ No newline at end of file
+}
+// TestHasPermission 权限检查测试
+func TestHasPermission(t *testing.T) {
+	subject := &Subject{ID: "user123", Permissions: []string{"user:read", "pii:*"}}
+
+	assert.True(t, HasPermission(subject, "user:read"))
+	assert.True(t, HasPermission(subject, "pii:read"))
+	assert.False(t, HasPermission(subject, "user:write"))
+	assert.False(t, HasPermission(nil, "user:read"))
+	assert.True(t, HasPermission(&Subject{Permissions: []string{"*"}}, "pii:read"))
+}
+
+// TestResolveSubject 可选认证主体解析测试
+func TestResolveSubject(t *testing.T) {
+	manager := NewJWTTokenManager(&JWTConfig{Secret: "test-secret", AccessExpiry: time.Hour}, log.NewHelper(log.DefaultLogger))
+	config := DefaultAuthMiddlewareConfig()
+	config.TokenManager = manager
+
+	// 上下文中已有主体时直接使用
+	existing := &Subject{ID: "ctx-user"}
+	ctx := context.WithValue(context.Background(), SubjectKey, existing)
+	assert.Equal(t, existing, ResolveSubject(ctx, config))
+
+	// 无传输信息或未携带令牌时返回 nil
+	assert.Nil(t, ResolveSubject(context.Background(), config))
+	header := testHeader(http.Header{})
+	ctx = transport.NewServerContext(context.Background(), &testTransport{header: header})
+	assert.Nil(t, ResolveSubject(ctx, config))
+
+	token, err := manager.GenerateToken(context.Background(), &Subject{ID: "user123", Permissions: []string{"pii:read"}}, TokenTypeAccess)
+	require.NoError(t, err)
+	header.Set("Authorization", "Bearer "+token.Value)
+	subject := ResolveSubject(ctx, config)
+	require.NotNil(t, subject)
+	assert.Equal(t, "user123", subject.ID)
+
+	header.Set("Authorization", "Bearer invalid")
+	assert.Nil(t, ResolveSubject(ctx, config))
+}
+
+// testHeader 基于 http.Header 的请求头
+type testHeader http.Header
+
+func (h testHeader) Get(key string) string      { return http.Header(h).Get(key) }
+func (h testHeader) Set(key, value string)      { http.Header(h).Set(key, value) }
+func (h testHeader) Add(key, value string)      { http.Header(h).Add(key, value) }
+func (h testHeader) Keys() []string             { return nil }
+func (h testHeader) Values(key string) []string { return http.Header(h).Values(key) }
+
+// testTransport 测试用传输信息
+type testTransport struct {
+	header transport.Header
+}
+
+func (t *testTransport) Kind() transport.Kind            { return transport.KindHTTP }
+func (t *testTransport) Endpoint() string                { return "" }
+func (t *testTransport) Operation() string               { return "/test" }
+func (t *testTransport) RequestHeader() transport.Header { return t.header }
+func (t *testTransport) ReplyHeader() transport.Header   { return testHeader(http.Header{}) }
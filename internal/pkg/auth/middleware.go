@@ -76,6 +76,50 @@ func AuthMiddleware(config *AuthMiddlewareConfig) middleware.Middleware {
 	}
 }
 
+// RoleMiddleware 创建角色中间件，要求认证主体至少拥有其中一个角色
+// 需放在 AuthMiddleware 之后
+func RoleMiddleware(roles ...string) middleware.Middleware {
+	return func(handler middleware.Handler) middleware.Handler {
+		return func(ctx context.Context, req interface{}) (interface{}, error) {
+			subject := GetSubjectFromContext(ctx)
+			if subject == nil {
+				return nil, errors.Unauthorized("AUTH_TOKEN_MISSING", "Authentication token is required")
+			}
+
+			if !HasAnyRole(subject, roles...) {
+				return nil, errors.Forbidden("AUTH_ROLE_REQUIRED", "Insufficient role for this operation")
+			}
+
+			return handler(ctx, req)
+		}
+	}
+}
+
+// HasAnyRole 检查主体是否拥有任一角色
+func HasAnyRole(subject *Subject, roles ...string) bool {
+	for _, have := range subject.Roles {
+		for _, want := range roles {
+			if have == want {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// HasPermission 检查主体是否拥有权限，支持 * 与 resource:* 通配
+func HasPermission(subject *Subject, permission string) bool {
+	if subject == nil {
+		return false
+	}
+	for _, perm := range subject.Permissions {
+		if perm == permission || matchWildcard(perm, permission) {
+			return true
+		}
+	}
+	return false
+}
+
 // GetSubjectFromContext 从上下文获取主体
 func GetSubjectFromContext(ctx context.Context) *Subject {
 	if subject, ok := ctx.Value(SubjectKey).(*Subject); ok {
@@ -84,6 +128,30 @@ func GetSubjectFromContext(ctx context.Context) *Subject {
 	return nil
 }
 
+// ResolveSubject 获取调用方主体：优先使用认证中间件放入上下文的主体，
+// 否则校验请求头中的令牌。未携带或令牌无效时返回 nil，不拒绝请求
+func ResolveSubject(ctx context.Context, config *AuthMiddlewareConfig) *Subject {
+	if subject := GetSubjectFromContext(ctx); subject != nil {
+		return subject
+	}
+	if config == nil || config.TokenManager == nil {
+		return nil
+	}
+	tr, ok := transport.FromServerContext(ctx)
+	if !ok {
+		return nil
+	}
+	token, err := extractToken(tr, config)
+	if err != nil {
+		return nil
+	}
+	subject, err := config.TokenManager.VerifyToken(ctx, token)
+	if err != nil {
+		return nil
+	}
+	return subject
+}
+
 // extractToken 提取令牌
 func extractToken(tr transport.Transporter, config *AuthMiddlewareConfig) (string, error) {
 	if header, ok := tr.(interface{ RequestHeader() transport.Header }); ok {
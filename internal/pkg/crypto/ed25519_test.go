@@ -0,0 +1,30 @@
+package crypto
+
+import (
+	"crypto/ed25519"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSigningKey(t *testing.T) {
+	pub, priv, err := GenerateSigningKey()
+	require.NoError(t, err)
+
+	publicKey, err := DecodePublicKey(pub)
+	require.NoError(t, err)
+	privateKey, err := DecodePrivateKey(priv + "\n")
+	require.NoError(t, err)
+	assert.Equal(t, publicKey, privateKey.Public().(ed25519.PublicKey))
+
+	signature := ed25519.Sign(privateKey, []byte("checkpoint"))
+	assert.True(t, ed25519.Verify(publicKey, []byte("checkpoint"), signature))
+
+	_, err = DecodePublicKey("not base64!")
+	assert.Error(t, err)
+	_, err = DecodePublicKey("c2hvcnQ=")
+	assert.ErrorContains(t, err, "invalid ed25519 public key length")
+	_, err = DecodePrivateKey(pub + pub)
+	assert.Error(t, err)
+}
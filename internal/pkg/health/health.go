@@ -261,6 +261,19 @@ func (hc *HealthChecker) GetHTTPHandler() http.HandlerFunc {
 	}
 }
 
+// GetLivenessHTTPHandler 获取存活探针HTTP处理器，只确认服务能够响应，不执行依赖与插件检查
+func (hc *HealthChecker) GetLivenessHTTPHandler() http.HandlerFunc {
+	liveness := NewLivenessChecker(hc)
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+
+		if err := json.NewEncoder(w).Encode(liveness.Check(r.Context())); err != nil {
+			hc.logger.Log(log.LevelError, "msg", "failed to encode liveness response", "error", err)
+		}
+	}
+}
+
 // PrebuiltCheckers 预构建的检查器
 
 // DatabaseChecker 数据库检查器
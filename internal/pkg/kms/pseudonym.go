@@ -0,0 +1,40 @@
+package kms
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"kratos-boilerplate/internal/pkg/sensitive"
+)
+
+// pseudonymKeyProvider 由根密钥派生假名化数据集密钥。
+// 不使用数据密钥：数据密钥会定期轮换，轮换后令牌改变会破坏导出数据的关联性。
+type pseudonymKeyProvider struct {
+	rootKeyGen RootKeyGenerator
+
+	once     sync.Once
+	provider sensitive.KeyProvider
+	err      error
+}
+
+// NewPseudonymKeyProvider 创建KMS假名化密钥提供者，根密钥在首次使用时生成
+func NewPseudonymKeyProvider(rootKeyGen RootKeyGenerator) sensitive.KeyProvider {
+	return &pseudonymKeyProvider{rootKeyGen: rootKeyGen}
+}
+
+// DatasetKey 获取数据集密钥
+func (p *pseudonymKeyProvider) DatasetKey(ctx context.Context, dataset string) ([]byte, error) {
+	p.once.Do(func() {
+		rootKey, err := p.rootKeyGen.GenerateRootKey()
+		if err != nil {
+			p.err = fmt.Errorf("failed to generate root key: %w", err)
+			return
+		}
+		p.provider = sensitive.NewDerivedKeyProvider(rootKey)
+	})
+	if p.err != nil {
+		return nil, p.err
+	}
+	return p.provider.DatasetKey(ctx, dataset)
+}
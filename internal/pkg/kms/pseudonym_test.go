@@ -0,0 +1,39 @@
+package kms
+
+import (
+	"context"
+	"testing"
+
+	"kratos-boilerplate/internal/biz"
+	"kratos-boilerplate/internal/pkg/sensitive"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestPseudonymKeyProvider 测试假名化密钥由根密钥派生且按数据集隔离
+func TestPseudonymKeyProvider(t *testing.T) {
+	config := &biz.KMSConfig{
+		Seed:       "test-seed",
+		Salt:       "test-salt",
+		Iterations: 10000,
+		KeyLength:  32,
+	}
+	generator := NewRootKeyGenerator(config)
+	provider := NewPseudonymKeyProvider(generator)
+	ctx := context.Background()
+
+	key, err := provider.DatasetKey(ctx, "orders")
+	require.NoError(t, err)
+	rootKey, err := generator.GenerateRootKey()
+	require.NoError(t, err)
+	assert.Equal(t, sensitive.DeriveDatasetKey(rootKey, "orders"), key)
+
+	other, err := provider.DatasetKey(ctx, "marketing")
+	require.NoError(t, err)
+	assert.NotEqual(t, key, other)
+
+	// 配置无效时返回错误
+	_, err = NewPseudonymKeyProvider(NewRootKeyGenerator(&biz.KMSConfig{})).DatasetKey(ctx, "orders")
+	assert.Error(t, err)
+}
@@ -0,0 +1,365 @@
+package log
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/go-kratos/kratos/v2/config"
+	kratoslog "github.com/go-kratos/kratos/v2/log"
+)
+
+const (
+	// ModuleKey 模块字段，经由 log.With(logger, "module", "biz.auth") 绑定，按模块级别过滤
+	ModuleKey = "module"
+	// RootModule 根模块，没有模块字段或未单独设置级别的模块使用根模块的级别
+	RootModule = "root"
+)
+
+// 模块级别的来源
+const (
+	LevelSourceDefault = "default"
+	LevelSourceConfig  = "config"
+	LevelSourceAdmin   = "admin"
+)
+
+var (
+	// ErrInvalidModule 模块名不合法
+	ErrInvalidModule = errors.New("invalid log module")
+	// ErrInvalidLevel 日志级别不合法
+	ErrInvalidLevel = errors.New("invalid log level")
+)
+
+// moduleNamePattern 模块名由点分隔的层级组成，如 biz.auth、data.kms
+var moduleNamePattern = regexp.MustCompile(`^[A-Za-z0-9_-]+(\.[A-Za-z0-9_-]+)*$`)
+
+// ModuleLevel 模块当前生效的日志级别
+type ModuleLevel struct {
+	Module string
+	Level  kratoslog.Level
+	// Source 生效级别的来源：default、config 或 admin
+	Source string
+	// From 生效级别设置在哪个模块上，沿用上级模块时为上级模块
+	From string
+	// ExpiresAt 管理接口设置的级别到期后恢复，为零值时不过期
+	ExpiresAt time.Time
+}
+
+// adminLevel 经由管理接口设置的级别
+type adminLevel struct {
+	level     kratoslog.Level
+	expiresAt time.Time
+	timer     *time.Timer
+}
+
+// LevelRegistry 按模块保存日志级别。每个模块的生效级别保存在各自的原子变量中，
+// 写日志时只读取原子变量；级别变化时重新计算所有模块
+type LevelRegistry struct {
+	mu      sync.Mutex
+	root    kratoslog.Level
+	config  map[string]kratoslog.Level
+	admin   map[string]*adminLevel
+	modules sync.Map // module -> *atomic.Int32
+	now     func() time.Time
+}
+
+// NewLevelRegistry 创建模块级别注册表，root 为 log.level 配置的级别
+func NewLevelRegistry(root kratoslog.Level) *LevelRegistry {
+	return &LevelRegistry{
+		root:   root,
+		config: make(map[string]kratoslog.Level),
+		admin:  make(map[string]*adminLevel),
+		now:    time.Now,
+	}
+}
+
+// ParseLevel 解析日志级别，不区分大小写；与 kratos 的 ParseLevel 不同，未知级别返回错误
+func ParseLevel(level string) (kratoslog.Level, error) {
+	switch strings.ToLower(strings.TrimSpace(level)) {
+	case "debug":
+		return kratoslog.LevelDebug, nil
+	case "info":
+		return kratoslog.LevelInfo, nil
+	case "warn", "warning":
+		return kratoslog.LevelWarn, nil
+	case "error":
+		return kratoslog.LevelError, nil
+	case "fatal":
+		return kratoslog.LevelFatal, nil
+	}
+	return 0, fmt.Errorf("%w: %q", ErrInvalidLevel, level)
+}
+
+// Filter 返回按模块级别过滤的日志器，模块名取自日志中的 module 字段
+func (r *LevelRegistry) Filter(logger kratoslog.Logger) kratoslog.Logger {
+	return &levelFilter{next: logger, levels: r}
+}
+
+// Enabled 判断模块是否输出该级别的日志
+func (r *LevelRegistry) Enabled(module string, level kratoslog.Level) bool {
+	if module == "" {
+		module = RootModule
+	}
+	v, ok := r.modules.Load(module)
+	if !ok {
+		r.mu.Lock()
+		v, ok = r.modules.Load(module)
+		if !ok {
+			l := new(atomic.Int32)
+			l.Store(int32(r.resolve(module).Level))
+			r.modules.Store(module, l)
+			v = l
+		}
+		r.mu.Unlock()
+	}
+	return level >= kratoslog.Level(v.(*atomic.Int32).Load())
+}
+
+// Get 返回模块当前生效的级别
+func (r *LevelRegistry) Get(module string) *ModuleLevel {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	m := r.resolve(module)
+	return &m
+}
+
+// Set 经由管理接口设置模块级别，ttl 大于 0 时到期后恢复为配置的级别
+func (r *LevelRegistry) Set(module, level string, ttl time.Duration) (*ModuleLevel, error) {
+	if !moduleNamePattern.MatchString(module) {
+		return nil, fmt.Errorf("%w: %q", ErrInvalidModule, module)
+	}
+	lvl, err := ParseLevel(level)
+	if err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.removeAdmin(module)
+	a := &adminLevel{level: lvl}
+	if ttl > 0 {
+		a.expiresAt = r.now().Add(ttl)
+		a.timer = time.AfterFunc(ttl, func() { r.expire(module, a) })
+	}
+	r.admin[module] = a
+	r.refresh(module)
+	m := r.resolve(module)
+	return &m, nil
+}
+
+// Reset 撤销经由管理接口设置的模块级别，恢复为配置的级别；没有设置时返回 false
+func (r *LevelRegistry) Reset(module string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if !r.removeAdmin(module) {
+		return false
+	}
+	r.refresh(module)
+	return true
+}
+
+// ApplyConfig 替换配置的模块级别，任一级别不合法时保持原配置不变；管理接口设置的级别仍然优先
+func (r *LevelRegistry) ApplyConfig(levels map[string]string) error {
+	config := make(map[string]kratoslog.Level, len(levels))
+	for module, level := range levels {
+		if !moduleNamePattern.MatchString(module) {
+			return fmt.Errorf("%w: %q", ErrInvalidModule, module)
+		}
+		lvl, err := ParseLevel(level)
+		if err != nil {
+			return fmt.Errorf("module %s: %w", module, err)
+		}
+		config[module] = lvl
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.config = config
+	r.refresh("")
+	return nil
+}
+
+// WatchConfig 监听配置中的 log 节点，log.levels 变化时（包括启动后才加入）替换配置的模块级别；
+// 新配置不合法时保持原级别并记录错误。kratos 合并新配置时保留已删除的键，删除模块不会生效，需改为其他级别。
+// 配置中没有 log 节点时无法监听，返回错误
+func (r *LevelRegistry) WatchConfig(c config.Config) error {
+	var mu sync.Mutex
+	current, err := scanConfigLevels(c.Value("log"))
+	if err != nil {
+		return err
+	}
+	return c.Watch("log", func(key string, value config.Value) {
+		levels, err := scanConfigLevels(value)
+		if err != nil {
+			kratoslog.Errorf("Failed to read %s.levels: %v", key, err)
+			return
+		}
+
+		mu.Lock()
+		defer mu.Unlock()
+		if reflect.DeepEqual(levels, current) {
+			return
+		}
+		if err := r.ApplyConfig(levels); err != nil {
+			kratoslog.Errorf("Failed to apply %s.levels: %v", key, err)
+			return
+		}
+		current = levels
+		kratoslog.Infof("Applied %s.levels: %v", key, levels)
+	})
+}
+
+// scanConfigLevels 读取 log 节点下的 levels，未配置时返回空表
+func scanConfigLevels(value config.Value) (map[string]string, error) {
+	var section struct {
+		Levels map[string]string `json:"levels"`
+	}
+	if err := value.Scan(&section); err != nil {
+		return nil, err
+	}
+	if section.Levels == nil {
+		section.Levels = map[string]string{}
+	}
+	return section.Levels, nil
+}
+
+// List 返回所有已知模块的生效级别，按模块名排序，根模块在最前
+func (r *LevelRegistry) List() []*ModuleLevel {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	names := map[string]struct{}{RootModule: {}}
+	r.modules.Range(func(key, _ interface{}) bool {
+		names[key.(string)] = struct{}{}
+		return true
+	})
+	for module := range r.config {
+		names[module] = struct{}{}
+	}
+	for module := range r.admin {
+		names[module] = struct{}{}
+	}
+	levels := make([]*ModuleLevel, 0, len(names))
+	for module := range names {
+		m := r.resolve(module)
+		levels = append(levels, &m)
+	}
+	sort.Slice(levels, func(i, j int) bool {
+		if levels[i].Module == RootModule || levels[j].Module == RootModule {
+			return levels[i].Module == RootModule
+		}
+		return levels[i].Module < levels[j].Module
+	})
+	return levels
+}
+
+// Close 停止所有到期恢复的定时器
+func (r *LevelRegistry) Close() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, a := range r.admin {
+		if a.timer != nil {
+			a.timer.Stop()
+		}
+	}
+}
+
+// expire 管理接口设置的级别到期，期间被重新设置时不处理
+func (r *LevelRegistry) expire(module string, a *adminLevel) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.admin[module] != a {
+		return
+	}
+	delete(r.admin, module)
+	r.refresh(module)
+}
+
+// removeAdmin 删除管理接口设置的级别并停止其定时器，调用方持有锁
+func (r *LevelRegistry) removeAdmin(module string) bool {
+	a, ok := r.admin[module]
+	if !ok {
+		return false
+	}
+	if a.timer != nil {
+		a.timer.Stop()
+	}
+	delete(r.admin, module)
+	return true
+}
+
+// refresh 重新计算 module 及其下级模块的生效级别，module 为空或为根模块时重新计算全部，调用方持有锁
+func (r *LevelRegistry) refresh(module string) {
+	all := module == "" || module == RootModule
+	r.modules.Range(func(key, value interface{}) bool {
+		name := key.(string)
+		if all || name == module || strings.HasPrefix(name, module+".") {
+			value.(*atomic.Int32).Store(int32(r.resolve(name).Level))
+		}
+		return true
+	})
+}
+
+// resolve 计算模块的生效级别：依次查找模块自身与各级上级模块，同一模块上管理接口设置的级别优先于配置，
+// 都没有设置时使用根模块的级别，调用方持有锁
+func (r *LevelRegistry) resolve(module string) ModuleLevel {
+	for name := module; name != ""; name = parentModule(name) {
+		if m, ok := r.lookup(module, name); ok {
+			return m
+		}
+	}
+	if module != RootModule {
+		if m, ok := r.lookup(module, RootModule); ok {
+			return m
+		}
+	}
+	return ModuleLevel{Module: module, Level: r.root, Source: LevelSourceDefault, From: RootModule}
+}
+
+// lookup 查找直接设置在 name 上的级别
+func (r *LevelRegistry) lookup(module, name string) (ModuleLevel, bool) {
+	if a, ok := r.admin[name]; ok {
+		return ModuleLevel{Module: module, Level: a.level, Source: LevelSourceAdmin, From: name, ExpiresAt: a.expiresAt}, true
+	}
+	if l, ok := r.config[name]; ok {
+		return ModuleLevel{Module: module, Level: l, Source: LevelSourceConfig, From: name}, true
+	}
+	return ModuleLevel{}, false
+}
+
+// parentModule 上级模块名，biz.auth 的上级为 biz，顶层模块没有上级
+func parentModule(module string) string {
+	if i := strings.LastIndexByte(module, '.'); i > 0 {
+		return module[:i]
+	}
+	return ""
+}
+
+// levelFilter 按模块级别过滤的 kratos 日志器。log.With 绑定的字段与本次日志的字段一起传入，
+// 同时存在多个 module 字段时以最后一个为准
+type levelFilter struct {
+	next   kratoslog.Logger
+	levels *LevelRegistry
+}
+
+// Log 实现 kratoslog.Logger 接口
+func (f *levelFilter) Log(level kratoslog.Level, keyvals ...interface{}) error {
+	var module string
+	for i := 0; i+1 < len(keyvals); i += 2 {
+		if key, ok := keyvals[i].(string); ok && key == ModuleKey {
+			if name, ok := keyvals[i+1].(string); ok {
+				module = name
+			}
+		}
+	}
+	if !f.levels.Enabled(module, level) {
+		return nil
+	}
+	return f.next.Log(level, keyvals...)
+}
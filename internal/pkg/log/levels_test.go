@@ -0,0 +1,162 @@
+package log
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/go-kratos/kratos/v2/config"
+	"github.com/go-kratos/kratos/v2/config/file"
+	kratoslog "github.com/go-kratos/kratos/v2/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+// TestLevelRegistry_Resolve 测试模块沿用上级模块与根模块的级别，管理接口设置的级别优先于配置
+func TestLevelRegistry_Resolve(t *testing.T) {
+	r := NewLevelRegistry(kratoslog.LevelInfo)
+	require.NoError(t, r.ApplyConfig(map[string]string{"biz": "warn", "data.kms": "debug"}))
+
+	assert.False(t, r.Enabled("biz.auth", kratoslog.LevelInfo))
+	assert.True(t, r.Enabled("data.kms", kratoslog.LevelDebug))
+	assert.False(t, r.Enabled("data.auth", kratoslog.LevelDebug))
+	assert.True(t, r.Enabled("", kratoslog.LevelInfo))
+
+	m, err := r.Set("biz.auth", "DEBUG", 0)
+	require.NoError(t, err)
+	assert.Equal(t, &ModuleLevel{Module: "biz.auth", Level: kratoslog.LevelDebug, Source: LevelSourceAdmin, From: "biz.auth"}, m)
+	assert.True(t, r.Enabled("biz.auth", kratoslog.LevelDebug))
+	assert.True(t, r.Enabled("biz.auth.token", kratoslog.LevelDebug))
+	assert.False(t, r.Enabled("biz.webhook", kratoslog.LevelInfo))
+
+	// 配置变化不覆盖管理接口设置的级别
+	require.NoError(t, r.ApplyConfig(map[string]string{"biz.auth": "error"}))
+	assert.True(t, r.Enabled("biz.auth", kratoslog.LevelDebug))
+	assert.True(t, r.Enabled("biz.webhook", kratoslog.LevelInfo))
+	assert.True(t, r.Reset("biz.auth"))
+	assert.False(t, r.Reset("biz.auth"))
+	assert.False(t, r.Enabled("biz.auth", kratoslog.LevelWarn))
+
+	// 设置根模块影响所有未单独设置的模块
+	_, err = r.Set(RootModule, "error", 0)
+	require.NoError(t, err)
+	assert.False(t, r.Enabled("biz.webhook", kratoslog.LevelWarn))
+	assert.False(t, r.Enabled("", kratoslog.LevelWarn))
+
+	levels := r.List()
+	require.NotEmpty(t, levels)
+	assert.Equal(t, RootModule, levels[0].Module)
+	assert.Equal(t, LevelSourceAdmin, levels[0].Source)
+	var modules []string
+	for _, l := range levels[1:] {
+		modules = append(modules, l.Module)
+	}
+	assert.Equal(t, []string{"biz.auth", "biz.auth.token", "biz.webhook", "data.auth", "data.kms"}, modules)
+}
+
+// TestLevelRegistry_Invalid 测试不合法的模块与级别，配置不合法时保持原配置
+func TestLevelRegistry_Invalid(t *testing.T) {
+	r := NewLevelRegistry(kratoslog.LevelInfo)
+	require.NoError(t, r.ApplyConfig(map[string]string{"biz": "warn"}))
+
+	_, err := r.Set("biz..auth", "debug", 0)
+	assert.ErrorIs(t, err, ErrInvalidModule)
+	_, err = r.Set("biz.auth", "verbose", 0)
+	assert.ErrorIs(t, err, ErrInvalidLevel)
+
+	assert.ErrorIs(t, r.ApplyConfig(map[string]string{"biz": "loud"}), ErrInvalidLevel)
+	assert.Equal(t, LevelSourceConfig, r.Get("biz.auth").Source)
+	assert.False(t, r.Enabled("biz.auth", kratoslog.LevelInfo))
+}
+
+// TestLevelRegistry_TTL 测试管理接口设置的级别到期后恢复，期间重新设置时以新的设置为准
+func TestLevelRegistry_TTL(t *testing.T) {
+	r := NewLevelRegistry(kratoslog.LevelInfo)
+	defer r.Close()
+
+	m, err := r.Set("plugin", "debug", 50*time.Millisecond)
+	require.NoError(t, err)
+	assert.False(t, m.ExpiresAt.IsZero())
+	assert.True(t, r.Enabled("plugin", kratoslog.LevelDebug))
+	assert.Eventually(t, func() bool { return !r.Enabled("plugin", kratoslog.LevelDebug) }, time.Second, 10*time.Millisecond)
+	assert.Equal(t, LevelSourceDefault, r.Get("plugin").Source)
+
+	_, err = r.Set("plugin", "debug", 50*time.Millisecond)
+	require.NoError(t, err)
+	_, err = r.Set("plugin", "warn", 0)
+	require.NoError(t, err)
+	time.Sleep(100 * time.Millisecond)
+	assert.False(t, r.Enabled("plugin", kratoslog.LevelInfo))
+	assert.True(t, r.Get("plugin").ExpiresAt.IsZero())
+}
+
+// TestLevelRegistry_Filter 测试按 log.With 绑定的模块字段过滤日志
+func TestLevelRegistry_Filter(t *testing.T) {
+	observedCore, observedLogs := observer.New(zapcore.DebugLevel)
+	r := NewLevelRegistry(kratoslog.LevelInfo)
+	logger := kratoslog.With(r.Filter(&zapLogger{zap: zap.New(observedCore), level: zapcore.DebugLevel, config: DefaultConfig()}), "service.name", "test")
+
+	auth := kratoslog.NewHelper(kratoslog.With(logger, ModuleKey, "biz.auth"))
+	kms := kratoslog.NewHelper(kratoslog.With(logger, ModuleKey, "data.kms"))
+	auth.Debug("auth debug")
+	kms.Info("kms info")
+
+	_, err := r.Set("biz", "debug", 0)
+	require.NoError(t, err)
+	auth.Debug("auth debug after set")
+	kms.Debug("kms debug")
+	kratoslog.NewHelper(logger).Debug("root debug")
+
+	var messages []string
+	for _, entry := range observedLogs.All() {
+		messages = append(messages, entry.Message)
+	}
+	assert.Equal(t, []string{"kms info", "auth debug after set"}, messages)
+}
+
+// TestLevelRegistry_WatchConfig 测试启动后才加入的 log.levels 也能生效，不合法的配置保持原级别
+func TestLevelRegistry_WatchConfig(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	write := func(content string) {
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	}
+	write("log:\n  level: info\n")
+
+	c := config.New(config.WithSource(file.NewSource(path)))
+	defer c.Close()
+	require.NoError(t, c.Load())
+
+	r := NewLevelRegistry(kratoslog.LevelInfo)
+	require.NoError(t, r.WatchConfig(c))
+	assert.False(t, r.Enabled("biz.auth", kratoslog.LevelDebug))
+
+	write("log:\n  level: info\n  levels:\n    biz.auth: debug\n")
+	assert.Eventually(t, func() bool {
+		return r.Enabled("biz.auth", kratoslog.LevelDebug)
+	}, 5*time.Second, 20*time.Millisecond)
+
+	write("log:\n  level: info\n  levels:\n    biz.auth: verbose\n")
+	time.Sleep(200 * time.Millisecond)
+	assert.True(t, r.Enabled("biz.auth", kratoslog.LevelDebug))
+
+	write("log:\n  level: info\n  levels:\n    biz.auth: warn\n")
+	assert.Eventually(t, func() bool {
+		return !r.Enabled("biz.auth", kratoslog.LevelInfo)
+	}, 5*time.Second, 20*time.Millisecond)
+}
+
+// TestLevelRegistry_WatchConfigWithoutLog 测试配置中没有 log 节点时返回错误
+func TestLevelRegistry_WatchConfigWithoutLog(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(path, []byte("server:\n  http:\n    addr: 0.0.0.0:8000\n"), 0o600))
+
+	c := config.New(config.WithSource(file.NewSource(path)))
+	defer c.Close()
+	require.NoError(t, c.Load())
+
+	assert.ErrorIs(t, NewLevelRegistry(kratoslog.LevelInfo).WatchConfig(c), config.ErrNotFound)
+}
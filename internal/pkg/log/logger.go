@@ -10,7 +10,10 @@ import (
 	"sync"
 	"time"
 
+	"kratos-boilerplate/internal/pkg/sensitive"
+
 	kratoslog "github.com/go-kratos/kratos/v2/log"
+	"go.opentelemetry.io/otel/trace"
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
 	"gopkg.in/natefinch/lumberjack.v2"
@@ -197,8 +200,8 @@ func NewLogger(config *Config) (Logger, error) {
 	// 创建输出
 	writeSyncer := getWriteSyncer(config)
 
-	// 创建核心
-	core := zapcore.NewCore(encoder, writeSyncer, level)
+	// 创建核心，所有日志在编码前脱敏
+	core := NewSanitizingCore(zapcore.NewCore(encoder, writeSyncer, level), sensitive.NewKeyValueSanitizer(nil))
 
 	// 应用采样（如果配置了）
 	if config.SampleConfig != nil {
@@ -250,26 +253,36 @@ func (l *zapLogger) Log(level kratoslog.Level, keyvals ...interface{}) error {
 		return nil
 	}
 
+	// kratos 的 msg 作为日志消息，采样按消息区分，消息为空时所有日志会被当作同一条采样
+	var msg string
 	fields := make([]zap.Field, 0, len(keyvals)/2)
 	for i := 0; i < len(keyvals); i += 2 {
 		if i+1 < len(keyvals) {
 			key := fmt.Sprintf("%v", keyvals[i])
 			value := keyvals[i+1]
+			if s, ok := value.(string); ok && key == kratoslog.DefaultMessageKey && msg == "" {
+				msg = s
+				continue
+			}
+			// WithContextFields 绑定的字段在上下文中没有值时不输出
+			if s, ok := value.(string); ok && s == "" && isContextFieldKey(key) {
+				continue
+			}
 			fields = append(fields, zap.Any(key, value))
 		}
 	}
 
 	switch zapLevel {
 	case zapcore.DebugLevel:
-		l.zap.Debug("", fields...)
+		l.zap.Debug(msg, fields...)
 	case zapcore.InfoLevel:
-		l.zap.Info("", fields...)
+		l.zap.Info(msg, fields...)
 	case zapcore.WarnLevel:
-		l.zap.Warn("", fields...)
+		l.zap.Warn(msg, fields...)
 	case zapcore.ErrorLevel:
-		l.zap.Error("", fields...)
+		l.zap.Error(msg, fields...)
 	case zapcore.FatalLevel:
-		l.zap.Fatal("", fields...)
+		l.zap.Fatal(msg, fields...)
 	}
 
 	return nil
@@ -532,23 +545,38 @@ const (
 	RequestIDKey contextKey = "request_id"
 )
 
-// getTraceIDFromContext 从上下文获取trace_id
+// isContextFieldKey 是否为从上下文提取的字段
+func isContextFieldKey(key string) bool {
+	switch contextKey(key) {
+	case TraceIDKey, SpanIDKey, UserIDKey, RequestIDKey:
+		return true
+	}
+	return false
+}
+
+// getTraceIDFromContext 从上下文获取trace_id，未设置时使用 OpenTelemetry 链路的 trace_id
 func getTraceIDFromContext(ctx context.Context) string {
 	if val := ctx.Value(TraceIDKey); val != nil {
 		if traceID, ok := val.(string); ok {
 			return traceID
 		}
 	}
+	if sc := trace.SpanContextFromContext(ctx); sc.HasTraceID() {
+		return sc.TraceID().String()
+	}
 	return ""
 }
 
-// getSpanIDFromContext 从上下文获取span_id
+// getSpanIDFromContext 从上下文获取span_id，未设置时使用 OpenTelemetry 链路的 span_id
 func getSpanIDFromContext(ctx context.Context) string {
 	if val := ctx.Value(SpanIDKey); val != nil {
 		if spanID, ok := val.(string); ok {
 			return spanID
 		}
 	}
+	if sc := trace.SpanContextFromContext(ctx); sc.HasSpanID() {
+		return sc.SpanID().String()
+	}
 	return ""
 }
 
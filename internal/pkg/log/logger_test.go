@@ -360,18 +360,15 @@ func TestKratosLoggerInterface(t *testing.T) {
 
 	assert.Equal(t, zapcore.InfoLevel, logs[0].Level) // Level 1 maps to Info
 
-	// 查找字段
-	msgFound := false
+	// msg 作为日志消息，其余键值作为字段
+	assert.Equal(t, "test message", logs[0].Message)
 	keyFound := false
 	for _, field := range logs[0].Context {
-		if field.Key == "msg" && field.String == "test message" {
-			msgFound = true
-		}
+		assert.NotEqual(t, "msg", field.Key)
 		if field.Key == "key" && field.String == "value" {
 			keyFound = true
 		}
 	}
-	assert.True(t, msgFound, "msg field not found")
 	assert.True(t, keyFound, "key field not found")
 }
 
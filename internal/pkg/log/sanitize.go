@@ -0,0 +1,87 @@
+package log
+
+import (
+	"kratos-boilerplate/internal/pkg/sensitive"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// sanitizingCore 在写入前对消息与字段脱敏的 zap core
+type sanitizingCore struct {
+	zapcore.Core
+	sanitizer *sensitive.KeyValueSanitizer
+}
+
+// NewSanitizingCore 包装 zap core，每条日志的消息与字段（包括 With 绑定的字段）在写入前脱敏
+func NewSanitizingCore(core zapcore.Core, sanitizer *sensitive.KeyValueSanitizer) zapcore.Core {
+	if sanitizer == nil {
+		sanitizer = sensitive.NewKeyValueSanitizer(nil)
+	}
+	return &sanitizingCore{Core: core, sanitizer: sanitizer}
+}
+
+// With 绑定字段前先脱敏
+func (c *sanitizingCore) With(fields []zapcore.Field) zapcore.Core {
+	return &sanitizingCore{Core: c.Core.With(c.sanitizeFields(fields)), sanitizer: c.sanitizer}
+}
+
+// Check 由本 core 写入，不能交给下层 core 的 Check，否则会绕过脱敏
+func (c *sanitizingCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(ent.Level) {
+		return ce.AddCore(ent, c)
+	}
+	return ce
+}
+
+// Write 脱敏后写入下层 core
+func (c *sanitizingCore) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	ent.Message = c.sanitizer.SanitizeMessage(ent.Message)
+	return c.Core.Write(ent, c.sanitizeFields(fields))
+}
+
+// sanitizeFields 脱敏字段，返回新的切片
+func (c *sanitizingCore) sanitizeFields(fields []zapcore.Field) []zapcore.Field {
+	if !c.sanitizer.Enabled() || len(fields) == 0 {
+		return fields
+	}
+	result := make([]zapcore.Field, len(fields))
+	for i, f := range fields {
+		result[i] = c.sanitizeField(f)
+	}
+	return result
+}
+
+func (c *sanitizingCore) sanitizeField(f zapcore.Field) zapcore.Field {
+	switch f.Type {
+	case zapcore.SkipType, zapcore.NamespaceType:
+		return f
+	case zapcore.StringType:
+		if sanitized, ok := c.sanitizer.SanitizeValue(f.Key, f.String).(string); ok {
+			f.String = sanitized
+			return f
+		}
+	case zapcore.BoolType, zapcore.Int64Type, zapcore.Int32Type, zapcore.Int16Type, zapcore.Int8Type,
+		zapcore.Uint64Type, zapcore.Uint32Type, zapcore.Uint16Type, zapcore.Uint8Type, zapcore.UintptrType,
+		zapcore.Float64Type, zapcore.Float32Type, zapcore.DurationType, zapcore.TimeType, zapcore.TimeFullType:
+		// 数值类字段只按键名处理，保留原类型
+		if !c.sanitizer.SensitiveKey(f.Key) {
+			return f
+		}
+	case zapcore.ReflectType:
+		return zap.Any(f.Key, c.sanitizer.SanitizeValue(f.Key, f.Interface))
+	case zapcore.ErrorType:
+		if err, ok := f.Interface.(error); ok {
+			return zap.String(f.Key, c.sanitizer.SanitizeMessage(err.Error()))
+		}
+	}
+
+	// 其他字段（Stringer、ObjectMarshaler 等）编码为普通值后脱敏
+	enc := zapcore.NewMapObjectEncoder()
+	f.AddTo(enc)
+	value, ok := enc.Fields[f.Key]
+	if !ok {
+		return f
+	}
+	return zap.Any(f.Key, c.sanitizer.SanitizeValue(f.Key, value))
+}
@@ -0,0 +1,66 @@
+package log
+
+import (
+	"errors"
+	"testing"
+
+	kratoslog "github.com/go-kratos/kratos/v2/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+type testAccount struct {
+	Email string
+	Plan  string
+}
+
+func (a testAccount) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	enc.AddString("email", a.Email)
+	enc.AddString("plan", a.Plan)
+	return nil
+}
+
+func TestSanitizingCore(t *testing.T) {
+	observedCore, observedLogs := observer.New(zapcore.DebugLevel)
+	logger := zap.New(NewSanitizingCore(observedCore, nil)).With(zap.String("session_token", "abc"))
+
+	logger.Info("【模拟发送邮件】目标: john.doe@example.com, 验证码: 123456",
+		zap.String("username", "alice"),
+		zap.Int("pin", 1234),
+		zap.Int("attempts", 3),
+		zap.Error(errors.New("smtp rejected john.doe@example.com")),
+		zap.Object("account", testAccount{Email: "john.doe@example.com", Plan: "pro"}),
+		zap.Any("headers", map[string]string{"authorization": "Bearer x"}),
+	)
+
+	require.Equal(t, 1, observedLogs.Len())
+	entry := observedLogs.All()[0]
+	assert.Equal(t, "【模拟发送邮件】目标: jo******@example.com, 验证码: [REDACTED]", entry.Message)
+
+	fields := entry.ContextMap()
+	assert.Equal(t, "[REDACTED]", fields["session_token"])
+	assert.Equal(t, "al***", fields["username"])
+	assert.Equal(t, int64(1234), fields["pin"])
+	assert.Equal(t, int64(3), fields["attempts"])
+	assert.Equal(t, "smtp rejected jo******@example.com", fields["error"])
+	assert.Equal(t, map[string]interface{}{"email": "jo******@example.com", "plan": "pro"}, fields["account"])
+	assert.Equal(t, map[string]interface{}{"authorization": "[REDACTED]"}, fields["headers"])
+}
+
+func TestZapLogger_Sanitizes(t *testing.T) {
+	observedCore, observedLogs := observer.New(zapcore.DebugLevel)
+	logger := &zapLogger{zap: zap.New(NewSanitizingCore(observedCore, nil)), level: zapcore.DebugLevel, config: DefaultConfig()}
+
+	// kratos 日志接口写入的键值同样脱敏
+	require.NoError(t, logger.Log(kratoslog.LevelInfo, "msg", "password authentication successful for user: alice", "password", "p"))
+	logger.Infof("refresh_token=%s", "eyJhbGci")
+
+	require.Equal(t, 2, observedLogs.Len())
+	fields := observedLogs.All()[0].ContextMap()
+	assert.Equal(t, "password authentication successful for user: al***", observedLogs.All()[0].Message)
+	assert.Equal(t, "[REDACTED]", fields["password"])
+	assert.Equal(t, "refresh_token=[REDACTED]", observedLogs.All()[1].Message)
+}
@@ -0,0 +1,248 @@
+package log
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"kratos-boilerplate/internal/conf"
+
+	kratoslog "github.com/go-kratos/kratos/v2/log"
+)
+
+// AccessLogger 访问日志，每个请求一条
+type AccessLogger kratoslog.Logger
+
+// AuditLogger 审计日志，每条操作日志一条
+type AuditLogger kratoslog.Logger
+
+// Sinks 按用途分开的日志输出
+type Sinks struct {
+	// App 应用日志，按模块级别过滤
+	App    kratoslog.Logger
+	Access AccessLogger
+	Audit  AuditLogger
+	// Levels 应用日志的模块级别，运行时可调整
+	Levels *LevelRegistry
+}
+
+// 日志类型字段，访问日志与审计日志写入应用日志时用于区分
+const (
+	LogTypeKey    = "log_type"
+	LogTypeAccess = "access"
+	LogTypeAudit  = "audit"
+)
+
+// NewSinks 根据 conf.Log 创建应用、访问与审计日志。访问日志与审计日志未配置输出时写入应用日志，
+// 并以 log_type 字段区分；返回的清理函数同步所有输出。
+// 应用日志的级别由模块级别注册表判断，zap 按 debug 级别创建，以便运行时调低模块级别
+func NewSinks(c *conf.Log) (*Sinks, func(), error) {
+	appConfig, err := ConfigFromConf(c)
+	if err != nil {
+		return nil, nil, err
+	}
+	root, err := ParseLevel(appConfig.Level)
+	if err != nil {
+		return nil, nil, err
+	}
+	levels := NewLevelRegistry(root)
+	if err := levels.ApplyConfig(c.GetLevels()); err != nil {
+		return nil, nil, fmt.Errorf("log levels: %w", err)
+	}
+	zapConfig := *appConfig
+	zapConfig.Level = "debug"
+	zapApp, err := NewLogger(&zapConfig)
+	if err != nil {
+		return nil, nil, err
+	}
+	app := levels.Filter(zapApp)
+	loggers := []Logger{zapApp}
+	cleanup := func() {
+		levels.Close()
+		for _, l := range loggers {
+			_ = l.Close()
+		}
+	}
+
+	sink := func(s *conf.Log_Sink, logType string) (kratoslog.Logger, error) {
+		if s.GetOutput() == "" {
+			return kratoslog.With(app, LogTypeKey, logType), nil
+		}
+		config, err := sinkConfig(appConfig, s, "logs/"+logType+".log")
+		if err != nil {
+			return nil, fmt.Errorf("%s log: %w", logType, err)
+		}
+		l, err := NewLogger(config)
+		if err != nil {
+			return nil, fmt.Errorf("%s log: %w", logType, err)
+		}
+		loggers = append(loggers, l)
+		return l, nil
+	}
+	access, err := sink(c.GetAccess(), LogTypeAccess)
+	if err != nil {
+		cleanup()
+		return nil, nil, err
+	}
+	audit, err := sink(c.GetAudit(), LogTypeAudit)
+	if err != nil {
+		cleanup()
+		return nil, nil, err
+	}
+	return &Sinks{App: app, Access: access, Audit: audit, Levels: levels}, cleanup, nil
+}
+
+// ConfigFromConf 将 conf.Log 转换为应用日志配置，未设置的项使用默认值。
+// 日志经由 kratos 日志接口写入时 zap 记录的调用位置没有意义，调用位置由 kratos 的 caller 字段提供
+func ConfigFromConf(c *conf.Log) (*Config, error) {
+	config := DefaultConfig()
+	config.EnableCaller = false
+	if c.GetLevel() != "" {
+		config.Level = c.GetLevel()
+	}
+	if c.GetFormat() != "" {
+		config.Format = c.GetFormat()
+	}
+	if c.GetOutput() != "" {
+		config.Output = c.GetOutput()
+	}
+	if c.GetFile() != "" {
+		config.File.Path = c.GetFile()
+	}
+	if err := applyRotation(&config.File, c.GetRotation()); err != nil {
+		return nil, err
+	}
+	if s := c.GetSampling(); s != nil {
+		config.SampleConfig = &SampleConfig{
+			Initial:    int(s.GetInitial()),
+			Thereafter: int(s.GetThereafter()),
+			Tick:       s.GetTick().AsDuration(),
+		}
+		if config.SampleConfig.Tick <= 0 {
+			config.SampleConfig.Tick = time.Second
+		}
+	}
+	if err := validateConfig(config); err != nil {
+		return nil, err
+	}
+	return config, nil
+}
+
+// sinkConfig 单独输出的配置，未设置的项沿用应用日志配置；单独输出不采样
+func sinkConfig(base *Config, s *conf.Log_Sink, defaultFile string) (*Config, error) {
+	config := *base
+	config.SampleConfig = nil
+	config.Output = s.GetOutput()
+	config.File.Path = defaultFile
+	if s.GetLevel() != "" {
+		config.Level = s.GetLevel()
+	}
+	if s.GetFormat() != "" {
+		config.Format = s.GetFormat()
+	}
+	if s.GetFile() != "" {
+		config.File.Path = s.GetFile()
+	}
+	if err := applyRotation(&config.File, s.GetRotation()); err != nil {
+		return nil, err
+	}
+	if err := validateConfig(&config); err != nil {
+		return nil, err
+	}
+	return &config, nil
+}
+
+// validateConfig 检查配置中的枚举值，避免写错时静默回退到默认输出
+func validateConfig(config *Config) error {
+	if _, err := parseLevel(config.Level); err != nil {
+		return fmt.Errorf("invalid log level: %w", err)
+	}
+	switch config.Format {
+	case "json", "text", "console":
+	default:
+		return fmt.Errorf("invalid log format: %s", config.Format)
+	}
+	switch config.Output {
+	case "stdout", "file", "both":
+	default:
+		return fmt.Errorf("invalid log output: %s", config.Output)
+	}
+	return nil
+}
+
+// applyRotation 将轮转配置写入文件配置，未设置的项保持不变
+func applyRotation(file *FileConfig, r *conf.Log_Rotation) error {
+	if r == nil {
+		return nil
+	}
+	if r.GetMaxSize() != "" {
+		size, err := parseSizeMB(r.GetMaxSize())
+		if err != nil {
+			return fmt.Errorf("invalid log rotation max_size: %w", err)
+		}
+		file.MaxSize = size
+	}
+	if r.GetMaxAge() != "" {
+		age, err := parseAgeDays(r.GetMaxAge())
+		if err != nil {
+			return fmt.Errorf("invalid log rotation max_age: %w", err)
+		}
+		file.MaxAge = age
+	}
+	if r.GetMaxBackups() > 0 {
+		file.MaxBackups = int(r.GetMaxBackups())
+	}
+	file.Compress = r.GetCompress()
+	return nil
+}
+
+// parseSizeMB 解析 100MB、1GB 等大小为 MB，不足 1MB 按 1MB 计；没有单位时为 MB
+func parseSizeMB(value string) (int, error) {
+	v := strings.ToUpper(strings.TrimSpace(value))
+	units := []struct {
+		suffix string
+		mb     float64
+	}{{"GB", 1024}, {"MB", 1}, {"KB", 1.0 / 1024}, {"G", 1024}, {"M", 1}, {"K", 1.0 / 1024}}
+	scale := 1.0
+	for _, u := range units {
+		if strings.HasSuffix(v, u.suffix) {
+			v, scale = strings.TrimSpace(strings.TrimSuffix(v, u.suffix)), u.mb
+			break
+		}
+	}
+	n, err := strconv.ParseFloat(v, 64)
+	if err != nil || n <= 0 {
+		return 0, fmt.Errorf("%q is not a positive size", value)
+	}
+	return int(math.Ceil(n * scale)), nil
+}
+
+// parseAgeDays 解析 30d、720h 等时长为天数，不足一天按一天计；没有单位时为天
+func parseAgeDays(value string) (int, error) {
+	v := strings.TrimSpace(value)
+	if n, err := strconv.Atoi(strings.TrimSuffix(v, "d")); err == nil && n > 0 {
+		return n, nil
+	}
+	d, err := time.ParseDuration(v)
+	if err != nil || d <= 0 {
+		return 0, fmt.Errorf("%q is not a positive duration", value)
+	}
+	return int(math.Ceil(d.Hours() / 24)), nil
+}
+
+// WithContextFields 为 kratos 日志器绑定 trace_id、span_id、request_id 与 user_id，
+// 取值与 WithContext 相同，经由 log.WithContext 或 Helper.WithContext 写入时从上下文读取
+func WithContextFields(logger kratoslog.Logger) kratoslog.Logger {
+	valuer := func(get func(ctx context.Context) string) kratoslog.Valuer {
+		return func(ctx context.Context) interface{} { return get(ctx) }
+	}
+	return kratoslog.With(logger,
+		string(TraceIDKey), valuer(getTraceIDFromContext),
+		string(SpanIDKey), valuer(getSpanIDFromContext),
+		string(RequestIDKey), valuer(getRequestIDFromContext),
+		string(UserIDKey), valuer(getUserIDFromContext),
+	)
+}
@@ -0,0 +1,143 @@
+package log
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"kratos-boilerplate/internal/conf"
+
+	kratoslog "github.com/go-kratos/kratos/v2/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+func TestConfigFromConf(t *testing.T) {
+	config, err := ConfigFromConf(nil)
+	require.NoError(t, err)
+	assert.Equal(t, "info", config.Level)
+	assert.Equal(t, "stdout", config.Output)
+	assert.False(t, config.EnableCaller)
+
+	config, err = ConfigFromConf(&conf.Log{
+		Level:  "debug",
+		Format: "text",
+		Output: "file",
+		File:   "/var/log/app.log",
+		Rotation: &conf.Log_Rotation{
+			MaxSize:    "1GB",
+			MaxAge:     "36h",
+			MaxBackups: 3,
+			Compress:   true,
+		},
+		Sampling: &conf.Log_Sampling{Initial: 10, Thereafter: 50},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "debug", config.Level)
+	assert.Equal(t, "text", config.Format)
+	assert.Equal(t, FileConfig{Path: "/var/log/app.log", MaxSize: 1024, MaxBackups: 3, MaxAge: 2, Compress: true}, config.File)
+	assert.Equal(t, &SampleConfig{Initial: 10, Thereafter: 50, Tick: time.Second}, config.SampleConfig)
+
+	for _, c := range []*conf.Log{
+		{Level: "verbose"},
+		{Format: "xml"},
+		{Output: "syslog"},
+		{Rotation: &conf.Log_Rotation{MaxSize: "big"}},
+		{Rotation: &conf.Log_Rotation{MaxAge: "-1d"}},
+	} {
+		_, err := ConfigFromConf(c)
+		assert.Error(t, err, "%v", c)
+	}
+}
+
+func TestParseRotationValues(t *testing.T) {
+	sizes := map[string]int{"100MB": 100, "100": 100, "1gb": 1024, "512KB": 1, "1.5G": 1536}
+	for value, want := range sizes {
+		got, err := parseSizeMB(value)
+		require.NoError(t, err, value)
+		assert.Equal(t, want, got, value)
+	}
+	ages := map[string]int{"30d": 30, "30": 30, "720h": 30, "1h": 1}
+	for value, want := range ages {
+		got, err := parseAgeDays(value)
+		require.NoError(t, err, value)
+		assert.Equal(t, want, got, value)
+	}
+}
+
+// TestNewSinks 测试未配置单独输出时写入应用日志，配置后写入各自的文件且不采样；应用日志按模块级别过滤
+func TestNewSinks(t *testing.T) {
+	sinks, cleanup, err := NewSinks(&conf.Log{Level: "warn", Levels: map[string]string{"data.kms": "debug"}})
+	require.NoError(t, err)
+	defer cleanup()
+	assert.NotNil(t, sinks.App)
+	assert.NotNil(t, sinks.Access)
+	assert.NotNil(t, sinks.Audit)
+	assert.False(t, sinks.Levels.Enabled("biz.auth", kratoslog.LevelInfo))
+	assert.True(t, sinks.Levels.Enabled("data.kms", kratoslog.LevelDebug))
+
+	dir := t.TempDir()
+	sinks, cleanup, err = NewSinks(&conf.Log{
+		Sampling: &conf.Log_Sampling{Initial: 1, Thereafter: 1000, Tick: durationpb.New(time.Minute)},
+		Access:   &conf.Log_Sink{Output: "file", File: filepath.Join(dir, "access.log")},
+		Audit:    &conf.Log_Sink{Output: "file", File: filepath.Join(dir, "audit.log"), Level: "warn"},
+	})
+	require.NoError(t, err)
+	for i := 0; i < 3; i++ {
+		require.NoError(t, sinks.Access.Log(kratoslog.LevelInfo, "msg", "request"))
+	}
+	require.NoError(t, sinks.Audit.Log(kratoslog.LevelInfo, "msg", "below level"))
+	cleanup()
+
+	access, err := os.ReadFile(filepath.Join(dir, "access.log"))
+	require.NoError(t, err)
+	assert.Equal(t, 3, countLines(access))
+	// 低于审计日志级别的日志不写入，文件在首次写入时才创建
+	_, err = os.Stat(filepath.Join(dir, "audit.log"))
+	assert.True(t, os.IsNotExist(err))
+
+	_, _, err = NewSinks(&conf.Log{Access: &conf.Log_Sink{Output: "kafka"}})
+	assert.Error(t, err)
+	_, _, err = NewSinks(&conf.Log{Levels: map[string]string{"biz": "loud"}})
+	assert.Error(t, err)
+}
+
+// TestWithContextFields 测试 kratos 日志器从上下文读取字段，没有的字段不输出
+func TestWithContextFields(t *testing.T) {
+	observedCore, observedLogs := observer.New(zapcore.DebugLevel)
+	logger := WithContextFields(&zapLogger{zap: zap.New(observedCore), level: zapcore.DebugLevel, config: DefaultConfig()})
+
+	traceID, _ := trace.TraceIDFromHex("4bf92f3577b34da6a3ce929d0e0e4736")
+	spanID, _ := trace.SpanIDFromHex("00f067aa0ba902b7")
+	ctx := trace.ContextWithSpanContext(context.Background(), trace.NewSpanContext(trace.SpanContextConfig{TraceID: traceID, SpanID: spanID}))
+	ctx = WithRequestID(WithUserID(ctx, "42"), "req-1")
+
+	kratoslog.NewHelper(logger).WithContext(ctx).Info("handled")
+	kratoslog.NewHelper(logger).Info("startup")
+
+	require.Equal(t, 2, observedLogs.Len())
+	fields := observedLogs.All()[0].ContextMap()
+	assert.Equal(t, "handled", observedLogs.All()[0].Message)
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", fields["trace_id"])
+	assert.Equal(t, "00f067aa0ba902b7", fields["span_id"])
+	assert.Equal(t, "req-1", fields["request_id"])
+	assert.Equal(t, "42", fields["user_id"])
+	assert.Empty(t, observedLogs.All()[1].ContextMap())
+}
+
+func countLines(b []byte) int {
+	n := 0
+	for _, c := range b {
+		if c == '\n' {
+			n++
+		}
+	}
+	return n
+}
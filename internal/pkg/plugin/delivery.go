@@ -0,0 +1,496 @@
+package plugin
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	// defaultRetryBackoff 首次重试的等待时间
+	defaultRetryBackoff = 100 * time.Millisecond
+	// defaultMaxRetryBackoff 重试等待时间上限
+	defaultMaxRetryBackoff = time.Minute
+	// defaultPollInterval 轮询持久化队列的间隔
+	defaultPollInterval = time.Second
+	// defaultDeliveryLease 领取投递记录的租约时长
+	defaultDeliveryLease = time.Minute
+	// defaultDeliveryBatch 每次领取的最大投递记录数
+	defaultDeliveryBatch = 100
+)
+
+// DeliveryMode 事件投递方式
+type DeliveryMode string
+
+const (
+	// DeliveryBestEffort 在内存中投递，进程退出时未完成的重试丢失
+	DeliveryBestEffort DeliveryMode = "best_effort"
+	// DeliveryAtLeastOnce 先写入持久化队列再投递，处理成功前会重复投递
+	DeliveryAtLeastOnce DeliveryMode = "at_least_once"
+)
+
+// PluginMetadataDelivery 插件配置元数据中指定事件投递方式的键
+const PluginMetadataDelivery = "delivery"
+
+// DeliveryOptions 订阅的投递选项
+type DeliveryOptions struct {
+	Mode DeliveryMode
+	// MaxRetries 首次投递失败后的最大重试次数，超过后进入死信
+	MaxRetries int
+	// Backoff 首次重试的等待时间，之后每次翻倍
+	Backoff time.Duration
+	// MaxBackoff 重试等待时间上限
+	MaxBackoff time.Duration
+}
+
+// DefaultDeliveryOptions Subscribe 使用的投递选项：内存投递，不重试
+func DefaultDeliveryOptions() DeliveryOptions {
+	return DeliveryOptions{Mode: DeliveryBestEffort}
+}
+
+// backoff 第 attempt 次投递失败后的等待时间
+func (o DeliveryOptions) backoff(attempt int) time.Duration {
+	base, max := o.Backoff, o.MaxBackoff
+	if base <= 0 {
+		base = defaultRetryBackoff
+	}
+	if max <= 0 {
+		max = defaultMaxRetryBackoff
+	}
+
+	d := base
+	for i := 1; i < attempt; i++ {
+		d *= 2
+		if d >= max {
+			return max
+		}
+	}
+	if d > max {
+		return max
+	}
+	return d
+}
+
+// PluginDeliveryOptions 插件事件处理器的投递选项
+// 投递方式取自插件配置元数据 delivery，重试次数取自 RetryCount，
+// 配置变更在插件重新注册事件处理器（重启或重新加载）后生效
+func PluginDeliveryOptions(config PluginConfig) DeliveryOptions {
+	options := DefaultDeliveryOptions()
+	if mode := config.Metadata[PluginMetadataDelivery]; mode != "" {
+		options.Mode = DeliveryMode(mode)
+	}
+	if config.RetryCount > 0 {
+		options.MaxRetries = config.RetryCount
+	}
+	return options
+}
+
+// pluginEventBus 传给插件的事件总线，插件通过 Subscribe 注册的处理器按插件配置投递
+type pluginEventBus struct {
+	EventBus
+	options DeliveryOptions
+}
+
+// Subscribe 按插件的投递选项订阅事件
+func (b *pluginEventBus) Subscribe(eventType EventType, handler EventHandler) error {
+	return b.EventBus.SubscribeWithOptions(eventType, handler, b.options)
+}
+
+// Delivery 单次投递信息，通过 DeliveryFromContext 传给事件处理器
+type Delivery struct {
+	// Attempt 第几次投递，从 1 开始
+	Attempt int
+	// IdempotencyKey 同一事件对同一订阅的每次投递保持不变，处理器可据此去重
+	IdempotencyKey string
+	// Subscription 订阅的处理器名称
+	Subscription string
+}
+
+type deliveryKey struct{}
+
+// withDelivery 在上下文中携带投递信息
+func withDelivery(ctx context.Context, d Delivery) context.Context {
+	return context.WithValue(ctx, deliveryKey{}, d)
+}
+
+// DeliveryFromContext 获取当前投递信息
+func DeliveryFromContext(ctx context.Context) (Delivery, bool) {
+	d, ok := ctx.Value(deliveryKey{}).(Delivery)
+	return d, ok
+}
+
+// idempotencyKey 事件对订阅的幂等键
+func idempotencyKey(eventID, subscription string) string {
+	return eventID + ":" + subscription
+}
+
+// DeliveryStatus 持久化投递记录的状态
+type DeliveryStatus string
+
+const (
+	DeliveryPending DeliveryStatus = "pending"
+	DeliveryDead    DeliveryStatus = "dead"
+)
+
+// DeliveryRecord 持久化的投递记录，每个事件对每个订阅一条
+type DeliveryRecord struct {
+	// ID 即投递的幂等键
+	ID            string
+	EventID       string
+	EventType     EventType
+	Source        string
+	Timestamp     time.Time
+	Data          map[string]interface{}
+	Metadata      map[string]string
+	Subscription  string
+	Status        DeliveryStatus
+	Attempts      int
+	LastError     string
+	NextAttemptAt time.Time
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
+}
+
+// event 还原投递记录中的事件
+func (r *DeliveryRecord) event() Event {
+	return RestoreEvent(r.EventID, r.EventType, r.Source, r.Timestamp, r.Data, r.Metadata)
+}
+
+// newDeliveryRecord 为事件的一个订阅创建投递记录
+func newDeliveryRecord(event Event, subscription string, now time.Time) *DeliveryRecord {
+	return &DeliveryRecord{
+		ID:            idempotencyKey(event.GetID(), subscription),
+		EventID:       event.GetID(),
+		EventType:     event.GetType(),
+		Source:        event.GetSource(),
+		Timestamp:     event.GetTimestamp(),
+		Data:          event.GetData(),
+		Metadata:      event.GetMetadata(),
+		Subscription:  subscription,
+		Status:        DeliveryPending,
+		NextAttemptAt: now,
+		CreatedAt:     now,
+		UpdatedAt:     now,
+	}
+}
+
+// DeadLetterQuery 死信查询条件，空字段不过滤
+type DeadLetterQuery struct {
+	EventType    EventType
+	Subscription string
+	Limit        int
+}
+
+var (
+	// ErrDeliveryNotFound 投递记录不存在
+	ErrDeliveryNotFound = errors.New("delivery not found")
+	// ErrEnqueueFailed 事件未能写入持久化队列，至少一次投递的订阅不会收到该事件
+	ErrEnqueueFailed = errors.New("failed to enqueue event")
+)
+
+// EventStore 至少一次投递的持久化队列
+type EventStore interface {
+	// Enqueue 写入投递记录，ID 已存在的记录被忽略
+	Enqueue(ctx context.Context, records []*DeliveryRecord) error
+	// Claim 领取到期的待投递记录，租约期内其他消费者领取不到这些记录
+	Claim(ctx context.Context, consumer string, limit int, lease time.Duration) ([]*DeliveryRecord, error)
+	// Ack 投递成功，删除记录
+	Ack(ctx context.Context, id string) error
+	// Retry 投递失败，记录尝试次数并安排下一次投递
+	Retry(ctx context.Context, id string, attempts int, next time.Time, lastErr string) error
+	// DeadLetter 重试次数用尽，移入死信
+	DeadLetter(ctx context.Context, id string, attempts int, lastErr string) error
+	// ListDeadLetters 查询死信
+	ListDeadLetters(ctx context.Context, query DeadLetterQuery) ([]*DeliveryRecord, error)
+	// Replay 将死信重新放回队列，尝试次数清零
+	Replay(ctx context.Context, id string) error
+}
+
+// memoryEventStore 内存队列，用于测试及未配置数据库的环境，进程退出后记录丢失
+type memoryEventStore struct {
+	mu      sync.Mutex
+	records map[string]*memoryRecord
+}
+
+type memoryRecord struct {
+	DeliveryRecord
+	lockedUntil time.Time
+}
+
+// NewMemoryEventStore 创建内存投递队列
+func NewMemoryEventStore() EventStore {
+	return &memoryEventStore{records: make(map[string]*memoryRecord)}
+}
+
+func (s *memoryEventStore) Enqueue(ctx context.Context, records []*DeliveryRecord) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, r := range records {
+		if _, exists := s.records[r.ID]; !exists {
+			s.records[r.ID] = &memoryRecord{DeliveryRecord: *r}
+		}
+	}
+	return nil
+}
+
+func (s *memoryEventStore) Claim(ctx context.Context, consumer string, limit int, lease time.Duration) ([]*DeliveryRecord, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	var due []*memoryRecord
+	for _, r := range s.records {
+		if r.Status == DeliveryPending && !r.NextAttemptAt.After(now) && !r.lockedUntil.After(now) {
+			due = append(due, r)
+		}
+	}
+	sort.Slice(due, func(i, j int) bool {
+		return due[i].NextAttemptAt.Before(due[j].NextAttemptAt)
+	})
+	if limit > 0 && len(due) > limit {
+		due = due[:limit]
+	}
+
+	claimed := make([]*DeliveryRecord, 0, len(due))
+	for _, r := range due {
+		r.lockedUntil = now.Add(lease)
+		record := r.DeliveryRecord
+		claimed = append(claimed, &record)
+	}
+	return claimed, nil
+}
+
+func (s *memoryEventStore) Ack(ctx context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.records, id)
+	return nil
+}
+
+func (s *memoryEventStore) Retry(ctx context.Context, id string, attempts int, next time.Time, lastErr string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	r, ok := s.records[id]
+	if !ok {
+		return ErrDeliveryNotFound
+	}
+	r.Attempts = attempts
+	r.NextAttemptAt = next
+	r.LastError = lastErr
+	r.UpdatedAt = time.Now()
+	r.lockedUntil = time.Time{}
+	return nil
+}
+
+func (s *memoryEventStore) DeadLetter(ctx context.Context, id string, attempts int, lastErr string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	r, ok := s.records[id]
+	if !ok {
+		return ErrDeliveryNotFound
+	}
+	r.Status = DeliveryDead
+	r.Attempts = attempts
+	r.LastError = lastErr
+	r.UpdatedAt = time.Now()
+	r.lockedUntil = time.Time{}
+	return nil
+}
+
+func (s *memoryEventStore) ListDeadLetters(ctx context.Context, query DeadLetterQuery) ([]*DeliveryRecord, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var result []*DeliveryRecord
+	for _, r := range s.records {
+		if r.Status != DeliveryDead {
+			continue
+		}
+		if query.EventType != "" && r.EventType != query.EventType {
+			continue
+		}
+		if query.Subscription != "" && r.Subscription != query.Subscription {
+			continue
+		}
+		record := r.DeliveryRecord
+		result = append(result, &record)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].UpdatedAt.After(result[j].UpdatedAt)
+	})
+	if query.Limit > 0 && len(result) > query.Limit {
+		result = result[:query.Limit]
+	}
+	return result, nil
+}
+
+func (s *memoryEventStore) Replay(ctx context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	r, ok := s.records[id]
+	if !ok || r.Status != DeliveryDead {
+		return ErrDeliveryNotFound
+	}
+	now := time.Now()
+	r.Status = DeliveryPending
+	r.Attempts = 0
+	r.NextAttemptAt = now
+	r.UpdatedAt = now
+	return nil
+}
+
+// startDispatcher 启动持久化队列的投递协程，仅首次调用生效
+func (eb *eventBusImpl) startDispatcher() {
+	eb.startOnce.Do(func() {
+		go eb.dispatchLoop()
+	})
+}
+
+// notify 唤醒投递协程立即领取记录
+func (eb *eventBusImpl) notify() {
+	select {
+	case eb.wake <- struct{}{}:
+	default:
+	}
+}
+
+// dispatchLoop 定期领取到期的投递记录，直到 Close
+func (eb *eventBusImpl) dispatchLoop() {
+	defer close(eb.stopped)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		<-eb.done
+		cancel()
+	}()
+
+	ticker := time.NewTicker(eb.pollInterval)
+	defer ticker.Stop()
+
+	for {
+		eb.dispatchPending(ctx)
+		select {
+		case <-eb.done:
+			return
+		case <-ticker.C:
+		case <-eb.wake:
+		}
+	}
+}
+
+// dispatchPending 领取并投递到期记录，一批领满时继续领取下一批
+func (eb *eventBusImpl) dispatchPending(ctx context.Context) {
+	for ctx.Err() == nil {
+		records, err := eb.store.Claim(ctx, eb.consumerID, eb.batchSize, eb.lease)
+		if err != nil {
+			eb.logger.Errorf("failed to claim event deliveries: %v", err)
+			return
+		}
+		if len(records) == 0 {
+			return
+		}
+
+		var wg sync.WaitGroup
+		workers := make(chan struct{}, eb.asyncWorkers)
+		for _, record := range records {
+			wg.Add(1)
+			workers <- struct{}{}
+			go func(r *DeliveryRecord) {
+				defer func() {
+					<-workers
+					wg.Done()
+				}()
+				eb.deliverRecord(ctx, r)
+			}(record)
+		}
+		wg.Wait()
+
+		if len(records) < eb.batchSize {
+			return
+		}
+	}
+}
+
+// deliverRecord 投递一条持久化记录，并按结果确认、安排重试或移入死信
+func (eb *eventBusImpl) deliverRecord(ctx context.Context, r *DeliveryRecord) {
+	sub := eb.findSubscription(r.EventType, r.Subscription)
+	if sub == nil {
+		// 订阅者尚未注册（如插件未加载），保留记录且不计入尝试次数
+		if err := eb.store.Retry(ctx, r.ID, r.Attempts, time.Now().Add(eb.pollInterval), r.LastError); err != nil {
+			eb.logger.Errorf("failed to reschedule delivery %s: %v", r.ID, err)
+		}
+		return
+	}
+
+	attempt := r.Attempts + 1
+	err := eb.handleEvent(withDelivery(ctx, Delivery{
+		Attempt:        attempt,
+		IdempotencyKey: r.ID,
+		Subscription:   r.Subscription,
+	}), sub.handler, r.event())
+
+	switch {
+	case err == nil:
+		err = eb.store.Ack(ctx, r.ID)
+	case attempt > sub.options.MaxRetries:
+		eb.logger.Warnf("event %s dead-lettered for %s after %d attempts: %v", r.EventID, r.Subscription, attempt, err)
+		err = eb.store.DeadLetter(ctx, r.ID, attempt, err.Error())
+	default:
+		err = eb.store.Retry(ctx, r.ID, attempt, time.Now().Add(sub.options.backoff(attempt)), err.Error())
+	}
+	if err != nil {
+		eb.logger.Errorf("failed to update delivery %s: %v", r.ID, err)
+	}
+}
+
+// findSubscription 按处理器名称查找订阅
+func (eb *eventBusImpl) findSubscription(eventType EventType, name string) *subscription {
+	eb.mu.RLock()
+	defer eb.mu.RUnlock()
+
+	for _, sub := range eb.subscribers[eventType] {
+		if sub.handler.GetName() == name {
+			return sub
+		}
+	}
+	return nil
+}
+
+// ListDeadLetters 查询死信
+func (eb *eventBusImpl) ListDeadLetters(ctx context.Context, query DeadLetterQuery) ([]*DeliveryRecord, error) {
+	return eb.store.ListDeadLetters(ctx, query)
+}
+
+// ReplayDeadLetter 将死信放回队列，由投递协程重新投递
+func (eb *eventBusImpl) ReplayDeadLetter(ctx context.Context, id string) error {
+	if err := eb.store.Replay(ctx, id); err != nil {
+		if errors.Is(err, ErrDeliveryNotFound) {
+			return NewPluginError(ErrCodePluginNotFound, "dead letter not found", id, err)
+		}
+		return NewPluginError(ErrCodePluginInternal, "failed to replay dead letter", id, err)
+	}
+	eb.startDispatcher()
+	eb.notify()
+	return nil
+}
+
+// Close 停止投递协程，等待进行中的投递结束
+func (eb *eventBusImpl) Close() error {
+	eb.closeOnce.Do(func() {
+		close(eb.done)
+		started := true
+		eb.startOnce.Do(func() { started = false })
+		if started {
+			<-eb.stopped
+		}
+	})
+	return nil
+}
@@ -0,0 +1,203 @@
+package plugin
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// recordingHandler 记录每次投递信息，前 failures 次投递返回错误
+type recordingHandler struct {
+	mu         sync.Mutex
+	failures   int
+	deliveries []Delivery
+	done       chan struct{}
+}
+
+func newRecordingHandler(failures int) *recordingHandler {
+	return &recordingHandler{failures: failures, done: make(chan struct{}, 16)}
+}
+
+func (h *recordingHandler) handler(name string) EventHandler {
+	return NewBaseEventHandler(name, []EventType{EventUserLogin}, time.Second, func(ctx context.Context, event Event) error {
+		d, _ := DeliveryFromContext(ctx)
+		h.mu.Lock()
+		h.deliveries = append(h.deliveries, d)
+		fail := len(h.deliveries) <= h.failures
+		h.mu.Unlock()
+		if fail {
+			return errors.New("temporary failure")
+		}
+		h.done <- struct{}{}
+		return nil
+	})
+}
+
+func (h *recordingHandler) attempts() []Delivery {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return append([]Delivery(nil), h.deliveries...)
+}
+
+func (h *recordingHandler) wait(t *testing.T) {
+	t.Helper()
+	select {
+	case <-h.done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("event was not delivered")
+	}
+}
+
+func newTestDurableBus(store EventStore) EventBus {
+	return NewEventBusWithConfig(EventBusConfig{
+		AsyncWorkers: 2,
+		Store:        store,
+		PollInterval: 10 * time.Millisecond,
+	})
+}
+
+func TestAtLeastOnceDeliveryRetries(t *testing.T) {
+	eb := newTestDurableBus(NewMemoryEventStore())
+	defer eb.Close()
+
+	h := newRecordingHandler(2)
+	require.NoError(t, eb.SubscribeWithOptions(EventUserLogin, h.handler("durable"), DeliveryOptions{
+		Mode:       DeliveryAtLeastOnce,
+		MaxRetries: 3,
+		Backoff:    time.Millisecond,
+	}))
+
+	event := NewEvent(EventUserLogin, "test", map[string]interface{}{"user": "alice"})
+	require.NoError(t, eb.Publish(context.Background(), event))
+	h.wait(t)
+
+	attempts := h.attempts()
+	require.Len(t, attempts, 3)
+	for i, d := range attempts {
+		assert.Equal(t, i+1, d.Attempt)
+		assert.Equal(t, event.GetID()+":durable", d.IdempotencyKey)
+		assert.Equal(t, "durable", d.Subscription)
+	}
+
+	dead, err := eb.ListDeadLetters(context.Background(), DeadLetterQuery{})
+	require.NoError(t, err)
+	assert.Empty(t, dead)
+}
+
+func TestDeadLetterReplay(t *testing.T) {
+	eb := newTestDurableBus(NewMemoryEventStore())
+	defer eb.Close()
+
+	h := newRecordingHandler(2)
+	require.NoError(t, eb.SubscribeWithOptions(EventUserLogin, h.handler("durable"), DeliveryOptions{
+		Mode:       DeliveryAtLeastOnce,
+		MaxRetries: 1,
+		Backoff:    time.Millisecond,
+	}))
+
+	event := NewEvent(EventUserLogin, "test", map[string]interface{}{"user": "alice"})
+	require.NoError(t, eb.Publish(context.Background(), event))
+
+	var dead []*DeliveryRecord
+	require.Eventually(t, func() bool {
+		var err error
+		dead, err = eb.ListDeadLetters(context.Background(), DeadLetterQuery{Subscription: "durable"})
+		return err == nil && len(dead) == 1
+	}, 5*time.Second, 10*time.Millisecond)
+	assert.Equal(t, 2, dead[0].Attempts)
+	assert.Equal(t, "temporary failure", dead[0].LastError)
+	assert.Equal(t, "alice", dead[0].Data["user"])
+
+	require.NoError(t, eb.ReplayDeadLetter(context.Background(), dead[0].ID))
+	h.wait(t)
+
+	attempts := h.attempts()
+	require.Len(t, attempts, 3)
+	assert.Equal(t, 1, attempts[2].Attempt)
+	assert.Equal(t, dead[0].ID, attempts[2].IdempotencyKey)
+
+	err := eb.ReplayDeadLetter(context.Background(), dead[0].ID)
+	var pe *PluginError
+	require.ErrorAs(t, err, &pe)
+	assert.Equal(t, ErrCodePluginNotFound, pe.Code)
+}
+
+func TestPendingDeliverySurvivesRestart(t *testing.T) {
+	store := NewMemoryEventStore()
+	first := newTestDurableBus(store)
+
+	stopped := make(chan struct{})
+	var once sync.Once
+	require.NoError(t, first.SubscribeWithOptions(EventUserLogin, NewBaseEventHandler("durable", []EventType{EventUserLogin}, time.Second,
+		func(ctx context.Context, event Event) error {
+			once.Do(func() { close(stopped) })
+			return errors.New("shutting down")
+		}), DeliveryOptions{Mode: DeliveryAtLeastOnce, MaxRetries: 5, Backoff: 50 * time.Millisecond}))
+
+	event := NewEvent(EventUserLogin, "test", nil)
+	require.NoError(t, first.Publish(context.Background(), event))
+	<-stopped
+	require.NoError(t, first.Close())
+
+	// 新进程的总线在订阅者注册前不会消耗尝试次数
+	second := newTestDurableBus(store)
+	defer second.Close()
+	time.Sleep(100 * time.Millisecond)
+
+	h := newRecordingHandler(0)
+	require.NoError(t, second.SubscribeWithOptions(EventUserLogin, h.handler("durable"), DeliveryOptions{Mode: DeliveryAtLeastOnce, MaxRetries: 5}))
+	h.wait(t)
+
+	attempts := h.attempts()
+	require.Len(t, attempts, 1)
+	assert.Equal(t, 2, attempts[0].Attempt)
+	assert.Equal(t, event.GetID()+":durable", attempts[0].IdempotencyKey)
+}
+
+func TestBestEffortDeliveryRetries(t *testing.T) {
+	eb := NewEventBus(2)
+	defer eb.Close()
+
+	h := newRecordingHandler(5)
+	require.NoError(t, eb.SubscribeWithOptions(EventUserLogin, h.handler("memory"), DeliveryOptions{
+		MaxRetries: 2,
+		Backoff:    time.Millisecond,
+	}))
+
+	err := eb.Publish(context.Background(), NewEvent(EventUserLogin, "test", nil))
+	require.Error(t, err)
+	assert.Len(t, h.attempts(), 3)
+
+	dead, err := eb.ListDeadLetters(context.Background(), DeadLetterQuery{EventType: EventUserLogin})
+	require.NoError(t, err)
+	require.Len(t, dead, 1)
+	assert.Equal(t, 3, dead[0].Attempts)
+}
+
+func TestDeliveryBackoff(t *testing.T) {
+	options := DeliveryOptions{Backoff: 100 * time.Millisecond, MaxBackoff: time.Second}
+	assert.Equal(t, 100*time.Millisecond, options.backoff(1))
+	assert.Equal(t, 200*time.Millisecond, options.backoff(2))
+	assert.Equal(t, 800*time.Millisecond, options.backoff(4))
+	assert.Equal(t, time.Second, options.backoff(5))
+	assert.Equal(t, time.Second, options.backoff(50))
+}
+
+func TestPluginDeliveryOptions(t *testing.T) {
+	options := PluginDeliveryOptions(PluginConfig{
+		RetryCount: 4,
+		Metadata:   map[string]string{PluginMetadataDelivery: string(DeliveryAtLeastOnce)},
+	})
+	assert.Equal(t, DeliveryAtLeastOnce, options.Mode)
+	assert.Equal(t, 4, options.MaxRetries)
+
+	eb := NewEventBus(1)
+	defer eb.Close()
+	err := eb.SubscribeWithOptions(EventUserLogin, newRecordingHandler(0).handler("bad"), DeliveryOptions{Mode: "exactly_once"})
+	require.Error(t, err)
+}
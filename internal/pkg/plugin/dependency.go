@@ -0,0 +1,599 @@
+package plugin
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// HostAPIVersion 宿主提供的插件 API 版本
+// 插件可通过 CompatibilityPlugin.HostAPIVersion 声明支持的范围，不兼容的插件拒绝加载
+const HostAPIVersion = "1.0.0"
+
+// Version 语义化版本
+type Version struct {
+	Major      int
+	Minor      int
+	Patch      int
+	Prerelease string
+}
+
+// ParseVersion 解析语义化版本，允许 v 前缀，缺省的次版本号与修订号按 0 处理
+func ParseVersion(s string) (Version, error) {
+	v, _, err := parsePartialVersion(s)
+	return v, err
+}
+
+// parsePartialVersion 解析版本并返回实际给出的版本号段数，x 或 * 视为未给出
+func parsePartialVersion(s string) (Version, int, error) {
+	raw := strings.TrimPrefix(strings.TrimSpace(s), "v")
+	if raw == "" {
+		return Version{}, 0, fmt.Errorf("empty version")
+	}
+
+	// 构建元数据不参与比较
+	if i := strings.IndexByte(raw, '+'); i >= 0 {
+		raw = raw[:i]
+	}
+
+	var v Version
+	if i := strings.IndexByte(raw, '-'); i >= 0 {
+		v.Prerelease = raw[i+1:]
+		raw = raw[:i]
+	}
+
+	parts := strings.Split(raw, ".")
+	if len(parts) > 3 {
+		return Version{}, 0, fmt.Errorf("invalid version %q", s)
+	}
+
+	fields := []*int{&v.Major, &v.Minor, &v.Patch}
+	n := 0
+	for i, part := range parts {
+		if part == "x" || part == "X" || part == "*" {
+			break
+		}
+		num, err := strconv.Atoi(part)
+		if err != nil || num < 0 {
+			return Version{}, 0, fmt.Errorf("invalid version %q", s)
+		}
+		*fields[i] = num
+		n++
+	}
+	if n < 3 && v.Prerelease != "" {
+		return Version{}, 0, fmt.Errorf("invalid version %q: prerelease requires a full version", s)
+	}
+
+	return v, n, nil
+}
+
+// String 返回版本字符串
+func (v Version) String() string {
+	s := fmt.Sprintf("%d.%d.%d", v.Major, v.Minor, v.Patch)
+	if v.Prerelease != "" {
+		s += "-" + v.Prerelease
+	}
+	return s
+}
+
+// Compare 比较两个版本，v 小于、等于、大于 o 时分别返回 -1、0、1
+func (v Version) Compare(o Version) int {
+	for _, d := range []int{v.Major - o.Major, v.Minor - o.Minor, v.Patch - o.Patch} {
+		if d < 0 {
+			return -1
+		}
+		if d > 0 {
+			return 1
+		}
+	}
+	return comparePrerelease(v.Prerelease, o.Prerelease)
+}
+
+// comparePrerelease 按语义化版本规则比较预发布标识，正式版本高于预发布版本
+func comparePrerelease(a, b string) int {
+	switch {
+	case a == b:
+		return 0
+	case a == "":
+		return 1
+	case b == "":
+		return -1
+	}
+
+	as, bs := strings.Split(a, "."), strings.Split(b, ".")
+	for i := 0; i < len(as) && i < len(bs); i++ {
+		an, aErr := strconv.Atoi(as[i])
+		bn, bErr := strconv.Atoi(bs[i])
+		switch {
+		case aErr == nil && bErr == nil:
+			if an != bn {
+				return compareInt(an, bn)
+			}
+		case aErr == nil:
+			return -1
+		case bErr == nil:
+			return 1
+		default:
+			if c := strings.Compare(as[i], bs[i]); c != 0 {
+				return c
+			}
+		}
+	}
+	return compareInt(len(as), len(bs))
+}
+
+func compareInt(a, b int) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+// bump 按给出的版本号段数递增版本，用于计算部分版本的上界
+func (v Version) bump(n int) Version {
+	switch n {
+	case 1:
+		return Version{Major: v.Major + 1}
+	case 2:
+		return Version{Major: v.Major, Minor: v.Minor + 1}
+	default:
+		return Version{Major: v.Major, Minor: v.Minor, Patch: v.Patch + 1}
+	}
+}
+
+// comparator 单个版本比较条件
+type comparator struct {
+	op      string
+	version Version
+}
+
+func (c comparator) check(v Version) bool {
+	cmp := v.Compare(c.version)
+	switch c.op {
+	case "=":
+		return cmp == 0
+	case "!=":
+		return cmp != 0
+	case ">":
+		return cmp > 0
+	case ">=":
+		return cmp >= 0
+	case "<":
+		return cmp < 0
+	case "<=":
+		return cmp <= 0
+	}
+	return false
+}
+
+// VersionConstraint 版本约束
+// 同一组内以空格或逗号分隔的条件需同时满足，|| 分隔的多组满足其一即可。
+// 支持 =、!=、>、>=、<、<=、^、~ 以及 1.x、1.2.* 形式的通配版本，如 ">=1.2 <2"、"^1.4 || ^2"
+type VersionConstraint struct {
+	raw  string
+	sets [][]comparator
+}
+
+// ParseVersionConstraint 解析版本约束，空字符串与 * 表示任意版本
+func ParseVersionConstraint(s string) (*VersionConstraint, error) {
+	c := &VersionConstraint{raw: strings.TrimSpace(s)}
+	if c.raw == "" {
+		return c, nil
+	}
+
+	for _, group := range strings.Split(c.raw, "||") {
+		tokens := strings.FieldsFunc(group, func(r rune) bool {
+			return r == ' ' || r == ',' || r == '\t'
+		})
+		if len(tokens) == 0 {
+			return nil, fmt.Errorf("invalid version constraint %q", s)
+		}
+
+		var set []comparator
+		for _, token := range tokens {
+			comparators, err := parseComparator(token)
+			if err != nil {
+				return nil, fmt.Errorf("invalid version constraint %q: %w", s, err)
+			}
+			set = append(set, comparators...)
+		}
+		c.sets = append(c.sets, set)
+	}
+
+	return c, nil
+}
+
+// parseComparator 将单个条件展开为基本比较条件
+func parseComparator(token string) ([]comparator, error) {
+	if token == "*" || token == "x" || token == "X" {
+		return nil, nil
+	}
+
+	op := ""
+	for _, prefix := range []string{">=", "<=", "!=", ">", "<", "=", "^", "~"} {
+		if strings.HasPrefix(token, prefix) {
+			op = prefix
+			break
+		}
+	}
+
+	v, n, err := parsePartialVersion(token[len(op):])
+	if err != nil {
+		return nil, err
+	}
+	if n == 0 {
+		// 仅有通配符，例如 >=*
+		return nil, nil
+	}
+
+	switch op {
+	case "", "=":
+		if n == 3 {
+			return []comparator{{"=", v}}, nil
+		}
+		return []comparator{{">=", v}, {"<", v.bump(n)}}, nil
+	case "!=":
+		return []comparator{{"!=", v}}, nil
+	case ">":
+		if n < 3 {
+			return []comparator{{">=", v.bump(n)}}, nil
+		}
+		return []comparator{{">", v}}, nil
+	case ">=", "<":
+		return []comparator{{op, v}}, nil
+	case "<=":
+		if n < 3 {
+			return []comparator{{"<", v.bump(n)}}, nil
+		}
+		return []comparator{{"<=", v}}, nil
+	case "^":
+		// 不允许变更最左侧的非零版本号
+		switch {
+		case v.Major > 0 || n == 1:
+			return []comparator{{">=", v}, {"<", v.bump(1)}}, nil
+		case v.Minor > 0 || n == 2:
+			return []comparator{{">=", v}, {"<", v.bump(2)}}, nil
+		default:
+			return []comparator{{">=", v}, {"<", v.bump(3)}}, nil
+		}
+	case "~":
+		if n == 1 {
+			return []comparator{{">=", v}, {"<", v.bump(1)}}, nil
+		}
+		return []comparator{{">=", v}, {"<", v.bump(2)}}, nil
+	}
+
+	return nil, fmt.Errorf("unsupported operator in %q", token)
+}
+
+// Check 检查版本是否满足约束
+func (c *VersionConstraint) Check(v Version) bool {
+	if c == nil || len(c.sets) == 0 {
+		return true
+	}
+
+	for _, set := range c.sets {
+		ok := true
+		for _, cmp := range set {
+			if !cmp.check(v) {
+				ok = false
+				break
+			}
+		}
+		if ok {
+			return true
+		}
+	}
+	return false
+}
+
+// String 返回约束的原始表示
+func (c *VersionConstraint) String() string {
+	if c == nil || c.raw == "" {
+		return "*"
+	}
+	return c.raw
+}
+
+// Dependency 插件依赖声明
+type Dependency struct {
+	Name       string
+	Constraint *VersionConstraint
+	Optional   bool
+}
+
+// ParseDependency 解析形如 "audit_logger>=1.2 <2" 的依赖声明，省略版本约束表示接受任意版本
+func ParseDependency(s string) (Dependency, error) {
+	s = strings.TrimSpace(s)
+	end := strings.IndexFunc(s, func(r rune) bool {
+		return strings.ContainsRune("<>=!^~ \t,", r)
+	})
+	if end < 0 {
+		end = len(s)
+	}
+
+	dep := Dependency{Name: s[:end]}
+	if dep.Name == "" {
+		return Dependency{}, fmt.Errorf("invalid dependency %q: missing plugin name", s)
+	}
+
+	constraint, err := ParseVersionConstraint(s[end:])
+	if err != nil {
+		return Dependency{}, fmt.Errorf("invalid dependency %q: %w", s, err)
+	}
+	dep.Constraint = constraint
+
+	return dep, nil
+}
+
+// String 返回依赖声明
+func (d Dependency) String() string {
+	if d.Constraint == nil || d.Constraint.raw == "" {
+		return d.Name
+	}
+	return d.Name + " " + d.Constraint.raw
+}
+
+// pluginDependencies 解析插件声明的必需依赖与可选依赖
+func pluginDependencies(p Plugin) ([]Dependency, error) {
+	var deps []Dependency
+	for _, s := range p.Dependencies() {
+		dep, err := ParseDependency(s)
+		if err != nil {
+			return nil, err
+		}
+		deps = append(deps, dep)
+	}
+
+	if cp, ok := p.(CompatibilityPlugin); ok {
+		for _, s := range cp.OptionalDependencies() {
+			dep, err := ParseDependency(s)
+			if err != nil {
+				return nil, err
+			}
+			dep.Optional = true
+			deps = append(deps, dep)
+		}
+	}
+
+	return deps, nil
+}
+
+// fillCompatibility 将插件的兼容性声明写入元数据
+func fillCompatibility(meta *PluginMetadata, p Plugin) {
+	meta.OptionalDependencies = nil
+	meta.HostAPIVersion = ""
+	if cp, ok := p.(CompatibilityPlugin); ok {
+		meta.OptionalDependencies = cp.OptionalDependencies()
+		meta.HostAPIVersion = cp.HostAPIVersion()
+	}
+}
+
+// dependencyNames 插件依赖的插件名，忽略无法解析的声明
+func dependencyNames(p Plugin, includeOptional bool) []string {
+	deps, _ := pluginDependencies(p)
+	names := make([]string, 0, len(deps))
+	for _, dep := range deps {
+		if dep.Optional && !includeOptional {
+			continue
+		}
+		names = append(names, dep.Name)
+	}
+	return names
+}
+
+// DependencyConflict 单条兼容性冲突
+type DependencyConflict struct {
+	// Plugin 提出要求的插件
+	Plugin string `json:"plugin"`
+	// Dependency 被依赖的插件，宿主 API 冲突时为 host
+	Dependency string `json:"dependency"`
+	// Constraint 要求的版本范围
+	Constraint string `json:"constraint"`
+	// Found 实际版本，依赖缺失时为空
+	Found string `json:"found,omitempty"`
+	// Reason 冲突说明
+	Reason string `json:"reason"`
+}
+
+// String 返回冲突描述
+func (c DependencyConflict) String() string {
+	return c.Reason
+}
+
+// DependencyError 依赖解析失败，列出全部冲突
+type DependencyError struct {
+	Conflicts []DependencyConflict `json:"conflicts"`
+}
+
+func (e *DependencyError) Error() string {
+	reasons := make([]string, 0, len(e.Conflicts))
+	for _, c := range e.Conflicts {
+		reasons = append(reasons, c.String())
+	}
+	return strings.Join(reasons, "; ")
+}
+
+// Missing 是否只缺少尚未加载的必需依赖，此时等依赖加载后可重试
+func (e *DependencyError) Missing() bool {
+	for _, c := range e.Conflicts {
+		if c.Found != "" || c.Dependency == hostDependency {
+			return false
+		}
+	}
+	return len(e.Conflicts) > 0
+}
+
+// hostDependency 宿主 API 冲突中 Dependency 字段的取值
+const hostDependency = "host"
+
+// checkCompatibility 检查插件与宿主及已注册插件的兼容性
+// installed 不应包含同名插件；返回的冲突同时覆盖插件自身的要求与已注册插件对它的要求
+func checkCompatibility(p Plugin, installed map[string]Plugin) []DependencyConflict {
+	name := p.Name()
+	var conflicts []DependencyConflict
+
+	_, versionErr := ParseVersion(p.Version())
+	if versionErr != nil {
+		conflicts = append(conflicts, DependencyConflict{
+			Plugin: name,
+			Reason: fmt.Sprintf("%s has invalid version %q", name, p.Version()),
+		})
+	}
+
+	// 宿主 API 版本
+	if cp, ok := p.(CompatibilityPlugin); ok && cp.HostAPIVersion() != "" {
+		constraint, err := ParseVersionConstraint(cp.HostAPIVersion())
+		host, _ := ParseVersion(HostAPIVersion)
+		switch {
+		case err != nil:
+			conflicts = append(conflicts, DependencyConflict{
+				Plugin:     name,
+				Dependency: hostDependency,
+				Constraint: cp.HostAPIVersion(),
+				Reason:     fmt.Sprintf("%s declares invalid host API range: %v", name, err),
+			})
+		case !constraint.Check(host):
+			conflicts = append(conflicts, DependencyConflict{
+				Plugin:     name,
+				Dependency: hostDependency,
+				Constraint: constraint.String(),
+				Found:      HostAPIVersion,
+				Reason:     fmt.Sprintf("%s requires host API %s, host provides %s", name, constraint, HostAPIVersion),
+			})
+		}
+	}
+
+	// 插件自身的依赖
+	deps, err := pluginDependencies(p)
+	if err != nil {
+		conflicts = append(conflicts, DependencyConflict{
+			Plugin: name,
+			Reason: fmt.Sprintf("%s declares %v", name, err),
+		})
+	}
+	for _, dep := range deps {
+		if dep.Name == name {
+			conflicts = append(conflicts, DependencyConflict{
+				Plugin:     name,
+				Dependency: dep.Name,
+				Reason:     fmt.Sprintf("%s depends on itself", name),
+			})
+			continue
+		}
+
+		installedDep, exists := installed[dep.Name]
+		if !exists {
+			if !dep.Optional {
+				conflicts = append(conflicts, DependencyConflict{
+					Plugin:     name,
+					Dependency: dep.Name,
+					Constraint: dep.Constraint.String(),
+					Reason:     fmt.Sprintf("%s requires %s, which is not loaded", name, dep),
+				})
+			}
+			continue
+		}
+
+		if c, ok := checkDependencyVersion(name, dep, installedDep); !ok {
+			conflicts = append(conflicts, c)
+		}
+	}
+
+	// 已注册插件对该插件的要求
+	if versionErr == nil {
+		others := make([]string, 0, len(installed))
+		for other := range installed {
+			others = append(others, other)
+		}
+		sort.Strings(others)
+
+		for _, other := range others {
+			otherDeps, _ := pluginDependencies(installed[other])
+			for _, dep := range otherDeps {
+				if dep.Name != name {
+					continue
+				}
+				if c, ok := checkDependencyVersion(other, dep, p); !ok {
+					conflicts = append(conflicts, c)
+				}
+			}
+		}
+	}
+
+	return conflicts
+}
+
+// checkDependencyVersion 检查依赖插件的版本是否满足要求
+func checkDependencyVersion(requester string, dep Dependency, target Plugin) (DependencyConflict, bool) {
+	conflict := DependencyConflict{
+		Plugin:     requester,
+		Dependency: dep.Name,
+		Constraint: dep.Constraint.String(),
+		Found:      target.Version(),
+	}
+
+	version, err := ParseVersion(target.Version())
+	if err != nil {
+		conflict.Reason = fmt.Sprintf("%s requires %s, found invalid version %q", requester, dep, target.Version())
+		return conflict, false
+	}
+	if !dep.Constraint.Check(version) {
+		conflict.Reason = fmt.Sprintf("%s requires %s, found %s", requester, dep, version)
+		return conflict, false
+	}
+	return conflict, true
+}
+
+// findCycle 在依赖图中查找一个环，graph[node] 为 node 依赖的节点
+func findCycle(graph map[string][]string) []string {
+	const (
+		unvisited = iota
+		visiting
+		done
+	)
+	state := make(map[string]int)
+
+	nodes := make([]string, 0, len(graph))
+	for node := range graph {
+		nodes = append(nodes, node)
+	}
+	sort.Strings(nodes)
+
+	var path []string
+	var visit func(node string) []string
+	visit = func(node string) []string {
+		state[node] = visiting
+		path = append(path, node)
+		for _, next := range graph[node] {
+			switch state[next] {
+			case visiting:
+				for i, n := range path {
+					if n == next {
+						return append(append([]string{}, path[i:]...), next)
+					}
+				}
+			case unvisited:
+				if cycle := visit(next); cycle != nil {
+					return cycle
+				}
+			}
+		}
+		path = path[:len(path)-1]
+		state[node] = done
+		return nil
+	}
+
+	for _, node := range nodes {
+		if state[node] == unvisited {
+			if cycle := visit(node); cycle != nil {
+				return cycle
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,208 @@
+package plugin
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// versionedMockPlugin 声明版本、可选依赖与宿主 API 要求的模拟插件
+type versionedMockPlugin struct {
+	testMockPlugin
+	version  string
+	optional []string
+	hostAPI  string
+}
+
+func (m *versionedMockPlugin) Version() string                { return m.version }
+func (m *versionedMockPlugin) OptionalDependencies() []string { return m.optional }
+func (m *versionedMockPlugin) HostAPIVersion() string         { return m.hostAPI }
+
+func newVersionedPlugin(name, version string, deps ...string) *versionedMockPlugin {
+	return &versionedMockPlugin{testMockPlugin: testMockPlugin{name: name, deps: deps}, version: version}
+}
+
+// dependencyConflicts 取出依赖错误中的冲突列表
+func dependencyConflicts(t *testing.T, err error) []DependencyConflict {
+	t.Helper()
+
+	var pe *PluginError
+	require.True(t, errors.As(err, &pe), "unexpected error: %v", err)
+	assert.Equal(t, ErrCodePluginDependency, pe.Code)
+
+	var de *DependencyError
+	require.True(t, errors.As(err, &de), "unexpected error: %v", err)
+	return de.Conflicts
+}
+
+func TestVersionConstraint(t *testing.T) {
+	tests := []struct {
+		constraint string
+		version    string
+		want       bool
+	}{
+		{"", "0.0.1", true},
+		{"*", "3.2.1", true},
+		{"1.2.3", "1.2.3", true},
+		{"=1.2.3", "1.2.4", false},
+		{"!=1.2.3", "1.2.4", true},
+		{">=1.2 <2", "1.9.9", true},
+		{">=1.2 <2", "2.0.0", false},
+		{">=1.2, <2", "1.1.0", false},
+		{"^1.2.3", "1.9.0", true},
+		{"^1.2.3", "2.0.0", false},
+		{"^0.2.3", "0.3.0", false},
+		{"~1.2.3", "1.2.9", true},
+		{"~1.2.3", "1.3.0", false},
+		{"1.x", "1.7.0", true},
+		{"1.x", "2.0.0", false},
+		{"1.2.*", "1.2.5", true},
+		{"<1 || >=2.1", "2.1.0", true},
+		{"<1 || >=2.1", "1.5.0", false},
+		{">=1.0.0", "1.0.0-beta.1", false},
+		{">=1.0.0-alpha", "1.0.0-beta.1", true},
+		{"<1.0.0", "1.0.0-rc.1", true},
+	}
+
+	for _, tt := range tests {
+		c, err := ParseVersionConstraint(tt.constraint)
+		require.NoError(t, err, tt.constraint)
+		v, err := ParseVersion(tt.version)
+		require.NoError(t, err, tt.version)
+		assert.Equal(t, tt.want, c.Check(v), "%s against %s", tt.version, tt.constraint)
+	}
+
+	for _, bad := range []string{">=", "1.2.3.4", ">>1", "1..2"} {
+		_, err := ParseVersionConstraint(bad)
+		assert.Error(t, err, bad)
+	}
+}
+
+func TestParseDependency(t *testing.T) {
+	dep, err := ParseDependency("auth >=1.2 <2")
+	require.NoError(t, err)
+	assert.Equal(t, "auth", dep.Name)
+	assert.Equal(t, "auth >=1.2 <2", dep.String())
+
+	dep, err = ParseDependency("auth^1.0")
+	require.NoError(t, err)
+	assert.Equal(t, "auth", dep.Name)
+	assert.Equal(t, "^1.0", dep.Constraint.String())
+
+	dep, err = ParseDependency("auth")
+	require.NoError(t, err)
+	assert.Equal(t, "*", dep.Constraint.String())
+
+	_, err = ParseDependency(">=1.0")
+	assert.Error(t, err)
+}
+
+func TestDiamondDependencies(t *testing.T) {
+	pr := NewPluginRegistry()
+	require.NoError(t, pr.Register(newVersionedPlugin("d", "1.4.0")))
+	require.NoError(t, pr.Register(newVersionedPlugin("b", "1.0.0", "d >=1.2")))
+	require.NoError(t, pr.Register(newVersionedPlugin("c", "1.0.0", "d ^1.0")))
+	require.NoError(t, pr.Register(newVersionedPlugin("a", "1.0.0", "b", "c ~1.0")))
+
+	order, err := pr.GetLoadOrder()
+	require.NoError(t, err)
+	names := make([]string, 0, len(order))
+	for _, p := range order {
+		names = append(names, p.Name())
+	}
+	assert.Equal(t, []string{"d", "b", "c", "a"}, names)
+
+	// 替换 d 会破坏 c 的约束
+	err = pr.CheckCompatibility(newVersionedPlugin("d", "2.0.0"))
+	conflicts := dependencyConflicts(t, err)
+	require.Len(t, conflicts, 1)
+	assert.Equal(t, "c", conflicts[0].Plugin)
+	assert.Equal(t, "2.0.0", conflicts[0].Found)
+	assert.NoError(t, pr.CheckCompatibility(newVersionedPlugin("d", "1.9.0")))
+}
+
+func TestConflictingConstraintsListed(t *testing.T) {
+	pr := NewPluginRegistry()
+	require.NoError(t, pr.Register(newVersionedPlugin("d", "1.5.0")))
+	require.NoError(t, pr.Register(newVersionedPlugin("c", "1.0.0", "d <2")))
+
+	// b 要求的版本与 c 的要求不可能同时满足
+	err := pr.Register(newVersionedPlugin("b", "1.0.0", "d >=2", "e"))
+	conflicts := dependencyConflicts(t, err)
+	require.Len(t, conflicts, 2)
+	assert.Equal(t, "d", conflicts[0].Dependency)
+	assert.Equal(t, "1.5.0", conflicts[0].Found)
+	assert.Equal(t, "e", conflicts[1].Dependency)
+	assert.Contains(t, err.Error(), "b requires d >=2, found 1.5.0")
+	assert.Contains(t, err.Error(), "b requires e, which is not loaded")
+	assert.False(t, pr.Exists("b"))
+
+	var de *DependencyError
+	require.True(t, errors.As(err, &de))
+	assert.False(t, de.Missing())
+
+	err = pr.Register(newVersionedPlugin("f", "1.0.0", "g"))
+	require.True(t, errors.As(err, &de))
+	assert.True(t, de.Missing())
+}
+
+func TestHostAPIVersion(t *testing.T) {
+	pr := NewPluginRegistry()
+
+	p := newVersionedPlugin("future", "1.0.0")
+	p.hostAPI = ">=2.0.0"
+	conflicts := dependencyConflicts(t, pr.Register(p))
+	require.Len(t, conflicts, 1)
+	assert.Equal(t, hostDependency, conflicts[0].Dependency)
+	assert.Equal(t, HostAPIVersion, conflicts[0].Found)
+
+	p.hostAPI = "^1.0"
+	assert.NoError(t, pr.Register(p))
+
+	bad := newVersionedPlugin("bad", "not-a-version")
+	conflicts = dependencyConflicts(t, pr.Register(bad))
+	require.Len(t, conflicts, 1)
+	assert.Contains(t, conflicts[0].Reason, "invalid version")
+}
+
+func TestOptionalDependencies(t *testing.T) {
+	pr := NewPluginRegistry()
+
+	// 缺少可选依赖不影响注册
+	cache := newVersionedPlugin("cache", "1.0.0")
+	cache.optional = []string{"metrics ^1.0"}
+	require.NoError(t, pr.Register(cache))
+
+	// 可选依赖存在时版本必须满足要求
+	conflicts := dependencyConflicts(t, pr.Register(newVersionedPlugin("metrics", "2.0.0")))
+	require.Len(t, conflicts, 1)
+	assert.Equal(t, "cache", conflicts[0].Plugin)
+
+	require.NoError(t, pr.Register(newVersionedPlugin("metrics", "1.3.0")))
+	order, err := pr.GetLoadOrder()
+	require.NoError(t, err)
+	require.Len(t, order, 2)
+	assert.Equal(t, "metrics", order[0].Name())
+
+	// 可选依赖不阻止卸载，必需依赖阻止卸载
+	require.NoError(t, pr.Unregister("metrics"))
+	require.NoError(t, pr.Register(newVersionedPlugin("api", "1.0.0", "cache")))
+	err = pr.Unregister("cache")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "api")
+}
+
+func TestOptionalDependencyCycle(t *testing.T) {
+	pr := NewPluginRegistry()
+
+	b := newVersionedPlugin("b", "1.0.0")
+	b.optional = []string{"a"}
+	require.NoError(t, pr.Register(b))
+	require.NoError(t, pr.Register(newVersionedPlugin("a", "1.0.0", "b")))
+
+	_, err := pr.GetLoadOrder()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "circular dependency detected: a -> b -> a")
+}
@@ -23,11 +23,13 @@ const (
 	EventConfigChanged  EventType = "config.changed"
 
 	// 业务事件
-	EventUserLogin   EventType = "user.login"
-	EventUserLogout  EventType = "user.logout"
-	EventDataCreated EventType = "data.created"
-	EventDataUpdated EventType = "data.updated"
-	EventDataDeleted EventType = "data.deleted"
+	EventUserLogin    EventType = "user.login"
+	EventUserLogout   EventType = "user.logout"
+	EventUserRegister EventType = "user.register"
+	EventUserUpdated  EventType = "user.updated"
+	EventDataCreated  EventType = "data.created"
+	EventDataUpdated  EventType = "data.updated"
+	EventDataDeleted  EventType = "data.deleted"
 )
 
 // Event 事件接口
@@ -66,8 +68,10 @@ type EventFilter interface {
 
 // EventBus 事件总线接口
 type EventBus interface {
-	// Subscribe 订阅事件
+	// Subscribe 订阅事件，使用 DefaultDeliveryOptions
 	Subscribe(eventType EventType, handler EventHandler) error
+	// SubscribeWithOptions 按指定投递选项订阅事件
+	SubscribeWithOptions(eventType EventType, handler EventHandler, options DeliveryOptions) error
 	// Unsubscribe 取消订阅
 	Unsubscribe(eventType EventType, handlerName string) error
 	// Publish 发布事件
@@ -78,4 +82,10 @@ type EventBus interface {
 	AddFilter(filter EventFilter) error
 	// RemoveFilter 移除事件过滤器
 	RemoveFilter(filter EventFilter) error
+	// ListDeadLetters 查询重试次数用尽的投递
+	ListDeadLetters(ctx context.Context, query DeadLetterQuery) ([]*DeliveryRecord, error)
+	// ReplayDeadLetter 重新投递死信，id 为投递的幂等键
+	ReplayDeadLetter(ctx context.Context, id string) error
+	// Close 停止持久化队列的投递
+	Close() error
 }
@@ -2,8 +2,11 @@ package plugin
 
 import (
 	"context"
+	"fmt"
 	"sync"
 	"time"
+
+	"github.com/go-kratos/kratos/v2/log"
 	"github.com/google/uuid"
 )
 
@@ -13,35 +16,111 @@ type eventBusImpl struct {
 	subscribers  map[EventType][]*subscription
 	filters      []EventFilter
 	asyncWorkers int
+
+	// 至少一次投递的持久化队列与投递协程
+	store        EventStore
+	consumerID   string
+	pollInterval time.Duration
+	lease        time.Duration
+	batchSize    int
+	startOnce    sync.Once
+	closeOnce    sync.Once
+	wake         chan struct{}
+	done         chan struct{}
+	stopped      chan struct{}
+	logger       *log.Helper
 }
 
 // subscription 订阅信息
 type subscription struct {
 	handler EventHandler
 	id      string
+	options DeliveryOptions
+}
+
+// EventBusConfig 事件总线配置
+type EventBusConfig struct {
+	// AsyncWorkers 同时投递的最大协程数
+	AsyncWorkers int
+	// Store 至少一次投递的持久化队列，为 nil 时使用内存队列
+	Store EventStore
+	// ConsumerID 领取投递记录的消费者标识，为空时自动生成
+	ConsumerID string
+	// PollInterval 轮询持久化队列的间隔
+	PollInterval time.Duration
+	// Lease 领取记录后的租约时长，超时未确认的记录会被重新投递
+	Lease time.Duration
+	// BatchSize 每次领取的最大记录数
+	BatchSize int
 }
 
 // NewEventBus 创建新的事件总线
 func NewEventBus(asyncWorkers int) EventBus {
-	if asyncWorkers <= 0 {
-		asyncWorkers = 10
+	return NewEventBusWithConfig(EventBusConfig{AsyncWorkers: asyncWorkers})
+}
+
+// NewEventBusWithConfig 根据配置创建事件总线
+// 配置了持久化队列时立即开始投递上次退出前未完成的记录
+func NewEventBusWithConfig(config EventBusConfig) EventBus {
+	if config.AsyncWorkers <= 0 {
+		config.AsyncWorkers = 10
+	}
+	if config.ConsumerID == "" {
+		config.ConsumerID = uuid.New().String()
+	}
+	if config.PollInterval <= 0 {
+		config.PollInterval = defaultPollInterval
+	}
+	if config.Lease <= 0 {
+		config.Lease = defaultDeliveryLease
+	}
+	if config.BatchSize <= 0 {
+		config.BatchSize = defaultDeliveryBatch
 	}
 
-	return &eventBusImpl{
+	eb := &eventBusImpl{
 		subscribers:  make(map[EventType][]*subscription),
 		filters:      make([]EventFilter, 0),
-		asyncWorkers: asyncWorkers,
+		asyncWorkers: config.AsyncWorkers,
+		store:        config.Store,
+		consumerID:   config.ConsumerID,
+		pollInterval: config.PollInterval,
+		lease:        config.Lease,
+		batchSize:    config.BatchSize,
+		wake:         make(chan struct{}, 1),
+		done:         make(chan struct{}),
+		stopped:      make(chan struct{}),
+		logger:       log.NewHelper(log.With(log.GetLogger(), "module", "plugin.event_bus")),
+	}
+	if eb.store == nil {
+		eb.store = NewMemoryEventStore()
+	} else {
+		eb.startDispatcher()
 	}
+	return eb
 }
 
 // Subscribe 订阅事件
 func (eb *eventBusImpl) Subscribe(eventType EventType, handler EventHandler) error {
+	return eb.SubscribeWithOptions(eventType, handler, DefaultDeliveryOptions())
+}
+
+// SubscribeWithOptions 按指定投递选项订阅事件
+func (eb *eventBusImpl) SubscribeWithOptions(eventType EventType, handler EventHandler, options DeliveryOptions) error {
+	if options.Mode == "" {
+		options.Mode = DeliveryBestEffort
+	}
+	if options.Mode != DeliveryBestEffort && options.Mode != DeliveryAtLeastOnce {
+		return NewPluginError(ErrCodePluginConfigError, "unknown delivery mode: "+string(options.Mode), handler.GetName(), nil)
+	}
+
 	eb.mu.Lock()
 	defer eb.mu.Unlock()
 
 	sub := &subscription{
 		handler: handler,
 		id:      uuid.New().String(),
+		options: options,
 	}
 
 	eb.subscribers[eventType] = append(eb.subscribers[eventType], sub)
@@ -69,6 +148,7 @@ func (eb *eventBusImpl) Unsubscribe(eventType EventType, handlerName string) err
 }
 
 // Publish 发布事件
+// 至少一次投递的订阅在事件写入持久化队列后即返回，由投递协程异步处理
 func (eb *eventBusImpl) Publish(ctx context.Context, event Event) error {
 	return eb.publish(ctx, event, false)
 }
@@ -80,36 +160,55 @@ func (eb *eventBusImpl) PublishAsync(ctx context.Context, event Event) error {
 
 // publish 发布事件核心实现
 func (eb *eventBusImpl) publish(ctx context.Context, event Event, async bool) error {
+	// 重试退避期间不持有锁，避免阻塞订阅与取消订阅
 	eb.mu.RLock()
-	defer eb.mu.RUnlock()
-
-	// 检查事件过滤器
 	if !eb.passFilters(event) {
+		eb.mu.RUnlock()
 		return nil
 	}
+	subs := append([]*subscription(nil), eb.subscribers[event.GetType()]...)
+	eb.mu.RUnlock()
 
-	subs, exists := eb.subscribers[event.GetType()]
-	if !exists {
+	if len(subs) == 0 {
 		return nil
 	}
 
+	// 至少一次投递的订阅先写入持久化队列，写入失败时不投递任何订阅者
+	var durable []*DeliveryRecord
+	now := time.Now()
+	for _, sub := range subs {
+		if sub.options.Mode == DeliveryAtLeastOnce {
+			durable = append(durable, newDeliveryRecord(event, sub.handler.GetName(), now))
+		}
+	}
+	if len(durable) > 0 {
+		if err := eb.store.Enqueue(ctx, durable); err != nil {
+			return NewPluginError(ErrCodePluginInternal, "failed to enqueue event", "event_bus", fmt.Errorf("%w: %v", ErrEnqueueFailed, err))
+		}
+		eb.startDispatcher()
+		eb.notify()
+	}
+
 	var wg sync.WaitGroup
 	var errors []error
 	var mu sync.Mutex
 
 	for _, sub := range subs {
+		if sub.options.Mode == DeliveryAtLeastOnce {
+			continue
+		}
 		if async {
 			wg.Add(1)
 			go func(s *subscription) {
 				defer wg.Done()
-				if err := eb.handleEvent(ctx, s.handler, event); err != nil {
+				if err := eb.deliver(ctx, s, event); err != nil {
 					mu.Lock()
 					errors = append(errors, err)
 					mu.Unlock()
 				}
 			}(sub)
 		} else {
-			if err := eb.handleEvent(ctx, sub.handler, event); err != nil {
+			if err := eb.deliver(ctx, sub, event); err != nil {
 				errors = append(errors, err)
 			}
 		}
@@ -126,6 +225,51 @@ func (eb *eventBusImpl) publish(ctx context.Context, event Event, async bool) er
 	return nil
 }
 
+// deliver 在内存中投递事件，失败时按订阅的重试选项退避重试
+// 重试次数用尽后写入死信，可通过 ReplayDeadLetter 重新投递
+func (eb *eventBusImpl) deliver(ctx context.Context, sub *subscription, event Event) error {
+	name := sub.handler.GetName()
+	key := idempotencyKey(event.GetID(), name)
+
+	for attempt := 1; ; attempt++ {
+		err := eb.handleEvent(withDelivery(ctx, Delivery{
+			Attempt:        attempt,
+			IdempotencyKey: key,
+			Subscription:   name,
+		}), sub.handler, event)
+		if err == nil {
+			return nil
+		}
+		if attempt > sub.options.MaxRetries {
+			if sub.options.MaxRetries > 0 {
+				eb.deadLetter(event, name, attempt, err)
+			}
+			return err
+		}
+
+		timer := time.NewTimer(sub.options.backoff(attempt))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		case <-timer.C:
+		}
+	}
+}
+
+// deadLetter 将内存投递失败的事件写入死信
+func (eb *eventBusImpl) deadLetter(event Event, subscription string, attempts int, cause error) {
+	record := newDeliveryRecord(event, subscription, time.Now())
+	record.Status = DeliveryDead
+	record.Attempts = attempts
+	record.LastError = cause.Error()
+	if err := eb.store.Enqueue(context.Background(), []*DeliveryRecord{record}); err != nil {
+		eb.logger.Errorf("failed to dead-letter event %s for %s: %v", event.GetID(), subscription, err)
+		return
+	}
+	eb.logger.Warnf("event %s dead-lettered for %s after %d attempts: %v", event.GetID(), subscription, attempts, cause)
+}
+
 // handleEvent 处理单个事件
 func (eb *eventBusImpl) handleEvent(ctx context.Context, handler EventHandler, event Event) error {
 	timeout := handler.GetTimeout()
@@ -195,6 +339,21 @@ func NewEvent(eventType EventType, source string, data map[string]interface{}) E
 	}
 }
 
+// RestoreEvent 还原持久化的事件，保留原事件 ID 以便订阅者去重
+func RestoreEvent(id string, eventType EventType, source string, timestamp time.Time, data map[string]interface{}, metadata map[string]string) Event {
+	if metadata == nil {
+		metadata = make(map[string]string)
+	}
+	return &eventImpl{
+		id:        id,
+		type_:     eventType,
+		source:    source,
+		timestamp: timestamp,
+		data:      data,
+		metadata:  metadata,
+	}
+}
+
 func (e *eventImpl) GetID() string {
 	return e.id
 }
@@ -2,6 +2,8 @@ package plugin
 
 import (
 	"context"
+	"errors"
+	"net/http"
 	"time"
 )
 
@@ -68,4 +70,55 @@ type HookManager interface {
 	ListHooks(point HookPoint) []Hook
 	// GetHook 获取指定钩子
 	GetHook(point HookPoint, hookName string) (Hook, error)
+	// SetPointPolicy 设置钩子点的执行策略
+	SetPointPolicy(point HookPoint, policy HookPointPolicy)
+	// SetHookPolicy 设置钩子的失败处理与熔断策略，对之后注册的同名钩子同样生效
+	SetHookPolicy(point HookPoint, hookName string, policy HookPolicy)
+	// GetHookStats 获取钩子的执行统计与熔断状态
+	GetHookStats(point HookPoint, hookName string) (HookStats, error)
+	// ResetHook 关闭熔断，重新启用被自动停用的钩子
+	ResetHook(point HookPoint, hookName string) error
+}
+
+// HookAbortError 钩子主动中止请求的错误
+// 钩子返回该错误时，同一钩子点的后续钩子不再执行，请求以 Status/Reason/Message 结束
+type HookAbortError struct {
+	Status  int    `json:"status"`
+	Reason  string `json:"reason"`
+	Message string `json:"message"`
+
+	// cause fail_closed 钩子失败时的原始错误
+	cause error
+}
+
+func (e *HookAbortError) Error() string {
+	return "hook aborted: " + e.Reason + ": " + e.Message
+}
+
+func (e *HookAbortError) Unwrap() error {
+	return e.cause
+}
+
+// NewHookAbortError 创建钩子中止错误，status 为 0 时使用 403
+func NewHookAbortError(status int, reason, message string) *HookAbortError {
+	if status == 0 {
+		status = http.StatusForbidden
+	}
+	if reason == "" {
+		reason = ErrCodeHookAborted
+	}
+	return &HookAbortError{
+		Status:  status,
+		Reason:  reason,
+		Message: message,
+	}
+}
+
+// AsHookAbort 判断错误链中是否包含钩子中止错误
+func AsHookAbort(err error) (*HookAbortError, bool) {
+	var abort *HookAbortError
+	if errors.As(err, &abort) {
+		return abort, true
+	}
+	return nil, false
 }
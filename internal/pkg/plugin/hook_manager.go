@@ -5,6 +5,24 @@ import (
 	"sort"
 	"sync"
 	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// hookInstrumentationName 钩子执行的追踪与指标名称
+const hookInstrumentationName = "kratos-boilerplate/internal/pkg/plugin"
+
+// 钩子执行结果，用作指标与追踪的 outcome 属性
+const (
+	hookOutcomeSuccess      = "success"
+	hookOutcomeError        = "error"
+	hookOutcomeAborted      = "aborted"
+	hookOutcomeShortCircuit = "short_circuit"
+	hookOutcomeRejected     = "rejected"
 )
 
 // hookEntry 钩子条目
@@ -13,19 +31,79 @@ type hookEntry struct {
 	priority int
 }
 
+// hookKey 钩子点内的钩子标识
+type hookKey struct {
+	point HookPoint
+	name  string
+}
+
+// HookManagerConfig 钩子管理器配置
+type HookManagerConfig struct {
+	// TracerProvider 钩子执行的追踪，为空时使用全局 TracerProvider
+	TracerProvider trace.TracerProvider
+	// MeterProvider 钩子执行的指标，为空时使用全局 MeterProvider
+	MeterProvider metric.MeterProvider
+	// Points 钩子点的执行策略
+	Points map[HookPoint]HookPointPolicy
+	// Hooks 钩子的失败处理与熔断策略，键为钩子点与钩子名称
+	Hooks map[HookPoint]map[string]HookPolicy
+}
+
 // hookManagerImpl 钩子管理器实现
 type hookManagerImpl struct {
-	mu     sync.RWMutex
-	hooks  map[HookPoint][]hookEntry
-	timers map[string]*time.Timer
+	mu       sync.RWMutex
+	hooks    map[HookPoint][]hookEntry
+	points   map[HookPoint]HookPointPolicy
+	policies map[hookKey]HookPolicy
+	breakers map[hookKey]*hookBreaker
+
+	tracer     trace.Tracer
+	executions metric.Int64Counter
+	duration   metric.Float64Histogram
+	now        func() time.Time
 }
 
 // NewHookManager 创建新的钩子管理器
 func NewHookManager() HookManager {
-	return &hookManagerImpl{
-		hooks:  make(map[HookPoint][]hookEntry),
-		timers: make(map[string]*time.Timer),
+	return NewHookManagerWithConfig(HookManagerConfig{})
+}
+
+// NewHookManagerWithConfig 根据配置创建钩子管理器
+func NewHookManagerWithConfig(config HookManagerConfig) HookManager {
+	tp := config.TracerProvider
+	if tp == nil {
+		tp = otel.GetTracerProvider()
+	}
+	mp := config.MeterProvider
+	if mp == nil {
+		mp = otel.GetMeterProvider()
 	}
+
+	hm := &hookManagerImpl{
+		hooks:    make(map[HookPoint][]hookEntry),
+		points:   make(map[HookPoint]HookPointPolicy),
+		policies: make(map[hookKey]HookPolicy),
+		breakers: make(map[hookKey]*hookBreaker),
+		tracer:   tp.Tracer(hookInstrumentationName),
+		now:      time.Now,
+	}
+
+	meter := mp.Meter(hookInstrumentationName)
+	// 指标创建失败时返回的仍是可用的空实现
+	hm.executions, _ = meter.Int64Counter("plugin.hook.executions",
+		metric.WithDescription("Number of plugin hook executions by outcome"))
+	hm.duration, _ = meter.Float64Histogram("plugin.hook.duration",
+		metric.WithDescription("Duration of plugin hook executions"), metric.WithUnit("s"))
+
+	for point, policy := range config.Points {
+		hm.points[point] = policy
+	}
+	for point, hooks := range config.Hooks {
+		for name, policy := range hooks {
+			hm.policies[hookKey{point, name}] = policy
+		}
+	}
+	return hm
 }
 
 // RegisterHook 注册钩子
@@ -45,6 +123,7 @@ func (hm *hookManagerImpl) RegisterHook(point HookPoint, hook Hook) error {
 		hook:     hook,
 		priority: hook.GetPriority(),
 	})
+	hm.breakers[hookKey{point, hook.GetName()}] = newHookBreaker()
 
 	// 按优先级排序（数值越小优先级越高）
 	sort.Slice(hm.hooks[point], func(i, j int) bool {
@@ -67,6 +146,7 @@ func (hm *hookManagerImpl) UnregisterHook(point HookPoint, hookName string) erro
 	for i, entry := range hooks {
 		if entry.hook.GetName() == hookName {
 			hm.hooks[point] = append(hooks[:i], hooks[i+1:]...)
+			delete(hm.breakers, hookKey{point, hookName})
 			return nil
 		}
 	}
@@ -74,57 +154,237 @@ func (hm *hookManagerImpl) UnregisterHook(point HookPoint, hookName string) erro
 	return NewPluginError(ErrCodePluginNotFound, "hook not found", hookName, nil)
 }
 
+// hookRun 一次钩子执行计划
+type hookRun struct {
+	hook    Hook
+	policy  HookPolicy
+	breaker *hookBreaker
+}
+
+// hookResult 钩子执行结果
+type hookResult struct {
+	err      error
+	rejected bool
+}
+
 // ExecuteHooks 执行钩子点的所有钩子
+//
+// 钩子中止（HookAbortError）或短路（HookShortCircuit）时立即返回；fail_closed 钩子失败
+// 或被熔断时以 HookAbortError 中止；fail_open 钩子的错误不影响后续钩子，返回最后一个错误。
+// 并行执行时所有钩子执行完毕后按优先级依次判断上述结果并合并数据修改。
 func (hm *hookManagerImpl) ExecuteHooks(ctx context.Context, point HookPoint, data HookData) error {
 	hm.mu.RLock()
-	hooks, exists := hm.hooks[point]
+	entries := hm.hooks[point]
+	pointPolicy := hm.points[point]
+	runs := make([]hookRun, 0, len(entries))
+	for _, entry := range entries {
+		key := hookKey{point, entry.hook.GetName()}
+		runs = append(runs, hookRun{
+			hook:    entry.hook,
+			policy:  hm.hookPolicy(key, entry.hook, pointPolicy),
+			breaker: hm.breakers[key],
+		})
+	}
 	hm.mu.RUnlock()
 
-	if !exists || len(hooks) == 0 {
+	if len(runs) == 0 {
 		return nil
 	}
 
-	var lastError error
+	if pointPolicy.Execution == HookExecutionParallel {
+		return hm.executeParallel(ctx, point, runs, data)
+	}
 
-	for _, entry := range hooks {
-		hook := entry.hook
-		timeout := hook.GetTimeout()
-		if timeout == 0 {
-			timeout = 30 * time.Second // 默认超时时间
+	var lastError error
+	for _, run := range runs {
+		result := hm.executeSingleHook(ctx, point, run, data)
+		if stop, err := hookOutcome(run, result); stop {
+			return err
+		} else if err != nil {
+			lastError = err
 		}
+	}
 
-		hookCtx, cancel := context.WithTimeout(ctx, timeout)
-		defer cancel()
+	return lastError
+}
 
-		// 执行钩子
-		if err := hm.executeSingleHook(hookCtx, hook, data); err != nil {
+// executeParallel 并行执行钩子，每个钩子使用独立的数据副本
+func (hm *hookManagerImpl) executeParallel(ctx context.Context, point HookPoint, runs []hookRun, data HookData) error {
+	views := make([]*hookDataView, len(runs))
+	results := make([]hookResult, len(runs))
+
+	var wg sync.WaitGroup
+	for i, run := range runs {
+		views[i] = newHookDataView(data)
+		wg.Add(1)
+		go func(i int, run hookRun) {
+			defer wg.Done()
+			results[i] = hm.executeSingleHook(ctx, point, run, views[i])
+		}(i, run)
+	}
+	wg.Wait()
+
+	var lastError error
+	for i, run := range runs {
+		for _, w := range views[i].writes {
+			data.SetData(w.key, w.value)
+		}
+		if stop, err := hookOutcome(run, results[i]); stop {
+			return err
+		} else if err != nil {
 			lastError = err
-			// 继续执行其他钩子
 		}
 	}
-
 	return lastError
 }
 
-// executeSingleHook 执行单个钩子
-func (hm *hookManagerImpl) executeSingleHook(ctx context.Context, hook Hook, data HookData) error {
-	hookName := hook.GetName()
-	requestID, _ := data.GetContext().Value("request_id").(string)
-	hookID := requestID + "_" + hookName
+// hookOutcome 根据钩子策略判断执行结果，stop 为 true 时不再执行后续钩子
+func hookOutcome(run hookRun, result hookResult) (stop bool, err error) {
+	name := run.hook.GetName()
+	if result.rejected {
+		if run.policy.Failure == HookFailClosed {
+			return true, newHookFailedError(name, NewPluginError(ErrCodePluginInternal, "hook circuit open", name, nil))
+		}
+		return false, nil
+	}
+	if result.err == nil {
+		return false, nil
+	}
+	if _, ok := AsHookAbort(result.err); ok {
+		return true, result.err
+	}
+	if _, ok := AsHookShortCircuit(result.err); ok {
+		return true, result.err
+	}
+	if run.policy.Failure == HookFailClosed {
+		return true, newHookFailedError(name, result.err)
+	}
+	return false, result.err
+}
+
+// hookPolicy 钩子的生效策略：SetHookPolicy 设置的策略优先，其次为钩子自带的策略，
+// 未指定失败处理方式时使用钩子点的设置，默认 fail_open
+func (hm *hookManagerImpl) hookPolicy(key hookKey, hook Hook, point HookPointPolicy) HookPolicy {
+	policy, ok := hm.policies[key]
+	if !ok {
+		if ph, isPolicyHook := hook.(PolicyHook); isPolicyHook {
+			policy = ph.GetPolicy()
+		}
+	}
+	if policy.Failure == "" {
+		policy.Failure = point.Failure
+	}
+	if policy.Failure == "" {
+		policy.Failure = HookFailOpen
+	}
+	return policy
+}
 
-	// 设置超时定时器
-	timer := time.AfterFunc(hook.GetTimeout(), func() {
-		// 超时处理
-	})
-	hm.timers[hookID] = timer
-	defer delete(hm.timers, hookID)
+// executeSingleHook 执行单个钩子，记录追踪、指标与熔断统计
+func (hm *hookManagerImpl) executeSingleHook(ctx context.Context, point HookPoint, run hookRun, data HookData) hookResult {
+	hookName := run.hook.GetName()
+	attrs := []attribute.KeyValue{
+		attribute.String("hook.point", string(point)),
+		attribute.String("hook.name", hookName),
+	}
+
+	ctx, span := hm.tracer.Start(ctx, "plugin.hook/"+string(point), trace.WithAttributes(attrs...))
+	defer span.End()
+
+	if run.breaker != nil && !run.breaker.allow(run.policy, hm.now()) {
+		span.SetAttributes(attribute.String("hook.outcome", hookOutcomeRejected))
+		hm.executions.Add(ctx, 1, metric.WithAttributes(append(attrs, attribute.String("outcome", hookOutcomeRejected))...))
+		return hookResult{rejected: true}
+	}
+
+	timeout := run.hook.GetTimeout()
+	if timeout == 0 {
+		timeout = 30 * time.Second // 默认超时时间
+	}
+
+	hookCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	start := hm.now()
+	err := run.hook.Execute(hookCtx, data)
+	latency := hm.now().Sub(start)
+
+	outcome := hookOutcomeSuccess
+	var failure error
+	if err != nil {
+		switch {
+		case isHookAbort(err):
+			outcome = hookOutcomeAborted
+		case isHookShortCircuit(err):
+			outcome = hookOutcomeShortCircuit
+		default:
+			outcome = hookOutcomeError
+			failure = err
+		}
+		err = NewPluginError(ErrCodePluginInternal, "hook execution failed", hookName, err)
+	}
+
+	// 中止与短路是钩子的正常结果，不计入熔断
+	if run.breaker != nil && run.breaker.record(run.policy, failure, latency, hm.now()) {
+		span.AddEvent("hook circuit opened")
+	}
+
+	span.SetAttributes(attribute.String("hook.outcome", outcome))
+	if failure != nil {
+		span.RecordError(failure)
+		span.SetStatus(codes.Error, failure.Error())
+	}
+	outcomeAttrs := metric.WithAttributes(append(attrs, attribute.String("outcome", outcome))...)
+	hm.executions.Add(ctx, 1, outcomeAttrs)
+	hm.duration.Record(ctx, latency.Seconds(), outcomeAttrs)
+
+	return hookResult{err: err}
+}
+
+func isHookAbort(err error) bool {
+	_, ok := AsHookAbort(err)
+	return ok
+}
+
+func isHookShortCircuit(err error) bool {
+	_, ok := AsHookShortCircuit(err)
+	return ok
+}
+
+// SetPointPolicy 设置钩子点的执行策略
+func (hm *hookManagerImpl) SetPointPolicy(point HookPoint, policy HookPointPolicy) {
+	hm.mu.Lock()
+	defer hm.mu.Unlock()
+	hm.points[point] = policy
+}
 
-	// 执行钩子
-	if err := hook.Execute(ctx, data); err != nil {
-		return NewPluginError(ErrCodePluginInternal, "hook execution failed", hookName, err)
+// SetHookPolicy 设置钩子的失败处理与熔断策略
+func (hm *hookManagerImpl) SetHookPolicy(point HookPoint, hookName string, policy HookPolicy) {
+	hm.mu.Lock()
+	defer hm.mu.Unlock()
+	hm.policies[hookKey{point, hookName}] = policy
+}
+
+// GetHookStats 获取钩子的执行统计与熔断状态
+func (hm *hookManagerImpl) GetHookStats(point HookPoint, hookName string) (HookStats, error) {
+	hm.mu.RLock()
+	breaker, ok := hm.breakers[hookKey{point, hookName}]
+	hm.mu.RUnlock()
+	if !ok {
+		return HookStats{}, NewPluginError(ErrCodePluginNotFound, "hook not found", hookName, nil)
 	}
+	return breaker.snapshot(), nil
+}
 
-	timer.Stop()
+// ResetHook 关闭熔断，重新启用被自动停用的钩子
+func (hm *hookManagerImpl) ResetHook(point HookPoint, hookName string) error {
+	hm.mu.RLock()
+	breaker, ok := hm.breakers[hookKey{point, hookName}]
+	hm.mu.RUnlock()
+	if !ok {
+		return NewPluginError(ErrCodePluginNotFound, "hook not found", hookName, nil)
+	}
+	breaker.reset()
 	return nil
 }
 
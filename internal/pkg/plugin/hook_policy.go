@@ -0,0 +1,326 @@
+package plugin
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const (
+	// defaultBreakerWindow 熔断统计的最近执行次数
+	defaultBreakerWindow = 20
+	// defaultBreakerMinRequests 窗口内至少执行多少次才判断是否熔断
+	defaultBreakerMinRequests = 10
+	// defaultBreakerCooldown 熔断后到下一次试探执行的等待时间
+	defaultBreakerCooldown = 30 * time.Second
+)
+
+// HookExecutionMode 钩子点内钩子的执行方式
+type HookExecutionMode string
+
+const (
+	// HookExecutionSequential 按优先级依次执行，前一个钩子的修改对后续钩子可见
+	HookExecutionSequential HookExecutionMode = "sequential"
+	// HookExecutionParallel 并行执行，每个钩子看到执行前的数据，修改按优先级合并
+	HookExecutionParallel HookExecutionMode = "parallel"
+)
+
+// HookFailureMode 钩子执行失败时的处理方式
+type HookFailureMode string
+
+const (
+	// HookFailOpen 记录错误后继续，适用于审计、通知等旁路钩子
+	HookFailOpen HookFailureMode = "fail_open"
+	// HookFailClosed 中止请求，适用于鉴权、风控等安全钩子；钩子被熔断时同样中止
+	HookFailClosed HookFailureMode = "fail_closed"
+)
+
+// HookPointPolicy 钩子点的执行策略
+type HookPointPolicy struct {
+	// Execution 执行方式，默认依次执行
+	Execution HookExecutionMode
+	// Failure 钩子未指定时的失败处理方式，默认 fail_open
+	Failure HookFailureMode
+}
+
+// HookPolicy 单个钩子的失败处理与熔断策略
+//
+// 最近 Window 次执行中失败（返回错误或超过 MaxLatency）的比例达到 MaxErrorRate 时熔断，
+// 熔断期间钩子不执行；Cooldown 之后试探执行一次，成功则恢复，失败则继续熔断。
+type HookPolicy struct {
+	// Failure 失败处理方式，为空时使用钩子点的设置
+	Failure HookFailureMode
+	// MaxErrorRate 允许的失败比例，为 0 时不熔断
+	MaxErrorRate float64
+	// MaxLatency 延迟预算，超过的执行计为失败，为 0 时不限制
+	MaxLatency time.Duration
+	// Window 统计的最近执行次数
+	Window int
+	// MinRequests 窗口内至少执行多少次才判断是否熔断
+	MinRequests int
+	// Cooldown 熔断后到试探执行的等待时间
+	Cooldown time.Duration
+}
+
+// breakerEnabled 是否启用熔断
+func (p HookPolicy) breakerEnabled() bool {
+	return p.MaxErrorRate > 0
+}
+
+func (p HookPolicy) window() int {
+	if p.Window <= 0 {
+		return defaultBreakerWindow
+	}
+	return p.Window
+}
+
+func (p HookPolicy) minRequests() int {
+	n := p.MinRequests
+	if n <= 0 {
+		n = defaultBreakerMinRequests
+	}
+	if n > p.window() {
+		n = p.window()
+	}
+	return n
+}
+
+func (p HookPolicy) cooldown() time.Duration {
+	if p.Cooldown <= 0 {
+		return defaultBreakerCooldown
+	}
+	return p.Cooldown
+}
+
+// PolicyHook 自带执行策略的钩子，HookManager.SetHookPolicy 设置的策略优先
+type PolicyHook interface {
+	Hook
+	// GetPolicy 获取钩子的失败处理与熔断策略
+	GetPolicy() HookPolicy
+}
+
+// HookShortCircuit 钩子直接给出响应的结果
+// 钩子返回该错误时，同一钩子点的后续钩子不再执行；在 before_request 中返回时业务处理被跳过，
+// 请求以 Reply 成功结束。进程外插件与 WASM 插件的钩子不支持短路。
+type HookShortCircuit struct {
+	Reply interface{}
+}
+
+func (e *HookShortCircuit) Error() string {
+	return "hook short-circuited"
+}
+
+// NewHookShortCircuit 创建钩子短路结果
+func NewHookShortCircuit(reply interface{}) *HookShortCircuit {
+	return &HookShortCircuit{Reply: reply}
+}
+
+// AsHookShortCircuit 判断错误链中是否包含钩子短路结果
+func AsHookShortCircuit(err error) (*HookShortCircuit, bool) {
+	var sc *HookShortCircuit
+	if errors.As(err, &sc) {
+		return sc, true
+	}
+	return nil, false
+}
+
+// newHookFailedError fail_closed 钩子失败时中止请求的错误
+func newHookFailedError(hookName string, cause error) *HookAbortError {
+	abort := NewHookAbortError(http.StatusServiceUnavailable, ErrCodeHookFailed, "hook "+hookName+" failed")
+	abort.cause = cause
+	return abort
+}
+
+// HookBreakerState 钩子熔断状态
+type HookBreakerState string
+
+const (
+	// HookBreakerClosed 正常执行
+	HookBreakerClosed HookBreakerState = "closed"
+	// HookBreakerOpen 已熔断，钩子不执行
+	HookBreakerOpen HookBreakerState = "open"
+	// HookBreakerHalfOpen 冷却结束，正在试探执行
+	HookBreakerHalfOpen HookBreakerState = "half_open"
+)
+
+// HookStats 钩子的执行统计与熔断状态
+type HookStats struct {
+	// Executions 执行次数
+	Executions int64
+	// Failures 返回错误的次数
+	Failures int64
+	// SlowCalls 超过延迟预算的次数
+	SlowCalls int64
+	// Rejected 熔断期间跳过的次数
+	Rejected int64
+	// State 熔断状态
+	State HookBreakerState
+	// OpenedAt 最近一次熔断的时间
+	OpenedAt time.Time
+	// LastError 最近一次错误
+	LastError string
+}
+
+// hookBreaker 单个钩子的执行统计与熔断器
+type hookBreaker struct {
+	mu      sync.Mutex
+	results []bool
+	next    int
+	count   int
+	probing bool
+	stats   HookStats
+}
+
+func newHookBreaker() *hookBreaker {
+	return &hookBreaker{stats: HookStats{State: HookBreakerClosed}}
+}
+
+// allow 判断钩子本次是否执行，熔断冷却结束时放行一次试探执行
+func (b *hookBreaker) allow(policy HookPolicy, now time.Time) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.stats.State {
+	case HookBreakerOpen:
+		if now.Sub(b.stats.OpenedAt) >= policy.cooldown() {
+			b.stats.State = HookBreakerHalfOpen
+			b.probing = true
+			return true
+		}
+	case HookBreakerHalfOpen:
+		if !b.probing {
+			b.probing = true
+			return true
+		}
+	default:
+		return true
+	}
+	b.stats.Rejected++
+	return false
+}
+
+// record 记录执行结果，返回本次是否触发熔断
+func (b *hookBreaker) record(policy HookPolicy, err error, latency time.Duration, now time.Time) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.stats.Executions++
+	failed := err != nil
+	if failed {
+		b.stats.Failures++
+		b.stats.LastError = err.Error()
+	}
+	slow := policy.MaxLatency > 0 && latency > policy.MaxLatency
+	if slow {
+		b.stats.SlowCalls++
+	}
+	bad := failed || slow
+
+	if b.stats.State == HookBreakerHalfOpen {
+		b.probing = false
+		if bad {
+			b.stats.State = HookBreakerOpen
+			b.stats.OpenedAt = now
+			return true
+		}
+		b.stats.State = HookBreakerClosed
+		b.resetWindow()
+		return false
+	}
+	if !policy.breakerEnabled() || b.stats.State != HookBreakerClosed {
+		return false
+	}
+
+	window := policy.window()
+	if len(b.results) != window {
+		b.results = make([]bool, window)
+		b.next, b.count = 0, 0
+	}
+	b.results[b.next] = bad
+	b.next = (b.next + 1) % window
+	if b.count < window {
+		b.count++
+	}
+	if b.count < policy.minRequests() {
+		return false
+	}
+
+	var failures int
+	for i := 0; i < b.count; i++ {
+		if b.results[i] {
+			failures++
+		}
+	}
+	if float64(failures)/float64(b.count) >= policy.MaxErrorRate {
+		b.stats.State = HookBreakerOpen
+		b.stats.OpenedAt = now
+		return true
+	}
+	return false
+}
+
+// reset 关闭熔断并清空统计窗口
+func (b *hookBreaker) reset() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.stats.State = HookBreakerClosed
+	b.probing = false
+	b.resetWindow()
+}
+
+func (b *hookBreaker) resetWindow() {
+	b.results = nil
+	b.next, b.count = 0, 0
+}
+
+func (b *hookBreaker) snapshot() HookStats {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.stats
+}
+
+// hookWrite 并行执行时钩子对数据的一次修改
+type hookWrite struct {
+	key   string
+	value interface{}
+}
+
+// hookDataView 并行执行时每个钩子独立的数据副本，记录修改以便按优先级合并
+type hookDataView struct {
+	base     HookData
+	data     map[string]interface{}
+	metadata map[string]string
+	writes   []hookWrite
+}
+
+func newHookDataView(base HookData) *hookDataView {
+	data := make(map[string]interface{}, len(base.GetData()))
+	for k, v := range base.GetData() {
+		data[k] = v
+	}
+	metadata := make(map[string]string, len(base.GetMetadata()))
+	for k, v := range base.GetMetadata() {
+		metadata[k] = v
+	}
+	return &hookDataView{base: base, data: data, metadata: metadata}
+}
+
+func (v *hookDataView) GetContext() context.Context {
+	return v.base.GetContext()
+}
+
+func (v *hookDataView) GetData() map[string]interface{} {
+	return v.data
+}
+
+func (v *hookDataView) SetData(key string, value interface{}) {
+	v.data[key] = value
+	v.writes = append(v.writes, hookWrite{key: key, value: value})
+}
+
+// GetMetadata 返回元数据副本，并行执行时对元数据的修改不合并
+func (v *hookDataView) GetMetadata() map[string]string {
+	return v.metadata
+}
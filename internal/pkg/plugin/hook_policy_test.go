@@ -0,0 +1,241 @@
+package plugin
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+// policyHook 自带策略的测试钩子
+type policyHook struct {
+	Hook
+	policy HookPolicy
+}
+
+func (h *policyHook) GetPolicy() HookPolicy {
+	return h.policy
+}
+
+func TestExecuteHooks_FailOpenAndClosed(t *testing.T) {
+	hm := NewHookManager()
+
+	var ran []string
+	record := func(name string, err error) Hook {
+		return NewBaseHook(name, len(ran), time.Second, func(ctx context.Context, data HookData) error {
+			ran = append(ran, name)
+			return err
+		})
+	}
+	require.NoError(t, hm.RegisterHook(HookPointBeforeBiz, record("audit", errors.New("audit down"))))
+	require.NoError(t, hm.RegisterHook(HookPointBeforeBiz, &policyHook{
+		Hook: NewBaseHook("authz", 10, time.Second, func(ctx context.Context, data HookData) error {
+			ran = append(ran, "authz")
+			return errors.New("denied")
+		}),
+		policy: HookPolicy{Failure: HookFailClosed},
+	}))
+	require.NoError(t, hm.RegisterHook(HookPointBeforeBiz, NewBaseHook("later", 20, time.Second, func(ctx context.Context, data HookData) error {
+		ran = append(ran, "later")
+		return nil
+	})))
+
+	err := hm.ExecuteHooks(context.Background(), HookPointBeforeBiz, NewHookData(context.Background(), nil))
+	abort, ok := AsHookAbort(err)
+	require.True(t, ok)
+	assert.Equal(t, ErrCodeHookFailed, abort.Reason)
+	assert.ErrorContains(t, err, "hook authz failed")
+	assert.ErrorContains(t, errors.Unwrap(abort), "denied")
+	assert.Equal(t, []string{"audit", "authz"}, ran)
+
+	// SetHookPolicy 优先于钩子自带的策略
+	ran = nil
+	hm.SetHookPolicy(HookPointBeforeBiz, "authz", HookPolicy{Failure: HookFailOpen})
+	err = hm.ExecuteHooks(context.Background(), HookPointBeforeBiz, NewHookData(context.Background(), nil))
+	_, aborted := AsHookAbort(err)
+	assert.False(t, aborted)
+	assert.ErrorContains(t, err, "denied")
+	assert.Equal(t, []string{"audit", "authz", "later"}, ran)
+}
+
+func TestExecuteHooks_Parallel(t *testing.T) {
+	hm := NewHookManagerWithConfig(HookManagerConfig{
+		Points: map[HookPoint]HookPointPolicy{HookPointAfterBiz: {Execution: HookExecutionParallel}},
+	})
+
+	var running, maxRunning atomic.Int32
+	slow := func(name string, priority int, value string) Hook {
+		return NewBaseHook(name, priority, time.Second, func(ctx context.Context, data HookData) error {
+			n := running.Add(1)
+			defer running.Add(-1)
+			for {
+				current := maxRunning.Load()
+				if n <= current || maxRunning.CompareAndSwap(current, n) {
+					break
+				}
+			}
+			// 并行执行时看不到其他钩子的修改
+			assert.Nil(t, data.GetData()["result"])
+			time.Sleep(50 * time.Millisecond)
+			data.SetData("result", value)
+			return nil
+		})
+	}
+	require.NoError(t, hm.RegisterHook(HookPointAfterBiz, slow("first", 1, "first")))
+	require.NoError(t, hm.RegisterHook(HookPointAfterBiz, slow("second", 2, "second")))
+	require.NoError(t, hm.RegisterHook(HookPointAfterBiz, slow("third", 3, "third")))
+
+	data := NewHookData(context.Background(), map[string]interface{}{})
+	require.NoError(t, hm.ExecuteHooks(context.Background(), HookPointAfterBiz, data))
+	assert.Equal(t, int32(3), maxRunning.Load())
+	// 修改按优先级合并，优先级最低的钩子最后写入
+	assert.Equal(t, "third", data.GetData()["result"])
+}
+
+func TestExecuteHooks_ParallelShortCircuit(t *testing.T) {
+	hm := NewHookManager()
+	hm.SetPointPolicy(HookPointBeforeBiz, HookPointPolicy{Execution: HookExecutionParallel})
+
+	require.NoError(t, hm.RegisterHook(HookPointBeforeBiz, NewBaseHook("cache", 1, time.Second, func(ctx context.Context, data HookData) error {
+		data.SetData("source", "cache")
+		return NewHookShortCircuit("cached")
+	})))
+	require.NoError(t, hm.RegisterHook(HookPointBeforeBiz, NewBaseHook("enrich", 2, time.Second, func(ctx context.Context, data HookData) error {
+		data.SetData("source", "enrich")
+		return nil
+	})))
+
+	data := NewHookData(context.Background(), map[string]interface{}{})
+	err := hm.ExecuteHooks(context.Background(), HookPointBeforeBiz, data)
+	sc, ok := AsHookShortCircuit(err)
+	require.True(t, ok)
+	assert.Equal(t, "cached", sc.Reply)
+	// 短路之后的钩子修改不合并
+	assert.Equal(t, "cache", data.GetData()["source"])
+}
+
+func TestExecuteHooks_CircuitBreaker(t *testing.T) {
+	hm := NewHookManager().(*hookManagerImpl)
+	now := time.Unix(0, 0)
+	hm.now = func() time.Time { return now }
+
+	var fail atomic.Bool
+	fail.Store(true)
+	var calls atomic.Int32
+	require.NoError(t, hm.RegisterHook(HookPointAfterAuth, NewBaseHook("geoip", 1, time.Second, func(ctx context.Context, data HookData) error {
+		calls.Add(1)
+		if fail.Load() {
+			return errors.New("lookup failed")
+		}
+		return nil
+	})))
+	hm.SetHookPolicy(HookPointAfterAuth, "geoip", HookPolicy{MaxErrorRate: 0.5, Window: 4, MinRequests: 4, Cooldown: time.Minute})
+
+	execute := func() error {
+		return hm.ExecuteHooks(context.Background(), HookPointAfterAuth, NewHookData(context.Background(), nil))
+	}
+	for i := 0; i < 4; i++ {
+		assert.Error(t, execute())
+	}
+	stats, err := hm.GetHookStats(HookPointAfterAuth, "geoip")
+	require.NoError(t, err)
+	assert.Equal(t, HookBreakerOpen, stats.State)
+	assert.Equal(t, int64(4), stats.Failures)
+	assert.Equal(t, "lookup failed", stats.LastError)
+
+	// 熔断期间 fail_open 钩子被跳过
+	assert.NoError(t, execute())
+	assert.Equal(t, int32(4), calls.Load())
+
+	// 冷却结束后试探执行，失败则继续熔断
+	now = now.Add(time.Minute)
+	assert.Error(t, execute())
+	assert.Equal(t, int32(5), calls.Load())
+	stats, _ = hm.GetHookStats(HookPointAfterAuth, "geoip")
+	assert.Equal(t, HookBreakerOpen, stats.State)
+
+	// 试探执行成功后恢复
+	fail.Store(false)
+	now = now.Add(time.Minute)
+	assert.NoError(t, execute())
+	stats, _ = hm.GetHookStats(HookPointAfterAuth, "geoip")
+	assert.Equal(t, HookBreakerClosed, stats.State)
+	assert.Equal(t, int64(1), stats.Rejected)
+}
+
+func TestExecuteHooks_LatencyBudget(t *testing.T) {
+	hm := NewHookManager()
+	hm.SetPointPolicy(HookPointBeforeAuth, HookPointPolicy{Failure: HookFailClosed})
+	require.NoError(t, hm.RegisterHook(HookPointBeforeAuth, NewBaseHook("slow_check", 1, time.Second, func(ctx context.Context, data HookData) error {
+		time.Sleep(20 * time.Millisecond)
+		return nil
+	})))
+	hm.SetHookPolicy(HookPointBeforeAuth, "slow_check", HookPolicy{
+		MaxErrorRate: 1,
+		MaxLatency:   5 * time.Millisecond,
+		Window:       2,
+		MinRequests:  2,
+		Cooldown:     time.Hour,
+	})
+
+	// 超过延迟预算不影响本次结果，但计入熔断统计
+	for i := 0; i < 2; i++ {
+		assert.NoError(t, hm.ExecuteHooks(context.Background(), HookPointBeforeAuth, NewHookData(context.Background(), nil)))
+	}
+	stats, err := hm.GetHookStats(HookPointBeforeAuth, "slow_check")
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), stats.SlowCalls)
+	assert.Equal(t, HookBreakerOpen, stats.State)
+
+	// fail_closed 钩子被熔断时中止请求
+	err = hm.ExecuteHooks(context.Background(), HookPointBeforeAuth, NewHookData(context.Background(), nil))
+	abort, ok := AsHookAbort(err)
+	require.True(t, ok)
+	assert.Equal(t, ErrCodeHookFailed, abort.Reason)
+
+	require.NoError(t, hm.ResetHook(HookPointBeforeAuth, "slow_check"))
+	stats, _ = hm.GetHookStats(HookPointBeforeAuth, "slow_check")
+	assert.Equal(t, HookBreakerClosed, stats.State)
+
+	_, err = hm.GetHookStats(HookPointBeforeAuth, "missing")
+	assert.Error(t, err)
+}
+
+func TestExecuteHooks_Tracing(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	hm := NewHookManagerWithConfig(HookManagerConfig{
+		TracerProvider: sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)),
+	})
+	require.NoError(t, hm.RegisterHook(HookPointBeforeData, NewBaseHook("ok", 1, time.Second, func(ctx context.Context, data HookData) error {
+		return nil
+	})))
+	require.NoError(t, hm.RegisterHook(HookPointBeforeData, NewBaseHook("broken", 2, time.Second, func(ctx context.Context, data HookData) error {
+		return errors.New("boom")
+	})))
+
+	_ = hm.ExecuteHooks(context.Background(), HookPointBeforeData, NewHookData(context.Background(), nil))
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 2)
+	outcomes := map[string]string{}
+	for _, span := range spans {
+		assert.Equal(t, "plugin.hook/before_data", span.Name())
+		var name, outcome string
+		for _, attr := range span.Attributes() {
+			switch attr.Key {
+			case "hook.name":
+				name = attr.Value.AsString()
+			case "hook.outcome":
+				outcome = attr.Value.AsString()
+			}
+		}
+		outcomes[name] = outcome
+	}
+	assert.Equal(t, map[string]string{"ok": hookOutcomeSuccess, "broken": hookOutcomeError}, outcomes)
+}
@@ -10,7 +10,7 @@ type Plugin interface {
 	Name() string
 	Version() string
 	Description() string
-	Dependencies() []string
+	Dependencies() []string // 必需依赖，可附带版本约束，如 "audit_logger>=1.2 <2"
 
 	// 生命周期方法
 	Initialize(ctx context.Context, config PluginConfig) error
@@ -34,6 +34,31 @@ type EventPlugin interface {
 	RegisterEventHandlers(bus EventBus) error
 }
 
+// ReconfigurablePlugin 支持运行时更新配置的插件接口
+// 未实现该接口的插件在配置变更后会被重新初始化并重启
+type ReconfigurablePlugin interface {
+	Plugin
+	Reconfigure(ctx context.Context, config PluginConfig) error
+}
+
+// CompatibilityPlugin 声明兼容性要求的插件接口
+type CompatibilityPlugin interface {
+	Plugin
+	// HostAPIVersion 支持的宿主 API 版本范围，如 ">=1.0 <2"，为空表示不限制
+	HostAPIVersion() string
+	// OptionalDependencies 可选依赖，格式同 Dependencies
+	// 依赖插件已加载时须满足版本约束并先于本插件启动，未加载时不影响本插件
+	OptionalDependencies() []string
+}
+
+// SchemaPlugin 声明配置 Settings JSON Schema 的插件接口
+// 插件管理器在加载插件与更新配置时按 Schema 校验配置并填充默认值
+type SchemaPlugin interface {
+	Plugin
+	// SettingsSchema 返回 JSON Schema 文档，为空表示不校验
+	SettingsSchema() []byte
+}
+
 // PluginManager 插件管理器接口
 type PluginManager interface {
 	// 插件生命周期管理
@@ -50,6 +75,12 @@ type PluginManager interface {
 	// 配置管理
 	UpdatePluginConfig(name string, config PluginConfig) error
 	GetPluginConfig(name string) (PluginConfig, error)
+
+	// 自动加载与热更新
+	LoadAll() error
+	ReloadPlugin(name string) error
+	StartWatching() error
+	StopWatching()
 }
 
 // PluginRegistry 插件注册表接口
@@ -62,6 +93,7 @@ type PluginRegistry interface {
 	ListByStatus(status PluginStatus) []Plugin
 	UpdateStatus(plugin Plugin, oldStatus, newStatus PluginStatus)
 	GetLoadOrder() ([]Plugin, error)
+	CheckCompatibility(plugin Plugin) error
 	GetDependents(pluginName string) []Plugin
 	GetDependencies(pluginName string) ([]Plugin, error)
 	FilterByMetadata(key, value string) []Plugin
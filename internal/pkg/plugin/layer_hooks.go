@@ -0,0 +1,59 @@
+package plugin
+
+import (
+	"context"
+	"time"
+)
+
+// LayerHookPoints 一层调用的钩子点：调用前、成功后与失败时
+type LayerHookPoints struct {
+	Layer  string
+	Before HookPoint
+	After  HookPoint
+	Error  HookPoint
+}
+
+var (
+	// BizHookPoints 业务逻辑层的钩子点
+	BizHookPoints = LayerHookPoints{Layer: "biz", Before: HookPointBeforeBiz, After: HookPointAfterBiz, Error: HookPointBizError}
+	// DataHookPoints 数据访问层的钩子点
+	DataHookPoints = LayerHookPoints{Layer: "data", Before: HookPointBeforeData, After: HookPointAfterData, Error: HookPointDataError}
+)
+
+// RunLayerHooks 在 fn 前后执行一层的钩子点，hookManager 为 nil 时直接执行 fn。
+//
+// 钩子数据只包含 layer、operation（如 auth.Login、user.GetUser），完成后加上 duration，失败时加上 error，
+// 不包含参数与返回值，避免把密码、令牌等交给进程外插件，可按 pluginv1.LayerHookPayload 解码。
+// before 钩子返回 HookAbortError 时不执行 fn 并返回该错误；其他钩子错误交给 onHookError，onHookError 为 nil 时忽略。
+func RunLayerHooks(ctx context.Context, hookManager HookManager, points LayerHookPoints, operation string, onHookError func(point HookPoint, err error), fn func(ctx context.Context) error) error {
+	if hookManager == nil {
+		return fn(ctx)
+	}
+	report := func(point HookPoint, err error) {
+		if err != nil && onHookError != nil {
+			onHookError(point, err)
+		}
+	}
+
+	data := NewHookData(ctx, map[string]interface{}{
+		"layer":     points.Layer,
+		"operation": operation,
+	})
+	if err := hookManager.ExecuteHooks(ctx, points.Before, data); err != nil {
+		if abort, ok := AsHookAbort(err); ok {
+			return abort
+		}
+		report(points.Before, err)
+	}
+
+	start := time.Now()
+	err := fn(ctx)
+	data.SetData("duration", durationPayload(time.Since(start)))
+	if err != nil {
+		data.SetData("error", errorPayload(err))
+		report(points.Error, hookManager.ExecuteHooks(ctx, points.Error, data))
+		return err
+	}
+	report(points.After, hookManager.ExecuteHooks(ctx, points.After, data))
+	return nil
+}
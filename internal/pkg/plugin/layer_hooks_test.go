@@ -0,0 +1,66 @@
+package plugin
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunLayerHooks(t *testing.T) {
+	hm := NewHookManager()
+	var fired []HookPoint
+	var last map[string]interface{}
+	for _, point := range []HookPoint{HookPointBeforeData, HookPointAfterData, HookPointDataError} {
+		point := point
+		require.NoError(t, hm.RegisterHook(point, NewBaseHook("record_"+string(point), 10, time.Second,
+			func(ctx context.Context, data HookData) error {
+				fired = append(fired, point)
+				last = data.GetData()
+				return nil
+			})))
+	}
+	ctx := context.Background()
+
+	require.NoError(t, RunLayerHooks(ctx, hm, DataHookPoints, "user.GetUser", nil, func(ctx context.Context) error { return nil }))
+	assert.Equal(t, []HookPoint{HookPointBeforeData, HookPointAfterData}, fired)
+	assert.Equal(t, "data", last["layer"])
+	assert.Equal(t, "user.GetUser", last["operation"])
+	assert.NotContains(t, last, "error")
+
+	fired = nil
+	err := RunLayerHooks(ctx, hm, DataHookPoints, "user.GetUser", nil, func(ctx context.Context) error { return errors.New("boom") })
+	assert.EqualError(t, err, "boom")
+	assert.Equal(t, []HookPoint{HookPointBeforeData, HookPointDataError}, fired)
+	assert.Equal(t, "boom", last["error"])
+
+	// before 钩子中止时不执行调用，其他钩子错误交给 onHookError
+	require.NoError(t, hm.RegisterHook(HookPointBeforeBiz, NewBaseHook("deny", 10, time.Second,
+		func(ctx context.Context, data HookData) error {
+			return NewHookAbortError(http.StatusForbidden, "DENIED", "denied")
+		})))
+	require.NoError(t, hm.RegisterHook(HookPointBizError, NewBaseHook("broken", 10, time.Second,
+		func(ctx context.Context, data HookData) error { return errors.New("hook failed") })))
+	called := false
+	err = RunLayerHooks(ctx, hm, BizHookPoints, "auth.Login", nil, func(ctx context.Context) error {
+		called = true
+		return nil
+	})
+	_, ok := AsHookAbort(err)
+	assert.True(t, ok)
+	assert.False(t, called)
+
+	require.NoError(t, hm.UnregisterHook(HookPointBeforeBiz, "deny"))
+	var failed []HookPoint
+	err = RunLayerHooks(ctx, hm, BizHookPoints, "auth.Login", func(point HookPoint, err error) { failed = append(failed, point) },
+		func(ctx context.Context) error { return errors.New("invalid password") })
+	assert.EqualError(t, err, "invalid password")
+	assert.Equal(t, []HookPoint{HookPointBizError}, failed)
+
+	// 没有钩子管理器时直接执行
+	require.NoError(t, RunLayerHooks(ctx, nil, BizHookPoints, "auth.Login", nil, func(ctx context.Context) error { return nil }))
+}
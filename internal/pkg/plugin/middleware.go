@@ -0,0 +1,134 @@
+package plugin
+
+import (
+	"context"
+	"time"
+
+	"kratos-boilerplate/internal/pkg/sensitive"
+
+	"github.com/go-kratos/kratos/v2/errors"
+	"github.com/go-kratos/kratos/v2/log"
+	"github.com/go-kratos/kratos/v2/middleware"
+	"github.com/go-kratos/kratos/v2/transport"
+)
+
+// hookHeaders 交给钩子的请求头，Authorization、Cookie 等凭证不交给插件
+var hookHeaders = []string{
+	"User-Agent",
+	"Content-Type",
+	"Accept",
+	"Accept-Language",
+	"X-Request-ID",
+	"X-Forwarded-For",
+	"X-Real-IP",
+	"Traceparent",
+}
+
+// hookSanitizer 交给钩子的请求脱敏器
+var hookSanitizer = sensitive.NewKeyValueSanitizer(nil)
+
+// hookPayload 脱敏后的请求或响应：proto 消息按字段敏感类型脱敏后转为 map，
+// 再按键名隐藏 password、token 等未声明敏感类型的凭证字段
+func hookPayload(key string, v interface{}) interface{} {
+	payload := hookSanitizer.SanitizeValue(key, v)
+	if m, ok := payload.(map[string]interface{}); ok {
+		return hookSanitizer.SanitizeValue(key, m)
+	}
+	return payload
+}
+
+// HookMiddleware 在请求处理流程中执行插件钩子
+//
+// 钩子数据中的 request、reply 为脱敏后的请求与响应，元数据只包含 hookHeaders 中的请求头，
+// 插件可能运行在进程外，凭证不随钩子数据传出。钩子数据可按 pluginv1.RequestHookPayload 解码。
+//
+// 执行顺序：before_request -> handler -> after_request -> before_response -> after_response。
+// 前三个钩子点中的钩子可以返回 HookAbortError 中止请求，或返回 HookShortCircuit 直接给出响应
+// （before_request 中短路时跳过业务处理），其他钩子错误只记录日志。
+// after_response 在响应确定后执行，错误、中止与短路均被忽略。
+func HookMiddleware(hookManager HookManager, logger log.Logger) middleware.Middleware {
+	helper := log.NewHelper(log.With(logger, "module", "plugin.middleware"))
+
+	return func(handler middleware.Handler) middleware.Handler {
+		return func(ctx context.Context, req interface{}) (interface{}, error) {
+			data := map[string]interface{}{
+				"request": hookPayload("request", req),
+			}
+			metadata := map[string]string{}
+			if tr, ok := transport.FromServerContext(ctx); ok {
+				data["operation"] = tr.Operation()
+				data["kind"] = tr.Kind().String()
+				data["endpoint"] = tr.Endpoint()
+				for _, key := range hookHeaders {
+					if value := tr.RequestHeader().Get(key); value != "" {
+						metadata[key] = value
+					}
+				}
+			}
+			hookData := &hookDataImpl{ctx: ctx, data: data, metadata: metadata}
+
+			var (
+				reply interface{}
+				err   error
+			)
+			start := time.Now()
+			sc, herr := runRequestHooks(ctx, hookManager, HookPointBeforeRequest, hookData, helper)
+			if herr != nil {
+				return nil, herr
+			}
+			if sc != nil {
+				reply = sc.Reply
+			} else {
+				reply, err = handler(ctx, req)
+			}
+
+			hookData.SetData("reply", hookPayload("reply", reply))
+			hookData.SetData("error", errorPayload(err))
+			hookData.SetData("duration", durationPayload(time.Since(start)))
+
+			for _, point := range []HookPoint{HookPointAfterRequest, HookPointBeforeResponse} {
+				sc, herr := runRequestHooks(ctx, hookManager, point, hookData, helper)
+				if herr != nil {
+					return nil, herr
+				}
+				if sc != nil {
+					reply, err = sc.Reply, nil
+					hookData.SetData("reply", hookPayload("reply", reply))
+					hookData.SetData("error", "")
+				}
+			}
+
+			if herr := hookManager.ExecuteHooks(ctx, HookPointAfterResponse, hookData); herr != nil {
+				helper.Warnf("after_response hooks failed: %v", herr)
+			}
+
+			return reply, err
+		}
+	}
+}
+
+// runRequestHooks 执行钩子点，钩子短路时返回短路结果，只有钩子中止时返回错误
+func runRequestHooks(ctx context.Context, hookManager HookManager, point HookPoint, data HookData, helper *log.Helper) (*HookShortCircuit, error) {
+	err := hookManager.ExecuteHooks(ctx, point, data)
+	if err == nil {
+		return nil, nil
+	}
+
+	if sc, ok := AsHookShortCircuit(err); ok {
+		return sc, nil
+	}
+	if abort, ok := AsHookAbort(err); ok {
+		if abort.cause != nil {
+			helper.Errorf("%s hooks failed closed: %v", point, abort.cause)
+		}
+		return nil, abort.KratosError()
+	}
+
+	helper.Warnf("%s hooks failed: %v", point, err)
+	return nil, nil
+}
+
+// KratosError 转换为 Kratos 错误，用于返回给客户端
+func (e *HookAbortError) KratosError() *errors.Error {
+	return errors.New(e.Status, e.Reason, e.Message)
+}
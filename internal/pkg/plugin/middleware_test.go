@@ -0,0 +1,247 @@
+package plugin
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	authv1 "kratos-boilerplate/api/auth/v1"
+
+	kerrors "github.com/go-kratos/kratos/v2/errors"
+	"github.com/go-kratos/kratos/v2/log"
+	"github.com/go-kratos/kratos/v2/transport"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHookMiddlewareRunsRequestHooks(t *testing.T) {
+	hm := NewHookManager()
+	var points []HookPoint
+	for _, point := range []HookPoint{HookPointBeforeRequest, HookPointAfterRequest, HookPointBeforeResponse, HookPointAfterResponse} {
+		point := point
+		require.NoError(t, hm.RegisterHook(point, NewBaseHook("record_"+string(point), 10, time.Second,
+			func(ctx context.Context, data HookData) error {
+				points = append(points, point)
+				return nil
+			})))
+	}
+
+	var seen interface{}
+	require.NoError(t, hm.RegisterHook(HookPointAfterRequest, NewBaseHook("inspect_reply", 20, time.Second,
+		func(ctx context.Context, data HookData) error {
+			seen = data.GetData()["reply"]
+			return errors.New("ignored")
+		})))
+
+	handler := HookMiddleware(hm, log.DefaultLogger)(func(ctx context.Context, req interface{}) (interface{}, error) {
+		return "pong", nil
+	})
+
+	reply, err := handler(context.Background(), "ping")
+	require.NoError(t, err)
+	assert.Equal(t, "pong", reply)
+	assert.Equal(t, "pong", seen)
+	assert.Equal(t, []HookPoint{HookPointBeforeRequest, HookPointAfterRequest, HookPointBeforeResponse, HookPointAfterResponse}, points)
+}
+
+// headerCarrier 测试用的请求头
+type headerCarrier http.Header
+
+func (h headerCarrier) Get(key string) string      { return http.Header(h).Get(key) }
+func (h headerCarrier) Set(key, value string)      { http.Header(h).Set(key, value) }
+func (h headerCarrier) Add(key, value string)      { http.Header(h).Add(key, value) }
+func (h headerCarrier) Values(key string) []string { return http.Header(h).Values(key) }
+func (h headerCarrier) Keys() []string {
+	keys := make([]string, 0, len(h))
+	for k := range h {
+		keys = append(keys, k)
+	}
+	return keys
+}
+
+// testTransport 测试用的服务端传输信息
+type testTransport struct {
+	header headerCarrier
+}
+
+func (t *testTransport) Kind() transport.Kind            { return transport.KindHTTP }
+func (t *testTransport) Endpoint() string                { return "http://127.0.0.1:8000" }
+func (t *testTransport) Operation() string               { return "/auth.v1.Auth/Login" }
+func (t *testTransport) RequestHeader() transport.Header { return t.header }
+func (t *testTransport) ReplyHeader() transport.Header   { return headerCarrier{} }
+
+// TestHookMiddlewareSanitizesHookData 测试钩子数据中的请求脱敏，元数据只包含允许的请求头
+func TestHookMiddlewareSanitizesHookData(t *testing.T) {
+	hm := NewHookManager()
+	var data map[string]interface{}
+	var metadata map[string]string
+	require.NoError(t, hm.RegisterHook(HookPointBeforeRequest, NewBaseHook("inspect", 10, time.Second,
+		func(ctx context.Context, d HookData) error {
+			data, metadata = d.GetData(), d.GetMetadata()
+			return nil
+		})))
+
+	header := headerCarrier{}
+	header.Set("Authorization", "Bearer secret-token")
+	header.Set("Cookie", "session=abc")
+	header.Set("User-Agent", "curl/8.0")
+	header.Set("X-Request-ID", "req-1")
+	ctx := transport.NewServerContext(context.Background(), &testTransport{header: header})
+
+	req := &authv1.LoginRequest{Username: "alice", Password: "MyPassword123!"}
+	handler := HookMiddleware(hm, log.DefaultLogger)(func(ctx context.Context, req interface{}) (interface{}, error) {
+		// 业务处理收到的请求不受影响
+		assert.Equal(t, "MyPassword123!", req.(*authv1.LoginRequest).Password)
+		return nil, nil
+	})
+	_, err := handler(ctx, req)
+	require.NoError(t, err)
+
+	assert.Equal(t, map[string]string{"User-Agent": "curl/8.0", "X-Request-ID": "req-1"}, metadata)
+	payload, ok := data["request"].(map[string]interface{})
+	require.True(t, ok)
+	assert.NotContains(t, payload["password"], "MyPassword123!")
+	assert.Equal(t, "/auth.v1.Auth/Login", data["operation"])
+
+	// 非 proto 请求按键名隐藏凭证
+	handler = HookMiddleware(hm, log.DefaultLogger)(func(ctx context.Context, req interface{}) (interface{}, error) {
+		return nil, nil
+	})
+	_, err = handler(ctx, map[string]interface{}{"api_token": "tk-123", "page": 1})
+	require.NoError(t, err)
+	payload, ok = data["request"].(map[string]interface{})
+	require.True(t, ok)
+	assert.NotEqual(t, "tk-123", payload["api_token"])
+	assert.Equal(t, 1, payload["page"])
+}
+
+func TestHookMiddlewareSanitizesReply(t *testing.T) {
+	hm := NewHookManager()
+	var replies []interface{}
+	for _, point := range []HookPoint{HookPointAfterRequest, HookPointBeforeResponse} {
+		require.NoError(t, hm.RegisterHook(point, NewBaseHook("inspect_"+string(point), 10, time.Second,
+			func(ctx context.Context, d HookData) error {
+				replies = append(replies, d.GetData()["reply"])
+				return nil
+			})))
+	}
+
+	handler := HookMiddleware(hm, log.DefaultLogger)(func(ctx context.Context, req interface{}) (interface{}, error) {
+		return &authv1.LoginReply{AccessToken: "eyJhbGciOiJIUzI1NiJ9.access", RefreshToken: "refresh-abc123", ExpiresIn: 3600}, nil
+	})
+	reply, err := handler(context.Background(), &authv1.LoginRequest{Username: "alice"})
+	require.NoError(t, err)
+	// 客户端收到的响应不受影响
+	assert.Equal(t, "eyJhbGciOiJIUzI1NiJ9.access", reply.(*authv1.LoginReply).AccessToken)
+
+	require.Len(t, replies, 2)
+	for _, r := range replies {
+		payload, ok := r.(map[string]interface{})
+		require.True(t, ok)
+		require.Contains(t, payload, "access_token")
+		assert.NotContains(t, payload["access_token"], "eyJhbGciOiJIUzI1NiJ9.access")
+		assert.NotContains(t, payload["refresh_token"], "refresh-abc123")
+	}
+}
+
+func TestHookMiddlewareAbort(t *testing.T) {
+	hm := NewHookManager()
+	require.NoError(t, hm.RegisterHook(HookPointBeforeRequest, NewBaseHook("rate_limit", 10, time.Second,
+		func(ctx context.Context, data HookData) error {
+			return NewHookAbortError(http.StatusTooManyRequests, "RATE_LIMITED", "too many requests")
+		})))
+
+	later := false
+	require.NoError(t, hm.RegisterHook(HookPointBeforeRequest, NewBaseHook("later", 20, time.Second,
+		func(ctx context.Context, data HookData) error {
+			later = true
+			return nil
+		})))
+
+	called := false
+	handler := HookMiddleware(hm, log.DefaultLogger)(func(ctx context.Context, req interface{}) (interface{}, error) {
+		called = true
+		return nil, nil
+	})
+
+	_, err := handler(context.Background(), nil)
+	require.Error(t, err)
+	assert.False(t, called)
+	assert.False(t, later)
+
+	kerr := kerrors.FromError(err)
+	assert.Equal(t, int32(http.StatusTooManyRequests), kerr.Code)
+	assert.Equal(t, "RATE_LIMITED", kerr.Reason)
+	assert.Equal(t, "too many requests", kerr.Message)
+}
+
+func TestExecuteHooksContinuesAfterError(t *testing.T) {
+	hm := NewHookManager()
+	require.NoError(t, hm.RegisterHook(HookPointBeforeAuth, NewBaseHook("failing", 10, time.Second,
+		func(ctx context.Context, data HookData) error {
+			return errors.New("boom")
+		})))
+	ran := false
+	require.NoError(t, hm.RegisterHook(HookPointBeforeAuth, NewBaseHook("next", 20, time.Second,
+		func(ctx context.Context, data HookData) error {
+			ran = true
+			return nil
+		})))
+
+	err := hm.ExecuteHooks(context.Background(), HookPointBeforeAuth, NewHookData(context.Background(), nil))
+	require.Error(t, err)
+	assert.True(t, ran)
+	_, aborted := AsHookAbort(err)
+	assert.False(t, aborted)
+}
+
+func TestHookMiddlewareShortCircuit(t *testing.T) {
+	hm := NewHookManager()
+	require.NoError(t, hm.RegisterHook(HookPointBeforeRequest, NewBaseHook("cache", 10, time.Second,
+		func(ctx context.Context, data HookData) error {
+			return NewHookShortCircuit("cached")
+		})))
+
+	var seen interface{}
+	require.NoError(t, hm.RegisterHook(HookPointAfterRequest, NewBaseHook("inspect_reply", 10, time.Second,
+		func(ctx context.Context, data HookData) error {
+			seen = data.GetData()["reply"]
+			return nil
+		})))
+
+	called := false
+	handler := HookMiddleware(hm, log.DefaultLogger)(func(ctx context.Context, req interface{}) (interface{}, error) {
+		called = true
+		return "pong", nil
+	})
+
+	reply, err := handler(context.Background(), "ping")
+	require.NoError(t, err)
+	assert.False(t, called)
+	assert.Equal(t, "cached", reply)
+	assert.Equal(t, "cached", seen)
+}
+
+func TestHookMiddlewareFailClosed(t *testing.T) {
+	hm := NewHookManager()
+	hm.SetPointPolicy(HookPointBeforeRequest, HookPointPolicy{Failure: HookFailClosed})
+	require.NoError(t, hm.RegisterHook(HookPointBeforeRequest, NewBaseHook("authz", 10, time.Second,
+		func(ctx context.Context, data HookData) error {
+			return errors.New("policy engine unavailable")
+		})))
+
+	called := false
+	handler := HookMiddleware(hm, log.DefaultLogger)(func(ctx context.Context, req interface{}) (interface{}, error) {
+		called = true
+		return nil, nil
+	})
+
+	_, err := handler(context.Background(), nil)
+	require.Error(t, err)
+	assert.False(t, called)
+	kerr := kerrors.FromError(err)
+	assert.Equal(t, int32(http.StatusServiceUnavailable), kerr.Code)
+	assert.Equal(t, ErrCodeHookFailed, kerr.Reason)
+}
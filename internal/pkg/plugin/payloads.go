@@ -0,0 +1,52 @@
+package plugin
+
+import (
+	"fmt"
+	"time"
+
+	pluginv1 "kratos-boilerplate/api/plugin/v1"
+)
+
+// 内置事件类型与钩子点的载荷类型，消息定义见 api/plugin/v1/payload.proto
+func init() {
+	mustRegister(RegisterEventPayload[*pluginv1.UserLoginEvent](EventUserLogin))
+	mustRegister(RegisterEventPayload[*pluginv1.UserLogoutEvent](EventUserLogout))
+	mustRegister(RegisterEventPayload[*pluginv1.UserRegisterEvent](EventUserRegister))
+
+	for _, point := range []HookPoint{HookPointBeforeRequest, HookPointAfterRequest, HookPointBeforeResponse, HookPointAfterResponse} {
+		mustRegister(RegisterHookPayload[*pluginv1.RequestHookPayload](point))
+	}
+
+	mustRegister(RegisterHookPayload[*pluginv1.BeforeAuthPayload](HookPointBeforeAuth))
+	mustRegister(RegisterHookPayload[*pluginv1.AfterAuthPayload](HookPointAfterAuth))
+	mustRegister(RegisterHookPayload[*pluginv1.AuthFailedPayload](HookPointAuthFailed))
+
+	for _, points := range []LayerHookPoints{BizHookPoints, DataHookPoints} {
+		for _, point := range []HookPoint{points.Before, points.After, points.Error} {
+			mustRegister(RegisterHookPayload[*pluginv1.LayerHookPayload](point))
+		}
+	}
+}
+
+func mustRegister(err error) {
+	if err != nil {
+		panic(err)
+	}
+}
+
+// durationPayload 将耗时编码为 google.protobuf.Duration 的 JSON 形式，如 "0.012000000s"
+func durationPayload(d time.Duration) string {
+	sign := ""
+	if d < 0 {
+		sign, d = "-", -d
+	}
+	return fmt.Sprintf("%s%d.%09ds", sign, d/time.Second, d%time.Second)
+}
+
+// errorPayload 钩子数据中的错误信息，没有错误时为空字符串
+func errorPayload(err error) string {
+	if err == nil {
+		return ""
+	}
+	return err.Error()
+}
@@ -2,15 +2,28 @@ package plugin
 
 import (
 	"context"
-	"fmt"
+	"errors"
 	"os"
 	"path/filepath"
 	"sync"
 	"time"
 
+	"github.com/fsnotify/fsnotify"
+	"github.com/go-kratos/kratos/v2/log"
 	"gopkg.in/yaml.v2"
 )
 
+const (
+	// defaultHealthInterval 插件进程健康检查间隔
+	defaultHealthInterval = 10 * time.Second
+	// defaultRestartBackoff 插件进程崩溃后首次重启的等待时间
+	defaultRestartBackoff = time.Second
+	// maxRestartBackoff 插件进程重启的最大等待时间
+	maxRestartBackoff = 30 * time.Second
+	// maxHealthFailures 连续健康检查失败多少次后重启插件进程
+	maxHealthFailures = 3
+)
+
 // pluginManagerImpl 插件管理器实现
 type pluginManagerImpl struct {
 	mu              sync.RWMutex
@@ -21,80 +34,139 @@ type pluginManagerImpl struct {
 	configDir       string
 	pluginDir       string
 	autoLoadEnabled bool
+	sandbox         SandboxConfig
+	trustStore      *TrustStore
+	logger          log.Logger
+
+	// 通过签名验证的制品副本所在的暂存目录，首次验证时创建。
+	// 崩溃重启在 pm.mu 之外验证制品，stageDir 由 stageMu 保护
+	stageMu  sync.Mutex
+	stageDir string
+
+	// 未通过签名验证的插件，按插件文件名索引
+	rejected map[string]PluginInfo
+
+	healthInterval time.Duration
+	restartBackoff time.Duration
+
+	// 目录监听
+	watchMu        sync.Mutex
+	watcher        *fsnotify.Watcher
+	watchDone      chan struct{}
+	pendingReloads map[string]*time.Timer
+	reloadDebounce time.Duration
 }
 
 // pluginWrapper 插件包装器
 type pluginWrapper struct {
-	plugin      Plugin
-	info        PluginInfo
-	config      PluginConfig
-	hooks       []Hook
+	plugin        Plugin
+	info          PluginInfo
+	config        PluginConfig
+	hooks         []Hook
 	eventHandlers []EventHandler
+
+	// 插件声明的配置 Schema，未声明时为 nil
+	schema *SettingsSchema
+
+	// 进程外插件与 WASM 插件
+	wasm      *wasmPlugin
+	process   *pluginProcess
+	remote    *rpcPlugin
+	restarts  int
+	unhealthy bool
+
+	// 插件文件摘要，用于识别文件内容变更
+	checksum string
+	// execPath 实际执行的插件文件，配置了信任库时为通过验证的暂存副本
+	execPath string
+}
+
+// ManagerConfig 插件管理器配置，对应配置文件中的 plugins 节点
+type ManagerConfig struct {
+	ConfigDir string
+	PluginDir string
+	AutoLoad  bool
+	Sandbox   SandboxConfig
+	// TrustStore 插件发布者信任库，为 nil 时不验证插件签名
+	TrustStore *TrustStore
 }
 
 // NewPluginManager 创建新的插件管理器
 func NewPluginManager(registry PluginRegistry, hookManager HookManager, eventBus EventBus, configDir, pluginDir string) PluginManager {
+	return NewPluginManagerWithConfig(registry, hookManager, eventBus, ManagerConfig{
+		ConfigDir: configDir,
+		PluginDir: pluginDir,
+		AutoLoad:  true,
+		Sandbox:   DefaultSandboxConfig(),
+	})
+}
+
+// NewPluginManagerWithConfig 根据配置创建插件管理器
+func NewPluginManagerWithConfig(registry PluginRegistry, hookManager HookManager, eventBus EventBus, config ManagerConfig) PluginManager {
 	return &pluginManagerImpl{
 		plugins:         make(map[string]*pluginWrapper),
 		registry:        registry,
 		hookManager:     hookManager,
 		eventBus:        eventBus,
-		configDir:       configDir,
-		pluginDir:       pluginDir,
-		autoLoadEnabled: true,
+		configDir:       config.ConfigDir,
+		pluginDir:       config.PluginDir,
+		autoLoadEnabled: config.AutoLoad,
+		sandbox:         config.Sandbox,
+		trustStore:      config.TrustStore,
+		logger:          log.With(log.GetLogger(), "module", "plugin"),
+		healthInterval:  defaultHealthInterval,
+		restartBackoff:  defaultRestartBackoff,
+		reloadDebounce:  defaultReloadDebounce,
+		rejected:        make(map[string]PluginInfo),
 	}
 }
 
 // LoadPlugin 加载插件
+// .wasm 文件在 wazero 沙箱中运行；其他文件作为插件二进制以子进程方式运行，
+// 宿主完成版本握手后通过 Unix Socket 上的 gRPC 连接代理所有调用。
+// 配置了信任库时，插件须先通过签名验证，失败的插件以 PluginStatusError 状态列出
 func (pm *pluginManagerImpl) LoadPlugin(path string) error {
 	pm.mu.Lock()
 	defer pm.mu.Unlock()
 
+	fileName := pluginNameFromPath(path)
+
 	// 检查插件文件是否存在
 	if _, err := os.Stat(path); os.IsNotExist(err) {
-		return NewPluginError(ErrCodePluginNotFound, "plugin file not found", filepath.Base(path), err)
+		return NewPluginError(ErrCodePluginNotFound, "plugin file not found", fileName, err)
 	}
 
 	// 加载插件配置
-	config, err := pm.loadPluginConfig(filepath.Base(path))
+	config, err := pm.loadPluginConfig(fileName)
 	if err != nil {
 		return err
 	}
 
 	if !config.Enabled {
-		return NewPluginError(ErrCodePluginConfigError, "plugin is disabled", filepath.Base(path), nil)
+		return NewPluginError(ErrCodePluginConfigError, "plugin is disabled", fileName, nil)
 	}
 
-	// 这里应该是实际的插件加载逻辑
-	// 由于这是示例，我们创建一个模拟插件
-	pluginName := filepath.Base(path)
-	plugin := &mockPlugin{
-		name: pluginName,
+	manifest, execPath, err := pm.verifyArtifact(path, fileName)
+	if err != nil {
+		pm.rejectPlugin(path, fileName, err)
+		return err
 	}
 
-	wrapper := &pluginWrapper{
-		plugin: plugin,
-		info: PluginInfo{
-			Metadata: PluginMetadata{
-				Name:        pluginName,
-				Version:     "1.0.0",
-				Description: "Mock plugin for demonstration",
-				Author:      "System",
-			},
-			Status:   PluginStatusLoaded,
-			LoadTime: time.Now(),
-			Path:     path,
-		},
-		config: config,
+	var wrapper *pluginWrapper
+	if filepath.Ext(path) == ".wasm" {
+		wrapper, err = pm.loadWasmPlugin(path, execPath, fileName, config, manifest)
+	} else {
+		wrapper, err = pm.loadProcessPlugin(path, execPath, fileName, config, manifest)
 	}
-
-	pm.plugins[pluginName] = wrapper
-
-	// 注册到注册表
-	if err := pm.registry.Register(plugin); err != nil {
-		delete(pm.plugins, pluginName)
+	if err != nil {
+		var pe *PluginError
+		if errors.As(err, &pe) && pe.Code == ErrCodePluginPermission {
+			pm.rejectPlugin(path, fileName, err)
+		}
 		return err
 	}
+	delete(pm.rejected, fileName)
+	pluginName := wrapper.info.Metadata.Name
 
 	// 发布插件加载事件
 	pm.eventBus.PublishAsync(context.Background(), NewEvent(
@@ -109,6 +181,106 @@ func (pm *pluginManagerImpl) LoadPlugin(path string) error {
 	return nil
 }
 
+// proxyPlugin 在宿主侧代理注册钩子与事件处理器的插件
+type proxyPlugin interface {
+	unregisterProxies(manager HookManager, bus EventBus)
+	// syncProxies 升级后按新版本提供的钩子与事件处理器增删代理
+	syncProxies(manager HookManager, bus EventBus) error
+}
+
+// loadProcessPlugin 启动 execPath 处的进程外插件并以 path 注册
+// 调用方需持有 pm.mu
+func (pm *pluginManagerImpl) loadProcessPlugin(path, execPath, fileName string, config PluginConfig, manifest *PluginManifest) (*pluginWrapper, error) {
+	proc, remote, err := pm.launchPlugin(execPath, config)
+	if err != nil {
+		pm.removeStaged(execPath)
+		return nil, NewPluginError(ErrCodePluginLoadFailed, "failed to launch plugin", fileName, err)
+	}
+
+	wrapper, err := pm.registerWrapper(remote, path, fileName, config, manifest)
+	if err != nil {
+		proc.shutdown(defaultShutdownGrace)
+		pm.removeStaged(execPath)
+		return nil, err
+	}
+	wrapper.process = proc
+	wrapper.remote = remote
+	wrapper.execPath = execPath
+
+	go pm.superviseProcess(wrapper, proc)
+	return wrapper, nil
+}
+
+// loadWasmPlugin 在沙箱中编译 execPath 处的 WASM 插件并以 path 注册
+// 调用方需持有 pm.mu
+func (pm *pluginManagerImpl) loadWasmPlugin(path, execPath, fileName string, config PluginConfig, manifest *PluginManifest) (*pluginWrapper, error) {
+	wasm, err := newWasmPlugin(context.Background(), execPath, pm.sandbox, pm.logger)
+	// 模块编译完成后不再读取文件
+	pm.removeStaged(execPath)
+	if err != nil {
+		return nil, NewPluginError(ErrCodePluginLoadFailed, "failed to load wasm plugin", fileName, err)
+	}
+
+	wrapper, err := pm.registerWrapper(wasm, path, fileName, config, manifest)
+	if err != nil {
+		_ = wasm.close(context.Background())
+		return nil, err
+	}
+	wrapper.wasm = wasm
+	return wrapper, nil
+}
+
+// registerWrapper 创建插件包装器并注册到注册表
+// 调用方需持有 pm.mu
+func (pm *pluginManagerImpl) registerWrapper(plugin Plugin, path, fileName string, config PluginConfig, manifest *PluginManifest) (*pluginWrapper, error) {
+	pluginName := plugin.Name()
+	if _, exists := pm.plugins[pluginName]; exists {
+		return nil, NewPluginError(ErrCodePluginAlreadyExist, "plugin already loaded", pluginName, nil)
+	}
+	if err := manifest.match(plugin); err != nil {
+		return nil, NewPluginError(ErrCodePluginPermission, "plugin does not match its signed manifest", fileName, err)
+	}
+
+	// 按插件声明的 Schema 校验配置并填充默认值
+	schema, err := pluginSettingsSchema(plugin, manifest)
+	if err != nil {
+		return nil, NewPluginError(ErrCodePluginConfigError, "invalid settings schema", pluginName, err)
+	}
+	config, err = applySettingsSchema(schema, pluginName, config)
+	if err != nil {
+		return nil, err
+	}
+
+	wrapper := &pluginWrapper{
+		plugin: plugin,
+		info: PluginInfo{
+			Metadata: PluginMetadata{
+				Name:         pluginName,
+				Version:      plugin.Version(),
+				Description:  plugin.Description(),
+				Dependencies: plugin.Dependencies(),
+			},
+			Status:     PluginStatusLoaded,
+			LoadTime:   time.Now(),
+			Path:       path,
+			ConfigPath: filepath.Join(pm.configDir, fileName+".yaml"),
+		},
+		config: config,
+		schema: schema,
+	}
+	fillCompatibility(&wrapper.info.Metadata, plugin)
+	fillManifest(&wrapper.info.Metadata, manifest)
+	wrapper.checksum, _ = fileChecksum(path)
+
+	// 注册到注册表
+	if err := pm.registry.Register(plugin); err != nil {
+		return nil, err
+	}
+	pm.plugins[pluginName] = wrapper
+
+	return wrapper, nil
+}
+
 // UnloadPlugin 卸载插件
 func (pm *pluginManagerImpl) UnloadPlugin(name string) error {
 	pm.mu.Lock()
@@ -116,6 +288,10 @@ func (pm *pluginManagerImpl) UnloadPlugin(name string) error {
 
 	wrapper, exists := pm.plugins[name]
 	if !exists {
+		if _, rejected := pm.rejected[name]; rejected {
+			delete(pm.rejected, name)
+			return nil
+		}
 		return NewPluginError(ErrCodePluginNotFound, "plugin not found", name, nil)
 	}
 
@@ -146,6 +322,10 @@ func (pm *pluginManagerImpl) UnloadPlugin(name string) error {
 		}
 	}
 
+	if proxy, ok := wrapper.plugin.(proxyPlugin); ok {
+		proxy.unregisterProxies(pm.hookManager, pm.eventBus)
+	}
+
 	// 从注册表注销
 	if err := pm.registry.Unregister(name); err != nil {
 		return err
@@ -153,6 +333,20 @@ func (pm *pluginManagerImpl) UnloadPlugin(name string) error {
 
 	delete(pm.plugins, name)
 
+	// 结束插件进程或释放 WASM 运行时
+	if wrapper.process != nil || wrapper.wasm != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), defaultShutdownGrace)
+		_ = wrapper.plugin.Cleanup(ctx)
+		if wrapper.wasm != nil {
+			_ = wrapper.wasm.close(ctx)
+		}
+		cancel()
+	}
+	if wrapper.process != nil {
+		wrapper.process.shutdown(defaultShutdownGrace)
+	}
+	pm.removeStaged(wrapper.execPath)
+
 	// 发布插件卸载事件
 	pm.eventBus.PublishAsync(context.Background(), NewEvent(
 		EventPluginUnloaded,
@@ -172,6 +366,9 @@ func (pm *pluginManagerImpl) StartPlugin(name string) error {
 
 	wrapper, exists := pm.plugins[name]
 	if !exists {
+		if info, rejected := pm.rejected[name]; rejected {
+			return NewPluginError(ErrCodePluginPermission, "plugin failed signature verification: "+info.ErrorMsg, name, nil)
+		}
 		return NewPluginError(ErrCodePluginNotFound, "plugin not found", name, nil)
 	}
 
@@ -179,6 +376,13 @@ func (pm *pluginManagerImpl) StartPlugin(name string) error {
 		return NewPluginError(ErrCodePluginAlreadyExist, "plugin already started", name, nil)
 	}
 
+	return pm.startPluginInternal(wrapper)
+}
+
+// startPluginInternal 内部启动插件实现
+func (pm *pluginManagerImpl) startPluginInternal(wrapper *pluginWrapper) error {
+	name := wrapper.info.Metadata.Name
+
 	// 初始化插件
 	if err := wrapper.plugin.Initialize(context.Background(), wrapper.config); err != nil {
 		wrapper.info.Status = PluginStatusError
@@ -197,7 +401,7 @@ func (pm *pluginManagerImpl) StartPlugin(name string) error {
 
 	// 注册事件处理器
 	if eventPlugin, ok := wrapper.plugin.(EventPlugin); ok {
-		if err := eventPlugin.RegisterEventHandlers(pm.eventBus); err != nil {
+		if err := eventPlugin.RegisterEventHandlers(pm.pluginBus(wrapper)); err != nil {
 			wrapper.info.Status = PluginStatusError
 			wrapper.info.ErrorMsg = err.Error()
 			return NewPluginError(ErrCodePluginStartFailed, "event handler registration failed", name, err)
@@ -212,6 +416,7 @@ func (pm *pluginManagerImpl) StartPlugin(name string) error {
 	}
 
 	wrapper.info.Status = PluginStatusStarted
+	wrapper.info.ErrorMsg = ""
 	now := time.Now()
 	wrapper.info.StartTime = &now
 
@@ -245,7 +450,12 @@ func (pm *pluginManagerImpl) StopPlugin(name string) error {
 }
 
 // stopPluginInternal 内部停止插件实现
+// 先注销宿主侧代理的钩子与事件处理器，停止后的插件不再接收调用，再次启动时重新注册
 func (pm *pluginManagerImpl) stopPluginInternal(wrapper *pluginWrapper) error {
+	if proxy, ok := wrapper.plugin.(proxyPlugin); ok {
+		proxy.unregisterProxies(pm.hookManager, pm.eventBus)
+	}
+
 	if err := wrapper.plugin.Stop(context.Background()); err != nil {
 		wrapper.info.Status = PluginStatusError
 		wrapper.info.ErrorMsg = err.Error()
@@ -286,10 +496,13 @@ func (pm *pluginManagerImpl) ListPlugins() []PluginInfo {
 	pm.mu.RLock()
 	defer pm.mu.RUnlock()
 
-	result := make([]PluginInfo, 0, len(pm.plugins))
+	result := make([]PluginInfo, 0, len(pm.plugins)+len(pm.rejected))
 	for _, wrapper := range pm.plugins {
 		result = append(result, wrapper.info)
 	}
+	for _, info := range pm.rejected {
+		result = append(result, info)
+	}
 
 	return result
 }
@@ -301,40 +514,23 @@ func (pm *pluginManagerImpl) GetPluginStatus(name string) (PluginStatus, error)
 
 	wrapper, exists := pm.plugins[name]
 	if !exists {
+		if info, rejected := pm.rejected[name]; rejected {
+			return info.Status, nil
+		}
 		return "", NewPluginError(ErrCodePluginNotFound, "plugin not found", name, nil)
 	}
 
 	return wrapper.info.Status, nil
 }
 
-// UpdatePluginConfig 更新插件配置
+// UpdatePluginConfig 更新插件配置并写回配置文件，运行中的插件会立即应用新配置
 func (pm *pluginManagerImpl) UpdatePluginConfig(name string, config PluginConfig) error {
-	pm.mu.Lock()
-	defer pm.mu.Unlock()
-
-	wrapper, exists := pm.plugins[name]
-	if !exists {
-		return NewPluginError(ErrCodePluginNotFound, "plugin not found", name, nil)
-	}
-
-	// 保存配置到文件
-	if err := pm.savePluginConfig(name, config); err != nil {
-		return err
-	}
-
-	wrapper.config = config
-
-	// 发布配置变更事件
-	pm.eventBus.PublishAsync(context.Background(), NewEvent(
-		EventConfigChanged,
-		"plugin_manager",
-		map[string]interface{}{
-			"plugin": name,
-			"config": config,
-		},
-	))
+	return pm.applyPluginConfig(name, config, true)
+}
 
-	return nil
+// pluginBus 插件注册事件处理器时使用的事件总线，按插件配置的投递方式与重试次数订阅
+func (pm *pluginManagerImpl) pluginBus(wrapper *pluginWrapper) EventBus {
+	return &pluginEventBus{EventBus: pm.eventBus, options: PluginDeliveryOptions(wrapper.config)}
 }
 
 // GetPluginConfig 获取插件配置
@@ -396,47 +592,93 @@ func (pm *pluginManagerImpl) savePluginConfig(pluginName string, config PluginCo
 	return nil
 }
 
-// mockPlugin 模拟插件实现
-type mockPlugin struct {
-	name string
+// verifyArtifact 使用信任库验证插件制品，返回清单与应执行的文件路径。
+// 验证的内容写入暂存目录后执行暂存副本，验证之后替换 path 不影响执行的内容；
+// 未配置信任库时跳过验证并返回 path
+func (pm *pluginManagerImpl) verifyArtifact(path, fileName string) (*PluginManifest, string, error) {
+	if pm.trustStore == nil {
+		return nil, path, nil
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, "", NewPluginError(ErrCodePluginPermission, "plugin signature verification failed", fileName, err)
+	}
+	manifest, err := pm.trustStore.VerifyContent(path, content)
+	if err != nil {
+		return nil, "", NewPluginError(ErrCodePluginPermission, "plugin signature verification failed", fileName, err)
+	}
+	execPath, err := pm.stageArtifact(fileName, filepath.Ext(path), content)
+	if err != nil {
+		return nil, "", NewPluginError(ErrCodePluginLoadFailed, "failed to stage verified plugin", fileName, err)
+	}
+	return manifest, execPath, nil
 }
 
-func (m *mockPlugin) Name() string {
-	return m.name
-}
+// stageArtifact 将通过验证的制品内容写入仅宿主可访问的暂存目录，返回副本路径
+func (pm *pluginManagerImpl) stageArtifact(fileName, ext string, content []byte) (string, error) {
+	pm.stageMu.Lock()
+	defer pm.stageMu.Unlock()
 
-func (m *mockPlugin) Version() string {
-	return "1.0.0"
-}
+	if pm.stageDir == "" {
+		dir, err := os.MkdirTemp("", "kratos-plugins-")
+		if err != nil {
+			return "", err
+		}
+		pm.stageDir = dir
+	}
 
-func (m *mockPlugin) Description() string {
-	return "Mock plugin for demonstration"
+	f, err := os.CreateTemp(pm.stageDir, fileName+"-*"+ext)
+	if err != nil {
+		return "", err
+	}
+	_, err = f.Write(content)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Chmod(f.Name(), 0500)
+	}
+	if err != nil {
+		_ = os.Remove(f.Name())
+		return "", err
+	}
+	return f.Name(), nil
 }
 
-func (m *mockPlugin) Dependencies() []string {
-	return nil
-}
+// removeStaged 删除暂存目录中的制品副本，path 不在暂存目录中时不处理
+func (pm *pluginManagerImpl) removeStaged(path string) {
+	pm.stageMu.Lock()
+	defer pm.stageMu.Unlock()
 
-func (m *mockPlugin) Initialize(ctx context.Context, config PluginConfig) error {
-	fmt.Printf("Plugin %s initialized with config: %+v\n", m.name, config)
-	return nil
+	if pm.stageDir == "" || path == "" || filepath.Dir(path) != pm.stageDir {
+		return
+	}
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		log.NewHelper(pm.logger).Warnf("failed to remove staged plugin %s: %v", path, err)
+	}
 }
 
-func (m *mockPlugin) Start(ctx context.Context) error {
-	fmt.Printf("Plugin %s started\n", m.name)
-	return nil
-}
+// rejectPlugin 记录未通过签名验证的插件
+// 调用方需持有 pm.mu
+func (pm *pluginManagerImpl) rejectPlugin(path, fileName string, err error) {
+	log.NewHelper(log.With(pm.logger, "plugin", fileName)).Errorf("rejected plugin %s: %v", path, err)
 
-func (m *mockPlugin) Stop(ctx context.Context) error {
-	fmt.Printf("Plugin %s stopped\n", m.name)
-	return nil
-}
+	pm.rejected[fileName] = PluginInfo{
+		Metadata:   PluginMetadata{Name: fileName},
+		Status:     PluginStatusError,
+		LoadTime:   time.Now(),
+		Path:       path,
+		ConfigPath: filepath.Join(pm.configDir, fileName+".yaml"),
+		ErrorMsg:   err.Error(),
+	}
 
-func (m *mockPlugin) Cleanup(ctx context.Context) error {
-	fmt.Printf("Plugin %s cleaned up\n", m.name)
-	return nil
+	pm.eventBus.PublishAsync(context.Background(), NewEvent(
+		EventPluginError,
+		"plugin_manager",
+		map[string]interface{}{
+			"plugin": fileName,
+			"path":   path,
+			"error":  err.Error(),
+		},
+	))
 }
-
-func (m *mockPlugin) HealthCheck(ctx context.Context) error {
-	return nil
-}
\ No newline at end of file
@@ -2,6 +2,7 @@ package plugin
 
 import (
 	"sort"
+	"strings"
 	"sync"
 )
 
@@ -30,8 +31,8 @@ func (pr *pluginRegistryImpl) Register(plugin Plugin) error {
 		return NewPluginError(ErrCodePluginAlreadyExist, "plugin already registered", name, nil)
 	}
 
-	// 检查依赖关系
-	if err := pr.checkDependencies(plugin); err != nil {
+	// 检查宿主 API 版本与依赖关系
+	if err := pr.checkCompatibility(plugin); err != nil {
 		return err
 	}
 
@@ -133,30 +134,50 @@ func (pr *pluginRegistryImpl) UpdateStatus(plugin Plugin, oldStatus, newStatus P
 	pr.byStatus[newStatus] = append(pr.byStatus[newStatus], plugin)
 }
 
-// GetLoadOrder 获取插件加载顺序
-func (pr *pluginRegistryImpl) GetLoadOrder() ([]Plugin, error) {
+// CheckCompatibility 检查插件能否加入注册表，已注册的同名插件视为将被替换
+func (pr *pluginRegistryImpl) CheckCompatibility(plugin Plugin) error {
 	pr.mu.RLock()
 	defer pr.mu.RUnlock()
 
-	// 构建依赖图（反向关系：graph[node] = 依赖node的插件列表）
-	dependencyGraph := make(map[string][]string)
-	plugins := make([]Plugin, 0, len(pr.plugins))
+	return pr.checkCompatibility(plugin)
+}
 
-	// 初始化图
-	for name := range pr.plugins {
-		dependencyGraph[name] = []string{}
+// checkCompatibility 检查宿主 API 版本、依赖是否存在及版本约束，返回列出全部冲突的错误
+// 调用方需持有 pr.mu
+func (pr *pluginRegistryImpl) checkCompatibility(plugin Plugin) error {
+	installed := make(map[string]Plugin, len(pr.plugins))
+	for name, p := range pr.plugins {
+		if name != plugin.Name() {
+			installed[name] = p
+		}
+	}
+
+	conflicts := checkCompatibility(plugin, installed)
+	if len(conflicts) == 0 {
+		return nil
 	}
+	return NewPluginError(ErrCodePluginDependency, "incompatible plugin dependencies", plugin.Name(),
+		&DependencyError{Conflicts: conflicts})
+}
 
-	// 构建依赖关系
+// GetLoadOrder 获取插件加载顺序
+// 被依赖的插件（包括已注册的可选依赖）排在依赖它的插件之前，无依赖关系的插件按名称排序
+func (pr *pluginRegistryImpl) GetLoadOrder() ([]Plugin, error) {
+	pr.mu.RLock()
+	defer pr.mu.RUnlock()
+
+	// 依赖图：graph[node] 为 node 依赖的插件
+	graph := make(map[string][]string, len(pr.plugins))
 	for name, plugin := range pr.plugins {
-		plugins = append(plugins, plugin)
-		for _, dep := range plugin.Dependencies() {
-			dependencyGraph[dep] = append(dependencyGraph[dep], name)
+		graph[name] = []string{}
+		for _, dep := range dependencyNames(plugin, true) {
+			if _, exists := pr.plugins[dep]; exists {
+				graph[name] = append(graph[name], dep)
+			}
 		}
 	}
 
-	// 拓扑排序
-	sorted, err := pr.topologicalSort(dependencyGraph)
+	sorted, err := pr.topologicalSort(graph)
 	if err != nil {
 		return nil, err
 	}
@@ -170,87 +191,80 @@ func (pr *pluginRegistryImpl) GetLoadOrder() ([]Plugin, error) {
 	return result, nil
 }
 
-// checkDependencies 检查插件依赖关系
-func (pr *pluginRegistryImpl) checkDependencies(plugin Plugin) error {
-	dependencies := plugin.Dependencies()
-	if len(dependencies) == 0 {
-		return nil
-	}
-
-	for _, dep := range dependencies {
-		if _, exists := pr.plugins[dep]; !exists {
-			return NewPluginError(ErrCodePluginDependency, "dependency not found: "+dep, plugin.Name(), nil)
-		}
-	}
-
-	return nil
-}
-
-// checkDependents 检查是否有其他插件依赖此插件
+// checkDependents 检查是否有其他插件必需此插件，可选依赖不阻止卸载
 func (pr *pluginRegistryImpl) checkDependents(pluginName string) error {
-	for _, p := range pr.plugins {
-		for _, dep := range p.Dependencies() {
+	var dependents []string
+	for name, p := range pr.plugins {
+		for _, dep := range dependencyNames(p, false) {
 			if dep == pluginName {
-				return NewPluginError(ErrCodePluginDependency, "other plugins depend on this plugin", pluginName, nil)
+				dependents = append(dependents, name)
+				break
 			}
 		}
 	}
+	if len(dependents) > 0 {
+		sort.Strings(dependents)
+		return NewPluginError(ErrCodePluginDependency, "other plugins depend on this plugin: "+strings.Join(dependents, ", "), pluginName, nil)
+	}
 
 	return nil
 }
 
-// topologicalSort 拓扑排序
+// topologicalSort 拓扑排序，graph[node] 为 node 依赖的节点
+// 同一层级的节点按名称排序以保证结果稳定，存在环时返回环上的插件
 func (pr *pluginRegistryImpl) topologicalSort(graph map[string][]string) ([]string, error) {
-	inDegree := make(map[string]int)
-	for node := range graph {
-		inDegree[node] = 0
-	}
-
-	// 计算入度
-	for _, dependencies := range graph {
-		for _, dep := range dependencies {
-			inDegree[dep]++
+	inDegree := make(map[string]int, len(graph))
+	dependents := make(map[string][]string, len(graph))
+	for node, deps := range graph {
+		inDegree[node] = len(deps)
+		for _, dep := range deps {
+			dependents[dep] = append(dependents[dep], node)
 		}
 	}
 
-	// 找到入度为0的节点
+	// 找到没有依赖的节点
 	queue := make([]string, 0)
 	for node, degree := range inDegree {
 		if degree == 0 {
 			queue = append(queue, node)
 		}
 	}
+	sort.Strings(queue)
 
-	result := make([]string, 0)
+	result := make([]string, 0, len(graph))
 	for len(queue) > 0 {
 		node := queue[0]
 		queue = queue[1:]
 		result = append(result, node)
 
-		for _, neighbor := range graph[node] {
-			inDegree[neighbor]--
-			if inDegree[neighbor] == 0 {
-				queue = append(queue, neighbor)
+		var ready []string
+		for _, dependent := range dependents[node] {
+			inDegree[dependent]--
+			if inDegree[dependent] == 0 {
+				ready = append(ready, dependent)
 			}
 		}
+		sort.Strings(ready)
+		queue = append(queue, ready...)
 	}
 
 	// 检查是否有环
 	if len(result) != len(graph) {
-		return nil, NewPluginError(ErrCodePluginDependency, "circular dependency detected", "", nil)
+		cycle := findCycle(graph)
+		return nil, NewPluginError(ErrCodePluginDependency, "circular dependency detected: "+strings.Join(cycle, " -> "), "", nil)
 	}
 
 	return result, nil
 }
 
-// GetDependents 获取依赖此插件的插件列表
+// GetDependents 获取依赖此插件的插件列表，包括可选依赖
 func (pr *pluginRegistryImpl) GetDependents(pluginName string) []Plugin {
 	pr.mu.RLock()
 	defer pr.mu.RUnlock()
 
 	result := make([]Plugin, 0)
 	for _, plugin := range pr.plugins {
-		for _, dep := range plugin.Dependencies() {
+		for _, dep := range dependencyNames(plugin, true) {
 			if dep == pluginName {
 				result = append(result, plugin)
 				break
@@ -261,7 +275,7 @@ func (pr *pluginRegistryImpl) GetDependents(pluginName string) []Plugin {
 	return result
 }
 
-// GetDependencies 获取此插件依赖的插件列表
+// GetDependencies 获取此插件依赖的已注册插件列表，包括可选依赖
 func (pr *pluginRegistryImpl) GetDependencies(pluginName string) ([]Plugin, error) {
 	pr.mu.RLock()
 	defer pr.mu.RUnlock()
@@ -272,7 +286,7 @@ func (pr *pluginRegistryImpl) GetDependencies(pluginName string) ([]Plugin, erro
 	}
 
 	result := make([]Plugin, 0)
-	for _, depName := range plugin.Dependencies() {
+	for _, depName := range dependencyNames(plugin, true) {
 		if depPlugin, exists := pr.plugins[depName]; exists {
 			result = append(result, depPlugin)
 		}
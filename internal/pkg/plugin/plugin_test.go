@@ -74,7 +74,7 @@ func TestPluginRegistry(t *testing.T) {
 	pr := NewPluginRegistry()
 
 	// 测试注册插件
-	plugin := &mockPlugin{name: "test_plugin"}
+	plugin := &testMockPlugin{name: "test_plugin"}
 	err := pr.Register(plugin)
 	require.NoError(t, err)
 
@@ -0,0 +1,188 @@
+package plugin
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/go-kratos/kratos/v2/log"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+const (
+	// defaultHandshakeTimeout 等待插件握手的默认超时时间
+	defaultHandshakeTimeout = 10 * time.Second
+	// defaultShutdownGrace 插件进程优雅退出的等待时间
+	defaultShutdownGrace = 5 * time.Second
+)
+
+// pluginProcess 运行中的插件子进程
+type pluginProcess struct {
+	path     string
+	cmd      *exec.Cmd
+	stdin    io.WriteCloser
+	conn     *grpc.ClientConn
+	exited   chan struct{}
+	exitErr  error
+	stopping atomic.Bool
+}
+
+// startPluginProcess 启动插件二进制并完成版本握手
+func startPluginProcess(path string, handshakeTimeout time.Duration, logger log.Logger) (*pluginProcess, error) {
+	if handshakeTimeout <= 0 {
+		handshakeTimeout = defaultHandshakeTimeout
+	}
+
+	cmd := exec.Command(path)
+	cmd.Env = append(os.Environ(),
+		MagicCookieKey+"="+MagicCookieValue,
+		ProtocolVersionsKey+"="+strconv.Itoa(RPCProtocolVersion),
+	)
+
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	// 使用 io.Pipe 而非 StdoutPipe，保证 Wait 返回前输出已全部转发
+	stdout, stdoutWriter := io.Pipe()
+	stderr, stderrWriter := io.Pipe()
+	cmd.Stdout = stdoutWriter
+	cmd.Stderr = stderrWriter
+
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+
+	proc := &pluginProcess{
+		path:   path,
+		cmd:    cmd,
+		stdin:  stdin,
+		exited: make(chan struct{}),
+	}
+
+	helper := log.NewHelper(log.With(logger, "plugin_path", path))
+	lines := make(chan string, 1)
+	go func() {
+		scanner := bufio.NewScanner(stdout)
+		handshaked := false
+		for scanner.Scan() {
+			if !handshaked {
+				handshaked = true
+				lines <- scanner.Text()
+				continue
+			}
+			helper.Info(scanner.Text())
+		}
+	}()
+	go func() {
+		scanner := bufio.NewScanner(stderr)
+		for scanner.Scan() {
+			helper.Warn(scanner.Text())
+		}
+	}()
+	go func() {
+		proc.exitErr = cmd.Wait()
+		_ = stdoutWriter.Close()
+		_ = stderrWriter.Close()
+		close(proc.exited)
+	}()
+
+	var line string
+	select {
+	case line = <-lines:
+	case <-proc.exited:
+		return nil, fmt.Errorf("plugin exited before handshake: %v", proc.exitErr)
+	case <-time.After(handshakeTimeout):
+		proc.kill()
+		return nil, fmt.Errorf("plugin handshake timed out after %s", handshakeTimeout)
+	}
+
+	addr, err := parseHandshake(line)
+	if err != nil {
+		proc.kill()
+		return nil, err
+	}
+
+	conn, err := grpc.NewClient("unix://"+addr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		proc.kill()
+		return nil, err
+	}
+	proc.conn = conn
+
+	return proc, nil
+}
+
+// parseHandshake 解析插件握手行，返回 Unix Socket 地址
+func parseHandshake(line string) (string, error) {
+	parts := strings.Split(strings.TrimSpace(line), "|")
+	if len(parts) != 4 {
+		return "", fmt.Errorf("invalid plugin handshake: %q", line)
+	}
+
+	core, err := strconv.Atoi(parts[0])
+	if err != nil || core != CoreProtocolVersion {
+		return "", fmt.Errorf("incompatible plugin core protocol version %q, expected %d", parts[0], CoreProtocolVersion)
+	}
+	proto, err := strconv.Atoi(parts[1])
+	if err != nil || proto != RPCProtocolVersion {
+		return "", fmt.Errorf("incompatible plugin protocol version %q, expected %d", parts[1], RPCProtocolVersion)
+	}
+	if parts[2] != "unix" {
+		return "", fmt.Errorf("unsupported plugin network %q", parts[2])
+	}
+	if parts[3] == "" {
+		return "", fmt.Errorf("empty plugin address in handshake")
+	}
+
+	return parts[3], nil
+}
+
+// shutdown 请求插件进程退出，超时后强制结束
+func (p *pluginProcess) shutdown(grace time.Duration) {
+	p.stopping.Store(true)
+
+	if p.conn != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), grace)
+		_ = p.conn.Invoke(ctx, "/"+rpcServiceName+"/Shutdown", &structpb.Struct{}, &structpb.Struct{})
+		cancel()
+	}
+	_ = p.stdin.Close()
+
+	select {
+	case <-p.exited:
+	case <-time.After(grace):
+		_ = p.cmd.Process.Kill()
+		<-p.exited
+	}
+
+	if p.conn != nil {
+		_ = p.conn.Close()
+	}
+}
+
+// kill 立即结束插件进程（不标记为主动停止，监控协程会按崩溃处理）
+func (p *pluginProcess) kill() {
+	if p.cmd.Process != nil {
+		_ = p.cmd.Process.Kill()
+	}
+}
+
+// running 检查插件进程是否仍在运行
+func (p *pluginProcess) running() bool {
+	select {
+	case <-p.exited:
+		return false
+	default:
+		return true
+	}
+}
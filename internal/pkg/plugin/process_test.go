@@ -0,0 +1,285 @@
+package plugin
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestMain 由插件管理器以子进程方式启动时，测试二进制充当插件
+func TestMain(m *testing.M) {
+	if os.Getenv(MagicCookieKey) == MagicCookieValue {
+		var p Plugin = &fixturePlugin{}
+		if filepath.Base(os.Args[0]) == "dependent" {
+			p = &dependentPlugin{}
+		}
+		if err := Serve(p); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		os.Exit(0)
+	}
+	os.Exit(m.Run())
+}
+
+// fixturePlugin 进程外插件测试夹具
+type fixturePlugin struct {
+	config  PluginConfig
+	started bool
+}
+
+func (p *fixturePlugin) Name() string           { return "fixture" }
+func (p *fixturePlugin) Version() string        { return "1.2.3" }
+func (p *fixturePlugin) Description() string    { return "out-of-process fixture plugin" }
+func (p *fixturePlugin) Dependencies() []string { return nil }
+
+func (p *fixturePlugin) SettingsSchema() []byte {
+	return []byte(`{
+		"type": "object",
+		"properties": {
+			"greeting": {"type": "string", "default": "hello"},
+			"limit": {"type": "integer", "minimum": 1}
+		}
+	}`)
+}
+
+func (p *fixturePlugin) Initialize(ctx context.Context, config PluginConfig) error {
+	p.config = config
+	return nil
+}
+
+func (p *fixturePlugin) Reconfigure(ctx context.Context, config PluginConfig) error {
+	p.config = config
+	return nil
+}
+
+func (p *fixturePlugin) Start(ctx context.Context) error {
+	p.started = true
+	return nil
+}
+
+func (p *fixturePlugin) Stop(ctx context.Context) error {
+	p.started = false
+	return nil
+}
+
+func (p *fixturePlugin) Cleanup(ctx context.Context) error {
+	return nil
+}
+
+func (p *fixturePlugin) HealthCheck(ctx context.Context) error {
+	if !p.started {
+		return errors.New("fixture not started")
+	}
+	return nil
+}
+
+func (p *fixturePlugin) RegisterHooks(manager HookManager) error {
+	if err := manager.RegisterHook(HookPointBeforeRequest, NewBaseHook("fixture_before_request", 10, time.Second,
+		func(ctx context.Context, data HookData) error {
+			if d, ok := data.GetData()["sleep"].(string); ok {
+				delay, _ := time.ParseDuration(d)
+				time.Sleep(delay)
+			}
+			data.SetData("handled_by", p.Name())
+			data.GetMetadata()["retry_count"] = fmt.Sprint(p.config.RetryCount)
+			data.GetMetadata()["greeting"] = fmt.Sprint(p.config.Settings["greeting"])
+			return nil
+		})); err != nil {
+		return err
+	}
+
+	return manager.RegisterHook(HookPointBeforeAuth, NewBaseHook("fixture_before_auth", 10, time.Second,
+		func(ctx context.Context, data HookData) error {
+			switch data.GetData()["username"] {
+			case "blocked":
+				return NewPluginError(ErrCodePluginPermission, "user is blocked", p.Name(), nil)
+			case "intruder":
+				return NewHookAbortError(429, "LOGIN_THROTTLED", "too many attempts")
+			}
+			return nil
+		}))
+}
+
+func (p *fixturePlugin) RegisterEventHandlers(bus EventBus) error {
+	return bus.Subscribe(EventUserLogin, NewBaseEventHandler("fixture_login", []EventType{EventUserLogin}, time.Second,
+		func(ctx context.Context, event Event) error {
+			if event.GetData()["fail"] == true {
+				return errors.New("login handler failed")
+			}
+			return nil
+		}))
+}
+
+func newProcessTestManager(t *testing.T) (*pluginManagerImpl, HookManager, EventBus) {
+	t.Helper()
+	hm := NewHookManager()
+	eb := NewEventBus(5)
+	pm := NewPluginManager(NewPluginRegistry(), hm, eb, t.TempDir(), t.TempDir()).(*pluginManagerImpl)
+	pm.restartBackoff = 10 * time.Millisecond
+	t.Cleanup(func() {
+		if pm.stageDir != "" {
+			_ = os.RemoveAll(pm.stageDir)
+		}
+	})
+	return pm, hm, eb
+}
+
+func pluginBinary(t *testing.T) string {
+	t.Helper()
+	path, err := os.Executable()
+	require.NoError(t, err)
+	return path
+}
+
+func TestProcessPluginLifecycle(t *testing.T) {
+	pm, hm, eb := newProcessTestManager(t)
+
+	require.NoError(t, pm.LoadPlugin(pluginBinary(t)))
+
+	plugins := pm.ListPlugins()
+	require.Len(t, plugins, 1)
+	assert.Equal(t, "fixture", plugins[0].Metadata.Name)
+	assert.Equal(t, "1.2.3", plugins[0].Metadata.Version)
+	assert.Equal(t, PluginStatusLoaded, plugins[0].Status)
+
+	require.NoError(t, pm.StartPlugin("fixture"))
+	status, err := pm.GetPluginStatus("fixture")
+	require.NoError(t, err)
+	assert.Equal(t, PluginStatusStarted, status)
+
+	// 钩子在插件进程中执行，修改后的数据写回宿主
+	data := NewHookData(context.Background(), map[string]interface{}{})
+	require.NoError(t, hm.ExecuteHooks(context.Background(), HookPointBeforeRequest, data))
+	assert.Equal(t, "fixture", data.GetData()["handled_by"])
+	assert.Equal(t, "3", data.GetMetadata()["retry_count"])
+
+	// 插件返回的错误代码跨进程保留
+	data = NewHookData(context.Background(), map[string]interface{}{"username": "blocked"})
+	err = hm.ExecuteHooks(context.Background(), HookPointBeforeAuth, data)
+	require.Error(t, err)
+	var pe *PluginError
+	require.True(t, errors.As(err, &pe))
+	require.True(t, errors.As(pe.Cause, &pe))
+	assert.Equal(t, ErrCodePluginPermission, pe.Code)
+
+	// 钩子中止错误跨进程保留
+	data = NewHookData(context.Background(), map[string]interface{}{"username": "intruder"})
+	abort, ok := AsHookAbort(hm.ExecuteHooks(context.Background(), HookPointBeforeAuth, data))
+	require.True(t, ok)
+	assert.Equal(t, 429, abort.Status)
+	assert.Equal(t, "LOGIN_THROTTLED", abort.Reason)
+	assert.Equal(t, "too many attempts", abort.Message)
+
+	// 事件转发到插件进程
+	require.NoError(t, eb.Publish(context.Background(), NewEvent(EventUserLogin, "test", map[string]interface{}{"user": "alice"})))
+	require.Error(t, eb.Publish(context.Background(), NewEvent(EventUserLogin, "test", map[string]interface{}{"fail": true})))
+
+	// 停止后钩子与事件处理器注销，重新启动后恢复
+	require.NoError(t, pm.StopPlugin("fixture"))
+	assert.Empty(t, hm.ListHooks(HookPointBeforeRequest))
+	assert.Empty(t, hm.ListHooks(HookPointBeforeAuth))
+	require.NoError(t, eb.Publish(context.Background(), NewEvent(EventUserLogin, "test", map[string]interface{}{"fail": true})))
+
+	require.NoError(t, pm.StartPlugin("fixture"))
+	data = NewHookData(context.Background(), map[string]interface{}{})
+	require.NoError(t, hm.ExecuteHooks(context.Background(), HookPointBeforeRequest, data))
+	assert.Equal(t, "fixture", data.GetData()["handled_by"])
+	require.Error(t, eb.Publish(context.Background(), NewEvent(EventUserLogin, "test", map[string]interface{}{"fail": true})))
+	require.NoError(t, pm.StopPlugin("fixture"))
+
+	proc := pm.plugins["fixture"].process
+	require.NoError(t, pm.UnloadPlugin("fixture"))
+	assert.False(t, proc.running())
+	assert.Empty(t, hm.ListHooks(HookPointBeforeRequest))
+}
+
+func TestProcessPluginRestartAfterCrash(t *testing.T) {
+	pm, hm, _ := newProcessTestManager(t)
+
+	require.NoError(t, pm.LoadPlugin(pluginBinary(t)))
+	require.NoError(t, pm.StartPlugin("fixture"))
+	defer pm.UnloadPlugin("fixture")
+
+	pm.mu.RLock()
+	crashed := pm.plugins["fixture"].process
+	pm.mu.RUnlock()
+	crashed.kill()
+
+	require.Eventually(t, func() bool {
+		pm.mu.RLock()
+		defer pm.mu.RUnlock()
+		wrapper := pm.plugins["fixture"]
+		return wrapper.restarts == 1 && wrapper.info.Status == PluginStatusStarted
+	}, 10*time.Second, 20*time.Millisecond)
+
+	// 重启后原有代理钩子继续可用
+	data := NewHookData(context.Background(), map[string]interface{}{})
+	require.NoError(t, hm.ExecuteHooks(context.Background(), HookPointBeforeRequest, data))
+	assert.Equal(t, "fixture", data.GetData()["handled_by"])
+	assert.Len(t, hm.ListHooks(HookPointBeforeRequest), 1)
+}
+
+func TestProcessPluginRestartSupersededByUnload(t *testing.T) {
+	pm, _, _ := newProcessTestManager(t)
+
+	require.NoError(t, pm.LoadPlugin(pluginBinary(t)))
+	require.NoError(t, pm.StartPlugin("fixture"))
+
+	pm.mu.RLock()
+	wrapper := pm.plugins["fixture"]
+	proc := wrapper.process
+	pm.mu.RUnlock()
+
+	// 重启在锁外启动新进程，期间插件被卸载时放弃切换
+	require.NoError(t, pm.UnloadPlugin("fixture"))
+	err := pm.restartProcess(wrapper, proc, true)
+	assert.ErrorIs(t, err, errRestartSuperseded)
+	assert.Same(t, proc, wrapper.process)
+	assert.Zero(t, wrapper.restarts)
+}
+
+func TestLoadPluginRejectsNonPluginBinary(t *testing.T) {
+	pm, _, _ := newProcessTestManager(t)
+
+	err := pm.LoadPlugin("/bin/true")
+	require.Error(t, err)
+	var pe *PluginError
+	require.True(t, errors.As(err, &pe))
+	assert.Equal(t, ErrCodePluginLoadFailed, pe.Code)
+	assert.Empty(t, pm.ListPlugins())
+}
+
+func TestParseHandshake(t *testing.T) {
+	tests := []struct {
+		name    string
+		line    string
+		want    string
+		wantErr bool
+	}{
+		{"valid", fmt.Sprintf("%d|%d|unix|/tmp/p.sock\n", CoreProtocolVersion, RPCProtocolVersion), "/tmp/p.sock", false},
+		{"wrong core version", fmt.Sprintf("99|%d|unix|/tmp/p.sock", RPCProtocolVersion), "", true},
+		{"wrong protocol version", fmt.Sprintf("%d|99|unix|/tmp/p.sock", CoreProtocolVersion), "", true},
+		{"unsupported network", fmt.Sprintf("%d|%d|tcp|127.0.0.1:1234", CoreProtocolVersion, RPCProtocolVersion), "", true},
+		{"malformed", "hello world", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			addr, err := parseHandshake(tt.line)
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, addr)
+		})
+	}
+}
@@ -0,0 +1,669 @@
+package plugin
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/go-kratos/kratos/v2/log"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// defaultReloadDebounce 文件变更事件的合并等待时间，避免插件文件写入过程中被重复加载
+const defaultReloadDebounce = 500 * time.Millisecond
+
+// errReconfigureUnsupported 插件不支持运行时更新配置
+var errReconfigureUnsupported = errors.New("plugin does not support reconfigure")
+
+// LoadAll 加载插件目录中的所有插件，并按依赖顺序启动已启用的插件
+// 单个插件加载失败只记录日志，不影响其他插件
+func (pm *pluginManagerImpl) LoadAll() error {
+	helper := log.NewHelper(pm.logger)
+
+	entries, err := os.ReadDir(pm.pluginDir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return NewPluginError(ErrCodePluginLoadFailed, "failed to read plugin directory", pm.pluginDir, err)
+	}
+
+	var pending []string
+	for _, entry := range entries {
+		if entry.IsDir() || ignoredPluginFile(entry.Name()) || isSignatureFile(entry.Name()) {
+			continue
+		}
+		pending = append(pending, filepath.Join(pm.pluginDir, entry.Name()))
+	}
+
+	// 依赖尚未加载的插件在下一轮重试，直到没有新的插件加载成功
+	for len(pending) > 0 {
+		var retry []string
+		for _, path := range pending {
+			err := pm.LoadPlugin(path)
+			var pe *PluginError
+			var de *DependencyError
+			switch {
+			case err == nil:
+			case errors.As(err, &pe) && pe.Code == ErrCodePluginAlreadyExist:
+			case errors.As(err, &de) && de.Missing():
+				retry = append(retry, path)
+			case errors.As(err, &pe) && pe.Code == ErrCodePluginConfigError && pe.Message == "plugin is disabled":
+				helper.Debugf("skip disabled plugin %s", path)
+			default:
+				helper.Warnf("failed to load plugin %s: %v", path, err)
+			}
+		}
+		if len(retry) == len(pending) {
+			for _, path := range retry {
+				helper.Warnf("failed to load plugin %s: unresolved dependencies", path)
+			}
+			break
+		}
+		pending = retry
+	}
+
+	order, err := pm.registry.GetLoadOrder()
+	if err != nil {
+		return err
+	}
+	for _, p := range order {
+		name := p.Name()
+		if status, _ := pm.GetPluginStatus(name); status != PluginStatusLoaded {
+			continue
+		}
+		if err := pm.StartPlugin(name); err != nil {
+			helper.Warnf("failed to start plugin %s: %v", name, err)
+		}
+	}
+
+	return nil
+}
+
+// StartWatching 监听插件目录与配置目录：
+// 新增插件文件会被加载并启动，插件文件变更触发排空后切换的升级，配置文件变更会热更新插件配置
+func (pm *pluginManagerImpl) StartWatching() error {
+	pm.watchMu.Lock()
+	defer pm.watchMu.Unlock()
+
+	if pm.watcher != nil {
+		return nil
+	}
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return err
+	}
+	for _, dir := range []string{pm.pluginDir, pm.configDir} {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			watcher.Close()
+			return err
+		}
+		if err := watcher.Add(dir); err != nil {
+			watcher.Close()
+			return err
+		}
+	}
+
+	pm.watcher = watcher
+	pm.watchDone = make(chan struct{})
+	pm.pendingReloads = make(map[string]*time.Timer)
+	go pm.watchLoop(watcher, pm.watchDone)
+
+	return nil
+}
+
+// StopWatching 停止目录监听，丢弃尚未处理的变更
+func (pm *pluginManagerImpl) StopWatching() {
+	pm.watchMu.Lock()
+	watcher, done := pm.watcher, pm.watchDone
+	pm.watcher = nil
+	for path, timer := range pm.pendingReloads {
+		timer.Stop()
+		delete(pm.pendingReloads, path)
+	}
+	pm.watchMu.Unlock()
+
+	if watcher != nil {
+		watcher.Close()
+		<-done
+	}
+}
+
+// watchLoop 处理文件系统事件
+func (pm *pluginManagerImpl) watchLoop(watcher *fsnotify.Watcher, done chan struct{}) {
+	defer close(done)
+	helper := log.NewHelper(pm.logger)
+
+	for {
+		select {
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return
+			}
+			if !event.Has(fsnotify.Create) && !event.Has(fsnotify.Write) &&
+				!event.Has(fsnotify.Remove) && !event.Has(fsnotify.Rename) {
+				continue
+			}
+			if ignoredPluginFile(filepath.Base(event.Name)) {
+				continue
+			}
+			// 清单与签名文件的变更按对应插件制品处理，与制品本身的变更合并
+			path := event.Name
+			if isSignatureFile(path) && filepath.Clean(filepath.Dir(path)) == filepath.Clean(pm.pluginDir) {
+				path = artifactPath(path)
+			}
+			pm.scheduleReload(path)
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return
+			}
+			helper.Errorf("plugin watcher error: %v", err)
+		}
+	}
+}
+
+// scheduleReload 合并同一文件的连续变更事件
+func (pm *pluginManagerImpl) scheduleReload(path string) {
+	pm.watchMu.Lock()
+	defer pm.watchMu.Unlock()
+
+	if pm.watcher == nil {
+		return
+	}
+	if timer, ok := pm.pendingReloads[path]; ok {
+		timer.Reset(pm.reloadDebounce)
+		return
+	}
+	pm.pendingReloads[path] = time.AfterFunc(pm.reloadDebounce, func() {
+		pm.watchMu.Lock()
+		delete(pm.pendingReloads, path)
+		pm.watchMu.Unlock()
+
+		pm.handleFileChange(path)
+	})
+}
+
+// handleFileChange 根据变更文件所在目录分发处理
+func (pm *pluginManagerImpl) handleFileChange(path string) {
+	helper := log.NewHelper(log.With(pm.logger, "path", path))
+
+	var err error
+	switch dir := filepath.Clean(filepath.Dir(path)); {
+	case dir == filepath.Clean(pm.configDir) && filepath.Ext(path) == ".yaml":
+		err = pm.handleConfigFileChange(path)
+	case dir == filepath.Clean(pm.pluginDir):
+		err = pm.handlePluginFileChange(path)
+	}
+	if err != nil {
+		helper.Warnf("failed to apply plugin file change: %v", err)
+	}
+}
+
+// handlePluginFileChange 处理插件文件的新增、更新与删除
+func (pm *pluginManagerImpl) handlePluginFileChange(path string) error {
+	name, checksum, found := pm.findPluginByPath(path)
+
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		if !found {
+			pm.forgetRejected(path)
+			return nil
+		}
+		log.NewHelper(pm.logger).Infof("plugin file %s removed, unloading %s", path, name)
+		return pm.UnloadPlugin(name)
+	}
+
+	if !found {
+		if err := pm.LoadPlugin(path); err != nil {
+			return err
+		}
+		name, _, _ = pm.findPluginByPath(path)
+		config, err := pm.GetPluginConfig(name)
+		if err != nil || !config.Enabled {
+			return err
+		}
+		return pm.StartPlugin(name)
+	}
+
+	// 文件内容未变化（例如仅修改权限）时不升级
+	if sum, err := fileChecksum(path); err == nil && sum == checksum {
+		return nil
+	}
+	return pm.ReloadPlugin(name)
+}
+
+// handleConfigFileChange 处理插件配置文件变更
+func (pm *pluginManagerImpl) handleConfigFileChange(path string) error {
+	fileName := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+
+	pm.mu.RLock()
+	var wrapper *pluginWrapper
+	for _, w := range pm.plugins {
+		if filepath.Clean(w.info.ConfigPath) == filepath.Clean(path) {
+			wrapper = w
+			break
+		}
+	}
+	pm.mu.RUnlock()
+
+	config, err := pm.loadPluginConfig(fileName)
+	if err != nil {
+		return err
+	}
+
+	if wrapper == nil {
+		// 插件此前被禁用而未加载，启用后加载同名插件文件
+		if !config.Enabled {
+			return nil
+		}
+		matches, _ := filepath.Glob(filepath.Join(pm.pluginDir, fileName+"*"))
+		for _, candidate := range matches {
+			if pluginNameFromPath(candidate) == fileName {
+				return pm.handlePluginFileChange(candidate)
+			}
+		}
+		return nil
+	}
+
+	name := wrapper.info.Metadata.Name
+	if !config.Enabled {
+		return pm.UnloadPlugin(name)
+	}
+
+	current, err := pm.GetPluginConfig(name)
+	if err != nil {
+		return err
+	}
+	// 与填充默认值后的当前配置比较，避免写回配置文件触发重复更新
+	pm.mu.RLock()
+	schema := wrapper.schema
+	pm.mu.RUnlock()
+	validated, err := applySettingsSchema(schema, name, config)
+	if err != nil {
+		return err
+	}
+	if reflect.DeepEqual(current, validated) {
+		return nil
+	}
+	return pm.applyPluginConfig(name, config, false)
+}
+
+// applyPluginConfig 更新插件配置，运行中的插件通过 Reconfigure 热更新，不支持时重新初始化并重启
+// persist 为 true 时写回配置文件
+func (pm *pluginManagerImpl) applyPluginConfig(name string, config PluginConfig, persist bool) error {
+	pm.mu.Lock()
+	defer pm.mu.Unlock()
+
+	wrapper, exists := pm.plugins[name]
+	if !exists {
+		return NewPluginError(ErrCodePluginNotFound, "plugin not found", name, nil)
+	}
+
+	config, err := applySettingsSchema(wrapper.schema, name, config)
+	if err != nil {
+		return err
+	}
+
+	previous := wrapper.config
+	wrapper.config = config
+
+	if wrapper.info.Status == PluginStatusStarted {
+		if err := pm.reconfigurePlugin(wrapper); err != nil {
+			wrapper.config = previous
+			return err
+		}
+	}
+
+	if persist {
+		// 保存配置到文件
+		if err := pm.savePluginConfig(pluginNameFromPath(wrapper.info.ConfigPath), config); err != nil {
+			return err
+		}
+	}
+
+	// 发布配置变更事件
+	pm.eventBus.PublishAsync(context.Background(), NewEvent(
+		EventConfigChanged,
+		"plugin_manager",
+		map[string]interface{}{
+			"plugin": name,
+			"config": config,
+		},
+	))
+
+	return nil
+}
+
+// reconfigurePlugin 将 wrapper.config 应用到运行中的插件
+// 调用方需持有 pm.mu
+func (pm *pluginManagerImpl) reconfigurePlugin(wrapper *pluginWrapper) error {
+	name := wrapper.info.Metadata.Name
+
+	if rp, ok := wrapper.plugin.(ReconfigurablePlugin); ok {
+		ctx, cancel := context.WithTimeout(context.Background(), pluginCallTimeout(wrapper.config))
+		err := rp.Reconfigure(ctx, wrapper.config)
+		cancel()
+		if err == nil {
+			return nil
+		}
+		if !errors.Is(err, errReconfigureUnsupported) {
+			return NewPluginError(ErrCodePluginConfigError, "plugin reconfigure failed", name, err)
+		}
+	}
+
+	// 不支持热更新的插件：停止后以新配置重新初始化并启动
+	if err := pm.stopPluginInternal(wrapper); err != nil {
+		return err
+	}
+	return pm.startPluginInternal(wrapper)
+}
+
+// ReloadPlugin 从插件文件重新加载插件
+// 新版本启动成功后切换流量，旧版本在途调用完成后停止，随后按加载顺序重启依赖它的插件
+func (pm *pluginManagerImpl) ReloadPlugin(name string) error {
+	pm.mu.Lock()
+	defer pm.mu.Unlock()
+
+	wrapper, exists := pm.plugins[name]
+	if !exists {
+		return NewPluginError(ErrCodePluginNotFound, "plugin not found", name, nil)
+	}
+
+	resume := wrapper.info.Status == PluginStatusStarted || wrapper.unhealthy
+
+	// 新版本制品同样须通过签名验证，验证失败时保留运行中的旧版本
+	manifest, execPath, err := pm.verifyArtifact(wrapper.info.Path, pluginNameFromPath(wrapper.info.Path))
+	if err != nil {
+		log.NewHelper(pm.logger).Errorf("refusing to reload plugin %s: %v", name, err)
+		return err
+	}
+
+	switch {
+	case wrapper.remote != nil:
+		err = pm.upgradeProcessPlugin(wrapper, execPath, manifest, resume)
+	case wrapper.wasm != nil:
+		err = pm.upgradeWasmPlugin(wrapper, execPath, manifest, resume)
+	default:
+		pm.removeStaged(execPath)
+		err = NewPluginError(ErrCodePluginLoadFailed, "plugin does not support reload", name, nil)
+	}
+	if err != nil {
+		return err
+	}
+
+	wrapper.info.Metadata.Version = wrapper.plugin.Version()
+	wrapper.info.Metadata.Description = wrapper.plugin.Description()
+	wrapper.info.Metadata.Dependencies = wrapper.plugin.Dependencies()
+	fillCompatibility(&wrapper.info.Metadata, wrapper.plugin)
+	fillManifest(&wrapper.info.Metadata, manifest)
+	wrapper.info.LoadTime = time.Now()
+	wrapper.checksum, _ = fileChecksum(wrapper.info.Path)
+
+	if err := pm.syncProxies(wrapper); err != nil {
+		log.NewHelper(pm.logger).Warnf("failed to refresh hooks of plugin %s: %v", name, err)
+	}
+
+	// 发布插件加载事件
+	pm.eventBus.PublishAsync(context.Background(), NewEvent(
+		EventPluginLoaded,
+		"plugin_manager",
+		map[string]interface{}{
+			"plugin":   name,
+			"path":     wrapper.info.Path,
+			"version":  wrapper.info.Metadata.Version,
+			"reloaded": true,
+		},
+	))
+
+	pm.restartDependents(name)
+	return nil
+}
+
+// upgradeProcessPlugin 启动 execPath 处的新版本插件进程并切换连接
+// 调用方需持有 pm.mu
+func (pm *pluginManagerImpl) upgradeProcessPlugin(wrapper *pluginWrapper, execPath string, manifest *PluginManifest, resume bool) error {
+	name := wrapper.info.Metadata.Name
+
+	proc, next, err := pm.launchPlugin(execPath, wrapper.config)
+	if err != nil {
+		pm.removeStaged(execPath)
+		return NewPluginError(ErrCodePluginLoadFailed, "failed to launch new plugin version", name, err)
+	}
+	schema, config, err := pm.prepareUpgrade(next, name, wrapper.config, manifest, resume)
+	if err != nil {
+		proc.shutdown(defaultShutdownGrace)
+		pm.removeStaged(execPath)
+		return err
+	}
+	wrapper.schema, wrapper.config = schema, config
+	// 旧进程已在运行，删除其暂存副本不影响排空
+	pm.removeStaged(wrapper.execPath)
+	wrapper.execPath = execPath
+
+	// 切换后新调用进入新进程，旧进程排空在途调用后退出
+	old := wrapper.remote.swap(next)
+	oldProc := wrapper.process
+	wrapper.process = proc
+	wrapper.restarts = 0
+	wrapper.unhealthy = false
+	go pm.superviseProcess(wrapper, proc)
+
+	go func() {
+		helper := log.NewHelper(log.With(pm.logger, "plugin", name))
+		if !old.drain(defaultShutdownGrace) {
+			helper.Warn("timed out draining in-flight calls of previous plugin version")
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), defaultShutdownGrace)
+		defer cancel()
+		if resume {
+			_ = old.Invoke(ctx, "/"+rpcServiceName+"/Stop", &structpb.Struct{}, &structpb.Struct{})
+		}
+		_ = old.Invoke(ctx, "/"+rpcServiceName+"/Cleanup", &structpb.Struct{}, &structpb.Struct{})
+		oldProc.shutdown(defaultShutdownGrace)
+	}()
+
+	return nil
+}
+
+// upgradeWasmPlugin 编译 execPath 处的新版本模块，等待在途调用结束后切换
+// 调用方需持有 pm.mu
+func (pm *pluginManagerImpl) upgradeWasmPlugin(wrapper *pluginWrapper, execPath string, manifest *PluginManifest, resume bool) error {
+	name := wrapper.info.Metadata.Name
+
+	next, err := newWasmPlugin(context.Background(), execPath, pm.sandbox, pm.logger)
+	pm.removeStaged(execPath)
+	if err != nil {
+		return NewPluginError(ErrCodePluginLoadFailed, "failed to load new plugin version", name, err)
+	}
+	schema, config, err := pm.prepareUpgrade(next, name, wrapper.config, manifest, resume)
+	if err != nil {
+		_ = next.close(context.Background())
+		return err
+	}
+	wrapper.schema, wrapper.config = schema, config
+
+	// swap 后 next 持有旧版本运行时
+	wrapper.wasm.swap(next)
+
+	ctx, cancel := context.WithTimeout(context.Background(), defaultShutdownGrace)
+	defer cancel()
+	if resume {
+		_ = next.Stop(ctx)
+	}
+	_ = next.Cleanup(ctx)
+	return next.close(ctx)
+}
+
+// prepareUpgrade 校验新版本插件的名称、签名清单、依赖兼容性与配置 Schema，并在需要时完成初始化与启动
+// 返回新版本的配置 Schema 与填充默认值后的配置
+func (pm *pluginManagerImpl) prepareUpgrade(next Plugin, name string, config PluginConfig, manifest *PluginManifest, start bool) (*SettingsSchema, PluginConfig, error) {
+	if next.Name() != name {
+		return nil, config, NewPluginError(ErrCodePluginLoadFailed, "new plugin version reports a different name: "+next.Name(), name, nil)
+	}
+	if err := manifest.match(next); err != nil {
+		return nil, config, NewPluginError(ErrCodePluginPermission, "new plugin version does not match its signed manifest", name, err)
+	}
+	// 升级后的版本可能不再满足宿主或其他插件的版本约束
+	if err := pm.registry.CheckCompatibility(next); err != nil {
+		return nil, config, err
+	}
+	// 新版本的 Schema 可能不再接受当前配置
+	schema, err := pluginSettingsSchema(next, manifest)
+	if err != nil {
+		return nil, config, NewPluginError(ErrCodePluginConfigError, "invalid settings schema", name, err)
+	}
+	config, err = applySettingsSchema(schema, name, config)
+	if err != nil {
+		return nil, config, err
+	}
+	if !start {
+		return schema, config, nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), pluginCallTimeout(config))
+	defer cancel()
+	if err := next.Initialize(ctx, config); err != nil {
+		return nil, config, NewPluginError(ErrCodePluginStartFailed, "new plugin version initialization failed", name, err)
+	}
+	if err := next.Start(ctx); err != nil {
+		return nil, config, NewPluginError(ErrCodePluginStartFailed, "new plugin version start failed", name, err)
+	}
+	return schema, config, nil
+}
+
+// syncProxies 按插件当前提供的钩子与事件处理器刷新宿主侧代理
+// 调用方需持有 pm.mu
+func (pm *pluginManagerImpl) syncProxies(wrapper *pluginWrapper) error {
+	proxy, ok := wrapper.plugin.(proxyPlugin)
+	if !ok || wrapper.info.Status != PluginStatusStarted {
+		return nil
+	}
+	return proxy.syncProxies(pm.hookManager, pm.pluginBus(wrapper))
+}
+
+// restartDependents 按 GetLoadOrder 给出的顺序重启直接或间接依赖指定插件的运行中插件
+// 先按逆序停止，再按顺序启动
+// 调用方需持有 pm.mu
+func (pm *pluginManagerImpl) restartDependents(name string) {
+	helper := log.NewHelper(pm.logger)
+
+	affected := make(map[string]bool)
+	queue := []string{name}
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+		for _, dependent := range pm.registry.GetDependents(current) {
+			if !affected[dependent.Name()] {
+				affected[dependent.Name()] = true
+				queue = append(queue, dependent.Name())
+			}
+		}
+	}
+	if len(affected) == 0 {
+		return
+	}
+
+	order, err := pm.registry.GetLoadOrder()
+	if err != nil {
+		helper.Warnf("failed to resolve plugin load order: %v", err)
+		return
+	}
+
+	var restart []*pluginWrapper
+	for _, p := range order {
+		if wrapper, ok := pm.plugins[p.Name()]; ok && affected[p.Name()] && wrapper.info.Status == PluginStatusStarted {
+			restart = append(restart, wrapper)
+		}
+	}
+
+	for i := len(restart) - 1; i >= 0; i-- {
+		if err := pm.stopPluginInternal(restart[i]); err != nil {
+			helper.Warnf("failed to stop dependent plugin %s: %v", restart[i].info.Metadata.Name, err)
+		}
+	}
+	for _, wrapper := range restart {
+		if err := pm.startPluginInternal(wrapper); err != nil {
+			helper.Warnf("failed to restart dependent plugin %s: %v", wrapper.info.Metadata.Name, err)
+		}
+	}
+}
+
+// pruneProxies 注销不在 hooks/handlers 中的已注册代理
+func pruneProxies(proxiedHooks, hooks, proxiedHandlers, handlers map[string]bool, manager HookManager, bus EventBus) {
+	for key := range proxiedHooks {
+		if !hooks[key] {
+			point, name, _ := strings.Cut(key, "/")
+			_ = manager.UnregisterHook(HookPoint(point), name)
+			delete(proxiedHooks, key)
+		}
+	}
+	for key := range proxiedHandlers {
+		if !handlers[key] {
+			eventType, name, _ := strings.Cut(key, "/")
+			_ = bus.Unsubscribe(EventType(eventType), name)
+			delete(proxiedHandlers, key)
+		}
+	}
+}
+
+// findPluginByPath 查找从指定文件加载的插件
+func (pm *pluginManagerImpl) findPluginByPath(path string) (name, checksum string, found bool) {
+	pm.mu.RLock()
+	defer pm.mu.RUnlock()
+
+	for name, wrapper := range pm.plugins {
+		if filepath.Clean(wrapper.info.Path) == filepath.Clean(path) {
+			return name, wrapper.checksum, true
+		}
+	}
+	return "", "", false
+}
+
+// forgetRejected 插件文件删除后移除其签名验证失败记录
+func (pm *pluginManagerImpl) forgetRejected(path string) {
+	pm.mu.Lock()
+	defer pm.mu.Unlock()
+
+	for name, info := range pm.rejected {
+		if filepath.Clean(info.Path) == filepath.Clean(path) {
+			delete(pm.rejected, name)
+		}
+	}
+}
+
+// pluginCallTimeout 插件生命周期调用的超时时间
+func pluginCallTimeout(config PluginConfig) time.Duration {
+	if config.Timeout > 0 {
+		return config.Timeout
+	}
+	return defaultShutdownGrace
+}
+
+// ignoredPluginFile 忽略隐藏文件与编辑器临时文件
+func ignoredPluginFile(name string) bool {
+	return strings.HasPrefix(name, ".") || strings.HasSuffix(name, "~") ||
+		strings.HasSuffix(name, ".swp") || strings.HasSuffix(name, ".tmp")
+}
+
+// fileChecksum 计算文件的 SHA-256 摘要
+func fileChecksum(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
@@ -0,0 +1,148 @@
+package plugin
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// dependentPlugin 依赖 fixture 的进程外插件，测试二进制以 dependent 为文件名运行时提供
+type dependentPlugin struct{}
+
+func (p *dependentPlugin) Name() string                                              { return "dependent" }
+func (p *dependentPlugin) Version() string                                           { return "0.1.0" }
+func (p *dependentPlugin) Description() string                                       { return "plugin depending on fixture" }
+func (p *dependentPlugin) Dependencies() []string                                    { return []string{"fixture"} }
+func (p *dependentPlugin) Initialize(ctx context.Context, config PluginConfig) error { return nil }
+func (p *dependentPlugin) Start(ctx context.Context) error                           { return nil }
+func (p *dependentPlugin) Stop(ctx context.Context) error                            { return nil }
+func (p *dependentPlugin) Cleanup(ctx context.Context) error                         { return nil }
+func (p *dependentPlugin) HealthCheck(ctx context.Context) error                     { return nil }
+
+// installPlugin 将测试二进制以指定文件名原子地放入插件目录
+func installPlugin(t *testing.T, dir, name string) string {
+	t.Helper()
+
+	src, err := os.Open(pluginBinary(t))
+	require.NoError(t, err)
+	defer src.Close()
+
+	tmp := filepath.Join(dir, "."+name+".tmp")
+	dst, err := os.OpenFile(tmp, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0755)
+	require.NoError(t, err)
+	_, err = io.Copy(dst, src)
+	require.NoError(t, err)
+	require.NoError(t, dst.Close())
+
+	path := filepath.Join(dir, name)
+	require.NoError(t, os.Rename(tmp, path))
+	return path
+}
+
+func TestLoadAllResolvesDependencies(t *testing.T) {
+	pm, _, _ := newProcessTestManager(t)
+	installPlugin(t, pm.pluginDir, "dependent")
+	installPlugin(t, pm.pluginDir, "fixture")
+	defer func() {
+		_ = pm.UnloadPlugin("dependent")
+		_ = pm.UnloadPlugin("fixture")
+	}()
+
+	require.NoError(t, pm.LoadAll())
+
+	for _, name := range []string{"fixture", "dependent"} {
+		status, err := pm.GetPluginStatus(name)
+		require.NoError(t, err)
+		assert.Equal(t, PluginStatusStarted, status, name)
+	}
+}
+
+func TestReloadPluginDrainsAndRestartsDependents(t *testing.T) {
+	pm, hm, _ := newProcessTestManager(t)
+	installPlugin(t, pm.pluginDir, "fixture")
+	installPlugin(t, pm.pluginDir, "dependent")
+	defer func() {
+		_ = pm.UnloadPlugin("dependent")
+		_ = pm.UnloadPlugin("fixture")
+	}()
+	require.NoError(t, pm.LoadAll())
+
+	pm.mu.RLock()
+	oldProc := pm.plugins["fixture"].process
+	dependentStarted := *pm.plugins["dependent"].info.StartTime
+	pm.mu.RUnlock()
+
+	// 升级期间的在途调用由旧进程完成
+	inFlight := make(chan error, 1)
+	data := NewHookData(context.Background(), map[string]interface{}{"sleep": "300ms"})
+	go func() {
+		inFlight <- hm.ExecuteHooks(context.Background(), HookPointBeforeRequest, data)
+	}()
+	time.Sleep(100 * time.Millisecond)
+
+	require.NoError(t, pm.ReloadPlugin("fixture"))
+	require.NoError(t, <-inFlight)
+	assert.Equal(t, "fixture", data.GetData()["handled_by"])
+
+	pm.mu.RLock()
+	wrapper := pm.plugins["fixture"]
+	newProc := wrapper.process
+	status := wrapper.info.Status
+	dependent := pm.plugins["dependent"].info
+	pm.mu.RUnlock()
+
+	assert.NotSame(t, oldProc, newProc)
+	assert.Equal(t, PluginStatusStarted, status)
+	assert.Equal(t, PluginStatusStarted, dependent.Status)
+	assert.True(t, dependent.StartTime.After(dependentStarted))
+	require.Eventually(t, func() bool { return !oldProc.running() }, 10*time.Second, 20*time.Millisecond)
+
+	// 新进程接管代理钩子
+	data = NewHookData(context.Background(), map[string]interface{}{})
+	require.NoError(t, hm.ExecuteHooks(context.Background(), HookPointBeforeRequest, data))
+	assert.Equal(t, "fixture", data.GetData()["handled_by"])
+	assert.Len(t, hm.ListHooks(HookPointBeforeRequest), 1)
+}
+
+func TestWatchLoadsReconfiguresAndUnloads(t *testing.T) {
+	pm, hm, _ := newProcessTestManager(t)
+	pm.reloadDebounce = 50 * time.Millisecond
+	require.NoError(t, pm.StartWatching())
+	defer pm.StopWatching()
+
+	path := installPlugin(t, pm.pluginDir, "fixture")
+	require.Eventually(t, func() bool {
+		status, err := pm.GetPluginStatus("fixture")
+		return err == nil && status == PluginStatusStarted
+	}, 10*time.Second, 20*time.Millisecond)
+
+	pm.mu.RLock()
+	proc := pm.plugins["fixture"].process
+	pm.mu.RUnlock()
+
+	// 配置变更通过 Reconfigure 热更新，不重启插件进程
+	require.NoError(t, os.WriteFile(filepath.Join(pm.configDir, "fixture.yaml"), []byte("enabled: true\nretry_count: 7\n"), 0644))
+	require.Eventually(t, func() bool {
+		data := NewHookData(context.Background(), map[string]interface{}{})
+		return hm.ExecuteHooks(context.Background(), HookPointBeforeRequest, data) == nil &&
+			data.GetMetadata()["retry_count"] == "7"
+	}, 10*time.Second, 20*time.Millisecond)
+
+	pm.mu.RLock()
+	assert.Same(t, proc, pm.plugins["fixture"].process)
+	pm.mu.RUnlock()
+
+	require.NoError(t, os.Remove(path))
+	require.Eventually(t, func() bool {
+		_, err := pm.GetPluginStatus("fixture")
+		return err != nil
+	}, 10*time.Second, 20*time.Millisecond)
+	assert.False(t, proc.running())
+	assert.Empty(t, hm.ListHooks(HookPointBeforeRequest))
+}
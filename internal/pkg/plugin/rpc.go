@@ -0,0 +1,172 @@
+package plugin
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// 进程外插件协议
+//
+// 宿主进程启动插件二进制后，插件在本地 Unix Socket 上启动 gRPC 服务，
+// 并向标准输出写入一行握手信息：
+//
+//	<核心协议版本>|<应用协议版本>|unix|<socket路径>
+//
+// 所有 RPC 的请求与响应都使用 google.protobuf.Struct 承载，
+// 因此协议无需代码生成即可在宿主与插件之间传输。
+const (
+	// CoreProtocolVersion 握手行格式版本
+	CoreProtocolVersion = 1
+	// RPCProtocolVersion 插件 RPC 协议版本
+	RPCProtocolVersion = 1
+
+	// MagicCookieKey 宿主传给插件的魔术环境变量，防止插件二进制被直接执行
+	MagicCookieKey = "KRATOS_PLUGIN_MAGIC_COOKIE"
+	// MagicCookieValue 魔术环境变量的值
+	MagicCookieValue = "8d2c4a1e7b6f4f0d9e3a5c7b1f2e4d6a"
+	// ProtocolVersionsKey 宿主支持的 RPC 协议版本列表（逗号分隔）
+	ProtocolVersionsKey = "KRATOS_PLUGIN_PROTOCOL_VERSIONS"
+
+	rpcServiceName = "kratos.plugin.v1.PluginRPC"
+)
+
+// rpcMetadata 插件元数据消息
+type rpcMetadata struct {
+	Name         string   `json:"name"`
+	Version      string   `json:"version"`
+	Description  string   `json:"description"`
+	Dependencies []string `json:"dependencies,omitempty"`
+	HookPlugin   bool     `json:"hook_plugin"`
+	EventPlugin  bool     `json:"event_plugin"`
+	Reconfigure  bool     `json:"reconfigure"`
+
+	HostAPIVersion       string          `json:"host_api_version,omitempty"`
+	OptionalDependencies []string        `json:"optional_dependencies,omitempty"`
+	SettingsSchema       json.RawMessage `json:"settings_schema,omitempty"`
+}
+
+// rpcInitializeRequest 初始化请求
+type rpcInitializeRequest struct {
+	Config PluginConfig `json:"config"`
+}
+
+// rpcHookDescriptor 插件侧注册的钩子描述
+type rpcHookDescriptor struct {
+	Point    HookPoint     `json:"point"`
+	Name     string        `json:"name"`
+	Priority int           `json:"priority"`
+	Timeout  time.Duration `json:"timeout"`
+}
+
+// rpcListHooksReply 钩子列表响应
+type rpcListHooksReply struct {
+	Hooks []rpcHookDescriptor `json:"hooks"`
+}
+
+// rpcHookData 跨进程传输的钩子数据
+type rpcHookData struct {
+	Point    HookPoint              `json:"point"`
+	Name     string                 `json:"name"`
+	Data     map[string]interface{} `json:"data"`
+	Metadata map[string]string      `json:"metadata"`
+}
+
+// rpcHandlerDescriptor 插件侧注册的事件处理器描述
+type rpcHandlerDescriptor struct {
+	Name       string        `json:"name"`
+	EventTypes []EventType   `json:"event_types"`
+	Timeout    time.Duration `json:"timeout"`
+}
+
+// rpcListHandlersReply 事件处理器列表响应
+type rpcListHandlersReply struct {
+	Handlers []rpcHandlerDescriptor `json:"handlers"`
+}
+
+// rpcEvent 跨进程传输的事件
+type rpcEvent struct {
+	Handler   string                 `json:"handler"`
+	ID        string                 `json:"id"`
+	Type      EventType              `json:"type"`
+	Source    string                 `json:"source"`
+	Timestamp time.Time              `json:"timestamp"`
+	Data      map[string]interface{} `json:"data"`
+	Metadata  map[string]string      `json:"metadata"`
+}
+
+// toStruct 将消息编码为 protobuf Struct
+func toStruct(v interface{}) (*structpb.Struct, error) {
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	s := &structpb.Struct{}
+	if err := s.UnmarshalJSON(raw); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// fromStruct 将 protobuf Struct 解码为消息
+func fromStruct(s *structpb.Struct, v interface{}) error {
+	if s == nil {
+		return nil
+	}
+	raw, err := s.MarshalJSON()
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(raw, v)
+}
+
+// rpcHandler 插件侧 RPC 方法签名
+type rpcHandler func(s *rpcServer, ctx context.Context, req *structpb.Struct) (*structpb.Struct, error)
+
+// unaryMethod 构造 gRPC 方法描述
+func unaryMethod(name string, h rpcHandler) grpc.MethodDesc {
+	return grpc.MethodDesc{
+		MethodName: name,
+		Handler: func(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+			in := &structpb.Struct{}
+			if err := dec(in); err != nil {
+				return nil, err
+			}
+			if interceptor == nil {
+				return h(srv.(*rpcServer), ctx, in)
+			}
+			info := &grpc.UnaryServerInfo{
+				Server:     srv,
+				FullMethod: "/" + rpcServiceName + "/" + name,
+			}
+			return interceptor(ctx, in, info, func(ctx context.Context, req interface{}) (interface{}, error) {
+				return h(srv.(*rpcServer), ctx, req.(*structpb.Struct))
+			})
+		},
+	}
+}
+
+// rpcServiceDesc 插件 RPC 服务描述
+var rpcServiceDesc = grpc.ServiceDesc{
+	ServiceName: rpcServiceName,
+	HandlerType: (*interface{})(nil),
+	Methods: []grpc.MethodDesc{
+		unaryMethod("Metadata", (*rpcServer).metadata),
+		unaryMethod("Initialize", (*rpcServer).initialize),
+		unaryMethod("Reconfigure", (*rpcServer).reconfigure),
+		unaryMethod("Start", (*rpcServer).start),
+		unaryMethod("Stop", (*rpcServer).stop),
+		unaryMethod("Cleanup", (*rpcServer).cleanup),
+		unaryMethod("HealthCheck", (*rpcServer).healthCheck),
+		unaryMethod("ListHooks", (*rpcServer).listHooks),
+		unaryMethod("ExecuteHook", (*rpcServer).executeHook),
+		unaryMethod("ListEventHandlers", (*rpcServer).listEventHandlers),
+		unaryMethod("HandleEvent", (*rpcServer).handleEvent),
+		unaryMethod("Shutdown", (*rpcServer).shutdown),
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "plugin_rpc",
+}
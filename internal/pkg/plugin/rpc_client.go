@@ -0,0 +1,358 @@
+package plugin
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// rpcPlugin 宿主侧的进程外插件代理
+// 实现 Plugin、HookPlugin 和 EventPlugin，所有调用都通过 gRPC 转发到插件进程
+type rpcPlugin struct {
+	mu       sync.RWMutex
+	conn     *rpcConn
+	metadata rpcMetadata
+	timeout  time.Duration
+
+	// 已在宿主侧注册的代理钩子与事件处理器，插件重启后复用
+	proxiedHooks    map[string]bool
+	proxiedHandlers map[string]bool
+}
+
+// rpcConn 插件连接及其在途调用计数，升级插件时用于排空旧连接
+type rpcConn struct {
+	*grpc.ClientConn
+	calls sync.WaitGroup
+}
+
+// drain 等待在途调用完成，超时返回 false
+func (c *rpcConn) drain(timeout time.Duration) bool {
+	done := make(chan struct{})
+	go func() {
+		c.calls.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return true
+	case <-time.After(timeout):
+		return false
+	}
+}
+
+// newRPCPlugin 创建插件代理并拉取插件元数据
+func newRPCPlugin(ctx context.Context, conn *grpc.ClientConn, timeout time.Duration) (*rpcPlugin, error) {
+	p := &rpcPlugin{
+		conn:            &rpcConn{ClientConn: conn},
+		timeout:         timeout,
+		proxiedHooks:    make(map[string]bool),
+		proxiedHandlers: make(map[string]bool),
+	}
+	if err := p.invoke(ctx, "Metadata", nil, &p.metadata); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// setConn 插件进程重启后切换连接
+func (p *rpcPlugin) setConn(conn *grpc.ClientConn) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.conn = &rpcConn{ClientConn: conn}
+}
+
+// swap 将后续调用切换到新版本插件进程，返回旧连接供调用方排空
+func (p *rpcPlugin) swap(next *rpcPlugin) *rpcConn {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	old := p.conn
+	p.conn = next.conn
+	p.metadata = next.metadata
+	return old
+}
+
+// invoke 调用插件 RPC 方法
+func (p *rpcPlugin) invoke(ctx context.Context, method string, in, out interface{}) error {
+	p.mu.RLock()
+	conn := p.conn
+	name := p.metadata.Name
+	conn.calls.Add(1)
+	p.mu.RUnlock()
+	defer conn.calls.Done()
+
+	if _, ok := ctx.Deadline(); !ok && p.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, p.timeout)
+		defer cancel()
+	}
+
+	req := &structpb.Struct{}
+	if in != nil {
+		var err error
+		if req, err = toStruct(in); err != nil {
+			return NewPluginError(ErrCodePluginInternal, "failed to encode plugin request", name, err)
+		}
+	}
+
+	reply := &structpb.Struct{}
+	if err := conn.Invoke(ctx, "/"+rpcServiceName+"/"+method, req, reply); err != nil {
+		return fromRPCError(err, name)
+	}
+
+	if out != nil {
+		if err := fromStruct(reply, out); err != nil {
+			return NewPluginError(ErrCodePluginInternal, "failed to decode plugin reply", name, err)
+		}
+	}
+	return nil
+}
+
+// meta 读取插件元数据，升级后元数据会被替换
+func (p *rpcPlugin) meta() rpcMetadata {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.metadata
+}
+
+func (p *rpcPlugin) Name() string {
+	return p.meta().Name
+}
+
+func (p *rpcPlugin) Version() string {
+	return p.meta().Version
+}
+
+func (p *rpcPlugin) Description() string {
+	return p.meta().Description
+}
+
+func (p *rpcPlugin) Dependencies() []string {
+	return p.meta().Dependencies
+}
+
+func (p *rpcPlugin) HostAPIVersion() string {
+	return p.meta().HostAPIVersion
+}
+
+func (p *rpcPlugin) OptionalDependencies() []string {
+	return p.meta().OptionalDependencies
+}
+
+func (p *rpcPlugin) SettingsSchema() []byte {
+	return p.meta().SettingsSchema
+}
+
+func (p *rpcPlugin) Initialize(ctx context.Context, config PluginConfig) error {
+	return p.invoke(ctx, "Initialize", rpcInitializeRequest{Config: config}, nil)
+}
+
+// Reconfigure 在插件进程中应用新配置，插件未实现时返回 errReconfigureUnsupported
+func (p *rpcPlugin) Reconfigure(ctx context.Context, config PluginConfig) error {
+	if !p.meta().Reconfigure {
+		return errReconfigureUnsupported
+	}
+	return p.invoke(ctx, "Reconfigure", rpcInitializeRequest{Config: config}, nil)
+}
+
+func (p *rpcPlugin) Start(ctx context.Context) error {
+	return p.invoke(ctx, "Start", nil, nil)
+}
+
+func (p *rpcPlugin) Stop(ctx context.Context) error {
+	return p.invoke(ctx, "Stop", nil, nil)
+}
+
+func (p *rpcPlugin) Cleanup(ctx context.Context) error {
+	return p.invoke(ctx, "Cleanup", nil, nil)
+}
+
+func (p *rpcPlugin) HealthCheck(ctx context.Context) error {
+	return p.invoke(ctx, "HealthCheck", nil, nil)
+}
+
+// RegisterHooks 拉取插件侧注册的钩子，并在宿主钩子管理器中注册代理
+func (p *rpcPlugin) RegisterHooks(manager HookManager) error {
+	if !p.meta().HookPlugin {
+		return nil
+	}
+
+	var reply rpcListHooksReply
+	if err := p.invoke(context.Background(), "ListHooks", nil, &reply); err != nil {
+		return err
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for _, desc := range reply.Hooks {
+		key := string(desc.Point) + "/" + desc.Name
+		if p.proxiedHooks[key] {
+			continue
+		}
+		if err := manager.RegisterHook(desc.Point, &rpcHook{plugin: p, desc: desc}); err != nil {
+			return err
+		}
+		p.proxiedHooks[key] = true
+	}
+	return nil
+}
+
+// RegisterEventHandlers 拉取插件侧注册的事件处理器，并在宿主事件总线上订阅代理
+func (p *rpcPlugin) RegisterEventHandlers(bus EventBus) error {
+	if !p.meta().EventPlugin {
+		return nil
+	}
+
+	var reply rpcListHandlersReply
+	if err := p.invoke(context.Background(), "ListEventHandlers", nil, &reply); err != nil {
+		return err
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for _, desc := range reply.Handlers {
+		handler := &rpcEventHandler{plugin: p, desc: desc}
+		for _, eventType := range desc.EventTypes {
+			key := string(eventType) + "/" + desc.Name
+			if p.proxiedHandlers[key] {
+				continue
+			}
+			if err := bus.Subscribe(eventType, handler); err != nil {
+				return err
+			}
+			p.proxiedHandlers[key] = true
+		}
+	}
+	return nil
+}
+
+// unregisterProxies 从宿主注销所有代理钩子与事件处理器
+func (p *rpcPlugin) unregisterProxies(manager HookManager, bus EventBus) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for key := range p.proxiedHooks {
+		point, name, _ := strings.Cut(key, "/")
+		_ = manager.UnregisterHook(HookPoint(point), name)
+	}
+	for key := range p.proxiedHandlers {
+		eventType, name, _ := strings.Cut(key, "/")
+		_ = bus.Unsubscribe(EventType(eventType), name)
+	}
+	p.proxiedHooks = make(map[string]bool)
+	p.proxiedHandlers = make(map[string]bool)
+}
+
+// syncProxies 注销新版本插件不再提供的代理，并注册新增的钩子与事件处理器
+func (p *rpcPlugin) syncProxies(manager HookManager, bus EventBus) error {
+	meta := p.meta()
+
+	hooks := make(map[string]bool)
+	if meta.HookPlugin {
+		var reply rpcListHooksReply
+		if err := p.invoke(context.Background(), "ListHooks", nil, &reply); err != nil {
+			return err
+		}
+		for _, desc := range reply.Hooks {
+			hooks[string(desc.Point)+"/"+desc.Name] = true
+		}
+	}
+
+	handlers := make(map[string]bool)
+	if meta.EventPlugin {
+		var reply rpcListHandlersReply
+		if err := p.invoke(context.Background(), "ListEventHandlers", nil, &reply); err != nil {
+			return err
+		}
+		for _, desc := range reply.Handlers {
+			for _, eventType := range desc.EventTypes {
+				handlers[string(eventType)+"/"+desc.Name] = true
+			}
+		}
+	}
+
+	p.mu.Lock()
+	pruneProxies(p.proxiedHooks, hooks, p.proxiedHandlers, handlers, manager, bus)
+	p.mu.Unlock()
+
+	if err := p.RegisterHooks(manager); err != nil {
+		return err
+	}
+	return p.RegisterEventHandlers(bus)
+}
+
+// rpcHook 宿主侧代理钩子
+type rpcHook struct {
+	plugin *rpcPlugin
+	desc   rpcHookDescriptor
+}
+
+func (h *rpcHook) GetName() string {
+	return h.desc.Name
+}
+
+func (h *rpcHook) GetPriority() int {
+	return h.desc.Priority
+}
+
+func (h *rpcHook) GetTimeout() time.Duration {
+	return h.desc.Timeout
+}
+
+// Execute 将钩子数据发送到插件执行，并把插件修改后的数据写回
+func (h *rpcHook) Execute(ctx context.Context, data HookData) error {
+	var reply rpcHookData
+	err := h.plugin.invoke(ctx, "ExecuteHook", rpcHookData{
+		Point:    h.desc.Point,
+		Name:     h.desc.Name,
+		Data:     data.GetData(),
+		Metadata: data.GetMetadata(),
+	}, &reply)
+	if err != nil {
+		return err
+	}
+
+	for k, v := range reply.Data {
+		data.SetData(k, v)
+	}
+	if metadata := data.GetMetadata(); metadata != nil {
+		for k, v := range reply.Metadata {
+			metadata[k] = v
+		}
+	}
+	return nil
+}
+
+// rpcEventHandler 宿主侧代理事件处理器
+type rpcEventHandler struct {
+	plugin *rpcPlugin
+	desc   rpcHandlerDescriptor
+}
+
+func (h *rpcEventHandler) GetName() string {
+	return h.desc.Name
+}
+
+func (h *rpcEventHandler) GetEventTypes() []EventType {
+	return h.desc.EventTypes
+}
+
+func (h *rpcEventHandler) GetTimeout() time.Duration {
+	return h.desc.Timeout
+}
+
+// Handle 将事件转发到插件处理
+func (h *rpcEventHandler) Handle(ctx context.Context, event Event) error {
+	return h.plugin.invoke(ctx, "HandleEvent", rpcEvent{
+		Handler:   h.desc.Name,
+		ID:        event.GetID(),
+		Type:      event.GetType(),
+		Source:    event.GetSource(),
+		Timestamp: event.GetTimestamp(),
+		Data:      event.GetData(),
+		Metadata:  event.GetMetadata(),
+	}, nil)
+}
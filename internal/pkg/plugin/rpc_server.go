@@ -0,0 +1,360 @@
+package plugin
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// Serve 在插件二进制中运行插件 RPC 服务
+// 插件的 main 函数只需调用 plugin.Serve(NewXxxPlugin())
+func Serve(p Plugin) error {
+	if os.Getenv(MagicCookieKey) != MagicCookieValue {
+		return errors.New("this binary is a plugin and must be launched by the plugin manager")
+	}
+	if !supportsProtocol(os.Getenv(ProtocolVersionsKey), RPCProtocolVersion) {
+		return fmt.Errorf("host does not support plugin protocol version %d", RPCProtocolVersion)
+	}
+
+	dir, err := os.MkdirTemp("", "kratos-plugin-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(dir)
+
+	socket := filepath.Join(dir, "plugin.sock")
+	lis, err := net.Listen("unix", socket)
+	if err != nil {
+		return err
+	}
+
+	srv := grpc.NewServer()
+	var once sync.Once
+	stop := func() { once.Do(func() { go srv.GracefulStop() }) }
+	srv.RegisterService(&rpcServiceDesc, newRPCServer(p, stop))
+
+	// 宿主关闭标准输入或发送终止信号时退出
+	go func() {
+		_, _ = io.Copy(io.Discard, os.Stdin)
+		stop()
+	}()
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM)
+	go func() {
+		<-signals
+		stop()
+	}()
+
+	fmt.Fprintf(os.Stdout, "%d|%d|unix|%s\n", CoreProtocolVersion, RPCProtocolVersion, socket)
+
+	return srv.Serve(lis)
+}
+
+// supportsProtocol 检查宿主声明的协议版本列表是否包含指定版本
+func supportsProtocol(versions string, want int) bool {
+	for _, v := range strings.Split(versions, ",") {
+		if n, err := strconv.Atoi(strings.TrimSpace(v)); err == nil && n == want {
+			return true
+		}
+	}
+	return false
+}
+
+// rpcServer 插件侧 RPC 服务实现
+type rpcServer struct {
+	plugin     Plugin
+	stopServer func()
+
+	mu               sync.Mutex
+	hooks            *hookManagerImpl
+	bus              *eventBusImpl
+	hooksRegistered  bool
+	eventsRegistered bool
+}
+
+// newRPCServer 创建插件侧 RPC 服务
+func newRPCServer(p Plugin, shutdown func()) *rpcServer {
+	return &rpcServer{
+		plugin:     p,
+		stopServer: shutdown,
+		hooks:      NewHookManager().(*hookManagerImpl),
+		bus:        NewEventBus(1).(*eventBusImpl),
+	}
+}
+
+func (s *rpcServer) metadata(ctx context.Context, _ *structpb.Struct) (*structpb.Struct, error) {
+	_, isHook := s.plugin.(HookPlugin)
+	_, isEvent := s.plugin.(EventPlugin)
+	_, reconfigurable := s.plugin.(ReconfigurablePlugin)
+	meta := rpcMetadata{
+		Name:         s.plugin.Name(),
+		Version:      s.plugin.Version(),
+		Description:  s.plugin.Description(),
+		Dependencies: s.plugin.Dependencies(),
+		HookPlugin:   isHook,
+		EventPlugin:  isEvent,
+		Reconfigure:  reconfigurable,
+	}
+	if cp, ok := s.plugin.(CompatibilityPlugin); ok {
+		meta.HostAPIVersion = cp.HostAPIVersion()
+		meta.OptionalDependencies = cp.OptionalDependencies()
+	}
+	if sp, ok := s.plugin.(SchemaPlugin); ok {
+		meta.SettingsSchema = sp.SettingsSchema()
+	}
+	return toStruct(meta)
+}
+
+func (s *rpcServer) initialize(ctx context.Context, req *structpb.Struct) (*structpb.Struct, error) {
+	var in rpcInitializeRequest
+	if err := fromStruct(req, &in); err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	return empty(s.plugin.Initialize(ctx, in.Config))
+}
+
+func (s *rpcServer) reconfigure(ctx context.Context, req *structpb.Struct) (*structpb.Struct, error) {
+	rp, ok := s.plugin.(ReconfigurablePlugin)
+	if !ok {
+		return nil, status.Error(codes.Unimplemented, "plugin does not support reconfigure")
+	}
+	var in rpcInitializeRequest
+	if err := fromStruct(req, &in); err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	return empty(rp.Reconfigure(ctx, in.Config))
+}
+
+func (s *rpcServer) start(ctx context.Context, _ *structpb.Struct) (*structpb.Struct, error) {
+	return empty(s.plugin.Start(ctx))
+}
+
+func (s *rpcServer) stop(ctx context.Context, _ *structpb.Struct) (*structpb.Struct, error) {
+	return empty(s.plugin.Stop(ctx))
+}
+
+func (s *rpcServer) cleanup(ctx context.Context, _ *structpb.Struct) (*structpb.Struct, error) {
+	return empty(s.plugin.Cleanup(ctx))
+}
+
+func (s *rpcServer) healthCheck(ctx context.Context, _ *structpb.Struct) (*structpb.Struct, error) {
+	return empty(s.plugin.HealthCheck(ctx))
+}
+
+func (s *rpcServer) listHooks(ctx context.Context, _ *structpb.Struct) (*structpb.Struct, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	hookPlugin, ok := s.plugin.(HookPlugin)
+	if !ok {
+		return toStruct(rpcListHooksReply{})
+	}
+	if !s.hooksRegistered {
+		if err := hookPlugin.RegisterHooks(s.hooks); err != nil {
+			return nil, toRPCError(err)
+		}
+		s.hooksRegistered = true
+	}
+
+	reply := rpcListHooksReply{}
+	s.hooks.mu.RLock()
+	for point, entries := range s.hooks.hooks {
+		for _, entry := range entries {
+			reply.Hooks = append(reply.Hooks, rpcHookDescriptor{
+				Point:    point,
+				Name:     entry.hook.GetName(),
+				Priority: entry.priority,
+				Timeout:  entry.hook.GetTimeout(),
+			})
+		}
+	}
+	s.hooks.mu.RUnlock()
+
+	return toStruct(reply)
+}
+
+func (s *rpcServer) executeHook(ctx context.Context, req *structpb.Struct) (*structpb.Struct, error) {
+	var in rpcHookData
+	if err := fromStruct(req, &in); err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	hook, err := s.hooks.GetHook(in.Point, in.Name)
+	if err != nil {
+		return nil, toRPCError(err)
+	}
+
+	data := NewHookData(ctx, in.Data)
+	for k, v := range in.Metadata {
+		data.GetMetadata()[k] = v
+	}
+	if err := hook.Execute(ctx, data); err != nil {
+		return nil, toRPCError(err)
+	}
+
+	return toStruct(rpcHookData{
+		Point:    in.Point,
+		Name:     in.Name,
+		Data:     data.GetData(),
+		Metadata: data.GetMetadata(),
+	})
+}
+
+func (s *rpcServer) listEventHandlers(ctx context.Context, _ *structpb.Struct) (*structpb.Struct, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	eventPlugin, ok := s.plugin.(EventPlugin)
+	if !ok {
+		return toStruct(rpcListHandlersReply{})
+	}
+	if !s.eventsRegistered {
+		if err := eventPlugin.RegisterEventHandlers(s.bus); err != nil {
+			return nil, toRPCError(err)
+		}
+		s.eventsRegistered = true
+	}
+
+	// 同一处理器可能订阅多个事件类型，按名称合并
+	merged := make(map[string]*rpcHandlerDescriptor)
+	order := make([]string, 0)
+	s.bus.mu.RLock()
+	for eventType, subs := range s.bus.subscribers {
+		for _, sub := range subs {
+			name := sub.handler.GetName()
+			desc, exists := merged[name]
+			if !exists {
+				desc = &rpcHandlerDescriptor{Name: name, Timeout: sub.handler.GetTimeout()}
+				merged[name] = desc
+				order = append(order, name)
+			}
+			desc.EventTypes = append(desc.EventTypes, eventType)
+		}
+	}
+	s.bus.mu.RUnlock()
+
+	reply := rpcListHandlersReply{}
+	for _, name := range order {
+		reply.Handlers = append(reply.Handlers, *merged[name])
+	}
+	return toStruct(reply)
+}
+
+func (s *rpcServer) handleEvent(ctx context.Context, req *structpb.Struct) (*structpb.Struct, error) {
+	var in rpcEvent
+	if err := fromStruct(req, &in); err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	event := &eventImpl{
+		id:        in.ID,
+		type_:     in.Type,
+		source:    in.Source,
+		timestamp: in.Timestamp,
+		data:      in.Data,
+		metadata:  in.Metadata,
+	}
+	if event.metadata == nil {
+		event.metadata = make(map[string]string)
+	}
+
+	s.bus.mu.RLock()
+	var handler EventHandler
+	for _, sub := range s.bus.subscribers[in.Type] {
+		if sub.handler.GetName() == in.Handler {
+			handler = sub.handler
+			break
+		}
+	}
+	s.bus.mu.RUnlock()
+
+	if handler == nil {
+		return nil, toRPCError(NewPluginError(ErrCodePluginNotFound, "handler not found", in.Handler, nil))
+	}
+	return empty(handler.Handle(ctx, event))
+}
+
+func (s *rpcServer) shutdown(ctx context.Context, _ *structpb.Struct) (*structpb.Struct, error) {
+	s.stopServer()
+	return &structpb.Struct{}, nil
+}
+
+// empty 将插件方法结果转换为空响应
+func empty(err error) (*structpb.Struct, error) {
+	if err != nil {
+		return nil, toRPCError(err)
+	}
+	return &structpb.Struct{}, nil
+}
+
+// toRPCError 将插件错误编码为 gRPC 状态，保留 PluginError 的错误代码
+func toRPCError(err error) error {
+	code := ErrCodePluginInternal
+	var pe *PluginError
+	if errors.As(err, &pe) {
+		code = pe.Code
+	}
+
+	fields := map[string]interface{}{"code": code}
+	if abort, ok := AsHookAbort(err); ok {
+		fields["code"] = ErrCodeHookAborted
+		fields["status"] = abort.Status
+		fields["reason"] = abort.Reason
+		fields["message"] = abort.Message
+	}
+
+	st := status.New(codes.Unknown, err.Error())
+	detail, derr := structpb.NewStruct(fields)
+	if derr != nil {
+		return st.Err()
+	}
+	if withDetail, derr := st.WithDetails(detail); derr == nil {
+		st = withDetail
+	}
+	return st.Err()
+}
+
+// fromRPCError 将 gRPC 状态还原为 PluginError
+func fromRPCError(err error, pluginName string) error {
+	if err == nil {
+		return nil
+	}
+	st, ok := status.FromError(err)
+	if !ok {
+		return NewPluginError(ErrCodePluginInternal, "plugin rpc failed", pluginName, err)
+	}
+
+	code := ErrCodePluginInternal
+	if st.Code() == codes.DeadlineExceeded {
+		code = ErrCodePluginTimeout
+	}
+	var cause error
+	for _, d := range st.Details() {
+		if s, ok := d.(*structpb.Struct); ok {
+			fields := s.GetFields()
+			if c := fields["code"].GetStringValue(); c != "" {
+				code = c
+			}
+			if code == ErrCodeHookAborted {
+				cause = NewHookAbortError(int(fields["status"].GetNumberValue()),
+					fields["reason"].GetStringValue(), fields["message"].GetStringValue())
+			}
+		}
+	}
+
+	return NewPluginError(code, st.Message(), pluginName, cause)
+}
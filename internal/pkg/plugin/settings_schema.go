@@ -0,0 +1,475 @@
+package plugin
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// SettingsSchema 插件配置 Settings 的 JSON Schema
+//
+// 支持 draft-07 中常用的关键字：type、properties、required、additionalProperties、
+// items、enum、const、default、minimum、maximum、exclusiveMinimum、exclusiveMaximum、
+// minLength、maxLength、pattern、minItems、maxItems 以及 format: duration，
+// 其他关键字（如 title、description）被忽略。
+type SettingsSchema struct {
+	root *schemaNode
+}
+
+// schemaNode 已编译的 Schema 节点
+type schemaNode struct {
+	types                []string
+	properties           map[string]*schemaNode
+	required             []string
+	additionalProperties *schemaNode
+	noAdditional         bool
+	items                *schemaNode
+	enum                 []interface{}
+	constValue           interface{}
+	hasConst             bool
+	defaultValue         interface{}
+	hasDefault           bool
+	minimum              *float64
+	maximum              *float64
+	exclusiveMinimum     *float64
+	exclusiveMaximum     *float64
+	minLength            *int
+	maxLength            *int
+	pattern              *regexp.Regexp
+	minItems             *int
+	maxItems             *int
+	format               string
+}
+
+// rawSchema Schema 的 JSON 结构
+type rawSchema struct {
+	Type                 json.RawMessage            `json:"type"`
+	Properties           map[string]json.RawMessage `json:"properties"`
+	Required             []string                   `json:"required"`
+	AdditionalProperties json.RawMessage            `json:"additionalProperties"`
+	Items                json.RawMessage            `json:"items"`
+	Enum                 []interface{}              `json:"enum"`
+	Const                json.RawMessage            `json:"const"`
+	Default              json.RawMessage            `json:"default"`
+	Minimum              *float64                   `json:"minimum"`
+	Maximum              *float64                   `json:"maximum"`
+	ExclusiveMinimum     *float64                   `json:"exclusiveMinimum"`
+	ExclusiveMaximum     *float64                   `json:"exclusiveMaximum"`
+	MinLength            *int                       `json:"minLength"`
+	MaxLength            *int                       `json:"maxLength"`
+	Pattern              string                     `json:"pattern"`
+	MinItems             *int                       `json:"minItems"`
+	MaxItems             *int                       `json:"maxItems"`
+	Format               string                     `json:"format"`
+}
+
+// schemaTypes 支持的类型关键字
+var schemaTypes = map[string]bool{
+	"object": true, "array": true, "string": true, "number": true,
+	"integer": true, "boolean": true, "null": true,
+}
+
+// CompileSettingsSchema 编译 JSON Schema，根节点须描述对象
+func CompileSettingsSchema(raw []byte) (*SettingsSchema, error) {
+	root, err := compileSchemaNode(raw, "#")
+	if err != nil {
+		return nil, err
+	}
+	if len(root.types) > 0 && !(len(root.types) == 1 && root.types[0] == "object") {
+		return nil, fmt.Errorf("settings schema root must be of type object")
+	}
+	return &SettingsSchema{root: root}, nil
+}
+
+func compileSchemaNode(raw json.RawMessage, path string) (*schemaNode, error) {
+	var rs rawSchema
+	if err := json.Unmarshal(raw, &rs); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	node := &schemaNode{
+		required:         rs.Required,
+		enum:             rs.Enum,
+		minimum:          rs.Minimum,
+		maximum:          rs.Maximum,
+		exclusiveMinimum: rs.ExclusiveMinimum,
+		exclusiveMaximum: rs.ExclusiveMaximum,
+		minLength:        rs.MinLength,
+		maxLength:        rs.MaxLength,
+		minItems:         rs.MinItems,
+		maxItems:         rs.MaxItems,
+		format:           rs.Format,
+	}
+
+	if len(rs.Type) > 0 {
+		var single string
+		if err := json.Unmarshal(rs.Type, &single); err == nil {
+			node.types = []string{single}
+		} else if err := json.Unmarshal(rs.Type, &node.types); err != nil {
+			return nil, fmt.Errorf("%s/type: must be a string or an array of strings", path)
+		}
+		for _, t := range node.types {
+			if !schemaTypes[t] {
+				return nil, fmt.Errorf("%s/type: unknown type %q", path, t)
+			}
+		}
+	}
+
+	if len(rs.Properties) > 0 {
+		node.properties = make(map[string]*schemaNode, len(rs.Properties))
+		for name, sub := range rs.Properties {
+			child, err := compileSchemaNode(sub, path+"/properties/"+name)
+			if err != nil {
+				return nil, err
+			}
+			node.properties[name] = child
+		}
+	}
+
+	if len(rs.AdditionalProperties) > 0 {
+		var allowed bool
+		if err := json.Unmarshal(rs.AdditionalProperties, &allowed); err == nil {
+			node.noAdditional = !allowed
+		} else {
+			child, err := compileSchemaNode(rs.AdditionalProperties, path+"/additionalProperties")
+			if err != nil {
+				return nil, err
+			}
+			node.additionalProperties = child
+		}
+	}
+
+	if len(rs.Items) > 0 {
+		child, err := compileSchemaNode(rs.Items, path+"/items")
+		if err != nil {
+			return nil, err
+		}
+		node.items = child
+	}
+
+	if len(rs.Const) > 0 {
+		if err := json.Unmarshal(rs.Const, &node.constValue); err != nil {
+			return nil, fmt.Errorf("%s/const: %w", path, err)
+		}
+		node.hasConst = true
+	}
+
+	if len(rs.Default) > 0 {
+		if err := json.Unmarshal(rs.Default, &node.defaultValue); err != nil {
+			return nil, fmt.Errorf("%s/default: %w", path, err)
+		}
+		node.hasDefault = true
+	}
+
+	if rs.Pattern != "" {
+		re, err := regexp.Compile(rs.Pattern)
+		if err != nil {
+			return nil, fmt.Errorf("%s/pattern: %w", path, err)
+		}
+		node.pattern = re
+	}
+
+	return node, nil
+}
+
+// FieldError 单个配置项的校验错误
+type FieldError struct {
+	// Field 配置项路径，如 limits.max、hosts[0]
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// SettingsError 配置校验失败，列出全部字段错误
+type SettingsError struct {
+	Fields []FieldError `json:"fields"`
+}
+
+func (e *SettingsError) Error() string {
+	msgs := make([]string, 0, len(e.Fields))
+	for _, f := range e.Fields {
+		msgs = append(msgs, f.Field+": "+f.Message)
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// Apply 校验配置并填充默认值，返回填充后的副本，不修改传入的配置
+// 校验失败时返回 *SettingsError；schema 为 nil 时原样返回
+func (s *SettingsSchema) Apply(settings map[string]interface{}) (map[string]interface{}, error) {
+	if s == nil {
+		return settings, nil
+	}
+
+	var value interface{} = map[string]interface{}{}
+	if settings != nil {
+		value = normalizeSettingValue(settings)
+	}
+
+	v := &schemaValidator{}
+	value = v.apply(s.root, value, "")
+	if len(v.errors) > 0 {
+		sort.SliceStable(v.errors, func(i, j int) bool { return v.errors[i].Field < v.errors[j].Field })
+		return nil, &SettingsError{Fields: v.errors}
+	}
+	return value.(map[string]interface{}), nil
+}
+
+// schemaValidator 收集校验过程中的字段错误
+type schemaValidator struct {
+	errors []FieldError
+}
+
+func (v *schemaValidator) fail(field, format string, args ...interface{}) {
+	if field == "" {
+		field = "settings"
+	}
+	v.errors = append(v.errors, FieldError{Field: field, Message: fmt.Sprintf(format, args...)})
+}
+
+// apply 校验 value 并返回填充默认值后的结果
+func (v *schemaValidator) apply(node *schemaNode, value interface{}, field string) interface{} {
+	if len(node.types) > 0 && !matchesAnyType(value, node.types) {
+		v.fail(field, "must be of type %s", strings.Join(node.types, " or "))
+		return value
+	}
+
+	if node.hasConst && !reflect.DeepEqual(value, node.constValue) {
+		v.fail(field, "must be %v", node.constValue)
+	}
+	if len(node.enum) > 0 {
+		found := false
+		for _, allowed := range node.enum {
+			if reflect.DeepEqual(value, allowed) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			v.fail(field, "must be one of %v", node.enum)
+		}
+	}
+
+	switch val := value.(type) {
+	case map[string]interface{}:
+		return v.applyObject(node, val, field)
+	case []interface{}:
+		return v.applyArray(node, val, field)
+	case string:
+		v.checkString(node, val, field)
+	case float64:
+		v.checkNumber(node, val, field)
+	}
+	return value
+}
+
+func (v *schemaValidator) applyObject(node *schemaNode, obj map[string]interface{}, field string) interface{} {
+	out := make(map[string]interface{}, len(obj)+len(node.properties))
+	for k, val := range obj {
+		out[k] = val
+	}
+
+	// 先填充默认值，带默认值的必填项视为已提供
+	for name, prop := range node.properties {
+		if _, ok := out[name]; !ok && prop.hasDefault {
+			out[name] = normalizeSettingValue(prop.defaultValue)
+		}
+	}
+
+	for _, name := range node.required {
+		if _, ok := out[name]; !ok {
+			v.fail(joinField(field, name), "is required")
+		}
+	}
+
+	for name, val := range out {
+		child := joinField(field, name)
+		if prop, ok := node.properties[name]; ok {
+			out[name] = v.apply(prop, val, child)
+			continue
+		}
+		switch {
+		case node.noAdditional:
+			v.fail(child, "is not allowed")
+		case node.additionalProperties != nil:
+			out[name] = v.apply(node.additionalProperties, val, child)
+		}
+	}
+	return out
+}
+
+func (v *schemaValidator) applyArray(node *schemaNode, arr []interface{}, field string) interface{} {
+	if node.minItems != nil && len(arr) < *node.minItems {
+		v.fail(field, "must contain at least %d items", *node.minItems)
+	}
+	if node.maxItems != nil && len(arr) > *node.maxItems {
+		v.fail(field, "must contain at most %d items", *node.maxItems)
+	}
+	if node.items == nil {
+		return arr
+	}
+
+	out := make([]interface{}, len(arr))
+	for i, item := range arr {
+		out[i] = v.apply(node.items, item, field+"["+strconv.Itoa(i)+"]")
+	}
+	return out
+}
+
+func (v *schemaValidator) checkString(node *schemaNode, s string, field string) {
+	length := utf8.RuneCountInString(s)
+	if node.minLength != nil && length < *node.minLength {
+		v.fail(field, "must be at least %d characters", *node.minLength)
+	}
+	if node.maxLength != nil && length > *node.maxLength {
+		v.fail(field, "must be at most %d characters", *node.maxLength)
+	}
+	if node.pattern != nil && !node.pattern.MatchString(s) {
+		v.fail(field, "must match pattern %s", node.pattern)
+	}
+	if node.format == "duration" {
+		if _, err := time.ParseDuration(s); err != nil {
+			v.fail(field, "must be a duration such as 30s or 5m")
+		}
+	}
+}
+
+func (v *schemaValidator) checkNumber(node *schemaNode, n float64, field string) {
+	if node.minimum != nil && n < *node.minimum {
+		v.fail(field, "must be >= %v", *node.minimum)
+	}
+	if node.maximum != nil && n > *node.maximum {
+		v.fail(field, "must be <= %v", *node.maximum)
+	}
+	if node.exclusiveMinimum != nil && n <= *node.exclusiveMinimum {
+		v.fail(field, "must be > %v", *node.exclusiveMinimum)
+	}
+	if node.exclusiveMaximum != nil && n >= *node.exclusiveMaximum {
+		v.fail(field, "must be < %v", *node.exclusiveMaximum)
+	}
+}
+
+// matchesAnyType 检查值是否属于任一类型
+func matchesAnyType(value interface{}, types []string) bool {
+	for _, t := range types {
+		switch val := value.(type) {
+		case nil:
+			if t == "null" {
+				return true
+			}
+		case bool:
+			if t == "boolean" {
+				return true
+			}
+		case string:
+			if t == "string" {
+				return true
+			}
+		case float64:
+			if t == "number" || (t == "integer" && val == math.Trunc(val)) {
+				return true
+			}
+		case []interface{}:
+			if t == "array" {
+				return true
+			}
+		case map[string]interface{}:
+			if t == "object" {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// normalizeSettingValue 将 YAML 或 Go 值转换为 JSON 数据模型：
+// 对象为 map[string]interface{}，数组为 []interface{}，数字为 float64
+func normalizeSettingValue(value interface{}) interface{} {
+	switch val := value.(type) {
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(val))
+		for k, item := range val {
+			out[k] = normalizeSettingValue(item)
+		}
+		return out
+	case map[interface{}]interface{}:
+		out := make(map[string]interface{}, len(val))
+		for k, item := range val {
+			out[fmt.Sprint(k)] = normalizeSettingValue(item)
+		}
+		return out
+	case []interface{}:
+		out := make([]interface{}, len(val))
+		for i, item := range val {
+			out[i] = normalizeSettingValue(item)
+		}
+		return out
+	case []string:
+		out := make([]interface{}, len(val))
+		for i, item := range val {
+			out[i] = item
+		}
+		return out
+	case int:
+		return float64(val)
+	case int8:
+		return float64(val)
+	case int16:
+		return float64(val)
+	case int32:
+		return float64(val)
+	case int64:
+		return float64(val)
+	case uint:
+		return float64(val)
+	case uint8:
+		return float64(val)
+	case uint16:
+		return float64(val)
+	case uint32:
+		return float64(val)
+	case uint64:
+		return float64(val)
+	case float32:
+		return float64(val)
+	default:
+		return value
+	}
+}
+
+func joinField(parent, name string) string {
+	if parent == "" {
+		return name
+	}
+	return parent + "." + name
+}
+
+// pluginSettingsSchema 编译插件声明的配置 Schema，插件未声明时使用签名清单中的 Schema
+func pluginSettingsSchema(p Plugin, manifest *PluginManifest) (*SettingsSchema, error) {
+	var raw []byte
+	if sp, ok := p.(SchemaPlugin); ok {
+		raw = sp.SettingsSchema()
+	}
+	if len(raw) == 0 && manifest != nil {
+		raw = manifest.SettingsSchema
+	}
+	if len(raw) == 0 {
+		return nil, nil
+	}
+	return CompileSettingsSchema(raw)
+}
+
+// applySettingsSchema 按 Schema 校验插件配置，返回填充默认值后的配置
+func applySettingsSchema(schema *SettingsSchema, name string, config PluginConfig) (PluginConfig, error) {
+	settings, err := schema.Apply(config.Settings)
+	if err != nil {
+		return config, NewPluginError(ErrCodePluginConfigError, "invalid plugin settings", name, err)
+	}
+	config.Settings = settings
+	return config, nil
+}
@@ -0,0 +1,146 @@
+package plugin
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testSettingsSchema = `{
+	"type": "object",
+	"required": ["endpoint"],
+	"additionalProperties": false,
+	"properties": {
+		"endpoint": {"type": "string", "pattern": "^https?://"},
+		"mode": {"enum": ["sync", "async"], "default": "async"},
+		"interval": {"type": "string", "format": "duration", "default": "30s"},
+		"retries": {"type": "integer", "minimum": 0, "maximum": 5, "default": 3},
+		"ratio": {"type": "number", "exclusiveMaximum": 1},
+		"tags": {"type": "array", "items": {"type": "string", "minLength": 1}, "maxItems": 2},
+		"limits": {
+			"type": "object",
+			"properties": {"max": {"type": "integer", "default": 10}},
+			"additionalProperties": {"type": "integer"}
+		}
+	}
+}`
+
+func TestSettingsSchemaApply(t *testing.T) {
+	schema, err := CompileSettingsSchema([]byte(testSettingsSchema))
+	require.NoError(t, err)
+
+	input := map[string]interface{}{
+		"endpoint": "https://example.com",
+		"limits":   map[interface{}]interface{}{"burst": 5},
+		"tags":     []interface{}{"a"},
+	}
+	settings, err := schema.Apply(input)
+	require.NoError(t, err)
+	assert.Equal(t, "async", settings["mode"])
+	assert.Equal(t, "30s", settings["interval"])
+	assert.Equal(t, float64(3), settings["retries"])
+	assert.Equal(t, map[string]interface{}{"burst": float64(5), "max": float64(10)}, settings["limits"])
+	assert.NotContains(t, input, "mode", "input must not be modified")
+
+	_, err = schema.Apply(map[string]interface{}{
+		"endpoint": "ftp://example.com",
+		"mode":     "batch",
+		"interval": "soon",
+		"retries":  1.5,
+		"ratio":    1,
+		"tags":     []interface{}{"a", "", "c"},
+		"limits":   map[string]interface{}{"burst": "many"},
+		"unknown":  true,
+	})
+	var se *SettingsError
+	require.True(t, errors.As(err, &se), "unexpected error: %v", err)
+
+	fields := make(map[string]string)
+	for _, f := range se.Fields {
+		fields[f.Field] = f.Message
+	}
+	assert.Contains(t, fields["endpoint"], "pattern")
+	assert.Contains(t, fields["mode"], "one of")
+	assert.Contains(t, fields["interval"], "duration")
+	assert.Contains(t, fields["retries"], "integer")
+	assert.Contains(t, fields["ratio"], "< 1")
+	assert.Contains(t, fields["tags"], "at most 2")
+	assert.Contains(t, fields["tags[1]"], "at least 1")
+	assert.Contains(t, fields["limits.burst"], "integer")
+	assert.Contains(t, fields["unknown"], "not allowed")
+
+	_, err = schema.Apply(nil)
+	require.True(t, errors.As(err, &se))
+	assert.Equal(t, []FieldError{{Field: "endpoint", Message: "is required"}}, se.Fields)
+}
+
+func TestCompileSettingsSchemaErrors(t *testing.T) {
+	for _, raw := range []string{
+		`{"type": "array"}`,
+		`{"properties": {"a": {"type": "decimal"}}}`,
+		`{"properties": {"a": {"pattern": "("}}}`,
+		`not json`,
+	} {
+		_, err := CompileSettingsSchema([]byte(raw))
+		assert.Error(t, err, raw)
+	}
+
+	var schema *SettingsSchema
+	settings := map[string]interface{}{"a": 1}
+	out, err := schema.Apply(settings)
+	require.NoError(t, err)
+	assert.Equal(t, settings, out)
+}
+
+func TestPluginSettingsValidation(t *testing.T) {
+	pm, hm, _ := newProcessTestManager(t)
+	path := installPlugin(t, pm.pluginDir, "fixture")
+	defer func() { _ = pm.UnloadPlugin("fixture") }()
+
+	// 加载时校验配置文件
+	configPath := filepath.Join(pm.configDir, "fixture.yaml")
+	require.NoError(t, os.WriteFile(configPath, []byte("enabled: true\nsettings:\n  limit: 0\n"), 0644))
+	err := pm.LoadPlugin(path)
+	var pe *PluginError
+	require.True(t, errors.As(err, &pe), "unexpected error: %v", err)
+	assert.Equal(t, ErrCodePluginConfigError, pe.Code)
+	var se *SettingsError
+	require.True(t, errors.As(err, &se))
+	assert.Equal(t, "limit", se.Fields[0].Field)
+
+	// 默认值在插件初始化前填充
+	require.NoError(t, os.WriteFile(configPath, []byte("enabled: true\nsettings:\n  limit: 2\n"), 0644))
+	require.NoError(t, pm.LoadPlugin(path))
+	require.NoError(t, pm.StartPlugin("fixture"))
+
+	config, err := pm.GetPluginConfig("fixture")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"greeting": "hello", "limit": float64(2)}, config.Settings)
+
+	data := NewHookData(context.Background(), map[string]interface{}{})
+	require.NoError(t, hm.ExecuteHooks(context.Background(), HookPointBeforeRequest, data))
+	assert.Equal(t, "hello", data.GetMetadata()["greeting"])
+
+	// 更新配置时校验，失败时保留原配置
+	invalid := config
+	invalid.Settings = map[string]interface{}{"greeting": 42}
+	err = pm.UpdatePluginConfig("fixture", invalid)
+	require.True(t, errors.As(err, &se), "unexpected error: %v", err)
+	assert.Equal(t, "greeting", se.Fields[0].Field)
+
+	current, err := pm.GetPluginConfig("fixture")
+	require.NoError(t, err)
+	assert.Equal(t, config.Settings, current.Settings)
+
+	valid := config
+	valid.Settings = map[string]interface{}{"greeting": "hi"}
+	require.NoError(t, pm.UpdatePluginConfig("fixture", valid))
+	data = NewHookData(context.Background(), map[string]interface{}{})
+	require.NoError(t, hm.ExecuteHooks(context.Background(), HookPointBeforeRequest, data))
+	assert.Equal(t, "hi", data.GetMetadata()["greeting"])
+}
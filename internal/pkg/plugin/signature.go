@@ -0,0 +1,258 @@
+package plugin
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"kratos-boilerplate/internal/pkg/crypto"
+
+	"gopkg.in/yaml.v2"
+)
+
+// 插件制品签名
+//
+// 每个插件制品旁放置两个文件：
+//
+//	<artifact>.manifest.json  清单，记录插件名称、版本、制品摘要、所需权限与发布者
+//	<artifact>.sig            发布者私钥对清单文件原始字节的 ed25519 分离签名（base64）
+//
+// 宿主通过信任库中发布者的公钥验证签名，再核对制品摘要，
+// 插件启动后还会核对插件自报的名称与版本是否与清单一致。
+const (
+	// ManifestSuffix 清单文件后缀
+	ManifestSuffix = ".manifest.json"
+	// SignatureSuffix 签名文件后缀
+	SignatureSuffix = ".sig"
+
+	// hashAlgorithm 制品摘要算法前缀
+	hashAlgorithm = "sha256:"
+)
+
+// PluginManifest 插件制品清单
+type PluginManifest struct {
+	Name        string   `json:"name"`
+	Version     string   `json:"version"`
+	Hash        string   `json:"hash"`
+	Permissions []string `json:"permissions,omitempty"`
+	Publisher   string   `json:"publisher"`
+	// SettingsSchema 插件配置的 JSON Schema，插件自身未声明 Schema 时使用
+	SettingsSchema json.RawMessage `json:"settings_schema,omitempty"`
+}
+
+// ManifestPath 插件制品的清单文件路径
+func ManifestPath(artifact string) string {
+	return artifact + ManifestSuffix
+}
+
+// SignaturePath 插件制品的签名文件路径
+func SignaturePath(artifact string) string {
+	return artifact + SignatureSuffix
+}
+
+// isSignatureFile 是否为清单或签名文件
+func isSignatureFile(name string) bool {
+	return strings.HasSuffix(name, ManifestSuffix) || strings.HasSuffix(name, SignatureSuffix)
+}
+
+// artifactPath 清单或签名文件对应的插件制品路径
+func artifactPath(path string) string {
+	if strings.HasSuffix(path, ManifestSuffix) {
+		return strings.TrimSuffix(path, ManifestSuffix)
+	}
+	return strings.TrimSuffix(path, SignatureSuffix)
+}
+
+// match 检查插件自报的名称与版本是否与清单一致
+func (m *PluginManifest) match(p Plugin) error {
+	if m == nil {
+		return nil
+	}
+	if p.Name() != m.Name {
+		return fmt.Errorf("plugin reports name %q, manifest declares %q", p.Name(), m.Name)
+	}
+	if p.Version() != m.Version {
+		return fmt.Errorf("plugin reports version %q, manifest declares %q", p.Version(), m.Version)
+	}
+	return nil
+}
+
+// fillManifest 将清单中的发布者与权限写入元数据
+func fillManifest(meta *PluginMetadata, manifest *PluginManifest) {
+	meta.Publisher = ""
+	meta.Permissions = nil
+	if manifest != nil {
+		meta.Publisher = manifest.Publisher
+		meta.Permissions = manifest.Permissions
+	}
+}
+
+// TrustedPublisher 信任库中的发布者
+type TrustedPublisher struct {
+	Name string `yaml:"name" json:"name"`
+	// PublicKey base64 编码的 ed25519 公钥
+	PublicKey string `yaml:"public_key" json:"public_key"`
+	// Permissions 允许该发布者的插件申请的权限，为空时不限制
+	Permissions []string `yaml:"permissions" json:"permissions,omitempty"`
+}
+
+// trustStoreFile 信任库文件格式
+type trustStoreFile struct {
+	Publishers []TrustedPublisher `yaml:"publishers"`
+}
+
+// TrustStore 插件发布者信任库
+type TrustStore struct {
+	publishers map[string]trustedKey
+}
+
+// trustedKey 已解析的发布者公钥
+type trustedKey struct {
+	key         ed25519.PublicKey
+	permissions map[string]bool
+}
+
+// NewTrustStore 根据发布者列表创建信任库
+func NewTrustStore(publishers ...TrustedPublisher) (*TrustStore, error) {
+	ts := &TrustStore{publishers: make(map[string]trustedKey, len(publishers))}
+	for _, p := range publishers {
+		if p.Name == "" {
+			return nil, fmt.Errorf("trusted publisher name is empty")
+		}
+		if _, exists := ts.publishers[p.Name]; exists {
+			return nil, fmt.Errorf("duplicate trusted publisher %s", p.Name)
+		}
+		key, err := crypto.DecodePublicKey(p.PublicKey)
+		if err != nil {
+			return nil, fmt.Errorf("trusted publisher %s: %w", p.Name, err)
+		}
+
+		var permissions map[string]bool
+		if len(p.Permissions) > 0 {
+			permissions = make(map[string]bool, len(p.Permissions))
+			for _, perm := range p.Permissions {
+				permissions[perm] = true
+			}
+		}
+		ts.publishers[p.Name] = trustedKey{key: key, permissions: permissions}
+	}
+	return ts, nil
+}
+
+// LoadTrustStore 从 YAML 文件加载信任库
+func LoadTrustStore(path string) (*TrustStore, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read trust store: %w", err)
+	}
+
+	var file trustStoreFile
+	if err := yaml.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("parse trust store %s: %w", path, err)
+	}
+	return NewTrustStore(file.Publishers...)
+}
+
+// Publishers 返回信任的发布者名称
+func (ts *TrustStore) Publishers() []string {
+	names := make([]string, 0, len(ts.publishers))
+	for name := range ts.publishers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Verify 验证插件制品的清单签名、发布者权限与制品摘要，返回通过验证的清单
+func (ts *TrustStore) Verify(artifact string) (*PluginManifest, error) {
+	content, err := os.ReadFile(artifact)
+	if err != nil {
+		return nil, fmt.Errorf("read artifact: %w", err)
+	}
+	return ts.VerifyContent(artifact, content)
+}
+
+// VerifyContent 按 artifact 旁的清单与签名验证已读入的制品内容。
+// 调用方随后应使用 content 而不是重新读取 artifact，避免验证后文件被替换
+func (ts *TrustStore) VerifyContent(artifact string, content []byte) (*PluginManifest, error) {
+	raw, err := os.ReadFile(ManifestPath(artifact))
+	if err != nil {
+		return nil, fmt.Errorf("read manifest: %w", err)
+	}
+	encoded, err := os.ReadFile(SignaturePath(artifact))
+	if err != nil {
+		return nil, fmt.Errorf("read signature: %w", err)
+	}
+	signature, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(encoded)))
+	if err != nil {
+		return nil, fmt.Errorf("decode signature: %w", err)
+	}
+
+	var manifest PluginManifest
+	if err := json.Unmarshal(raw, &manifest); err != nil {
+		return nil, fmt.Errorf("parse manifest: %w", err)
+	}
+
+	publisher, ok := ts.publishers[manifest.Publisher]
+	if !ok {
+		return nil, fmt.Errorf("publisher %q is not trusted", manifest.Publisher)
+	}
+	if !ed25519.Verify(publisher.key, raw, signature) {
+		return nil, fmt.Errorf("manifest signature does not match publisher %s", manifest.Publisher)
+	}
+
+	if publisher.permissions != nil {
+		for _, perm := range manifest.Permissions {
+			if !publisher.permissions[perm] {
+				return nil, fmt.Errorf("publisher %s is not allowed to request permission %q", manifest.Publisher, perm)
+			}
+		}
+	}
+
+	sum := sha256.Sum256(content)
+	if manifest.Hash != hashAlgorithm+hex.EncodeToString(sum[:]) {
+		return nil, fmt.Errorf("artifact hash %s%s does not match manifest %s", hashAlgorithm, hex.EncodeToString(sum[:]), manifest.Hash)
+	}
+
+	return &manifest, nil
+}
+
+// SignArtifact 计算制品摘要，写入清单并用发布者私钥生成分离签名
+// manifest 中的 Hash 字段由制品内容计算得出
+func SignArtifact(artifact string, manifest PluginManifest, key ed25519.PrivateKey) error {
+	if manifest.Name == "" || manifest.Version == "" || manifest.Publisher == "" {
+		return fmt.Errorf("manifest name, version and publisher are required")
+	}
+	if _, err := ParseVersion(manifest.Version); err != nil {
+		return err
+	}
+
+	sum, err := fileChecksum(artifact)
+	if err != nil {
+		return fmt.Errorf("hash artifact: %w", err)
+	}
+	manifest.Hash = hashAlgorithm + sum
+
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(manifest); err != nil {
+		return err
+	}
+
+	signature := ed25519.Sign(key, buf.Bytes())
+	if err := os.WriteFile(ManifestPath(artifact), buf.Bytes(), 0644); err != nil {
+		return fmt.Errorf("write manifest: %w", err)
+	}
+	if err := os.WriteFile(SignaturePath(artifact), []byte(base64.StdEncoding.EncodeToString(signature)+"\n"), 0644); err != nil {
+		return fmt.Errorf("write signature: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,199 @@
+package plugin
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"kratos-boilerplate/internal/pkg/crypto"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestPublisher 生成测试发布者的信任库条目与私钥
+func newTestPublisher(t *testing.T, name string, permissions ...string) (TrustedPublisher, string) {
+	t.Helper()
+	pub, priv, err := crypto.GenerateSigningKey()
+	require.NoError(t, err)
+	return TrustedPublisher{Name: name, PublicKey: pub, Permissions: permissions}, priv
+}
+
+func signTestArtifact(t *testing.T, artifact, privateKey string, manifest PluginManifest) {
+	t.Helper()
+	key, err := crypto.DecodePrivateKey(privateKey)
+	require.NoError(t, err)
+	require.NoError(t, SignArtifact(artifact, manifest, key))
+}
+
+func TestTrustStoreVerify(t *testing.T) {
+	acme, acmeKey := newTestPublisher(t, "acme", "hooks", "events")
+	_, otherKey := newTestPublisher(t, "other")
+	ts, err := NewTrustStore(acme)
+	require.NoError(t, err)
+
+	artifact := filepath.Join(t.TempDir(), "demo.wasm")
+	require.NoError(t, os.WriteFile(artifact, []byte("module"), 0644))
+	manifest := PluginManifest{Name: "demo", Version: "1.0.0", Permissions: []string{"hooks"}, Publisher: "acme"}
+
+	// 缺少签名
+	_, err = ts.Verify(artifact)
+	assert.Error(t, err)
+
+	signTestArtifact(t, artifact, acmeKey, manifest)
+	verified, err := ts.Verify(artifact)
+	require.NoError(t, err)
+	assert.Equal(t, "demo", verified.Name)
+	assert.Contains(t, verified.Hash, "sha256:")
+
+	// 篡改清单
+	raw, err := os.ReadFile(ManifestPath(artifact))
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(ManifestPath(artifact), append(raw, ' '), 0644))
+	_, err = ts.Verify(artifact)
+	assert.ErrorContains(t, err, "signature does not match")
+
+	// 篡改制品
+	signTestArtifact(t, artifact, acmeKey, manifest)
+	require.NoError(t, os.WriteFile(artifact, []byte("tampered"), 0644))
+	_, err = ts.Verify(artifact)
+	assert.ErrorContains(t, err, "does not match manifest")
+
+	// 冒用发布者
+	signTestArtifact(t, artifact, otherKey, manifest)
+	_, err = ts.Verify(artifact)
+	assert.ErrorContains(t, err, "signature does not match")
+
+	// 未信任的发布者
+	manifest.Publisher = "other"
+	signTestArtifact(t, artifact, otherKey, manifest)
+	_, err = ts.Verify(artifact)
+	assert.ErrorContains(t, err, "not trusted")
+
+	// 超出发布者允许的权限
+	manifest.Publisher = "acme"
+	manifest.Permissions = []string{"hooks", "network"}
+	signTestArtifact(t, artifact, acmeKey, manifest)
+	_, err = ts.Verify(artifact)
+	assert.ErrorContains(t, err, `permission "network"`)
+}
+
+func TestLoadTrustStore(t *testing.T) {
+	acme, _ := newTestPublisher(t, "acme")
+	path := filepath.Join(t.TempDir(), "trust.yaml")
+	require.NoError(t, os.WriteFile(path, []byte("publishers:\n  - name: acme\n    public_key: "+acme.PublicKey+"\n    permissions: [hooks]\n"), 0644))
+
+	ts, err := LoadTrustStore(path)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"acme"}, ts.Publishers())
+
+	_, err = NewTrustStore(TrustedPublisher{Name: "bad", PublicKey: "c2hvcnQ="})
+	assert.Error(t, err)
+}
+
+func TestLoadPluginVerifiesSignature(t *testing.T) {
+	acme, acmeKey := newTestPublisher(t, "acme")
+	ts, err := NewTrustStore(acme)
+	require.NoError(t, err)
+
+	pm, _, _ := newProcessTestManager(t)
+	pm.trustStore = ts
+	path := installPlugin(t, pm.pluginDir, "fixture")
+	defer func() { _ = pm.UnloadPlugin("fixture") }()
+
+	assertRejected := func(reason string) {
+		t.Helper()
+		err := pm.LoadPlugin(path)
+		var pe *PluginError
+		require.True(t, errors.As(err, &pe), "unexpected error: %v", err)
+		assert.Equal(t, ErrCodePluginPermission, pe.Code)
+		assert.ErrorContains(t, err, reason)
+
+		status, err := pm.GetPluginStatus("fixture")
+		require.NoError(t, err)
+		assert.Equal(t, PluginStatusError, status)
+		require.Len(t, pm.ListPlugins(), 1)
+		assert.Contains(t, pm.ListPlugins()[0].ErrorMsg, reason)
+		assert.Error(t, pm.StartPlugin("fixture"))
+	}
+
+	// 未签名的制品在启动插件进程前被拒绝
+	assertRejected("read manifest")
+
+	// 签名有效但清单与插件自报的版本不一致
+	signTestArtifact(t, path, acmeKey, PluginManifest{Name: "fixture", Version: "9.9.9", Publisher: "acme"})
+	assertRejected(`manifest declares "9.9.9"`)
+
+	signTestArtifact(t, path, acmeKey, PluginManifest{Name: "fixture", Version: "1.2.3", Permissions: []string{"hooks"}, Publisher: "acme"})
+	require.NoError(t, pm.LoadPlugin(path))
+
+	plugins := pm.ListPlugins()
+	require.Len(t, plugins, 1)
+	assert.Equal(t, PluginStatusLoaded, plugins[0].Status)
+	assert.Equal(t, "acme", plugins[0].Metadata.Publisher)
+	assert.Equal(t, []string{"hooks"}, plugins[0].Metadata.Permissions)
+	require.NoError(t, pm.StartPlugin("fixture"))
+
+	// 重新加载未重新签名的新制品时保留运行中的版本
+	require.NoError(t, os.WriteFile(SignaturePath(path), []byte("AAAA\n"), 0644))
+	err = pm.ReloadPlugin("fixture")
+	var pe *PluginError
+	require.True(t, errors.As(err, &pe))
+	assert.Equal(t, ErrCodePluginPermission, pe.Code)
+	status, err := pm.GetPluginStatus("fixture")
+	require.NoError(t, err)
+	assert.Equal(t, PluginStatusStarted, status)
+}
+
+func TestRestartPluginVerifiesSignature(t *testing.T) {
+	acme, acmeKey := newTestPublisher(t, "acme")
+	ts, err := NewTrustStore(acme)
+	require.NoError(t, err)
+
+	pm, _, _ := newProcessTestManager(t)
+	pm.trustStore = ts
+	path := installPlugin(t, pm.pluginDir, "fixture")
+	defer func() { _ = pm.UnloadPlugin("fixture") }()
+
+	signTestArtifact(t, path, acmeKey, PluginManifest{Name: "fixture", Version: "1.2.3", Publisher: "acme"})
+	require.NoError(t, pm.LoadPlugin(path))
+	require.NoError(t, pm.StartPlugin("fixture"))
+
+	// 执行的是通过验证的暂存副本而不是插件目录中的文件
+	pm.mu.RLock()
+	first := pm.plugins["fixture"].process
+	pm.mu.RUnlock()
+	assert.NotEqual(t, path, first.path)
+	assert.Equal(t, pm.stageDir, filepath.Dir(first.path))
+
+	// 签名有效时崩溃后重启，旧的暂存副本被删除
+	first.kill()
+	require.Eventually(t, func() bool {
+		pm.mu.RLock()
+		defer pm.mu.RUnlock()
+		wrapper := pm.plugins["fixture"]
+		return wrapper.restarts == 1 && wrapper.info.Status == PluginStatusStarted
+	}, 10*time.Second, 20*time.Millisecond)
+	assert.NoFileExists(t, first.path)
+
+	// 签名失效后崩溃不再重启，插件保持错误状态
+	require.NoError(t, os.WriteFile(SignaturePath(path), []byte("AAAA\n"), 0644))
+	pm.mu.RLock()
+	second := pm.plugins["fixture"].process
+	pm.mu.RUnlock()
+	second.kill()
+	require.Eventually(t, func() bool {
+		pm.mu.RLock()
+		defer pm.mu.RUnlock()
+		wrapper := pm.plugins["fixture"]
+		return wrapper.info.Status == PluginStatusError && strings.Contains(wrapper.info.ErrorMsg, "restart refused")
+	}, 10*time.Second, 20*time.Millisecond)
+
+	pm.mu.RLock()
+	defer pm.mu.RUnlock()
+	assert.Equal(t, 1, pm.plugins["fixture"].restarts)
+	assert.Same(t, second, pm.plugins["fixture"].process)
+}
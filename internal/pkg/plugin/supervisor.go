@@ -0,0 +1,240 @@
+package plugin
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/go-kratos/kratos/v2/log"
+)
+
+// errRestartSuperseded 重启期间插件已被卸载或由重新加载替换
+var errRestartSuperseded = errors.New("plugin process superseded during restart")
+
+// pluginNameFromPath 从插件文件路径推导插件名（去掉扩展名）
+func pluginNameFromPath(path string) string {
+	base := filepath.Base(path)
+	return strings.TrimSuffix(base, filepath.Ext(base))
+}
+
+// launchPlugin 启动插件进程并建立 RPC 代理
+func (pm *pluginManagerImpl) launchPlugin(path string, config PluginConfig) (*pluginProcess, *rpcPlugin, error) {
+	proc, err := startPluginProcess(path, defaultHandshakeTimeout, pm.logger)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), defaultHandshakeTimeout)
+	defer cancel()
+
+	remote, err := newRPCPlugin(ctx, proc.conn, config.Timeout)
+	if err != nil {
+		proc.shutdown(defaultShutdownGrace)
+		return nil, nil, err
+	}
+
+	return proc, remote, nil
+}
+
+// superviseProcess 监控插件进程：定期健康检查，并在进程意外退出时触发重启
+func (pm *pluginManagerImpl) superviseProcess(wrapper *pluginWrapper, proc *pluginProcess) {
+	ticker := time.NewTicker(pm.healthInterval)
+	defer ticker.Stop()
+
+	failures := 0
+	for {
+		select {
+		case <-proc.exited:
+			if !proc.stopping.Load() {
+				pm.handleProcessExit(wrapper, proc)
+			}
+			return
+		case <-ticker.C:
+			if proc.stopping.Load() {
+				return
+			}
+			failures = pm.checkProcessHealth(wrapper, proc, failures)
+		}
+	}
+}
+
+// checkProcessHealth 对运行中的插件执行健康检查，返回连续失败次数
+func (pm *pluginManagerImpl) checkProcessHealth(wrapper *pluginWrapper, proc *pluginProcess, failures int) int {
+	pm.mu.RLock()
+	active := wrapper.process == proc && (wrapper.info.Status == PluginStatusStarted || wrapper.unhealthy)
+	pm.mu.RUnlock()
+	if !active {
+		return 0
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), pm.healthInterval)
+	err := wrapper.remote.HealthCheck(ctx)
+	cancel()
+
+	pm.mu.Lock()
+	defer pm.mu.Unlock()
+
+	if wrapper.process != proc {
+		return 0
+	}
+
+	if err == nil {
+		if wrapper.unhealthy {
+			wrapper.unhealthy = false
+			wrapper.info.Status = PluginStatusStarted
+			wrapper.info.ErrorMsg = ""
+		}
+		return 0
+	}
+
+	failures++
+	wrapper.unhealthy = true
+	wrapper.info.Status = PluginStatusError
+	wrapper.info.ErrorMsg = fmt.Sprintf("health check failed (%d/%d): %v", failures, maxHealthFailures, err)
+
+	if failures >= maxHealthFailures {
+		// 强制结束进程，由监控协程按崩溃流程重启
+		proc.kill()
+	}
+
+	return failures
+}
+
+// handleProcessExit 处理插件进程意外退出，按指数退避重启
+func (pm *pluginManagerImpl) handleProcessExit(wrapper *pluginWrapper, proc *pluginProcess) {
+	name := wrapper.info.Metadata.Name
+	helper := log.NewHelper(log.With(pm.logger, "plugin", name))
+
+	pm.mu.Lock()
+	if pm.plugins[name] != wrapper || wrapper.process != proc {
+		pm.mu.Unlock()
+		return
+	}
+	resume := wrapper.info.Status == PluginStatusStarted || wrapper.unhealthy
+	wrapper.info.Status = PluginStatusError
+	wrapper.info.ErrorMsg = fmt.Sprintf("plugin process exited: %v", proc.exitErr)
+	retries := wrapper.config.RetryCount
+	pm.mu.Unlock()
+
+	_ = proc.conn.Close()
+	helper.Warnf("plugin process exited unexpectedly: %v", proc.exitErr)
+
+	pm.eventBus.PublishAsync(context.Background(), NewEvent(
+		EventPluginError,
+		"plugin_manager",
+		map[string]interface{}{
+			"plugin": name,
+			"error":  fmt.Sprintf("%v", proc.exitErr),
+		},
+	))
+
+	backoff := pm.restartBackoff
+	var lastErr error
+	for attempt := 1; attempt <= retries; attempt++ {
+		time.Sleep(backoff)
+		backoff *= 2
+		if backoff > maxRestartBackoff {
+			backoff = maxRestartBackoff
+		}
+
+		lastErr = pm.restartProcess(wrapper, proc, resume)
+		if errors.Is(lastErr, errRestartSuperseded) {
+			return
+		}
+
+		if lastErr == nil {
+			helper.Infof("plugin process restarted after %d attempt(s)", attempt)
+			return
+		}
+		// 制品未通过验证时重试没有意义，保持错误状态等待重新签名后重新加载
+		var pe *PluginError
+		if errors.As(lastErr, &pe) && pe.Code == ErrCodePluginPermission {
+			helper.Errorf("refusing to restart plugin: %v", lastErr)
+			pm.mu.Lock()
+			if pm.plugins[name] == wrapper {
+				wrapper.info.Status = PluginStatusError
+				wrapper.info.ErrorMsg = fmt.Sprintf("plugin restart refused: %v", lastErr)
+			}
+			pm.mu.Unlock()
+			return
+		}
+		helper.Warnf("plugin restart attempt %d/%d failed: %v", attempt, retries, lastErr)
+	}
+
+	pm.mu.Lock()
+	defer pm.mu.Unlock()
+	if pm.plugins[name] == wrapper {
+		wrapper.info.ErrorMsg = fmt.Sprintf("plugin restart attempts exhausted: %v", lastErr)
+	}
+}
+
+// restartProcess 重新启动已退出的插件进程 proc，resume 为 true 时恢复到已启动状态。
+// 与加载时一样先验证制品签名并执行通过验证的副本，新进程自报的名称与版本须与运行中的插件及清单一致，
+// 制品已更换为其他版本时须经由重新加载切换。
+// 验证制品与启动握手不持有 pm.mu，只在切换进程时加锁；期间插件被卸载或已由重新加载替换时
+// 结束新进程并返回 errRestartSuperseded
+func (pm *pluginManagerImpl) restartProcess(wrapper *pluginWrapper, proc *pluginProcess, resume bool) error {
+	pm.mu.RLock()
+	current := pm.plugins[wrapper.info.Metadata.Name] == wrapper && wrapper.process == proc
+	path, config := wrapper.info.Path, wrapper.config
+	pm.mu.RUnlock()
+	if !current {
+		return errRestartSuperseded
+	}
+
+	manifest, execPath, err := pm.verifyArtifact(path, pluginNameFromPath(path))
+	if err != nil {
+		return err
+	}
+	next, remote, err := pm.launchPlugin(execPath, config)
+	if err != nil {
+		pm.removeStaged(execPath)
+		return err
+	}
+
+	pm.mu.Lock()
+	defer pm.mu.Unlock()
+
+	name := wrapper.info.Metadata.Name
+	if pm.plugins[name] != wrapper || wrapper.process != proc {
+		next.shutdown(defaultShutdownGrace)
+		pm.removeStaged(execPath)
+		return errRestartSuperseded
+	}
+	if err := restartedPluginMatches(wrapper, remote, manifest); err != nil {
+		next.shutdown(defaultShutdownGrace)
+		pm.removeStaged(execPath)
+		return NewPluginError(ErrCodePluginPermission, "restarted plugin does not match the loaded plugin", name, err)
+	}
+
+	wrapper.remote.setConn(next.conn)
+	pm.removeStaged(wrapper.execPath)
+	wrapper.execPath = execPath
+	wrapper.process = next
+	wrapper.restarts++
+	wrapper.unhealthy = false
+	wrapper.info.Status = PluginStatusLoaded
+	wrapper.info.ErrorMsg = ""
+
+	if resume {
+		if err := pm.startPluginInternal(wrapper); err != nil {
+			next.shutdown(defaultShutdownGrace)
+			return err
+		}
+	}
+
+	go pm.superviseProcess(wrapper, next)
+	return nil
+}
+
+// restartedPluginMatches 检查重启后的插件进程自报的名称与版本是否与运行中的插件及签名清单一致
+func restartedPluginMatches(wrapper *pluginWrapper, next Plugin, manifest *PluginManifest) error {
+	meta := wrapper.info.Metadata
+	if next.Name() != meta.Name || next.Version() != meta.Version {
+		return fmt.Errorf("plugin reports %s@%s, loaded %s@%s", next.Name(), next.Version(), meta.Name, meta.Version)
+	}
+	return manifest.match(next)
+}
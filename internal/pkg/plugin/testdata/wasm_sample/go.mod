@@ -0,0 +1,3 @@
+module wasm_sample
+
+go 1.24
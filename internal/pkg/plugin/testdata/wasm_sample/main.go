@@ -0,0 +1,152 @@
+//go:build wasip1
+
+// wasm_sample 是 WASM 插件运行时测试使用的示例模块
+//
+// 构建: GOOS=wasip1 GOARCH=wasm go build -buildmode=c-shared -o sample.wasm .
+package main
+
+import (
+	"encoding/json"
+	"unsafe"
+)
+
+//go:wasmimport kratos log
+func hostLog(level, ptr, size uint32)
+
+// buffers 持有已分配给宿主的内存，避免被 GC 回收
+var buffers = map[uint32][]byte{}
+
+var settings map[string]interface{}
+
+//go:wasmexport kratos_alloc
+func alloc(size uint32) uint32 {
+	if size == 0 {
+		size = 1
+	}
+	buf := make([]byte, size)
+	ptr := uint32(uintptr(unsafe.Pointer(unsafe.SliceData(buf))))
+	buffers[ptr] = buf
+	return ptr
+}
+
+//go:wasmexport kratos_free
+func free(ptr uint32) {
+	delete(buffers, ptr)
+}
+
+func input(ptr, size uint32) []byte {
+	return unsafe.Slice((*byte)(unsafe.Pointer(uintptr(ptr))), size)
+}
+
+func output(v interface{}) uint64 {
+	raw, _ := json.Marshal(v)
+	ptr := alloc(uint32(len(raw)))
+	copy(buffers[ptr], raw)
+	return uint64(ptr)<<32 | uint64(len(raw))
+}
+
+func logf(msg string) {
+	raw := []byte(msg)
+	hostLog(1, uint32(uintptr(unsafe.Pointer(unsafe.SliceData(raw)))), uint32(len(raw)))
+}
+
+type hookDescriptor struct {
+	Point    string `json:"point"`
+	Name     string `json:"name"`
+	Priority int    `json:"priority"`
+}
+
+type handlerDescriptor struct {
+	Name       string   `json:"name"`
+	EventTypes []string `json:"event_types"`
+}
+
+type pluginError struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+type result struct {
+	Data     map[string]interface{} `json:"data,omitempty"`
+	Metadata map[string]string      `json:"metadata,omitempty"`
+	Error    *pluginError           `json:"error,omitempty"`
+}
+
+//go:wasmexport kratos_metadata
+func metadata() uint64 {
+	return output(map[string]interface{}{
+		"name":        "wasm_sample",
+		"version":     "0.1.0",
+		"description": "sample wasm plugin",
+		"hooks": []hookDescriptor{
+			{Point: "before_request", Name: "wasm_sample_before_request", Priority: 5},
+			{Point: "before_auth", Name: "wasm_sample_before_auth", Priority: 5},
+		},
+		"handlers": []handlerDescriptor{
+			{Name: "wasm_sample_login", EventTypes: []string{"user.login"}},
+		},
+	})
+}
+
+//go:wasmexport kratos_initialize
+func initialize(ptr, size uint32) uint64 {
+	var in struct {
+		Config struct {
+			Settings map[string]interface{} `json:"settings"`
+		} `json:"config"`
+	}
+	if err := json.Unmarshal(input(ptr, size), &in); err != nil {
+		return output(result{Error: &pluginError{Code: "PLUGIN_CONFIG_ERROR", Message: err.Error()}})
+	}
+	settings = in.Config.Settings
+	logf("wasm_sample initialized")
+	return output(result{})
+}
+
+//go:wasmexport kratos_hook_execute
+func hookExecute(ptr, size uint32) uint64 {
+	var in struct {
+		Name     string                 `json:"name"`
+		Data     map[string]interface{} `json:"data"`
+		Metadata map[string]string      `json:"metadata"`
+	}
+	if err := json.Unmarshal(input(ptr, size), &in); err != nil {
+		return output(result{Error: &pluginError{Code: "PLUGIN_INTERNAL_ERROR", Message: err.Error()}})
+	}
+
+	switch {
+	case in.Data["spin"] == true:
+		for {
+		}
+	case in.Data["allocate_mb"] != nil:
+		mb := int(in.Data["allocate_mb"].(float64))
+		block := make([]byte, mb<<20)
+		for i := range block {
+			block[i] = 1
+		}
+		return output(result{Data: map[string]interface{}{"allocated": len(block)}})
+	case in.Name == "wasm_sample_before_auth" && in.Data["username"] == "blocked":
+		return output(result{Error: &pluginError{Code: "PLUGIN_PERMISSION_ERROR", Message: "user is blocked"}})
+	}
+
+	return output(result{
+		Data:     map[string]interface{}{"handled_by": "wasm_sample", "greeting": settings["greeting"]},
+		Metadata: map[string]string{"wasm": "true"},
+	})
+}
+
+//go:wasmexport kratos_event_handle
+func eventHandle(ptr, size uint32) uint64 {
+	var in struct {
+		Data map[string]interface{} `json:"data"`
+	}
+	if err := json.Unmarshal(input(ptr, size), &in); err != nil {
+		return output(result{Error: &pluginError{Code: "PLUGIN_INTERNAL_ERROR", Message: err.Error()}})
+	}
+	if in.Data["fail"] == true {
+		return output(result{Error: &pluginError{Code: "PLUGIN_INTERNAL_ERROR", Message: "login handler failed"}})
+	}
+	return output(result{})
+}
+
+func main() {}
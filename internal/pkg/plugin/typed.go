@@ -0,0 +1,284 @@
+package plugin
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// 类型化事件与钩子
+//
+// 事件数据与钩子数据以 protobuf 消息定义，事件类型或钩子点与消息类型的对应关系
+// 通过 RegisterEventPayload / RegisterHookPayload 注册。载荷按 protobuf JSON 映射
+// （保留原始字段名）编码为 map[string]interface{}，因此未类型化的处理器、持久化队列
+// 与进程外插件的 Struct 传输均无需修改；载荷的消息全名写入 EventMetadataPayloadType，
+// 类型化处理器据此拒绝类型不符的事件，而不是静默得到零值。
+
+// EventMetadataPayloadType 事件元数据中载荷消息全名的键
+const EventMetadataPayloadType = "payload_type"
+
+var (
+	// ErrPayloadNotRegistered 事件类型或钩子点未注册载荷类型
+	ErrPayloadNotRegistered = errors.New("payload type not registered")
+	// ErrPayloadTypeMismatch 载荷类型与注册的类型不一致
+	ErrPayloadTypeMismatch = errors.New("payload type mismatch")
+)
+
+var (
+	// 输出零值字段，钩子清空的字段也会写回钩子数据
+	payloadEncoder = protojson.MarshalOptions{UseProtoNames: true, EmitUnpopulated: true}
+	// 允许未知字段，发布方新增字段时旧的订阅方仍可解码
+	payloadDecoder = protojson.UnmarshalOptions{DiscardUnknown: true}
+)
+
+// payloadRegistry 事件类型与钩子点的载荷类型注册表
+var payloadRegistry = struct {
+	sync.RWMutex
+	events map[EventType]protoreflect.MessageType
+	hooks  map[HookPoint]protoreflect.MessageType
+}{
+	events: make(map[EventType]protoreflect.MessageType),
+	hooks:  make(map[HookPoint]protoreflect.MessageType),
+}
+
+// RegisterEventPayload 注册事件类型的载荷类型，同一事件类型只能注册一种载荷
+func RegisterEventPayload[T proto.Message](eventType EventType) error {
+	var zero T
+	mt := zero.ProtoReflect().Type()
+
+	payloadRegistry.Lock()
+	defer payloadRegistry.Unlock()
+	if current, ok := payloadRegistry.events[eventType]; ok && current.Descriptor().FullName() != mt.Descriptor().FullName() {
+		return fmt.Errorf("%w: event %s is registered as %s", ErrPayloadTypeMismatch, eventType, current.Descriptor().FullName())
+	}
+	payloadRegistry.events[eventType] = mt
+	return nil
+}
+
+// RegisterHookPayload 注册钩子点的载荷类型，同一钩子点只能注册一种载荷
+func RegisterHookPayload[T proto.Message](point HookPoint) error {
+	var zero T
+	mt := zero.ProtoReflect().Type()
+
+	payloadRegistry.Lock()
+	defer payloadRegistry.Unlock()
+	if current, ok := payloadRegistry.hooks[point]; ok && current.Descriptor().FullName() != mt.Descriptor().FullName() {
+		return fmt.Errorf("%w: hook point %s is registered as %s", ErrPayloadTypeMismatch, point, current.Descriptor().FullName())
+	}
+	payloadRegistry.hooks[point] = mt
+	return nil
+}
+
+// EventPayloadType 获取事件类型注册的载荷消息全名
+func EventPayloadType(eventType EventType) (string, bool) {
+	payloadRegistry.RLock()
+	defer payloadRegistry.RUnlock()
+	mt, ok := payloadRegistry.events[eventType]
+	if !ok {
+		return "", false
+	}
+	return string(mt.Descriptor().FullName()), true
+}
+
+// HookPayloadType 获取钩子点注册的载荷消息全名
+func HookPayloadType(point HookPoint) (string, bool) {
+	payloadRegistry.RLock()
+	defer payloadRegistry.RUnlock()
+	mt, ok := payloadRegistry.hooks[point]
+	if !ok {
+		return "", false
+	}
+	return string(mt.Descriptor().FullName()), true
+}
+
+// checkEventPayload 校验 T 是否为事件类型注册的载荷
+func checkEventPayload[T proto.Message](eventType EventType) error {
+	registered, ok := EventPayloadType(eventType)
+	if !ok {
+		return fmt.Errorf("%w: event %s", ErrPayloadNotRegistered, eventType)
+	}
+	return checkPayloadName[T](registered, "event "+string(eventType))
+}
+
+// checkHookPayload 校验 T 是否为钩子点注册的载荷
+func checkHookPayload[T proto.Message](point HookPoint) error {
+	registered, ok := HookPayloadType(point)
+	if !ok {
+		return fmt.Errorf("%w: hook point %s", ErrPayloadNotRegistered, point)
+	}
+	return checkPayloadName[T](registered, "hook point "+string(point))
+}
+
+func checkPayloadName[T proto.Message](registered, target string) error {
+	if name := payloadName[T](); name != registered {
+		return fmt.Errorf("%w: %s expects %s, got %s", ErrPayloadTypeMismatch, target, registered, name)
+	}
+	return nil
+}
+
+func payloadName[T proto.Message]() string {
+	var zero T
+	return string(zero.ProtoReflect().Descriptor().FullName())
+}
+
+// newPayload 创建 T 的空消息
+func newPayload[T proto.Message]() T {
+	var zero T
+	return zero.ProtoReflect().New().Interface().(T)
+}
+
+// encodePayload 将载荷编码为事件数据与钩子数据使用的 map
+func encodePayload(payload proto.Message) (map[string]interface{}, error) {
+	raw, err := payloadEncoder.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+	data := make(map[string]interface{})
+	if err := json.Unmarshal(raw, &data); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// decodePayload 将事件数据或钩子数据解码到载荷
+func decodePayload(data map[string]interface{}, payload proto.Message) error {
+	if data == nil {
+		data = map[string]interface{}{}
+	}
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	return payloadDecoder.Unmarshal(raw, payload)
+}
+
+// TypedEvent 载荷已解码的事件
+type TypedEvent[T proto.Message] struct {
+	Event
+	Payload T
+}
+
+// NewTypedEvent 创建类型化事件，载荷类型须已为事件类型注册
+func NewTypedEvent[T proto.Message](eventType EventType, source string, payload T) (*TypedEvent[T], error) {
+	if err := checkEventPayload[T](eventType); err != nil {
+		return nil, err
+	}
+	data, err := encodePayload(payload)
+	if err != nil {
+		return nil, fmt.Errorf("encode %s payload: %w", eventType, err)
+	}
+	event := NewEvent(eventType, source, data)
+	event.GetMetadata()[EventMetadataPayloadType] = payloadName[T]()
+	return &TypedEvent[T]{Event: event, Payload: payload}, nil
+}
+
+// DecodeEvent 解码事件载荷，事件声明的载荷类型与 T 不一致或数据无法解码时返回错误
+func DecodeEvent[T proto.Message](event Event) (*TypedEvent[T], error) {
+	if declared := event.GetMetadata()[EventMetadataPayloadType]; declared != "" {
+		if err := checkPayloadName[T](declared, "event "+string(event.GetType())); err != nil {
+			return nil, err
+		}
+	}
+	payload := newPayload[T]()
+	if err := decodePayload(event.GetData(), payload); err != nil {
+		return nil, fmt.Errorf("%w: decode %s as %s: %v", ErrPayloadTypeMismatch, event.GetType(), payloadName[T](), err)
+	}
+	return &TypedEvent[T]{Event: event, Payload: payload}, nil
+}
+
+// Publish 同步发布类型化事件
+func Publish[T proto.Message](ctx context.Context, bus EventBus, eventType EventType, source string, payload T) error {
+	event, err := NewTypedEvent(eventType, source, payload)
+	if err != nil {
+		return err
+	}
+	return bus.Publish(ctx, event.Event)
+}
+
+// PublishAsync 异步发布类型化事件
+func PublishAsync[T proto.Message](ctx context.Context, bus EventBus, eventType EventType, source string, payload T) error {
+	event, err := NewTypedEvent(eventType, source, payload)
+	if err != nil {
+		return err
+	}
+	return bus.PublishAsync(ctx, event.Event)
+}
+
+// Subscribe 以类型化处理器订阅事件，使用 DefaultDeliveryOptions
+func Subscribe[T proto.Message](bus EventBus, eventType EventType, name string, timeout time.Duration, handler func(context.Context, *TypedEvent[T]) error) error {
+	return SubscribeWithOptions(bus, eventType, name, timeout, handler, DefaultDeliveryOptions())
+}
+
+// SubscribeWithOptions 按指定投递选项以类型化处理器订阅事件
+// 载荷解码失败视为处理失败，按投递选项重试或进入死信
+func SubscribeWithOptions[T proto.Message](bus EventBus, eventType EventType, name string, timeout time.Duration, handler func(context.Context, *TypedEvent[T]) error, options DeliveryOptions) error {
+	if err := checkEventPayload[T](eventType); err != nil {
+		return err
+	}
+	return bus.SubscribeWithOptions(eventType, NewTypedEventHandler(name, []EventType{eventType}, timeout, handler), options)
+}
+
+// NewTypedEventHandler 创建类型化事件处理器，可用于 EventPlugin.RegisterEventHandlers
+func NewTypedEventHandler[T proto.Message](name string, eventTypes []EventType, timeout time.Duration, handler func(context.Context, *TypedEvent[T]) error) EventHandler {
+	return NewBaseEventHandler(name, eventTypes, timeout, func(ctx context.Context, event Event) error {
+		typed, err := DecodeEvent[T](event)
+		if err != nil {
+			return err
+		}
+		return handler(ctx, typed)
+	})
+}
+
+// RegisterHook 在钩子点注册类型化钩子
+// 钩子对载荷的修改写回钩子数据，对后续钩子与调用方可见
+func RegisterHook[T proto.Message](manager HookManager, point HookPoint, name string, priority int, timeout time.Duration, handler func(context.Context, T) error) error {
+	if err := checkHookPayload[T](point); err != nil {
+		return err
+	}
+	return manager.RegisterHook(point, NewBaseHook(name, priority, timeout, func(ctx context.Context, data HookData) error {
+		payload := newPayload[T]()
+		if err := decodePayload(data.GetData(), payload); err != nil {
+			return fmt.Errorf("%w: decode %s as %s: %v", ErrPayloadTypeMismatch, point, payloadName[T](), err)
+		}
+		if err := handler(ctx, payload); err != nil {
+			return err
+		}
+		updated, err := encodePayload(payload)
+		if err != nil {
+			return err
+		}
+		for key, value := range updated {
+			data.SetData(key, value)
+		}
+		return nil
+	}))
+}
+
+// ExecuteHooks 以类型化载荷执行钩子点的所有钩子，钩子的修改写回 payload
+func ExecuteHooks[T proto.Message](ctx context.Context, manager HookManager, point HookPoint, payload T) error {
+	if err := checkHookPayload[T](point); err != nil {
+		return err
+	}
+	data, err := encodePayload(payload)
+	if err != nil {
+		return fmt.Errorf("encode %s payload: %w", point, err)
+	}
+	hookData := NewHookData(ctx, data)
+	hookData.GetMetadata()[EventMetadataPayloadType] = payloadName[T]()
+	if err := manager.ExecuteHooks(ctx, point, hookData); err != nil {
+		return err
+	}
+
+	proto.Reset(payload)
+	if err := decodePayload(hookData.GetData(), payload); err != nil {
+		return fmt.Errorf("%w: decode %s as %s: %v", ErrPayloadTypeMismatch, point, payloadName[T](), err)
+	}
+	return nil
+}
@@ -0,0 +1,196 @@
+package plugin
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	authv1 "kratos-boilerplate/api/auth/v1"
+	pluginv1 "kratos-boilerplate/api/plugin/v1"
+
+	"github.com/go-kratos/kratos/v2/log"
+	"github.com/go-kratos/kratos/v2/transport"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/apipb"
+)
+
+// 通用载荷测试使用 well-known 类型，与内置载荷注册互不影响
+const (
+	eventTypedMethod EventType = "test.typed.method"
+	hookTypedMethod  HookPoint = "test_typed_method"
+)
+
+func init() {
+	if err := RegisterEventPayload[*apipb.Method](eventTypedMethod); err != nil {
+		panic(err)
+	}
+	if err := RegisterHookPayload[*apipb.Method](hookTypedMethod); err != nil {
+		panic(err)
+	}
+}
+
+func TestRegisterEventPayload(t *testing.T) {
+	// 重复注册同一类型
+	assert.NoError(t, RegisterEventPayload[*apipb.Method](eventTypedMethod))
+	// 已注册为其他类型
+	assert.ErrorIs(t, RegisterEventPayload[*apipb.Mixin](eventTypedMethod), ErrPayloadTypeMismatch)
+
+	name, ok := EventPayloadType(eventTypedMethod)
+	assert.True(t, ok)
+	assert.Equal(t, "google.protobuf.Method", name)
+}
+
+func TestTypedEvents(t *testing.T) {
+	bus := NewEventBus(1)
+	defer bus.Close()
+
+	received := make(chan *TypedEvent[*apipb.Method], 1)
+	require.NoError(t, Subscribe(bus, eventTypedMethod, "typed", time.Second, func(ctx context.Context, event *TypedEvent[*apipb.Method]) error {
+		received <- event
+		return nil
+	}))
+
+	// 未类型化的处理器按字段名读取数据
+	var untyped map[string]interface{}
+	require.NoError(t, bus.Subscribe(eventTypedMethod, NewBaseEventHandler("untyped", []EventType{eventTypedMethod}, time.Second,
+		func(ctx context.Context, event Event) error {
+			untyped = event.GetData()
+			return nil
+		})))
+
+	err := Publish(context.Background(), bus, eventTypedMethod, "test", &apipb.Method{
+		Name:           "Login",
+		RequestTypeUrl: "auth.v1.LoginRequest",
+	})
+	require.NoError(t, err)
+
+	event := <-received
+	assert.Equal(t, "Login", event.Payload.GetName())
+	assert.Equal(t, "auth.v1.LoginRequest", event.Payload.GetRequestTypeUrl())
+	assert.Equal(t, "google.protobuf.Method", event.GetMetadata()[EventMetadataPayloadType])
+	assert.Equal(t, "Login", untyped["name"])
+	assert.Equal(t, "auth.v1.LoginRequest", untyped["request_type_url"])
+
+	// 载荷类型与注册的类型不一致
+	assert.ErrorIs(t, Publish(context.Background(), bus, eventTypedMethod, "test", &apipb.Mixin{Name: "x"}), ErrPayloadTypeMismatch)
+	assert.ErrorIs(t, Subscribe(bus, eventTypedMethod, "mixin", time.Second, func(ctx context.Context, event *TypedEvent[*apipb.Mixin]) error {
+		return nil
+	}), ErrPayloadTypeMismatch)
+	assert.ErrorIs(t, Publish(context.Background(), bus, "test.typed.unknown", "test", &apipb.Method{}), ErrPayloadNotRegistered)
+}
+
+func TestDecodeEvent(t *testing.T) {
+	// 未声明载荷类型的事件按数据解码，忽略未知字段
+	event := NewEvent(eventTypedMethod, "test", map[string]interface{}{"name": "Logout", "added_later": true})
+	typed, err := DecodeEvent[*apipb.Method](event)
+	require.NoError(t, err)
+	assert.Equal(t, "Logout", typed.Payload.GetName())
+
+	// 字段类型不符时返回错误而不是零值
+	_, err = DecodeEvent[*apipb.Method](NewEvent(eventTypedMethod, "test", map[string]interface{}{"name": 42}))
+	assert.ErrorIs(t, err, ErrPayloadTypeMismatch)
+
+	// 声明的载荷类型不符
+	declared := NewEvent(eventTypedMethod, "test", map[string]interface{}{"name": "Logout"})
+	declared.GetMetadata()[EventMetadataPayloadType] = "google.protobuf.Mixin"
+	_, err = DecodeEvent[*apipb.Method](declared)
+	assert.ErrorIs(t, err, ErrPayloadTypeMismatch)
+}
+
+// 测试类型化事件经持久化队列投递
+func TestTypedEvents_AtLeastOnce(t *testing.T) {
+	bus := NewEventBusWithConfig(EventBusConfig{Store: NewMemoryEventStore(), PollInterval: 10 * time.Millisecond})
+	defer bus.Close()
+
+	received := make(chan string, 1)
+	require.NoError(t, SubscribeWithOptions(bus, eventTypedMethod, "durable", time.Second,
+		func(ctx context.Context, event *TypedEvent[*apipb.Method]) error {
+			received <- event.Payload.GetName()
+			return nil
+		}, DeliveryOptions{Mode: DeliveryAtLeastOnce}))
+
+	require.NoError(t, Publish(context.Background(), bus, eventTypedMethod, "test", &apipb.Method{Name: "Register"}))
+
+	select {
+	case name := <-received:
+		assert.Equal(t, "Register", name)
+	case <-time.After(2 * time.Second):
+		t.Fatal("typed event was not delivered")
+	}
+}
+
+func TestTypedHooks(t *testing.T) {
+	hm := NewHookManager()
+
+	require.NoError(t, RegisterHook(hm, hookTypedMethod, "rename", 1, time.Second, func(ctx context.Context, m *apipb.Method) error {
+		m.Name = "Renamed" + m.GetName()
+		m.ResponseTypeUrl = ""
+		return nil
+	}))
+	// 未类型化的钩子看到前一个钩子的修改
+	var seen interface{}
+	require.NoError(t, hm.RegisterHook(hookTypedMethod, NewBaseHook("untyped", 2, time.Second, func(ctx context.Context, data HookData) error {
+		seen = data.GetData()["name"]
+		data.SetData("request_streaming", true)
+		return nil
+	})))
+
+	payload := &apipb.Method{Name: "Login", ResponseTypeUrl: "auth.v1.LoginReply"}
+	require.NoError(t, ExecuteHooks(context.Background(), hm, hookTypedMethod, payload))
+	assert.Equal(t, "RenamedLogin", seen)
+	assert.Equal(t, "RenamedLogin", payload.GetName())
+	assert.Empty(t, payload.GetResponseTypeUrl())
+	assert.True(t, payload.GetRequestStreaming())
+
+	assert.ErrorIs(t, RegisterHook(hm, hookTypedMethod, "mixin", 3, time.Second, func(ctx context.Context, m *apipb.Mixin) error {
+		return nil
+	}), ErrPayloadTypeMismatch)
+	assert.ErrorIs(t, ExecuteHooks(context.Background(), hm, "test_typed_unknown", &apipb.Method{}), ErrPayloadNotRegistered)
+}
+
+// TestBuiltinPayloads 测试内置钩子点产生的数据可按注册的载荷解码
+func TestBuiltinPayloads(t *testing.T) {
+	name, ok := EventPayloadType(EventUserLogin)
+	assert.True(t, ok)
+	assert.Equal(t, "plugin.v1.UserLoginEvent", name)
+
+	hm := NewHookManager()
+	var request *pluginv1.RequestHookPayload
+	require.NoError(t, RegisterHook(hm, HookPointAfterRequest, "typed_request", 10, time.Second,
+		func(ctx context.Context, payload *pluginv1.RequestHookPayload) error {
+			request = payload
+			return nil
+		}))
+	var layer *pluginv1.LayerHookPayload
+	require.NoError(t, RegisterHook(hm, HookPointDataError, "typed_layer", 10, time.Second,
+		func(ctx context.Context, payload *pluginv1.LayerHookPayload) error {
+			layer = payload
+			return nil
+		}))
+
+	ctx := transport.NewServerContext(context.Background(), &testTransport{header: headerCarrier{}})
+	handler := HookMiddleware(hm, log.DefaultLogger)(func(ctx context.Context, req interface{}) (interface{}, error) {
+		return nil, errors.New("boom")
+	})
+	_, err := handler(ctx, &authv1.LoginRequest{Username: "alice"})
+	require.Error(t, err)
+	require.NotNil(t, request)
+	assert.Equal(t, "/auth.v1.Auth/Login", request.GetOperation())
+	assert.Equal(t, "al***", request.GetRequest().GetStructValue().GetFields()["username"].GetStringValue())
+	assert.Equal(t, "boom", request.GetError())
+	assert.NotNil(t, request.GetDuration())
+
+	err = RunLayerHooks(context.Background(), hm, DataHookPoints, "user.GetUser", nil, func(ctx context.Context) error {
+		return errors.New("not found")
+	})
+	require.Error(t, err)
+	require.NotNil(t, layer)
+	assert.Equal(t, "data", layer.GetLayer())
+	assert.Equal(t, "user.GetUser", layer.GetOperation())
+	assert.Equal(t, "not found", layer.GetError())
+
+	assert.Equal(t, "1.500000000s", durationPayload(1500*time.Millisecond))
+	assert.Equal(t, "-0.000000001s", durationPayload(-time.Nanosecond))
+}
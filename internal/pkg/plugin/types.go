@@ -27,6 +27,14 @@ type PluginMetadata struct {
 	Dependencies []string          `json:"dependencies" yaml:"dependencies"`
 	Tags         []string          `json:"tags" yaml:"tags"`
 	Metadata     map[string]string `json:"metadata" yaml:"metadata"`
+
+	// 兼容性要求，见 CompatibilityPlugin
+	OptionalDependencies []string `json:"optional_dependencies,omitempty" yaml:"optional_dependencies"`
+	HostAPIVersion       string   `json:"host_api_version,omitempty" yaml:"host_api_version"`
+
+	// 签名清单信息，未启用签名验证时为空
+	Publisher   string   `json:"publisher,omitempty" yaml:"publisher"`
+	Permissions []string `json:"permissions,omitempty" yaml:"permissions"`
 }
 
 // PluginInfo 插件信息
@@ -82,6 +90,8 @@ const (
 	ErrCodePluginDependency   = "PLUGIN_DEPENDENCY_ERROR"
 	ErrCodePluginPermission   = "PLUGIN_PERMISSION_ERROR"
 	ErrCodePluginInternal     = "PLUGIN_INTERNAL_ERROR"
+	ErrCodeHookAborted        = "HOOK_ABORTED"
+	ErrCodeHookFailed         = "HOOK_FAILED"
 )
 
 // NewPluginError 创建插件错误
@@ -0,0 +1,684 @@
+package plugin
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-kratos/kratos/v2/log"
+	"github.com/tetratelabs/wazero"
+	"github.com/tetratelabs/wazero/api"
+	"github.com/tetratelabs/wazero/imports/wasi_snapshot_preview1"
+)
+
+// WASM 插件 ABI
+//
+// 模块需导出：
+//
+//	memory
+//	kratos_alloc(size i32) i32          在模块内存中分配缓冲区，供宿主写入输入
+//	kratos_free(ptr i32)                释放 kratos_alloc 或结果返回的缓冲区
+//	kratos_metadata() i64               返回插件元数据 JSON
+//
+// 可选导出（参数为输入 JSON 的指针和长度）：
+//
+//	kratos_initialize(ptr, len i32) i64 输入 {"config": PluginConfig}
+//	kratos_reconfigure(ptr, len i32) i64 输入 {"config": PluginConfig}，运行时更新配置
+//	kratos_start / kratos_stop / kratos_cleanup / kratos_health_check () i64
+//	kratos_hook_execute(ptr, len i32) i64  输入 {"point","name","data","metadata"}
+//	kratos_event_handle(ptr, len i32) i64  输入 {"handler","id","type","source","timestamp","data","metadata"}
+//
+// 所有 i64 返回值的高 32 位为结果 JSON 指针，低 32 位为长度。结果格式：
+//
+//	{"data": {...}, "metadata": {...}, "error": {"code": "...", "message": "..."}}
+//
+// 钩子可返回 {"error": {"code": "HOOK_ABORTED", "status": 403, "reason": "...", "message": "..."}} 中止请求。
+//
+// 宿主向模块提供 kratos.log(level, ptr, len i32) 用于输出日志，level 取值 0-3 依次为 debug/info/warn/error。
+const (
+	wasmHostModule = "kratos"
+	wasmPageSize   = 64 * 1024
+	// wasmInstantiateTimeout 模块实例化（含 _initialize）的最长时间
+	wasmInstantiateTimeout = 10 * time.Second
+)
+
+// wasmCompilationCache 进程内共享的编译缓存，重新加载同一模块时无需重复编译
+var wasmCompilationCache = wazero.NewCompilationCache()
+
+// SandboxConfig WASM 插件沙箱配置，对应 plugins.security
+type SandboxConfig struct {
+	Enabled bool
+	// MaxMemory 单个插件实例可用的最大线性内存（字节）
+	MaxMemory uint64
+	// MaxCPUPercent 插件在每个统计窗口内可占用的执行时间百分比
+	MaxCPUPercent int
+	// MaxExecutionTime 单次调用的最长执行时间，超时后实例被终止并在下次调用时重建
+	MaxExecutionTime time.Duration
+}
+
+// DefaultSandboxConfig 默认沙箱配置
+func DefaultSandboxConfig() SandboxConfig {
+	return SandboxConfig{
+		Enabled:          true,
+		MaxMemory:        100 << 20,
+		MaxCPUPercent:    10,
+		MaxExecutionTime: time.Second,
+	}
+}
+
+// ParseMemorySize 解析 "100MB"、"512KB"、"1GB" 形式的内存大小
+func ParseMemorySize(s string) (uint64, error) {
+	s = strings.ToUpper(strings.TrimSpace(s))
+	if s == "" {
+		return 0, nil
+	}
+
+	units := []struct {
+		suffix string
+		factor uint64
+	}{
+		{"GB", 1 << 30}, {"MB", 1 << 20}, {"KB", 1 << 10}, {"G", 1 << 30}, {"M", 1 << 20}, {"K", 1 << 10}, {"B", 1},
+	}
+	for _, u := range units {
+		if strings.HasSuffix(s, u.suffix) {
+			n, err := strconv.ParseUint(strings.TrimSpace(strings.TrimSuffix(s, u.suffix)), 10, 64)
+			if err != nil {
+				return 0, fmt.Errorf("invalid memory size %q: %w", s, err)
+			}
+			return n * u.factor, nil
+		}
+	}
+
+	n, err := strconv.ParseUint(s, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid memory size %q: %w", s, err)
+	}
+	return n, nil
+}
+
+// cpuBudget 按时间窗口统计执行时间，限制插件的 CPU 占用比例
+type cpuBudget struct {
+	window      time.Duration
+	limit       time.Duration
+	windowStart time.Time
+	used        time.Duration
+}
+
+// newCPUBudget 创建 CPU 预算，percent <= 0 表示不限制
+func newCPUBudget(percent int, window time.Duration) *cpuBudget {
+	if percent <= 0 || percent >= 100 {
+		return nil
+	}
+	return &cpuBudget{
+		window: window,
+		limit:  window * time.Duration(percent) / 100,
+	}
+}
+
+// allow 检查当前窗口是否还有剩余预算
+func (b *cpuBudget) allow(now time.Time) bool {
+	if b == nil {
+		return true
+	}
+	if now.Sub(b.windowStart) >= b.window {
+		b.windowStart = now
+		b.used = 0
+	}
+	return b.used < b.limit
+}
+
+// consume 记录一次执行耗时
+func (b *cpuBudget) consume(d time.Duration) {
+	if b != nil {
+		b.used += d
+	}
+}
+
+// wasmMetadata 模块返回的元数据
+type wasmMetadata struct {
+	Name         string                 `json:"name"`
+	Version      string                 `json:"version"`
+	Description  string                 `json:"description"`
+	Dependencies []string               `json:"dependencies,omitempty"`
+	Hooks        []rpcHookDescriptor    `json:"hooks,omitempty"`
+	Handlers     []rpcHandlerDescriptor `json:"handlers,omitempty"`
+
+	HostAPIVersion       string          `json:"host_api_version,omitempty"`
+	OptionalDependencies []string        `json:"optional_dependencies,omitempty"`
+	SettingsSchema       json.RawMessage `json:"settings_schema,omitempty"`
+}
+
+// wasmResult 模块调用结果
+type wasmResult struct {
+	Data     map[string]interface{} `json:"data,omitempty"`
+	Metadata map[string]string      `json:"metadata,omitempty"`
+	Error    *struct {
+		Code    string `json:"code"`
+		Message string `json:"message"`
+		// Status 与 Reason 仅在 code 为 HOOK_ABORTED 时使用
+		Status int    `json:"status,omitempty"`
+		Reason string `json:"reason,omitempty"`
+	} `json:"error,omitempty"`
+}
+
+// errWasmExportMissing 模块未导出可选函数
+var errWasmExportMissing = errors.New("wasm export not found")
+
+// wasmPlugin 基于 wazero 的沙箱插件
+// 实现 Plugin、HookPlugin 和 EventPlugin，同一实例的调用串行执行
+type wasmPlugin struct {
+	mu       sync.Mutex
+	metaMu   sync.RWMutex
+	path     string
+	sandbox  SandboxConfig
+	log      *log.Helper
+	runtime  wazero.Runtime
+	compiled wazero.CompiledModule
+	module   api.Module
+	metadata wasmMetadata
+	config   PluginConfig
+	budget   *cpuBudget
+
+	proxiedHooks    map[string]bool
+	proxiedHandlers map[string]bool
+}
+
+// newWasmPlugin 编译 WASM 模块并读取插件元数据
+func newWasmPlugin(ctx context.Context, path string, sandbox SandboxConfig, logger log.Logger) (*wasmPlugin, error) {
+	code, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	runtimeConfig := wazero.NewRuntimeConfig().
+		WithCloseOnContextDone(true).
+		WithCompilationCache(wasmCompilationCache)
+	if sandbox.Enabled && sandbox.MaxMemory > 0 {
+		pages := sandbox.MaxMemory / wasmPageSize
+		if pages == 0 {
+			pages = 1
+		}
+		if pages > 65536 {
+			pages = 65536
+		}
+		runtimeConfig = runtimeConfig.WithMemoryLimitPages(uint32(pages))
+	}
+
+	p := &wasmPlugin{
+		path:            path,
+		sandbox:         sandbox,
+		log:             log.NewHelper(log.With(logger, "plugin_path", path)),
+		runtime:         wazero.NewRuntimeWithConfig(ctx, runtimeConfig),
+		proxiedHooks:    make(map[string]bool),
+		proxiedHandlers: make(map[string]bool),
+	}
+	if sandbox.Enabled {
+		p.budget = newCPUBudget(sandbox.MaxCPUPercent, time.Second)
+	}
+
+	if err := p.setup(ctx, code); err != nil {
+		_ = p.runtime.Close(ctx)
+		return nil, err
+	}
+
+	if err := p.call(ctx, "kratos_metadata", nil, &p.metadata); err != nil {
+		_ = p.runtime.Close(ctx)
+		return nil, err
+	}
+	if p.metadata.Name == "" {
+		_ = p.runtime.Close(ctx)
+		return nil, fmt.Errorf("wasm module %s did not report a plugin name", path)
+	}
+
+	return p, nil
+}
+
+// setup 注册宿主函数并编译模块
+func (p *wasmPlugin) setup(ctx context.Context, code []byte) error {
+	if _, err := wasi_snapshot_preview1.Instantiate(ctx, p.runtime); err != nil {
+		return err
+	}
+
+	_, err := p.runtime.NewHostModuleBuilder(wasmHostModule).
+		NewFunctionBuilder().
+		WithFunc(func(ctx context.Context, m api.Module, level, ptr, size uint32) {
+			msg, ok := m.Memory().Read(ptr, size)
+			if !ok {
+				return
+			}
+			p.log.Log(log.Level(int32(level)-1), "msg", string(msg))
+		}).
+		Export("log").
+		Instantiate(ctx)
+	if err != nil {
+		return err
+	}
+
+	p.compiled, err = p.runtime.CompileModule(ctx, code)
+	return err
+}
+
+// instantiate 创建新的模块实例
+// 调用方需持有 p.mu
+func (p *wasmPlugin) instantiate(ctx context.Context) error {
+	// 实例化不受单次调用的超时限制，避免 _initialize 被调用方的短超时中断
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), wasmInstantiateTimeout)
+	defer cancel()
+
+	config := wazero.NewModuleConfig().
+		WithName("").
+		WithStartFunctions("_initialize").
+		WithSysNanotime().
+		WithSysWalltime().
+		WithRandSource(rand.Reader).
+		WithStdout(&logWriter{log: p.log, level: log.LevelInfo}).
+		WithStderr(&logWriter{log: p.log, level: log.LevelWarn})
+
+	module, err := p.runtime.InstantiateModule(ctx, p.compiled, config)
+	if err != nil {
+		return err
+	}
+	for _, name := range []string{"kratos_alloc", "kratos_free"} {
+		if module.ExportedFunction(name) == nil {
+			_ = module.Close(ctx)
+			return fmt.Errorf("wasm module must export %s", name)
+		}
+	}
+	p.module = module
+	return nil
+}
+
+// call 调用模块导出函数，输入与输出均为 JSON
+func (p *wasmPlugin) call(ctx context.Context, export string, in interface{}, out interface{}) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	name := p.metadata.Name
+
+	// 实例在超时或内存耗尽后会被关闭，下次调用时重建
+	if p.module == nil || p.module.IsClosed() {
+		if p.module != nil {
+			p.log.Warn("wasm instance was terminated, re-instantiating")
+		}
+		if err := p.instantiate(ctx); err != nil {
+			return NewPluginError(ErrCodePluginLoadFailed, "failed to instantiate wasm module", name, err)
+		}
+	}
+
+	fn := p.module.ExportedFunction(export)
+	if fn == nil {
+		return errWasmExportMissing
+	}
+
+	if !p.budget.allow(time.Now()) {
+		return NewPluginError(ErrCodePluginTimeout, "wasm plugin cpu budget exhausted", name, nil)
+	}
+
+	if p.sandbox.Enabled && p.sandbox.MaxExecutionTime > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, p.sandbox.MaxExecutionTime)
+		defer cancel()
+	}
+
+	start := time.Now()
+	results, err := p.invoke(ctx, fn, in)
+	p.budget.consume(time.Since(start))
+	if err != nil {
+		if ctx.Err() != nil {
+			return NewPluginError(ErrCodePluginTimeout, "wasm execution timed out", name, err)
+		}
+		return NewPluginError(ErrCodePluginInternal, "wasm execution failed", name, err)
+	}
+
+	raw, err := p.readResult(ctx, results)
+	if err != nil {
+		return NewPluginError(ErrCodePluginInternal, "invalid wasm result", name, err)
+	}
+
+	if export == "kratos_metadata" {
+		if err := json.Unmarshal(raw, out); err != nil {
+			return NewPluginError(ErrCodePluginInternal, "invalid wasm metadata", name, err)
+		}
+		return nil
+	}
+
+	var res wasmResult
+	if err := json.Unmarshal(raw, &res); err != nil {
+		return NewPluginError(ErrCodePluginInternal, "invalid wasm result", name, err)
+	}
+	if res.Error != nil {
+		code := res.Error.Code
+		if code == "" {
+			code = ErrCodePluginInternal
+		}
+		if code == ErrCodeHookAborted {
+			return NewPluginError(code, res.Error.Message, name,
+				NewHookAbortError(res.Error.Status, res.Error.Reason, res.Error.Message))
+		}
+		return NewPluginError(code, res.Error.Message, name, nil)
+	}
+	if result, ok := out.(*wasmResult); ok {
+		*result = res
+	}
+	return nil
+}
+
+// invoke 将输入写入模块内存并调用函数
+func (p *wasmPlugin) invoke(ctx context.Context, fn api.Function, in interface{}) ([]uint64, error) {
+	if in == nil {
+		return fn.Call(ctx)
+	}
+
+	raw, err := json.Marshal(in)
+	if err != nil {
+		return nil, err
+	}
+
+	allocated, err := p.module.ExportedFunction("kratos_alloc").Call(ctx, uint64(len(raw)))
+	if err != nil {
+		return nil, err
+	}
+	ptr := uint32(allocated[0])
+	if !p.module.Memory().Write(ptr, raw) {
+		return nil, fmt.Errorf("input out of wasm memory range")
+	}
+
+	results, err := fn.Call(ctx, uint64(ptr), uint64(len(raw)))
+	if err == nil {
+		_, err = p.module.ExportedFunction("kratos_free").Call(ctx, uint64(ptr))
+	}
+	return results, err
+}
+
+// readResult 读取并释放打包在 i64 中的结果缓冲区
+func (p *wasmPlugin) readResult(ctx context.Context, results []uint64) ([]byte, error) {
+	if len(results) != 1 {
+		return nil, fmt.Errorf("expected 1 result, got %d", len(results))
+	}
+
+	ptr, size := uint32(results[0]>>32), uint32(results[0])
+	view, ok := p.module.Memory().Read(ptr, size)
+	if !ok {
+		return nil, fmt.Errorf("result out of wasm memory range")
+	}
+	raw := make([]byte, len(view))
+	copy(raw, view)
+
+	_, err := p.module.ExportedFunction("kratos_free").Call(ctx, uint64(ptr))
+	return raw, err
+}
+
+// lifecycle 调用可选的生命周期导出函数
+func (p *wasmPlugin) lifecycle(ctx context.Context, export string, in interface{}) error {
+	if err := p.call(ctx, export, in, nil); err != nil && !errors.Is(err, errWasmExportMissing) {
+		return err
+	}
+	return nil
+}
+
+// meta 读取模块元数据，升级后元数据会被替换
+func (p *wasmPlugin) meta() wasmMetadata {
+	p.metaMu.RLock()
+	defer p.metaMu.RUnlock()
+	return p.metadata
+}
+
+func (p *wasmPlugin) Name() string {
+	return p.meta().Name
+}
+
+func (p *wasmPlugin) Version() string {
+	return p.meta().Version
+}
+
+func (p *wasmPlugin) Description() string {
+	return p.meta().Description
+}
+
+func (p *wasmPlugin) Dependencies() []string {
+	return p.meta().Dependencies
+}
+
+func (p *wasmPlugin) HostAPIVersion() string {
+	return p.meta().HostAPIVersion
+}
+
+func (p *wasmPlugin) OptionalDependencies() []string {
+	return p.meta().OptionalDependencies
+}
+
+func (p *wasmPlugin) SettingsSchema() []byte {
+	return p.meta().SettingsSchema
+}
+
+func (p *wasmPlugin) Initialize(ctx context.Context, config PluginConfig) error {
+	p.config = config
+	return p.lifecycle(ctx, "kratos_initialize", rpcInitializeRequest{Config: config})
+}
+
+// Reconfigure 调用模块的 kratos_reconfigure 导出，模块未导出时返回 errReconfigureUnsupported
+func (p *wasmPlugin) Reconfigure(ctx context.Context, config PluginConfig) error {
+	err := p.call(ctx, "kratos_reconfigure", rpcInitializeRequest{Config: config}, nil)
+	if errors.Is(err, errWasmExportMissing) {
+		return errReconfigureUnsupported
+	}
+	if err == nil {
+		p.config = config
+	}
+	return err
+}
+
+func (p *wasmPlugin) Start(ctx context.Context) error {
+	return p.lifecycle(ctx, "kratos_start", nil)
+}
+
+func (p *wasmPlugin) Stop(ctx context.Context) error {
+	return p.lifecycle(ctx, "kratos_stop", nil)
+}
+
+func (p *wasmPlugin) Cleanup(ctx context.Context) error {
+	return p.lifecycle(ctx, "kratos_cleanup", nil)
+}
+
+func (p *wasmPlugin) HealthCheck(ctx context.Context) error {
+	return p.lifecycle(ctx, "kratos_health_check", nil)
+}
+
+// swap 等待在途调用结束后切换到新版本模块，next 接管旧版本的运行时以便调用方停止并释放
+func (p *wasmPlugin) swap(next *wasmPlugin) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.metaMu.Lock()
+	defer p.metaMu.Unlock()
+
+	p.path, next.path = next.path, p.path
+	p.runtime, next.runtime = next.runtime, p.runtime
+	p.compiled, next.compiled = next.compiled, p.compiled
+	p.module, next.module = next.module, p.module
+	p.metadata, next.metadata = next.metadata, p.metadata
+	p.config, next.config = next.config, p.config
+	p.budget, next.budget = next.budget, p.budget
+}
+
+// close 释放运行时及所有模块实例
+func (p *wasmPlugin) close(ctx context.Context) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.runtime.Close(ctx)
+}
+
+// RegisterHooks 按模块元数据注册钩子
+func (p *wasmPlugin) RegisterHooks(manager HookManager) error {
+	for _, desc := range p.meta().Hooks {
+		key := string(desc.Point) + "/" + desc.Name
+		if p.proxiedHooks[key] {
+			continue
+		}
+		if err := manager.RegisterHook(desc.Point, &wasmHook{plugin: p, desc: desc}); err != nil {
+			return err
+		}
+		p.proxiedHooks[key] = true
+	}
+	return nil
+}
+
+// RegisterEventHandlers 按模块元数据订阅事件
+func (p *wasmPlugin) RegisterEventHandlers(bus EventBus) error {
+	for _, desc := range p.meta().Handlers {
+		handler := &wasmEventHandler{plugin: p, desc: desc}
+		for _, eventType := range desc.EventTypes {
+			key := string(eventType) + "/" + desc.Name
+			if p.proxiedHandlers[key] {
+				continue
+			}
+			if err := bus.Subscribe(eventType, handler); err != nil {
+				return err
+			}
+			p.proxiedHandlers[key] = true
+		}
+	}
+	return nil
+}
+
+// unregisterProxies 注销模块注册的钩子与事件处理器
+func (p *wasmPlugin) unregisterProxies(manager HookManager, bus EventBus) {
+	for key := range p.proxiedHooks {
+		point, name, _ := strings.Cut(key, "/")
+		_ = manager.UnregisterHook(HookPoint(point), name)
+	}
+	for key := range p.proxiedHandlers {
+		eventType, name, _ := strings.Cut(key, "/")
+		_ = bus.Unsubscribe(EventType(eventType), name)
+	}
+	p.proxiedHooks = make(map[string]bool)
+	p.proxiedHandlers = make(map[string]bool)
+}
+
+// syncProxies 注销新版本模块不再提供的代理，并注册新增的钩子与事件处理器
+func (p *wasmPlugin) syncProxies(manager HookManager, bus EventBus) error {
+	meta := p.meta()
+
+	hooks := make(map[string]bool)
+	for _, desc := range meta.Hooks {
+		hooks[string(desc.Point)+"/"+desc.Name] = true
+	}
+	handlers := make(map[string]bool)
+	for _, desc := range meta.Handlers {
+		for _, eventType := range desc.EventTypes {
+			handlers[string(eventType)+"/"+desc.Name] = true
+		}
+	}
+	pruneProxies(p.proxiedHooks, hooks, p.proxiedHandlers, handlers, manager, bus)
+
+	if err := p.RegisterHooks(manager); err != nil {
+		return err
+	}
+	return p.RegisterEventHandlers(bus)
+}
+
+// wasmHook 由 WASM 模块实现的钩子
+type wasmHook struct {
+	plugin *wasmPlugin
+	desc   rpcHookDescriptor
+}
+
+func (h *wasmHook) GetName() string {
+	return h.desc.Name
+}
+
+func (h *wasmHook) GetPriority() int {
+	return h.desc.Priority
+}
+
+func (h *wasmHook) GetTimeout() time.Duration {
+	return h.desc.Timeout
+}
+
+// Execute 以 JSON 形式将 HookData 交给模块处理，并写回模块返回的数据
+func (h *wasmHook) Execute(ctx context.Context, data HookData) error {
+	var res wasmResult
+	err := h.plugin.call(ctx, "kratos_hook_execute", rpcHookData{
+		Point:    h.desc.Point,
+		Name:     h.desc.Name,
+		Data:     data.GetData(),
+		Metadata: data.GetMetadata(),
+	}, &res)
+	if err != nil {
+		return err
+	}
+
+	for k, v := range res.Data {
+		data.SetData(k, v)
+	}
+	if metadata := data.GetMetadata(); metadata != nil {
+		for k, v := range res.Metadata {
+			metadata[k] = v
+		}
+	}
+	return nil
+}
+
+// wasmEventHandler 由 WASM 模块实现的事件处理器
+type wasmEventHandler struct {
+	plugin *wasmPlugin
+	desc   rpcHandlerDescriptor
+}
+
+func (h *wasmEventHandler) GetName() string {
+	return h.desc.Name
+}
+
+func (h *wasmEventHandler) GetEventTypes() []EventType {
+	return h.desc.EventTypes
+}
+
+func (h *wasmEventHandler) GetTimeout() time.Duration {
+	return h.desc.Timeout
+}
+
+// Handle 将事件交给模块处理
+func (h *wasmEventHandler) Handle(ctx context.Context, event Event) error {
+	return h.plugin.call(ctx, "kratos_event_handle", rpcEvent{
+		Handler:   h.desc.Name,
+		ID:        event.GetID(),
+		Type:      event.GetType(),
+		Source:    event.GetSource(),
+		Timestamp: event.GetTimestamp(),
+		Data:      event.GetData(),
+		Metadata:  event.GetMetadata(),
+	}, nil)
+}
+
+// logWriter 将模块的标准输出按行转发到日志
+type logWriter struct {
+	mu    sync.Mutex
+	log   *log.Helper
+	level log.Level
+	buf   []byte
+}
+
+func (w *logWriter) Write(b []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.buf = append(w.buf, b...)
+	for {
+		i := bytes.IndexByte(w.buf, '\n')
+		if i < 0 {
+			break
+		}
+		if line := strings.TrimRight(string(w.buf[:i]), "\r"); line != "" {
+			w.log.Log(w.level, "msg", line)
+		}
+		w.buf = w.buf[i+1:]
+	}
+	return len(b), nil
+}
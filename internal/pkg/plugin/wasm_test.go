@@ -0,0 +1,173 @@
+package plugin
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-kratos/kratos/v2/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	sampleWasmOnce sync.Once
+	sampleWasmPath string
+	sampleWasmErr  error
+)
+
+// buildSampleWasm 编译 testdata/wasm_sample 示例模块
+func buildSampleWasm(t *testing.T) string {
+	t.Helper()
+
+	sampleWasmOnce.Do(func() {
+		dir, err := os.MkdirTemp("", "wasm-sample-")
+		if err != nil {
+			sampleWasmErr = err
+			return
+		}
+		sampleWasmPath = filepath.Join(dir, "wasm_sample.wasm")
+
+		cmd := exec.Command("go", "build", "-buildmode=c-shared", "-o", sampleWasmPath, ".")
+		cmd.Dir = filepath.Join("testdata", "wasm_sample")
+		cmd.Env = append(os.Environ(), "GOOS=wasip1", "GOARCH=wasm", "GOFLAGS=")
+		if out, err := cmd.CombinedOutput(); err != nil {
+			sampleWasmErr = errors.New(string(out))
+		}
+	})
+
+	if sampleWasmErr != nil {
+		t.Skipf("cannot build sample wasm module: %v", sampleWasmErr)
+	}
+	return sampleWasmPath
+}
+
+func newTestWasmPlugin(t *testing.T, sandbox SandboxConfig) *wasmPlugin {
+	t.Helper()
+	p, err := newWasmPlugin(context.Background(), buildSampleWasm(t), sandbox, log.DefaultLogger)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = p.close(context.Background()) })
+	return p
+}
+
+func TestWasmPluginHooksAndEvents(t *testing.T) {
+	hm := NewHookManager()
+	eb := NewEventBus(5)
+	pm := NewPluginManager(NewPluginRegistry(), hm, eb, t.TempDir(), t.TempDir())
+
+	require.NoError(t, pm.LoadPlugin(buildSampleWasm(t)))
+	require.NoError(t, pm.UpdatePluginConfig("wasm_sample", PluginConfig{
+		Enabled:  true,
+		Settings: map[string]interface{}{"greeting": "hello"},
+	}))
+	require.NoError(t, pm.StartPlugin("wasm_sample"))
+
+	info := pm.ListPlugins()
+	require.Len(t, info, 1)
+	assert.Equal(t, "0.1.0", info[0].Metadata.Version)
+	assert.Equal(t, PluginStatusStarted, info[0].Status)
+
+	data := NewHookData(context.Background(), map[string]interface{}{"path": "/api/v1/users"})
+	require.NoError(t, hm.ExecuteHooks(context.Background(), HookPointBeforeRequest, data))
+	assert.Equal(t, "wasm_sample", data.GetData()["handled_by"])
+	assert.Equal(t, "hello", data.GetData()["greeting"])
+	assert.Equal(t, "true", data.GetMetadata()["wasm"])
+
+	data = NewHookData(context.Background(), map[string]interface{}{"username": "blocked"})
+	err := hm.ExecuteHooks(context.Background(), HookPointBeforeAuth, data)
+	require.Error(t, err)
+	var pe *PluginError
+	require.True(t, errors.As(err, &pe))
+	require.True(t, errors.As(pe.Cause, &pe))
+	assert.Equal(t, ErrCodePluginPermission, pe.Code)
+
+	require.NoError(t, eb.Publish(context.Background(), NewEvent(EventUserLogin, "test", map[string]interface{}{"user": "alice"})))
+	require.Error(t, eb.Publish(context.Background(), NewEvent(EventUserLogin, "test", map[string]interface{}{"fail": true})))
+
+	require.NoError(t, pm.UnloadPlugin("wasm_sample"))
+	assert.Empty(t, hm.ListHooks(HookPointBeforeRequest))
+}
+
+func TestWasmPluginExecutionTimeout(t *testing.T) {
+	sandbox := DefaultSandboxConfig()
+	sandbox.MaxCPUPercent = 0
+	sandbox.MaxExecutionTime = 200 * time.Millisecond
+	p := newTestWasmPlugin(t, sandbox)
+
+	hook := &wasmHook{plugin: p, desc: rpcHookDescriptor{Point: HookPointBeforeRequest, Name: "wasm_sample_before_request"}}
+
+	start := time.Now()
+	err := hook.Execute(context.Background(), NewHookData(context.Background(), map[string]interface{}{"spin": true}))
+	require.Error(t, err)
+	var pe *PluginError
+	require.True(t, errors.As(err, &pe))
+	assert.Equal(t, ErrCodePluginTimeout, pe.Code)
+	assert.Less(t, time.Since(start), 5*time.Second)
+
+	// 被终止的实例在下一次调用时重建
+	data := NewHookData(context.Background(), map[string]interface{}{})
+	require.NoError(t, hook.Execute(context.Background(), data))
+	assert.Equal(t, "wasm_sample", data.GetData()["handled_by"])
+}
+
+func TestWasmPluginMemoryLimit(t *testing.T) {
+	sandbox := DefaultSandboxConfig()
+	sandbox.MaxCPUPercent = 0
+	sandbox.MaxExecutionTime = 10 * time.Second
+	sandbox.MaxMemory = 64 << 20
+	p := newTestWasmPlugin(t, sandbox)
+
+	hook := &wasmHook{plugin: p, desc: rpcHookDescriptor{Point: HookPointBeforeRequest, Name: "wasm_sample_before_request"}}
+
+	data := NewHookData(context.Background(), map[string]interface{}{"allocate_mb": 8})
+	require.NoError(t, hook.Execute(context.Background(), data))
+
+	err := hook.Execute(context.Background(), NewHookData(context.Background(), map[string]interface{}{"allocate_mb": 128}))
+	require.Error(t, err)
+
+	data = NewHookData(context.Background(), map[string]interface{}{})
+	require.NoError(t, hook.Execute(context.Background(), data))
+}
+
+func TestWasmPluginCPUBudget(t *testing.T) {
+	budget := newCPUBudget(10, time.Second)
+	now := time.Now()
+
+	assert.True(t, budget.allow(now))
+	budget.consume(150 * time.Millisecond)
+	assert.False(t, budget.allow(now.Add(500*time.Millisecond)))
+	assert.True(t, budget.allow(now.Add(1100*time.Millisecond)))
+
+	assert.Nil(t, newCPUBudget(0, time.Second))
+	assert.True(t, (*cpuBudget)(nil).allow(now))
+}
+
+func TestParseMemorySize(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    uint64
+		wantErr bool
+	}{
+		{"100MB", 100 << 20, false},
+		{"512kb", 512 << 10, false},
+		{"1G", 1 << 30, false},
+		{"4096", 4096, false},
+		{"", 0, false},
+		{"lots", 0, true},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseMemorySize(tt.in)
+		if tt.wantErr {
+			assert.Error(t, err, tt.in)
+			continue
+		}
+		require.NoError(t, err, tt.in)
+		assert.Equal(t, tt.want, got, tt.in)
+	}
+}
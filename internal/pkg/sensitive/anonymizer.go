@@ -4,8 +4,9 @@ import (
 	"encoding/json"
 	"fmt"
 	"reflect"
-	"regexp"
 	"strings"
+
+	"google.golang.org/protobuf/proto"
 )
 
 // anonymizer 脱敏处理器实现
@@ -68,6 +69,11 @@ func (a *anonymizer) AnonymizeValue(value interface{}, rules map[string]Anonymiz
 		return a.AnonymizeObject(sensitive)
 	}
 	
+	// protobuf 消息按字段上声明的敏感类型脱敏，字段名规则仅用于非 protobuf 值
+	if msg, ok := value.(proto.Message); ok {
+		return anonymizeProto(msg, rules)
+	}
+	
 	// 使用反射处理结构体
 	return a.anonymizeValueByReflection(value, rules)
 }
@@ -158,19 +164,7 @@ func (a *anonymizer) anonymizeMap(v reflect.Value, rules map[string]AnonymizeRul
 // anonymizeStringValue 脱敏字符串值
 func (a *anonymizer) anonymizeStringValue(value string, rules map[string]AnonymizeRule) string {
 	// 检测字符串中的敏感信息
-	detected := a.detector.DetectAll(value)
-	
-	result := value
-	for fieldType, matches := range detected {
-		if rule, exists := rules[fieldType]; exists {
-			for _, match := range matches {
-				anonymized := a.AnonymizeString(match, rule)
-				result = strings.ReplaceAll(result, match, anonymized)
-			}
-		}
-	}
-	
-	return result
+	return maskDetected(a.detector, value, rules, a)
 }
 
 // fieldAnonymizer 字段脱敏器实现
@@ -275,19 +269,7 @@ func (l *logSanitizer) SanitizeLogMessage(message string) string {
 	}
 	
 	// 检测并脱敏敏感信息
-	detected := l.detector.DetectAll(message)
-	
-	result := message
-	for fieldType, matches := range detected {
-		if rule, exists := l.rules[fieldType]; exists {
-			for _, match := range matches {
-				anonymized := l.anonymizer.AnonymizeString(match, rule)
-				result = strings.ReplaceAll(result, match, anonymized)
-			}
-		}
-	}
-	
-	return result
+	return maskDetected(l.detector, message, l.rules, l.anonymizer)
 }
 
 // ContainsSensitiveInfo 检查是否包含敏感信息
@@ -296,38 +278,39 @@ func (l *logSanitizer) ContainsSensitiveInfo(content string) bool {
 	return len(detected) > 0
 }
 
-// sensitiveDetector 敏感信息检测器实现
+// sensitiveDetector 敏感信息检测器实现，检测类型来自检测器注册表
 type sensitiveDetector struct {
-	emailRegex    *regexp.Regexp
-	phoneRegex    *regexp.Regexp
-	idCardRegex   *regexp.Regexp
-	bankCardRegex *regexp.Regexp
+	registry *DetectorRegistry
 }
 
-// NewSensitiveDetector 创建敏感信息检测器
+// NewSensitiveDetector 创建使用全局检测器注册表的敏感信息检测器
 func NewSensitiveDetector() SensitiveDetector {
-	return &sensitiveDetector{
-		emailRegex:    regexp.MustCompile(`[a-zA-Z0-9._%+-]+@[a-zA-Z0-9.-]+\.[a-zA-Z]{2,}`),
-		phoneRegex:    regexp.MustCompile(`1[3-9]\d{9}`),
-		idCardRegex:   regexp.MustCompile(`[1-9]\d{5}(18|19|20)\d{2}(0[1-9]|1[0-2])(0[1-9]|[12]\d|3[01])\d{3}[\dXx]`),
-		bankCardRegex: regexp.MustCompile(`[1-9]\d{11,19}`),
-	}
+	return NewSensitiveDetectorWithRegistry(DefaultDetectorRegistry())
+}
+
+// NewSensitiveDetectorWithRegistry 创建使用指定注册表的敏感信息检测器
+func NewSensitiveDetectorWithRegistry(registry *DetectorRegistry) SensitiveDetector {
+	return &sensitiveDetector{registry: registry}
+}
+
+// detectorAliases 兼容旧的类型名称
+var detectorAliases = map[string]string{
+	"idcard":   "id_card",
+	"bankcard": "bank_card",
+	"apikey":   "api_key",
 }
 
 // DetectSensitiveInfo 检测特定类型的敏感信息
 func (s *sensitiveDetector) DetectSensitiveInfo(text string, infoType string) []string {
-	switch strings.ToLower(infoType) {
-	case "email":
-		return s.DetectEmail(text)
-	case "phone":
-		return s.DetectPhone(text)
-	case "idcard", "id_card":
-		return s.DetectIDCard(text)
-	case "bankcard", "bank_card":
-		return s.DetectBankCard(text)
-	default:
+	name := strings.ToLower(infoType)
+	if alias, ok := detectorAliases[name]; ok {
+		name = alias
+	}
+	d, ok := s.registry.Get(name)
+	if !ok {
 		return nil
 	}
+	return d.Find(text)
 }
 
 // HasSensitiveInfo 检查是否包含敏感信息
@@ -338,43 +321,51 @@ func (s *sensitiveDetector) HasSensitiveInfo(text string, infoType string) bool
 
 // DetectEmail 检测邮箱
 func (s *sensitiveDetector) DetectEmail(text string) []string {
-	return s.emailRegex.FindAllString(text, -1)
+	return s.DetectSensitiveInfo(text, "email")
 }
 
 // DetectPhone 检测手机号
 func (s *sensitiveDetector) DetectPhone(text string) []string {
-	return s.phoneRegex.FindAllString(text, -1)
+	return s.DetectSensitiveInfo(text, "phone")
 }
 
 // DetectIDCard 检测身份证号
 func (s *sensitiveDetector) DetectIDCard(text string) []string {
-	return s.idCardRegex.FindAllString(text, -1)
+	return s.DetectSensitiveInfo(text, "id_card")
 }
 
 // DetectBankCard 检测银行卡号
 func (s *sensitiveDetector) DetectBankCard(text string) []string {
-	return s.bankCardRegex.FindAllString(text, -1)
+	return s.DetectSensitiveInfo(text, "bank_card")
 }
 
-// DetectAll 检测所有敏感信息
+// DetectAll 检测所有敏感信息，按需启用的检测器（IP 地址）只能经由 DetectSensitiveInfo 指定类型检测
 func (s *sensitiveDetector) DetectAll(text string) map[string][]string {
 	result := make(map[string][]string)
-	
-	if emails := s.DetectEmail(text); len(emails) > 0 {
-		result["email"] = emails
-	}
-	
-	if phones := s.DetectPhone(text); len(phones) > 0 {
-		result["phone"] = phones
+	for _, d := range s.registry.Detectors() {
+		if d.OptIn {
+			continue
+		}
+		if matches := d.Find(text); len(matches) > 0 {
+			result[d.Name] = matches
+		}
 	}
-	
-	if idCards := s.DetectIDCard(text); len(idCards) > 0 {
-		result["id_card"] = idCards
+	return result
+}
+
+// maskDetected 检测并脱敏文本中的敏感信息
+// 注册表检测器按注册顺序处理并使用检测器的默认规则；其他实现只处理 rules 中有规则的类型。
+func maskDetected(detector SensitiveDetector, text string, rules map[string]AnonymizeRule, anonymizer Anonymizer) string {
+	if d, ok := detector.(*sensitiveDetector); ok {
+		return d.registry.Mask(text, rules, anonymizer)
 	}
-	
-	if bankCards := s.DetectBankCard(text); len(bankCards) > 0 {
-		result["bank_card"] = bankCards
+	result := text
+	for fieldType, matches := range detector.DetectAll(text) {
+		if rule, exists := rules[fieldType]; exists {
+			for _, match := range matches {
+				result = strings.ReplaceAll(result, match, anonymizer.AnonymizeString(match, rule))
+			}
+		}
 	}
-	
 	return result
-}
\ No newline at end of file
+}
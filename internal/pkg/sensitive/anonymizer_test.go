@@ -396,14 +396,14 @@ func TestSensitiveDetector_Methods(t *testing.T) {
 	assert.Equal(t, "13812345678", phones[0])
 	
 	// 测试身份证检测
-	idCards := detector.DetectSensitiveInfo("My ID is 110101199001011234", "idcard")
+	idCards := detector.DetectSensitiveInfo("My ID is 110101199001011237", "idcard")
 	assert.Len(t, idCards, 1)
-	assert.Equal(t, "110101199001011234", idCards[0])
+	assert.Equal(t, "110101199001011237", idCards[0])
 	
 	// 测试银行卡检测
-	bankCards := detector.DetectSensitiveInfo("Card number: 6222021234567890", "bankcard")
+	bankCards := detector.DetectSensitiveInfo("Card number: 6222021234567894", "bankcard")
 	assert.Len(t, bankCards, 1)
-	assert.Equal(t, "6222021234567890", bankCards[0])
+	assert.Equal(t, "6222021234567894", bankCards[0])
 	
 	// 测试HasSensitiveInfo方法
 	assert.True(t, detector.HasSensitiveInfo("test@example.com", "email"))
@@ -0,0 +1,456 @@
+package sensitive
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+
+	"gopkg.in/yaml.v2"
+)
+
+// 敏感信息检测器注册表
+//
+// 每个检测器由正则、可选的校验函数与默认脱敏规则组成：正则负责找出候选值，校验函数过滤
+// 格式相符但校验位不对的误报（例如不满足 Luhn 的长数字串）。DetectAll、LogSanitizer 与
+// StructuredLogger 的自动检测都按注册顺序遍历同一个注册表，自定义检测器可从 YAML 加载。
+// IP 地址等按需启用的检测器默认不参与，日志器在 CustomRules 中加入同名规则（如 ipv4）后才脱敏。
+
+var (
+	// ErrInvalidDetector 检测器定义不完整或正则无法编译
+	ErrInvalidDetector = errors.New("invalid sensitive detector")
+	// ErrUnknownValidator 引用了未注册的校验函数
+	ErrUnknownValidator = errors.New("unknown detector validator")
+)
+
+// Validator 检测器校验函数，返回 false 的候选值不视为敏感信息
+type Validator func(match string) bool
+
+// Detector 敏感信息检测器
+type Detector struct {
+	// Name 类型名称，即 DetectAll 结果与脱敏规则的键
+	Name string
+	// Pattern 候选值正则
+	Pattern *regexp.Regexp
+	// Validate 校验函数，为 nil 时不校验
+	Validate Validator
+	// Rule 默认脱敏规则，调用方传入同名规则时以调用方为准
+	Rule AnonymizeRule
+	// DigitBoundary 候选值前后不能紧邻数字，避免手机号等数字串匹配更长数字串（纳秒耗时、ID）的一部分
+	DigitBoundary bool
+	// OptIn 按需启用：不参与 DetectAll 与默认脱敏，调用方传入同名规则时才脱敏。
+	// IP 地址在监听地址、访问日志中大量出现，默认脱敏会使运维日志无法使用
+	OptIn bool
+}
+
+// Find 查找文本中通过校验的敏感信息
+func (d *Detector) Find(text string) []string {
+	var valid []string
+	for _, loc := range d.Pattern.FindAllStringIndex(text, -1) {
+		if d.DigitBoundary && (loc[0] > 0 && isDigit(text[loc[0]-1]) || loc[1] < len(text) && isDigit(text[loc[1]])) {
+			continue
+		}
+		m := text[loc[0]:loc[1]]
+		if d.Validate == nil || d.Validate(m) {
+			valid = append(valid, m)
+		}
+	}
+	return valid
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+// DetectorRegistry 敏感信息检测器注册表，按注册顺序检测与脱敏
+type DetectorRegistry struct {
+	mu        sync.RWMutex
+	detectors []*Detector
+}
+
+// NewDetectorRegistry 创建包含内置检测器的注册表
+func NewDetectorRegistry() *DetectorRegistry {
+	return &DetectorRegistry{detectors: BuiltinDetectors()}
+}
+
+var defaultDetectorRegistry = NewDetectorRegistry()
+
+// DefaultDetectorRegistry 获取全局检测器注册表，NewSensitiveDetector 使用该注册表
+func DefaultDetectorRegistry() *DetectorRegistry {
+	return defaultDetectorRegistry
+}
+
+// Register 注册检测器，同名检测器被替换并保留原有顺序
+func (r *DetectorRegistry) Register(d *Detector) error {
+	if d == nil || d.Name == "" || d.Pattern == nil {
+		return fmt.Errorf("%w: name and pattern are required", ErrInvalidDetector)
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i, existing := range r.detectors {
+		if existing.Name == d.Name {
+			r.detectors[i] = d
+			return nil
+		}
+	}
+	r.detectors = append(r.detectors, d)
+	return nil
+}
+
+// Unregister 移除检测器
+func (r *DetectorRegistry) Unregister(name string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i, d := range r.detectors {
+		if d.Name == name {
+			r.detectors = append(r.detectors[:i:i], r.detectors[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// Get 按类型名称获取检测器
+func (r *DetectorRegistry) Get(name string) (*Detector, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for _, d := range r.detectors {
+		if d.Name == name {
+			return d, true
+		}
+	}
+	return nil, false
+}
+
+// Detectors 按注册顺序返回检测器列表的副本
+func (r *DetectorRegistry) Detectors() []*Detector {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return append([]*Detector(nil), r.detectors...)
+}
+
+// Mask 按注册顺序检测并脱敏文本，rules 中的同名规则覆盖检测器的默认规则，按需启用的检测器只在
+// rules 中有同名规则时参与。先脱敏的值不再被后续检测器匹配，因此结构更明确的检测器（私钥、JWT、
+// 身份证号）排在前面。
+func (r *DetectorRegistry) Mask(text string, rules map[string]AnonymizeRule, anonymizer Anonymizer) string {
+	if text == "" {
+		return text
+	}
+	for _, d := range r.Detectors() {
+		rule, ok := rules[d.Name]
+		if !ok {
+			if d.OptIn {
+				continue
+			}
+			rule = d.Rule
+		}
+		matches := d.Find(text)
+		if len(matches) == 0 {
+			continue
+		}
+		for _, match := range matches {
+			text = strings.ReplaceAll(text, match, anonymizer.AnonymizeString(match, rule))
+		}
+	}
+	return text
+}
+
+// DetectorConfig YAML 中的检测器定义
+type DetectorConfig struct {
+	// Name 类型名称，与内置检测器同名时替换内置检测器
+	Name string `yaml:"name" json:"name"`
+	// Pattern 候选值正则
+	Pattern string `yaml:"pattern" json:"pattern"`
+	// Validator 校验函数名称，见 RegisterValidator
+	Validator string `yaml:"validator" json:"validator,omitempty"`
+	// KeepStart 保留开头字符数
+	KeepStart int `yaml:"keep_start" json:"keep_start"`
+	// KeepEnd 保留结尾字符数
+	KeepEnd int `yaml:"keep_end" json:"keep_end"`
+	// MaskChar 遮盖字符，默认 *
+	MaskChar string `yaml:"mask_char" json:"mask_char,omitempty"`
+	// Redact 完全隐藏，忽略 KeepStart 与 KeepEnd
+	Redact bool `yaml:"redact" json:"redact,omitempty"`
+	// DigitBoundary 候选值前后不能紧邻数字
+	DigitBoundary bool `yaml:"digit_boundary" json:"digit_boundary,omitempty"`
+	// OptIn 按需启用，见 Detector.OptIn
+	OptIn bool `yaml:"opt_in" json:"opt_in,omitempty"`
+	// Disabled 移除同名检测器，用于关闭误报较多的内置检测器
+	Disabled bool `yaml:"disabled" json:"disabled,omitempty"`
+}
+
+// detectorFile 检测器配置文件格式
+type detectorFile struct {
+	Detectors []DetectorConfig `yaml:"detectors"`
+}
+
+// NewDetector 根据配置创建检测器
+func NewDetector(c DetectorConfig) (*Detector, error) {
+	if c.Name == "" || c.Pattern == "" {
+		return nil, fmt.Errorf("%w: name and pattern are required", ErrInvalidDetector)
+	}
+	pattern, err := regexp.Compile(c.Pattern)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s: %v", ErrInvalidDetector, c.Name, err)
+	}
+	d := &Detector{Name: c.Name, Pattern: pattern, DigitBoundary: c.DigitBoundary, OptIn: c.OptIn}
+	if c.Validator != "" {
+		v, ok := lookupValidator(c.Validator)
+		if !ok {
+			return nil, fmt.Errorf("%w: %s: %s", ErrUnknownValidator, c.Name, c.Validator)
+		}
+		d.Validate = v
+	}
+	if c.Redact {
+		d.Rule = RedactRule
+	} else {
+		d.Rule = AnonymizeRule{FieldName: c.Name, KeepStart: c.KeepStart, KeepEnd: c.KeepEnd, MaskChar: c.MaskChar}
+	}
+	return d, nil
+}
+
+// LoadYAML 从 YAML 加载自定义检测器，任一定义无效时不修改注册表
+func (r *DetectorRegistry) LoadYAML(data []byte) error {
+	var file detectorFile
+	if err := yaml.Unmarshal(data, &file); err != nil {
+		return fmt.Errorf("parse detectors: %w", err)
+	}
+	detectors := make([]*Detector, len(file.Detectors))
+	for i, c := range file.Detectors {
+		if c.Disabled {
+			continue
+		}
+		d, err := NewDetector(c)
+		if err != nil {
+			return err
+		}
+		detectors[i] = d
+	}
+	for i, c := range file.Detectors {
+		if c.Disabled {
+			r.Unregister(c.Name)
+			continue
+		}
+		if err := r.Register(detectors[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// LoadFile 从 YAML 文件加载自定义检测器
+func (r *DetectorRegistry) LoadFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("read detectors: %w", err)
+	}
+	if err := r.LoadYAML(data); err != nil {
+		return fmt.Errorf("load detectors %s: %w", path, err)
+	}
+	return nil
+}
+
+// 校验函数注册表，YAML 中按名称引用
+var validators = struct {
+	sync.RWMutex
+	m map[string]Validator
+}{m: map[string]Validator{
+	"luhn":    ValidateLuhn,
+	"id_card": ValidateIDCard,
+	"jwt":     ValidateJWT,
+	"iban":    ValidateIBAN,
+	"ipv4":    ValidateIPv4,
+	"ipv6":    ValidateIPv6,
+}}
+
+// RegisterValidator 注册校验函数，供 YAML 中的检测器引用
+func RegisterValidator(name string, v Validator) {
+	validators.Lock()
+	defer validators.Unlock()
+	validators.m[name] = v
+}
+
+func lookupValidator(name string) (Validator, bool) {
+	validators.RLock()
+	defer validators.RUnlock()
+	v, ok := validators.m[name]
+	return v, ok
+}
+
+// BuiltinDetectors 内置检测器，按脱敏顺序排列
+func BuiltinDetectors() []*Detector {
+	return []*Detector{
+		{
+			Name:    "private_key",
+			Pattern: regexp.MustCompile(`-----BEGIN (?:[A-Z0-9]+ )*PRIVATE KEY-----[\s\S]*?-----END (?:[A-Z0-9]+ )*PRIVATE KEY-----`),
+			Rule:    PrivateKeyRule,
+		},
+		{
+			Name:     "jwt",
+			Pattern:  regexp.MustCompile(`eyJ[A-Za-z0-9_-]+\.eyJ[A-Za-z0-9_-]+\.[A-Za-z0-9_-]*`),
+			Validate: ValidateJWT,
+			Rule:     JWTRule,
+		},
+		{
+			Name:    "api_key",
+			Pattern: regexp.MustCompile(`\b(?:(?:sk|pk|rk)_(?:live|test)_[A-Za-z0-9]{16,}|AKIA[0-9A-Z]{16}|gh[pousr]_[A-Za-z0-9]{36}|AIza[0-9A-Za-z_-]{35}|xox[abprs]-[A-Za-z0-9-]{10,})\b`),
+			Rule:    APIKeyRule,
+		},
+		{
+			Name:    "email",
+			Pattern: regexp.MustCompile(`[a-zA-Z0-9._%+-]+@[a-zA-Z0-9.-]+\.[a-zA-Z]{2,}`),
+			Rule:    EmailRule,
+		},
+		{
+			Name:     "iban",
+			Pattern:  regexp.MustCompile(`\b[A-Z]{2}\d{2}[A-Z0-9]{11,30}\b`),
+			Validate: ValidateIBAN,
+			Rule:     IBANRule,
+		},
+		{
+			Name:          "id_card",
+			Pattern:       regexp.MustCompile(`[1-9]\d{5}(18|19|20)\d{2}(0[1-9]|1[0-2])(0[1-9]|[12]\d|3[01])\d{3}[\dXx]`),
+			Validate:      ValidateIDCard,
+			Rule:          IDCardRule,
+			DigitBoundary: true,
+		},
+		{
+			Name:          "bank_card",
+			Pattern:       regexp.MustCompile(`[1-9]\d{11,19}`),
+			Validate:      ValidateLuhn,
+			Rule:          BankCardRule,
+			DigitBoundary: true,
+		},
+		{
+			Name:          "phone",
+			Pattern:       regexp.MustCompile(`1[3-9]\d{9}`),
+			Rule:          PhoneRule,
+			DigitBoundary: true,
+		},
+		{
+			Name:    "passport",
+			Pattern: regexp.MustCompile(`\b(?:[EGDSP]\d{8}|E[A-HJ-NP-Z]\d{7})\b`),
+			Rule:    PassportRule,
+		},
+		{
+			Name:     "ipv6",
+			Pattern:  regexp.MustCompile(`[0-9A-Fa-f]{0,4}(?::[0-9A-Fa-f]{0,4}){2,7}`),
+			Validate: ValidateIPv6,
+			Rule:     IPv6Rule,
+			OptIn:    true,
+		},
+		{
+			Name:     "ipv4",
+			Pattern:  regexp.MustCompile(`\b(?:\d{1,3}\.){3}\d{1,3}\b`),
+			Validate: ValidateIPv4,
+			Rule:     IPv4Rule,
+			OptIn:    true,
+		},
+	}
+}
+
+// ValidateLuhn Luhn 校验，用于银行卡号
+func ValidateLuhn(number string) bool {
+	if len(number) < 2 {
+		return false
+	}
+	sum := 0
+	double := false
+	for i := len(number) - 1; i >= 0; i-- {
+		c := number[i]
+		if c < '0' || c > '9' {
+			return false
+		}
+		d := int(c - '0')
+		if double {
+			d *= 2
+			if d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+		double = !double
+	}
+	return sum%10 == 0
+}
+
+// ValidateIDCard 18 位居民身份证号校验码校验（GB 11643）
+func ValidateIDCard(id string) bool {
+	if len(id) != 18 {
+		return false
+	}
+	weights := [17]int{7, 9, 10, 5, 8, 4, 2, 1, 6, 3, 7, 9, 10, 5, 8, 4, 2}
+	sum := 0
+	for i := 0; i < 17; i++ {
+		c := id[i]
+		if c < '0' || c > '9' {
+			return false
+		}
+		sum += int(c-'0') * weights[i]
+	}
+	return "10X98765432"[sum%11] == strings.ToUpper(id[17:])[0]
+}
+
+// ValidateJWT 校验 JWT 结构：头部与载荷均为 base64url 编码的 JSON 对象，头部包含 alg
+func ValidateJWT(token string) bool {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return false
+	}
+	var header map[string]interface{}
+	if !decodeJWTSegment(parts[0], &header) {
+		return false
+	}
+	if _, ok := header["alg"].(string); !ok {
+		return false
+	}
+	var claims map[string]interface{}
+	return decodeJWTSegment(parts[1], &claims)
+}
+
+func decodeJWTSegment(segment string, v interface{}) bool {
+	raw, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(segment, "="))
+	if err != nil {
+		return false
+	}
+	return json.Unmarshal(raw, v) == nil
+}
+
+// ValidateIBAN IBAN 校验（ISO 13616 mod 97）
+func ValidateIBAN(iban string) bool {
+	if len(iban) < 15 || len(iban) > 34 {
+		return false
+	}
+	rearranged := iban[4:] + iban[:4]
+	remainder := 0
+	for _, c := range rearranged {
+		switch {
+		case c >= '0' && c <= '9':
+			remainder = (remainder*10 + int(c-'0')) % 97
+		case c >= 'A' && c <= 'Z':
+			remainder = (remainder*100 + int(c-'A') + 10) % 97
+		default:
+			return false
+		}
+	}
+	return remainder == 1
+}
+
+// ValidateIPv4 校验 IPv4 地址
+func ValidateIPv4(ip string) bool {
+	parsed := net.ParseIP(ip)
+	return parsed != nil && parsed.To4() != nil && !strings.Contains(ip, ":")
+}
+
+// ValidateIPv6 校验 IPv6 地址，至少包含一个十六进制数字，排除单独的 "::"
+func ValidateIPv6(ip string) bool {
+	if !strings.Contains(ip, ":") || strings.Trim(ip, ":") == "" {
+		return false
+	}
+	return net.ParseIP(ip) != nil
+}
@@ -0,0 +1,143 @@
+package sensitive
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"encoding/binary"
+	"errors"
+	"math/big"
+)
+
+// FF1 保留格式加密（NIST SP 800-38G），只实现十进制数字串的加密。
+// FF1 在同一密钥与 tweak 下是数字串上的置换，不同输入必然得到不同输出，手机号令牌据此避免冲突。
+
+const (
+	ff1Radix  = 10
+	ff1Rounds = 10
+	// ff1MinLength 十进制下 radix^minlen >= 1000000 要求的最小长度
+	ff1MinLength = 6
+	// ff1MaxLength 本实现支持的最大长度，手机号远小于此值
+	ff1MaxLength = 64
+)
+
+var errFF1Length = errors.New("ff1: numeral string length out of range")
+
+// ff1Cipher 十进制 FF1 加密器
+type ff1Cipher struct {
+	block cipher.Block
+}
+
+// newFF1Cipher 创建 FF1 加密器，key 为 16、24 或 32 字节的 AES 密钥
+func newFF1Cipher(key []byte) (*ff1Cipher, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return &ff1Cipher{block: block}, nil
+}
+
+// encrypt 加密十进制数字串，digits 中每个元素为 0-9 的数值
+func (c *ff1Cipher) encrypt(tweak []byte, digits []byte) ([]byte, error) {
+	n := len(digits)
+	if n < ff1MinLength || n > ff1MaxLength {
+		return nil, errFF1Length
+	}
+	u := n / 2
+	v := n - u
+	a := append([]byte(nil), digits[:u]...)
+	b := append([]byte(nil), digits[u:]...)
+
+	// b 字节数 = ceil(ceil(v*log2(10))/8)，以 10^v - 1 的字节长度计算
+	maxB := new(big.Int).Sub(new(big.Int).Exp(big.NewInt(ff1Radix), big.NewInt(int64(v)), nil), big.NewInt(1))
+	byteLen := (maxB.BitLen() + 7) / 8
+	d := 4*((byteLen+3)/4) + 4
+
+	p := make([]byte, 16)
+	p[0], p[1], p[2] = 1, 2, 1
+	p[3], p[4], p[5] = byte(ff1Radix>>16), byte(ff1Radix>>8), byte(ff1Radix)
+	p[6] = ff1Rounds
+	p[7] = byte(u % 256)
+	binary.BigEndian.PutUint32(p[8:12], uint32(n))
+	binary.BigEndian.PutUint32(p[12:16], uint32(len(tweak)))
+
+	pad := (16 - (len(tweak)+byteLen+1)%16) % 16
+	q := make([]byte, len(tweak)+pad+1+byteLen)
+	copy(q, tweak)
+
+	modU := new(big.Int).Exp(big.NewInt(ff1Radix), big.NewInt(int64(u)), nil)
+	modV := new(big.Int).Exp(big.NewInt(ff1Radix), big.NewInt(int64(v)), nil)
+	for i := 0; i < ff1Rounds; i++ {
+		q[len(tweak)+pad] = byte(i)
+		numB := numRadix(b).Bytes()
+		tail := q[len(q)-byteLen:]
+		for j := range tail {
+			tail[j] = 0
+		}
+		copy(tail[byteLen-len(numB):], numB)
+
+		r := c.prf(append(append([]byte(nil), p...), q...))
+		s := c.expand(r, d)
+		y := new(big.Int).SetBytes(s)
+
+		m, mod := u, modU
+		if i%2 == 1 {
+			m, mod = v, modV
+		}
+		num := new(big.Int).Add(numRadix(a), y)
+		num.Mod(num, mod)
+		a, b = b, strRadix(num, m)
+	}
+	return append(a, b...), nil
+}
+
+// prf CBC-MAC，返回最后一个分组
+func (c *ff1Cipher) prf(x []byte) []byte {
+	y := make([]byte, aes.BlockSize)
+	for i := 0; i < len(x); i += aes.BlockSize {
+		for j := 0; j < aes.BlockSize; j++ {
+			y[j] ^= x[i+j]
+		}
+		c.block.Encrypt(y, y)
+	}
+	return y
+}
+
+// expand 将 R 扩展为 d 字节：R || CIPH(R xor [1]) || CIPH(R xor [2]) ...
+func (c *ff1Cipher) expand(r []byte, d int) []byte {
+	s := append([]byte(nil), r...)
+	for j := 1; len(s) < d; j++ {
+		block := append([]byte(nil), r...)
+		var counter [aes.BlockSize]byte
+		binary.BigEndian.PutUint64(counter[8:], uint64(j))
+		for k := range block {
+			block[k] ^= counter[k]
+		}
+		c.block.Encrypt(block, block)
+		s = append(s, block...)
+	}
+	return s[:d]
+}
+
+// numRadix 十进制数字串转为整数
+func numRadix(digits []byte) *big.Int {
+	x := new(big.Int)
+	ten := big.NewInt(ff1Radix)
+	for _, d := range digits {
+		x.Mul(x, ten)
+		x.Add(x, big.NewInt(int64(d)))
+	}
+	return x
+}
+
+// strRadix 整数转为 m 位十进制数字串，不足时高位补零
+func strRadix(x *big.Int, m int) []byte {
+	digits := make([]byte, m)
+	x = new(big.Int).Set(x)
+	ten := big.NewInt(ff1Radix)
+	rem := new(big.Int)
+	for i := m - 1; i >= 0; i-- {
+		x.QuoRem(x, ten, rem)
+		digits[i] = byte(rem.Int64())
+	}
+	return digits
+}
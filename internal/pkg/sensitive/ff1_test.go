@@ -0,0 +1,55 @@
+package sensitive
+
+import (
+	"encoding/hex"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func digitsOf(s string) []byte {
+	digits := make([]byte, len(s))
+	for i := range s {
+		digits[i] = s[i] - '0'
+	}
+	return digits
+}
+
+func stringOf(digits []byte) string {
+	b := make([]byte, len(digits))
+	for i, d := range digits {
+		b[i] = '0' + d
+	}
+	return string(b)
+}
+
+// TestFF1Cipher_NISTVectors 测试 NIST SP 800-38G 的十进制样例
+func TestFF1Cipher_NISTVectors(t *testing.T) {
+	tests := []struct {
+		name  string
+		key   string
+		tweak string
+		want  string
+	}{
+		{"sample1", "2B7E151628AED2A6ABF7158809CF4F3C", "", "2433477484"},
+		{"sample2", "2B7E151628AED2A6ABF7158809CF4F3C", "39383736353433323130", "6124200773"},
+		{"sample7", "2B7E151628AED2A6ABF7158809CF4F3CEF4359D8D580AA4F7F036D6F04FC6A94", "", "6657667009"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			key, _ := hex.DecodeString(tt.key)
+			tweak, _ := hex.DecodeString(tt.tweak)
+			c, err := newFF1Cipher(key)
+			require.NoError(t, err)
+			got, err := c.encrypt(tweak, digitsOf("0123456789"))
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, stringOf(got))
+		})
+	}
+
+	c, err := newFF1Cipher(make([]byte, 32))
+	require.NoError(t, err)
+	_, err = c.encrypt(nil, digitsOf("12345"))
+	assert.ErrorIs(t, err, errFF1Length)
+}
@@ -0,0 +1,338 @@
+package sensitive
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/go-kratos/kratos/v2/log"
+	"google.golang.org/protobuf/proto"
+)
+
+// 日志输出端脱敏
+//
+// StructuredLogger 只处理经由自身方法写入的日志，通过 log.Helper 写入的格式化消息与键值不经过它。
+// KeyValueSanitizer 在日志输出端对每个键值与消息统一脱敏：NewSanitizingLogger 包装 kratos 的
+// log.Logger，internal/pkg/log 的 zap core 也使用同一个 KeyValueSanitizer，业务代码无法绕过。
+
+// inlineRedactKeys 消息中 "键: 值" 形式出现时直接隐藏值的中文关键词，英文关键词来自 SensitiveKeys。
+// 中文键名没有分隔符，包含关键词即隐藏
+var inlineRedactKeys = []string{"验证码", "密码", "口令", "令牌", "密钥"}
+
+// logSensitiveKeys 未指定配置时日志隐藏的键名。单独的 key、auth 过于宽泛
+// （idempotency_key、auth_method），只隐藏带限定词的组合
+var logSensitiveKeys = []string{
+	"password", "passwd", "token", "secret", "credential", "credentials", "authorization", "cookie", "session",
+	"api_key", "apikey", "private_key", "secret_key", "access_key", "signing_key", "encryption_key",
+}
+
+// logKeyRules 日志中额外按键名脱敏的规则，GetDefaultRules 之外的用户标识
+var logKeyRules = map[string]AnonymizeRule{
+	"username": UsernameRule,
+	"user":     UsernameRule,
+	"用户":       UsernameRule,
+	"用户名":      UsernameRule,
+}
+
+// KeyValueSanitizer 日志键值与消息脱敏器
+type KeyValueSanitizer struct {
+	config      *StructuredLogConfig
+	rules       map[string]AnonymizeRule
+	redactKeys  [][]string
+	passthrough map[string]struct{}
+	anonymizer  Anonymizer
+	detector    SensitiveDetector
+	inline      *regexp.Regexp
+}
+
+// NewKeyValueSanitizer 创建日志键值与消息脱敏器
+//
+// 键名与 CustomRules 完全匹配时按规则脱敏；键名按 _ . - 与驼峰拆分后，连续几段与 SensitiveKeys
+// 中的某个键名相同时完全隐藏（api_key 匹配 X-Api-Key 与 apiKey，token 不匹配 tokenizer）；
+// 其他值在 AutoDetect 开启时检测邮箱、手机号等内容，IP 地址只在 CustomRules 含 ipv4、ipv6 规则时脱敏。消息中 "password: xxx"、"验证码: 123456"
+// 形式的片段按同样的键名规则处理。config 为 nil 时使用默认配置与 logSensitiveKeys。
+func NewKeyValueSanitizer(config *StructuredLogConfig) *KeyValueSanitizer {
+	if config == nil {
+		config = DefaultStructuredLogConfig()
+		config.SensitiveKeys = logSensitiveKeys
+	}
+
+	rules := MergeRules(logKeyRules, config.CustomRules)
+	redactKeys := make([][]string, 0, len(config.SensitiveKeys))
+	for _, key := range config.SensitiveKeys {
+		if segments := keySegments(key); len(segments) > 0 {
+			redactKeys = append(redactKeys, segments)
+		}
+	}
+	passthrough := make(map[string]struct{}, len(config.PassthroughKeys))
+	for _, key := range config.PassthroughKeys {
+		passthrough[strings.ToLower(key)] = struct{}{}
+	}
+
+	// 较长的关键词优先匹配
+	var keywords []string
+	for key := range rules {
+		keywords = append(keywords, regexp.QuoteMeta(key))
+	}
+	for _, segments := range redactKeys {
+		for _, segment := range segments {
+			keywords = append(keywords, regexp.QuoteMeta(segment))
+		}
+	}
+	for _, key := range inlineRedactKeys {
+		keywords = append(keywords, regexp.QuoteMeta(key))
+	}
+	sort.Slice(keywords, func(i, j int) bool { return len(keywords[i]) > len(keywords[j]) })
+
+	return &KeyValueSanitizer{
+		config:      config,
+		rules:       rules,
+		redactKeys:  redactKeys,
+		passthrough: passthrough,
+		anonymizer:  NewAnonymizer(),
+		detector:    NewSensitiveDetector(),
+		// 匹配包含关键词的完整键名，是否脱敏再按完整键名判断
+		inline: regexp.MustCompile(`(?i)(^|[^A-Za-z0-9_])([\w-]*(?:` + strings.Join(keywords, "|") + `)[\w-]*)("?\s*[:=：]\s*)("[^"]*"|[^\s,;，；)）]+)`),
+	}
+}
+
+// Enabled 是否启用脱敏
+func (s *KeyValueSanitizer) Enabled() bool {
+	return s.config.Enabled
+}
+
+// SensitiveKey 判断键名是否按规则脱敏或隐藏
+func (s *KeyValueSanitizer) SensitiveKey(key string) bool {
+	_, ok := s.keyRule(key)
+	return ok
+}
+
+// keyRule 查找键名对应的规则，包含敏感键名段的键使用 RedactRule
+func (s *KeyValueSanitizer) keyRule(key string) (AnonymizeRule, bool) {
+	lower := strings.ToLower(key)
+	if rule, ok := s.rules[lower]; ok {
+		return rule, true
+	}
+	segments := keySegments(key)
+	for _, redact := range s.redactKeys {
+		if containsSegments(segments, redact) {
+			return RedactRule, true
+		}
+	}
+	for _, keyword := range inlineRedactKeys {
+		if strings.Contains(lower, keyword) {
+			return RedactRule, true
+		}
+	}
+	return AnonymizeRule{}, false
+}
+
+// keySegments 将键名按非字母数字字符与驼峰拆分为小写的段，如 X-Api-Key、apiKey 均为 [api key]
+func keySegments(key string) []string {
+	var (
+		segments []string
+		current  []rune
+		prev     rune
+	)
+	flush := func() {
+		if len(current) > 0 {
+			segments = append(segments, strings.ToLower(string(current)))
+			current = current[:0]
+		}
+	}
+	for _, r := range key {
+		switch {
+		case !unicode.IsLetter(r) && !unicode.IsDigit(r):
+			flush()
+		case unicode.IsUpper(r) && (unicode.IsLower(prev) || unicode.IsDigit(prev)):
+			flush()
+			current = append(current, r)
+		default:
+			current = append(current, r)
+		}
+		prev = r
+	}
+	flush()
+	return segments
+}
+
+// containsSegments 判断 segments 中是否有连续几段与 sub 相同
+func containsSegments(segments, sub []string) bool {
+	for i := 0; i+len(sub) <= len(segments); i++ {
+		match := true
+		for j := range sub {
+			if segments[i+j] != sub[j] {
+				match = false
+				break
+			}
+		}
+		if match {
+			return true
+		}
+	}
+	return false
+}
+
+// SanitizeMessage 脱敏日志消息中的键值片段与可识别的敏感内容
+func (s *KeyValueSanitizer) SanitizeMessage(msg string) string {
+	if !s.config.Enabled || msg == "" {
+		return msg
+	}
+	msg = s.inline.ReplaceAllStringFunc(msg, func(match string) string {
+		sub := s.inline.FindStringSubmatch(match)
+		prefix, value := sub[1]+sub[2]+sub[3], sub[4]
+		// user_id、filename 等包含关键词的普通字段不处理
+		rule, ok := s.keyRule(sub[2])
+		if !ok {
+			return match
+		}
+		if len(value) >= 2 && strings.HasPrefix(value, `"`) && strings.HasSuffix(value, `"`) {
+			return prefix + `"` + maskAnnotated(value[1:len(value)-1], rule) + `"`
+		}
+		return prefix + maskAnnotated(value, rule)
+	})
+	if s.config.AutoDetect {
+		// CustomRules 中有 ipv4 等同名规则时，按需启用的检测器也参与
+		msg = maskDetected(s.detector, msg, s.rules, s.anonymizer)
+	}
+	return msg
+}
+
+// SanitizeValue 脱敏键对应的值，尽量保留值的类型以便结构化输出
+func (s *KeyValueSanitizer) SanitizeValue(key string, value interface{}) interface{} {
+	if !s.config.Enabled || value == nil {
+		return value
+	}
+	lower := strings.ToLower(key)
+	if _, ok := s.passthrough[lower]; ok {
+		return value
+	}
+
+	switch v := value.(type) {
+	case log.Valuer:
+		// 由 log.With 绑定、尚未求值的动态值
+		return value
+	case MakeSensitive:
+		return v.Anonymize()
+	case LogSafeStringer:
+		return v.LogSafeString()
+	case proto.Message:
+		return anonymizeProto(v, s.rules)
+	}
+
+	if rule, ok := s.keyRule(key); ok {
+		return maskAnnotated(fmt.Sprint(value), rule)
+	}
+
+	switch v := value.(type) {
+	case string:
+		return s.SanitizeMessage(v)
+	case error:
+		return s.SanitizeMessage(v.Error())
+	case bool, int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, float32, float64,
+		time.Time, time.Duration:
+		return value
+	case fmt.Stringer:
+		return s.SanitizeMessage(v.String())
+	case map[string]interface{}:
+		result := make(map[string]interface{}, len(v))
+		for k, item := range v {
+			result[k] = s.SanitizeValue(k, item)
+		}
+		return result
+	case []interface{}:
+		result := make([]interface{}, len(v))
+		for i, item := range v {
+			result[i] = s.SanitizeValue(key, item)
+		}
+		return result
+	}
+	return s.sanitizeReflect(key, reflect.ValueOf(value))
+}
+
+// sanitizeReflect 脱敏其他类型的值，结构体按字段名规则处理，容器逐个元素处理
+func (s *KeyValueSanitizer) sanitizeReflect(key string, v reflect.Value) interface{} {
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if v.IsNil() {
+			return nil
+		}
+		return s.SanitizeValue(key, v.Elem().Interface())
+	case reflect.Struct:
+		return s.anonymizer.AnonymizeValue(v.Interface(), s.rules)
+	case reflect.Slice, reflect.Array:
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			return v.Interface()
+		}
+		result := make([]interface{}, v.Len())
+		for i := 0; i < v.Len(); i++ {
+			result[i] = s.SanitizeValue(key, v.Index(i).Interface())
+		}
+		return result
+	case reflect.Map:
+		result := make(map[string]interface{}, v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			k := fmt.Sprint(iter.Key().Interface())
+			result[k] = s.SanitizeValue(k, iter.Value().Interface())
+		}
+		return result
+	case reflect.String:
+		return s.SanitizeMessage(v.String())
+	default:
+		return v.Interface()
+	}
+}
+
+// SanitizeKeyvals 脱敏 kratos 日志的键值列表，返回新的列表
+func (s *KeyValueSanitizer) SanitizeKeyvals(keyvals ...interface{}) []interface{} {
+	if !s.config.Enabled || len(keyvals) == 0 {
+		return keyvals
+	}
+	result := make([]interface{}, len(keyvals))
+	copy(result, keyvals)
+	for i := 0; i+1 < len(result); i += 2 {
+		key, ok := result[i].(string)
+		if !ok {
+			key = fmt.Sprint(result[i])
+		}
+		if key == log.DefaultMessageKey {
+			if msg, ok := result[i+1].(string); ok {
+				result[i+1] = s.SanitizeMessage(msg)
+				continue
+			}
+		}
+		result[i+1] = s.SanitizeValue(key, result[i+1])
+	}
+	return result
+}
+
+// sanitizingLogger 对每条日志脱敏后写入下层的 kratos 日志器
+type sanitizingLogger struct {
+	logger    log.Logger
+	sanitizer *KeyValueSanitizer
+}
+
+// NewSanitizingLogger 创建脱敏日志器，所有键值与消息在写入 logger 前脱敏
+//
+// 应放在日志链的最底层，直接包装输出日志器，再由 log.With 添加时间戳、调用位置等字段，
+// 这样 log.Valuer 已求值、调用位置的栈深度也不受影响：
+//
+//	logger := log.With(sensitive.NewSanitizingLogger(log.NewStdLogger(os.Stdout), nil), "ts", log.DefaultTimestamp)
+func NewSanitizingLogger(logger log.Logger, config *StructuredLogConfig) log.Logger {
+	return NewSanitizingLoggerWithSanitizer(logger, NewKeyValueSanitizer(config))
+}
+
+// NewSanitizingLoggerWithSanitizer 使用已有的脱敏器创建脱敏日志器
+func NewSanitizingLoggerWithSanitizer(logger log.Logger, sanitizer *KeyValueSanitizer) log.Logger {
+	return &sanitizingLogger{logger: logger, sanitizer: sanitizer}
+}
+
+// Log 实现 log.Logger 接口
+func (l *sanitizingLogger) Log(level log.Level, keyvals ...interface{}) error {
+	return l.logger.Log(level, l.sanitizer.SanitizeKeyvals(keyvals...)...)
+}
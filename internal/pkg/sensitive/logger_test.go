@@ -0,0 +1,141 @@
+package sensitive
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/go-kratos/kratos/v2/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// recordLogger 记录写入的键值
+type recordLogger struct {
+	keyvals []interface{}
+}
+
+func (r *recordLogger) Log(level log.Level, keyvals ...interface{}) error {
+	r.keyvals = keyvals
+	return nil
+}
+
+func (r *recordLogger) value(key string) interface{} {
+	for i := 0; i+1 < len(r.keyvals); i += 2 {
+		if r.keyvals[i] == key {
+			return r.keyvals[i+1]
+		}
+	}
+	return nil
+}
+
+func TestKeyValueSanitizer_SanitizeMessage(t *testing.T) {
+	s := NewKeyValueSanitizer(nil)
+
+	tests := []struct {
+		name string
+		msg  string
+		want string
+	}{
+		{"captcha", "【模拟发送短信】目标: 13812345678, 验证码: 123456", "【模拟发送短信】目标: 138****5678, 验证码: [REDACTED]"},
+		{"username", "password mismatch for user: alice", "password mismatch for user: al***"},
+		{"password", "login failed, password=Secret123 retry", "login failed, password=[REDACTED] retry"},
+		{"quoted token", `payload {"access_token":"eyJhbGci"}`, `payload {"access_token":"[REDACTED]"}`},
+		{"plain key prefix", "user_id: 42 loaded", "user_id: 42 loaded"},
+		{"word boundary", "filename: app.log", "filename: app.log"},
+		{"email", "send to john.doe@example.com", "send to jo******@example.com"},
+		{"header key", "X-Api-Key: k-123 rejected", "X-Api-Key: [REDACTED] rejected"},
+		{"harmless key", "author: alice, auth_method: password", "author: alice, auth_method: password"},
+		{"phone inside digits", "took 913812345678ns path=/orders/138123456789", "took 913812345678ns path=/orders/138123456789"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, s.SanitizeMessage(tt.msg))
+		})
+	}
+}
+
+func TestKeyValueSanitizer_SanitizeValue(t *testing.T) {
+	s := NewKeyValueSanitizer(nil)
+
+	assert.Equal(t, "[REDACTED]", s.SanitizeValue("refresh_token", "abc"))
+	assert.Equal(t, "[REDACTED]", s.SanitizeValue("Password", 123456))
+	assert.Equal(t, "138****5678", s.SanitizeValue("phone", "13812345678"))
+	// 非敏感键保留值的类型
+	assert.Equal(t, 42, s.SanitizeValue("attempts", 42))
+	assert.Equal(t, "connect jo**@example.com failed", s.SanitizeValue("error", errors.New("connect john@example.com failed")))
+	// 不脱敏的系统字段
+	assert.Equal(t, "13812345678901234", s.SanitizeValue("trace.id", "13812345678901234"))
+
+	nested := s.SanitizeValue("payload", map[string]interface{}{
+		"email":  "john.doe@example.com",
+		"secret": "s3cr3t",
+		"tags":   []interface{}{"ok"},
+	}).(map[string]interface{})
+	assert.Equal(t, "jo******@example.com", nested["email"])
+	assert.Equal(t, "[REDACTED]", nested["secret"])
+	assert.Equal(t, []interface{}{"ok"}, nested["tags"])
+
+	typed := s.SanitizeValue("headers", map[string][]string{"X-Api-Key": {"k-123"}}).(map[string]interface{})
+	assert.Equal(t, "[REDACTED]", typed["X-Api-Key"])
+
+	// 实现 LogSafeStringer 的值由自身决定输出
+	assert.Equal(t, "[SAFE]alice", s.SanitizeValue("user", mockLogSafeStringer{value: "alice"}))
+}
+
+// TestKeyValueSanitizer_KeySegments 测试键名按整段匹配敏感键名
+func TestKeyValueSanitizer_KeySegments(t *testing.T) {
+	s := NewKeyValueSanitizer(nil)
+
+	for _, key := range []string{"password", "access_token", "accessToken", "X-Api-Key", "apiKey", "client.secret", "private-key", "Authorization"} {
+		assert.True(t, s.SensitiveKey(key), key)
+	}
+	for _, key := range []string{"author", "idempotency_key", "monkey", "auth_method", "tokenizer", "key", "keyboard"} {
+		assert.False(t, s.SensitiveKey(key), key)
+	}
+	assert.Equal(t, []string{"x", "api", "key"}, keySegments("X-Api-Key"))
+	assert.Equal(t, []string{"access", "token", "v2"}, keySegments("accessToken_v2"))
+}
+
+func TestNewSanitizingLogger(t *testing.T) {
+	out := &recordLogger{}
+	logger := log.With(NewSanitizingLogger(out, nil), "caller", "auth/strategies.go:74")
+	helper := log.NewHelper(logger)
+
+	helper.Infof("【模拟生成图片验证码】验证码: %s", "a1b2")
+	assert.Equal(t, "【模拟生成图片验证码】验证码: [REDACTED]", out.value(log.DefaultMessageKey))
+	assert.Equal(t, "auth/strategies.go:74", out.value("caller"))
+
+	helper.Infow("username", "alice", "password", "MyPassword123!", "ip", "10.0.0.1")
+	assert.Equal(t, "al***", out.value("username"))
+	assert.Equal(t, "[REDACTED]", out.value("password"))
+	// IP 地址默认不脱敏，运维日志中的监听地址与客户端地址保持原样
+	assert.Equal(t, "10.0.0.1", out.value("ip"))
+
+	// 日志器按需启用 IP 检测，或只对指定字段脱敏
+	config := DefaultStructuredLogConfig()
+	config.CustomRules = MergeRules(config.CustomRules, map[string]AnonymizeRule{"ipv4": IPv4Rule})
+	optIn := &recordLogger{}
+	require.NoError(t, NewSanitizingLogger(optIn, config).Log(log.LevelInfo, "msg", "listen on 10.0.0.5:9000"))
+	assert.Equal(t, "listen on 10.0.*.*:9000", optIn.value("msg"))
+	config = DefaultStructuredLogConfig()
+	config.CustomRules = MergeRules(config.CustomRules, map[string]AnonymizeRule{"client_ip": IPv4Rule})
+	field := &recordLogger{}
+	require.NoError(t, NewSanitizingLogger(field, config).Log(log.LevelInfo, "client_ip", "10.0.0.5", "server_ip", "10.0.0.6"))
+	assert.Equal(t, "10.0.*.*", field.value("client_ip"))
+	assert.Equal(t, "10.0.0.6", field.value("server_ip"))
+
+	// 禁用后原样输出
+	config = DefaultStructuredLogConfig()
+	config.Enabled = false
+	disabled := &recordLogger{}
+	require.NoError(t, NewSanitizingLogger(disabled, config).Log(log.LevelInfo, "password", "plain"))
+	assert.Equal(t, "plain", disabled.value("password"))
+}
+
+func TestNewSanitizingLogger_OddKeyvals(t *testing.T) {
+	out := &recordLogger{}
+	require.NoError(t, NewSanitizingLogger(out, nil).Log(log.LevelInfo, "token", "abc", "dangling"))
+	assert.Equal(t, []interface{}{"token", "[REDACTED]", "dangling"}, out.keyvals)
+	assert.Equal(t, "[REDACTED]", fmt.Sprint(out.value("token")))
+}
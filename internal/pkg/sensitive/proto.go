@@ -0,0 +1,288 @@
+package sensitive
+
+import (
+	"encoding/json"
+	"sort"
+	"strings"
+	"sync"
+
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+// 基于 protobuf 字段选项的脱敏
+//
+// api/kratos/sensitive.proto 定义了字段选项 (kratos.sensitive)，接口消息在字段上声明敏感类型，
+// 脱敏时通过 protoreflect 遍历消息并按敏感类型选择规则，不再依赖字段名猜测。
+// 选项按扩展字段号从 FieldOptions 中读取，因此本包不依赖生成代码，扩展是否链接进程序均可识别。
+
+// SensitiveFieldNumber (kratos.sensitive) 扩展的字段号，与 api/kratos/sensitive.proto 一致
+const SensitiveFieldNumber protowire.Number = 52001
+
+// Sensitivity 字段敏感类型，取值与 api/kratos/sensitive.proto 中的 kratos.Sensitivity 一致
+type Sensitivity int32
+
+const (
+	SensitivityUnspecified Sensitivity = 0
+	SensitivityPassword    Sensitivity = 1
+	SensitivityToken       Sensitivity = 2
+	SensitivitySecret      Sensitivity = 3
+	SensitivityEmail       Sensitivity = 4
+	SensitivityPhone       Sensitivity = 5
+	SensitivityName        Sensitivity = 6
+	SensitivityIDCard      Sensitivity = 7
+	SensitivityBankCard    Sensitivity = 8
+	SensitivityAddress     Sensitivity = 9
+)
+
+var sensitivityNames = map[Sensitivity]string{
+	SensitivityPassword: "password",
+	SensitivityToken:    "token",
+	SensitivitySecret:   "secret",
+	SensitivityEmail:    "email",
+	SensitivityPhone:    "phone",
+	SensitivityName:     "name",
+	SensitivityIDCard:   "id_card",
+	SensitivityBankCard: "bank_card",
+	SensitivityAddress:  "address",
+}
+
+// String 返回敏感类型名称，与 GetDefaultRules 的键一致
+func (s Sensitivity) String() string {
+	if name, ok := sensitivityNames[s]; ok {
+		return name
+	}
+	return "unspecified"
+}
+
+// sensitivityRules 各敏感类型的默认脱敏规则
+var sensitivityRules = map[Sensitivity]AnonymizeRule{
+	SensitivityPassword: RedactRule,
+	SensitivityToken:    RedactRule,
+	SensitivitySecret:   RedactRule,
+	SensitivityEmail:    EmailRule,
+	SensitivityPhone:    PhoneRule,
+	SensitivityName:     NameRule,
+	SensitivityIDCard:   IDCardRule,
+	SensitivityBankCard: BankCardRule,
+	SensitivityAddress:  AddressRule,
+}
+
+// fieldSensitivityCache 字段描述符到敏感类型的缓存，描述符在进程内不变
+var fieldSensitivityCache sync.Map
+
+// FieldSensitivity 读取字段上声明的 (kratos.sensitive) 选项，未声明时返回 SensitivityUnspecified
+func FieldSensitivity(fd protoreflect.FieldDescriptor) Sensitivity {
+	if cached, ok := fieldSensitivityCache.Load(fd); ok {
+		return cached.(Sensitivity)
+	}
+	s := readFieldSensitivity(fd)
+	fieldSensitivityCache.Store(fd, s)
+	return s
+}
+
+func readFieldSensitivity(fd protoreflect.FieldDescriptor) Sensitivity {
+	opts, ok := fd.Options().(*descriptorpb.FieldOptions)
+	if !ok || opts == nil {
+		return SensitivityUnspecified
+	}
+	// 扩展已链接时在已知字段中，未链接时在未知字段中，序列化后统一按字段号查找
+	raw, err := proto.MarshalOptions{AllowPartial: true}.Marshal(opts)
+	if err != nil {
+		return SensitivityUnspecified
+	}
+	s := SensitivityUnspecified
+	for len(raw) > 0 {
+		num, typ, n := protowire.ConsumeTag(raw)
+		if n < 0 {
+			return SensitivityUnspecified
+		}
+		raw = raw[n:]
+		if num == SensitiveFieldNumber && typ == protowire.VarintType {
+			v, m := protowire.ConsumeVarint(raw)
+			if m < 0 {
+				return SensitivityUnspecified
+			}
+			s = Sensitivity(v)
+			raw = raw[m:]
+			continue
+		}
+		m := protowire.ConsumeFieldValue(num, typ, raw)
+		if m < 0 {
+			return SensitivityUnspecified
+		}
+		raw = raw[m:]
+	}
+	return s
+}
+
+// MaskProto 返回按字段敏感类型脱敏后的消息副本，原消息不变
+// rules 中以敏感类型名称（如 "password"、"email"）为键的规则覆盖默认规则；
+// 非字符串的敏感字段直接清空。未声明敏感类型的字段保持原值，嵌套消息递归处理。
+func MaskProto(msg proto.Message, rules map[string]AnonymizeRule) proto.Message {
+	masked, _ := maskProto(msg, &protoMasker{rules: rules})
+	return masked
+}
+
+// MaskProtoPII 只脱敏个人信息类字段，返回消息副本与被脱敏字段的路径（如 "email"、"profile.phone"）。
+// 密码、令牌等凭证字段保持原值，由接口自身决定是否返回。
+func MaskProtoPII(msg proto.Message, rules map[string]AnonymizeRule) (proto.Message, []string) {
+	return maskProto(msg, &protoMasker{rules: rules, filter: Sensitivity.IsPII})
+}
+
+// IsPII 是否为个人信息类敏感类型
+func (s Sensitivity) IsPII() bool {
+	switch s {
+	case SensitivityEmail, SensitivityPhone, SensitivityName, SensitivityIDCard, SensitivityBankCard, SensitivityAddress:
+		return true
+	}
+	return false
+}
+
+// protoMasker 按敏感类型脱敏消息，记录被脱敏的字段路径
+type protoMasker struct {
+	rules  map[string]AnonymizeRule
+	filter func(Sensitivity) bool
+	paths  []string
+	seen   map[string]bool
+}
+
+func maskProto(msg proto.Message, pm *protoMasker) (proto.Message, []string) {
+	if msg == nil || !msg.ProtoReflect().IsValid() {
+		return msg, nil
+	}
+	masked := proto.Clone(msg)
+	pm.mask(masked.ProtoReflect(), "")
+	// Range 的字段顺序不固定，排序后查看明文审计记录中的字段列表才稳定
+	sort.Strings(pm.paths)
+	return masked, pm.paths
+}
+
+func (pm *protoMasker) mask(m protoreflect.Message, prefix string) {
+	m.Range(func(fd protoreflect.FieldDescriptor, v protoreflect.Value) bool {
+		path := prefix + string(fd.Name())
+		if s := FieldSensitivity(fd); s != SensitivityUnspecified {
+			if pm.filter == nil || pm.filter(s) {
+				maskField(m, fd, v, s, pm.rules)
+				pm.record(path)
+			}
+			return true
+		}
+		switch {
+		case fd.IsMap():
+			if !isMessageKind(fd.MapValue()) {
+				return true
+			}
+			v.Map().Range(func(_ protoreflect.MapKey, mv protoreflect.Value) bool {
+				pm.mask(mv.Message(), path+".")
+				return true
+			})
+		case !isMessageKind(fd):
+		case fd.IsList():
+			list := v.List()
+			for i := 0; i < list.Len(); i++ {
+				pm.mask(list.Get(i).Message(), path+".")
+			}
+		default:
+			pm.mask(v.Message(), path+".")
+		}
+		return true
+	})
+}
+
+// record 记录被脱敏的字段路径，列表与映射中的同名字段只记录一次
+func (pm *protoMasker) record(path string) {
+	if pm.seen == nil {
+		pm.seen = make(map[string]bool)
+	}
+	if !pm.seen[path] {
+		pm.seen[path] = true
+		pm.paths = append(pm.paths, path)
+	}
+}
+
+// maskField 脱敏声明了敏感类型的字段
+func maskField(m protoreflect.Message, fd protoreflect.FieldDescriptor, v protoreflect.Value, s Sensitivity, rules map[string]AnonymizeRule) {
+	rule := sensitivityRule(s, rules)
+	switch {
+	case fd.IsMap():
+		if fd.MapValue().Kind() != protoreflect.StringKind {
+			m.Clear(fd)
+			return
+		}
+		mp := v.Map()
+		mp.Range(func(k protoreflect.MapKey, mv protoreflect.Value) bool {
+			mp.Set(k, protoreflect.ValueOfString(maskAnnotated(mv.String(), rule)))
+			return true
+		})
+	case fd.Kind() != protoreflect.StringKind:
+		m.Clear(fd)
+	case fd.IsList():
+		list := v.List()
+		for i := 0; i < list.Len(); i++ {
+			list.Set(i, protoreflect.ValueOfString(maskAnnotated(list.Get(i).String(), rule)))
+		}
+	default:
+		m.Set(fd, protoreflect.ValueOfString(maskAnnotated(v.String(), rule)))
+	}
+}
+
+func sensitivityRule(s Sensitivity, rules map[string]AnonymizeRule) AnonymizeRule {
+	if rule, ok := rules[s.String()]; ok {
+		return rule
+	}
+	if rule, ok := sensitivityRules[s]; ok {
+		return rule
+	}
+	return RedactRule
+}
+
+// maskAnnotated 脱敏已声明敏感类型的值
+// 预定义规则对格式不符的值原样返回，以免按字段名猜测时误伤；声明了敏感类型的值必须脱敏，
+// 因此依次退回按保留位数脱敏与完全遮盖。
+func maskAnnotated(value string, rule AnonymizeRule) string {
+	if value == "" {
+		return value
+	}
+	masked := value
+	if rule.CustomFunc != nil {
+		masked = rule.CustomFunc(value)
+	}
+	if masked == value {
+		masked = anonymizeWithRule(value, rule)
+	}
+	if masked == value {
+		maskChar := rule.MaskChar
+		if maskChar == "" {
+			maskChar = "*"
+		}
+		masked = strings.Repeat(maskChar, len([]rune(value)))
+	}
+	return masked
+}
+
+func isMessageKind(fd protoreflect.FieldDescriptor) bool {
+	return fd.Kind() == protoreflect.MessageKind || fd.Kind() == protoreflect.GroupKind
+}
+
+// protoLogEncoder 日志中按 proto 字段名输出，与接口文档一致
+var protoLogEncoder = protojson.MarshalOptions{UseProtoNames: true}
+
+// anonymizeProto 脱敏 protobuf 消息并转换为日志使用的 map
+func anonymizeProto(msg proto.Message, rules map[string]AnonymizeRule) interface{} {
+	if !msg.ProtoReflect().IsValid() {
+		return nil
+	}
+	raw, err := protoLogEncoder.Marshal(MaskProto(msg, rules))
+	if err != nil {
+		return "[UNENCODABLE " + string(msg.ProtoReflect().Descriptor().FullName()) + "]"
+	}
+	result := make(map[string]interface{})
+	if err := json.Unmarshal(raw, &result); err != nil {
+		return string(raw)
+	}
+	return result
+}
@@ -0,0 +1,170 @@
+package sensitive
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+// sensitiveOption 构造带 (kratos.sensitive) 选项的字段选项，扩展以未知字段形式存在，与未链接生成代码时一致
+func sensitiveOption(s Sensitivity) *descriptorpb.FieldOptions {
+	opts := &descriptorpb.FieldOptions{}
+	raw := protowire.AppendTag(nil, SensitiveFieldNumber, protowire.VarintType)
+	raw = protowire.AppendVarint(raw, uint64(s))
+	opts.ProtoReflect().SetUnknown(raw)
+	return opts
+}
+
+// testMessages 构造测试用的消息类型，字段声明与 api/auth/v1 的用法一致
+func testMessages(t *testing.T) (register, session protoreflect.MessageDescriptor) {
+	t.Helper()
+	str := descriptorpb.FieldDescriptorProto_TYPE_STRING.Enum()
+	msg := descriptorpb.FieldDescriptorProto_TYPE_MESSAGE.Enum()
+	optional := descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL.Enum()
+	repeated := descriptorpb.FieldDescriptorProto_LABEL_REPEATED.Enum()
+	field := func(name string, number int32, label *descriptorpb.FieldDescriptorProto_Label, typ *descriptorpb.FieldDescriptorProto_Type, s Sensitivity) *descriptorpb.FieldDescriptorProto {
+		f := &descriptorpb.FieldDescriptorProto{Name: proto.String(name), Number: proto.Int32(number), Label: label, Type: typ}
+		if s != SensitivityUnspecified {
+			f.Options = sensitiveOption(s)
+		}
+		return f
+	}
+
+	sessionField := field("session", 5, optional, msg, SensitivityUnspecified)
+	sessionField.TypeName = proto.String(".sensitive.test.Session")
+	historyField := field("history", 6, repeated, msg, SensitivityUnspecified)
+	historyField.TypeName = proto.String(".sensitive.test.Session")
+	file := &descriptorpb.FileDescriptorProto{
+		Name:    proto.String("sensitive/test.proto"),
+		Package: proto.String("sensitive.test"),
+		Syntax:  proto.String("proto3"),
+		MessageType: []*descriptorpb.DescriptorProto{
+			{
+				Name: proto.String("Register"),
+				Field: []*descriptorpb.FieldDescriptorProto{
+					field("username", 1, optional, str, SensitivityUnspecified),
+					field("password", 2, optional, str, SensitivityPassword),
+					field("contact", 3, optional, str, SensitivityEmail),
+					field("mobile", 4, optional, str, SensitivityPhone),
+					sessionField,
+					historyField,
+				},
+			},
+			{
+				Name: proto.String("Session"),
+				Field: []*descriptorpb.FieldDescriptorProto{
+					field("access_token", 1, optional, str, SensitivityToken),
+					field("scopes", 2, repeated, str, SensitivitySecret),
+					field("device", 3, optional, str, SensitivityUnspecified),
+				},
+			},
+		},
+	}
+	fd, err := protodesc.NewFile(file, protoregistry.GlobalFiles)
+	require.NoError(t, err)
+	return fd.Messages().ByName("Register"), fd.Messages().ByName("Session")
+}
+
+func newTestRegister(t *testing.T) proto.Message {
+	registerDesc, sessionDesc := testMessages(t)
+	newSession := func(token, device string) protoreflect.Message {
+		s := dynamicpb.NewMessage(sessionDesc)
+		s.Set(sessionDesc.Fields().ByName("access_token"), protoreflect.ValueOfString(token))
+		s.Set(sessionDesc.Fields().ByName("device"), protoreflect.ValueOfString(device))
+		scopes := s.Mutable(sessionDesc.Fields().ByName("scopes")).List()
+		scopes.Append(protoreflect.ValueOfString("admin"))
+		return s
+	}
+
+	m := dynamicpb.NewMessage(registerDesc)
+	fields := registerDesc.Fields()
+	m.Set(fields.ByName("username"), protoreflect.ValueOfString("john_doe"))
+	m.Set(fields.ByName("password"), protoreflect.ValueOfString("MyPassword123!"))
+	m.Set(fields.ByName("contact"), protoreflect.ValueOfString("john.doe@example.com"))
+	m.Set(fields.ByName("mobile"), protoreflect.ValueOfString("+8613812345678"))
+	m.Set(fields.ByName("session"), protoreflect.ValueOfMessage(newSession("eyJhbGciOiJIUzI1NiJ9.payload", "ios")))
+	m.Mutable(fields.ByName("history")).List().Append(protoreflect.ValueOfMessage(newSession("old-token", "web")))
+	return m
+}
+
+func TestFieldSensitivity(t *testing.T) {
+	registerDesc, _ := testMessages(t)
+	fields := registerDesc.Fields()
+
+	assert.Equal(t, SensitivityUnspecified, FieldSensitivity(fields.ByName("username")))
+	assert.Equal(t, SensitivityPassword, FieldSensitivity(fields.ByName("password")))
+	assert.Equal(t, SensitivityEmail, FieldSensitivity(fields.ByName("contact")))
+	// 第二次读取命中缓存
+	assert.Equal(t, SensitivityPhone, FieldSensitivity(fields.ByName("mobile")))
+	assert.Equal(t, SensitivityPhone, FieldSensitivity(fields.ByName("mobile")))
+	assert.Equal(t, "id_card", SensitivityIDCard.String())
+}
+
+func TestMaskProto(t *testing.T) {
+	original := newTestRegister(t)
+	masked := MaskProto(original, nil).ProtoReflect()
+	fields := masked.Descriptor().Fields()
+	get := func(m protoreflect.Message, name protoreflect.Name) string {
+		return m.Get(m.Descriptor().Fields().ByName(name)).String()
+	}
+
+	// 按声明的敏感类型脱敏，字段名与默认规则无关
+	assert.Equal(t, "john_doe", get(masked, "username"))
+	assert.Equal(t, "[REDACTED]", get(masked, "password"))
+	assert.Equal(t, "jo******@example.com", get(masked, "contact"))
+	// 非大陆格式的手机号退回按保留位数脱敏
+	assert.Equal(t, "+86*******5678", get(masked, "mobile"))
+
+	session := masked.Get(fields.ByName("session")).Message()
+	assert.Equal(t, "[REDACTED]", get(session, "access_token"))
+	assert.Equal(t, "ios", get(session, "device"))
+	assert.Equal(t, "[REDACTED]", session.Get(session.Descriptor().Fields().ByName("scopes")).List().Get(0).String())
+	history := masked.Get(fields.ByName("history")).List().Get(0).Message()
+	assert.Equal(t, "[REDACTED]", get(history, "access_token"))
+
+	// 原消息不变
+	assert.Equal(t, "MyPassword123!", get(original.ProtoReflect(), "password"))
+
+	// 以敏感类型名称为键的规则覆盖默认规则
+	custom := MaskProto(original, map[string]AnonymizeRule{
+		"password": {CustomFunc: func(string) string { return "***" }},
+	}).ProtoReflect()
+	assert.Equal(t, "***", get(custom, "password"))
+}
+
+func TestAnonymizeValue_Proto(t *testing.T) {
+	msg := newTestRegister(t)
+
+	result, ok := NewAnonymizer().AnonymizeValue(msg, GetDefaultRules()).(map[string]interface{})
+	require.True(t, ok)
+	assert.Equal(t, "john_doe", result["username"])
+	assert.Equal(t, "[REDACTED]", result["password"])
+	assert.Equal(t, "jo******@example.com", result["contact"])
+	assert.Equal(t, "[REDACTED]", result["session"].(map[string]interface{})["access_token"])
+
+	// 结构体中嵌套的 protobuf 消息同样按声明脱敏，结构体字段仍按字段名规则
+	wrapper := struct {
+		Email   string
+		Request proto.Message
+	}{Email: "admin@example.com", Request: msg}
+	nested := NewAnonymizer().AnonymizeValue(wrapper, GetDefaultRules()).(map[string]interface{})
+	assert.Equal(t, "ad***@example.com", nested["Email"])
+	assert.Equal(t, "[REDACTED]", nested["Request"].(map[string]interface{})["password"])
+}
+
+func TestLogSanitizeMiddleware_SanitizeProto(t *testing.T) {
+	m := NewLogSanitizeMiddleware(nil, nil)
+
+	result, ok := m.sanitizeData(newTestRegister(t)).(map[string]interface{})
+	require.True(t, ok)
+	assert.Equal(t, "[REDACTED]", result["password"])
+	assert.Equal(t, "+86*******5678", result["mobile"])
+}
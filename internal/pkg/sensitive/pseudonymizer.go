@@ -0,0 +1,412 @@
+package sensitive
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base32"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"kratos-boilerplate/internal/pkg/auth"
+	"kratos-boilerplate/internal/pkg/crypto"
+)
+
+// 假名化错误定义
+var (
+	ErrPseudonymKeyUnavailable = errors.New("假名化密钥不可用")
+	ErrVaultDisabled           = errors.New("未启用可逆假名化")
+	ErrRevealDenied            = errors.New("无权还原假名化数据")
+	ErrTokenNotFound           = errors.New("假名令牌不存在")
+	ErrTokenExists             = errors.New("假名令牌已存在")
+	ErrTokenCollision          = errors.New("假名令牌冲突")
+	ErrPhoneTooShort           = errors.New("手机号位数不足，无法生成保留格式的令牌")
+)
+
+// PseudonymFormat 假名令牌格式
+type PseudonymFormat string
+
+const (
+	// PseudonymToken 不透明令牌，如 tok_5k2m...
+	PseudonymToken PseudonymFormat = "token"
+	// PseudonymPhone 保留格式的手机号令牌，长度、分隔符和号段不变，由 FF1 加密得到，不同号码的令牌不会相同
+	PseudonymPhone PseudonymFormat = "phone"
+)
+
+// PseudonymRevealPermission 还原假名令牌所需的权限，按数据集授权时为 pseudonym:reveal:<dataset>
+const PseudonymRevealPermission = "pseudonym:reveal"
+
+// RuleAction 字段的导出处理方式
+type RuleAction string
+
+const (
+	// ActionMask 按脱敏规则遮盖
+	ActionMask RuleAction = "mask"
+	// ActionPseudonymize 替换为确定性的不透明令牌
+	ActionPseudonymize RuleAction = "pseudonymize"
+	// ActionPseudonymizePhone 替换为保留格式的手机号令牌
+	ActionPseudonymizePhone RuleAction = "pseudonymize_phone"
+)
+
+// defaultTokenPrefix 默认令牌前缀
+const defaultTokenPrefix = "tok_"
+
+// phoneKeepDigits 手机号令牌默认保留的号段位数
+const phoneKeepDigits = 3
+
+// KeyProvider 按数据集提供假名化密钥。同一数据集必须始终返回同一密钥，
+// 否则导出数据之间无法关联。
+type KeyProvider interface {
+	DatasetKey(ctx context.Context, dataset string) ([]byte, error)
+}
+
+// TokenVault 可逆模式下保存令牌与密文的存储，只保存加密后的原值
+type TokenVault interface {
+	// Store 保存令牌对应的密文，令牌已存在时不覆盖并返回 ErrTokenExists
+	Store(ctx context.Context, dataset, token string, sealed []byte) error
+	// Load 读取令牌对应的密文，不存在时返回 ErrTokenNotFound
+	Load(ctx context.Context, dataset, token string) ([]byte, error)
+}
+
+// RevealPolicy 判断调用方能否还原某个数据集的令牌，返回 nil 表示允许
+type RevealPolicy func(ctx context.Context, dataset string) error
+
+// PseudonymizerOption 假名化器选项
+type PseudonymizerOption func(*Pseudonymizer)
+
+// WithTokenPrefix 设置不透明令牌前缀
+func WithTokenPrefix(prefix string) PseudonymizerOption {
+	return func(p *Pseudonymizer) {
+		p.prefix = prefix
+	}
+}
+
+// WithVault 启用可逆模式，policy 为空时要求调用方持有 PseudonymRevealPermission 权限
+func WithVault(vault TokenVault, policy RevealPolicy) PseudonymizerOption {
+	return func(p *Pseudonymizer) {
+		if policy == nil {
+			policy = PermissionRevealPolicy(PseudonymRevealPermission)
+		}
+		p.vault = vault
+		p.policy = policy
+	}
+}
+
+// PermissionRevealPolicy 要求上下文中的 auth.Subject 持有 permission 或 permission:<dataset> 权限，
+// 主体由认证中间件放入上下文，未认证的调用方一律拒绝
+func PermissionRevealPolicy(permission string) RevealPolicy {
+	return func(ctx context.Context, dataset string) error {
+		subject := auth.GetSubjectFromContext(ctx)
+		if subject == nil {
+			return errors.New("no authenticated subject")
+		}
+		if auth.HasPermission(subject, permission) || auth.HasPermission(subject, permission+":"+dataset) {
+			return nil
+		}
+		return fmt.Errorf("subject %s lacks permission %s for dataset %s", subject.ID, permission, dataset)
+	}
+}
+
+// Pseudonymizer 确定性假名化器。同一数据集内相同的值总是得到相同的令牌，
+// 不同数据集的令牌互不相关。
+type Pseudonymizer struct {
+	keys   KeyProvider
+	prefix string
+	vault  TokenVault
+	policy RevealPolicy
+}
+
+// NewPseudonymizer 创建假名化器
+func NewPseudonymizer(keys KeyProvider, opts ...PseudonymizerOption) *Pseudonymizer {
+	p := &Pseudonymizer{
+		keys:   keys,
+		prefix: defaultTokenPrefix,
+	}
+	for _, opt := range opts {
+		opt(p)
+	}
+	return p
+}
+
+// Token 生成不透明令牌
+func (p *Pseudonymizer) Token(ctx context.Context, dataset, value string) (string, error) {
+	return p.Pseudonymize(ctx, dataset, value, PseudonymToken)
+}
+
+// PhoneToken 生成保留格式的手机号令牌
+func (p *Pseudonymizer) PhoneToken(ctx context.Context, dataset, phone string) (string, error) {
+	return p.Pseudonymize(ctx, dataset, phone, PseudonymPhone)
+}
+
+// Pseudonymize 按格式生成令牌，启用可逆模式时同时保存密文
+func (p *Pseudonymizer) Pseudonymize(ctx context.Context, dataset, value string, format PseudonymFormat) (string, error) {
+	if value == "" {
+		return value, nil
+	}
+	key, err := p.datasetKey(ctx, dataset)
+	if err != nil {
+		return "", err
+	}
+
+	var token string
+	switch format {
+	case PseudonymPhone:
+		if token, err = phoneToken(key, value); err != nil {
+			return "", err
+		}
+	default:
+		mac := hmac.New(sha256.New, key)
+		mac.Write([]byte(format))
+		mac.Write([]byte{0})
+		mac.Write([]byte(value))
+		token = p.prefix + strings.ToLower(base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString(mac.Sum(nil)[:16]))
+	}
+
+	if p.vault != nil {
+		if err := p.remember(ctx, dataset, token, value, key); err != nil {
+			return "", err
+		}
+	}
+	return token, nil
+}
+
+// Reveal 还原令牌，仅在启用可逆模式且调用方通过 RevealPolicy 校验时可用
+func (p *Pseudonymizer) Reveal(ctx context.Context, dataset, token string) (string, error) {
+	if p.vault == nil {
+		return "", ErrVaultDisabled
+	}
+	if err := p.policy(ctx, dataset); err != nil {
+		return "", fmt.Errorf("%w: %v", ErrRevealDenied, err)
+	}
+
+	key, err := p.datasetKey(ctx, dataset)
+	if err != nil {
+		return "", err
+	}
+	sealed, err := p.vault.Load(ctx, dataset, token)
+	if err != nil {
+		return "", err
+	}
+	return openValue(key, sealed)
+}
+
+// Rule 生成假名化的脱敏规则，可直接放入规则集合替换遮盖规则。
+// CustomFunc 无法返回错误，生成失败时整体遮盖，不输出原值。
+func (p *Pseudonymizer) Rule(fieldName, dataset string, format PseudonymFormat) AnonymizeRule {
+	return AnonymizeRule{
+		FieldName: fieldName,
+		CustomFunc: func(value string) string {
+			token, err := p.Pseudonymize(context.Background(), dataset, value, format)
+			if err != nil {
+				return strings.Repeat("*", len([]rune(value)))
+			}
+			return token
+		},
+	}
+}
+
+// Rules 按字段处理方式生成规则集合。以默认规则为基础，
+// mask 字段保留原有遮盖规则，pseudonymize 字段替换为假名化规则。
+func (p *Pseudonymizer) Rules(dataset string, actions map[string]RuleAction) (map[string]AnonymizeRule, error) {
+	rules := GetDefaultRules()
+	for field, action := range actions {
+		switch action {
+		case ActionMask:
+			if _, ok := rules[field]; !ok {
+				rules[field] = AnonymizeRule{FieldName: field, KeepStart: 1, KeepEnd: 1, MaskChar: "*"}
+			}
+		case ActionPseudonymize:
+			rules[field] = p.Rule(field, dataset, PseudonymToken)
+		case ActionPseudonymizePhone:
+			rules[field] = p.Rule(field, dataset, PseudonymPhone)
+		default:
+			return nil, fmt.Errorf("unknown action %q for field %s", action, field)
+		}
+	}
+	return rules, nil
+}
+
+// datasetKey 获取数据集密钥
+func (p *Pseudonymizer) datasetKey(ctx context.Context, dataset string) ([]byte, error) {
+	if p.keys == nil {
+		return nil, ErrPseudonymKeyUnavailable
+	}
+	key, err := p.keys.DatasetKey(ctx, dataset)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrPseudonymKeyUnavailable, err)
+	}
+	if len(key) == 0 {
+		return nil, ErrPseudonymKeyUnavailable
+	}
+	return key, nil
+}
+
+// remember 将原值加密后存入令牌库。令牌已存在且对应其他值时返回 ErrTokenCollision，
+// 不会让一个令牌还原出两个值；并发写入同一令牌时以先写入的为准再比较。
+func (p *Pseudonymizer) remember(ctx context.Context, dataset, token, value string, key []byte) error {
+	sealed, err := p.vault.Load(ctx, dataset, token)
+	if errors.Is(err, ErrTokenNotFound) {
+		if sealed, err = sealValue(key, value); err != nil {
+			return err
+		}
+		err = p.vault.Store(ctx, dataset, token, sealed)
+		if !errors.Is(err, ErrTokenExists) {
+			return err
+		}
+		sealed, err = p.vault.Load(ctx, dataset, token)
+	}
+	if err != nil {
+		return err
+	}
+
+	existing, err := openValue(key, sealed)
+	if err != nil {
+		return err
+	}
+	if existing != value {
+		return ErrTokenCollision
+	}
+	return nil
+}
+
+// phoneToken 保留非数字字符与前几位号段，其余数字经 FF1 加密，号段作为 tweak。
+// FF1 是数字串上的置换，号段相同的不同号码得到不同令牌
+func phoneToken(key []byte, phone string) (string, error) {
+	var digits []byte
+	for _, r := range phone {
+		if r >= '0' && r <= '9' {
+			digits = append(digits, byte(r-'0'))
+		}
+	}
+	keep := phoneKeepDigits
+	if len(digits)-keep < ff1MinLength {
+		keep = len(digits) - ff1MinLength
+	}
+	if keep < 0 {
+		return "", ErrPhoneTooShort
+	}
+
+	tweak := make([]byte, keep)
+	for i, d := range digits[:keep] {
+		tweak[i] = '0' + d
+	}
+	c, err := newFF1Cipher(ff1Key(key))
+	if err != nil {
+		return "", err
+	}
+	encrypted, err := c.encrypt(tweak, digits[keep:])
+	if err != nil {
+		return "", err
+	}
+
+	var b strings.Builder
+	seen := 0
+	for _, r := range phone {
+		if r < '0' || r > '9' {
+			b.WriteRune(r)
+			continue
+		}
+		if seen < keep {
+			b.WriteRune(r)
+		} else {
+			b.WriteByte('0' + encrypted[seen-keep])
+		}
+		seen++
+	}
+	return b.String(), nil
+}
+
+// ff1Key 由数据集密钥派生手机号令牌的 FF1 密钥，与令牌库密钥分离
+func ff1Key(key []byte) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte("pseudonym-ff1"))
+	return mac.Sum(nil)
+}
+
+// vaultKey 由数据集密钥派生令牌库加密密钥，与令牌密钥分离
+func vaultKey(key []byte) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte("pseudonym-vault"))
+	return mac.Sum(nil)
+}
+
+func sealValue(key []byte, value string) ([]byte, error) {
+	encryptor, err := crypto.NewAESEncryptor(vaultKey(key))
+	if err != nil {
+		return nil, err
+	}
+	return encryptor.Encrypt([]byte(value))
+}
+
+func openValue(key []byte, sealed []byte) (string, error) {
+	encryptor, err := crypto.NewAESEncryptor(vaultKey(key))
+	if err != nil {
+		return "", err
+	}
+	plaintext, err := encryptor.Decrypt(sealed)
+	if err != nil {
+		return "", fmt.Errorf("failed to open token value: %w", err)
+	}
+	return string(plaintext), nil
+}
+
+// derivedKeyProvider 由主密钥按数据集名派生密钥
+type derivedKeyProvider struct {
+	master []byte
+}
+
+// NewDerivedKeyProvider 创建按数据集派生密钥的提供者，
+// 数据集密钥为 HMAC-SHA256(master, "pseudonym:" + dataset)
+func NewDerivedKeyProvider(master []byte) KeyProvider {
+	return &derivedKeyProvider{master: append([]byte(nil), master...)}
+}
+
+// DatasetKey 派生数据集密钥
+func (d *derivedKeyProvider) DatasetKey(_ context.Context, dataset string) ([]byte, error) {
+	if len(d.master) == 0 {
+		return nil, ErrPseudonymKeyUnavailable
+	}
+	return DeriveDatasetKey(d.master, dataset), nil
+}
+
+// DeriveDatasetKey 由主密钥派生数据集密钥
+func DeriveDatasetKey(master []byte, dataset string) []byte {
+	mac := hmac.New(sha256.New, master)
+	mac.Write([]byte("pseudonym:" + dataset))
+	return mac.Sum(nil)
+}
+
+// memoryVault 内存令牌库
+type memoryVault struct {
+	mu     sync.RWMutex
+	tokens map[string][]byte
+}
+
+// NewMemoryVault 创建内存令牌库，适用于测试与单次导出任务；需要持久保存时使用 data.NewPseudonymVault
+func NewMemoryVault() TokenVault {
+	return &memoryVault{tokens: make(map[string][]byte)}
+}
+
+// Store 保存令牌，已存在时不覆盖
+func (m *memoryVault) Store(_ context.Context, dataset, token string, sealed []byte) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.tokens[dataset+"\x00"+token]; ok {
+		return ErrTokenExists
+	}
+	m.tokens[dataset+"\x00"+token] = sealed
+	return nil
+}
+
+// Load 读取令牌
+func (m *memoryVault) Load(_ context.Context, dataset, token string) ([]byte, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	sealed, ok := m.tokens[dataset+"\x00"+token]
+	if !ok {
+		return nil, ErrTokenNotFound
+	}
+	return sealed, nil
+}
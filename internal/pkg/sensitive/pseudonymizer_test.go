@@ -0,0 +1,219 @@
+package sensitive
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"testing"
+
+	"kratos-boilerplate/internal/pkg/auth"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestPseudonymizer(opts ...PseudonymizerOption) *Pseudonymizer {
+	return NewPseudonymizer(NewDerivedKeyProvider([]byte("test-master-key")), opts...)
+}
+
+// TestPseudonymizer_Token 测试不透明令牌的确定性与数据集隔离
+func TestPseudonymizer_Token(t *testing.T) {
+	p := newTestPseudonymizer()
+	ctx := context.Background()
+
+	token1, err := p.Token(ctx, "orders", "alice@example.com")
+	require.NoError(t, err)
+	token2, err := p.Token(ctx, "orders", "alice@example.com")
+	require.NoError(t, err)
+	assert.Equal(t, token1, token2)
+	assert.Regexp(t, regexp.MustCompile(`^tok_[a-z2-7]{26}$`), token1)
+	assert.NotContains(t, token1, "alice")
+
+	other, err := p.Token(ctx, "orders", "bob@example.com")
+	require.NoError(t, err)
+	assert.NotEqual(t, token1, other)
+
+	scoped, err := p.Token(ctx, "marketing", "alice@example.com")
+	require.NoError(t, err)
+	assert.NotEqual(t, token1, scoped)
+
+	// 不同主密钥得到不同令牌
+	foreign, err := NewPseudonymizer(NewDerivedKeyProvider([]byte("other-key"))).Token(ctx, "orders", "alice@example.com")
+	require.NoError(t, err)
+	assert.NotEqual(t, token1, foreign)
+
+	empty, err := p.Token(ctx, "orders", "")
+	require.NoError(t, err)
+	assert.Empty(t, empty)
+}
+
+// TestPseudonymizer_PhoneToken 测试保留格式的手机号令牌
+func TestPseudonymizer_PhoneToken(t *testing.T) {
+	p := newTestPseudonymizer()
+	ctx := context.Background()
+
+	token, err := p.PhoneToken(ctx, "orders", "13812345678")
+	require.NoError(t, err)
+	assert.Len(t, token, 11)
+	assert.Regexp(t, regexp.MustCompile(`^138\d{8}$`), token)
+	assert.NotEqual(t, "13812345678", token)
+
+	again, err := p.PhoneToken(ctx, "orders", "13812345678")
+	require.NoError(t, err)
+	assert.Equal(t, token, again)
+
+	formatted, err := p.PhoneToken(ctx, "orders", "+86 138-1234-5678")
+	require.NoError(t, err)
+	assert.Regexp(t, regexp.MustCompile(`^\+86 1\d{2}-\d{4}-\d{4}$`), formatted)
+
+	_, err = p.PhoneToken(ctx, "orders", "12345")
+	assert.ErrorIs(t, err, ErrPhoneTooShort)
+}
+
+// TestPseudonymizer_PhoneTokenUnique 测试号段相同的不同号码不会得到相同令牌
+func TestPseudonymizer_PhoneTokenUnique(t *testing.T) {
+	p := newTestPseudonymizer()
+	ctx := context.Background()
+
+	seen := make(map[string]string, 20000)
+	for i := 0; i < 20000; i++ {
+		phone := fmt.Sprintf("1381234%04d", i)
+		token, err := p.PhoneToken(ctx, "orders", phone)
+		require.NoError(t, err)
+		if other, ok := seen[token]; ok {
+			t.Fatalf("%s and %s share token %s", other, phone, token)
+		}
+		seen[token] = phone
+	}
+}
+
+// TestPseudonymizer_Vault 测试可逆模式与还原权限
+func TestPseudonymizer_Vault(t *testing.T) {
+	ctx := context.Background()
+	errNotAdmin := errors.New("not admin")
+	type adminKey struct{}
+	policy := func(ctx context.Context, dataset string) error {
+		if ctx.Value(adminKey{}) == nil {
+			return errNotAdmin
+		}
+		return nil
+	}
+	p := newTestPseudonymizer(WithVault(NewMemoryVault(), policy))
+
+	token, err := p.Token(ctx, "orders", "alice@example.com")
+	require.NoError(t, err)
+
+	_, err = p.Reveal(ctx, "orders", token)
+	assert.ErrorIs(t, err, ErrRevealDenied)
+
+	admin := context.WithValue(ctx, adminKey{}, true)
+	value, err := p.Reveal(admin, "orders", token)
+	require.NoError(t, err)
+	assert.Equal(t, "alice@example.com", value)
+
+	_, err = p.Reveal(admin, "marketing", token)
+	assert.ErrorIs(t, err, ErrTokenNotFound)
+
+	// 未启用可逆模式或未配置策略时不允许还原
+	_, err = newTestPseudonymizer().Reveal(admin, "orders", token)
+	assert.ErrorIs(t, err, ErrVaultDisabled)
+	_, err = newTestPseudonymizer(WithVault(NewMemoryVault(), nil)).Reveal(admin, "orders", token)
+	assert.ErrorIs(t, err, ErrRevealDenied)
+}
+
+// TestPseudonymizer_RevealPermission 测试默认策略按调用方主体的权限还原
+func TestPseudonymizer_RevealPermission(t *testing.T) {
+	ctx := context.Background()
+	p := newTestPseudonymizer(WithVault(NewMemoryVault(), nil))
+	token, err := p.Token(ctx, "orders", "alice@example.com")
+	require.NoError(t, err)
+
+	withSubject := func(permissions ...string) context.Context {
+		return context.WithValue(ctx, auth.SubjectKey, &auth.Subject{ID: "u1", Permissions: permissions})
+	}
+	for _, tt := range []struct {
+		name string
+		ctx  context.Context
+		ok   bool
+	}{
+		{"anonymous", ctx, false},
+		{"no permission", withSubject("pii:read"), false},
+		{"other dataset", withSubject(PseudonymRevealPermission + ":marketing"), false},
+		{"dataset scoped", withSubject(PseudonymRevealPermission + ":orders"), true},
+		{"all datasets", withSubject(PseudonymRevealPermission), true},
+		{"wildcard", withSubject("pseudonym:*"), true},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			value, err := p.Reveal(tt.ctx, "orders", token)
+			if !tt.ok {
+				assert.ErrorIs(t, err, ErrRevealDenied)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, "alice@example.com", value)
+		})
+	}
+}
+
+// TestPseudonymizer_VaultStoresCiphertext 测试令牌库不保存明文
+func TestPseudonymizer_VaultStoresCiphertext(t *testing.T) {
+	vault := NewMemoryVault()
+	p := newTestPseudonymizer(WithVault(vault, nil))
+	ctx := context.Background()
+
+	token, err := p.Token(ctx, "orders", "alice@example.com")
+	require.NoError(t, err)
+	sealed, err := vault.Load(ctx, "orders", token)
+	require.NoError(t, err)
+	assert.NotContains(t, string(sealed), "alice")
+
+	// 令牌已存在时不覆盖，且对应其他值时报告冲突
+	require.NoError(t, vault.Store(ctx, "orders", "tok_fixed", sealed))
+	assert.ErrorIs(t, vault.Store(ctx, "orders", "tok_fixed", []byte("other")), ErrTokenExists)
+	require.NoError(t, p.remember(ctx, "orders", "tok_fixed", "alice@example.com", DeriveDatasetKey([]byte("test-master-key"), "orders")))
+	err = p.remember(ctx, "orders", "tok_fixed", "bob@example.com", DeriveDatasetKey([]byte("test-master-key"), "orders"))
+	assert.ErrorIs(t, err, ErrTokenCollision)
+}
+
+// TestPseudonymizer_Rules 测试假名化规则与脱敏器集成
+func TestPseudonymizer_Rules(t *testing.T) {
+	p := newTestPseudonymizer()
+	rules, err := p.Rules("orders", map[string]RuleAction{
+		"email": ActionPseudonymize,
+		"phone": ActionPseudonymizePhone,
+		"name":  ActionMask,
+	})
+	require.NoError(t, err)
+
+	anonymizer := NewAnonymizer()
+	email := anonymizer.AnonymizeString("alice@example.com", rules["email"])
+	expected, err := p.Token(context.Background(), "orders", "alice@example.com")
+	require.NoError(t, err)
+	assert.Equal(t, expected, email)
+
+	phone := anonymizer.AnonymizeString("13812345678", rules["phone"])
+	assert.Regexp(t, regexp.MustCompile(`^138\d{8}$`), phone)
+	assert.Equal(t, NameRule.KeepStart, rules["name"].KeepStart)
+
+	type record struct {
+		Email string `json:"email"`
+		Phone string `json:"phone"`
+	}
+	result := anonymizer.AnonymizeValue(record{Email: "alice@example.com", Phone: "13812345678"}, rules).(map[string]interface{})
+	assert.Equal(t, expected, result["Email"])
+	assert.Equal(t, phone, result["Phone"])
+
+	_, err = p.Rules("orders", map[string]RuleAction{"email": "hash"})
+	assert.Error(t, err)
+}
+
+// TestPseudonymizer_RuleKeyUnavailable 测试密钥不可用时整体遮盖
+func TestPseudonymizer_RuleKeyUnavailable(t *testing.T) {
+	p := NewPseudonymizer(NewDerivedKeyProvider(nil))
+	rule := p.Rule("email", "orders", PseudonymToken)
+	assert.Equal(t, "*****", NewAnonymizer().AnonymizeString("a@b.c", rule))
+
+	_, err := p.Token(context.Background(), "orders", "a@b.c")
+	assert.ErrorIs(t, err, ErrPseudonymKeyUnavailable)
+}
@@ -130,6 +130,86 @@ var (
 	}
 )
 
+// RedactRule 完全隐藏规则，用于密码、令牌等不能保留任何字符的字段
+var RedactRule = AnonymizeRule{
+	FieldName: "redact",
+	CustomFunc: func(string) string {
+		return "[REDACTED]"
+	},
+}
+
+// JWTRule JWT 脱敏规则，只保留不含敏感信息的头部
+var JWTRule = AnonymizeRule{
+	FieldName: "jwt",
+	CustomFunc: func(token string) string {
+		return strings.SplitN(token, ".", 2)[0] + ".[REDACTED]"
+	},
+}
+
+// APIKeyRule API 密钥脱敏规则，保留标识密钥类型的前缀
+var APIKeyRule = AnonymizeRule{
+	FieldName: "api_key",
+	KeepStart: 6,
+	KeepEnd:   0,
+	MaskChar:  "*",
+}
+
+// PrivateKeyRule 私钥 PEM 块脱敏规则
+var PrivateKeyRule = AnonymizeRule{
+	FieldName: "private_key",
+	CustomFunc: func(string) string {
+		return "[REDACTED PRIVATE KEY]"
+	},
+}
+
+// IBANRule 国际银行账号脱敏规则，保留国家代码、校验位与后四位
+var IBANRule = AnonymizeRule{
+	FieldName: "iban",
+	KeepStart: 4,
+	KeepEnd:   4,
+	MaskChar:  "*",
+}
+
+// PassportRule 护照号脱敏规则
+var PassportRule = AnonymizeRule{
+	FieldName: "passport",
+	KeepStart: 1,
+	KeepEnd:   2,
+	MaskChar:  "*",
+}
+
+// IPv4Rule IPv4 地址脱敏规则，保留前两段：192.168.*.*
+var IPv4Rule = AnonymizeRule{
+	FieldName: "ipv4",
+	CustomFunc: func(ip string) string {
+		parts := strings.Split(ip, ".")
+		if len(parts) != 4 {
+			return ip
+		}
+		return parts[0] + "." + parts[1] + ".*.*"
+	},
+}
+
+// IPv6Rule IPv6 地址脱敏规则，保留前两组：2001:db8:****
+var IPv6Rule = AnonymizeRule{
+	FieldName: "ipv6",
+	CustomFunc: func(ip string) string {
+		parts := strings.SplitN(ip, ":", 3)
+		if len(parts) != 3 {
+			return ip
+		}
+		return parts[0] + ":" + parts[1] + ":****"
+	},
+}
+
+// UsernameRule 用户名脱敏规则，保留前两个字符
+var UsernameRule = AnonymizeRule{
+	FieldName: "username",
+	KeepStart: 2,
+	KeepEnd:   0,
+	MaskChar:  "*",
+}
+
 // GetDefaultRules 获取默认脱敏规则集合
 func GetDefaultRules() map[string]AnonymizeRule {
 	return map[string]AnonymizeRule{
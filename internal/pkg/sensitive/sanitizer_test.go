@@ -34,13 +34,13 @@ func TestLogSanitizer_SanitizeLogMessage(t *testing.T) {
 		},
 		{
 			name:     "id_card_sanitization",
-			message:  "身份证号：110101199001011234",
-			contains: []string{"110101199001011234"},
+			message:  "身份证号：110101199001011237",
+			contains: []string{"110101199001011237"},
 		},
 		{
 			name:     "multiple_sensitive_data",
-			message:  "用户信息：邮箱user@test.com，手机13812345678，身份证110101199001011234",
-			contains: []string{"user@test.com", "13812345678", "110101199001011234"},
+			message:  "用户信息：邮箱user@test.com，手机13812345678，身份证110101199001011237",
+			contains: []string{"user@test.com", "13812345678", "110101199001011237"},
 		},
 	}
 
@@ -75,7 +75,7 @@ func TestLogSanitizer_ContainsSensitiveInfo(t *testing.T) {
 		},
 		{
 			name:     "contains_id_card",
-			content:  "身份证：110101199001011234",
+			content:  "身份证：110101199001011237",
 			expected: true,
 		},
 		{
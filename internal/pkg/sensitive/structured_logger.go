@@ -13,6 +13,7 @@ type StructuredLogger struct {
 	helper     *log.Helper
 	logger     log.Logger // 保存原始logger用于WithFields
 	anonymizer Anonymizer
+	detector   SensitiveDetector
 	rules      map[string]AnonymizeRule
 	config     *StructuredLogConfig
 }
@@ -25,6 +26,7 @@ type StructuredLogConfig struct {
 	SensitiveKeys     []string                 `json:"sensitive_keys"`     // 敏感字段名列表
 	MaxValueLength    int                      `json:"max_value_length"`   // 最大值长度
 	TruncateThreshold int                      `json:"truncate_threshold"` // 截断阈值
+	PassthroughKeys   []string                 `json:"passthrough_keys"`   // 不脱敏的字段名列表，如时间戳、链路ID
 }
 
 // DefaultStructuredLogConfig 默认配置
@@ -36,6 +38,7 @@ func DefaultStructuredLogConfig() *StructuredLogConfig {
 		SensitiveKeys:     []string{"password", "token", "secret", "key", "auth", "credential"},
 		MaxValueLength:    1000,
 		TruncateThreshold: 500,
+		PassthroughKeys:   []string{"ts", "caller", "level", "trace.id", "span.id", "trace_id", "span_id", "request_id", "service.id", "service.name", "service.version"},
 	}
 }
 
@@ -49,6 +52,7 @@ func NewStructuredLogger(helper *log.Helper, config *StructuredLogConfig) *Struc
 		helper:     helper,
 		logger:     nil, // 从helper创建时无法获取原始logger
 		anonymizer: NewAnonymizer(),
+		detector:   NewSensitiveDetector(),
 		rules:      config.CustomRules,
 		config:     config,
 	}
@@ -65,6 +69,7 @@ func NewStructuredLoggerFromLogger(logger log.Logger, config *StructuredLogConfi
 		helper:     helper,
 		logger:     logger,
 		anonymizer: NewAnonymizer(),
+		detector:   NewSensitiveDetector(),
 		rules:      config.CustomRules,
 		config:     config,
 	}
@@ -142,6 +147,7 @@ func (s *StructuredLogger) WithContext(ctx context.Context) StructuredLoggerInte
 		helper:     s.helper.WithContext(ctx),
 		logger:     s.logger,
 		anonymizer: s.anonymizer,
+		detector:   s.detector,
 		rules:      s.rules,
 		config:     s.config,
 	}
@@ -159,6 +165,7 @@ func (s *StructuredLogger) WithFields(keysAndValues ...interface{}) StructuredLo
 			helper:     newHelper,
 			logger:     newLogger,
 			anonymizer: s.anonymizer,
+			detector:   s.detector,
 			rules:      s.rules,
 			config:     s.config,
 		}
@@ -318,20 +325,7 @@ func (s *StructuredLogger) autoDetectAndSanitize(value interface{}) interface{}
 	strValue := fmt.Sprintf("%v", value)
 	
 	// 使用敏感信息检测器
-	detector := NewSensitiveDetector()
-	detected := detector.DetectAll(strValue)
-	
-	result := strValue
-	for fieldType, matches := range detected {
-		if rule, exists := s.rules[fieldType]; exists {
-			for _, match := range matches {
-				anonymized := s.anonymizer.AnonymizeString(match, rule)
-				result = strings.ReplaceAll(result, match, anonymized)
-			}
-		}
-	}
-	
-	return result
+	return maskDetected(s.detector, strValue, s.rules, s.anonymizer)
 }
 
 // defaultSanitize 默认脱敏策略
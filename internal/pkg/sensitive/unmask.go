@@ -0,0 +1,144 @@
+package sensitive
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"kratos-boilerplate/internal/pkg/auth"
+
+	"github.com/go-kratos/kratos/v2/log"
+	"github.com/go-kratos/kratos/v2/middleware"
+	"github.com/go-kratos/kratos/v2/transport"
+	"google.golang.org/protobuf/proto"
+)
+
+// 响应个人信息脱敏
+//
+// 接口响应中声明为个人信息的字段（邮箱、手机号、姓名等）默认脱敏。调用方主体持有 pii:read 权限，
+// 并在 X-Unmask-Reason 请求头中说明原因时返回明文，每次返回明文都写入审计记录。
+// 审计写入失败时返回脱敏结果，保证每次明文查看都有记录。
+
+const (
+	// PIIReadPermission 查看个人信息明文所需的权限
+	PIIReadPermission = "pii:read"
+	// UnmaskReasonHeader 说明查看明文原因的请求头
+	UnmaskReasonHeader = "X-Unmask-Reason"
+	// maxUnmaskReasonLength 审计记录中原因的最大长度
+	maxUnmaskReasonLength = 256
+)
+
+// UnmaskRecord 个人信息明文查看记录
+type UnmaskRecord struct {
+	SubjectID   string    `json:"subject_id"`
+	SubjectType string    `json:"subject_type"`
+	Operation   string    `json:"operation"`
+	Fields      []string  `json:"fields"`
+	Reason      string    `json:"reason"`
+	Time        time.Time `json:"time"`
+}
+
+// UnmaskAuditor 个人信息明文查看审计
+type UnmaskAuditor interface {
+	RecordUnmask(ctx context.Context, record *UnmaskRecord) error
+}
+
+// ResponseMaskConfig 响应脱敏配置
+type ResponseMaskConfig struct {
+	Rules        map[string]AnonymizeRule                // 覆盖默认规则，键为敏感类型名称
+	Permission   string                                  // 查看明文所需权限，默认 pii:read
+	ReasonHeader string                                  // 原因请求头，默认 X-Unmask-Reason
+	Subject      func(ctx context.Context) *auth.Subject // 解析调用方主体，默认读取上下文中的认证主体
+	Auditor      UnmaskAuditor                           // 未配置时不返回明文
+	Logger       log.Logger
+}
+
+// ResponseMaskMiddleware 创建响应脱敏中间件，只处理 protobuf 响应
+func ResponseMaskMiddleware(config *ResponseMaskConfig) middleware.Middleware {
+	if config == nil {
+		config = &ResponseMaskConfig{}
+	}
+	permission := config.Permission
+	if permission == "" {
+		permission = PIIReadPermission
+	}
+	reasonHeader := config.ReasonHeader
+	if reasonHeader == "" {
+		reasonHeader = UnmaskReasonHeader
+	}
+	resolve := config.Subject
+	if resolve == nil {
+		resolve = auth.GetSubjectFromContext
+	}
+	logger := config.Logger
+	if logger == nil {
+		logger = log.GetLogger()
+	}
+	helper := log.NewHelper(log.With(logger, "middleware", "response_mask"))
+
+	return func(handler middleware.Handler) middleware.Handler {
+		return func(ctx context.Context, req interface{}) (interface{}, error) {
+			reply, err := handler(ctx, req)
+			if err != nil {
+				return reply, err
+			}
+			msg, ok := reply.(proto.Message)
+			if !ok {
+				return reply, nil
+			}
+			masked, fields := MaskProtoPII(msg, config.Rules)
+			if len(fields) == 0 {
+				return reply, nil
+			}
+
+			tr, ok := transport.FromServerContext(ctx)
+			if !ok {
+				return masked, nil
+			}
+			reason := unmaskReason(tr.RequestHeader().Get(reasonHeader))
+			if reason == "" {
+				return masked, nil
+			}
+
+			subject := resolve(ctx)
+			if !auth.HasPermission(subject, permission) {
+				helper.WithContext(ctx).Warnw("msg", "unmask denied", "operation", tr.Operation(), "subject", subjectID(subject))
+				return masked, nil
+			}
+			if config.Auditor == nil {
+				helper.WithContext(ctx).Errorw("msg", "unmask requested without auditor", "operation", tr.Operation())
+				return masked, nil
+			}
+
+			record := &UnmaskRecord{
+				SubjectID:   subject.ID,
+				SubjectType: subject.Type,
+				Operation:   tr.Operation(),
+				Fields:      fields,
+				Reason:      reason,
+				Time:        time.Now(),
+			}
+			if err := config.Auditor.RecordUnmask(ctx, record); err != nil {
+				helper.WithContext(ctx).Errorw("msg", "failed to record unmask", "operation", tr.Operation(), "subject", subject.ID, "error", err)
+				return masked, nil
+			}
+			return reply, nil
+		}
+	}
+}
+
+// unmaskReason 规整原因请求头，过长时截断
+func unmaskReason(value string) string {
+	reason := strings.TrimSpace(value)
+	if runes := []rune(reason); len(runes) > maxUnmaskReasonLength {
+		reason = string(runes[:maxUnmaskReasonLength])
+	}
+	return reason
+}
+
+func subjectID(subject *auth.Subject) string {
+	if subject == nil {
+		return ""
+	}
+	return subject.ID
+}
@@ -0,0 +1,154 @@
+package sensitive
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+
+	"kratos-boilerplate/internal/pkg/auth"
+
+	"github.com/go-kratos/kratos/v2/transport"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// testHeader 基于 http.Header 的请求头
+type testHeader http.Header
+
+func (h testHeader) Get(key string) string      { return http.Header(h).Get(key) }
+func (h testHeader) Set(key, value string)      { http.Header(h).Set(key, value) }
+func (h testHeader) Add(key, value string)      { http.Header(h).Add(key, value) }
+func (h testHeader) Keys() []string             { return nil }
+func (h testHeader) Values(key string) []string { return http.Header(h).Values(key) }
+
+// recordingAuditor 记录审计调用
+type recordingAuditor struct {
+	records []*UnmaskRecord
+	err     error
+}
+
+func (a *recordingAuditor) RecordUnmask(_ context.Context, record *UnmaskRecord) error {
+	if a.err != nil {
+		return a.err
+	}
+	a.records = append(a.records, record)
+	return nil
+}
+
+func unmaskContext(reason string, subject *auth.Subject) context.Context {
+	header := testHeader(http.Header{})
+	if reason != "" {
+		header.Set(UnmaskReasonHeader, reason)
+	}
+	tr := &MockTransporter{}
+	tr.On("RequestHeader").Return(transport.Header(header))
+	tr.On("Operation").Return("/auth.v1.Auth/GetProfile")
+	ctx := transport.NewServerContext(context.Background(), tr)
+	if subject != nil {
+		ctx = context.WithValue(ctx, auth.SubjectKey, subject)
+	}
+	return ctx
+}
+
+func fieldString(msg proto.Message, path ...string) string {
+	m := msg.ProtoReflect()
+	for _, name := range path[:len(path)-1] {
+		m = m.Get(m.Descriptor().Fields().ByName(protoreflect.Name(name))).Message()
+	}
+	return m.Get(m.Descriptor().Fields().ByName(protoreflect.Name(path[len(path)-1]))).String()
+}
+
+// TestMaskProtoPII 测试只脱敏个人信息字段并返回字段路径
+func TestMaskProtoPII(t *testing.T) {
+	original := newTestRegister(t)
+	masked, fields := MaskProtoPII(original, nil)
+
+	assert.Equal(t, []string{"contact", "mobile"}, fields)
+	assert.NotEqual(t, "john.doe@example.com", fieldString(masked, "contact"))
+	assert.NotEqual(t, "+8613812345678", fieldString(masked, "mobile"))
+	// 凭证字段由接口自身决定是否返回
+	assert.Equal(t, "MyPassword123!", fieldString(masked, "password"))
+	assert.Equal(t, "eyJhbGciOiJIUzI1NiJ9.payload", fieldString(masked, "session", "access_token"))
+	// 原消息不变
+	assert.Equal(t, "john.doe@example.com", fieldString(original, "contact"))
+
+	assert.True(t, SensitivityEmail.IsPII())
+	assert.False(t, SensitivityToken.IsPII())
+}
+
+// TestResponseMaskMiddleware 测试默认脱敏与带权限、原因的明文查看
+func TestResponseMaskMiddleware(t *testing.T) {
+	reply := newTestRegister(t)
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return reply, nil
+	}
+	reader := &auth.Subject{ID: "42", Type: "user", Permissions: []string{"pii:read"}}
+	auditor := &recordingAuditor{}
+	mw := ResponseMaskMiddleware(&ResponseMaskConfig{Auditor: auditor})(handler)
+
+	tests := []struct {
+		name    string
+		ctx     context.Context
+		unmask  bool
+		records int
+	}{
+		{"no_transport", context.Background(), false, 0},
+		{"no_reason", unmaskContext("", reader), false, 0},
+		{"blank_reason", unmaskContext("   ", reader), false, 0},
+		{"no_subject", unmaskContext("ticket-1", nil), false, 0},
+		{"no_permission", unmaskContext("ticket-1", &auth.Subject{ID: "7", Permissions: []string{"user:read"}}), false, 0},
+		{"permitted", unmaskContext("ticket-1", reader), true, 1},
+		{"wildcard", unmaskContext("ticket-2", &auth.Subject{ID: "1", Permissions: []string{"pii:*"}}), true, 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := mw(tt.ctx, nil)
+			require.NoError(t, err)
+			contact := fieldString(got.(proto.Message), "contact")
+			if tt.unmask {
+				assert.Equal(t, "john.doe@example.com", contact)
+			} else {
+				assert.NotEqual(t, "john.doe@example.com", contact)
+			}
+			assert.Len(t, auditor.records, tt.records)
+		})
+	}
+
+	record := auditor.records[0]
+	assert.Equal(t, "42", record.SubjectID)
+	assert.Equal(t, "/auth.v1.Auth/GetProfile", record.Operation)
+	assert.Equal(t, []string{"contact", "mobile"}, record.Fields)
+	assert.Equal(t, "ticket-1", record.Reason)
+}
+
+// TestResponseMaskMiddleware_AuditRequired 测试审计不可用时不返回明文
+func TestResponseMaskMiddleware_AuditRequired(t *testing.T) {
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return newTestRegister(t), nil
+	}
+	reader := &auth.Subject{ID: "42", Permissions: []string{"pii:read"}}
+
+	for name, config := range map[string]*ResponseMaskConfig{
+		"no_auditor":   {},
+		"audit_failed": {Auditor: &recordingAuditor{err: errors.New("db down")}},
+	} {
+		t.Run(name, func(t *testing.T) {
+			got, err := ResponseMaskMiddleware(config)(handler)(unmaskContext("ticket-1", reader), nil)
+			require.NoError(t, err)
+			assert.NotEqual(t, "john.doe@example.com", fieldString(got.(proto.Message), "contact"))
+		})
+	}
+
+	// 非 protobuf 响应与错误原样返回
+	plain, err := ResponseMaskMiddleware(nil)(func(ctx context.Context, req interface{}) (interface{}, error) {
+		return "ok", nil
+	})(context.Background(), nil)
+	require.NoError(t, err)
+	assert.Equal(t, "ok", plain)
+
+	assert.Equal(t, maxUnmaskReasonLength, len([]rune(unmaskReason(strings.Repeat("原", 300)))))
+}
@@ -0,0 +1,87 @@
+package webhook
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// ErrDestinationNotAllowed 回调地址指向回环、私有、链路本地等内部网络地址
+var ErrDestinationNotAllowed = errors.New("webhook destination not allowed")
+
+// ClientConfig 投递客户端配置
+type ClientConfig struct {
+	// Timeout 单次投递的超时时间
+	Timeout time.Duration
+	// AllowPrivateNetworks 允许投递到内部网络地址，仅用于本地开发与测试
+	AllowPrivateNetworks bool
+}
+
+// NewClient 创建投递使用的 HTTP 客户端。
+// 连接建立时检查解析后的目标地址，域名解析到内部网络地址（包括 DNS 重绑定）同样被拒绝；
+// 客户端不跟随重定向、不使用环境变量中的代理，3xx 响应按投递失败处理。
+func NewClient(config ClientConfig) *http.Client {
+	dialer := &net.Dialer{Timeout: 30 * time.Second, KeepAlive: 30 * time.Second}
+	if !config.AllowPrivateNetworks {
+		dialer.Control = func(network, address string, c syscall.RawConn) error {
+			addr, err := netip.ParseAddrPort(address)
+			if err != nil {
+				return fmt.Errorf("%w: %s", ErrDestinationNotAllowed, address)
+			}
+			if !AllowedAddr(addr.Addr()) {
+				return fmt.Errorf("%w: %s", ErrDestinationNotAllowed, addr.Addr())
+			}
+			return nil
+		}
+	}
+	transport := &http.Transport{
+		DialContext:           dialer.DialContext,
+		ForceAttemptHTTP2:     true,
+		MaxIdleConns:          100,
+		IdleConnTimeout:       90 * time.Second,
+		TLSHandshakeTimeout:   10 * time.Second,
+		ExpectContinueTimeout: time.Second,
+	}
+	return &http.Client{
+		Timeout:   config.Timeout,
+		Transport: transport,
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
+
+// CheckHost 校验回调地址中的主机，IP 字面量与 localhost 须为允许的地址；
+// 域名的解析结果在连接建立时由 NewClient 创建的客户端检查
+func CheckHost(host string) error {
+	host = strings.TrimSuffix(strings.ToLower(host), ".")
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return fmt.Errorf("%w: %s", ErrDestinationNotAllowed, host)
+	}
+	addr, err := netip.ParseAddr(strings.Trim(host, "[]"))
+	if err != nil {
+		return nil
+	}
+	if !AllowedAddr(addr) {
+		return fmt.Errorf("%w: %s", ErrDestinationNotAllowed, addr)
+	}
+	return nil
+}
+
+// AllowedAddr 地址是否可以作为投递目标，拒绝未指定、回环、私有、链路本地、组播与运营商级 NAT 地址
+func AllowedAddr(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	if !addr.IsValid() || addr.IsUnspecified() || addr.IsLoopback() || addr.IsPrivate() ||
+		addr.IsLinkLocalUnicast() || addr.IsLinkLocalMulticast() || addr.IsInterfaceLocalMulticast() || addr.IsMulticast() {
+		return false
+	}
+	return !sharedAddressSpace.Contains(addr)
+}
+
+// sharedAddressSpace 运营商级 NAT 地址段（RFC 6598）
+var sharedAddressSpace = netip.MustParsePrefix("100.64.0.0/10")
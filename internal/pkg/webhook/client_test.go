@@ -0,0 +1,73 @@
+package webhook
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAllowedAddr(t *testing.T) {
+	for _, s := range []string{"127.0.0.1", "10.1.2.3", "172.16.0.1", "192.168.1.1", "169.254.169.254",
+		"0.0.0.0", "100.64.0.1", "224.0.0.1", "::1", "::", "fe80::1", "fc00::1", "::ffff:127.0.0.1", "::ffff:169.254.169.254"} {
+		assert.False(t, AllowedAddr(netip.MustParseAddr(s)), s)
+	}
+	for _, s := range []string{"8.8.8.8", "93.184.216.34", "2606:4700::1111"} {
+		assert.True(t, AllowedAddr(netip.MustParseAddr(s)), s)
+	}
+}
+
+func TestCheckHost(t *testing.T) {
+	for _, host := range []string{"localhost", "LOCALHOST.", "api.localhost", "127.0.0.1", "[::1]", "169.254.169.254"} {
+		assert.ErrorIs(t, CheckHost(host), ErrDestinationNotAllowed, host)
+	}
+	for _, host := range []string{"partner.example.com", "8.8.8.8", "[2606:4700::1111]"} {
+		assert.NoError(t, CheckHost(host), host)
+	}
+}
+
+// 测试连接建立时拒绝解析到内部网络的地址，域名无法绕过
+func TestNewClient_BlocksPrivateDestinations(t *testing.T) {
+	hit := false
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hit = true
+	}))
+	defer server.Close()
+
+	client := NewClient(ClientConfig{Timeout: time.Second})
+	_, err := client.Post(server.URL, "application/json", nil)
+	assert.ErrorIs(t, err, ErrDestinationNotAllowed)
+
+	_, err = client.Post(strings.Replace(server.URL, "127.0.0.1", "localhost", 1), "application/json", nil)
+	assert.ErrorIs(t, err, ErrDestinationNotAllowed)
+	assert.False(t, hit)
+
+	resp, err := NewClient(ClientConfig{Timeout: time.Second, AllowPrivateNetworks: true}).Post(server.URL, "application/json", nil)
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.True(t, hit)
+}
+
+// 测试不跟随重定向，3xx 响应原样返回
+func TestNewClient_DoesNotFollowRedirects(t *testing.T) {
+	hit := false
+	internal := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hit = true
+	}))
+	defer internal.Close()
+	redirect := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, internal.URL, http.StatusTemporaryRedirect)
+	}))
+	defer redirect.Close()
+
+	resp, err := NewClient(ClientConfig{Timeout: time.Second, AllowPrivateNetworks: true}).Post(redirect.URL, "application/json", nil)
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusTemporaryRedirect, resp.StatusCode)
+	assert.False(t, hit)
+}
@@ -0,0 +1,93 @@
+// Package webhook 提供出站 Webhook 的载荷签名与校验，以及限制投递目标的 HTTP 客户端。
+//
+// 签名算法：HMAC-SHA256(secret, timestamp + "." + body)，十六进制编码后以
+// "sha256=" 前缀放在 X-Webhook-Signature 头中；X-Webhook-Timestamp 为 Unix 秒。
+// 接收方应校验签名，并拒绝时间戳与当前时间相差超过容忍窗口的请求以防重放。
+package webhook
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// HeaderID 投递的幂等键，同一事件对同一端点的重试保持不变
+	HeaderID = "X-Webhook-Id"
+	// HeaderEvent 事件类型
+	HeaderEvent = "X-Webhook-Event"
+	// HeaderTimestamp 签名时间，Unix 秒
+	HeaderTimestamp = "X-Webhook-Timestamp"
+	// HeaderSignature 载荷签名
+	HeaderSignature = "X-Webhook-Signature"
+
+	// signaturePrefix 签名算法前缀
+	signaturePrefix = "sha256="
+	// DefaultTolerance 校验签名时允许的时间偏差
+	DefaultTolerance = 5 * time.Minute
+)
+
+var (
+	// ErrMissingSignature 请求缺少签名或时间戳
+	ErrMissingSignature = errors.New("webhook signature missing")
+	// ErrInvalidSignature 签名不匹配
+	ErrInvalidSignature = errors.New("webhook signature invalid")
+	// ErrTimestampExpired 时间戳超出容忍窗口
+	ErrTimestampExpired = errors.New("webhook timestamp outside tolerance")
+)
+
+// Sign 计算载荷签名，返回 X-Webhook-Signature 头的值
+func Sign(secret string, timestamp time.Time, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp.Unix(), 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return signaturePrefix + hex.EncodeToString(mac.Sum(nil))
+}
+
+// SignRequest 为请求设置时间戳与签名头
+func SignRequest(req *http.Request, secret string, timestamp time.Time, body []byte) {
+	req.Header.Set(HeaderTimestamp, strconv.FormatInt(timestamp.Unix(), 10))
+	req.Header.Set(HeaderSignature, Sign(secret, timestamp, body))
+}
+
+// Verify 校验请求头中的签名与时间戳，tolerance 为 0 时使用 DefaultTolerance
+func Verify(header http.Header, secret string, body []byte, tolerance time.Duration) error {
+	signature := header.Get(HeaderSignature)
+	ts := header.Get(HeaderTimestamp)
+	if signature == "" || ts == "" || !strings.HasPrefix(signature, signaturePrefix) {
+		return ErrMissingSignature
+	}
+
+	unix, err := strconv.ParseInt(ts, 10, 64)
+	if err != nil {
+		return ErrMissingSignature
+	}
+	if tolerance <= 0 {
+		tolerance = DefaultTolerance
+	}
+	timestamp := time.Unix(unix, 0)
+	if d := time.Since(timestamp); d > tolerance || d < -tolerance {
+		return ErrTimestampExpired
+	}
+
+	if !hmac.Equal([]byte(signature), []byte(Sign(secret, timestamp, body))) {
+		return ErrInvalidSignature
+	}
+	return nil
+}
+
+// GenerateSecret 生成随机签名密钥
+func GenerateSecret() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return "whsec_" + hex.EncodeToString(b), nil
+}
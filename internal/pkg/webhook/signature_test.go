@@ -0,0 +1,43 @@
+package webhook
+
+import (
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSignAndVerify(t *testing.T) {
+	secret, err := GenerateSecret()
+	require.NoError(t, err)
+	body := []byte(`{"type":"user.login"}`)
+
+	req, err := http.NewRequest(http.MethodPost, "http://example.com", nil)
+	require.NoError(t, err)
+	SignRequest(req, secret, time.Now(), body)
+	assert.NoError(t, Verify(req.Header, secret, body, 0))
+
+	assert.ErrorIs(t, Verify(req.Header, "other", body, 0), ErrInvalidSignature)
+	assert.ErrorIs(t, Verify(req.Header, secret, []byte(`{}`), 0), ErrInvalidSignature)
+	assert.ErrorIs(t, Verify(http.Header{}, secret, body, 0), ErrMissingSignature)
+
+	old := time.Now().Add(-time.Hour)
+	SignRequest(req, secret, old, body)
+	assert.ErrorIs(t, Verify(req.Header, secret, body, 0), ErrTimestampExpired)
+	assert.NoError(t, Verify(req.Header, secret, body, 2*time.Hour))
+}
+
+func TestSignIsStable(t *testing.T) {
+	ts := time.Unix(1700000000, 0)
+	sig := Sign("secret", ts, []byte("payload"))
+	assert.Equal(t, sig, Sign("secret", ts, []byte("payload")))
+	assert.Len(t, sig, len(signaturePrefix)+64)
+
+	header := http.Header{}
+	header.Set(HeaderTimestamp, strconv.FormatInt(ts.Unix(), 10))
+	header.Set(HeaderSignature, sig)
+	assert.NoError(t, Verify(header, "secret", []byte("payload"), 100*365*24*time.Hour))
+}
@@ -0,0 +1,38 @@
+package server
+
+import (
+	"kratos-boilerplate/internal/conf"
+	"kratos-boilerplate/internal/pkg/auth"
+
+	"github.com/go-kratos/kratos/v2/log"
+	"github.com/go-kratos/kratos/v2/middleware"
+	"github.com/go-kratos/kratos/v2/middleware/selector"
+)
+
+// adminRole 管理接口要求的角色
+const adminRole = "admin"
+
+// adminOperationPrefixes 仅限管理员访问的接口
+var adminOperationPrefixes = []string{
+	"/plugin.v1.PluginAdmin/",
+	"/webhook.v1.WebhookAdmin/",
+	"/operationlog.v1.OperationLogAdmin/",
+	"/loglevel.v1.LogLevelAdmin/",
+}
+
+// adminOnly 校验访问令牌并要求 admin 角色，仅作用于管理接口
+func adminOnly(c *conf.Auth, logger log.Logger) middleware.Middleware {
+	tokenManager := auth.NewJWTTokenManager(&auth.JWTConfig{
+		Secret:       c.GetJwtSecretKey(),
+		AccessExpiry: c.GetAccessTokenExpiration().AsDuration(),
+	}, log.NewHelper(log.With(logger, "module", "server.admin")))
+
+	config := auth.DefaultAuthMiddlewareConfig()
+	config.TokenManager = tokenManager
+	config.Logger = logger
+
+	return selector.Server(
+		auth.AuthMiddleware(config),
+		auth.RoleMiddleware(adminRole),
+	).Prefix(adminOperationPrefixes...).Build()
+}
@@ -2,22 +2,27 @@ package server
 
 import (
 	v1 "kratos-boilerplate/api/helloworld/v1"
+	loglevelv1 "kratos-boilerplate/api/loglevel/v1"
+	operationlogv1 "kratos-boilerplate/api/operationlog/v1"
+	pluginv1 "kratos-boilerplate/api/plugin/v1"
+	webhookv1 "kratos-boilerplate/api/webhook/v1"
+	"kratos-boilerplate/internal/biz"
 	"kratos-boilerplate/internal/conf"
+	pkglog "kratos-boilerplate/internal/pkg/log"
+	"kratos-boilerplate/internal/pkg/plugin"
+	"kratos-boilerplate/internal/pkg/sensitive"
 	"kratos-boilerplate/internal/service"
 
 	"github.com/go-kratos/kratos/v2/log"
-	"github.com/go-kratos/kratos/v2/middleware/recovery"
 	"github.com/go-kratos/kratos/v2/transport/grpc"
 )
 
 // NewGRPCServer new a gRPC server.
-func NewGRPCServer(c *conf.Server, greeter *service.GreeterService, logger log.Logger) *grpc.Server {
+func NewGRPCServer(c *conf.Server, ac *conf.Auth, greeter *service.GreeterService, plugins *service.PluginService, webhooks *service.WebhookService, operationLogs *service.OperationLogService, logLevels *service.LogLevelService, hooks plugin.HookManager, auditor sensitive.UnmaskAuditor, logWriter *biz.OperationLogWriter, accessLogger pkglog.AccessLogger, logger log.Logger) *grpc.Server {
+	admin := adminOnly(ac, logger)
 	var opts = []grpc.ServerOption{
-		grpc.Middleware(
-			recovery.Recovery(),
-			// 暂时注释掉操作日志中间件，等实现了 repo 后再启用
-			// middleware.OperationLogMiddleware(repo),
-		),
+		grpc.Middleware(serverMiddleware(ac, admin, hooks, auditor, logWriter, accessLogger, logger)...),
+		grpc.StreamMiddleware(admin),
 	}
 	if c.Grpc.Network != "" {
 		opts = append(opts, grpc.Network(c.Grpc.Network))
@@ -30,5 +35,9 @@ func NewGRPCServer(c *conf.Server, greeter *service.GreeterService, logger log.L
 	}
 	srv := grpc.NewServer(opts...)
 	v1.RegisterGreeterServer(srv, greeter)
+	pluginv1.RegisterPluginAdminServer(srv, plugins)
+	webhookv1.RegisterWebhookAdminServer(srv, webhooks)
+	operationlogv1.RegisterOperationLogAdminServer(srv, operationLogs)
+	loglevelv1.RegisterLogLevelAdminServer(srv, logLevels)
 	return srv
 }
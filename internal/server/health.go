@@ -2,16 +2,19 @@ package server
 
 import (
 	"context"
+	"fmt"
 	"kratos-boilerplate/internal/conf"
 	"kratos-boilerplate/internal/data"
 	"kratos-boilerplate/internal/pkg/health"
+	"kratos-boilerplate/internal/pkg/plugin"
+	"strings"
 	"time"
 
 	"github.com/go-kratos/kratos/v2/log"
 )
 
 // NewHealthChecker creates a new health checker with default checks
-func NewHealthChecker(data *data.Data, c *conf.Bootstrap, logger log.Logger) *health.HealthChecker {
+func NewHealthChecker(data *data.Data, c *conf.Bootstrap, pm plugin.PluginManager, logger log.Logger) *health.HealthChecker {
 	hc := health.NewHealthChecker(30*time.Second, logger)
 
 	// Add basic liveness check
@@ -68,6 +71,63 @@ func NewHealthChecker(data *data.Data, c *conf.Bootstrap, logger log.Logger) *he
 		hc.AddChecker(mockRedisChecker)
 	}
 
+	// Plugin health check
+	if pm != nil {
+		hc.AddChecker(newPluginChecker(pm, logger))
+	}
+
 	log.NewHelper(logger).Info("Health checker initialized with basic checks")
 	return hc
 }
+
+// newPluginChecker checks every running plugin. A failing plugin degrades
+// the service instead of marking it unhealthy, since plugins only extend it.
+// The health endpoints are unauthenticated, so the result only carries each
+// plugin's status; failure reasons are logged and available through the
+// admin plugin API. The liveness probe does not run this check.
+func newPluginChecker(pm plugin.PluginManager, logger log.Logger) health.Checker {
+	helper := log.NewHelper(log.With(logger, "module", "server.health"))
+	return health.NewNamedChecker("plugins", func(ctx context.Context) health.CheckResult {
+		start := time.Now()
+		details := make(map[string]interface{})
+		var failed []string
+
+		for _, info := range pm.ListPlugins() {
+			name := info.Metadata.Name
+			switch info.Status {
+			case plugin.PluginStatusStarted:
+				p, err := pm.GetPlugin(name)
+				if err == nil {
+					err = p.HealthCheck(ctx)
+				}
+				if err != nil {
+					helper.Warnf("plugin %s health check failed: %v", name, err)
+					details[name] = string(health.StatusUnhealthy)
+					failed = append(failed, name)
+					continue
+				}
+				details[name] = string(health.StatusHealthy)
+			case plugin.PluginStatusError:
+				helper.Warnf("plugin %s is in error state: %s", name, info.ErrorMsg)
+				details[name] = string(info.Status)
+				failed = append(failed, name)
+			default:
+				details[name] = string(info.Status)
+			}
+		}
+
+		result := health.CheckResult{
+			Name:      "plugins",
+			Status:    health.StatusHealthy,
+			Message:   "All plugins are healthy",
+			Details:   details,
+			Timestamp: time.Now(),
+			Duration:  time.Since(start),
+		}
+		if len(failed) > 0 {
+			result.Status = health.StatusDegraded
+			result.Message = fmt.Sprintf("Unhealthy plugins: %s", strings.Join(failed, ", "))
+		}
+		return result
+	})
+}
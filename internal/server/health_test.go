@@ -0,0 +1,70 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"kratos-boilerplate/internal/pkg/health"
+	"kratos-boilerplate/internal/pkg/plugin"
+
+	"github.com/go-kratos/kratos/v2/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// failingPlugin 健康检查失败的插件
+type failingPlugin struct {
+	plugin.Plugin
+}
+
+func (p *failingPlugin) HealthCheck(ctx context.Context) error {
+	return errors.New("dial unix /run/secret/billing.sock: connection refused")
+}
+
+// listPluginManager 只实现健康检查用到的方法
+type listPluginManager struct {
+	plugin.PluginManager
+	plugins []plugin.PluginInfo
+	calls   int
+}
+
+func (m *listPluginManager) ListPlugins() []plugin.PluginInfo {
+	m.calls++
+	return m.plugins
+}
+
+func (m *listPluginManager) GetPlugin(name string) (plugin.Plugin, error) {
+	return &failingPlugin{}, nil
+}
+
+// TestPluginChecker_HidesErrors 测试公开的健康检查只返回插件状态，存活探针不执行插件检查
+func TestPluginChecker_HidesErrors(t *testing.T) {
+	pm := &listPluginManager{plugins: []plugin.PluginInfo{
+		{Metadata: plugin.PluginMetadata{Name: "billing"}, Status: plugin.PluginStatusStarted},
+		{Metadata: plugin.PluginMetadata{Name: "audit"}, Status: plugin.PluginStatusError, ErrorMsg: "signature verification failed: /opt/plugins/audit"},
+	}}
+	hc := health.NewHealthChecker(0, log.NewStdLogger(io.Discard))
+	hc.AddChecker(newPluginChecker(pm, log.NewStdLogger(io.Discard)))
+
+	rec := httptest.NewRecorder()
+	hc.GetHTTPHandler()(rec, httptest.NewRequest(http.MethodGet, "/health/ready", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.NotContains(t, rec.Body.String(), "connection refused")
+	assert.NotContains(t, rec.Body.String(), "/opt/plugins")
+
+	var result health.OverallHealth
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &result))
+	assert.Equal(t, health.StatusDegraded, result.Status)
+	assert.Equal(t, map[string]interface{}{"billing": "unhealthy", "audit": "error"}, result.Checks["plugins"].Details)
+	assert.Equal(t, 1, pm.calls)
+
+	rec = httptest.NewRecorder()
+	hc.GetLivenessHTTPHandler()(rec, httptest.NewRequest(http.MethodGet, "/health/live", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, 1, pm.calls)
+}
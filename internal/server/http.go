@@ -8,28 +8,31 @@ import (
 
 	authv1 "kratos-boilerplate/api/auth/v1"
 	v1 "kratos-boilerplate/api/helloworld/v1"
+	loglevelv1 "kratos-boilerplate/api/loglevel/v1"
+	operationlogv1 "kratos-boilerplate/api/operationlog/v1"
+	pluginv1 "kratos-boilerplate/api/plugin/v1"
+	webhookv1 "kratos-boilerplate/api/webhook/v1"
+	"kratos-boilerplate/internal/biz"
 	"kratos-boilerplate/internal/conf"
 	"kratos-boilerplate/internal/pkg/health"
+	pkglog "kratos-boilerplate/internal/pkg/log"
+	"kratos-boilerplate/internal/pkg/plugin"
 	"kratos-boilerplate/internal/pkg/security"
+	"kratos-boilerplate/internal/pkg/sensitive"
 	"kratos-boilerplate/internal/service"
 
 	"github.com/go-kratos/kratos/v2/log"
-	"github.com/go-kratos/kratos/v2/middleware/recovery"
 	kratosHttp "github.com/go-kratos/kratos/v2/transport/http"
 )
 
 // NewHTTPServer new an HTTP server.
-func NewHTTPServer(c *conf.Server, greeter *service.GreeterService, auth *service.AuthService, healthChecker *health.HealthChecker, logger log.Logger) *kratosHttp.Server {
+func NewHTTPServer(c *conf.Server, ac *conf.Auth, greeter *service.GreeterService, auth *service.AuthService, plugins *service.PluginService, webhooks *service.WebhookService, operationLogs *service.OperationLogService, logLevels *service.LogLevelService, healthChecker *health.HealthChecker, hooks plugin.HookManager, auditor sensitive.UnmaskAuditor, logWriter *biz.OperationLogWriter, accessLogger pkglog.AccessLogger, logger log.Logger) *kratosHttp.Server {
 	// Security configuration
 	securityConfig := security.DefaultSecurityConfig()
 
 	var opts = []kratosHttp.ServerOption{
-		kratosHttp.Middleware(
-			recovery.Recovery(),
-			// Security middleware will be added as filters
-			// 暂时注释掉操作日志中间件，等实现了 repo 后再启用
-			// middleware.OperationLogMiddleware(repo),
-		),
+		// Security middleware will be added as filters
+		kratosHttp.Middleware(serverMiddleware(ac, adminOnly(ac, logger), hooks, auditor, logWriter, accessLogger, logger)...),
 		// Add security filters
 		kratosHttp.Filter(
 			security.SecurityHeadersFilter(securityConfig),
@@ -50,12 +53,16 @@ func NewHTTPServer(c *conf.Server, greeter *service.GreeterService, auth *servic
 	// Register API handlers
 	v1.RegisterGreeterHTTPServer(srv, greeter)
 	authv1.RegisterAuthHTTPServer(srv, auth)
+	pluginv1.RegisterPluginAdminHTTPServer(srv, plugins)
+	webhookv1.RegisterWebhookAdminHTTPServer(srv, webhooks)
+	operationlogv1.RegisterOperationLogAdminHTTPServer(srv, operationLogs)
+	loglevelv1.RegisterLogLevelAdminHTTPServer(srv, logLevels)
 
 	// Register health check endpoints
 	if healthChecker != nil {
 		srv.HandleFunc("/health", healthChecker.GetHTTPHandler())
-		srv.HandleFunc("/health/live", healthChecker.GetHTTPHandler())  // Kubernetes liveness probe
-		srv.HandleFunc("/health/ready", healthChecker.GetHTTPHandler()) // Kubernetes readiness probe
+		srv.HandleFunc("/health/live", healthChecker.GetLivenessHTTPHandler()) // Kubernetes liveness probe
+		srv.HandleFunc("/health/ready", healthChecker.GetHTTPHandler())        // Kubernetes readiness probe
 	}
 
 	// Register basic metrics endpoint
@@ -0,0 +1,46 @@
+package middleware
+
+import (
+	"context"
+
+	"kratos-boilerplate/internal/pkg/auth"
+	pkglog "kratos-boilerplate/internal/pkg/log"
+
+	"github.com/go-kratos/kratos/v2/middleware"
+	"github.com/go-kratos/kratos/v2/transport"
+	"github.com/google/uuid"
+)
+
+const (
+	// RequestIDHeader 请求 ID 请求头，调用方未携带时生成，并在响应头中返回
+	RequestIDHeader = "X-Request-ID"
+	// maxRequestIDLength 调用方传入的请求 ID 超过该长度时重新生成
+	maxRequestIDLength = 128
+)
+
+// LogContextMiddleware 将请求 ID 与调用方用户 ID 放入 context，之后经由 log.WithContext 写入的日志都带有这两个字段
+// resolve 解析调用方主体，为空时读取认证中间件设置到 context 中的主体
+func LogContextMiddleware(resolve func(ctx context.Context) *auth.Subject) middleware.Middleware {
+	if resolve == nil {
+		resolve = auth.GetSubjectFromContext
+	}
+	return func(handler middleware.Handler) middleware.Handler {
+		return func(ctx context.Context, req interface{}) (interface{}, error) {
+			var requestID string
+			if tr, ok := transport.FromServerContext(ctx); ok {
+				requestID = tr.RequestHeader().Get(RequestIDHeader)
+				if requestID == "" || len(requestID) > maxRequestIDLength {
+					requestID = uuid.NewString()
+				}
+				tr.ReplyHeader().Set(RequestIDHeader, requestID)
+			}
+			if requestID != "" {
+				ctx = pkglog.WithRequestID(ctx, requestID)
+			}
+			if subject := resolve(ctx); subject != nil {
+				ctx = pkglog.WithUserID(ctx, subject.ID)
+			}
+			return handler(ctx, req)
+		}
+	}
+}
@@ -2,9 +2,11 @@ package middleware
 
 import (
 	"context"
+	"strconv"
 	"time"
 
 	"kratos-boilerplate/internal/biz"
+	"kratos-boilerplate/internal/pkg/auth"
 
 	"github.com/go-kratos/kratos/v2/middleware"
 	"github.com/go-kratos/kratos/v2/transport"
@@ -12,13 +14,16 @@ import (
 	"github.com/go-kratos/kratos/v2/transport/http"
 )
 
-// OperationLogMiddleware 创建操作日志中间件
-func OperationLogMiddleware(repo biz.OperationLogRepo) middleware.Middleware {
+// OperationLogMiddleware 创建操作日志中间件，日志交由写入器异步批量写入
+// resolve 解析调用方主体，为空时读取认证中间件设置到 context 中的主体
+func OperationLogMiddleware(writer *biz.OperationLogWriter, resolve func(ctx context.Context) *auth.Subject) middleware.Middleware {
+	if resolve == nil {
+		resolve = auth.GetSubjectFromContext
+	}
 	return func(handler middleware.Handler) middleware.Handler {
 		return func(ctx context.Context, req interface{}) (reply interface{}, err error) {
-			// 获取用户信息，这里假设用户信息已经在认证中间件中设置到了 context 中
-			userID := getUserIDFromContext(ctx)
-			username := getUsernameFromContext(ctx)
+			start := time.Now()
+			subject := resolve(ctx)
 
 			// 获取操作相关信息
 			operation := getOperation(ctx)
@@ -27,39 +32,45 @@ func OperationLogMiddleware(repo biz.OperationLogRepo) middleware.Middleware {
 			// 执行实际的处理
 			reply, err = handler(ctx, req)
 
-			// 记录操作日志
-			log := &biz.OperationLog{
-				UserID:    userID,
-				Username:  username,
+			entry := &biz.OperationLog{
+				UserID:    getUserID(subject),
+				Username:  getUsername(subject),
 				Operation: operation,
 				Target:    target,
 				Content:   formatContent(req),
 				Result:    formatResult(reply, err),
-				CreatedAt: time.Now(),
+				CreatedAt: start,
 			}
-
-			// 异步记录日志
-			go func() {
-				if err := repo.CreateLog(context.Background(), log); err != nil {
-					// 这里可以添加日志记录失败的处理逻辑
-				}
-			}()
+			// 路径长度不受限，超出列长度时截断
+			entry.TruncateColumns()
+			writer.Write(entry)
 
 			return reply, err
 		}
 	}
 }
 
-// 从 context 中获取用户 ID
-func getUserIDFromContext(ctx context.Context) int64 {
-	// 实际项目中需要根据认证中间件的实现来获取用户 ID
-	return 0
+// 获取用户 ID，主体 ID 不是用户 ID（如 API 客户端）时为 0
+func getUserID(subject *auth.Subject) int64 {
+	if subject == nil {
+		return 0
+	}
+	id, err := strconv.ParseInt(subject.ID, 10, 64)
+	if err != nil {
+		return 0
+	}
+	return id
 }
 
-// 从 context 中获取用户名
-func getUsernameFromContext(ctx context.Context) string {
-	// 实际项目中需要根据认证中间件的实现来获取用户名
-	return ""
+// 获取用户名，令牌中没有用户名时使用主体 ID
+func getUsername(subject *auth.Subject) string {
+	if subject == nil {
+		return ""
+	}
+	if username := subject.Attributes["username"]; username != "" {
+		return username
+	}
+	return subject.ID
 }
 
 // 获取操作类型
@@ -0,0 +1,37 @@
+package server
+
+import (
+	"context"
+
+	"kratos-boilerplate/internal/biz"
+	"kratos-boilerplate/internal/conf"
+	"kratos-boilerplate/internal/pkg/auth"
+	"kratos-boilerplate/internal/server/middleware"
+
+	"github.com/go-kratos/kratos/v2/log"
+	kratosMiddleware "github.com/go-kratos/kratos/v2/middleware"
+)
+
+// operationLog 记录每个请求的操作日志。
+// 非管理接口不经过认证中间件，按请求头中的令牌解析操作用户，令牌无效时记为匿名。
+func operationLog(c *conf.Auth, writer *biz.OperationLogWriter, logger log.Logger) kratosMiddleware.Middleware {
+	return middleware.OperationLogMiddleware(writer, subjectResolver(c, logger, "server.operation_log"))
+}
+
+// logContext 将请求 ID 与调用方用户 ID 放入 context，供访问日志与应用日志使用
+func logContext(c *conf.Auth, logger log.Logger) kratosMiddleware.Middleware {
+	return middleware.LogContextMiddleware(subjectResolver(c, logger, "server.log_context"))
+}
+
+// subjectResolver 按请求头中的令牌解析调用方主体，令牌无效时返回 nil
+func subjectResolver(c *conf.Auth, logger log.Logger, module string) func(ctx context.Context) *auth.Subject {
+	config := auth.DefaultAuthMiddlewareConfig()
+	config.TokenManager = auth.NewJWTTokenManager(&auth.JWTConfig{
+		Secret:       c.GetJwtSecretKey(),
+		AccessExpiry: c.GetAccessTokenExpiration().AsDuration(),
+	}, log.NewHelper(log.With(logger, "module", module)))
+
+	return func(ctx context.Context) *auth.Subject {
+		return auth.ResolveSubject(ctx, config)
+	}
+}
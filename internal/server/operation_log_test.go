@@ -0,0 +1,109 @@
+package server
+
+import (
+	"context"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"kratos-boilerplate/internal/biz"
+	"kratos-boilerplate/internal/conf"
+
+	"github.com/go-kratos/kratos/v2/log"
+	"github.com/go-kratos/kratos/v2/transport"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// loginUserRepo 只实现登录所需方法的用户仓储
+type loginUserRepo struct {
+	biz.UserRepo
+	user *biz.User
+}
+
+func (r *loginUserRepo) GetLock(ctx context.Context, username string) (*biz.AccountLock, error) {
+	return nil, biz.ErrUserNotFound
+}
+
+func (r *loginUserRepo) GetUser(ctx context.Context, username string) (*biz.User, error) {
+	if username != r.user.Username {
+		return nil, biz.ErrUserNotFound
+	}
+	return r.user, nil
+}
+
+func (r *loginUserRepo) SaveRefreshToken(ctx context.Context, username, tokenID string, expiresAt time.Time) error {
+	return nil
+}
+
+// memoryOperationLogRepo 保存在内存中的操作日志仓储
+type memoryOperationLogRepo struct {
+	biz.OperationLogRepo
+	mu   sync.Mutex
+	logs []*biz.OperationLog
+}
+
+func (r *memoryOperationLogRepo) CreateLogs(ctx context.Context, logs []*biz.OperationLog) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.logs = append(r.logs, logs...)
+	return nil
+}
+
+// headerTransport 只带请求头的服务端传输
+type headerTransport struct {
+	header transport.Header
+}
+
+func (t *headerTransport) Kind() transport.Kind            { return transport.KindHTTP }
+func (t *headerTransport) Endpoint() string                { return "" }
+func (t *headerTransport) Operation() string               { return "" }
+func (t *headerTransport) RequestHeader() transport.Header { return t.header }
+func (t *headerTransport) ReplyHeader() transport.Header   { return t.header }
+
+type mapHeader map[string]string
+
+func (h mapHeader) Get(key string) string      { return h[key] }
+func (h mapHeader) Set(key, value string)      { h[key] = value }
+func (h mapHeader) Add(key, value string)      { h[key] = value }
+func (h mapHeader) Keys() []string             { return nil }
+func (h mapHeader) Values(key string) []string { return []string{h[key]} }
+
+// TestOperationLog_RecordsLoggedInUser 测试使用登录签发的令牌调用接口时，操作日志记录该用户
+func TestOperationLog_RecordsLoggedInUser(t *testing.T) {
+	hash, err := bcrypt.GenerateFromPassword([]byte("Password123"), bcrypt.MinCost)
+	require.NoError(t, err)
+	logger := log.NewStdLogger(os.Stdout)
+
+	config := biz.DefaultAuthConfig
+	config.JWTSecretKey = "operation-log-test-secret"
+	config.CaptchaEnabled = false
+	uc := biz.NewAuthUsecase(&loginUserRepo{user: &biz.User{ID: 42, Username: "alice", Password: string(hash)}}, nil, config, logger)
+	tokens, err := uc.Login(context.Background(), "alice", "Password123", "", "", "")
+	require.NoError(t, err)
+
+	repo := &memoryOperationLogRepo{}
+	writerConfig := biz.DefaultOperationLogWriterConfig
+	writerConfig.FlushInterval = 10 * time.Millisecond
+	writer := biz.NewOperationLogWriterWithConfig(repo, writerConfig, logger)
+
+	handler := operationLog(&conf.Auth{JwtSecretKey: config.JWTSecretKey}, writer, logger)(
+		func(ctx context.Context, req interface{}) (interface{}, error) { return "ok", nil })
+	for _, header := range []mapHeader{
+		{"Authorization": "Bearer " + tokens.AccessToken},
+		{"Authorization": "Bearer invalid"},
+	} {
+		ctx := transport.NewServerContext(context.Background(), &headerTransport{header: header})
+		_, err = handler(ctx, nil)
+		require.NoError(t, err)
+	}
+	writer.Close()
+
+	require.Len(t, repo.logs, 2)
+	assert.Equal(t, int64(42), repo.logs[0].UserID)
+	assert.Equal(t, "alice", repo.logs[0].Username)
+	assert.Equal(t, int64(0), repo.logs[1].UserID)
+	assert.Empty(t, repo.logs[1].Username)
+}
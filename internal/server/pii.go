@@ -0,0 +1,30 @@
+package server
+
+import (
+	"context"
+
+	"kratos-boilerplate/internal/conf"
+	"kratos-boilerplate/internal/pkg/auth"
+	"kratos-boilerplate/internal/pkg/sensitive"
+
+	"github.com/go-kratos/kratos/v2/log"
+	"github.com/go-kratos/kratos/v2/middleware"
+)
+
+// piiMask 响应中的个人信息默认脱敏，持有 pii:read 权限并说明原因的调用方可查看明文。
+// 非管理接口不经过认证中间件，请求查看明文时按请求头中的令牌解析调用方。
+func piiMask(c *conf.Auth, auditor sensitive.UnmaskAuditor, logger log.Logger) middleware.Middleware {
+	config := auth.DefaultAuthMiddlewareConfig()
+	config.TokenManager = auth.NewJWTTokenManager(&auth.JWTConfig{
+		Secret:       c.GetJwtSecretKey(),
+		AccessExpiry: c.GetAccessTokenExpiration().AsDuration(),
+	}, log.NewHelper(log.With(logger, "module", "server.pii")))
+
+	return sensitive.ResponseMaskMiddleware(&sensitive.ResponseMaskConfig{
+		Subject: func(ctx context.Context) *auth.Subject {
+			return auth.ResolveSubject(ctx, config)
+		},
+		Auditor: auditor,
+		Logger:  logger,
+	})
+}
@@ -0,0 +1,79 @@
+package server
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	authv1 "kratos-boilerplate/api/auth/v1"
+	"kratos-boilerplate/internal/biz"
+	"kratos-boilerplate/internal/conf"
+	"kratos-boilerplate/internal/pkg/auth"
+	"kratos-boilerplate/internal/pkg/plugin"
+	"kratos-boilerplate/internal/pkg/sensitive"
+
+	"github.com/go-kratos/kratos/v2/log"
+	"github.com/go-kratos/kratos/v2/middleware"
+	"github.com/go-kratos/kratos/v2/transport"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// countingAuditor 记录查看明文的次数
+type countingAuditor struct {
+	records int
+}
+
+func (a *countingAuditor) RecordUnmask(ctx context.Context, record *sensitive.UnmaskRecord) error {
+	a.records++
+	return nil
+}
+
+// TestServerMiddleware_HooksSeeMaskedPII 测试完整中间件链中插件钩子只看到脱敏后的个人信息，
+// 钩子短路给出的响应同样脱敏
+func TestServerMiddleware_HooksSeeMaskedPII(t *testing.T) {
+	logger := log.NewStdLogger(os.Stdout)
+	ac := &conf.Auth{JwtSecretKey: "pii-chain-test-secret"}
+	writer := biz.NewOperationLogWriterWithConfig(&memoryOperationLogRepo{}, biz.DefaultOperationLogWriterConfig, logger)
+	defer writer.Close()
+	auditor := &countingAuditor{}
+
+	hooks := plugin.NewHookManager()
+	var seen interface{}
+	require.NoError(t, hooks.RegisterHook(plugin.HookPointAfterRequest, plugin.NewBaseHook("inspect", 10, time.Second,
+		func(ctx context.Context, data plugin.HookData) error {
+			seen = data.GetData()["reply"]
+			return nil
+		})))
+	chain := middleware.Chain(serverMiddleware(ac, adminOnly(ac, logger), hooks, auditor, writer, logger, logger)...)
+
+	token, err := auth.NewJWTTokenManager(&auth.JWTConfig{Secret: ac.JwtSecretKey, AccessExpiry: time.Hour}, log.NewHelper(logger)).
+		GenerateToken(context.Background(), &auth.Subject{ID: "1", Permissions: []string{sensitive.PIIReadPermission}}, auth.TokenTypeAccess)
+	require.NoError(t, err)
+	header := mapHeader{"Authorization": "Bearer " + token.Value, sensitive.UnmaskReasonHeader: "support ticket 42"}
+	ctx := transport.NewServerContext(context.Background(), &headerTransport{header: header})
+
+	// 有权限的调用方查看明文，钩子仍只看到脱敏后的响应
+	handler := chain(func(ctx context.Context, req interface{}) (interface{}, error) {
+		return &authv1.RegisterRequest{Username: "alice", Email: "alice@example.com", Phone: "+8613812345678"}, nil
+	})
+	reply, err := handler(ctx, &authv1.RegisterRequest{})
+	require.NoError(t, err)
+	assert.Equal(t, "alice@example.com", reply.(*authv1.RegisterRequest).Email)
+	assert.Equal(t, 1, auditor.records)
+	payload, ok := seen.(map[string]interface{})
+	require.True(t, ok)
+	assert.NotContains(t, payload["email"], "alice@example.com")
+	assert.NotContains(t, payload["phone"], "13812345678")
+
+	// 钩子短路给出的响应经过脱敏
+	require.NoError(t, hooks.RegisterHook(plugin.HookPointBeforeRequest, plugin.NewBaseHook("cache", 10, time.Second,
+		func(ctx context.Context, data plugin.HookData) error {
+			return plugin.NewHookShortCircuit(&authv1.RegisterRequest{Email: "bob@example.com"})
+		})))
+	ctx = transport.NewServerContext(context.Background(), &headerTransport{header: mapHeader{}})
+	reply, err = handler(ctx, &authv1.RegisterRequest{})
+	require.NoError(t, err)
+	assert.NotEqual(t, "bob@example.com", reply.(*authv1.RegisterRequest).Email)
+}
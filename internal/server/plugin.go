@@ -0,0 +1,131 @@
+package server
+
+import (
+	"kratos-boilerplate/internal/conf"
+	"kratos-boilerplate/internal/pkg/plugin"
+
+	"github.com/go-kratos/kratos/v2/log"
+)
+
+// pluginEventWorkers 插件事件总线的异步工作协程数
+const pluginEventWorkers = 10
+
+// NewPluginEventBus creates the event bus shared by plugins and business code.
+// At-least-once subscriptions are queued in the given store and survive restarts.
+func NewPluginEventBus(c *conf.Bootstrap, store plugin.EventStore) (plugin.EventBus, func()) {
+	config := plugin.EventBusConfig{
+		AsyncWorkers: pluginEventWorkers,
+		Store:        store,
+	}
+	if ec := c.GetPlugins().GetEvents(); ec != nil {
+		if ec.Workers > 0 {
+			config.AsyncWorkers = int(ec.Workers)
+		}
+		if ec.PollInterval != nil {
+			config.PollInterval = ec.PollInterval.AsDuration()
+		}
+		if ec.Lease != nil {
+			config.Lease = ec.Lease.AsDuration()
+		}
+		config.BatchSize = int(ec.BatchSize)
+	}
+
+	bus := plugin.NewEventBusWithConfig(config)
+	return bus, func() {
+		_ = bus.Close()
+	}
+}
+
+// NewPluginHookManager creates the hook manager with the execution policies from the plugins config.
+func NewPluginHookManager(c *conf.Bootstrap) plugin.HookManager {
+	config := plugin.HookManagerConfig{
+		Points: make(map[plugin.HookPoint]plugin.HookPointPolicy),
+		Hooks:  make(map[plugin.HookPoint]map[string]plugin.HookPolicy),
+	}
+	hc := c.GetPlugins().GetHooks()
+	for point, p := range hc.GetPoints() {
+		config.Points[plugin.HookPoint(point)] = plugin.HookPointPolicy{
+			Execution: plugin.HookExecutionMode(p.GetExecution()),
+			Failure:   plugin.HookFailureMode(p.GetFailure()),
+		}
+	}
+	for _, h := range hc.GetHooks() {
+		point := plugin.HookPoint(h.GetPoint())
+		if config.Hooks[point] == nil {
+			config.Hooks[point] = make(map[string]plugin.HookPolicy)
+		}
+		config.Hooks[point][h.GetName()] = plugin.HookPolicy{
+			Failure:      plugin.HookFailureMode(h.GetFailure()),
+			MaxErrorRate: h.GetMaxErrorRate(),
+			MaxLatency:   h.GetMaxLatency().AsDuration(),
+			Window:       int(h.GetWindow()),
+			MinRequests:  int(h.GetMinRequests()),
+			Cooldown:     h.GetCooldown().AsDuration(),
+		}
+	}
+	return plugin.NewHookManagerWithConfig(config)
+}
+
+// NewPluginManager creates the plugin manager from the plugins config and loads plugins on startup.
+func NewPluginManager(c *conf.Bootstrap, hooks plugin.HookManager, events plugin.EventBus, logger log.Logger) (plugin.PluginManager, func(), error) {
+	helper := log.NewHelper(logger)
+	pc := c.GetPlugins()
+
+	sandbox := plugin.DefaultSandboxConfig()
+	if sec := pc.GetSecurity(); sec != nil {
+		sandbox.Enabled = sec.SandboxEnabled
+		if sec.MaxMemory != "" {
+			size, err := plugin.ParseMemorySize(sec.MaxMemory)
+			if err != nil {
+				return nil, nil, err
+			}
+			sandbox.MaxMemory = size
+		}
+		if sec.MaxCpuPercent > 0 {
+			sandbox.MaxCPUPercent = int(sec.MaxCpuPercent)
+		}
+		if sec.MaxExecutionTime != nil {
+			sandbox.MaxExecutionTime = sec.MaxExecutionTime.AsDuration()
+		}
+	}
+
+	// 启用签名校验时只加载信任库中发布者签名的插件
+	var trustStore *plugin.TrustStore
+	if sec := pc.GetSecurity(); sec.GetRequireSignature() {
+		ts, err := plugin.LoadTrustStore(sec.GetTrustStore())
+		if err != nil {
+			return nil, nil, err
+		}
+		trustStore = ts
+		helper.Infof("plugin signature verification enabled, trusted publishers: %v", ts.Publishers())
+	}
+
+	pm := plugin.NewPluginManagerWithConfig(plugin.NewPluginRegistry(), hooks, events, plugin.ManagerConfig{
+		ConfigDir:  pc.GetConfigDirectory(),
+		PluginDir:  pc.GetDirectory(),
+		AutoLoad:   pc.GetAutoLoad(),
+		Sandbox:    sandbox,
+		TrustStore: trustStore,
+	})
+
+	// 启动时加载插件目录，并监听插件与配置文件变更实现热更新
+	if pc.GetEnabled() && pc.GetAutoLoad() {
+		if err := pm.LoadAll(); err != nil {
+			helper.Warnf("failed to load plugins: %v", err)
+		}
+		if err := pm.StartWatching(); err != nil {
+			helper.Warnf("failed to watch plugin directories: %v", err)
+		}
+	}
+
+	cleanup := func() {
+		pm.StopWatching()
+		for _, info := range pm.ListPlugins() {
+			if err := pm.UnloadPlugin(info.Metadata.Name); err != nil {
+				helper.Warnf("failed to unload plugin %s: %v", info.Metadata.Name, err)
+			}
+		}
+	}
+
+	return pm, cleanup, nil
+}
@@ -1,8 +1,40 @@
 package server
 
 import (
+	"kratos-boilerplate/internal/biz"
+	"kratos-boilerplate/internal/conf"
+	pkglog "kratos-boilerplate/internal/pkg/log"
+	"kratos-boilerplate/internal/pkg/plugin"
+	"kratos-boilerplate/internal/pkg/sensitive"
+
+	"github.com/go-kratos/kratos/v2/log"
+	"github.com/go-kratos/kratos/v2/middleware"
+	"github.com/go-kratos/kratos/v2/middleware/logging"
+	"github.com/go-kratos/kratos/v2/middleware/recovery"
 	"github.com/google/wire"
 )
 
 // ProviderSet is server providers.
-var ProviderSet = wire.NewSet(NewGRPCServer, NewHTTPServer, NewHealthChecker)
+var ProviderSet = wire.NewSet(
+	NewGRPCServer,
+	NewHTTPServer,
+	NewHealthChecker,
+	NewPluginHookManager,
+	NewPluginEventBus,
+	NewPluginManager,
+)
+
+// serverMiddleware HTTP 与 gRPC 服务共用的中间件链，admin 为管理接口鉴权中间件
+func serverMiddleware(ac *conf.Auth, admin middleware.Middleware, hooks plugin.HookManager, auditor sensitive.UnmaskAuditor, logWriter *biz.OperationLogWriter, accessLogger pkglog.AccessLogger, logger log.Logger) []middleware.Middleware {
+	return []middleware.Middleware{
+		recovery.Recovery(),
+		logContext(ac, logger),
+		logging.Server(accessLogger),
+		// 位于管理员校验之前，被拒绝的管理接口访问也记录
+		operationLog(ac, logWriter, logger),
+		admin,
+		// 位于插件钩子之外，钩子短路给出的响应同样脱敏；钩子数据中的响应由 HookMiddleware 脱敏
+		piiMask(ac, auditor, logger),
+		plugin.HookMiddleware(hooks, logger),
+	}
+}
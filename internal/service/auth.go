@@ -5,6 +5,7 @@ import (
 
 	v1 "kratos-boilerplate/api/auth/v1"
 	"kratos-boilerplate/internal/biz"
+	"kratos-boilerplate/internal/pkg/plugin"
 
 	"github.com/go-kratos/kratos/v2/errors"
 	"github.com/go-kratos/kratos/v2/log"
@@ -21,7 +22,7 @@ type AuthService struct {
 func NewAuthService(uc biz.AuthUsecase, logger log.Logger) *AuthService {
 	return &AuthService{
 		uc:  uc,
-		log: log.NewHelper(logger),
+		log: log.NewHelper(log.With(logger, "module", "service.auth")),
 	}
 }
 
@@ -79,6 +80,9 @@ func (s *AuthService) Register(ctx context.Context, req *v1.RegisterRequest) (*v
 		case biz.ErrCaptchaExpired:
 			return nil, errors.BadRequest("CAPTCHA_EXPIRED", "验证码已过期")
 		default:
+			if abort, ok := plugin.AsHookAbort(err); ok {
+				return nil, abort.KratosError()
+			}
 			return nil, errors.InternalServer("REGISTER_ERROR", err.Error())
 		}
 	}
@@ -107,6 +111,9 @@ func (s *AuthService) Login(ctx context.Context, req *v1.LoginRequest) (*v1.Logi
 		case biz.ErrTotpCodeInvalid:
 			return nil, errors.BadRequest("TOTP_INVALID", "TOTP验证码无效")
 		default:
+			if abort, ok := plugin.AsHookAbort(err); ok {
+				return nil, abort.KratosError()
+			}
 			return nil, errors.InternalServer("LOGIN_ERROR", err.Error())
 		}
 	}
@@ -147,6 +154,9 @@ func (s *AuthService) Logout(ctx context.Context, req *v1.LogoutRequest) (*v1.Lo
 		case biz.ErrTokenExpired:
 			return nil, errors.Unauthorized("TOKEN_EXPIRED", "访问令牌已过期")
 		default:
+			if abort, ok := plugin.AsHookAbort(err); ok {
+				return nil, abort.KratosError()
+			}
 			return nil, errors.InternalServer("LOGOUT_ERROR", err.Error())
 		}
 	}
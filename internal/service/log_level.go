@@ -0,0 +1,74 @@
+package service
+
+import (
+	"context"
+	stderrors "errors"
+	"strings"
+
+	v1 "kratos-boilerplate/api/loglevel/v1"
+	pkglog "kratos-boilerplate/internal/pkg/log"
+
+	"github.com/go-kratos/kratos/v2/errors"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// LogLevelService 日志级别管理服务，排查线上问题时临时调高模块的日志级别，无需重新部署
+type LogLevelService struct {
+	v1.UnimplementedLogLevelAdminServer
+
+	levels *pkglog.LevelRegistry
+}
+
+// NewLogLevelService 创建日志级别管理服务
+func NewLogLevelService(levels *pkglog.LevelRegistry) *LogLevelService {
+	return &LogLevelService{levels: levels}
+}
+
+// 列出各模块当前生效的日志级别
+func (s *LogLevelService) ListLogLevels(ctx context.Context, req *v1.ListLogLevelsRequest) (*v1.ListLogLevelsReply, error) {
+	reply := &v1.ListLogLevelsReply{}
+	for _, m := range s.levels.List() {
+		reply.Levels = append(reply.Levels, toModuleLevel(m))
+	}
+	return reply, nil
+}
+
+// 设置模块的日志级别
+func (s *LogLevelService) SetLogLevel(ctx context.Context, req *v1.SetLogLevelRequest) (*v1.ModuleLevel, error) {
+	if req.Ttl != nil && req.Ttl.AsDuration() < 0 {
+		return nil, errors.BadRequest("LOG_LEVEL_INVALID", "ttl must not be negative")
+	}
+	m, err := s.levels.Set(req.Module, req.Level, req.Ttl.AsDuration())
+	if err != nil {
+		return nil, logLevelError(err)
+	}
+	return toModuleLevel(m), nil
+}
+
+// 撤销经由管理接口设置的级别，返回恢复后的级别
+func (s *LogLevelService) ResetLogLevel(ctx context.Context, req *v1.ResetLogLevelRequest) (*v1.ModuleLevel, error) {
+	s.levels.Reset(req.Module)
+	return toModuleLevel(s.levels.Get(req.Module)), nil
+}
+
+// toModuleLevel 转换模块级别，级别使用小写名称
+func toModuleLevel(m *pkglog.ModuleLevel) *v1.ModuleLevel {
+	level := &v1.ModuleLevel{
+		Module: m.Module,
+		Level:  strings.ToLower(m.Level.String()),
+		Source: m.Source,
+		From:   m.From,
+	}
+	if !m.ExpiresAt.IsZero() {
+		level.ExpiresAt = timestamppb.New(m.ExpiresAt)
+	}
+	return level
+}
+
+// logLevelError 将日志级别错误转换为 API 错误
+func logLevelError(err error) error {
+	if stderrors.Is(err, pkglog.ErrInvalidModule) || stderrors.Is(err, pkglog.ErrInvalidLevel) {
+		return errors.BadRequest("LOG_LEVEL_INVALID", err.Error())
+	}
+	return errors.InternalServer("LOG_LEVEL_INTERNAL", err.Error())
+}
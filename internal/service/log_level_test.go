@@ -0,0 +1,52 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	v1 "kratos-boilerplate/api/loglevel/v1"
+	pkglog "kratos-boilerplate/internal/pkg/log"
+
+	kerrors "github.com/go-kratos/kratos/v2/errors"
+	kratoslog "github.com/go-kratos/kratos/v2/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+func TestLogLevelService(t *testing.T) {
+	levels := pkglog.NewLevelRegistry(kratoslog.LevelInfo)
+	defer levels.Close()
+	require.NoError(t, levels.ApplyConfig(map[string]string{"biz": "warn"}))
+	svc := NewLogLevelService(levels)
+	ctx := context.Background()
+
+	level, err := svc.SetLogLevel(ctx, &v1.SetLogLevelRequest{Module: "biz.auth", Level: "debug", Ttl: durationpb.New(time.Hour)})
+	require.NoError(t, err)
+	assert.Equal(t, "debug", level.Level)
+	assert.Equal(t, "admin", level.Source)
+	assert.NotNil(t, level.ExpiresAt)
+
+	reply, err := svc.ListLogLevels(ctx, &v1.ListLogLevelsRequest{})
+	require.NoError(t, err)
+	require.Len(t, reply.Levels, 3)
+	assert.Equal(t, "root", reply.Levels[0].Module)
+	assert.Equal(t, "info", reply.Levels[0].Level)
+	assert.Equal(t, "biz", reply.Levels[1].Module)
+	assert.Equal(t, "warn", reply.Levels[1].Level)
+	assert.Nil(t, reply.Levels[1].ExpiresAt)
+
+	level, err = svc.ResetLogLevel(ctx, &v1.ResetLogLevelRequest{Module: "biz.auth"})
+	require.NoError(t, err)
+	assert.Equal(t, "warn", level.Level)
+	assert.Equal(t, "config", level.Source)
+	assert.Equal(t, "biz", level.From)
+
+	_, err = svc.SetLogLevel(ctx, &v1.SetLogLevelRequest{Module: "biz.auth", Level: "verbose"})
+	assert.True(t, kerrors.IsBadRequest(err))
+	_, err = svc.SetLogLevel(ctx, &v1.SetLogLevelRequest{Module: "biz auth", Level: "debug"})
+	assert.True(t, kerrors.IsBadRequest(err))
+	_, err = svc.SetLogLevel(ctx, &v1.SetLogLevelRequest{Module: "biz.auth", Level: "debug", Ttl: durationpb.New(-time.Second)})
+	assert.True(t, kerrors.IsBadRequest(err))
+}
@@ -0,0 +1,77 @@
+package service
+
+import (
+	"context"
+	stderrors "errors"
+	"time"
+
+	v1 "kratos-boilerplate/api/operationlog/v1"
+	"kratos-boilerplate/internal/biz"
+
+	"github.com/go-kratos/kratos/v2/errors"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// OperationLogService 操作日志管理服务，供管理员审计用户操作
+type OperationLogService struct {
+	v1.UnimplementedOperationLogAdminServer
+
+	uc *biz.OperationLogUsecase
+}
+
+// NewOperationLogService 创建操作日志管理服务
+func NewOperationLogService(uc *biz.OperationLogUsecase) *OperationLogService {
+	return &OperationLogService{uc: uc}
+}
+
+// 分页查询操作日志
+func (s *OperationLogService) ListOperationLogs(ctx context.Context, req *v1.ListOperationLogsRequest) (*v1.ListOperationLogsReply, error) {
+	query := &biz.OperationLogQuery{
+		UserID:    req.UserId,
+		Username:  req.Username,
+		Operation: req.Operation,
+		Target:    req.Target,
+		StartTime: timeOf(req.StartTime),
+		EndTime:   timeOf(req.EndTime),
+		Page:      int(req.Page),
+		PageSize:  int(req.PageSize),
+	}
+	logs, total, err := s.uc.ListLogs(ctx, query)
+	if err != nil {
+		return nil, operationLogError(err)
+	}
+	reply := &v1.ListOperationLogsReply{
+		Total:    total,
+		Page:     int32(query.Page),
+		PageSize: int32(query.PageSize),
+	}
+	for _, l := range logs {
+		reply.Logs = append(reply.Logs, &v1.OperationLog{
+			Id:        l.ID,
+			UserId:    l.UserID,
+			Username:  l.Username,
+			Operation: l.Operation,
+			Target:    l.Target,
+			Content:   l.Content,
+			Result:    l.Result,
+			CreatedAt: timestamppb.New(l.CreatedAt),
+		})
+	}
+	return reply, nil
+}
+
+// timeOf 转换可选的时间条件，未设置时为零值
+func timeOf(ts *timestamppb.Timestamp) time.Time {
+	if ts == nil {
+		return time.Time{}
+	}
+	return ts.AsTime()
+}
+
+// operationLogError 将操作日志错误转换为 API 错误
+func operationLogError(err error) error {
+	if stderrors.Is(err, biz.ErrOperationLogQueryInvalid) {
+		return errors.BadRequest("OPERATION_LOG_QUERY_INVALID", err.Error())
+	}
+	return errors.InternalServer("OPERATION_LOG_INTERNAL", err.Error())
+}
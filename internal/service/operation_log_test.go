@@ -0,0 +1,54 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	v1 "kratos-boilerplate/api/operationlog/v1"
+	"kratos-boilerplate/internal/biz"
+
+	kerrors "github.com/go-kratos/kratos/v2/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// fakeOperationLogRepo 只实现操作日志管理服务用到的方法
+type fakeOperationLogRepo struct {
+	biz.OperationLogRepo
+
+	query *biz.OperationLogQuery
+}
+
+func (r *fakeOperationLogRepo) QueryLogs(ctx context.Context, query *biz.OperationLogQuery) ([]*biz.OperationLog, int64, error) {
+	r.query = query
+	return []*biz.OperationLog{{ID: 9, UserID: 7, Username: "alice", Operation: "GET /api/v1/x", Result: "success", CreatedAt: query.StartTime}}, 41, nil
+}
+
+func TestOperationLogService_List(t *testing.T) {
+	repo := &fakeOperationLogRepo{}
+	svc := NewOperationLogService(biz.NewOperationLogUsecase(repo))
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	reply, err := svc.ListOperationLogs(context.Background(), &v1.ListOperationLogsRequest{
+		UserId:    7,
+		Operation: "GET ",
+		StartTime: timestamppb.New(start),
+		Page:      3,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, int64(41), reply.Total)
+	assert.Equal(t, int32(3), reply.Page)
+	assert.Equal(t, int32(20), reply.PageSize)
+	require.Len(t, reply.Logs, 1)
+	assert.Equal(t, "alice", reply.Logs[0].Username)
+	assert.Equal(t, start, reply.Logs[0].CreatedAt.AsTime())
+
+	assert.Equal(t, int64(7), repo.query.UserID)
+	assert.Equal(t, start, repo.query.StartTime)
+	assert.True(t, repo.query.EndTime.IsZero())
+
+	_, err = svc.ListOperationLogs(context.Background(), &v1.ListOperationLogsRequest{PageSize: 1000})
+	assert.True(t, kerrors.IsBadRequest(err))
+}
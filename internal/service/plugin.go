@@ -0,0 +1,359 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	stderrors "errors"
+	"fmt"
+	"sort"
+	"sync/atomic"
+	"time"
+
+	v1 "kratos-boilerplate/api/plugin/v1"
+	"kratos-boilerplate/internal/pkg/plugin"
+
+	"github.com/go-kratos/kratos/v2/errors"
+	"github.com/go-kratos/kratos/v2/log"
+	"google.golang.org/protobuf/types/known/durationpb"
+	"google.golang.org/protobuf/types/known/structpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// pluginEventBuffer 每个事件订阅流的缓冲区大小，客户端消费过慢时丢弃事件而不阻塞事件总线
+const pluginEventBuffer = 64
+
+// pluginLifecycleEvents 推送给订阅者的插件生命周期事件
+var pluginLifecycleEvents = []plugin.EventType{
+	plugin.EventPluginLoaded,
+	plugin.EventPluginUnloaded,
+	plugin.EventPluginStarted,
+	plugin.EventPluginStopped,
+	plugin.EventPluginError,
+	plugin.EventConfigChanged,
+}
+
+// PluginService 插件管理服务，供运维人员管理运行中的插件
+type PluginService struct {
+	v1.UnimplementedPluginAdminServer
+
+	pm       plugin.PluginManager
+	events   plugin.EventBus
+	watchers atomic.Int64
+	log      *log.Helper
+}
+
+// NewPluginService 创建插件管理服务
+func NewPluginService(pm plugin.PluginManager, events plugin.EventBus, logger log.Logger) *PluginService {
+	return &PluginService{
+		pm:     pm,
+		events: events,
+		log:    log.NewHelper(log.With(logger, "module", "service.plugin")),
+	}
+}
+
+// 列出插件
+func (s *PluginService) ListPlugins(ctx context.Context, req *v1.ListPluginsRequest) (*v1.ListPluginsReply, error) {
+	infos := s.pm.ListPlugins()
+	sort.Slice(infos, func(i, j int) bool {
+		return infos[i].Metadata.Name < infos[j].Metadata.Name
+	})
+
+	reply := &v1.ListPluginsReply{}
+	for _, info := range infos {
+		if req.Status != "" && string(info.Status) != req.Status {
+			continue
+		}
+		reply.Plugins = append(reply.Plugins, toPluginInfo(info))
+	}
+	return reply, nil
+}
+
+// 启动插件
+func (s *PluginService) StartPlugin(ctx context.Context, req *v1.PluginRequest) (*v1.PluginReply, error) {
+	if err := s.pm.StartPlugin(req.Name); err != nil {
+		return nil, pluginError(err)
+	}
+	s.log.WithContext(ctx).Infof("plugin %s started by operator", req.Name)
+	return s.pluginReply(req.Name)
+}
+
+// 停止插件
+func (s *PluginService) StopPlugin(ctx context.Context, req *v1.PluginRequest) (*v1.PluginReply, error) {
+	if err := s.pm.StopPlugin(req.Name); err != nil {
+		return nil, pluginError(err)
+	}
+	s.log.WithContext(ctx).Infof("plugin %s stopped by operator", req.Name)
+	return s.pluginReply(req.Name)
+}
+
+// 重新加载插件
+func (s *PluginService) ReloadPlugin(ctx context.Context, req *v1.PluginRequest) (*v1.PluginReply, error) {
+	if err := s.pm.ReloadPlugin(req.Name); err != nil {
+		return nil, pluginError(err)
+	}
+	s.log.WithContext(ctx).Infof("plugin %s reloaded by operator", req.Name)
+	return s.pluginReply(req.Name)
+}
+
+// 获取插件配置
+func (s *PluginService) GetPluginSettings(ctx context.Context, req *v1.PluginRequest) (*v1.PluginSettings, error) {
+	config, err := s.pm.GetPluginConfig(req.Name)
+	if err != nil {
+		return nil, pluginError(err)
+	}
+	return toPluginSettings(req.Name, config)
+}
+
+// 更新插件配置
+func (s *PluginService) UpdatePluginSettings(ctx context.Context, req *v1.UpdatePluginSettingsRequest) (*v1.PluginSettings, error) {
+	if req.Settings == nil {
+		return nil, errors.BadRequest("PLUGIN_SETTINGS_REQUIRED", "插件配置不能为空")
+	}
+
+	config := plugin.PluginConfig{
+		Enabled:    req.Settings.Enabled,
+		Priority:   int(req.Settings.Priority),
+		Settings:   req.Settings.Settings.AsMap(),
+		Timeout:    req.Settings.Timeout.AsDuration(),
+		RetryCount: int(req.Settings.RetryCount),
+		Metadata:   req.Settings.Metadata,
+	}
+	if config.Metadata == nil {
+		config.Metadata = make(map[string]string)
+	}
+
+	if err := s.pm.UpdatePluginConfig(req.Name, config); err != nil {
+		return nil, pluginError(err)
+	}
+	s.log.WithContext(ctx).Infof("plugin %s settings updated by operator", req.Name)
+	return toPluginSettings(req.Name, config)
+}
+
+// 执行插件健康检查
+func (s *PluginService) CheckPluginHealth(ctx context.Context, req *v1.PluginRequest) (*v1.PluginHealthReply, error) {
+	p, err := s.pm.GetPlugin(req.Name)
+	if err != nil {
+		return nil, pluginError(err)
+	}
+	status, err := s.pm.GetPluginStatus(req.Name)
+	if err != nil {
+		return nil, pluginError(err)
+	}
+
+	start := time.Now()
+	reply := &v1.PluginHealthReply{
+		Name:    req.Name,
+		Healthy: true,
+		Status:  string(status),
+	}
+	if err := p.HealthCheck(ctx); err != nil {
+		reply.Healthy = false
+		reply.Message = err.Error()
+	}
+	reply.Duration = durationpb.New(time.Since(start))
+	return reply, nil
+}
+
+// 订阅插件生命周期事件
+func (s *PluginService) WatchPluginEvents(req *v1.WatchPluginEventsRequest, stream v1.PluginAdmin_WatchPluginEventsServer) error {
+	ctx := stream.Context()
+	ch := make(chan plugin.Event, pluginEventBuffer)
+
+	handlerName := fmt.Sprintf("plugin_admin_watch_%d", s.watchers.Add(1))
+	handler := plugin.NewBaseEventHandler(handlerName, pluginLifecycleEvents, time.Second,
+		func(ctx context.Context, event plugin.Event) error {
+			if req.Name != "" && event.GetData()["plugin"] != req.Name {
+				return nil
+			}
+			select {
+			case ch <- event:
+			default:
+				s.log.Warnf("plugin event stream %s is full, dropping event %s", handlerName, event.GetID())
+			}
+			return nil
+		})
+
+	for _, eventType := range pluginLifecycleEvents {
+		if err := s.events.Subscribe(eventType, handler); err != nil {
+			return errors.InternalServer("PLUGIN_EVENT_SUBSCRIBE_FAILED", err.Error())
+		}
+		defer s.events.Unsubscribe(eventType, handlerName)
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case event := <-ch:
+			msg, err := toPluginEvent(event)
+			if err != nil {
+				s.log.WithContext(ctx).Warnf("failed to encode plugin event %s: %v", event.GetID(), err)
+				continue
+			}
+			if err := stream.Send(msg); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// 列出死信
+func (s *PluginService) ListDeadLetters(ctx context.Context, req *v1.ListDeadLettersRequest) (*v1.ListDeadLettersReply, error) {
+	records, err := s.events.ListDeadLetters(ctx, plugin.DeadLetterQuery{
+		EventType:    plugin.EventType(req.EventType),
+		Subscription: req.Subscription,
+		Limit:        int(req.Limit),
+	})
+	if err != nil {
+		return nil, errors.InternalServer(plugin.ErrCodePluginInternal, err.Error())
+	}
+
+	reply := &v1.ListDeadLettersReply{}
+	for _, r := range records {
+		msg, err := toDeadLetter(r)
+		if err != nil {
+			return nil, errors.InternalServer(plugin.ErrCodePluginInternal, err.Error())
+		}
+		reply.DeadLetters = append(reply.DeadLetters, msg)
+	}
+	return reply, nil
+}
+
+// 重新投递死信
+func (s *PluginService) ReplayDeadLetter(ctx context.Context, req *v1.ReplayDeadLetterRequest) (*v1.ReplayDeadLetterReply, error) {
+	if err := s.events.ReplayDeadLetter(ctx, req.Id); err != nil {
+		return nil, pluginError(err)
+	}
+	s.log.WithContext(ctx).Infof("dead letter %s replayed by operator", req.Id)
+	return &v1.ReplayDeadLetterReply{Id: req.Id}, nil
+}
+
+// pluginReply 返回插件的最新信息
+func (s *PluginService) pluginReply(name string) (*v1.PluginReply, error) {
+	for _, info := range s.pm.ListPlugins() {
+		if info.Metadata.Name == name {
+			return &v1.PluginReply{Plugin: toPluginInfo(info)}, nil
+		}
+	}
+	return nil, errors.NotFound(plugin.ErrCodePluginNotFound, "插件不存在")
+}
+
+// pluginError 将插件错误转换为 API 错误
+func pluginError(err error) error {
+	var pe *plugin.PluginError
+	if !stderrors.As(err, &pe) {
+		return errors.InternalServer(plugin.ErrCodePluginInternal, err.Error())
+	}
+
+	switch pe.Code {
+	case plugin.ErrCodePluginNotFound:
+		return errors.NotFound(pe.Code, err.Error())
+	case plugin.ErrCodePluginAlreadyExist, plugin.ErrCodePluginStopFailed, plugin.ErrCodePluginDependency:
+		return errors.Conflict(pe.Code, err.Error())
+	case plugin.ErrCodePluginConfigError:
+		kerr := errors.BadRequest(pe.Code, err.Error())
+		// 配置校验失败时按字段返回错误信息
+		var se *plugin.SettingsError
+		if stderrors.As(err, &se) {
+			fields := make(map[string]string, len(se.Fields))
+			for _, f := range se.Fields {
+				fields[f.Field] = f.Message
+			}
+			kerr = kerr.WithMetadata(fields)
+		}
+		return kerr
+	case plugin.ErrCodePluginPermission:
+		return errors.Forbidden(pe.Code, err.Error())
+	default:
+		return errors.InternalServer(pe.Code, err.Error())
+	}
+}
+
+func toPluginInfo(info plugin.PluginInfo) *v1.PluginInfo {
+	out := &v1.PluginInfo{
+		Name:         info.Metadata.Name,
+		Version:      info.Metadata.Version,
+		Description:  info.Metadata.Description,
+		Dependencies: info.Metadata.Dependencies,
+		Status:       string(info.Status),
+		Path:         info.Path,
+		ConfigPath:   info.ConfigPath,
+		LoadTime:     timestamppb.New(info.LoadTime),
+		ErrorMsg:     info.ErrorMsg,
+		Publisher:    info.Metadata.Publisher,
+		Permissions:  info.Metadata.Permissions,
+	}
+	if info.StartTime != nil {
+		out.StartTime = timestamppb.New(*info.StartTime)
+	}
+	if info.StopTime != nil {
+		out.StopTime = timestamppb.New(*info.StopTime)
+	}
+	return out
+}
+
+func toPluginSettings(name string, config plugin.PluginConfig) (*v1.PluginSettings, error) {
+	settings, err := toStruct(config.Settings)
+	if err != nil {
+		return nil, errors.InternalServer(plugin.ErrCodePluginConfigError, err.Error())
+	}
+	return &v1.PluginSettings{
+		Name:       name,
+		Enabled:    config.Enabled,
+		Priority:   int32(config.Priority),
+		Settings:   settings,
+		Timeout:    durationpb.New(config.Timeout),
+		RetryCount: int32(config.RetryCount),
+		Metadata:   config.Metadata,
+	}, nil
+}
+
+func toPluginEvent(event plugin.Event) (*v1.PluginEvent, error) {
+	data, err := toStruct(event.GetData())
+	if err != nil {
+		return nil, err
+	}
+	name, _ := event.GetData()["plugin"].(string)
+	return &v1.PluginEvent{
+		Id:        event.GetID(),
+		Type:      string(event.GetType()),
+		Plugin:    name,
+		Data:      data,
+		Timestamp: timestamppb.New(event.GetTimestamp()),
+	}, nil
+}
+
+func toDeadLetter(r *plugin.DeliveryRecord) (*v1.DeadLetter, error) {
+	data, err := toStruct(r.Data)
+	if err != nil {
+		return nil, err
+	}
+	return &v1.DeadLetter{
+		Id:           r.ID,
+		EventId:      r.EventID,
+		EventType:    string(r.EventType),
+		Source:       r.Source,
+		Subscription: r.Subscription,
+		Data:         data,
+		Attempts:     int32(r.Attempts),
+		LastError:    r.LastError,
+		Timestamp:    timestamppb.New(r.Timestamp),
+		UpdatedAt:    timestamppb.New(r.UpdatedAt),
+	}, nil
+}
+
+// toStruct 通过 JSON 将任意结构转换为 protobuf Struct
+func toStruct(v interface{}) (*structpb.Struct, error) {
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	s := &structpb.Struct{}
+	if string(raw) == "null" {
+		return s, nil
+	}
+	if err := s.UnmarshalJSON(raw); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
@@ -0,0 +1,152 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	v1 "kratos-boilerplate/api/plugin/v1"
+	"kratos-boilerplate/internal/pkg/plugin"
+
+	kerrors "github.com/go-kratos/kratos/v2/errors"
+	"github.com/go-kratos/kratos/v2/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakePluginManager 只实现插件管理服务用到的方法
+type fakePluginManager struct {
+	plugin.PluginManager
+
+	infos   []plugin.PluginInfo
+	configs map[string]plugin.PluginConfig
+}
+
+func (m *fakePluginManager) ListPlugins() []plugin.PluginInfo {
+	return m.infos
+}
+
+func (m *fakePluginManager) StartPlugin(name string) error {
+	for i := range m.infos {
+		if m.infos[i].Metadata.Name == name {
+			if m.infos[i].Status == plugin.PluginStatusStarted {
+				return plugin.NewPluginError(plugin.ErrCodePluginAlreadyExist, "plugin already started", name, nil)
+			}
+			m.infos[i].Status = plugin.PluginStatusStarted
+			return nil
+		}
+	}
+	return plugin.NewPluginError(plugin.ErrCodePluginNotFound, "plugin not found", name, nil)
+}
+
+func (m *fakePluginManager) GetPluginConfig(name string) (plugin.PluginConfig, error) {
+	config, ok := m.configs[name]
+	if !ok {
+		return plugin.PluginConfig{}, plugin.NewPluginError(plugin.ErrCodePluginNotFound, "plugin not found", name, nil)
+	}
+	return config, nil
+}
+
+func (m *fakePluginManager) UpdatePluginConfig(name string, config plugin.PluginConfig) error {
+	if _, ok := m.configs[name]; !ok {
+		return plugin.NewPluginError(plugin.ErrCodePluginNotFound, "plugin not found", name, nil)
+	}
+	m.configs[name] = config
+	return nil
+}
+
+func newTestPluginService() (*PluginService, *fakePluginManager) {
+	pm := &fakePluginManager{
+		infos: []plugin.PluginInfo{
+			{Metadata: plugin.PluginMetadata{Name: "b_plugin"}, Status: plugin.PluginStatusStarted, LoadTime: time.Now()},
+			{Metadata: plugin.PluginMetadata{Name: "a_plugin"}, Status: plugin.PluginStatusLoaded, LoadTime: time.Now()},
+		},
+		configs: map[string]plugin.PluginConfig{
+			"a_plugin": {Enabled: true, Priority: 10, Settings: map[string]interface{}{"limit": float64(5)}, Timeout: time.Second},
+		},
+	}
+	return NewPluginService(pm, plugin.NewEventBus(1), log.DefaultLogger), pm
+}
+
+func TestPluginService_ListAndStart(t *testing.T) {
+	svc, _ := newTestPluginService()
+
+	reply, err := svc.ListPlugins(context.Background(), &v1.ListPluginsRequest{})
+	require.NoError(t, err)
+	require.Len(t, reply.Plugins, 2)
+	assert.Equal(t, "a_plugin", reply.Plugins[0].Name)
+
+	reply, err = svc.ListPlugins(context.Background(), &v1.ListPluginsRequest{Status: "started"})
+	require.NoError(t, err)
+	require.Len(t, reply.Plugins, 1)
+	assert.Equal(t, "b_plugin", reply.Plugins[0].Name)
+
+	started, err := svc.StartPlugin(context.Background(), &v1.PluginRequest{Name: "a_plugin"})
+	require.NoError(t, err)
+	assert.Equal(t, "started", started.Plugin.Status)
+
+	_, err = svc.StartPlugin(context.Background(), &v1.PluginRequest{Name: "a_plugin"})
+	assert.Equal(t, int32(409), kerrors.FromError(err).Code)
+
+	_, err = svc.StartPlugin(context.Background(), &v1.PluginRequest{Name: "missing"})
+	kerr := kerrors.FromError(err)
+	assert.Equal(t, int32(404), kerr.Code)
+	assert.Equal(t, plugin.ErrCodePluginNotFound, kerr.Reason)
+}
+
+func TestPluginService_Settings(t *testing.T) {
+	svc, pm := newTestPluginService()
+
+	settings, err := svc.GetPluginSettings(context.Background(), &v1.PluginRequest{Name: "a_plugin"})
+	require.NoError(t, err)
+	assert.Equal(t, int32(10), settings.Priority)
+	assert.Equal(t, float64(5), settings.Settings.AsMap()["limit"])
+
+	settings.RetryCount = 2
+	_, err = svc.UpdatePluginSettings(context.Background(), &v1.UpdatePluginSettingsRequest{Name: "a_plugin", Settings: settings})
+	require.NoError(t, err)
+	assert.Equal(t, 2, pm.configs["a_plugin"].RetryCount)
+	assert.Equal(t, time.Second, pm.configs["a_plugin"].Timeout)
+
+	_, err = svc.UpdatePluginSettings(context.Background(), &v1.UpdatePluginSettingsRequest{Name: "a_plugin"})
+	assert.Equal(t, int32(400), kerrors.FromError(err).Code)
+}
+
+func TestPluginService_DeadLetters(t *testing.T) {
+	svc, _ := newTestPluginService()
+	handler := plugin.NewBaseEventHandler("audit", []plugin.EventType{plugin.EventUserLogin}, time.Second,
+		func(ctx context.Context, event plugin.Event) error {
+			return errors.New("audit store unavailable")
+		})
+	require.NoError(t, svc.events.SubscribeWithOptions(plugin.EventUserLogin, handler, plugin.DeliveryOptions{
+		MaxRetries: 1,
+		Backoff:    time.Millisecond,
+	}))
+	_ = svc.events.Publish(context.Background(), plugin.NewEvent(plugin.EventUserLogin, "auth", map[string]interface{}{"user": "alice"}))
+
+	reply, err := svc.ListDeadLetters(context.Background(), &v1.ListDeadLettersRequest{Subscription: "audit"})
+	require.NoError(t, err)
+	require.Len(t, reply.DeadLetters, 1)
+	assert.Equal(t, int32(2), reply.DeadLetters[0].Attempts)
+	assert.Equal(t, "audit store unavailable", reply.DeadLetters[0].LastError)
+	assert.Equal(t, "alice", reply.DeadLetters[0].Data.AsMap()["user"])
+
+	replayed, err := svc.ReplayDeadLetter(context.Background(), &v1.ReplayDeadLetterRequest{Id: reply.DeadLetters[0].Id})
+	require.NoError(t, err)
+	assert.Equal(t, reply.DeadLetters[0].Id, replayed.Id)
+
+	_, err = svc.ReplayDeadLetter(context.Background(), &v1.ReplayDeadLetterRequest{Id: "missing"})
+	assert.Equal(t, int32(404), kerrors.FromError(err).Code)
+}
+
+func TestPluginError(t *testing.T) {
+	assert.Equal(t, int32(400), kerrors.FromError(pluginError(
+		plugin.NewPluginError(plugin.ErrCodePluginConfigError, "bad config", "a", nil))).Code)
+	assert.Equal(t, int32(500), kerrors.FromError(pluginError(errors.New("boom"))).Code)
+
+	kerr := kerrors.FromError(pluginError(plugin.NewPluginError(plugin.ErrCodePluginConfigError, "invalid plugin settings", "a",
+		&plugin.SettingsError{Fields: []plugin.FieldError{{Field: "limit", Message: "must be >= 1"}}})))
+	assert.Equal(t, int32(400), kerr.Code)
+	assert.Equal(t, map[string]string{"limit": "must be >= 1"}, kerr.Metadata)
+}
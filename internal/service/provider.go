@@ -8,4 +8,8 @@ import (
 var ProviderSet = wire.NewSet(
 	NewGreeterService,
 	NewAuthService,
+	NewPluginService,
+	NewWebhookService,
+	NewOperationLogService,
+	NewLogLevelService,
 )
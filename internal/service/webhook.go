@@ -0,0 +1,160 @@
+package service
+
+import (
+	"context"
+	stderrors "errors"
+
+	v1 "kratos-boilerplate/api/webhook/v1"
+	"kratos-boilerplate/internal/biz"
+
+	"github.com/go-kratos/kratos/v2/errors"
+	"github.com/go-kratos/kratos/v2/log"
+	"google.golang.org/protobuf/types/known/durationpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// WebhookService Webhook 管理服务，供运维人员为合作方注册事件回调
+type WebhookService struct {
+	v1.UnimplementedWebhookAdminServer
+
+	uc  *biz.WebhookUsecase
+	log *log.Helper
+}
+
+// NewWebhookService 创建 Webhook 管理服务
+func NewWebhookService(uc *biz.WebhookUsecase, logger log.Logger) *WebhookService {
+	return &WebhookService{
+		uc:  uc,
+		log: log.NewHelper(log.With(logger, "module", "service.webhook")),
+	}
+}
+
+// 注册端点，仅在此处返回签名密钥
+func (s *WebhookService) CreateWebhook(ctx context.Context, req *v1.CreateWebhookRequest) (*v1.Webhook, error) {
+	ep, err := s.uc.CreateEndpoint(ctx, &biz.WebhookEndpoint{
+		URL:         req.Url,
+		EventTypes:  req.EventTypes,
+		Description: req.Description,
+		Secret:      req.Secret,
+		MaxRetries:  int(req.MaxRetries),
+		Enabled:     !req.Disabled,
+	})
+	if err != nil {
+		return nil, webhookError(err)
+	}
+	reply := toWebhook(ep)
+	reply.Secret = ep.Secret
+	return reply, nil
+}
+
+// 列出端点
+func (s *WebhookService) ListWebhooks(ctx context.Context, req *v1.ListWebhooksRequest) (*v1.ListWebhooksReply, error) {
+	endpoints, err := s.uc.ListEndpoints(ctx)
+	if err != nil {
+		return nil, webhookError(err)
+	}
+	reply := &v1.ListWebhooksReply{}
+	for _, ep := range endpoints {
+		reply.Webhooks = append(reply.Webhooks, toWebhook(ep))
+	}
+	return reply, nil
+}
+
+// 获取端点
+func (s *WebhookService) GetWebhook(ctx context.Context, req *v1.GetWebhookRequest) (*v1.Webhook, error) {
+	ep, err := s.uc.GetEndpoint(ctx, req.Id)
+	if err != nil {
+		return nil, webhookError(err)
+	}
+	return toWebhook(ep), nil
+}
+
+// 更新端点
+func (s *WebhookService) UpdateWebhook(ctx context.Context, req *v1.UpdateWebhookRequest) (*v1.Webhook, error) {
+	ep, err := s.uc.UpdateEndpoint(ctx, &biz.WebhookEndpoint{
+		ID:          req.Id,
+		URL:         req.Url,
+		EventTypes:  req.EventTypes,
+		Description: req.Description,
+		Secret:      req.Secret,
+		MaxRetries:  int(req.MaxRetries),
+		Enabled:     req.Enabled,
+	})
+	if err != nil {
+		return nil, webhookError(err)
+	}
+	s.log.WithContext(ctx).Infof("webhook %d updated by operator", req.Id)
+	return toWebhook(ep), nil
+}
+
+// 删除端点
+func (s *WebhookService) DeleteWebhook(ctx context.Context, req *v1.DeleteWebhookRequest) (*v1.DeleteWebhookReply, error) {
+	if err := s.uc.DeleteEndpoint(ctx, req.Id); err != nil {
+		return nil, webhookError(err)
+	}
+	s.log.WithContext(ctx).Infof("webhook %d deleted by operator", req.Id)
+	return &v1.DeleteWebhookReply{}, nil
+}
+
+// 重新启用端点
+func (s *WebhookService) EnableWebhook(ctx context.Context, req *v1.EnableWebhookRequest) (*v1.Webhook, error) {
+	ep, err := s.uc.EnableEndpoint(ctx, req.Id)
+	if err != nil {
+		return nil, webhookError(err)
+	}
+	s.log.WithContext(ctx).Infof("webhook %d enabled by operator", req.Id)
+	return toWebhook(ep), nil
+}
+
+// 查询投递记录
+func (s *WebhookService) ListWebhookDeliveries(ctx context.Context, req *v1.ListWebhookDeliveriesRequest) (*v1.ListWebhookDeliveriesReply, error) {
+	deliveries, err := s.uc.ListDeliveries(ctx, req.Id, int(req.Limit))
+	if err != nil {
+		return nil, webhookError(err)
+	}
+	reply := &v1.ListWebhookDeliveriesReply{}
+	for _, d := range deliveries {
+		reply.Deliveries = append(reply.Deliveries, &v1.WebhookDelivery{
+			Id:             d.ID,
+			EventId:        d.EventID,
+			EventType:      d.EventType,
+			IdempotencyKey: d.IdempotencyKey,
+			Attempt:        int32(d.Attempt),
+			StatusCode:     int32(d.StatusCode),
+			Success:        d.Success,
+			Error:          d.Error,
+			Response:       d.Response,
+			Duration:       durationpb.New(d.Duration),
+			CreatedAt:      timestamppb.New(d.CreatedAt),
+		})
+	}
+	return reply, nil
+}
+
+// toWebhook 转换端点，不包含签名密钥
+func toWebhook(ep *biz.WebhookEndpoint) *v1.Webhook {
+	return &v1.Webhook{
+		Id:             ep.ID,
+		Url:            ep.URL,
+		EventTypes:     ep.EventTypes,
+		Description:    ep.Description,
+		Enabled:        ep.Enabled,
+		MaxRetries:     int32(ep.MaxRetries),
+		FailureCount:   int32(ep.FailureCount),
+		DisabledReason: ep.DisabledReason,
+		CreatedAt:      timestamppb.New(ep.CreatedAt),
+		UpdatedAt:      timestamppb.New(ep.UpdatedAt),
+	}
+}
+
+// webhookError 将 Webhook 错误转换为 API 错误
+func webhookError(err error) error {
+	switch {
+	case stderrors.Is(err, biz.ErrWebhookNotFound):
+		return errors.NotFound("WEBHOOK_NOT_FOUND", err.Error())
+	case stderrors.Is(err, biz.ErrWebhookInvalid):
+		return errors.BadRequest("WEBHOOK_INVALID", err.Error())
+	default:
+		return errors.InternalServer("WEBHOOK_INTERNAL", err.Error())
+	}
+}
@@ -0,0 +1,82 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"testing"
+
+	v1 "kratos-boilerplate/api/webhook/v1"
+	"kratos-boilerplate/internal/biz"
+	"kratos-boilerplate/internal/pkg/plugin"
+
+	kerrors "github.com/go-kratos/kratos/v2/errors"
+	"github.com/go-kratos/kratos/v2/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeWebhookRepo 只实现 Webhook 管理服务用到的方法
+type fakeWebhookRepo struct {
+	biz.WebhookRepo
+
+	endpoints map[int64]*biz.WebhookEndpoint
+}
+
+func (r *fakeWebhookRepo) CreateEndpoint(ctx context.Context, ep *biz.WebhookEndpoint) error {
+	ep.ID = int64(len(r.endpoints) + 1)
+	stored := *ep
+	r.endpoints[ep.ID] = &stored
+	return nil
+}
+
+func (r *fakeWebhookRepo) GetEndpoint(ctx context.Context, id int64) (*biz.WebhookEndpoint, error) {
+	ep, ok := r.endpoints[id]
+	if !ok {
+		return nil, biz.ErrWebhookNotFound
+	}
+	result := *ep
+	return &result, nil
+}
+
+func (r *fakeWebhookRepo) ListEndpoints(ctx context.Context) ([]*biz.WebhookEndpoint, error) {
+	var result []*biz.WebhookEndpoint
+	for _, ep := range r.endpoints {
+		result = append(result, ep)
+	}
+	return result, nil
+}
+
+func TestWebhookService_Create(t *testing.T) {
+	bus := plugin.NewEventBus(1)
+	defer bus.Close()
+	repo := &fakeWebhookRepo{endpoints: make(map[int64]*biz.WebhookEndpoint)}
+	svc := NewWebhookService(biz.NewWebhookUsecase(repo, bus, log.NewStdLogger(os.Stdout)), log.NewStdLogger(os.Stdout))
+
+	created, err := svc.CreateWebhook(context.Background(), &v1.CreateWebhookRequest{
+		Url:        "https://partner.example.com/hooks",
+		EventTypes: []string{"user.login", "user.logout"},
+		Disabled:   true,
+	})
+	require.NoError(t, err)
+	assert.NotEmpty(t, created.Secret)
+	assert.False(t, created.Enabled)
+
+	// 密钥只在注册时返回
+	got, err := svc.GetWebhook(context.Background(), &v1.GetWebhookRequest{Id: created.Id})
+	require.NoError(t, err)
+	assert.Empty(t, got.Secret)
+	assert.Equal(t, []string{"user.login", "user.logout"}, got.EventTypes)
+
+	_, err = svc.CreateWebhook(context.Background(), &v1.CreateWebhookRequest{Url: "not a url", EventTypes: []string{"user.login"}})
+	assert.Equal(t, int32(400), kerrors.FromError(err).Code)
+
+	_, err = svc.GetWebhook(context.Background(), &v1.GetWebhookRequest{Id: 42})
+	assert.Equal(t, int32(404), kerrors.FromError(err).Code)
+}
+
+func TestWebhookError(t *testing.T) {
+	assert.Equal(t, int32(404), kerrors.FromError(webhookError(fmt.Errorf("get: %w", biz.ErrWebhookNotFound))).Code)
+	assert.Equal(t, int32(500), kerrors.FromError(webhookError(errors.New("boom"))).Code)
+}
@@ -0,0 +1,36 @@
+-- 创建事件投递队列表，保存至少一次投递的待投递记录与死信
+CREATE TABLE IF NOT EXISTS event_deliveries (
+    id VARCHAR(255) PRIMARY KEY,
+    event_id VARCHAR(64) NOT NULL,
+    event_type VARCHAR(100) NOT NULL,
+    source VARCHAR(255) NOT NULL DEFAULT '',
+    event_time TIMESTAMP WITH TIME ZONE NOT NULL,
+    data JSONB,
+    metadata JSONB,
+    subscription VARCHAR(255) NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending',
+    attempts INTEGER NOT NULL DEFAULT 0,
+    last_error TEXT NOT NULL DEFAULT '',
+    next_attempt_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    locked_by VARCHAR(64),
+    locked_until TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+    CONSTRAINT event_deliveries_status_check CHECK (status IN ('pending', 'dead'))
+);
+
+-- 创建索引
+CREATE INDEX IF NOT EXISTS idx_event_deliveries_due ON event_deliveries(next_attempt_at) WHERE status = 'pending';
+CREATE INDEX IF NOT EXISTS idx_event_deliveries_dead ON event_deliveries(updated_at) WHERE status = 'dead';
+CREATE INDEX IF NOT EXISTS idx_event_deliveries_event_id ON event_deliveries(event_id);
+
+-- 添加注释
+COMMENT ON TABLE event_deliveries IS '事件投递队列表';
+COMMENT ON COLUMN event_deliveries.id IS '投递幂等键：事件ID:订阅名称';
+COMMENT ON COLUMN event_deliveries.subscription IS '订阅的事件处理器名称';
+COMMENT ON COLUMN event_deliveries.status IS '投递状态：pending-待投递, dead-死信';
+COMMENT ON COLUMN event_deliveries.attempts IS '已尝试投递次数';
+COMMENT ON COLUMN event_deliveries.next_attempt_at IS '下一次投递时间';
+COMMENT ON COLUMN event_deliveries.locked_by IS '领取记录的消费者';
+COMMENT ON COLUMN event_deliveries.locked_until IS '领取租约到期时间';
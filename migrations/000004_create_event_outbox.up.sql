@@ -0,0 +1,22 @@
+-- 创建事件发件箱表，与业务数据在同一事务中写入，提交后由转发器发布到事件总线
+CREATE TABLE IF NOT EXISTS event_outbox (
+    id BIGSERIAL PRIMARY KEY,
+    event_id VARCHAR(64) NOT NULL,
+    aggregate_type VARCHAR(100) NOT NULL,
+    aggregate_id VARCHAR(255) NOT NULL,
+    event_type VARCHAR(100) NOT NULL,
+    source VARCHAR(255) NOT NULL DEFAULT '',
+    event_time TIMESTAMP WITH TIME ZONE NOT NULL,
+    data JSONB,
+    metadata JSONB,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- 创建索引
+CREATE INDEX IF NOT EXISTS idx_event_outbox_aggregate ON event_outbox(aggregate_type, aggregate_id, id);
+
+-- 添加注释
+COMMENT ON TABLE event_outbox IS '事件发件箱表，发布后删除';
+COMMENT ON COLUMN event_outbox.id IS '写入顺序，转发器按此顺序发布';
+COMMENT ON COLUMN event_outbox.aggregate_type IS '事件所属业务对象类型，如 user';
+COMMENT ON COLUMN event_outbox.aggregate_id IS '事件所属业务对象ID，同一对象的事件按写入顺序发布';
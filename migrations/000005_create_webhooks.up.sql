@@ -0,0 +1,41 @@
+-- 创建 Webhook 端点表
+CREATE TABLE IF NOT EXISTS webhook_endpoints (
+    id BIGSERIAL PRIMARY KEY,
+    url VARCHAR(2048) NOT NULL,
+    secret VARCHAR(255) NOT NULL,
+    event_types TEXT[] NOT NULL,
+    description VARCHAR(500) NOT NULL DEFAULT '',
+    enabled BOOLEAN NOT NULL DEFAULT TRUE,
+    max_retries INTEGER NOT NULL DEFAULT 0,
+    failure_count INTEGER NOT NULL DEFAULT 0,
+    disabled_reason TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- 创建 Webhook 投递日志表
+CREATE TABLE IF NOT EXISTS webhook_deliveries (
+    id BIGSERIAL PRIMARY KEY,
+    endpoint_id BIGINT NOT NULL REFERENCES webhook_endpoints(id) ON DELETE CASCADE,
+    event_id VARCHAR(64) NOT NULL,
+    event_type VARCHAR(100) NOT NULL,
+    idempotency_key VARCHAR(255) NOT NULL,
+    attempt INTEGER NOT NULL,
+    status_code INTEGER NOT NULL DEFAULT 0,
+    success BOOLEAN NOT NULL,
+    error TEXT NOT NULL DEFAULT '',
+    response TEXT NOT NULL DEFAULT '',
+    duration_ms BIGINT NOT NULL DEFAULT 0,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- 创建索引
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_endpoint ON webhook_deliveries(endpoint_id, created_at DESC);
+
+-- 添加注释
+COMMENT ON TABLE webhook_endpoints IS 'Webhook 端点表';
+COMMENT ON COLUMN webhook_endpoints.secret IS 'HMAC-SHA256 签名密钥';
+COMMENT ON COLUMN webhook_endpoints.event_types IS '订阅的事件类型';
+COMMENT ON COLUMN webhook_endpoints.failure_count IS '连续投递失败次数，达到阈值后自动停用';
+COMMENT ON TABLE webhook_deliveries IS 'Webhook 投递日志表，每次尝试一条';
+COMMENT ON COLUMN webhook_deliveries.idempotency_key IS '同一事件对同一端点的重试保持不变，即 X-Webhook-Id';
@@ -0,0 +1,26 @@
+-- 创建操作日志表
+CREATE TABLE IF NOT EXISTS operation_logs (
+    id BIGSERIAL PRIMARY KEY,
+    user_id BIGINT NOT NULL DEFAULT 0,
+    username VARCHAR(255) NOT NULL DEFAULT '',
+    operation VARCHAR(255) NOT NULL,
+    target VARCHAR(2048) NOT NULL DEFAULT '',
+    content TEXT NOT NULL DEFAULT '',
+    result TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- 创建索引，查询均按时间倒序
+CREATE INDEX IF NOT EXISTS idx_operation_logs_created_at ON operation_logs(created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_operation_logs_user ON operation_logs(user_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_operation_logs_username ON operation_logs(username, created_at DESC);
+-- text_pattern_ops 支持操作类型的前缀匹配
+CREATE INDEX IF NOT EXISTS idx_operation_logs_operation ON operation_logs(operation text_pattern_ops);
+
+-- 添加注释
+COMMENT ON TABLE operation_logs IS '操作日志表';
+COMMENT ON COLUMN operation_logs.user_id IS '用户 ID，未认证或非用户主体为 0';
+COMMENT ON COLUMN operation_logs.operation IS '操作类型，HTTP 为方法与路径，gRPC 为方法全名';
+COMMENT ON COLUMN operation_logs.target IS '操作对象';
+COMMENT ON COLUMN operation_logs.result IS '操作结果，失败时为错误信息';
+COMMENT ON COLUMN operation_logs.created_at IS '操作发生时间';
@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS audit_checkpoints;
+DROP INDEX IF EXISTS idx_operation_logs_seq;
+ALTER TABLE operation_logs DROP COLUMN IF EXISTS hash;
+ALTER TABLE operation_logs DROP COLUMN IF EXISTS prev_hash;
+ALTER TABLE operation_logs DROP COLUMN IF EXISTS seq;
@@ -0,0 +1,23 @@
+-- 操作日志哈希链，迁移前写入的记录 seq 为空，不参与校验
+ALTER TABLE operation_logs ADD COLUMN IF NOT EXISTS seq BIGINT;
+ALTER TABLE operation_logs ADD COLUMN IF NOT EXISTS prev_hash VARCHAR(80) NOT NULL DEFAULT '';
+ALTER TABLE operation_logs ADD COLUMN IF NOT EXISTS hash VARCHAR(80) NOT NULL DEFAULT '';
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_operation_logs_seq ON operation_logs(seq);
+
+-- 创建哈希链检查点表
+CREATE TABLE IF NOT EXISTS audit_checkpoints (
+    id BIGSERIAL PRIMARY KEY,
+    seq BIGINT NOT NULL,
+    hash VARCHAR(80) NOT NULL,
+    signature TEXT NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_audit_checkpoints_seq ON audit_checkpoints(seq);
+
+-- 添加注释
+COMMENT ON COLUMN operation_logs.seq IS '哈希链序号，连续递增';
+COMMENT ON COLUMN operation_logs.prev_hash IS '链上前一条记录的哈希';
+COMMENT ON COLUMN operation_logs.hash IS '本条记录的链上哈希，格式为 算法:十六进制';
+COMMENT ON TABLE audit_checkpoints IS '操作日志哈希链检查点，ed25519 签名链头的序号与哈希';
@@ -0,0 +1,37 @@
+-- 恢复为普通表，已归档删除的分区不会恢复
+DROP TABLE IF EXISTS operation_log_archives;
+
+ALTER TABLE operation_logs RENAME TO operation_logs_partitioned;
+ALTER SEQUENCE operation_logs_id_seq OWNED BY NONE;
+DROP INDEX IF EXISTS idx_operation_logs_created_at;
+DROP INDEX IF EXISTS idx_operation_logs_user;
+DROP INDEX IF EXISTS idx_operation_logs_username;
+DROP INDEX IF EXISTS idx_operation_logs_operation;
+DROP INDEX IF EXISTS idx_operation_logs_seq;
+
+CREATE TABLE operation_logs (
+    id BIGINT PRIMARY KEY DEFAULT nextval('operation_logs_id_seq'),
+    user_id BIGINT NOT NULL DEFAULT 0,
+    username VARCHAR(255) NOT NULL DEFAULT '',
+    operation VARCHAR(255) NOT NULL,
+    target VARCHAR(2048) NOT NULL DEFAULT '',
+    content TEXT NOT NULL DEFAULT '',
+    result TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    seq BIGINT,
+    prev_hash VARCHAR(80) NOT NULL DEFAULT '',
+    hash VARCHAR(80) NOT NULL DEFAULT ''
+);
+
+ALTER SEQUENCE operation_logs_id_seq OWNED BY operation_logs.id;
+
+INSERT INTO operation_logs (id, user_id, username, operation, target, content, result, created_at, seq, prev_hash, hash)
+SELECT id, user_id, username, operation, target, content, result, created_at, seq, prev_hash, hash FROM operation_logs_partitioned;
+
+DROP TABLE operation_logs_partitioned;
+
+CREATE INDEX IF NOT EXISTS idx_operation_logs_created_at ON operation_logs(created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_operation_logs_user ON operation_logs(user_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_operation_logs_username ON operation_logs(username, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_operation_logs_operation ON operation_logs(operation text_pattern_ops);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_operation_logs_seq ON operation_logs(seq);
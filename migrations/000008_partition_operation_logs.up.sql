@@ -0,0 +1,92 @@
+-- 操作日志改为按月范围分区，分区名为 operation_logs_YYYYMM，边界为 UTC 月初
+ALTER TABLE operation_logs RENAME TO operation_logs_legacy;
+ALTER INDEX IF EXISTS idx_operation_logs_created_at RENAME TO idx_operation_logs_legacy_created_at;
+ALTER INDEX IF EXISTS idx_operation_logs_user RENAME TO idx_operation_logs_legacy_user;
+ALTER INDEX IF EXISTS idx_operation_logs_username RENAME TO idx_operation_logs_legacy_username;
+ALTER INDEX IF EXISTS idx_operation_logs_operation RENAME TO idx_operation_logs_legacy_operation;
+ALTER INDEX IF EXISTS idx_operation_logs_seq RENAME TO idx_operation_logs_legacy_seq;
+ALTER SEQUENCE operation_logs_id_seq OWNED BY NONE;
+
+-- 分区表的主键与唯一约束必须包含分区键
+CREATE TABLE operation_logs (
+    id BIGINT NOT NULL DEFAULT nextval('operation_logs_id_seq'),
+    user_id BIGINT NOT NULL DEFAULT 0,
+    username VARCHAR(255) NOT NULL DEFAULT '',
+    operation VARCHAR(255) NOT NULL,
+    target VARCHAR(2048) NOT NULL DEFAULT '',
+    content TEXT NOT NULL DEFAULT '',
+    result TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    seq BIGINT,
+    prev_hash VARCHAR(80) NOT NULL DEFAULT '',
+    hash VARCHAR(80) NOT NULL DEFAULT '',
+    PRIMARY KEY (id, created_at),
+    UNIQUE (seq, created_at)
+) PARTITION BY RANGE (created_at);
+
+ALTER SEQUENCE operation_logs_id_seq OWNED BY operation_logs.id;
+
+CREATE INDEX IF NOT EXISTS idx_operation_logs_created_at ON operation_logs(created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_operation_logs_user ON operation_logs(user_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_operation_logs_username ON operation_logs(username, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_operation_logs_operation ON operation_logs(operation text_pattern_ops);
+CREATE INDEX IF NOT EXISTS idx_operation_logs_seq ON operation_logs(seq);
+
+-- 为已有记录所在的月份到下月创建分区，其余记录落入默认分区，由归档任务移入按月预建的分区
+DO $$
+DECLARE
+    month TIMESTAMP;
+    last_month TIMESTAMP := date_trunc('month', now() AT TIME ZONE 'UTC') + INTERVAL '1 month';
+BEGIN
+    SELECT date_trunc('month', MIN(created_at) AT TIME ZONE 'UTC') INTO month FROM operation_logs_legacy;
+    IF month IS NULL OR month > last_month THEN
+        month := date_trunc('month', now() AT TIME ZONE 'UTC');
+    END IF;
+    WHILE month <= last_month LOOP
+        EXECUTE format(
+            'CREATE TABLE IF NOT EXISTS %I PARTITION OF operation_logs FOR VALUES FROM (%L) TO (%L)',
+            'operation_logs_' || to_char(month, 'YYYYMM'),
+            (month AT TIME ZONE 'UTC'),
+            ((month + INTERVAL '1 month') AT TIME ZONE 'UTC')
+        );
+        month := month + INTERVAL '1 month';
+    END LOOP;
+END $$;
+
+CREATE TABLE IF NOT EXISTS operation_logs_default PARTITION OF operation_logs DEFAULT;
+
+INSERT INTO operation_logs (id, user_id, username, operation, target, content, result, created_at, seq, prev_hash, hash)
+SELECT id, user_id, username, operation, target, content, result, created_at, seq, prev_hash, hash FROM operation_logs_legacy;
+
+DROP TABLE operation_logs_legacy;
+
+-- 创建归档目录表，记录已导出并删除的分区
+CREATE TABLE IF NOT EXISTS operation_log_archives (
+    id BIGSERIAL PRIMARY KEY,
+    partition_name VARCHAR(63) NOT NULL UNIQUE,
+    range_start TIMESTAMP WITH TIME ZONE NOT NULL,
+    range_end TIMESTAMP WITH TIME ZONE NOT NULL,
+    records BIGINT NOT NULL,
+    min_seq BIGINT,
+    max_seq BIGINT,
+    last_hash VARCHAR(80) NOT NULL DEFAULT '',
+    file VARCHAR(1024) NOT NULL,
+    sha256 VARCHAR(64) NOT NULL,
+    archived_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- 添加注释
+COMMENT ON TABLE operation_logs IS '操作日志表，按操作时间每月一个分区';
+COMMENT ON COLUMN operation_logs.user_id IS '用户 ID，未认证或非用户主体为 0';
+COMMENT ON COLUMN operation_logs.operation IS '操作类型，HTTP 为方法与路径，gRPC 为方法全名';
+COMMENT ON COLUMN operation_logs.target IS '操作对象';
+COMMENT ON COLUMN operation_logs.result IS '操作结果，失败时为错误信息';
+COMMENT ON COLUMN operation_logs.created_at IS '操作发生时间';
+COMMENT ON COLUMN operation_logs.seq IS '哈希链序号，连续递增';
+COMMENT ON COLUMN operation_logs.prev_hash IS '链上前一条记录的哈希';
+COMMENT ON COLUMN operation_logs.hash IS '本条记录的链上哈希，格式为 算法:十六进制';
+COMMENT ON TABLE operation_log_archives IS '已归档的操作日志分区';
+COMMENT ON COLUMN operation_log_archives.max_seq IS '分区内最大的哈希链序号，分区全部归档后作为链头';
+COMMENT ON COLUMN operation_log_archives.last_hash IS '序号为 max_seq 的记录的哈希';
+COMMENT ON COLUMN operation_log_archives.file IS '归档清单文件路径';
+COMMENT ON COLUMN operation_log_archives.sha256 IS '归档数据文件的 SHA-256 校验和';
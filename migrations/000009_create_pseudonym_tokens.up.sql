@@ -0,0 +1,8 @@
+-- 创建可逆假名化令牌库，只保存以数据集密钥派生的 AES-GCM 密文
+CREATE TABLE IF NOT EXISTS pseudonym_tokens (
+    dataset VARCHAR(255) NOT NULL,
+    token VARCHAR(255) NOT NULL,
+    sealed BYTEA NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (dataset, token)
+);
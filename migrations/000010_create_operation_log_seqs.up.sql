@@ -0,0 +1,16 @@
+-- 分区表的唯一约束必须包含分区键，seq 无法再建全局唯一索引。
+-- 写入操作日志时在同一事务中登记序号，由这张不分区的登记表保证序号全局唯一
+CREATE TABLE IF NOT EXISTS operation_log_seqs (
+    seq BIGINT PRIMARY KEY
+);
+
+-- 登记已有记录与已归档分区的序号，已存在的重复序号由 verify-audit 报告
+INSERT INTO operation_log_seqs (seq)
+SELECT seq FROM operation_logs WHERE seq IS NOT NULL
+ON CONFLICT (seq) DO NOTHING;
+
+INSERT INTO operation_log_seqs (seq)
+SELECT generate_series(min_seq, max_seq) FROM operation_log_archives WHERE min_seq IS NOT NULL AND max_seq IS NOT NULL
+ON CONFLICT (seq) DO NOTHING;
+
+COMMENT ON TABLE operation_log_seqs IS '已分配的操作日志哈希链序号，归档删除分区后保留';
@@ -5,11 +5,25 @@ package main
 import (
 	"context"
 	"fmt"
+	"os"
 	"time"
 
 	"kratos-boilerplate/internal/pkg/plugin"
 )
 
+// settingsSchema 插件配置的 JSON Schema
+const settingsSchema = `{
+	"type": "object",
+	"additionalProperties": false,
+	"properties": {
+		"log_event_data": {
+			"type": "boolean",
+			"default": true,
+			"description": "是否在审计日志中输出事件数据"
+		}
+	}
+}`
+
 // AuditLoggerPlugin 审计日志插件
 type AuditLoggerPlugin struct {
 	name    string
@@ -40,9 +54,13 @@ func (p *AuditLoggerPlugin) Dependencies() []string {
 	return nil
 }
 
+func (p *AuditLoggerPlugin) SettingsSchema() []byte {
+	return []byte(settingsSchema)
+}
+
 func (p *AuditLoggerPlugin) Initialize(ctx context.Context, config plugin.PluginConfig) error {
 	p.config = config
-	fmt.Printf("AuditLoggerPlugin initialized with config: %+v\n", config)
+	fmt.Printf("AuditLoggerPlugin initialized, log event data: %v\n", config.Settings["log_event_data"])
 	return nil
 }
 
@@ -119,7 +137,11 @@ func (p *AuditLoggerPlugin) RegisterEventHandlers(bus plugin.EventBus) error {
 		events,
 		15*time.Second,
 		func(ctx context.Context, event plugin.Event) error {
-			fmt.Printf("AuditLoggerPlugin: Event %s received: %+v\n", event.GetType(), event.GetData())
+			if logData, _ := p.config.Settings["log_event_data"].(bool); logData {
+				fmt.Printf("AuditLoggerPlugin: Event %s received: %+v\n", event.GetType(), event.GetData())
+			} else {
+				fmt.Printf("AuditLoggerPlugin: Event %s received\n", event.GetType())
+			}
 			// 在这里可以记录详细的审计日志
 			return nil
 		},
@@ -128,5 +150,10 @@ func (p *AuditLoggerPlugin) RegisterEventHandlers(bus plugin.EventBus) error {
 	return bus.Subscribe(plugin.EventUserLogin, eventHandler)
 }
 
-// 导出插件实例
-var AuditLoggerPluginInstance = NewAuditLoggerPlugin()
\ No newline at end of file
+func main() {
+	// 插件以子进程方式运行，由插件管理器启动并通过 gRPC 调用
+	if err := plugin.Serve(NewAuditLoggerPlugin()); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
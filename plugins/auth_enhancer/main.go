@@ -5,22 +5,46 @@ package main
 import (
 	"context"
 	"fmt"
+	"net/http"
+	"os"
+	"sync"
 	"time"
 
 	"kratos-boilerplate/internal/pkg/plugin"
 )
 
+// defaultLoginRateLimit 每个用户名每分钟允许的登录尝试次数
+const defaultLoginRateLimit = 10
+
+// settingsSchema 插件配置的 JSON Schema
+const settingsSchema = `{
+	"type": "object",
+	"additionalProperties": false,
+	"properties": {
+		"login_rate_limit": {
+			"type": "integer",
+			"minimum": 1,
+			"default": 10,
+			"description": "每个用户名每分钟允许的登录尝试次数"
+		}
+	}
+}`
+
 // AuthEnhancerPlugin 认证增强插件
 type AuthEnhancerPlugin struct {
 	name    string
 	config  plugin.PluginConfig
 	started bool
+
+	mu       sync.Mutex
+	attempts map[string][]time.Time
 }
 
 // NewAuthEnhancerPlugin 创建认证增强插件
 func NewAuthEnhancerPlugin() *AuthEnhancerPlugin {
 	return &AuthEnhancerPlugin{
-		name: "auth_enhancer",
+		name:     "auth_enhancer",
+		attempts: make(map[string][]time.Time),
 	}
 }
 
@@ -40,9 +64,13 @@ func (p *AuthEnhancerPlugin) Dependencies() []string {
 	return nil
 }
 
+func (p *AuthEnhancerPlugin) SettingsSchema() []byte {
+	return []byte(settingsSchema)
+}
+
 func (p *AuthEnhancerPlugin) Initialize(ctx context.Context, config plugin.PluginConfig) error {
 	p.config = config
-	fmt.Printf("AuthEnhancerPlugin initialized with config: %+v\n", config)
+	fmt.Printf("AuthEnhancerPlugin initialized, login rate limit %v/min\n", config.Settings["login_rate_limit"])
 	return nil
 }
 
@@ -77,8 +105,13 @@ func (p *AuthEnhancerPlugin) RegisterHooks(manager plugin.HookManager) error {
 		10, // 高优先级
 		5*time.Second,
 		func(ctx context.Context, data plugin.HookData) error {
-			fmt.Println("AuthEnhancerPlugin: Pre-authentication hook executed")
-			// 在这里可以添加JWT验证、速率限制等逻辑
+			if data.GetData()["action"] != "login" {
+				return nil
+			}
+			username, _ := data.GetData()["username"].(string)
+			if !p.allowLogin(username, time.Now()) {
+				return plugin.NewHookAbortError(http.StatusTooManyRequests, "LOGIN_RATE_LIMITED", "登录尝试过于频繁，请稍后再试")
+			}
 			return nil
 		},
 	)
@@ -118,5 +151,34 @@ func (p *AuthEnhancerPlugin) RegisterEventHandlers(bus plugin.EventBus) error {
 	return bus.Subscribe(plugin.EventUserLogin, loginHandler)
 }
 
-// 导出插件实例
-var AuthEnhancerPluginInstance = NewAuthEnhancerPlugin()
\ No newline at end of file
+// allowLogin 按用户名统计一分钟内的登录尝试次数
+func (p *AuthEnhancerPlugin) allowLogin(username string, now time.Time) bool {
+	limit := defaultLoginRateLimit
+	if v, ok := p.config.Settings["login_rate_limit"].(float64); ok && v > 0 {
+		limit = int(v)
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	recent := p.attempts[username][:0]
+	for _, t := range p.attempts[username] {
+		if now.Sub(t) < time.Minute {
+			recent = append(recent, t)
+		}
+	}
+	if len(recent) >= limit {
+		p.attempts[username] = recent
+		return false
+	}
+	p.attempts[username] = append(recent, now)
+	return true
+}
+
+func main() {
+	// 插件以子进程方式运行，由插件管理器启动并通过 gRPC 调用
+	if err := plugin.Serve(NewAuthEnhancerPlugin()); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
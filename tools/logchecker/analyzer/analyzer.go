@@ -0,0 +1,75 @@
+// Package analyzer 以 go/analysis 分析器的形式提供日志规范检查，
+// 可由 logchecker 命令、multichecker（go vet -vettool）或 gopls 使用。
+package analyzer
+
+import (
+	"reflect"
+	"strings"
+	"unicode"
+
+	"golang.org/x/tools/go/analysis"
+)
+
+// 问题类型，即 Diagnostic.Category，同时作为报告中的规则 ID
+const (
+	RuleStructuredLog  = "structured-log"
+	RuleSensitiveTaint = "sensitive-taint"
+)
+
+// Finding 分析器结果中的单个问题，供 logchecker 命令生成报告
+type Finding struct {
+	Diagnostic analysis.Diagnostic
+	Method     string // 日志方法名
+	Suggestion string // 建议使用的方法或包装写法
+}
+
+// findingsType 分析器的结果类型
+var findingsType = reflect.TypeOf([]Finding(nil))
+
+// Analyzers 返回全部分析器
+func Analyzers() []*analysis.Analyzer {
+	return []*analysis.Analyzer{StructuredLog, SensitiveTaint}
+}
+
+// listFlag 逗号分隔的列表参数
+type listFlag []string
+
+func (l *listFlag) String() string {
+	return strings.Join(*l, ",")
+}
+
+func (l *listFlag) Set(value string) error {
+	*l = nil
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			*l = append(*l, item)
+		}
+	}
+	return nil
+}
+
+func (l listFlag) contains(s string) bool {
+	for _, item := range l {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
+
+// snakeCase 将 TotpSecret、UserID 转为 totp_secret、user_id
+func snakeCase(s string) string {
+	runes := []rune(s)
+	var b strings.Builder
+	for i, r := range runes {
+		if unicode.IsUpper(r) {
+			if i > 0 && (unicode.IsLower(runes[i-1]) ||
+				(unicode.IsUpper(runes[i-1]) && i+1 < len(runes) && unicode.IsLower(runes[i+1]))) {
+				b.WriteByte('_')
+			}
+			r = unicode.ToLower(r)
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
@@ -0,0 +1,85 @@
+package analyzer
+
+import (
+	"go/ast"
+	"go/parser"
+	"path/filepath"
+	"testing"
+
+	"golang.org/x/tools/go/analysis/analysistest"
+)
+
+// testdata 复用 logchecker 的测试数据模块
+var testdata = filepath.Join("..", "testdata")
+
+func TestStructuredLog(t *testing.T) {
+	analysistest.RunWithSuggestedFixes(t, testdata, StructuredLog, ".")
+}
+
+func TestSensitiveTaint(t *testing.T) {
+	results := analysistest.RunWithSuggestedFixes(t, testdata, SensitiveTaint, "./taint/...")
+
+	for _, result := range results {
+		findings, _ := result.Result.([]Finding)
+		for _, f := range findings {
+			if f.Diagnostic.Category != RuleSensitiveTaint {
+				t.Errorf("Category = %q", f.Diagnostic.Category)
+			}
+			if f.Method == "Infof" && f.Suggestion == "sensitive.NewLogSafeValue(u.Email)" {
+				return
+			}
+		}
+	}
+	t.Error("expected finding with suggestion sensitive.NewLogSafeValue(u.Email)")
+}
+
+func TestShouldUseStructuredLog(t *testing.T) {
+	tests := []struct {
+		name     string
+		call     string
+		expected bool
+	}{
+		{"multiple arguments", `log.Info("User logged in", "user_id", id)`, true},
+		{"format placeholders", `log.Infof("User %s logged in")`, true},
+		{"long message", `log.Info("This is a very long message that exceeds the minimum length threshold")`, true},
+		{"sensitive keyword", `log.Info("User password updated")`, true},
+		{"simple message", `log.Info("Server started")`, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			expr, err := parser.ParseExpr(tt.call)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := shouldUseStructuredLog(expr.(*ast.CallExpr)); got != tt.expected {
+				t.Errorf("shouldUseStructuredLog(%s) = %v, want %v", tt.call, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestCleanMessage(t *testing.T) {
+	for in, want := range map[string]string{
+		"User  logged in":                 "User logged in",
+		"Failed to connect to database: ": "Failed to connect to database",
+		"用户登录失败：":                         "用户登录失败",
+	} {
+		if got := cleanMessage(in); got != want {
+			t.Errorf("cleanMessage(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestSnakeCase(t *testing.T) {
+	for in, want := range map[string]string{
+		"Email":      "email",
+		"TotpSecret": "totp_secret",
+		"UserID":     "user_id",
+		"IDCard":     "id_card",
+	} {
+		if got := snakeCase(in); got != want {
+			t.Errorf("snakeCase(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
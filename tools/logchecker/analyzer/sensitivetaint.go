@@ -0,0 +1,472 @@
+package analyzer
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+	"go/types"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/types/typeutil"
+)
+
+// SensitiveTaint 检查敏感值是否流入日志调用
+var SensitiveTaint = &analysis.Analyzer{
+	Name: "sensitivetaint",
+	Doc: "检查实现 sensitive.MakeSensitive 的对象、GetSensitiveFields 声明的字段及其在函数内的派生值" +
+		"是否作为参数传入日志调用，并提供用 sensitive.NewLogSafeValue 包装的修复",
+	Run:              runSensitiveTaint,
+	RunDespiteErrors: true,
+	ResultType:       findingsType,
+	FactTypes:        []analysis.Fact{new(sensitiveFieldsFact)},
+}
+
+// sensitiveFieldsFact 类型通过 GetSensitiveFields 声明的敏感字段，随类型跨包传递
+type sensitiveFieldsFact struct {
+	Fields []string
+}
+
+func (*sensitiveFieldsFact) AFact() {}
+
+func (f *sensitiveFieldsFact) String() string {
+	return "sensitiveFields(" + strings.Join(f.Fields, ",") + ")"
+}
+
+// logMethods 参与污点检查的日志方法（含结构化方法，结构化日志只按键名脱敏，值本身仍可能泄露）
+var logMethods = map[string]bool{
+	"Debug": true, "Debugf": true, "Debugw": true,
+	"Info": true, "Infof": true, "Infow": true,
+	"Warn": true, "Warnf": true, "Warnw": true, "Warning": true, "Warningf": true,
+	"Error": true, "Errorf": true, "Errorw": true,
+	"Fatal": true, "Fatalf": true, "Fatalw": true,
+	"Panic": true, "Panicf": true,
+	"Print": true, "Printf": true, "Println": true,
+	"Log": true,
+}
+
+// taintPass 单个包的污点分析状态
+type taintPass struct {
+	pass     *analysis.Pass
+	iface    *types.Interface // sensitive.MakeSensitive
+	ifacePkg *types.Package
+	findings []Finding
+}
+
+// funcTaint 单个函数内的污点状态
+type funcTaint struct {
+	tp      *taintPass
+	tainted map[types.Object]string // 变量 -> 敏感来源，如 User.Email
+}
+
+func runSensitiveTaint(pass *analysis.Pass) (interface{}, error) {
+	exportSensitiveFields(pass)
+
+	tp := &taintPass{pass: pass}
+	tp.ifacePkg, tp.iface = findMakeSensitive(pass.Pkg)
+	if tp.iface == nil && len(pass.AllObjectFacts()) == 0 {
+		// 既未引用 sensitive 包也没有声明敏感字段的类型
+		return []Finding(nil), nil
+	}
+
+	for _, file := range pass.Files {
+		for _, decl := range file.Decls {
+			if fn, ok := decl.(*ast.FuncDecl); ok && fn.Body != nil {
+				tp.checkFunc(file, fn.Body)
+			}
+		}
+	}
+	return tp.findings, nil
+}
+
+// exportSensitiveFields 读取本包 GetSensitiveFields 方法返回的字段列表，导出为接收者类型的 fact
+func exportSensitiveFields(pass *analysis.Pass) {
+	for _, file := range pass.Files {
+		for _, decl := range file.Decls {
+			fn, ok := decl.(*ast.FuncDecl)
+			if !ok || fn.Body == nil || fn.Recv == nil || fn.Name.Name != "GetSensitiveFields" {
+				continue
+			}
+			method, ok := pass.TypesInfo.Defs[fn.Name].(*types.Func)
+			if !ok {
+				continue
+			}
+			named := namedType(method.Type().(*types.Signature).Recv().Type())
+			if named == nil || named.Obj().Pkg() != pass.Pkg {
+				continue
+			}
+
+			fact := &sensitiveFieldsFact{}
+			ast.Inspect(fn.Body, func(n ast.Node) bool {
+				ret, ok := n.(*ast.ReturnStmt)
+				if !ok {
+					return true
+				}
+				ast.Inspect(ret, func(n ast.Node) bool {
+					if lit, ok := n.(*ast.BasicLit); ok && lit.Kind == token.STRING {
+						if name, err := strconv.Unquote(lit.Value); err == nil {
+							fact.Fields = append(fact.Fields, strings.ToLower(name))
+						}
+					}
+					return true
+				})
+				return false
+			})
+			if len(fact.Fields) > 0 {
+				pass.ExportObjectFact(named.Obj(), fact)
+			}
+		}
+	}
+}
+
+// findMakeSensitive 在包及其依赖中查找 sensitive.MakeSensitive 接口
+func findMakeSensitive(pkg *types.Package) (*types.Package, *types.Interface) {
+	visited := make(map[*types.Package]bool)
+	var visit func(p *types.Package) (*types.Package, *types.Interface)
+	visit = func(p *types.Package) (*types.Package, *types.Interface) {
+		if p == nil || visited[p] {
+			return nil, nil
+		}
+		visited[p] = true
+		if p.Name() == "sensitive" {
+			if obj, ok := p.Scope().Lookup("MakeSensitive").(*types.TypeName); ok {
+				if iface, ok := obj.Type().Underlying().(*types.Interface); ok {
+					return p, iface
+				}
+			}
+		}
+		for _, imp := range p.Imports() {
+			if found, iface := visit(imp); iface != nil {
+				return found, iface
+			}
+		}
+		return nil, nil
+	}
+	return visit(pkg)
+}
+
+// checkFunc 传播函数内的污点并检查日志调用
+func (tp *taintPass) checkFunc(file *ast.File, body *ast.BlockStmt) {
+	ft := &funcTaint{tp: tp, tainted: make(map[types.Object]string)}
+
+	// 不区分语句顺序，反复传播直到没有新的污点变量
+	for changed := true; changed; {
+		changed = false
+		ast.Inspect(body, func(n ast.Node) bool {
+			switch s := n.(type) {
+			case *ast.AssignStmt:
+				changed = ft.assignAll(s.Lhs, s.Rhs) || changed
+			case *ast.ValueSpec:
+				lhs := make([]ast.Expr, len(s.Names))
+				for i, name := range s.Names {
+					lhs[i] = name
+				}
+				changed = ft.assignAll(lhs, s.Values) || changed
+			case *ast.RangeStmt:
+				if s.Value != nil {
+					changed = ft.assign(s.Value, s.X) || changed
+				}
+			}
+			return true
+		})
+	}
+
+	ast.Inspect(body, func(n ast.Node) bool {
+		if call, ok := n.(*ast.CallExpr); ok {
+			tp.checkLogCall(ft, file, call)
+		}
+		return true
+	})
+}
+
+// assignAll 处理多重赋值，单个右值（如多返回值调用）污染所有左值
+func (ft *funcTaint) assignAll(lhs, rhs []ast.Expr) bool {
+	changed := false
+	switch {
+	case len(lhs) == len(rhs):
+		for i := range lhs {
+			changed = ft.assign(lhs[i], rhs[i]) || changed
+		}
+	case len(rhs) == 1:
+		for _, l := range lhs {
+			changed = ft.assign(l, rhs[0]) || changed
+		}
+	}
+	return changed
+}
+
+// assign 右值含敏感来源时标记左侧变量，返回是否新增了污点
+func (ft *funcTaint) assign(lhs, rhs ast.Expr) bool {
+	ident, ok := lhs.(*ast.Ident)
+	if !ok {
+		return false
+	}
+	info := ft.tp.pass.TypesInfo
+	obj := info.Defs[ident]
+	if obj == nil {
+		obj = info.Uses[ident]
+	}
+	if obj == nil || !carriesText(obj.Type()) {
+		return false
+	}
+	if _, ok := ft.tainted[obj]; ok {
+		return false
+	}
+	if src := ft.source(rhs); src != "" {
+		ft.tainted[obj] = src
+		return true
+	}
+	return false
+}
+
+// source 返回表达式的敏感来源，未受污染时返回空串
+func (ft *funcTaint) source(expr ast.Expr) string {
+	info := ft.tp.pass.TypesInfo
+	if tv, ok := info.Types[expr]; ok {
+		if tv.Value != nil || tv.IsType() || (tv.Type != nil && !carriesText(tv.Type)) {
+			return ""
+		}
+	}
+
+	switch e := expr.(type) {
+	case *ast.Ident:
+		return ft.tainted[info.Uses[e]]
+	case *ast.SelectorExpr:
+		if src := ft.tp.fieldSource(e); src != "" {
+			return src
+		}
+		return ft.source(e.X)
+	case *ast.CallExpr:
+		if isSanitizer(info, e) {
+			return ""
+		}
+		if sel, ok := e.Fun.(*ast.SelectorExpr); ok {
+			if src := ft.source(sel.X); src != "" {
+				return src
+			}
+		}
+		for _, arg := range e.Args {
+			if src := ft.source(arg); src != "" {
+				return src
+			}
+		}
+	case *ast.BinaryExpr:
+		if src := ft.source(e.X); src != "" {
+			return src
+		}
+		return ft.source(e.Y)
+	case *ast.UnaryExpr:
+		return ft.source(e.X)
+	case *ast.StarExpr:
+		return ft.source(e.X)
+	case *ast.ParenExpr:
+		return ft.source(e.X)
+	case *ast.IndexExpr:
+		return ft.source(e.X)
+	case *ast.SliceExpr:
+		return ft.source(e.X)
+	case *ast.TypeAssertExpr:
+		return ft.source(e.X)
+	case *ast.KeyValueExpr:
+		return ft.source(e.Value)
+	case *ast.CompositeLit:
+		for _, elt := range e.Elts {
+			if src := ft.source(elt); src != "" {
+				return src
+			}
+		}
+	}
+	return ""
+}
+
+// carriesText 布尔、数值与 error 类型不会携带敏感文本
+func carriesText(t types.Type) bool {
+	if t == nil {
+		return true
+	}
+	if basic, ok := t.Underlying().(*types.Basic); ok {
+		return basic.Info()&(types.IsBoolean|types.IsNumeric) == 0
+	}
+	return !types.Identical(t, types.Universe.Lookup("error").Type())
+}
+
+// isSanitizer sensitive 包的函数与 Anonymize/LogSafeString 方法的返回值视为已脱敏
+func isSanitizer(info *types.Info, call *ast.CallExpr) bool {
+	fn, ok := typeutil.Callee(info, call).(*types.Func)
+	if !ok {
+		return false
+	}
+	if fn.Pkg() != nil && fn.Pkg().Name() == "sensitive" {
+		return true
+	}
+	return fn.Name() == "Anonymize" || fn.Name() == "LogSafeString"
+}
+
+// fieldSource 选择的字段在所属类型的 GetSensitiveFields 列表中时返回来源描述
+func (tp *taintPass) fieldSource(sel *ast.SelectorExpr) string {
+	selection := tp.pass.TypesInfo.Selections[sel]
+	if selection == nil || selection.Kind() != types.FieldVal {
+		return ""
+	}
+	named := namedType(selection.Recv())
+	if named == nil {
+		return ""
+	}
+	var fact sensitiveFieldsFact
+	if !tp.pass.ImportObjectFact(named.Obj(), &fact) {
+		return ""
+	}
+	field := selection.Obj().(*types.Var)
+	for _, name := range fieldNames(named, field) {
+		for _, sensitive := range fact.Fields {
+			if name == sensitive {
+				return named.Obj().Name() + "." + field.Name()
+			}
+		}
+	}
+	return ""
+}
+
+// fieldNames 字段可能出现在 GetSensitiveFields 中的名称：原名、蛇形命名与 json 标签
+func fieldNames(named *types.Named, field *types.Var) []string {
+	names := []string{strings.ToLower(field.Name()), snakeCase(field.Name())}
+	if st, ok := named.Underlying().(*types.Struct); ok {
+		for i := 0; i < st.NumFields(); i++ {
+			if st.Field(i) != field {
+				continue
+			}
+			if tag := reflect.StructTag(st.Tag(i)).Get("json"); tag != "" {
+				names = append(names, strings.ToLower(strings.Split(tag, ",")[0]))
+			}
+		}
+	}
+	return names
+}
+
+// namedType 解引用指针后的具名类型
+func namedType(t types.Type) *types.Named {
+	if ptr, ok := t.(*types.Pointer); ok {
+		t = ptr.Elem()
+	}
+	named, _ := t.(*types.Named)
+	return named
+}
+
+// sensitiveType 类型实现 MakeSensitive 时返回类型名
+func (tp *taintPass) sensitiveType(t types.Type) *types.TypeName {
+	if t == nil || tp.iface == nil {
+		return nil
+	}
+	if !types.Implements(t, tp.iface) {
+		if _, isPtr := t.(*types.Pointer); isPtr || !types.Implements(types.NewPointer(t), tp.iface) {
+			return nil
+		}
+	}
+	if named := namedType(t); named != nil {
+		return named.Obj()
+	}
+	return nil
+}
+
+// checkLogCall 检查日志调用的参数
+func (tp *taintPass) checkLogCall(ft *funcTaint, file *ast.File, call *ast.CallExpr) {
+	info := tp.pass.TypesInfo
+	fn, ok := typeutil.Callee(info, call).(*types.Func)
+	if !ok || !logMethods[fn.Name()] || !isLoggerFunc(fn) {
+		return
+	}
+	for _, arg := range call.Args {
+		if tn := tp.sensitiveType(info.TypeOf(arg)); tn != nil {
+			tp.report(file, arg, fn.Name(), fmt.Sprintf("%s 实现了 sensitive.MakeSensitive，整体写入日志会输出全部敏感字段", tn.Name()))
+			continue
+		}
+		if src := ft.source(arg); src != "" {
+			tp.report(file, arg, fn.Name(), fmt.Sprintf("日志参数来自敏感字段 %s", src))
+		}
+	}
+}
+
+// isLoggerFunc 日志包的函数，或 Logger/Helper 类型的方法
+func isLoggerFunc(fn *types.Func) bool {
+	sig, ok := fn.Type().(*types.Signature)
+	if !ok {
+		return false
+	}
+	if recv := sig.Recv(); recv != nil {
+		named := namedType(recv.Type())
+		if named == nil {
+			return false
+		}
+		name := strings.ToLower(named.Obj().Name())
+		return strings.Contains(name, "log") || name == "helper"
+	}
+	return fn.Pkg() != nil && strings.HasSuffix(fn.Pkg().Name(), "log")
+}
+
+// report 报告问题，能确定 sensitive 包时提供用 NewLogSafeValue 包装参数的修复
+func (tp *taintPass) report(file *ast.File, arg ast.Expr, method, message string) {
+	diag := analysis.Diagnostic{
+		Pos:      arg.Pos(),
+		End:      arg.End(),
+		Category: RuleSensitiveTaint,
+		Message:  message,
+	}
+
+	wrapped := fmt.Sprintf("sensitive.NewLogSafeValue(%s)", types.ExprString(arg))
+	if tp.ifacePkg != nil {
+		qualifier, importEdit := tp.sensitiveQualifier(file)
+		wrapped = fmt.Sprintf("%sNewLogSafeValue(%s)", qualifier, types.ExprString(arg))
+
+		fix := analysis.SuggestedFix{
+			Message: "使用 sensitive.NewLogSafeValue 包装参数",
+			TextEdits: []analysis.TextEdit{
+				{Pos: arg.Pos(), End: arg.Pos(), NewText: []byte(qualifier + "NewLogSafeValue(")},
+				{Pos: arg.End(), End: arg.End(), NewText: []byte(")")},
+			},
+		}
+		if importEdit != nil {
+			fix.TextEdits = append([]analysis.TextEdit{*importEdit}, fix.TextEdits...)
+		}
+		diag.SuggestedFixes = []analysis.SuggestedFix{fix}
+	}
+
+	tp.pass.Report(diag)
+	tp.findings = append(tp.findings, Finding{Diagnostic: diag, Method: method, Suggestion: wrapped})
+}
+
+// sensitiveQualifier 返回文件中引用 sensitive 包的前缀，未导入时一并返回添加导入的修改
+func (tp *taintPass) sensitiveQualifier(file *ast.File) (string, *analysis.TextEdit) {
+	if tp.ifacePkg == tp.pass.Pkg {
+		return "", nil
+	}
+	path := tp.ifacePkg.Path()
+	for _, spec := range file.Imports {
+		if value, err := strconv.Unquote(spec.Path.Value); err == nil && value == path {
+			if spec.Name != nil {
+				return spec.Name.Name + ".", nil
+			}
+			return tp.ifacePkg.Name() + ".", nil
+		}
+	}
+
+	// 在第一个 import 声明中追加，没有时在 package 子句后新增
+	for _, decl := range file.Decls {
+		gen, ok := decl.(*ast.GenDecl)
+		if !ok || gen.Tok != token.IMPORT {
+			continue
+		}
+		if gen.Lparen.IsValid() {
+			return tp.ifacePkg.Name() + ".", &analysis.TextEdit{
+				Pos: gen.Rparen, End: gen.Rparen, NewText: []byte("\t" + strconv.Quote(path) + "\n"),
+			}
+		}
+		return tp.ifacePkg.Name() + ".", &analysis.TextEdit{
+			Pos: gen.End(), End: gen.End(), NewText: []byte("\nimport " + strconv.Quote(path)),
+		}
+	}
+	return tp.ifacePkg.Name() + ".", &analysis.TextEdit{
+		Pos: file.Name.End(), End: file.Name.End(), NewText: []byte("\n\nimport " + strconv.Quote(path)),
+	}
+}
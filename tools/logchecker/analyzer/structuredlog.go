@@ -0,0 +1,329 @@
+package analyzer
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/token"
+	"go/types"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"golang.org/x/tools/go/analysis"
+)
+
+// StructuredLog 检查应改用结构化日志的格式化日志调用
+var StructuredLog = &analysis.Analyzer{
+	Name:             "structuredlog",
+	Doc:              "检查应改用结构化日志方法（Infow 等）的格式化日志调用，并提供改写为结构化调用的修复",
+	Run:              runStructuredLog,
+	RunDespiteErrors: true,
+	ResultType:       findingsType,
+}
+
+var (
+	whitelistMethods  = listFlag{"Printf"}
+	sensitiveKeywords = listFlag{
+		"password", "token", "secret", "key", "auth",
+		"email", "phone", "mobile", "card", "id",
+		"user", "account", "login", "credential",
+		"session", "cookie", "jwt", "oauth",
+	}
+	minMessageLength = 50
+)
+
+func init() {
+	StructuredLog.Flags.Var(&whitelistMethods, "whitelist", "允许使用格式化日志的方法，逗号分隔")
+	StructuredLog.Flags.Var(&sensitiveKeywords, "keywords", "消息包含时建议使用结构化日志的敏感关键词，逗号分隔")
+	StructuredLog.Flags.IntVar(&minMessageLength, "min-length", minMessageLength, "消息超过此长度时建议使用结构化日志")
+}
+
+// structuredMethods 格式化日志方法对应的结构化方法
+var structuredMethods = map[string]string{
+	"Infof":    "Infow",
+	"Info":     "Infow",
+	"Debugf":   "Debugw",
+	"Debug":    "Debugw",
+	"Errorf":   "Errorw",
+	"Error":    "Errorw",
+	"Warnf":    "Warnw",
+	"Warn":     "Warnw",
+	"Warningf": "Warnw",
+	"Warning":  "Warnw",
+	"Printf":   "Infow",
+	"Print":    "Infow",
+	"Println":  "Infow",
+}
+
+func runStructuredLog(pass *analysis.Pass) (interface{}, error) {
+	var findings []Finding
+	for _, file := range pass.Files {
+		ast.Inspect(file, func(n ast.Node) bool {
+			call, ok := n.(*ast.CallExpr)
+			if !ok {
+				return true
+			}
+			sel, ok := call.Fun.(*ast.SelectorExpr)
+			if !ok {
+				return true
+			}
+			method := sel.Sel.Name
+			target, ok := structuredMethods[method]
+			if !ok || whitelistMethods.contains(method) || !shouldUseStructuredLog(call) {
+				return true
+			}
+
+			diag := analysis.Diagnostic{
+				Pos:      call.Pos(),
+				End:      call.End(),
+				Category: RuleStructuredLog,
+				Message:  fmt.Sprintf("使用了格式化日志方法 '%s'，建议使用结构化日志方法 '%s' 以获得更好的日志脱敏和分析能力", method, target),
+			}
+			if fix, ok := structuredFix(pass, call, sel, target); ok {
+				diag.SuggestedFixes = []analysis.SuggestedFix{fix}
+			}
+			pass.Report(diag)
+			findings = append(findings, Finding{Diagnostic: diag, Method: method, Suggestion: target})
+			return true
+		})
+	}
+	return findings, nil
+}
+
+// shouldUseStructuredLog 判断是否应该使用结构化日志
+func shouldUseStructuredLog(call *ast.CallExpr) bool {
+	// 如果参数数量大于1，可能包含结构化数据
+	if len(call.Args) > 1 {
+		return true
+	}
+
+	// 检查第一个参数是否包含格式化占位符
+	if len(call.Args) > 0 {
+		if lit, ok := call.Args[0].(*ast.BasicLit); ok && lit.Kind == token.STRING {
+			value := strings.Trim(lit.Value, "`\"")
+			if strings.Contains(value, "%") {
+				return true
+			}
+			// 如果消息较长或包含敏感信息关键词，建议使用结构化日志
+			if len(value) > minMessageLength || containsSensitiveKeywords(value) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// containsSensitiveKeywords 检查是否包含敏感信息关键词
+func containsSensitiveKeywords(text string) bool {
+	lowerText := strings.ToLower(text)
+	for _, keyword := range sensitiveKeywords {
+		if strings.Contains(lowerText, strings.ToLower(keyword)) {
+			return true
+		}
+	}
+	return false
+}
+
+// structuredFix 将调用改写为结构化方法。接收者没有对应的结构化方法，
+// 或参数无法可靠地转换为键值对时不提供修复。
+func structuredFix(pass *analysis.Pass, call *ast.CallExpr, sel *ast.SelectorExpr, target string) (analysis.SuggestedFix, bool) {
+	msgParam, ok := structuredSignature(pass.TypesInfo, sel, target)
+	if !ok {
+		return analysis.SuggestedFix{}, false
+	}
+
+	var (
+		msg     string
+		keyvals []string
+	)
+	if strings.HasSuffix(sel.Sel.Name, "f") {
+		msg, keyvals, ok = formatKeyvals(pass, call)
+	} else {
+		msg, keyvals, ok = printKeyvals(pass, call)
+	}
+	if !ok {
+		return analysis.SuggestedFix{}, false
+	}
+
+	args := make([]string, 0, len(keyvals)+2)
+	if !msgParam {
+		// kratos log.Helper 的 Infow 只接收键值对，消息放在 msg 键下
+		args = append(args, strconv.Quote("msg"))
+	}
+	args = append(args, strconv.Quote(msg))
+	args = append(args, keyvals...)
+
+	return analysis.SuggestedFix{
+		Message: fmt.Sprintf("改写为 %s 结构化调用", target),
+		TextEdits: []analysis.TextEdit{{
+			Pos:     sel.Sel.Pos(),
+			End:     call.End(),
+			NewText: []byte(target + "(" + strings.Join(args, ", ") + ")"),
+		}},
+	}, true
+}
+
+// structuredSignature 查找接收者的结构化方法，返回其第一个参数是否为消息。
+// 支持 Infow(msg string, keysAndValues ...interface{}) 与 kratos 的 Infow(keyvals ...interface{})。
+func structuredSignature(info *types.Info, sel *ast.SelectorExpr, target string) (msgParam bool, ok bool) {
+	var obj types.Object
+	if ident, isIdent := sel.X.(*ast.Ident); isIdent {
+		if pkgName, isPkg := info.Uses[ident].(*types.PkgName); isPkg {
+			obj = pkgName.Imported().Scope().Lookup(target)
+		}
+	}
+	if obj == nil {
+		recv := info.TypeOf(sel.X)
+		if recv == nil {
+			return false, false
+		}
+		obj, _, _ = types.LookupFieldOrMethod(recv, true, nil, target)
+	}
+	fn, isFunc := obj.(*types.Func)
+	if !isFunc {
+		return false, false
+	}
+	sig := fn.Type().(*types.Signature)
+	if !sig.Variadic() {
+		return false, false
+	}
+	switch params := sig.Params(); params.Len() {
+	case 1:
+		return false, true
+	case 2:
+		basic, isBasic := params.At(0).Type().(*types.Basic)
+		return true, isBasic && basic.Kind() == types.String
+	}
+	return false, false
+}
+
+// formatKeyvals 将 Infof("msg %s", x) 的参数拆为消息与键值对，
+// 只处理 %v、%s、%d 这类不带宽度与精度的占位符
+func formatKeyvals(pass *analysis.Pass, call *ast.CallExpr) (string, []string, bool) {
+	if len(call.Args) == 0 {
+		return "", nil, false
+	}
+	format, ok := stringLiteral(call.Args[0])
+	if !ok {
+		return "", nil, false
+	}
+
+	var (
+		msg      strings.Builder
+		segments []string // 每个占位符前的文本
+		last     int
+	)
+	for i := 0; i < len(format); i++ {
+		if format[i] != '%' {
+			continue
+		}
+		j := i + 1
+		if j < len(format) && (format[j] == '+' || format[j] == '#') {
+			j++
+		}
+		if j >= len(format) || !strings.ContainsRune("vsdqxXtfgeT", rune(format[j])) {
+			// %% 与带宽度、精度的占位符不改写
+			return "", nil, false
+		}
+		segments = append(segments, format[last:i])
+		msg.WriteString(format[last:i])
+		last = j + 1
+		i = j
+	}
+	msg.WriteString(format[last:])
+
+	values := call.Args[1:]
+	if len(segments) != len(values) || call.Ellipsis.IsValid() {
+		return "", nil, false
+	}
+
+	used := make(map[string]int)
+	keyvals := make([]string, 0, len(values)*2)
+	for i, value := range values {
+		key := argKey(pass.TypesInfo, value, segments[i], i)
+		if used[key]++; used[key] > 1 {
+			key = fmt.Sprintf("%s_%d", key, used[key])
+		}
+		src, ok := exprSource(pass.Fset, value)
+		if !ok {
+			return "", nil, false
+		}
+		keyvals = append(keyvals, strconv.Quote(key), src)
+	}
+	return cleanMessage(msg.String()), keyvals, true
+}
+
+// printKeyvals 处理 Info("msg", "key", value) 这类已按键值对书写的调用
+func printKeyvals(pass *analysis.Pass, call *ast.CallExpr) (string, []string, bool) {
+	if len(call.Args) == 0 || len(call.Args)%2 == 0 || call.Ellipsis.IsValid() {
+		return "", nil, false
+	}
+	msg, ok := stringLiteral(call.Args[0])
+	if !ok {
+		return "", nil, false
+	}
+	keyvals := make([]string, 0, len(call.Args)-1)
+	for i, arg := range call.Args[1:] {
+		if i%2 == 0 {
+			if _, ok := stringLiteral(arg); !ok {
+				return "", nil, false
+			}
+		}
+		src, ok := exprSource(pass.Fset, arg)
+		if !ok {
+			return "", nil, false
+		}
+		keyvals = append(keyvals, src)
+	}
+	return msg, keyvals, true
+}
+
+// argKey 为格式化参数生成键名：error 类型用 error，变量与字段用蛇形命名，
+// 其他取占位符前的最后一个单词
+func argKey(info *types.Info, arg ast.Expr, before string, index int) string {
+	if t := info.TypeOf(arg); t != nil && types.Implements(t, types.Universe.Lookup("error").Type().Underlying().(*types.Interface)) {
+		return "error"
+	}
+	switch e := arg.(type) {
+	case *ast.Ident:
+		if e.Name != "nil" && e.Name != "true" && e.Name != "false" {
+			return snakeCase(e.Name)
+		}
+	case *ast.SelectorExpr:
+		return snakeCase(e.Sel.Name)
+	}
+
+	words := strings.FieldsFunc(before, func(r rune) bool {
+		return !(r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_'))
+	})
+	if len(words) > 0 {
+		return snakeCase(words[len(words)-1])
+	}
+	return fmt.Sprintf("arg%d", index+1)
+}
+
+// cleanMessage 去掉占位符留下的多余空白与结尾分隔符
+func cleanMessage(msg string) string {
+	msg = strings.Join(strings.Fields(msg), " ")
+	return strings.TrimRight(msg, " :：=,，;；")
+}
+
+func stringLiteral(expr ast.Expr) (string, bool) {
+	lit, ok := expr.(*ast.BasicLit)
+	if !ok || lit.Kind != token.STRING {
+		return "", false
+	}
+	value, err := strconv.Unquote(lit.Value)
+	return value, err == nil
+}
+
+func exprSource(fset *token.FileSet, expr ast.Expr) (string, bool) {
+	var buf bytes.Buffer
+	if err := format.Node(&buf, fset, expr); err != nil {
+		return "", false
+	}
+	return buf.String(), true
+}
@@ -1,12 +1,18 @@
 package main
 
 import (
-	"go/ast"
-	"go/parser"
+	"fmt"
 	"go/token"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
+
+	"logchecker/analyzer"
+
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/analysis/checker"
+	"golang.org/x/tools/go/packages"
 )
 
 // LogChecker 日志规范检查器
@@ -20,17 +26,39 @@ type Issue struct {
 	File        string `json:"file"`
 	Line        int    `json:"line"`
 	Column      int    `json:"column"`
+	EndLine     int    `json:"end_line"`
+	EndColumn   int    `json:"end_column"`
 	Method      string `json:"method"`
 	Suggestion  string `json:"suggestion"`
 	Description string `json:"description"`
 	Severity    string `json:"severity"`
+	Rule        string `json:"rule"`
+	Fix         *Fix   `json:"fix,omitempty"`
+}
+
+// Fix 问题的自动修复
+type Fix struct {
+	Description string `json:"description"`
+	Edits       []Edit `json:"edits"`
+}
+
+// Edit 对源文件的一处替换，Offset/EndOffset 为字节偏移
+type Edit struct {
+	File        string `json:"file"`
+	Offset      int    `json:"offset"`
+	EndOffset   int    `json:"end_offset"`
+	StartLine   int    `json:"start_line"`
+	StartColumn int    `json:"start_column"`
+	EndLine     int    `json:"end_line"`
+	EndColumn   int    `json:"end_column"`
+	NewText     string `json:"new_text"`
 }
 
 // ScanResult 扫描结果
 type ScanResult struct {
-	TotalFiles int     `json:"total_files"`
-	ScannedFiles int   `json:"scanned_files"`
-	Issues     []Issue `json:"issues"`
+	TotalFiles   int     `json:"total_files"`
+	ScannedFiles int     `json:"scanned_files"`
+	Issues       []Issue `json:"issues"`
 }
 
 // NewLogChecker 创建新的日志检查器
@@ -53,62 +81,184 @@ func (c *LogChecker) ScanDirectory(dir string) (*ScanResult, error) {
 			return err
 		}
 
-		// 跳过非Go文件
-		if !strings.HasSuffix(path, ".go") {
-			return nil
-		}
-
-		// 跳过测试文件（可配置）
-		if c.config.SkipTestFiles && strings.HasSuffix(path, "_test.go") {
-			return nil
-		}
-
-		// 检查是否在忽略列表中
-		if c.shouldIgnoreFile(path) {
+		// 跳过非Go文件、测试文件（可配置）与忽略列表中的文件
+		if !strings.HasSuffix(path, ".go") || c.shouldSkipFile(path) {
 			return nil
 		}
 
 		result.TotalFiles++
-
-		// 解析文件
-		issues, err := c.scanFile(path)
-		if err != nil {
-			return err
-		}
-
 		result.ScannedFiles++
-		result.Issues = append(result.Issues, issues...)
-
 		return nil
 	})
+	if err != nil {
+		return result, err
+	}
+
+	issues, err := c.analyze(dir, "./...")
+	if err != nil {
+		return result, err
+	}
+	result.Issues = append(result.Issues, issues...)
 
-	return result, err
+	return result, nil
 }
 
-// scanFile 扫描单个Go文件
+// scanFile 扫描单个Go文件，分析其所在的包后只保留该文件的问题
 func (c *LogChecker) scanFile(filename string) ([]Issue, error) {
-	src, err := os.ReadFile(filename)
+	abs, err := filepath.Abs(filename)
 	if err != nil {
 		return nil, err
 	}
-
-	// 解析AST
-	node, err := parser.ParseFile(c.fset, filename, src, parser.ParseComments)
+	dir := filepath.Dir(filename)
+	issues, err := c.analyze(dir, "file="+abs)
 	if err != nil {
 		return nil, err
 	}
 
-	// 创建访问器
-	visitor := &logVisitor{
-		checker:  c,
-		filename: filename,
-		issues:   make([]Issue, 0),
+	result := make([]Issue, 0, len(issues))
+	for _, issue := range issues {
+		if filepath.Clean(issue.File) == filepath.Clean(filename) {
+			result = append(result, issue)
+		}
+	}
+	return result, nil
+}
+
+// analyze 加载包并运行分析器。存在语法或类型错误的包仍按已得到的信息分析。
+func (c *LogChecker) analyze(dir string, patterns ...string) ([]Issue, error) {
+	if err := c.configureAnalyzers(); err != nil {
+		return nil, err
+	}
+
+	cfg := &packages.Config{
+		Mode:  packages.LoadAllSyntax,
+		Dir:   dir,
+		Fset:  c.fset,
+		Tests: !c.config.SkipTestFiles,
+	}
+	pkgs, err := packages.Load(cfg, patterns...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load packages: %w", err)
 	}
 
-	// 遍历AST
-	ast.Walk(visitor, node)
+	graph, err := checker.Analyze(c.analyzers(), pkgs, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to analyze packages: %w", err)
+	}
 
-	return visitor.issues, nil
+	issues := make([]Issue, 0)
+	seen := make(map[string]bool)
+	for _, act := range graph.Roots {
+		if act.Err != nil {
+			return nil, fmt.Errorf("%s: %w", act, act.Err)
+		}
+		findings, _ := act.Result.([]analyzer.Finding)
+		for _, finding := range findings {
+			issue := c.newIssue(dir, finding)
+			// 测试变体中的同一文件只报告一次
+			key := fmt.Sprintf("%s:%d:%d:%s", issue.File, issue.Line, issue.Column, issue.Rule)
+			if seen[key] || c.shouldSkipFile(issue.File) {
+				continue
+			}
+			seen[key] = true
+			issues = append(issues, issue)
+		}
+	}
+	return issues, nil
+}
+
+// analyzers 按配置启用的分析器
+func (c *LogChecker) analyzers() []*analysis.Analyzer {
+	analyzers := []*analysis.Analyzer{analyzer.StructuredLog}
+	if c.config.TaintAnalysis {
+		analyzers = append(analyzers, analyzer.SensitiveTaint)
+	}
+	return analyzers
+}
+
+// configureAnalyzers 将配置写入分析器参数
+func (c *LogChecker) configureAnalyzers() error {
+	flags := map[string]string{
+		"whitelist":  strings.Join(c.config.WhitelistMethods, ","),
+		"keywords":   strings.Join(c.config.SensitiveKeywords, ","),
+		"min-length": strconv.Itoa(c.config.MinMessageLength),
+	}
+	for name, value := range flags {
+		if err := analyzer.StructuredLog.Flags.Set(name, value); err != nil {
+			return fmt.Errorf("invalid config %s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// newIssue 将分析结果转换为报告中的问题
+func (c *LogChecker) newIssue(dir string, finding analyzer.Finding) Issue {
+	diag := finding.Diagnostic
+	pos := c.fset.Position(diag.Pos)
+	end := pos
+	if diag.End.IsValid() {
+		end = c.fset.Position(diag.End)
+	}
+
+	issue := Issue{
+		File:        c.displayPath(dir, pos.Filename),
+		Line:        pos.Line,
+		Column:      pos.Column,
+		EndLine:     end.Line,
+		EndColumn:   end.Column,
+		Method:      finding.Method,
+		Suggestion:  finding.Suggestion,
+		Description: diag.Message,
+		Severity:    c.config.GetSeverity(finding.Method),
+		Rule:        diag.Category,
+	}
+	if diag.Category == analyzer.RuleSensitiveTaint {
+		issue.Severity = "high"
+	}
+
+	if len(diag.SuggestedFixes) > 0 {
+		fix := diag.SuggestedFixes[0]
+		issue.Fix = &Fix{Description: fix.Message}
+		for _, edit := range fix.TextEdits {
+			start := c.fset.Position(edit.Pos)
+			stop := start
+			if edit.End.IsValid() {
+				stop = c.fset.Position(edit.End)
+			}
+			issue.Fix.Edits = append(issue.Fix.Edits, Edit{
+				File:        c.displayPath(dir, start.Filename),
+				Offset:      start.Offset,
+				EndOffset:   stop.Offset,
+				StartLine:   start.Line,
+				StartColumn: start.Column,
+				EndLine:     stop.Line,
+				EndColumn:   stop.Column,
+				NewText:     string(edit.NewText),
+			})
+		}
+	}
+	return issue
+}
+
+// displayPath 将绝对路径转换为相对扫描目录的路径，与目录遍历得到的路径一致
+func (c *LogChecker) displayPath(dir, filename string) string {
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return filename
+	}
+	rel, err := filepath.Rel(abs, filename)
+	if err != nil || strings.HasPrefix(rel, "..") {
+		return filename
+	}
+	return filepath.Join(dir, rel)
+}
+
+// shouldSkipFile 检查文件是否应该跳过
+func (c *LogChecker) shouldSkipFile(path string) bool {
+	if c.config.SkipTestFiles && strings.HasSuffix(path, "_test.go") {
+		return true
+	}
+	return c.shouldIgnoreFile(path)
 }
 
 // shouldIgnoreFile 检查文件是否应该被忽略
@@ -122,4 +272,4 @@ func (c *LogChecker) shouldIgnoreFile(path string) bool {
 		}
 	}
 	return false
-}
\ No newline at end of file
+}
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"encoding/json"
 	"os"
 	"path/filepath"
 	"testing"
@@ -150,60 +151,6 @@ func TestConfig_LoadFromFile(t *testing.T) {
 	}
 }
 
-func TestVisitor_ShouldUseStructuredLog(t *testing.T) {
-	tests := []struct {
-		name     string
-		message  string
-		argCount int
-		expected bool
-	}{
-		{
-			name:     "multiple arguments",
-			message:  "User logged in",
-			argCount: 3,
-			expected: true,
-		},
-		{
-			name:     "format placeholders",
-			message:  "User %s logged in",
-			argCount: 1,
-			expected: true,
-		},
-		{
-			name:     "long message",
-			message:  "This is a very long message that exceeds the minimum length threshold",
-			argCount: 1,
-			expected: true,
-		},
-		{
-			name:     "sensitive keyword",
-			message:  "User password updated",
-			argCount: 1,
-			expected: true,
-		},
-		{
-			name:     "simple message",
-			message:  "Server started",
-			argCount: 1,
-			expected: false,
-		},
-	}
-
-	visitor := &logVisitor{
-		checker: NewLogChecker(""),
-	}
-
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			// 这里需要构造AST节点来测试，简化测试
-			result := visitor.containsSensitiveKeywords(tt.message)
-			if tt.name == "sensitive keyword" && !result {
-				t.Error("Expected to detect sensitive keyword")
-			}
-		})
-	}
-}
-
 func TestReporter_GenerateReport(t *testing.T) {
 	tests := []struct {
 		name   string
@@ -212,6 +159,8 @@ func TestReporter_GenerateReport(t *testing.T) {
 		{"console format", "console"},
 		{"json format", "json"},
 		{"html format", "html"},
+		{"sarif format", "sarif"},
+		{"text format", "text"},
 	}
 
 	// 创建测试数据
@@ -240,4 +189,112 @@ func TestReporter_GenerateReport(t *testing.T) {
 			}
 		})
 	}
-}
\ No newline at end of file
+}
+func TestLogChecker_ScanDirectoryTaint(t *testing.T) {
+	result, err := NewLogChecker("").ScanDirectory("testdata")
+	if err != nil {
+		t.Fatalf("ScanDirectory() error = %v", err)
+	}
+
+	var found bool
+	for _, issue := range result.Issues {
+		if issue.Rule != "sensitive-taint" {
+			continue
+		}
+		if issue.Severity != "high" {
+			t.Errorf("taint issue severity = %s, want high", issue.Severity)
+		}
+		if issue.File == filepath.Join("testdata", "taint", "service", "user.go") && issue.Line == 13 {
+			found = true
+			if issue.Suggestion != "sensitive.NewLogSafeValue(u.Email)" {
+				t.Errorf("Suggestion = %q", issue.Suggestion)
+			}
+		}
+	}
+	if !found {
+		t.Error("expected taint issue for u.Email")
+	}
+}
+
+func TestApplyFixes(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "testdata")
+	if err := os.CopyFS(dir, os.DirFS("testdata")); err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := NewLogChecker("").ScanDirectory(dir)
+	if err != nil {
+		t.Fatalf("ScanDirectory() error = %v", err)
+	}
+	applied, err := ApplyFixes(result.Issues)
+	if err != nil {
+		t.Fatalf("ApplyFixes() error = %v", err)
+	}
+	if applied == 0 {
+		t.Fatal("ApplyFixes() applied no fixes")
+	}
+
+	got, err := os.ReadFile(filepath.Join(dir, "sample.go"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, err := os.ReadFile(filepath.Join("testdata", "sample.go.golden"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != string(want) {
+		t.Errorf("fixed sample.go mismatch:\n%s", got)
+	}
+
+	// 修复后的代码仍能编译，且不再有可修复的问题
+	result, err = NewLogChecker("").ScanDirectory(dir)
+	if err != nil {
+		t.Fatalf("ScanDirectory() after fix error = %v", err)
+	}
+	for _, issue := range result.Issues {
+		if issue.Fix != nil && issue.Rule == "structured-log" {
+			t.Errorf("unfixed issue at %s:%d", issue.File, issue.Line)
+		}
+	}
+}
+
+func TestReporter_SARIF(t *testing.T) {
+	result := &ScanResult{Issues: []Issue{{
+		File: "internal/biz/user.go", Line: 3, Column: 2, EndLine: 3, EndColumn: 9,
+		Description: "日志参数来自敏感字段 User.Email", Severity: "high", Rule: "sensitive-taint",
+		Fix: &Fix{Description: "wrap", Edits: []Edit{
+			{File: "internal/biz/user.go", StartLine: 3, StartColumn: 2, EndLine: 3, EndColumn: 2, NewText: "sensitive.NewLogSafeValue("},
+		}},
+	}}}
+
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stdout := os.Stdout
+	os.Stdout = w
+	err = NewReporter("sarif").GenerateReport(result)
+	os.Stdout = stdout
+	w.Close()
+	if err != nil {
+		t.Fatalf("GenerateReport() error = %v", err)
+	}
+
+	var log sarifLog
+	if err := json.NewDecoder(r).Decode(&log); err != nil {
+		t.Fatalf("invalid SARIF: %v", err)
+	}
+	if log.Version != "2.1.0" || len(log.Runs) != 1 || len(log.Runs[0].Tool.Driver.Rules) != 2 {
+		t.Fatalf("unexpected SARIF log: %+v", log)
+	}
+	res := log.Runs[0].Results[0]
+	if res.RuleID != "sensitive-taint" || res.Level != "error" {
+		t.Errorf("unexpected result: %+v", res)
+	}
+	if uri := res.Locations[0].PhysicalLocation.ArtifactLocation.URI; uri != "internal/biz/user.go" {
+		t.Errorf("uri = %s", uri)
+	}
+	if len(res.Fixes) != 1 || res.Fixes[0].ArtifactChanges[0].Replacements[0].InsertedContent.Text != "sensitive.NewLogSafeValue(" {
+		t.Errorf("unexpected fixes: %+v", res.Fixes)
+	}
+}
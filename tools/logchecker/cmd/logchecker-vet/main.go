@@ -0,0 +1,16 @@
+// logchecker-vet 以 multichecker 形式运行日志规范分析器，可直接运行或作为 go vet 的 vettool：
+//
+//	logchecker-vet ./...
+//	logchecker-vet -fix ./...
+//	go vet -vettool=$(which logchecker-vet) ./...
+package main
+
+import (
+	"logchecker/analyzer"
+
+	"golang.org/x/tools/go/analysis/multichecker"
+)
+
+func main() {
+	multichecker.Main(analyzer.Analyzers()...)
+}
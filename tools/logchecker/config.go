@@ -31,6 +31,9 @@ type Config struct {
 	// 最小消息长度阈值（超过此长度建议使用结构化日志）
 	MinMessageLength int `json:"min_message_length"`
 	
+	// 是否检查敏感字段（GetSensitiveFields 声明）与 MakeSensitive 对象流入日志调用
+	TaintAnalysis bool `json:"taint_analysis"`
+	
 	// 严重程度配置
 	SeverityConfig SeverityConfig `json:"severity_config"`
 }
@@ -70,6 +73,7 @@ func LoadConfig(configPath string) *Config {
 			"session", "cookie", "jwt", "oauth",
 		},
 		MinMessageLength: 50,
+		TaintAnalysis:    true,
 		SeverityConfig: SeverityConfig{
 			ErrorMethods: []string{"Errorf", "Error"},
 			WarnMethods:  []string{"Warnf", "Warn", "Warningf", "Warning"},
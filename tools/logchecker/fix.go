@@ -0,0 +1,100 @@
+package main
+
+import (
+	"fmt"
+	"go/format"
+	"os"
+	"sort"
+)
+
+// ApplyFixes 将问题附带的修复写回源文件，返回应用的修复数。
+// 与已应用修改重叠的修复整体跳过，完全相同的修改（如重复添加的导入）只应用一次。
+func ApplyFixes(issues []Issue) (int, error) {
+	fixes := make([]*Fix, 0, len(issues))
+	for _, issue := range issues {
+		if issue.Fix != nil && len(issue.Fix.Edits) > 0 {
+			fixes = append(fixes, issue.Fix)
+		}
+	}
+	// 按位置顺序选取，保证结果确定
+	sort.SliceStable(fixes, func(i, j int) bool {
+		a, b := fixes[i].Edits[0], fixes[j].Edits[0]
+		if a.File != b.File {
+			return a.File < b.File
+		}
+		return a.Offset < b.Offset
+	})
+
+	accepted := make(map[string][]Edit)
+	applied := 0
+	for _, fix := range fixes {
+		var pending []Edit
+		conflict := false
+		for _, edit := range fix.Edits {
+			dup, overlap := checkEdit(accepted[edit.File], edit)
+			if overlap {
+				conflict = true
+				break
+			}
+			if !dup {
+				pending = append(pending, edit)
+			}
+		}
+		if conflict {
+			continue
+		}
+		for _, edit := range pending {
+			accepted[edit.File] = append(accepted[edit.File], edit)
+		}
+		applied++
+	}
+
+	for file, edits := range accepted {
+		if err := applyEdits(file, edits); err != nil {
+			return applied, err
+		}
+	}
+	return applied, nil
+}
+
+// checkEdit 判断修改是否与已接受的修改相同或重叠。同一位置的插入不视为重叠。
+func checkEdit(accepted []Edit, edit Edit) (duplicate, overlap bool) {
+	for _, other := range accepted {
+		if other == edit {
+			return true, false
+		}
+		if edit.Offset < other.EndOffset && other.Offset < edit.EndOffset {
+			return false, true
+		}
+		if edit.Offset == other.Offset && (edit.Offset != edit.EndOffset || other.Offset != other.EndOffset) {
+			return false, true
+		}
+	}
+	return false, false
+}
+
+// applyEdits 按偏移从后往前应用修改并格式化文件
+func applyEdits(file string, edits []Edit) error {
+	src, err := os.ReadFile(file)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", file, err)
+	}
+	sort.SliceStable(edits, func(i, j int) bool {
+		return edits[i].Offset > edits[j].Offset
+	})
+	for _, edit := range edits {
+		if edit.Offset < 0 || edit.EndOffset > len(src) || edit.Offset > edit.EndOffset {
+			return fmt.Errorf("invalid edit %d-%d in %s", edit.Offset, edit.EndOffset, file)
+		}
+		src = append(src[:edit.Offset], append([]byte(edit.NewText), src[edit.EndOffset:]...)...)
+	}
+
+	if formatted, err := format.Source(src); err == nil {
+		src = formatted
+	}
+	info, err := os.Stat(file)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(file, src, info.Mode())
+}
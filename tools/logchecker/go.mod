@@ -1,3 +1,10 @@
 module logchecker
 
-go 1.21
\ No newline at end of file
+go 1.23.0
+
+require golang.org/x/tools v0.31.0
+
+require (
+	golang.org/x/mod v0.24.0 // indirect
+	golang.org/x/sync v0.12.0 // indirect
+)
@@ -10,8 +10,10 @@ func main() {
 	var (
 		dir    = flag.String("dir", ".", "Directory to scan for Go files")
 		config = flag.String("config", "", "Path to configuration file")
-		output = flag.String("output", "console", "Output format: console, json, html")
+		output = flag.String("output", "console", "Output format: console (text), json, html, sarif")
 		verbose = flag.Bool("verbose", false, "Enable verbose output")
+		fix     = flag.Bool("fix", false, "Apply suggested fixes to source files")
+		srcRoot = flag.String("src-root", "", "Make file paths in SARIF reports relative to this directory")
 	)
 	flag.Parse()
 
@@ -34,11 +36,22 @@ func main() {
 
 	// 生成报告
 	reporter := NewReporter(*output)
+	reporter.SetSourceRoot(*srcRoot)
 	if err := reporter.GenerateReport(results); err != nil {
 		fmt.Fprintf(os.Stderr, "Error generating report: %v\n", err)
 		os.Exit(1)
 	}
 
+	// 应用自动修复，报告仍为修复前的问题
+	if *fix {
+		applied, err := ApplyFixes(results.Issues)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error applying fixes: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Fprintf(os.Stderr, "Applied %d fixes\n", applied)
+	}
+
 	// 如果发现问题，返回非零退出码
 	if len(results.Issues) > 0 {
 		os.Exit(1)
@@ -8,11 +8,14 @@ import (
 	"sort"
 	"strings"
 	"time"
+
+	"logchecker/analyzer"
 )
 
 // Reporter 报告生成器
 type Reporter struct {
-	format string
+	format  string
+	srcRoot string
 }
 
 // NewReporter 创建新的报告生成器
@@ -22,6 +25,11 @@ func NewReporter(format string) *Reporter {
 	}
 }
 
+// SetSourceRoot 设置 SARIF 报告中文件路径的基准目录
+func (r *Reporter) SetSourceRoot(dir string) {
+	r.srcRoot = dir
+}
+
 // GenerateReport 生成报告
 func (r *Reporter) GenerateReport(result *ScanResult) error {
 	switch r.format {
@@ -29,8 +37,10 @@ func (r *Reporter) GenerateReport(result *ScanResult) error {
 		return r.generateJSONReport(result)
 	case "html":
 		return r.generateHTMLReport(result)
-	case "console":
+	case "console", "text":
 		return r.generateConsoleReport(result)
+	case "sarif":
+		return r.generateSARIFReport(result)
 	default:
 		return fmt.Errorf("unsupported output format: %s", r.format)
 	}
@@ -78,7 +88,8 @@ func (r *Reporter) generateConsoleReport(result *ScanResult) error {
 	fmt.Printf("1. 使用结构化日志方法（如 Infow, Debugw, Errorw, Warnw）替代格式化日志方法\n")
 	fmt.Printf("2. 将格式化参数转换为键值对形式，便于日志分析和脱敏\n")
 	fmt.Printf("3. 对于包含敏感信息的日志，确保使用结构化日志以启用自动脱敏\n")
-	fmt.Printf("4. 参考项目中的 SafeLogger 使用指南: docs/structured-logging-guide.md\n")
+	fmt.Printf("4. 敏感字段或实现 MakeSensitive 的对象写入日志前使用 sensitive.NewLogSafeValue 包装\n")
+	fmt.Printf("5. 参考项目中的 SafeLogger 使用指南: docs/structured-logging-guide.md\n")
 
 	return nil
 }
@@ -88,7 +99,14 @@ func (r *Reporter) printIssues(issues []Issue) {
 	for _, issue := range issues {
 		fmt.Printf("  📁 %s:%d:%d\n", issue.File, issue.Line, issue.Column)
 		fmt.Printf("     问题: %s\n", issue.Description)
-		fmt.Printf("     建议: 使用 %s 替代 %s\n", issue.Suggestion, issue.Method)
+		if issue.Rule == analyzer.RuleSensitiveTaint {
+			fmt.Printf("     建议: 使用 %s 包装 %s 的参数\n", issue.Suggestion, issue.Method)
+		} else {
+			fmt.Printf("     建议: 使用 %s 替代 %s\n", issue.Suggestion, issue.Method)
+		}
+		if issue.Fix != nil {
+			fmt.Printf("     修复: %s（-fix 自动应用）\n", issue.Fix.Description)
+		}
 		fmt.Println()
 	}
 }
@@ -163,6 +181,7 @@ func (r *Reporter) generateHTMLReport(result *ScanResult) error {
             <li>使用结构化日志方法（如 Infow, Debugw, Errorw, Warnw）替代格式化日志方法</li>
             <li>将格式化参数转换为键值对形式，便于日志分析和脱敏</li>
             <li>对于包含敏感信息的日志，确保使用结构化日志以启用自动脱敏</li>
+            <li>敏感字段或实现 MakeSensitive 的对象写入日志前使用 sensitive.NewLogSafeValue 包装</li>
             <li>参考项目中的 SafeLogger 使用指南</li>
         </ol>
     </div>
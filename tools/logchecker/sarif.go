@@ -0,0 +1,198 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+
+	"logchecker/analyzer"
+)
+
+// SARIF 2.1.0 报告，供 GitHub code scanning 等平台导入
+const (
+	sarifVersion = "2.1.0"
+	sarifSchema  = "https://json.schemastore.org/sarif-2.1.0.json"
+)
+
+// sarifRules 规则说明
+var sarifRules = []sarifRule{
+	{
+		ID:               analyzer.RuleStructuredLog,
+		Name:             "StructuredLog",
+		ShortDescription: sarifMessage{Text: "格式化日志方法应改用结构化日志方法"},
+		FullDescription:  sarifMessage{Text: analyzer.StructuredLog.Doc},
+	},
+	{
+		ID:               analyzer.RuleSensitiveTaint,
+		Name:             "SensitiveTaint",
+		ShortDescription: sarifMessage{Text: "敏感字段或 MakeSensitive 对象写入日志"},
+		FullDescription:  sarifMessage{Text: analyzer.SensitiveTaint.Doc},
+	},
+}
+
+type sarifLog struct {
+	Version string     `json:"version"`
+	Schema  string     `json:"$schema"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool    sarifTool     `json:"tool"`
+	Results []sarifResult `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name  string      `json:"name"`
+	Rules []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID               string       `json:"id"`
+	Name             string       `json:"name"`
+	ShortDescription sarifMessage `json:"shortDescription"`
+	FullDescription  sarifMessage `json:"fullDescription"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifResult struct {
+	RuleID    string          `json:"ruleId"`
+	Level     string          `json:"level"`
+	Message   sarifMessage    `json:"message"`
+	Locations []sarifLocation `json:"locations"`
+	Fixes     []sarifFix      `json:"fixes,omitempty"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation sarifPhysicalLocation `json:"physicalLocation"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+	Region           sarifRegion           `json:"region"`
+}
+
+type sarifArtifactLocation struct {
+	URI string `json:"uri"`
+}
+
+type sarifRegion struct {
+	StartLine   int `json:"startLine"`
+	StartColumn int `json:"startColumn"`
+	EndLine     int `json:"endLine,omitempty"`
+	EndColumn   int `json:"endColumn,omitempty"`
+}
+
+type sarifFix struct {
+	Description     sarifMessage          `json:"description"`
+	ArtifactChanges []sarifArtifactChange `json:"artifactChanges"`
+}
+
+type sarifArtifactChange struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+	Replacements     []sarifReplacement    `json:"replacements"`
+}
+
+type sarifReplacement struct {
+	DeletedRegion   sarifRegion  `json:"deletedRegion"`
+	InsertedContent sarifMessage `json:"insertedContent"`
+}
+
+// generateSARIFReport 生成SARIF报告
+func (r *Reporter) generateSARIFReport(result *ScanResult) error {
+	results := make([]sarifResult, 0, len(result.Issues))
+	for _, issue := range result.Issues {
+		res := sarifResult{
+			RuleID:  issue.Rule,
+			Level:   sarifLevel(issue.Severity),
+			Message: sarifMessage{Text: issue.Description},
+			Locations: []sarifLocation{{
+				PhysicalLocation: sarifPhysicalLocation{
+					ArtifactLocation: sarifArtifactLocation{URI: r.sarifURI(issue.File)},
+					Region: sarifRegion{
+						StartLine:   issue.Line,
+						StartColumn: issue.Column,
+						EndLine:     issue.EndLine,
+						EndColumn:   issue.EndColumn,
+					},
+				},
+			}},
+		}
+		if issue.Fix != nil {
+			res.Fixes = []sarifFix{r.sarifFix(issue.Fix)}
+		}
+		results = append(results, res)
+	}
+
+	log := sarifLog{
+		Version: sarifVersion,
+		Schema:  sarifSchema,
+		Runs: []sarifRun{{
+			Tool:    sarifTool{Driver: sarifDriver{Name: "logchecker", Rules: sarifRules}},
+			Results: results,
+		}},
+	}
+
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(log)
+}
+
+// sarifFix 按文件分组修复中的修改
+func (r *Reporter) sarifFix(fix *Fix) sarifFix {
+	result := sarifFix{Description: sarifMessage{Text: fix.Description}}
+	index := make(map[string]int)
+	for _, edit := range fix.Edits {
+		uri := r.sarifURI(edit.File)
+		i, ok := index[uri]
+		if !ok {
+			i = len(result.ArtifactChanges)
+			index[uri] = i
+			result.ArtifactChanges = append(result.ArtifactChanges, sarifArtifactChange{
+				ArtifactLocation: sarifArtifactLocation{URI: uri},
+			})
+		}
+		result.ArtifactChanges[i].Replacements = append(result.ArtifactChanges[i].Replacements, sarifReplacement{
+			DeletedRegion: sarifRegion{
+				StartLine:   edit.StartLine,
+				StartColumn: edit.StartColumn,
+				EndLine:     edit.EndLine,
+				EndColumn:   edit.EndColumn,
+			},
+			InsertedContent: sarifMessage{Text: edit.NewText},
+		})
+	}
+	return result
+}
+
+// sarifURI 文件路径转换为相对源码根目录的 URI
+func (r *Reporter) sarifURI(file string) string {
+	if r.srcRoot != "" {
+		if abs, err := filepath.Abs(file); err == nil {
+			if root, err := filepath.Abs(r.srcRoot); err == nil {
+				if rel, err := filepath.Rel(root, abs); err == nil {
+					file = rel
+				}
+			}
+		}
+	}
+	return filepath.ToSlash(filepath.Clean(file))
+}
+
+// sarifLevel 严重程度对应的 SARIF 级别
+func sarifLevel(severity string) string {
+	switch severity {
+	case "high":
+		return "error"
+	case "medium":
+		return "warning"
+	default:
+		return "note"
+	}
+}
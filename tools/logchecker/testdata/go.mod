@@ -0,0 +1,7 @@
+module logchecker.test
+
+go 1.21
+
+require github.com/go-kratos/kratos/v2 v2.0.0
+
+replace github.com/go-kratos/kratos/v2 => ./kratos
@@ -0,0 +1,3 @@
+module github.com/go-kratos/kratos/v2
+
+go 1.21
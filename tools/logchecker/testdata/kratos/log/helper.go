@@ -0,0 +1,18 @@
+// Package log 仅保留 kratos log.Helper 的方法签名，供测试数据编译
+package log
+
+// Helper is a logger helper.
+type Helper struct{}
+
+func (h *Helper) Debug(a ...interface{})                 {}
+func (h *Helper) Debugf(format string, a ...interface{}) {}
+func (h *Helper) Debugw(keyvals ...interface{})          {}
+func (h *Helper) Info(a ...interface{})                  {}
+func (h *Helper) Infof(format string, a ...interface{})  {}
+func (h *Helper) Infow(keyvals ...interface{})           {}
+func (h *Helper) Warn(a ...interface{})                  {}
+func (h *Helper) Warnf(format string, a ...interface{})  {}
+func (h *Helper) Warnw(keyvals ...interface{})           {}
+func (h *Helper) Error(a ...interface{})                 {}
+func (h *Helper) Errorf(format string, a ...interface{}) {}
+func (h *Helper) Errorw(keyvals ...interface{})          {}
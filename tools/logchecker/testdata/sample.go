@@ -12,21 +12,21 @@ type Service struct {
 // 应该被检测的问题代码
 func (s *Service) BadExamples() {
 	// 格式化日志方法 - 应该被检测
-	s.log.Infof("User %s logged in", "john")
-	s.log.Debugf("Processing request %d", 123)
-	s.log.Errorf("Failed to connect to database: %v", fmt.Errorf("connection timeout"))
-	s.log.Warnf("High memory usage: %d%%", 85)
+	s.log.Infof("User %s logged in", "john") // want "'Infof'"
+	s.log.Debugf("Processing request %d", 123) // want "'Debugf'"
+	s.log.Errorf("Failed to connect to database: %v", fmt.Errorf("connection timeout")) // want "'Errorf'"
+	s.log.Warnf("High memory usage: %d%%", 85) // want "'Warnf'"
 	
 	// 包含敏感信息 - 应该被检测
-	s.log.Info("User password updated successfully")
-	s.log.Debug("JWT token generated for user")
-	s.log.Error("Authentication failed for email user@example.com")
+	s.log.Info("User password updated successfully") // want "'Info'"
+	s.log.Debug("JWT token generated for user") // want "'Debug'"
+	s.log.Error("Authentication failed for email user@example.com") // want "'Error'"
 	
 	// 长消息 - 应该被检测
-	s.log.Info("This is a very long log message that exceeds the minimum length threshold and should be detected by the log checker tool")
+	s.log.Info("This is a very long log message that exceeds the minimum length threshold and should be detected by the log checker tool") // want "'Info'"
 	
 	// 多参数调用 - 应该被检测
-	s.log.Info("Operation completed", "duration", "5s", "status", "success")
+	s.log.Info("Operation completed", "duration", "5s", "status", "success") // want "'Info'"
 }
 
 // 正确的结构化日志使用
@@ -0,0 +1,26 @@
+package biz
+
+import "github.com/go-kratos/kratos/v2/log"
+
+// User 用户模型
+type User struct { // want User:"sensitiveFields\\(email,phone,totp_secret\\)"
+	ID         int64
+	Username   string
+	Email      string
+	Phone      string
+	TotpSecret string
+}
+
+// GetSensitiveFields 获取敏感字段列表
+func (u *User) GetSensitiveFields() []string {
+	return []string{"email", "phone", "totp_secret"}
+}
+
+// Anonymize 脱敏处理
+func (u *User) Anonymize() interface{} {
+	return map[string]interface{}{"id": u.ID}
+}
+
+func (u *User) logPhone(l *log.Helper) {
+	l.Infof("phone %s", u.Phone) // want "User.Phone"
+}
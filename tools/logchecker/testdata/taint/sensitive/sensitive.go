@@ -0,0 +1,17 @@
+package sensitive
+
+// MakeSensitive 敏感信息脱敏接口
+type MakeSensitive interface {
+	GetSensitiveFields() []string
+	Anonymize() interface{}
+}
+
+// LogSafeValue 日志安全值
+type LogSafeValue struct {
+	value interface{}
+}
+
+// NewLogSafeValue 创建日志安全值
+func NewLogSafeValue(value interface{}) *LogSafeValue {
+	return &LogSafeValue{value: value}
+}
@@ -0,0 +1,36 @@
+package service
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/go-kratos/kratos/v2/log"
+	"logchecker.test/taint/biz"
+	"logchecker.test/taint/sensitive"
+)
+
+func Login(l *log.Helper, u *biz.User) {
+	l.Infof("login %s", u.Email) // want "User.Email"
+	l.Infof("user %v", u)        // want "User 实现了 sensitive.MakeSensitive"
+
+	email := strings.ToLower(u.Email)
+	msg := fmt.Sprintf("user %s", email)
+	l.Info(msg) // want "User.Email"
+	go func() {
+		l.Errorw("login", "detail", msg) // want "User.Email"
+	}()
+
+	var secrets []string
+	secrets = append(secrets, u.TotpSecret)
+	for _, s := range secrets {
+		l.Info(s) // want "User.TotpSecret"
+	}
+
+	// 以下不应报告
+	l.Infof("user %d %s", u.ID, u.Username)
+	l.Infof("phone length %d", len(u.Phone))
+	l.Info(strings.Contains(u.Email, "@"))
+	l.Infof("login %v", sensitive.NewLogSafeValue(u.Email))
+	l.Errorw("login", "user", u.Anonymize())
+	fmt.Println(u.Email)
+}